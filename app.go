@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -11,9 +12,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	goruntime "runtime"
 	"strings"
+	"sync"
 	"time"
 
+	"wepoc/internal/auth"
+	"wepoc/internal/i18n"
 	"wepoc/internal/config"
 	"wepoc/internal/database"
 	"wepoc/internal/models"
@@ -26,10 +31,16 @@ import (
 type App struct {
 	ctx context.Context
 	db  *database.Database
-	taskManager *scanner.TaskManager
 	jsonTaskManager *scanner.JSONTaskManager
 	config *models.Config
 	templateParser *scanner.TemplateParser
+	wordlistManager *scanner.WordlistManager
+	lockMu sync.Mutex
+	locked bool // 启动时等待输入口令，工作区尚未打开
+	users     *auth.UserStore // 多用户模式的账号
+	session   *auth.UserInfo  // 当前登录用户（整个应用实例共用一个会话，见Login）
+	sessionMu sync.RWMutex
+	configWatcher *config.ConfigWatcher // 监视外部对config.json的修改
 }
 
 // NewApp creates a new App application struct
@@ -41,6 +52,9 @@ func NewApp() *App {
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	// 配置和数据库的后台消息同样按日志级别过滤并写入日志
+	config.SetLogHandler(scanner.ConsoleLog)
+	database.SetLogHandler(scanner.ConsoleLog)
 
 	// Load configuration
 	cfg, err := config.LoadConfig()
@@ -50,6 +64,37 @@ func (a *App) startup(ctx context.Context) {
 	}
 	a.config = cfg
 
+	i18n.SetLanguage(cfg.Language)
+
+	// User accounts of multi-user mode
+	if wepocDir, err := config.GetWepocDir(); err == nil {
+		a.users = auth.NewUserStore(wepocDir)
+	}
+
+	// Wait for the passphrase before opening the workspace
+	if cfg.Security.LockOnStartup {
+		if status, err := config.GetSecretStoreStatus(); err == nil && status.Locked {
+			a.locked = true
+			runtime.LogInfo(ctx, "应用已加锁，等待输入口令")
+			return
+		}
+	}
+	a.initialize(ctx, cfg)
+}
+
+// initialize opens the workspace: database, task managers and background jobs
+func (a *App) initialize(ctx context.Context, cfg *models.Config) {
+	// Decrypt the results and logs directories sealed at the last shutdown
+	if cfg.Security.EncryptResults {
+		if paths, err := config.SealedDirPaths(); err == nil {
+			for dir, sealedPath := range paths {
+				if err := config.UnsealDirectory(sealedPath, dir); err != nil {
+					runtime.LogErrorf(ctx, "Failed to decrypt %s: %v", dir, err)
+				}
+			}
+		}
+	}
+
 	// Validate and fix nuclei path if needed
 	if err := config.ValidateNucleiPath(cfg); err != nil {
 		runtime.LogErrorf(ctx, "Nuclei path validation failed: %v", err)
@@ -69,39 +114,136 @@ func (a *App) startup(ctx context.Context) {
 		return
 	}
 	a.db = db
-
-	// Initialize task manager (legacy database-based)
-	a.taskManager = scanner.NewTaskManager(db, cfg.MaxConcurrency, cfg)
+	// 结果加密开启前写入的加密文档同样可读（加密由applyResultEncryption开启）
+	db.SetDocumentCipher(nil, config.DecryptSecret)
 
 	// Initialize JSON task manager (new lightweight approach)
-	jsonTaskManager, err := scanner.NewJSONTaskManager(cfg)
+	jsonTaskManager, err := scanner.NewJSONTaskManager(cfg, db)
 	if err != nil {
 		runtime.LogErrorf(ctx, "Failed to initialize JSON task manager: %v", err)
 		return
 	}
 	a.jsonTaskManager = jsonTaskManager
+	jsonTaskManager.SetSecretCipher(config.EncryptSecret, config.DecryptSecret)
+	a.sealTaskSecrets()
+	a.applyResultEncryption(cfg.Security)
+	scanner.SetExportSigningKey(config.ExportSigningKey)
+	scanner.SetAuditChainKey(config.AuditChainKey)
+	if user := a.sessionUser(); user != nil {
+		jsonTaskManager.SetAuditUser(user.Username)
+	}
+
+	// Forward scan events of tasks without a dedicated handler (e.g. after a frontend reload)
+	jsonTaskManager.SetDefaultEventHandler(func(event *scanner.ScanEvent) {
+		runtime.EventsEmit(a.ctx, "scan-event", event)
+	})
+
+	// Forward raw nuclei output of tasks with log streaming turned on
+	jsonTaskManager.SetLogLineHandler(func(line *scanner.TaskLogLine) {
+		runtime.EventsEmit(a.ctx, "task-log-line", line)
+	})
+
+	// Remove temp directories left behind by crashed scans and apply the retention policy
+	go func() {
+		if report, err := jsonTaskManager.CleanupTempDirs(); err != nil {
+			runtime.LogWarningf(ctx, "Failed to clean up temp directories: %v", err)
+		} else if report.RemovedDirs > 0 {
+			runtime.LogInfof(ctx, "Cleaned up %d temp directories, reclaimed %s", report.RemovedDirs, report.Reclaimed)
+		}
+		if _, err := jsonTaskManager.ApplyRetention(); err != nil {
+			runtime.LogWarningf(ctx, "Failed to apply retention policy: %v", err)
+		}
+	}()
+
+	// Pause and resume tasks according to their scan windows
+	jsonTaskManager.StartWindowScheduler()
+
+	// Forward OOB interactions to frontend
+	jsonTaskManager.SetOOBHandler(func(interaction *scanner.OOBInteraction) {
+		runtime.EventsEmit(a.ctx, "oob-interaction", interaction)
+	})
+
+	// Notify frontend of follow-up tasks created from findings
+	jsonTaskManager.SetFollowUpHandler(func(task *scanner.TaskConfig) {
+		runtime.LogInfo(a.ctx, fmt.Sprintf("已根据规则 %s 创建跟进任务 %d", task.FollowUpRule, task.ID))
+		runtime.EventsEmit(a.ctx, "follow-up-task", task)
+	})
 
 	// Initialize template parser
 	a.templateParser = scanner.NewTemplateParser()
 
-	// Start event listener for task updates (legacy)
-	go a.listenForTaskEvents()
+	// Initialize wordlist manager
+	wordlistManager, err := scanner.NewWordlistManager()
+	if err != nil {
+		runtime.LogErrorf(ctx, "Failed to initialize wordlist manager: %v", err)
+	}
+	a.wordlistManager = wordlistManager
+
+	// Hot-reload config.json when it is edited outside the application
+	if a.configWatcher == nil {
+		watcher, err := config.WatchConfig(a.onConfigFileChanged, func(err error) {
+			runtime.LogWarningf(a.ctx, "config.json 已被修改，但无法加载: %v", err)
+		})
+		if err != nil {
+			runtime.LogWarningf(ctx, "Failed to watch config file: %v", err)
+		}
+		a.configWatcher = watcher
+	}
 
 	runtime.LogInfo(ctx, "Application started successfully")
 }
 
 // shutdown is called at application termination
 func (a *App) shutdown(ctx context.Context) {
+	if a.configWatcher != nil {
+		a.configWatcher.Stop()
+	}
+	if a.jsonTaskManager != nil {
+		// 停止仍在运行的扫描并保存已有结果，任务标记为interrupted
+		if result := a.jsonTaskManager.ShutdownTasks(); len(result.StoppedTasks) > 0 {
+			runtime.LogInfo(ctx, fmt.Sprintf("退出时中断了 %d 个任务: %v", len(result.StoppedTasks), result.StoppedTasks))
+		}
+		a.jsonTaskManager.CloseOOBSessions()
+		a.jsonTaskManager.CloseForwarding()
+	}
 	if a.db != nil {
 		a.db.Close()
 	}
+
+	// Encrypt the results and logs directories until the next unlock
+	if a.config != nil && a.config.Security.EncryptResults && !a.locked {
+		if paths, err := config.SealedDirPaths(); err == nil {
+			for dir, sealedPath := range paths {
+				if _, err := config.SealDirectory(dir, sealedPath); err != nil {
+					runtime.LogErrorf(ctx, "Failed to encrypt %s: %v", dir, err)
+				}
+			}
+		}
+	}
 }
 
-// listenForTaskEvents listens for task events and emits them to frontend
-func (a *App) listenForTaskEvents() {
-	for event := range a.taskManager.GetEventChannel() {
-		runtime.EventsEmit(a.ctx, "task-event", event)
+// beforeClose asks for confirmation when closing the window would interrupt running scans;
+// returning true keeps the application open
+func (a *App) beforeClose(ctx context.Context) bool {
+	if a.jsonTaskManager == nil {
+		return false
+	}
+	running := a.jsonTaskManager.RunningTaskIDs()
+	if len(running) == 0 {
+		return false
+	}
+	selection, err := runtime.MessageDialog(ctx, runtime.MessageDialogOptions{
+		Type:          runtime.QuestionDialog,
+		Title:         "确认退出",
+		Message:       fmt.Sprintf("%d 个任务正在扫描。退出将停止这些扫描、保存已有结果并将任务标记为中断。\n\n确认退出？", len(running)),
+		Buttons:       []string{"退出", "取消"},
+		DefaultButton: "取消",
+		CancelButton:  "取消",
+	})
+	if err != nil {
+		return false
 	}
+	return selection != "退出" && selection != "Yes"
 }
 
 // ============ Configuration Methods ============
@@ -113,33 +255,384 @@ func (a *App) GetConfig() *models.Config {
 		cfg, _ := config.GetDefaultConfig()
 		return cfg
 	}
+	// 多用户模式下只有管理员可以看到配置中的密钥
+	if a.users != nil && a.users.Enabled() {
+		if user := a.sessionUser(); user == nil || !user.Role.Can(auth.PermManageConfig) {
+			return config.RedactedConfig(a.config)
+		}
+	}
 	return a.config
 }
 
 // SaveConfig saves the configuration
 func (a *App) SaveConfig(cfg *models.Config) error {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return err
+	}
+	if err := validateSecurityConfig(cfg.Security); err != nil {
+		return err
+	}
+	if err := scanner.ValidateTemplateRoots(cfg.TemplateRoots); err != nil {
+		return err
+	}
 	if err := config.SaveConfig(cfg); err != nil {
 		return err
 	}
+	changed := config.ChangedFields(a.config, cfg)
 	a.config = cfg
+	i18n.SetLanguage(cfg.Language)
+	a.audit(scanner.AuditConfigSaved, 0, strings.Join(changed, ", "))
 	
-	// Update task managers with new configuration
-	if a.taskManager != nil {
-		a.taskManager.UpdateConfig(cfg)
-	}
+	// Update task manager with new configuration
 	if a.jsonTaskManager != nil {
 		a.jsonTaskManager.UpdateConfig(cfg)
 	}
+	a.applyResultEncryption(cfg.Security)
 	
 	return nil
 }
 
+// AppLockStatus reports whether the workspace waits for the passphrase
+type AppLockStatus struct {
+	Locked         bool `json:"locked"`          // 等待输入口令，工作区尚未打开
+	Protected      bool `json:"protected"`       // 配置密钥受口令保护
+	LockOnStartup  bool `json:"lock_on_startup"`
+	EncryptResults bool `json:"encrypt_results"`
+}
+
+// GetAppLockStatus returns the state of the startup passphrase gate
+func (a *App) GetAppLockStatus() (*AppLockStatus, error) {
+	status, err := config.GetSecretStoreStatus()
+	if err != nil {
+		return nil, err
+	}
+	a.lockMu.Lock()
+	defer a.lockMu.Unlock()
+	result := &AppLockStatus{Locked: a.locked, Protected: status.Protected}
+	if a.config != nil {
+		result.LockOnStartup = a.config.Security.LockOnStartup
+		result.EncryptResults = a.config.Security.EncryptResults
+	}
+	return result, nil
+}
+
+// UnlockApp checks the passphrase at the startup gate, decrypts the secrets and opens the
+// workspace
+func (a *App) UnlockApp(passphrase string) error {
+	a.lockMu.Lock()
+	defer a.lockMu.Unlock()
+	if !a.locked {
+		return nil
+	}
+	if err := config.UnlockSecrets(passphrase); err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	a.config = cfg
+	i18n.SetLanguage(cfg.Language)
+	a.locked = false
+
+	runtime.LogInfo(a.ctx, "应用已解锁")
+	a.initialize(a.ctx, cfg)
+	runtime.EventsEmit(a.ctx, "app-unlocked")
+	return nil
+}
+
+// CurrentUser describes the session for the frontend
+type CurrentUser struct {
+	MultiUser   bool              `json:"multi_user"` // 是否已启用多用户模式
+	User        *auth.UserInfo    `json:"user,omitempty"`
+	Permissions []auth.Permission `json:"permissions"` // 当前可执行的操作，单用户模式下为全部
+}
+
+// GetCurrentUser returns the logged-in user and their permissions
+func (a *App) GetCurrentUser() *CurrentUser {
+	current := &CurrentUser{MultiUser: a.users != nil && a.users.Enabled(), Permissions: []auth.Permission{}}
+	if !current.MultiUser {
+		current.Permissions = auth.RoleAdmin.Permissions()
+		return current
+	}
+	if user := a.sessionUser(); user != nil {
+		current.User = user
+		current.Permissions = user.Role.Permissions()
+	}
+	return current
+}
+
+// Login starts a session in multi-user mode. There is one session per app instance: the
+// bindings cannot tell their callers apart, so every window (or browser attached to the dev
+// server) of the instance acts as the logged-in user until Logout.
+func (a *App) Login(username string, password string) (*CurrentUser, error) {
+	if a.users == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	user, err := a.users.Authenticate(username, password)
+	if err != nil {
+		runtime.LogWarning(a.ctx, fmt.Sprintf("用户 %s 登录失败", username))
+		return nil, err
+	}
+	a.setSession(user)
+	a.audit(scanner.AuditLogin, 0, string(user.Role))
+	runtime.LogInfo(a.ctx, fmt.Sprintf("用户 %s 已登录（%s）", user.Username, user.Role))
+	return a.GetCurrentUser(), nil
+}
+
+// Logout ends the current session
+func (a *App) Logout() {
+	if user := a.sessionUser(); user != nil {
+		a.audit(scanner.AuditLogout, 0, "")
+		runtime.LogInfo(a.ctx, fmt.Sprintf("用户 %s 已退出", user.Username))
+	}
+	a.setSession(nil)
+}
+
+// ListUsers returns the user accounts
+func (a *App) ListUsers() ([]*auth.UserInfo, error) {
+	if err := a.authorize(auth.PermManageUsers); err != nil {
+		return nil, err
+	}
+	return a.users.List()
+}
+
+// CreateUser adds a user account. The first account turns multi-user mode on, must be an
+// admin and is logged in right away; later accounts are created by admins.
+func (a *App) CreateUser(username string, password string, role auth.Role) (*auth.UserInfo, error) {
+	if a.users == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	bootstrap := !a.users.Enabled()
+	if !bootstrap {
+		if err := a.authorize(auth.PermManageUsers); err != nil {
+			return nil, err
+		}
+	}
+	user, err := a.users.Create(username, password, role)
+	if err != nil {
+		return nil, err
+	}
+	if bootstrap {
+		a.setSession(user)
+		runtime.LogInfo(a.ctx, "已启用多用户模式")
+	}
+	a.audit(scanner.AuditUserChanged, 0, fmt.Sprintf("created %s (%s)", user.Username, user.Role))
+	return user, nil
+}
+
+// UpdateUser changes the role of an account or disables it
+func (a *App) UpdateUser(username string, role auth.Role, disabled bool) (*auth.UserInfo, error) {
+	if err := a.authorize(auth.PermManageUsers); err != nil {
+		return nil, err
+	}
+	user, err := a.users.Update(username, role, disabled)
+	if err != nil {
+		return nil, err
+	}
+	a.audit(scanner.AuditUserChanged, 0, fmt.Sprintf("updated %s (%s, disabled=%t)", user.Username, user.Role, user.Disabled))
+	return user, nil
+}
+
+// SetUserPassword changes the password of an account; users may change their own password,
+// admins any password
+func (a *App) SetUserPassword(username string, password string) error {
+	if a.users == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	if current := a.sessionUser(); current == nil || !strings.EqualFold(current.Username, username) {
+		if err := a.authorize(auth.PermManageUsers); err != nil {
+			return err
+		}
+	}
+	if err := a.users.SetPassword(username, password); err != nil {
+		return err
+	}
+	a.audit(scanner.AuditUserChanged, 0, fmt.Sprintf("password of %s changed", username))
+	return nil
+}
+
+// DeleteUser removes an account
+func (a *App) DeleteUser(username string) error {
+	if err := a.authorize(auth.PermManageUsers); err != nil {
+		return err
+	}
+	if err := a.users.Delete(username); err != nil {
+		return err
+	}
+	a.audit(scanner.AuditUserChanged, 0, fmt.Sprintf("deleted %s", username))
+	if current := a.sessionUser(); current != nil && strings.EqualFold(current.Username, username) {
+		a.setSession(nil)
+	}
+	return nil
+}
+
+// authorize checks that the current user may perform an action. Everything is allowed in
+// single-user mode; in multi-user mode the role is re-read so that changes apply at once.
+func (a *App) authorize(permission auth.Permission) error {
+	a.lockMu.Lock()
+	locked := a.locked
+	a.lockMu.Unlock()
+	if locked {
+		return i18n.Errorf(i18n.ErrAppLocked)
+	}
+	if a.users == nil || !a.users.Enabled() {
+		return nil
+	}
+
+	session := a.sessionUser()
+	if session == nil {
+		return i18n.Errorf(i18n.ErrAuthRequired)
+	}
+	user, err := a.users.Get(session.Username)
+	if err != nil || user.Disabled {
+		a.setSession(nil)
+		return i18n.Errorf(i18n.ErrAuthRequired)
+	}
+	if !user.Role.Can(permission) {
+		a.audit(scanner.AuditPermissionDenied, 0, string(permission))
+		return i18n.Errorf(i18n.ErrPermissionDenied, user.Username, user.Role)
+	}
+	return nil
+}
+
+// sessionUser returns the logged-in user, nil without a session
+func (a *App) sessionUser() *auth.UserInfo {
+	a.sessionMu.RLock()
+	defer a.sessionMu.RUnlock()
+	return a.session
+}
+
+// setSession starts or (with nil) ends the session and attributes audit entries to it
+func (a *App) setSession(user *auth.UserInfo) {
+	a.sessionMu.Lock()
+	a.session = user
+	a.sessionMu.Unlock()
+
+	if a.jsonTaskManager != nil {
+		if user != nil {
+			a.jsonTaskManager.SetAuditUser(user.Username)
+		} else {
+			a.jsonTaskManager.SetAuditUser("")
+		}
+	}
+}
+
+// audit records an action in the audit log
+func (a *App) audit(action string, taskID int64, detail string) {
+	if a.jsonTaskManager != nil {
+		a.jsonTaskManager.Audit(action, taskID, detail)
+	}
+}
+
+// applyResultEncryption encrypts the scan data in the database (results, HTTP request logs,
+// findings, progress) and the evidence files as they are written when results encryption is
+// on, and encrypts what was stored in the clear before. Data encrypted earlier stays readable
+// after the option is turned off.
+func (a *App) applyResultEncryption(security models.SecurityConfig) {
+	if a.db == nil || a.jsonTaskManager == nil {
+		return
+	}
+	if !security.EncryptResults {
+		a.db.SetDocumentCipher(nil, config.DecryptSecret)
+		a.jsonTaskManager.SetFileCipher(nil, nil, config.ReadSealedFile)
+		a.jsonTaskManager.SetRecordCipher(nil, config.DecryptSecret)
+		return
+	}
+	a.db.SetDocumentCipher(config.EncryptSecret, config.DecryptSecret)
+	a.jsonTaskManager.SetFileCipher(config.SealFile, config.WriteSealedFile, config.ReadSealedFile)
+	a.jsonTaskManager.SetRecordCipher(config.EncryptSecret, config.DecryptSecret)
+	if count, err := a.db.SealDocuments(); err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to encrypt stored results: %v", err)
+	} else if count > 0 {
+		runtime.LogInfo(a.ctx, fmt.Sprintf("已加密数据库中的 %d 条结果和日志", count))
+	}
+	if err := a.jsonTaskManager.SealEvidenceFiles(); err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to encrypt evidence files: %v", err)
+	}
+}
+
+// validateSecurityConfig checks that the workspace protection options can be enforced
+func validateSecurityConfig(security models.SecurityConfig) error {
+	if security.EncryptResults && !security.LockOnStartup {
+		return i18n.Errorf(i18n.ErrEncryptResultsNeedsLock)
+	}
+	if security.LockOnStartup {
+		status, err := config.GetSecretStoreStatus()
+		if err != nil {
+			return err
+		}
+		if !status.Protected {
+			return i18n.Errorf(i18n.ErrSecretsNotProtected)
+		}
+	}
+	return nil
+}
+
+// GetSecretStoreStatus returns whether the key encrypting config secrets is passphrase
+// protected and still locked
+func (a *App) GetSecretStoreStatus() (*config.SecretStoreStatus, error) {
+	return config.GetSecretStoreStatus()
+}
+
+// UnlockSecrets unlocks the passphrase protected secret key and reloads the configuration
+// with its secrets decrypted
+func (a *App) UnlockSecrets(passphrase string) error {
+	if err := config.UnlockSecrets(passphrase); err != nil {
+		return err
+	}
+	runtime.LogInfo(a.ctx, "配置密钥已解锁")
+	a.sealTaskSecrets()
+	return a.reloadConfig()
+}
+
+// sealTaskSecrets encrypts the Interactsh tokens of tasks saved before task secrets were
+// stored encrypted (not possible while the secret key is locked)
+func (a *App) sealTaskSecrets() {
+	if a.jsonTaskManager == nil {
+		return
+	}
+	if count, err := a.jsonTaskManager.SealTaskSecrets(); err != nil {
+		runtime.LogWarningf(a.ctx, "Failed to encrypt task secrets: %v", err)
+	} else if count > 0 {
+		runtime.LogInfof(a.ctx, "Encrypted the Interactsh tokens of %d tasks", count)
+	}
+}
+
+// SetSecretPassphrase protects the secret key with a passphrase (empty newPassphrase removes
+// the protection); secrets already in config.json stay valid
+func (a *App) SetSecretPassphrase(oldPassphrase, newPassphrase string) error {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return err
+	}
+	if err := config.SetSecretPassphrase(oldPassphrase, newPassphrase); err != nil {
+		return err
+	}
+	if newPassphrase == "" {
+		runtime.LogInfo(a.ctx, "已取消配置密钥的口令保护")
+		a.audit(scanner.AuditPassphraseChanged, 0, "removed")
+	} else {
+		runtime.LogInfo(a.ctx, "已设置配置密钥的口令保护")
+		a.audit(scanner.AuditPassphraseChanged, 0, "set")
+	}
+	return nil
+}
+
+// GetMessageCatalog returns the message formats of a language (zh/en) keyed by the codes
+// carried by errors and events, for the frontend to localize backend messages
+func (a *App) GetMessageCatalog(lang string) map[i18n.Code]string {
+	return i18n.Catalog(lang)
+}
+
 // ============ Template Management Methods ============
 
 // PreValidateTemplates validates templates without importing them
 func (a *App) PreValidateTemplates(dirPath string) (*scanner.ImportResult, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
 	if a.templateParser == nil {
-		return nil, fmt.Errorf("application not initialized properly")
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
 	}
 
 	nucleiPath := a.config.NucleiPath
@@ -155,8 +648,11 @@ func (a *App) PreValidateTemplates(dirPath string) (*scanner.ImportResult, error
 
 // ConfirmAndImportTemplates imports only the pre-validated templates with progress updates
 func (a *App) ConfirmAndImportTemplates(validTemplates []*models.Template) (*scanner.ImportResult, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
 	if a.db == nil || a.templateParser == nil {
-		return nil, fmt.Errorf("application not initialized properly")
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
 	}
 
 	// Get target directory from config
@@ -229,14 +725,18 @@ func (a *App) ConfirmAndImportTemplates(validTemplates []*models.Template) (*sca
 		},
 	}
 	runtime.EventsEmit(a.ctx, "template-import-progress", completionEvent)
+	a.audit(scanner.AuditTemplatesImported, 0, fmt.Sprintf("%d templates", result.Validated))
 
 	return result, nil
 }
 
 // DeleteTemplate deletes a template file from the filesystem
 func (a *App) DeleteTemplate(templateID string) error {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return err
+	}
 	if a.db == nil {
-		return fmt.Errorf("application not initialized properly")
+		return i18n.Errorf(i18n.ErrNotInitialized)
 	}
 
 	// Get template from database by template_id
@@ -258,14 +758,136 @@ func (a *App) DeleteTemplate(templateID string) error {
 	if err := a.db.DeleteTemplate(template.ID); err != nil {
 		return fmt.Errorf("failed to delete template from database: %w", err)
 	}
+	a.audit(scanner.AuditTemplatesDeleted, 0, templateID)
 
 	return nil
 }
 
+// bulkProgressCallback emits the progress of a bulk template operation as
+// template-bulk-progress events, shaped like the template import progress
+func (a *App) bulkProgressCallback(operation string) scanner.BulkProgressFunc {
+	return func(current, total int, status string, stats ...map[string]int) {
+		percentage := 0.0
+		if total > 0 {
+			percentage = float64(current) / float64(total) * 100
+		}
+		data := map[string]interface{}{
+			"operation":  operation,
+			"current":    current,
+			"total":      total,
+			"percentage": percentage,
+			"status":     status,
+		}
+		if len(stats) > 0 {
+			data["successful"] = stats[0]["successful"]
+			data["errors"] = stats[0]["errors"]
+		}
+		runtime.EventsEmit(a.ctx, "template-bulk-progress", map[string]interface{}{
+			"type": "template_bulk_progress",
+			"data": data,
+		})
+	}
+}
+
+// emitBulkComplete sends the completion event of a bulk template operation
+func (a *App) emitBulkComplete(result *scanner.BulkTemplateResult) {
+	runtime.EventsEmit(a.ctx, "template-bulk-progress", map[string]interface{}{
+		"type": "template_bulk_complete",
+		"data": map[string]interface{}{
+			"operation":  result.Operation,
+			"current":    result.Total,
+			"total":      result.Total,
+			"successful": result.Succeeded,
+			"errors":     result.Failed,
+			"percentage": 100.0,
+			"status":     "操作完成!",
+		},
+	})
+}
+
+// BulkDeleteTemplates deletes the files and database entries of several templates
+func (a *App) BulkDeleteTemplates(templateIDs []string) (*scanner.BulkTemplateResult, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
+	if a.db == nil || a.templateParser == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("批量删除 %d 个模板", len(templateIDs)))
+	result := a.templateParser.BulkDeleteTemplates(a.db, templateIDs, a.bulkProgressCallback("delete"))
+	a.emitBulkComplete(result)
+	a.audit(scanner.AuditTemplatesDeleted, 0, fmt.Sprintf("%d templates", result.Succeeded))
+	return result, nil
+}
+
+// BulkEditTemplateTags adds and removes tags on several templates (YAML files and database)
+func (a *App) BulkEditTemplateTags(templateIDs []string, addTags []string, removeTags []string) (*scanner.BulkTemplateResult, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
+	if a.db == nil || a.templateParser == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("批量编辑 %d 个模板的标签: +%v -%v", len(templateIDs), addTags, removeTags))
+	result := a.templateParser.BulkEditTemplateTags(a.db, templateIDs, addTags, removeTags, a.bulkProgressCallback("tags"))
+	a.emitBulkComplete(result)
+	a.audit(scanner.AuditTemplateEdited, 0, fmt.Sprintf("tags of %d templates: +%v -%v", result.Succeeded, addTags, removeTags))
+	return result, nil
+}
+
+// BulkExportTemplates exports several templates to a zip archive; opens a save dialog when path is empty
+func (a *App) BulkExportTemplates(templateIDs []string, path string) (*scanner.BulkTemplateResult, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.db == nil || a.templateParser == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	if path == "" {
+		selected, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			DefaultFilename: fmt.Sprintf("wepoc_templates_%s.zip", time.Now().Format("20060102_150405")),
+			Title:           "导出模板",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "Zip Archives (*.zip)", Pattern: "*.zip"},
+			},
+		})
+		if err != nil || selected == "" {
+			return nil, i18n.Errorf(i18n.ErrCancelled)
+		}
+		path = selected
+	}
+
+	runtime.LogInfo(a.ctx, fmt.Sprintf("批量导出 %d 个模板到: %s", len(templateIDs), path))
+	result, err := a.templateParser.BulkExportTemplates(a.db, templateIDs, path, a.bulkProgressCallback("export"))
+	if err != nil {
+		return nil, err
+	}
+	a.emitBulkComplete(result)
+	a.audit(scanner.AuditExported, 0, fmt.Sprintf("%d templates to %s", result.Succeeded, path))
+	return result, nil
+}
+
+// BulkRevalidateTemplates validates several templates again with the configured nuclei
+func (a *App) BulkRevalidateTemplates(templateIDs []string) (*scanner.BulkTemplateResult, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
+	if a.db == nil || a.templateParser == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("重新验证 %d 个模板", len(templateIDs)))
+	result := a.templateParser.BulkRevalidateTemplates(a.db, templateIDs, a.config.NucleiPath, a.bulkProgressCallback("validate"))
+	a.emitBulkComplete(result)
+	return result, nil
+}
+
 // ImportTemplates imports templates from a directory with validation and progress updates
 func (a *App) ImportTemplates(dirPath string) (*scanner.ImportResult, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
 	if a.db == nil || a.templateParser == nil {
-		return nil, fmt.Errorf("application not initialized properly")
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
 	}
 
 	// Get target directory from config
@@ -325,8 +947,27 @@ func (a *App) ImportTemplates(dirPath string) (*scanner.ImportResult, error) {
 		return nil, fmt.Errorf("failed to import templates: %w", err)
 	}
 
+	// 来源目录带有签名清单时，验证通过的模板获得签名者的信任级别
+	result.Manifest = scanner.VerifyTemplateManifest(dirPath, a.config.TemplateTrust.Signers)
+	if result.Manifest.Error != "" {
+		runtime.LogInfo(a.ctx, fmt.Sprintf("模板清单验证失败: %s", result.Manifest.Error))
+		result.Errors = append(result.Errors, result.Manifest.Error)
+	}
+
+	// 导入目录是扁平的，分类取自来源目录结构
+	categories := make(map[string]string)
+	trust := make(map[string]string)
+	for _, template := range templates {
+		categories[filepath.Base(template.FilePath)] = template.Category
+		trust[filepath.Base(template.FilePath)] = result.Manifest.TrustOf(template.FilePath)
+	}
+
 	// Load templates from target directory and insert into database
 	templates, _ = a.templateParser.ScanDirectory(targetDir)
+	for _, template := range templates {
+		template.Category = categories[filepath.Base(template.FilePath)]
+		template.Trust = trust[filepath.Base(template.FilePath)]
+	}
 	if len(templates) > 0 {
 		if err := a.db.BatchInsertTemplates(templates); err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("Failed to save templates to database: %v", err))
@@ -342,23 +983,175 @@ func (a *App) ImportTemplates(dirPath string) (*scanner.ImportResult, error) {
 			"successful":  result.Validated,
 			"errors":      result.Failed,
 			"duplicates":  result.AlreadyExists,
+			"unchanged":   result.Unchanged,
+			"updated":     result.Updated,
 			"percentage":  100.0,
 			"status":      "导入完成!",
 		},
 	}
 	runtime.EventsEmit(a.ctx, "template-import-progress", completionEvent)
+	if result.Unchanged > 0 {
+		runtime.LogInfo(a.ctx, fmt.Sprintf("增量导入: %d 个模板未变化已跳过，%d 个模板已更新", result.Unchanged, result.Updated))
+	}
+	a.audit(scanner.AuditTemplatesImported, 0, fmt.Sprintf("%d templates from %s", result.Validated, dirPath))
 
 	return result, nil
 }
 
-// GetAllTemplates returns all templates from database
-func (a *App) GetAllTemplates() ([]*models.Template, error) {
-	if a.db == nil {
-		return []*models.Template{}, nil
+// templateSourceSync creates a syncer for Git and zip URL imports into the POC directory
+func (a *App) templateSourceSync() (*scanner.TemplateSourceSync, error) {
+	if a.db == nil || a.templateParser == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
 	}
-	templates, err := a.db.GetAllTemplates()
+	wepocDir, err := config.GetWepocDir()
 	if err != nil {
-		runtime.LogErrorf(a.ctx, "Failed to get templates: %v", err)
+		return nil, err
+	}
+	cacheDir := filepath.Join(wepocDir, "template_sources")
+	return scanner.NewTemplateSourceSync(a.db, a.templateParser, cacheDir, a.config.POCDirectory, a.config.NucleiPath, a.config.TemplateTrust.Signers), nil
+}
+
+// importProgressCallback emits sync progress as template-import-progress events
+func (a *App) importProgressCallback() scanner.BulkProgressFunc {
+	return func(current, total int, status string, stats ...map[string]int) {
+		percentage := 0.0
+		if total > 0 {
+			percentage = float64(current) / float64(total) * 100
+		}
+		data := map[string]interface{}{
+			"current":    current,
+			"total":      total,
+			"percentage": percentage,
+			"status":     status,
+			"totalFound": total,
+			"imported":   current,
+		}
+		if len(stats) > 0 {
+			data["successful"] = stats[0]["successful"]
+			data["errors"] = stats[0]["errors"]
+			data["duplicates"] = stats[0]["duplicates"]
+		}
+		runtime.EventsEmit(a.ctx, "template-import-progress", map[string]interface{}{
+			"type": "template_import_progress",
+			"data": data,
+		})
+	}
+}
+
+// emitSyncComplete sends the import completion event of a template source sync
+func (a *App) emitSyncComplete(result *scanner.TemplateSyncResult) {
+	status := "导入完成!"
+	if result.UpToDate {
+		status = "模板已是最新"
+	}
+	runtime.EventsEmit(a.ctx, "template-import-progress", map[string]interface{}{
+		"type": "template_import_complete",
+		"data": map[string]interface{}{
+			"totalFound": result.TotalFound,
+			"imported":   result.Added + result.Updated,
+			"successful": result.Added + result.Updated + result.Unchanged,
+			"errors":     result.Failed,
+			"duplicates": result.Conflicts,
+			"percentage": 100.0,
+			"status":     status,
+		},
+	})
+}
+
+// ImportTemplatesFromGit clones (or pulls) a Git repository and imports the templates below
+// subpath; importing the same repository again only re-imports changed files
+func (a *App) ImportTemplatesFromGit(repoURL string, branch string, subpath string, gitAuth scanner.GitAuth) (*scanner.TemplateSyncResult, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
+	sync, err := a.templateSourceSync()
+	if err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("从Git仓库导入模板: %s (分支: %s, 目录: %s)", repoURL, branch, subpath))
+	result, err := sync.SyncGit(repoURL, branch, subpath, gitAuth, a.importProgressCallback())
+	if err != nil {
+		return nil, err
+	}
+	a.audit(scanner.AuditTemplatesImported, 0, fmt.Sprintf("%d added, %d updated from %s", result.Added, result.Updated, repoURL))
+	a.emitSyncComplete(result)
+	return result, nil
+}
+
+// ImportTemplatesFromZipURL downloads a zip archive of templates and imports the templates
+// below subpath; importing the same URL again only re-imports changed files
+func (a *App) ImportTemplatesFromZipURL(zipURL string, subpath string) (*scanner.TemplateSyncResult, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
+	sync, err := a.templateSourceSync()
+	if err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("从URL导入模板: %s", zipURL))
+	result, err := sync.SyncZipURL(zipURL, subpath, a.importProgressCallback())
+	if err != nil {
+		return nil, err
+	}
+	a.audit(scanner.AuditTemplatesImported, 0, fmt.Sprintf("%d added, %d updated from %s", result.Added, result.Updated, zipURL))
+	a.emitSyncComplete(result)
+	return result, nil
+}
+
+// ImportTemplatesFromArchive imports the templates below subpath of a local zip archive (e.g.
+// a nuclei-templates release in an air-gapped network); opens a file dialog when path is empty
+func (a *App) ImportTemplatesFromArchive(archivePath string, subpath string) (*scanner.TemplateSyncResult, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
+	sync, err := a.templateSourceSync()
+	if err != nil {
+		return nil, err
+	}
+	if archivePath == "" {
+		selected, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: "导入模板压缩包",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "Zip Files (*.zip)", Pattern: "*.zip"},
+			},
+		})
+		if err != nil || selected == "" {
+			return nil, i18n.Errorf(i18n.ErrCancelled)
+		}
+		archivePath = selected
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("从本地压缩包导入模板: %s", archivePath))
+	result, err := sync.SyncArchive(archivePath, subpath, a.importProgressCallback())
+	if err != nil {
+		return nil, err
+	}
+	a.audit(scanner.AuditTemplatesImported, 0, fmt.Sprintf("%d added, %d updated from %s", result.Added, result.Updated, archivePath))
+	a.emitSyncComplete(result)
+	return result, nil
+}
+
+// GetTemplateSources lists the Git repositories and zip URLs templates were imported from
+func (a *App) GetTemplateSources() ([]*models.TemplateSource, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.db.ListTemplateSources()
+}
+
+// GetAllTemplates returns all templates from database
+func (a *App) GetAllTemplates() ([]*models.Template, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return []*models.Template{}, nil
+	}
+	templates, err := a.db.GetAllTemplates()
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to get templates: %v", err)
 		return []*models.Template{}, nil
 	}
 	if templates == nil {
@@ -367,14 +1160,130 @@ func (a *App) GetAllTemplates() ([]*models.Template, error) {
 	return templates, nil
 }
 
+// SearchTemplateContent full-text searches template metadata and YAML bodies (e.g. a path
+// or header name) and returns matches with an excerpt
+func (a *App) SearchTemplateContent(query string, limit int) ([]*models.TemplateSearchHit, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.db.SearchTemplateContent(query, limit)
+}
+
+// RebuildTemplateIndex re-reads all template files into the full-text index (e.g. after
+// templates were edited outside wepoc)
+func (a *App) RebuildTemplateIndex() error {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogInfo(a.ctx, "重建模板全文索引")
+	return a.db.RebuildTemplateIndex()
+}
+
+// GetTemplatesPage returns one page of templates for lazy-loading tables; sortBy is one of
+// name, template_id, severity, author, category, created_at (prefix "-" for descending)
+func (a *App) GetTemplatesPage(offset int, limit int, sortBy string, filter models.TemplateFilter) (*models.TemplatePage, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.db.GetTemplatesPage(offset, limit, sortBy, filter)
+}
+
+// CountTemplates returns the number of templates matching a filter
+func (a *App) CountTemplates(filter models.TemplateFilter) (int, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return 0, err
+	}
+	if a.db == nil {
+		return 0, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.db.CountTemplates(filter)
+}
+
+// GetTemplateCategories returns all template categories with their template counts
+func (a *App) GetTemplateCategories() ([]*models.TemplateCategory, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.db.ListTemplateCategories()
+}
+
+// GetTemplatesByCategory returns the templates of a category and its subcategories
+// ("-" for uncategorized templates)
+func (a *App) GetTemplatesByCategory(category string) ([]*models.Template, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.db.ListTemplates("name", models.TemplateFilter{Category: category})
+}
+
+// GetFavoriteTemplates returns all templates marked as favorite
+func (a *App) GetFavoriteTemplates() ([]*models.Template, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.db.ListTemplates("name", models.TemplateFilter{Favorites: true})
+}
+
+// SetTemplateCategory assigns a category to templates; an empty category removes it
+func (a *App) SetTemplateCategory(templateIDs []string, category string) (int, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return 0, err
+	}
+	if a.db == nil {
+		return 0, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("设置 %d 个模板的分类: %s", len(templateIDs), category))
+	count, err := a.db.SetTemplateCategory(templateIDs, category)
+	if err != nil {
+		return 0, err
+	}
+	a.audit(scanner.AuditTemplateEdited, 0, fmt.Sprintf("category of %d templates: %s", count, category))
+	return count, nil
+}
+
+// SetTemplateFavorite marks or unmarks a template as favorite
+func (a *App) SetTemplateFavorite(templateID string, favorite bool) error {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.db.SetTemplateFavorite(templateID, favorite)
+}
+
 // SearchTemplates searches templates by keyword and severity
 func (a *App) SearchTemplates(keyword string, severity string) ([]*models.Template, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
 	return a.db.SearchTemplates(keyword, severity)
 }
 
 
 // ClearAllTemplates removes all templates
 func (a *App) ClearAllTemplates() error {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return err
+	}
 	return a.db.ClearAllTemplates()
 }
 
@@ -512,6 +1421,9 @@ func (a *App) isExecutableFile(path string) bool {
 
 // CreateScanTask creates a new scanning task (JSON-based)
 func (a *App) CreateScanTask(pocsJSON string, targetsJSON string, taskName string) (*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
 	runtime.LogInfof(a.ctx, "=== CreateScanTask called ===")
 	runtime.LogInfof(a.ctx, "pocsJSON: %s", pocsJSON)
 	runtime.LogInfof(a.ctx, "targetsJSON: %s", targetsJSON)
@@ -538,417 +1450,2271 @@ func (a *App) CreateScanTask(pocsJSON string, targetsJSON string, taskName strin
 		return nil, err
 	}
 	runtime.LogInfof(a.ctx, "Task created successfully: %+v", task)
+	a.audit(scanner.AuditTaskCreated, task.ID, fmt.Sprintf("%d templates, %d targets", len(task.POCs), len(task.Targets)))
+	if len(task.FilteredTemplates) > 0 {
+		runtime.LogInfo(a.ctx, fmt.Sprintf("任务 %d 中有 %d 个模板会被Nuclei过滤", task.ID, len(task.FilteredTemplates)))
+	}
 
 	return task, nil
 }
 
-// StartScanTask starts a scanning task (JSON-based) with real-time event emission
-func (a *App) StartScanTask(taskID int64) error {
-	// Register event handler to emit events to frontend
-	a.jsonTaskManager.RegisterEventHandler(taskID, func(event *scanner.ScanEvent) {
-		// Emit event to frontend via Wails runtime
-		runtime.EventsEmit(a.ctx, "scan-event", event)
-	})
-
-	return a.jsonTaskManager.StartTask(taskID)
-}
-
-// RescanTask restarts a completed or failed task with the same configuration
-func (a *App) RescanTask(taskID int64) error {
-	// Register event handler to emit events to frontend
-	a.jsonTaskManager.RegisterEventHandler(taskID, func(event *scanner.ScanEvent) {
-		// Emit event to frontend via Wails runtime
-		runtime.EventsEmit(a.ctx, "scan-event", event)
-	})
-
-	return a.jsonTaskManager.RescanTask(taskID)
-}
-
-// PauseScanTask pauses a running task
-func (a *App) PauseScanTask(taskID int64) error {
-	return a.taskManager.PauseTask(taskID)
-}
-
-// StopScanTask stops a running task
-func (a *App) StopScanTask(taskID int64) error {
-	return a.taskManager.StopTask(taskID)
-}
-
-// GetAllScanTasks returns all scan tasks (JSON-based)
-func (a *App) GetAllScanTasks() ([]*scanner.TaskConfig, error) {
-	return a.jsonTaskManager.GetAllTasks()
-}
-
-// GetRunningScanTasks returns all running tasks (legacy)
-func (a *App) GetRunningScanTasks() []*models.ScanTask {
-	return a.taskManager.GetRunningTasks()
-}
-
-// UpdateScanTask updates an existing scan task (JSON-based)
-func (a *App) UpdateScanTask(taskID int64, pocsJSON string, targetsJSON string, taskName string) (*scanner.TaskConfig, error) {
-	var pocs []string
-	if err := json.Unmarshal([]byte(pocsJSON), &pocs); err != nil {
-		return nil, fmt.Errorf("invalid POCs JSON: %v", err)
+// CreateWorkflowTask creates a task that runs a nuclei workflow of the template library
+// instead of a flat template list
+func (a *App) CreateWorkflowTask(templateID string, targetsJSON string, taskName string) (*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
 	}
-
 	var targets []string
 	if err := json.Unmarshal([]byte(targetsJSON), &targets); err != nil {
-		return nil, fmt.Errorf("invalid targets JSON: %v", err)
+		return nil, fmt.Errorf("invalid targets JSON: %w", err)
 	}
 
-	return a.jsonTaskManager.UpdateTask(taskID, pocs, targets, taskName)
-}
-
-// DeleteScanTask deletes a scan task (JSON-based)
-func (a *App) DeleteScanTask(taskID int64) error {
-	return a.jsonTaskManager.DeleteTask(taskID)
+	task, err := a.jsonTaskManager.CreateWorkflowTask(templateID, targets, taskName)
+	if err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("已创建工作流任务 %d: %s（%d 个模板）", task.ID, templateID, len(task.POCs)))
+	return task, nil
 }
 
-// GetScanTaskResult returns the scan result for a task (JSON-based)
-func (a *App) GetScanTaskResult(taskID int64) (*scanner.TaskResult, error) {
-	return a.jsonTaskManager.GetTaskResult(taskID)
+// ValidateTargets previews the normalized targets of a task, with the duplicate and invalid
+// entries and their reasons, before the task is created
+func (a *App) ValidateTargets(targets []string) *scanner.TargetValidation {
+	return scanner.ValidateTargets(targets)
 }
 
-// GetAllScanResults returns all scan results (JSON-based)
-func (a *App) GetAllScanResults() ([]*scanner.TaskResult, error) {
-	return a.jsonTaskManager.GetAllTaskResults()
+// SearchTargets previews the targets a search engine query (fofa, hunter or shodan) returns,
+// optionally without the targets already part of a task
+func (a *App) SearchTargets(engine string, query string, limit int, skipKnown bool) (*scanner.UncoverResult, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.UncoverTargets(a.ctx, engine, query, limit, skipKnown)
 }
 
-// GetTaskLogs returns the logs for a specific task from JSON file
-func (a *App) GetTaskLogsFromFile(taskID int64) ([]*scanner.ScanLogEntry, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+// CreateTaskFromQuery creates a task from the results of a search engine query
+func (a *App) CreateTaskFromQuery(engine string, query string, limit int, pocs []string, taskName string, skipKnown bool) (*scanner.UncoverTask, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
 	}
-
-	logFile := filepath.Join(homeDir, ".wepoc", "logs", fmt.Sprintf("task_%d.json", taskID))
-
-	// Check if log file exists
-	if _, err := os.Stat(logFile); os.IsNotExist(err) {
-		return []*scanner.ScanLogEntry{}, nil // Return empty logs if file doesn't exist
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
 	}
-
-	// Read log file
-	data, err := os.ReadFile(logFile)
+	created, err := a.jsonTaskManager.CreateTaskFromQuery(a.ctx, engine, query, limit, pocs, taskName, skipKnown)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read log file: %w", err)
+		return nil, err
 	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("已通过 %s 查询创建任务 %d: %d 个目标", engine, created.Task.ID, len(created.Task.Targets)))
+	return created, nil
+}
 
-	// Parse JSON
-	var allLogs []*scanner.ScanLogEntry
-	if err := json.Unmarshal(data, &allLogs); err != nil {
-		return nil, fmt.Errorf("failed to parse log file: %w", err)
+// GetTaskSubdomains returns the subdomains enumerated for a task and which of them were
+// added to its targets
+func (a *App) GetTaskSubdomains(taskID int64) (*scanner.SubdomainEnumeration, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
 	}
-
-	// Filter logs - only return DEBUG (HTTP packets) and VULN entries
-	// Skip INFO/WARN/ERROR to reduce data size and improve performance
-	var filteredLogs []*scanner.ScanLogEntry
-	for _, log := range allLogs {
-		if log.Level == "DEBUG" || log.Level == "VULN" {
-			filteredLogs = append(filteredLogs, log)
-		}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
 	}
-
-	runtime.LogInfof(a.ctx, "Loaded %d filtered logs (from %d total) for task %d", len(filteredLogs), len(allLogs), taskID)
-	return filteredLogs, nil
+	return a.jsonTaskManager.GetTaskSubdomains(taskID)
 }
 
-// GetTaskProgress returns the progress of a task
-func (a *App) GetTaskProgress(taskID int64) (*models.TaskProgress, error) {
-	return a.taskManager.GetTaskStatus(taskID)
-}
-
-// GetTaskLogs returns the logs for a specific task
-func (a *App) GetTaskLogs(taskID int64) ([]*models.ScanLog, error) {
-	return a.taskManager.GetTaskLogs(taskID)
+// CheckFilteredTemplates returns which of the selected templates nuclei will filter
+// (code/headless/file) with the given task options and why, before a task is created
+func (a *App) CheckFilteredTemplates(pocs []string, options scanner.TaskOptions) ([]*scanner.FilteredTemplate, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.CheckFilteredTemplates(pocs, options), nil
 }
 
-// GetTaskLogSummary returns the log summary for a specific task
-func (a *App) GetTaskLogSummary(taskID int64) (map[string]interface{}, error) {
-	return a.taskManager.GetTaskLogSummary(taskID)
+// GetHeadlessBrowserStatus returns the browser used by tasks with headless templates enabled
+func (a *App) GetHeadlessBrowserStatus() (*scanner.HeadlessBrowserStatus, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	return scanner.DetectHeadlessBrowser(a.config.Headless), nil
 }
 
-// GetScanResult returns the comprehensive scan result for a specific task
-func (a *App) GetScanResult(taskID int64) (map[string]interface{}, error) {
-	return a.taskManager.GetScanResult(taskID)
+// DownloadHeadlessBrowser downloads a Chromium compatible with nuclei headless templates,
+// emitting "headless-browser-progress" events while downloading
+func (a *App) DownloadHeadlessBrowser() (*scanner.HeadlessBrowserStatus, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
+	}
+	status, err := scanner.DownloadHeadlessBrowser(a.config.Headless, func(current, total int, message string, stats ...map[string]int) {
+		runtime.EventsEmit(a.ctx, "headless-browser-progress", map[string]interface{}{
+			"current": current,
+			"total":   total,
+			"status":  message,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("Chromium已就绪: %s", status.Path))
+	return status, nil
 }
 
-// DeleteScanResult deletes a scan result file (legacy)
-func (a *App) DeleteScanResult(filepath string) error {
-	return a.taskManager.DeleteScanResult(filepath)
+// GetTaskCodeTemplates returns the code protocol templates of a task and whether each is confirmed
+func (a *App) GetTaskCodeTemplates(taskID int64) ([]*scanner.CodeTemplate, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetTaskCodeTemplates(taskID)
 }
 
-// ============ Results Methods ============
-
-// GetScanResults returns results from a scan output file
-func (a *App) GetScanResults(taskID int64) ([]*models.NucleiResult, error) {
-	task, err := a.db.GetScanTaskByID(taskID)
-	if err != nil {
+// ConfirmCodeTemplates asks the user to confirm running the code templates of a task,
+// which execute commands on this machine, and records the confirmation in the audit log
+func (a *App) ConfirmCodeTemplates(taskID int64) (*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
 		return nil, err
 	}
-
-	// Read output file
-	data, err := json.Marshal([]byte(task.OutputFile))
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	templates, err := a.jsonTaskManager.GetTaskCodeTemplates(taskID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read results file: %w", err)
+		return nil, err
 	}
-
-	var results []*models.NucleiResult
-	if err := json.Unmarshal(data, &results); err != nil {
-		return nil, fmt.Errorf("failed to parse results: %w", err)
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("任务 %d 不包含code模板", taskID)
 	}
 
-	return results, nil
+	names := make([]string, 0, len(templates))
+	for i, template := range templates {
+		if i == 10 {
+			names = append(names, fmt.Sprintf("... 共 %d 个", len(templates)))
+			break
+		}
+		names = append(names, template.TemplateID)
+	}
+	selection, err := runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
+		Type:          runtime.QuestionDialog,
+		Title:         "确认执行code模板",
+		Message:       fmt.Sprintf("以下code模板会在本机执行命令：\n\n%s\n\n确认执行？", strings.Join(names, "\n")),
+		Buttons:       []string{"确认", "取消"},
+		DefaultButton: "取消",
+		CancelButton:  "取消",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to show confirmation dialog: %w", err)
+	}
+	if selection != "确认" && selection != "Yes" {
+		return nil, fmt.Errorf("已取消执行code模板")
+	}
+
+	task, err := a.jsonTaskManager.ConfirmCodeTemplates(taskID)
+	if err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("任务 %d 已确认执行 %d 个code模板", taskID, len(templates)))
+	return task, nil
+}
+
+// ConfirmTaskRequestEstimate asks the user to confirm starting a task whose estimated request
+// count exceeds the confirmation threshold, and records the confirmation in the audit log
+func (a *App) ConfirmTaskRequestEstimate(taskID int64) (*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	report, err := a.jsonTaskManager.DryRunTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	selection, err := runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
+		Type:  runtime.QuestionDialog,
+		Title: "确认请求量",
+		Message: fmt.Sprintf("该任务预估发送 %d 个请求（%d 个目标，每个目标 %d 个请求），按 %d 请求/秒约需 %s。\n\n确认启动？",
+			report.EstimatedRequests, report.TargetCount, report.RequestsPerTarget, report.RateLimit, report.EstimatedDuration),
+		Buttons:       []string{"确认", "取消"},
+		DefaultButton: "取消",
+		CancelButton:  "取消",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to show confirmation dialog: %w", err)
+	}
+	if selection != "确认" && selection != "Yes" {
+		return nil, fmt.Errorf("已取消")
+	}
+
+	task, err := a.jsonTaskManager.ConfirmRequestEstimate(taskID, false)
+	if err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("任务 %d 已确认预估请求数 %d", taskID, report.EstimatedRequests))
+	return task, nil
+}
+
+// OverrideTaskRequestLimit lets an administrator start a task regardless of its estimated
+// request count, now and after later changes to its targets or templates
+func (a *App) OverrideTaskRequestLimit(taskID int64) (*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	task, err := a.jsonTaskManager.ConfirmRequestEstimate(taskID, true)
+	if err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("管理员已放行任务 %d 的请求量限制", taskID))
+	return task, nil
+}
+
+// GetAuditLog returns the most recent audit log entries, newest first
+func (a *App) GetAuditLog(limit int) ([]*scanner.AuditEntry, error) {
+	if err := a.authorize(auth.PermManageUsers); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetAuditLog(limit)
+}
+
+// QueryAuditLog returns a page of the audit log filtered by action, user, task, time range
+// and text, newest first
+func (a *App) QueryAuditLog(offset int, limit int, filter scanner.AuditLogFilter) (*scanner.AuditLogPage, error) {
+	if err := a.authorize(auth.PermManageUsers); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.QueryAuditLog(offset, limit, filter)
+}
+
+// VerifyAuditLog checks the hash chain of the audit log for edited, removed or reordered entries
+func (a *App) VerifyAuditLog() (*scanner.AuditVerification, error) {
+	if err := a.authorize(auth.PermManageUsers); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	result, err := a.jsonTaskManager.VerifyAuditLog()
+	if err != nil {
+		return nil, err
+	}
+	if !result.Valid {
+		runtime.LogWarning(a.ctx, fmt.Sprintf("审计日志校验失败（第 %d 行）: %s", result.BrokenAt, result.Error))
+	}
+	return result, nil
+}
+
+// StartScanTask starts a scanning task (JSON-based) with real-time event emission
+func (a *App) StartScanTask(taskID int64) error {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return err
+	}
+	// Register event handler to emit events to frontend
+	a.jsonTaskManager.RegisterEventHandler(taskID, func(event *scanner.ScanEvent) {
+		// Emit event to frontend via Wails runtime
+		runtime.EventsEmit(a.ctx, "scan-event", event)
+	})
+
+	if err := a.jsonTaskManager.StartTask(taskID); err != nil {
+		return err
+	}
+	a.audit(scanner.AuditTaskStarted, taskID, "")
+	return nil
+}
+
+// RescanTask restarts a completed or failed task with the same configuration
+func (a *App) RescanTask(taskID int64) error {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return err
+	}
+	// Register event handler to emit events to frontend
+	a.jsonTaskManager.RegisterEventHandler(taskID, func(event *scanner.ScanEvent) {
+		// Emit event to frontend via Wails runtime
+		runtime.EventsEmit(a.ctx, "scan-event", event)
+	})
+
+	if err := a.jsonTaskManager.RescanTask(taskID); err != nil {
+		return err
+	}
+	a.audit(scanner.AuditTaskStarted, taskID, "rescan")
+	return nil
+}
+
+// RescanTaskWithSnapshot restarts a completed or failed task against the exact template
+// versions recorded when it was last started
+func (a *App) RescanTaskWithSnapshot(taskID int64) error {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return err
+	}
+	a.jsonTaskManager.RegisterEventHandler(taskID, func(event *scanner.ScanEvent) {
+		runtime.EventsEmit(a.ctx, "scan-event", event)
+	})
+
+	if err := a.jsonTaskManager.RescanTaskWithSnapshot(taskID); err != nil {
+		return err
+	}
+	a.audit(scanner.AuditTaskStarted, taskID, "rescan with snapshot")
+	return nil
+}
+
+// GetTaskTemplateSnapshot returns the templates recorded when a task was last started and
+// whether each one changed since
+func (a *App) GetTaskTemplateSnapshot(taskID int64) ([]*scanner.TemplateSnapshotItem, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetTemplateSnapshot(taskID)
+}
+
+// PauseScanTask pauses a running task; StartScanTask continues it from nuclei's resume file
+func (a *App) PauseScanTask(taskID int64) error {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return err
+	}
+	if a.jsonTaskManager == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.PauseTask(taskID)
+}
+
+// StopScanTask stops a running task
+func (a *App) StopScanTask(taskID int64) error {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return err
+	}
+	if a.jsonTaskManager == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	if err := a.jsonTaskManager.StopTask(taskID); err != nil {
+		return err
+	}
+	a.audit(scanner.AuditTaskStopped, taskID, "")
+	return nil
+}
+
+// StopAllTasks immediately terminates every running nuclei process (task scans and single
+// POC tests), saves partial results and marks the tasks stopped
+func (a *App) StopAllTasks() (*scanner.StopAllResult, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogWarning(a.ctx, "紧急停止所有扫描任务")
+	result := a.jsonTaskManager.StopAllTasks()
+	a.audit(scanner.AuditTaskStopped, 0, fmt.Sprintf("stop all: %d tasks", len(result.StoppedTasks)))
+	runtime.LogInfo(a.ctx, fmt.Sprintf("已停止 %d 个任务，终止 %d 个其他进程", len(result.StoppedTasks), result.KilledProcesses))
+	return result, nil
+}
+
+// GetAllScanTasks returns all scan tasks (JSON-based)
+func (a *App) GetAllScanTasks() ([]*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	return a.jsonTaskManager.GetAllTasks()
+}
+
+// ListScanTasks returns the tasks matching a label/status/date/name filter, sorted on the
+// backend (created_at, updated_at, end_time, name, found_vulns or status; "-" for descending)
+func (a *App) ListScanTasks(sortBy string, filter models.TaskFilter) ([]*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.ListTasks(sortBy, filter)
+}
+
+// GetTaskLabels returns the labels used on tasks with their task counts, for the label filter
+func (a *App) GetTaskLabels() ([]*scanner.TaskLabelCount, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetTaskLabels()
+}
+
+// GetRunningScanTasks returns the tasks currently being scanned
+func (a *App) GetRunningScanTasks() ([]*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, nil
+	}
+	return a.jsonTaskManager.GetRunningTasks(), nil
+}
+
+// UpdateScanTask updates an existing scan task (JSON-based)
+func (a *App) UpdateScanTask(taskID int64, pocsJSON string, targetsJSON string, taskName string) (*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	var pocs []string
+	if err := json.Unmarshal([]byte(pocsJSON), &pocs); err != nil {
+		return nil, fmt.Errorf("invalid POCs JSON: %v", err)
+	}
+
+	var targets []string
+	if err := json.Unmarshal([]byte(targetsJSON), &targets); err != nil {
+		return nil, fmt.Errorf("invalid targets JSON: %v", err)
+	}
+
+	return a.jsonTaskManager.UpdateTask(taskID, pocs, targets, taskName)
+}
+
+// UpdateScanTaskMetadata renames a task and edits its description and labels; allowed on
+// any task that is not running, while POC/target edits go through UpdateScanTask
+func (a *App) UpdateScanTaskMetadata(taskID int64, name string, description string, labels []string) (*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	return a.jsonTaskManager.UpdateTaskMetadata(taskID, name, description, labels)
+}
+
+// UpdateScanTaskOptions updates the per-task scan options (e.g. interactsh server)
+func (a *App) UpdateScanTaskOptions(taskID int64, options scanner.TaskOptions) (*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	return a.jsonTaskManager.UpdateTaskOptions(taskID, options)
+}
+
+// UpdateTaskNotes saves the scope description, tester notes and executive summary of a
+// task, which are included in its report exports
+func (a *App) UpdateTaskNotes(taskID int64, notes scanner.TaskNotes) (*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	return a.jsonTaskManager.UpdateTaskNotes(taskID, notes)
+}
+
+// ImportNucleiResults imports the JSONL output of a standalone nuclei run as a new completed
+// task; opens a file dialog when path is empty
+func (a *App) ImportNucleiResults(path string, taskName string) (*scanner.TaskConfig, error) {
+	return a.ImportScanResults(path, scanner.ResultFormatNuclei, taskName)
+}
+
+// ImportScanResults imports the output of nuclei, xray or afrog (format) as a new completed
+// task; opens a file dialog when path is empty
+func (a *App) ImportScanResults(path string, format string, taskName string) (*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		selected, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: fmt.Sprintf("导入%s扫描结果", format),
+			Filters: []runtime.FileFilter{
+				{DisplayName: "JSON Files (*.json, *.jsonl)", Pattern: "*.json;*.jsonl"},
+				{DisplayName: "All Files", Pattern: "*"},
+			},
+		})
+		if err != nil || selected == "" {
+			return nil, i18n.Errorf(i18n.ErrCancelled)
+		}
+		path = selected
+	}
+
+	runtime.LogInfo(a.ctx, fmt.Sprintf("导入%s扫描结果: %s", format, path))
+	task, err := a.jsonTaskManager.ImportExternalResults(path, format, taskName)
+	if err != nil {
+		return nil, err
+	}
+	a.audit(scanner.AuditTaskCreated, task.ID, fmt.Sprintf("imported %d %s findings from %s", task.FoundVulns, format, path))
+	return task, nil
+}
+
+// ProbeTargets detects the working scheme (https/http) of bare host:port targets
+func (a *App) ProbeTargets(targets []string) (*scanner.TargetProbeReport, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("探测 %d 个目标的协议", len(targets)))
+	return a.jsonTaskManager.ProbeTaskTargets(a.ctx, targets), nil
+}
+
+// TestLoginScript runs a login request and reports the session value that would be injected
+func (a *App) TestLoginScript(login scanner.LoginConfig) (*scanner.LoginTestResult, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("测试登录脚本: %s", login.Target))
+	return a.jsonTaskManager.TestLogin(a.ctx, login), nil
+}
+
+// ValidateClientCertificate checks that a client certificate/key pair (and optional CA bundle) can be loaded
+func (a *App) ValidateClientCertificate(cert scanner.ClientCertConfig) *scanner.ClientCertValidation {
+	return scanner.ValidateClientCert(cert)
+}
+
+// DeleteScanTask deletes a scan task (JSON-based)
+func (a *App) DeleteScanTask(taskID int64) error {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return err
+	}
+	// 删除后无法再查到任务名称，先记录下来
+	name := ""
+	if task, err := a.jsonTaskManager.GetTaskByID(taskID); err == nil {
+		name = task.Name
+	}
+	if err := a.jsonTaskManager.DeleteTask(taskID); err != nil {
+		return err
+	}
+	a.audit(scanner.AuditTaskDeleted, taskID, name)
+	return nil
+}
+
+// GetScanTaskResult returns the scan result for a task (JSON-based)
+func (a *App) GetScanTaskResult(taskID int64) (*scanner.TaskResult, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	return a.jsonTaskManager.GetTaskResult(taskID)
+}
+
+// GetAllScanResults returns all scan results (JSON-based)
+func (a *App) GetAllScanResults() ([]*scanner.TaskResult, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	return a.jsonTaskManager.GetAllTaskResults()
+}
+
+// ListTaskResults returns task results filtered and sorted on the backend; unlike
+// GetAllScanResults it can include scans without findings
+func (a *App) ListTaskResults(sortBy string, filter models.TaskResultFilter) ([]*scanner.TaskResult, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.ListTaskResults(sortBy, filter)
+}
+
+// GetTaskResultByTarget returns the result of a task split by target: findings, HTTP
+// request count, errors and probe status of each target
+func (a *App) GetTaskResultByTarget(taskID int64) (*scanner.TaskTargetBreakdown, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetTaskResultByTarget(taskID)
+}
+
+// ListFindings returns the unique findings of all scans with first/last seen times, duplicates
+// within and across tasks collapsed
+func (a *App) ListFindings(sortBy string) ([]*models.Finding, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.ListFindings(sortBy)
+}
+
+// GetTaskLogs returns the logs for a specific task from JSON file
+func (a *App) GetTaskLogsFromFile(taskID int64) ([]*scanner.ScanLogEntry, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	logFile := filepath.Join(homeDir, ".wepoc", "logs", fmt.Sprintf("task_%d.json", taskID))
+
+	// Check if log file exists
+	if _, err := os.Stat(logFile); os.IsNotExist(err) {
+		return []*scanner.ScanLogEntry{}, nil // Return empty logs if file doesn't exist
+	}
+
+	// Read log file
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	// Parse JSON
+	var allLogs []*scanner.ScanLogEntry
+	if err := json.Unmarshal(data, &allLogs); err != nil {
+		return nil, fmt.Errorf("failed to parse log file: %w", err)
+	}
+
+	// Filter logs - only return DEBUG (HTTP packets) and VULN entries
+	// Skip INFO/WARN/ERROR to reduce data size and improve performance
+	var filteredLogs []*scanner.ScanLogEntry
+	for _, log := range allLogs {
+		if log.Level == "DEBUG" || log.Level == "VULN" {
+			filteredLogs = append(filteredLogs, log)
+		}
+	}
+
+	runtime.LogInfof(a.ctx, "Loaded %d filtered logs (from %d total) for task %d", len(filteredLogs), len(allLogs), taskID)
+	return filteredLogs, nil
+}
+
+// GetTaskProgress returns the progress of a task
+func (a *App) GetTaskProgress(taskID int64) (*scanner.ScanProgress, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	live, err := a.jsonTaskManager.GetLiveProgress(taskID)
+	if err != nil {
+		return nil, err
+	}
+	return live.Progress, nil
+}
+
+// GetTaskLogs returns the logs for a specific task
+func (a *App) GetTaskLogs(taskID int64) ([]*scanner.ScanLogEntry, error) {
+	return a.GetTaskLogsFromFile(taskID)
+}
+
+// GetTaskLogSummary returns the summary of a task: result statistics, timeline milestones
+// and error categories
+func (a *App) GetTaskLogSummary(taskID int64) (*scanner.TaskSummary, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetTaskSummary(taskID)
+}
+
+// GetScanResult returns the comprehensive scan result for a specific task
+func (a *App) GetScanResult(taskID int64) (*scanner.TaskResult, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetTaskResult(taskID)
+}
+
+// DeleteScanResult deletes a result file listed by ListResultFiles
+func (a *App) DeleteScanResult(filepath string) error {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return err
+	}
+	if a.jsonTaskManager == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.DeleteResultFile(filepath)
+}
+
+// LockScanTask locks a finished task as evidence: its result and logs can no longer be
+// modified, rescanned or deleted, and retention leaves it alone
+func (a *App) LockScanTask(taskID int64, reason string) (*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	return a.jsonTaskManager.LockTask(taskID, reason)
+}
+
+// UnlockScanTask removes the evidence lock of a task (administrators only)
+func (a *App) UnlockScanTask(taskID int64, reason string) (*scanner.TaskConfig, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
+	}
+	return a.jsonTaskManager.UnlockTask(taskID, reason)
+}
+
+// ============ Results Methods ============
+
+// GetScanResults returns the findings of a task, falling back to its result files on disk
+// for tasks scanned before results were stored in the database
+func (a *App) GetScanResults(taskID int64) ([]*models.NucleiResult, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	result, err := a.jsonTaskManager.GetTaskResult(taskID)
+	if err != nil {
+		if vulns, legacyErr := a.jsonTaskManager.LegacyTaskResult(taskID); legacyErr == nil {
+			return vulns, nil
+		}
+		return nil, err
+	}
+	return result.Vulnerabilities, nil
+}
+
+// ListResultFiles lists all result files in the results directory
+func (a *App) ListResultFiles() ([]*scanner.ResultFile, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.ListResultFiles()
+}
+
+// GetResultFileVulnerabilities returns the findings stored in a result file
+func (a *App) GetResultFileVulnerabilities(path string) ([]*models.NucleiResult, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.ReadResultFile(path)
+}
+
+// ============ Utility Methods ============
+
+// CheckNucleiInstalled checks if nuclei is installed
+func (a *App) CheckNucleiInstalled() (bool, string, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return false, "", err
+	}
+	return config.CheckNucleiInstalled(a.config.NucleiPath)
+}
+
+// ValidateNucleiPath validates the current nuclei path and tries to fix it if needed
+func (a *App) ValidateNucleiPath() error {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return err
+	}
+	if err := config.ValidateNucleiPath(a.config); err != nil {
+		return err
+	}
+	
+	// Save the updated config if it was changed
+	return config.SaveConfig(a.config)
+}
+
+// NucleiTestResult represents the result of testing a nuclei path
+type NucleiTestResult struct {
+	Valid   bool   `json:"valid"`
+	Version string `json:"version"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ProxyTestResult represents the result of a proxy test
+type ProxyTestResult struct {
+	URL         string `json:"url"`
+	Available   bool   `json:"available"`
+	ResponseTime int64  `json:"response_time"` // in milliseconds
+	Error       string `json:"error,omitempty"`
+}
+
+// ProxyTestResults represents the results of testing multiple proxies
+type ProxyTestResults struct {
+	Results []ProxyTestResult `json:"results"`
+	Summary struct {
+		Total     int `json:"total"`
+		Available int `json:"available"`
+		Failed    int `json:"failed"`
+	} `json:"summary"`
+}
+
+// TestNucleiPath tests a user-specified nuclei path
+func (a *App) TestNucleiPath(userPath string) (NucleiTestResult, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return NucleiTestResult{}, err
+	}
+	valid, version, err := config.ValidateUserNucleiPath(userPath)
+	result := NucleiTestResult{
+		Valid:   valid,
+		Version: version,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, nil
+}
+
+// SetNucleiPath sets a new nuclei path after validation
+func (a *App) SetNucleiPath(newPath string) error {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return err
+	}
+	// Normalize the path
+	normalizedPath := filepath.Clean(newPath)
+	
+	// First validate the new path
+	valid, version, err := config.ValidateUserNucleiPath(normalizedPath)
+	if err != nil {
+		return fmt.Errorf("invalid nuclei path: %w", err)
+	}
+	
+	if !valid {
+		return fmt.Errorf("nuclei path validation failed")
+	}
+	
+	// Update the config with normalized path
+	a.config.NucleiPath = normalizedPath
+	
+	// Save the updated config
+	if err := config.SaveConfig(a.config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	
+	// Update JSONTaskManager config
+	if a.jsonTaskManager != nil {
+		a.jsonTaskManager.UpdateConfig(a.config)
+	}
+	
+	runtime.LogInfof(a.ctx, "Nuclei path updated to: %s (version: %s)", normalizedPath, version)
+	return nil
+}
+
+// InstallNucleiFromArchive installs nuclei from a local release archive (.zip or .tar.gz)
+// into ~/.wepoc/bin and uses it; opens a file dialog when path is empty
+func (a *App) InstallNucleiFromArchive(archivePath string) (*NucleiTestResult, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
+	}
+	if a.config == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	if archivePath == "" {
+		selected, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: "选择nuclei安装包",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "Archives (*.zip, *.tar.gz)", Pattern: "*.zip;*.tar.gz;*.tgz"},
+			},
+		})
+		if err != nil || selected == "" {
+			return nil, i18n.Errorf(i18n.ErrCancelled)
+		}
+		archivePath = selected
+	}
+
+	runtime.LogInfo(a.ctx, fmt.Sprintf("从本地压缩包安装nuclei: %s", archivePath))
+	path, version, err := config.InstallNucleiFromArchive(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	a.config.NucleiPath = path
+	if err := config.SaveConfig(a.config); err != nil {
+		return nil, fmt.Errorf("failed to save config: %w", err)
+	}
+	if a.jsonTaskManager != nil {
+		a.jsonTaskManager.UpdateConfig(a.config)
+	}
+	a.audit(scanner.AuditConfigSaved, 0, fmt.Sprintf("nuclei_path: %s (%s)", path, version))
+	return &NucleiTestResult{Valid: true, Version: version}, nil
+}
+
+// SetOfflineMode turns the air-gapped mode on or off: nuclei runs without update checks or
+// public interactsh servers, and features calling out to the internet are disabled
+func (a *App) SetOfflineMode(offline bool) error {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return err
+	}
+	if a.config == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	cfg := *a.config
+	cfg.Offline = offline
+	return a.SaveConfig(&cfg)
+}
+
+// GetConfigProfiles returns the named config profiles (secrets redacted for users that
+// cannot manage the config)
+func (a *App) GetConfigProfiles() []models.ConfigProfile {
+	return a.GetConfig().Profiles
+}
+
+// SaveConfigProfile adds a config profile or replaces the profile with the same name. The
+// nuclei path of the profile must be runnable; while the profile is active its settings
+// are applied to the config right away.
+func (a *App) SaveConfigProfile(profile models.ConfigProfile) error {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return err
+	}
+	if a.config == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	profile.Name = strings.TrimSpace(profile.Name)
+	if err := scanner.ValidateConfigProfile(profile); err != nil {
+		return err
+	}
+	if profile.NucleiPath != "" {
+		profile.NucleiPath = filepath.Clean(profile.NucleiPath)
+		if _, _, err := config.ValidateUserNucleiPath(profile.NucleiPath); err != nil {
+			return fmt.Errorf("invalid nuclei path: %w", err)
+		}
+	}
+
+	cfg := *a.config
+	cfg.Profiles = make([]models.ConfigProfile, 0, len(a.config.Profiles)+1)
+	replaced := false
+	for _, existing := range a.config.Profiles {
+		if strings.EqualFold(existing.Name, profile.Name) {
+			existing, replaced = profile, true
+		}
+		cfg.Profiles = append(cfg.Profiles, existing)
+	}
+	if !replaced {
+		cfg.Profiles = append(cfg.Profiles, profile)
+	}
+	target := &cfg
+	if strings.EqualFold(cfg.ActiveProfile, profile.Name) {
+		target = scanner.ApplyConfigProfile(&cfg, profile)
+	}
+	if err := a.SaveConfig(target); err != nil {
+		return err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("已保存配置方案: %s", profile.Name))
+	return nil
+}
+
+// SaveCurrentConfigProfile saves the current nuclei path, proxy, interactsh and rate
+// settings as a profile
+func (a *App) SaveCurrentConfigProfile(name, description string) error {
+	if a.config == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.SaveConfigProfile(scanner.ConfigProfileFromConfig(a.config, name, description))
+}
+
+// DeleteConfigProfile removes a config profile; the current settings are kept. Tasks still
+// referencing the profile fail to start until they are given another one.
+func (a *App) DeleteConfigProfile(name string) error {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return err
+	}
+	if a.config == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	cfg := *a.config
+	cfg.Profiles = make([]models.ConfigProfile, 0, len(a.config.Profiles))
+	for _, profile := range a.config.Profiles {
+		if !strings.EqualFold(profile.Name, name) {
+			cfg.Profiles = append(cfg.Profiles, profile)
+		}
+	}
+	if len(cfg.Profiles) == len(a.config.Profiles) {
+		return i18n.Errorf(i18n.ErrConfigProfileNotFound, name)
+	}
+	if strings.EqualFold(cfg.ActiveProfile, name) {
+		cfg.ActiveProfile = ""
+	}
+	if err := a.SaveConfig(&cfg); err != nil {
+		return err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("已删除配置方案: %s", name))
+	return nil
+}
+
+// SwitchConfigProfile applies the settings of a config profile to the config and the task
+// managers without restarting; running scans keep the settings they started with
+func (a *App) SwitchConfigProfile(name string) error {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return err
+	}
+	if a.config == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	profile, ok := scanner.LookupConfigProfile(a.config, name)
+	if !ok {
+		return i18n.Errorf(i18n.ErrConfigProfileNotFound, name)
+	}
+	if err := a.SaveConfig(scanner.ApplyConfigProfile(a.config, profile)); err != nil {
+		return err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("已切换到配置方案: %s", profile.Name))
+	runtime.EventsEmit(a.ctx, "config-profile-switched", profile.Name)
+	return nil
+}
+
+// ReloadConfig reloads the configuration and updates task managers
+func (a *App) ReloadConfig() error {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return err
+	}
+	return a.reloadConfig()
+}
+
+// reloadConfig reloads config.json, e.g. after the secrets were unlocked
+func (a *App) reloadConfig() error {
+	// Reload config from file
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+	
+	a.applyReloadedConfig(cfg)
+	runtime.LogInfof(a.ctx, "Configuration reloaded successfully")
+	return nil
+}
+
+// onConfigFileChanged applies a config.json edited outside the application
+func (a *App) onConfigFileChanged(cfg *models.Config) {
+	changed := config.ChangedFields(a.config, cfg)
+	if len(changed) == 0 {
+		return
+	}
+	a.applyReloadedConfig(cfg)
+	a.audit(scanner.AuditConfigReloaded, 0, strings.Join(changed, ", "))
+	runtime.LogInfo(a.ctx, fmt.Sprintf("检测到config.json被修改，已重新加载配置: %s", strings.Join(changed, ", ")))
+}
+
+// applyReloadedConfig hands a config loaded from disk to the task managers and tells the
+// frontend to refresh its views
+func (a *App) applyReloadedConfig(cfg *models.Config) {
+	a.config = cfg
+	i18n.SetLanguage(cfg.Language)
+
+	// Update JSONTaskManager config
+	if a.jsonTaskManager != nil {
+		a.jsonTaskManager.UpdateConfig(cfg)
+	}
+	a.applyResultEncryption(cfg.Security)
+
+	runtime.EventsEmit(a.ctx, "config-changed", a.GetConfig())
+}
+
+// GetAppInfo returns application information
+func (a *App) GetAppInfo() map[string]string {
+	return map[string]string{
+		"version": "1.0.0",
+		"name":    "WePOC",
+		"author":  "Security Team",
+	}
+}
+
+// RunDiagnostics checks nuclei, the templates directory, free disk space, the database,
+// the interactsh server and the configured proxies for the settings page
+func (a *App) RunDiagnostics() (*scanner.DiagnosticsReport, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
+	}
+	cfg := a.config
+	diagnostics := []scanner.Diagnostic{
+		{Name: "nuclei", Title: "Nuclei", Run: func(check *scanner.DiagnosticCheck) {
+			check.Detail = cfg.NucleiPath
+			_, version, err := config.CheckNucleiInstalled(cfg.NucleiPath)
+			if err != nil {
+				check.Status = scanner.DiagnosticError
+				check.Message = err.Error()
+				return
+			}
+			check.Message = fmt.Sprintf("版本 %s", version)
+		}},
+		{Name: "templates_dir", Title: "模板目录", Run: func(check *scanner.DiagnosticCheck) {
+			scanner.CheckDirWritable(check, cfg.POCDirectory)
+		}},
+		{Name: "disk_space", Title: "磁盘空间", Run: func(check *scanner.DiagnosticCheck) {
+			scanner.CheckDiskFree(check, cfg.ResultsDir)
+		}},
+		{Name: "database", Title: "数据库", Run: func(check *scanner.DiagnosticCheck) {
+			check.Detail = cfg.DatabasePath
+			if a.db == nil {
+				check.Status = scanner.DiagnosticError
+				check.Message = "数据库未初始化"
+				return
+			}
+			problems, err := a.db.IntegrityCheck()
+			if err != nil {
+				check.Status = scanner.DiagnosticError
+				check.Message = err.Error()
+				return
+			}
+			if len(problems) > 0 {
+				check.Status = scanner.DiagnosticError
+				check.Message = fmt.Sprintf("完整性检查发现 %d 个问题", len(problems))
+				check.Detail = strings.Join(problems, "\n")
+				return
+			}
+			check.Message = "完整性检查通过"
+		}},
+		{Name: "interactsh", Title: "Interactsh", Run: func(check *scanner.DiagnosticCheck) {
+			if cfg.NucleiConfig.InteractshDisable {
+				check.Status = scanner.DiagnosticSkipped
+				check.Message = "已禁用Interactsh"
+				return
+			}
+			if cfg.Offline && cfg.NucleiConfig.InteractshServer == "" {
+				check.Status = scanner.DiagnosticSkipped
+				check.Message = "离线模式，未配置自建Interactsh服务器"
+				return
+			}
+			result := scanner.TestInteractshServer(a.ctx, cfg.NucleiConfig.InteractshServer, cfg.NucleiConfig.InteractshToken)
+			check.Detail = result.Server
+			switch {
+			case !result.Registered:
+				check.Status = scanner.DiagnosticError
+				check.Message = fmt.Sprintf("注册失败: %s", result.Error)
+			case !result.RoundTrip:
+				check.Status = scanner.DiagnosticWarning
+				check.Message = "注册成功，但未收到测试交互，OOB类漏洞可能无法检出"
+			default:
+				check.Message = fmt.Sprintf("收到 %s 交互，耗时 %dms", strings.Join(result.Protocols, "/"), result.LatencyMs)
+			}
+		}},
+		{Name: "proxy", Title: "代理", Run: func(check *scanner.DiagnosticCheck) {
+			var proxies []string
+			if cfg.NucleiConfig.ProxyEnabled {
+				for _, proxyURL := range append([]string{cfg.NucleiConfig.ProxyURL}, cfg.NucleiConfig.ProxyList...) {
+					if strings.TrimSpace(proxyURL) != "" {
+						proxies = append(proxies, proxyURL)
+					}
+				}
+			}
+			if len(proxies) == 0 {
+				check.Status = scanner.DiagnosticSkipped
+				check.Message = "未启用代理"
+				return
+			}
+			if cfg.Offline {
+				check.Status = scanner.DiagnosticSkipped
+				check.Message = "离线模式，跳过代理测试"
+				return
+			}
+			results := a.testProxies(proxies)
+			var failed []string
+			for _, result := range results.Results {
+				if !result.Available {
+					failed = append(failed, fmt.Sprintf("%s: %s", result.URL, result.Error))
+				}
+			}
+			check.Message = fmt.Sprintf("%d/%d 个代理可用", results.Summary.Available, results.Summary.Total)
+			check.Detail = strings.Join(failed, "\n")
+			switch {
+			case results.Summary.Available == 0:
+				check.Status = scanner.DiagnosticError
+			case len(failed) > 0:
+				check.Status = scanner.DiagnosticWarning
+			}
+		}},
+	}
+
+	report := scanner.RunDiagnostics(diagnostics)
+	runtime.LogInfo(a.ctx, fmt.Sprintf("自检完成，状态: %s", report.Status))
+	return report, nil
+}
+
+// TestProxies tests the availability of proxy servers
+func (a *App) TestProxies(proxyList []string) (*ProxyTestResults, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
+	}
+	return a.testProxies(proxyList), nil
+}
+
+// testProxies tests each proxy of the list
+func (a *App) testProxies(proxyList []string) *ProxyTestResults {
+	results := &ProxyTestResults{
+		Results: make([]ProxyTestResult, 0, len(proxyList)),
+	}
+	
+	// Test each proxy
+	for _, proxyURL := range proxyList {
+		if strings.TrimSpace(proxyURL) == "" {
+			continue
+		}
+		
+		result := a.testSingleProxy(proxyURL)
+		results.Results = append(results.Results, result)
+		
+		if result.Available {
+			results.Summary.Available++
+		} else {
+			results.Summary.Failed++
+		}
+	}
+	
+	results.Summary.Total = len(results.Results)
+	return results
+}
+
+// testSingleProxy tests a single proxy server
+func (a *App) testSingleProxy(proxyURL string) ProxyTestResult {
+	result := ProxyTestResult{
+		URL: scanner.MaskProxyURL(proxyURL),
+	}
+	
+	// Parse proxy URL
+	parsedURL, err := url.Parse(proxyURL)
+	if err != nil {
+		result.Error = fmt.Sprintf("Invalid proxy URL: %v", err)
+		return result
+	}
+
+	// 代理测试需要访问外网的测试地址
+	if a.config != nil && a.config.Offline {
+		result.Error = i18n.Errorf(i18n.ErrOffline).Error()
+		return result
+	}
+
+	// Fall back to configured credentials when the URL has none
+	if parsedURL.User == nil && a.config != nil && a.config.NucleiConfig.ProxyUsername != "" {
+		parsedURL.User = url.UserPassword(a.config.NucleiConfig.ProxyUsername, a.config.NucleiConfig.ProxyPassword)
+	}
+	
+	// Create HTTP client with proxy
+	transport := &http.Transport{}
+	
+	// Set proxy based on scheme
+	switch parsedURL.Scheme {
+	case "http", "https":
+		// Proxy-Authorization is sent automatically from the URL user info
+		transport.Proxy = http.ProxyURL(parsedURL)
+	case "socks5", "socks5h":
+		// For SOCKS5, perform the full handshake (including authentication)
+		return a.testSOCKS5Proxy(parsedURL)
+	default:
+		result.Error = "Unsupported proxy scheme"
+		return result
+	}
+	
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   10 * time.Second,
+	}
+	
+	// Test proxy by making a request to a test URL
+	start := time.Now()
+	resp, err := client.Get("http://httpbin.org/ip")
+	responseTime := time.Since(start).Milliseconds()
+	
+	if err != nil {
+		result.Error = fmt.Sprintf("Connection failed: %v", err)
+		result.ResponseTime = responseTime
+		return result
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode == 200 {
+		result.Available = true
+		result.ResponseTime = responseTime
+	} else if resp.StatusCode == http.StatusProxyAuthRequired {
+		result.Error = "Proxy authentication required or credentials rejected (HTTP 407)"
+		result.ResponseTime = responseTime
+	} else {
+		result.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		result.ResponseTime = responseTime
+	}
+	
+	return result
+}
+
+// testSOCKS5Proxy tests SOCKS5 proxy by performing the handshake and fetching a test URL through it
+func (a *App) testSOCKS5Proxy(parsedURL *url.URL) ProxyTestResult {
+	result := ProxyTestResult{
+		URL: scanner.MaskProxyURL(parsedURL.String()),
+	}
+	
+	// Extract host and port
+	host := parsedURL.Hostname()
+	port := parsedURL.Port()
+	if port == "" {
+		port = "1080" // Default SOCKS5 port
+	}
+
+	username, password := "", ""
+	if parsedURL.User != nil {
+		username = parsedURL.User.Username()
+		password, _ = parsedURL.User.Password()
+	}
+	
+	ctx, cancel := context.WithTimeout(a.ctx, 10*time.Second)
+	defer cancel()
+
+	// Handshake + CONNECT to the test host
+	start := time.Now()
+	conn, err := scanner.DialSOCKS5(ctx, net.JoinHostPort(host, port), username, password, "httpbin.org:80")
+	if err != nil {
+		result.Error = err.Error()
+		result.ResponseTime = time.Since(start).Milliseconds()
+		return result
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Write([]byte("GET /ip HTTP/1.1\r\nHost: httpbin.org\r\nConnection: close\r\n\r\n")); err != nil {
+		result.Error = fmt.Sprintf("Request through proxy failed: %v", err)
+		result.ResponseTime = time.Since(start).Milliseconds()
+		return result
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	result.ResponseTime = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = fmt.Sprintf("Request through proxy failed: %v", err)
+		return result
+	}
+	resp.Body.Close()
+	
+	result.Available = true
+	return result
+}
+
+// GetTaskHTTPLogs returns HTTP request logs for a specific task
+func (a *App) GetTaskHTTPLogs(taskID int64) ([]*scanner.HTTPRequestLog, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("获取任务 %d 的HTTP请求日志", taskID))
+	return a.jsonTaskManager.GetHTTPRequestLogs(taskID)
+}
+
+// GetTaskTimeline returns the execution milestones of a task (created, queued, started, first
+// request, first vuln, template batches, completion) for the result page timeline
+func (a *App) GetTaskTimeline(taskID int64) ([]*scanner.TimelineEvent, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	return a.jsonTaskManager.GetTaskTimeline(taskID)
+}
+
+// GetTaskHTTPLogFullResponse returns the complete response of a logged HTTP request that
+// was truncated or had its binary body omitted (needs http_logs.keep_full_bodies)
+func (a *App) GetTaskHTTPLogFullResponse(taskID int64, requestID int64) (string, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return "", err
+	}
+	return a.jsonTaskManager.GetHTTPLogFullResponse(taskID, requestID)
+}
+
+// ReplayRequest re-sends a logged HTTP request (honoring proxy settings) and stores the fresh response
+func (a *App) ReplayRequest(taskID int64, requestID int64) (*scanner.ReplayEntry, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("重放任务 %d 的HTTP请求 #%d", taskID, requestID))
+	return a.jsonTaskManager.ReplayHTTPRequest(a.ctx, taskID, requestID)
+}
+
+// SendRepeaterRequest sends an edited raw request with custom target, TLS and redirect options
+func (a *App) SendRepeaterRequest(params scanner.RepeaterRequest) (*scanner.ReplayEntry, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("发送自定义请求: task=%d target=%s", params.TaskID, params.Target))
+	return a.jsonTaskManager.SendRepeaterRequest(a.ctx, params)
+}
+
+// GetTaskReplays returns all replayed requests for a specific task
+func (a *App) GetTaskReplays(taskID int64) ([]*scanner.ReplayEntry, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	return a.jsonTaskManager.GetReplayEntries(taskID)
+}
+
+// GetRequestAsCurl returns a curl command equivalent to a logged HTTP request
+func (a *App) GetRequestAsCurl(taskID int64, requestID int64) (string, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return "", err
+	}
+	return a.jsonTaskManager.GetRequestAsCurl(taskID, requestID)
+}
+
+// GetFindingAsCurl returns a curl command reproducing a finding of a task
+func (a *App) GetFindingAsCurl(taskID int64, findingIndex int) (string, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return "", err
+	}
+	return a.jsonTaskManager.GetFindingAsCurl(taskID, findingIndex)
+}
+
+// GetTaskNucleiCommand returns the nuclei command line, template files and targets used by
+// the last scan of a task, for reproducing it outside wepoc
+func (a *App) GetTaskNucleiCommand(taskID int64) (*scanner.NucleiCommand, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetTaskNucleiCommand(taskID)
+}
+
+// AddFindingAttachment attaches an evidence file (screenshot, exported packet) or a note to a
+// finding; opens a file dialog when both path and note are empty
+func (a *App) AddFindingAttachment(taskID int64, findingIndex int, path string, note string) (*models.FindingAttachment, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	if path == "" && note == "" {
+		selected, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: "选择漏洞证据文件",
+		})
+		if err != nil || selected == "" {
+			return nil, i18n.Errorf(i18n.ErrCancelled)
+		}
+		path = selected
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("为任务 %d 的漏洞 #%d 添加附件: %s", taskID, findingIndex, path))
+	return a.jsonTaskManager.AddFindingAttachment(taskID, findingIndex, path, note)
+}
+
+// GetFindingAttachments returns the attachments of a finding without file contents
+func (a *App) GetFindingAttachments(taskID int64, findingIndex int) ([]*models.FindingAttachment, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetFindingAttachments(taskID, findingIndex)
+}
+
+// ReadFindingAttachment returns an attachment with its file content (e.g. to show a screenshot)
+func (a *App) ReadFindingAttachment(id int64) (*models.FindingAttachment, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.ReadFindingAttachment(id)
+}
+
+// DeleteFindingAttachment removes an attachment of a finding
+func (a *App) DeleteFindingAttachment(id int64) error {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return err
+	}
+	if a.jsonTaskManager == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.DeleteFindingAttachment(id)
+}
+
+// VerifyFinding re-runs the template of a finding against its matched-at URL and records
+// whether it is still vulnerable on the finding
+func (a *App) VerifyFinding(taskID int64, findingIndex int) (*models.FindingVerification, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("复核任务 %d 的漏洞 #%d", taskID, findingIndex))
+	return a.jsonTaskManager.VerifyFinding(a.ctx, taskID, findingIndex)
+}
+
+// SendRequestToBurp forwards a logged HTTP request to the configured Burp proxy listener
+func (a *App) SendRequestToBurp(taskID int64, requestID int64) (*scanner.ReplayEntry, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("发送任务 %d 的HTTP请求 #%d 到Burp", taskID, requestID))
+	return a.jsonTaskManager.SendRequestToBurp(a.ctx, taskID, requestID)
+}
+
+// SendFindingToBurp forwards the request of a finding to the configured Burp proxy listener
+func (a *App) SendFindingToBurp(taskID int64, findingIndex int) (*scanner.ReplayEntry, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("发送任务 %d 的漏洞 #%d 到Burp", taskID, findingIndex))
+	return a.jsonTaskManager.SendFindingToBurp(a.ctx, taskID, findingIndex)
+}
+
+// GenerateOOBPayload returns an interactsh host for manual testing, correlated to the task/template/target
+func (a *App) GenerateOOBPayload(taskID int64, templateID string, target string) (string, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return "", err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("生成任务 %d 的OOB载荷: template=%s target=%s", taskID, templateID, target))
+	return a.jsonTaskManager.GenerateOOBPayload(taskID, templateID, target)
+}
+
+// GetOOBInteractions returns the DNS/HTTP/SMTP interactions recorded for a task
+func (a *App) GetOOBInteractions(taskID int64) ([]*scanner.OOBInteraction, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	return a.jsonTaskManager.GetOOBInteractions(taskID)
+}
+
+// StopOOBSession stops polling the interactsh session of a task
+func (a *App) StopOOBSession(taskID int64) error {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return err
+	}
+	if a.jsonTaskManager == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	a.jsonTaskManager.StopOOBSession(taskID)
+	return nil
+}
+
+// TestInteractshServer checks that an interactsh server can register a session and receive interactions
+func (a *App) TestInteractshServer(server string, token string) (*scanner.InteractshTestResult, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("测试Interactsh服务器: %s", server))
+	return scanner.TestInteractshServer(a.ctx, server, token), nil
+}
+
+// GetForwardingStatus returns the delivery counters of the SIEM forwarding connectors
+func (a *App) GetForwardingStatus() ([]*scanner.ForwarderStatus, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetForwardingStatus(), nil
+}
+
+// TestForwarding sends a test event through a forwarding connector (syslog/splunk/elasticsearch/eventbus)
+// of the given, possibly unsaved, config
+func (a *App) TestForwarding(cfg models.ForwardingConfig, connector string) (*scanner.ForwardTestResult, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("测试结果转发: %s", connector))
+	return scanner.TestForwardConnector(a.ctx, cfg, connector), nil
+}
+
+// ImportWordlist imports a payload file into the wordlist library; opens a file dialog when path is empty
+func (a *App) ImportWordlist(path string) (*scanner.WordlistInfo, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
+	}
+	if a.wordlistManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+
+	if path == "" {
+		selected, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: "导入字典",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "Wordlists (*.txt, *.lst, *.dic)", Pattern: "*.txt;*.lst;*.dic"},
+				{DisplayName: "All Files", Pattern: "*"},
+			},
+		})
+		if err != nil || selected == "" {
+			return nil, i18n.Errorf(i18n.ErrCancelled)
+		}
+		path = selected
+	}
+
+	runtime.LogInfo(a.ctx, fmt.Sprintf("导入字典: %s", path))
+	return a.wordlistManager.Import(path, "")
+}
+
+// ListWordlists returns all wordlists in ~/.wepoc/wordlists
+func (a *App) ListWordlists() ([]*scanner.WordlistInfo, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.wordlistManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.wordlistManager.List()
+}
+
+// PreviewWordlist returns the first lines of a wordlist
+func (a *App) PreviewWordlist(name string, maxLines int) (*scanner.WordlistPreview, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.wordlistManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.wordlistManager.Preview(name, maxLines)
+}
+
+// DeleteWordlist removes a wordlist from the library
+func (a *App) DeleteWordlist(name string) error {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return err
+	}
+	if a.wordlistManager == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("删除字典: %s", name))
+	return a.wordlistManager.Delete(name)
+}
+
+// GetCredentialDictionary returns the default-credential dictionary (service -> username/password pairs)
+func (a *App) GetCredentialDictionary() (*scanner.CredentialDictionary, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetCredentialDictionary()
+}
+
+// SetServiceCredentials replaces the default credentials of a service
+func (a *App) SetServiceCredentials(service string, pairs []scanner.CredentialPair) (*scanner.CredentialDictionary, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("更新凭证字典: %s (%d 条)", service, len(pairs)))
+	dictionary, err := a.jsonTaskManager.SetServiceCredentials(service, pairs)
+	if err != nil {
+		return nil, err
+	}
+	a.audit(scanner.AuditCredentialsChanged, 0, fmt.Sprintf("%s: %d pairs", service, len(pairs)))
+	return dictionary, nil
+}
+
+// DeleteServiceCredentials removes a service from the credential dictionary
+func (a *App) DeleteServiceCredentials(service string) (*scanner.CredentialDictionary, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("删除凭证字典服务: %s", service))
+	dictionary, err := a.jsonTaskManager.DeleteServiceCredentials(service)
+	if err != nil {
+		return nil, err
+	}
+	a.audit(scanner.AuditCredentialsChanged, 0, service+" deleted")
+	return dictionary, nil
+}
+
+// DryRunTask previews a task: templates that will load, estimated request count and duration
+func (a *App) DryRunTask(taskID int64) (*scanner.DryRunReport, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("预演任务 %d", taskID))
+	return a.jsonTaskManager.DryRunTask(taskID)
 }
 
-// ListResultFiles lists all result files in the results directory
-func (a *App) ListResultFiles() ([]map[string]interface{}, error) {
-	// This will be implemented to scan the results directory
-	// and return metadata about each result file
-	return nil, nil
+// GetTaskErrorSummary returns the scan errors of a task counted by category (DNS, TLS, proxy...)
+func (a *App) GetTaskErrorSummary(taskID int64) (*scanner.TaskErrorSummary, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetTaskErrorSummary(taskID)
 }
 
-// ============ Utility Methods ============
+// GetTaskEvents returns the scan events of a task after the given sequence number (0 for all),
+// letting the frontend catch up on events it missed
+func (a *App) GetTaskEvents(taskID int64, afterSeq int64) ([]*scanner.ScanEvent, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetTaskEvents(taskID, afterSeq)
+}
 
-// CheckNucleiInstalled checks if nuclei is installed
-func (a *App) CheckNucleiInstalled() (bool, string, error) {
-	return config.CheckNucleiInstalled(a.config.NucleiPath)
+// TailTaskLog returns the raw nuclei output lines of a task written after fromOffset; pass
+// the returned offset to the next call
+func (a *App) TailTaskLog(taskID int64, fromOffset int64) (*scanner.TaskLogTail, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.TailTaskLog(taskID, fromOffset)
 }
 
-// ValidateNucleiPath validates the current nuclei path and tries to fix it if needed
-func (a *App) ValidateNucleiPath() error {
-	if err := config.ValidateNucleiPath(a.config); err != nil {
+// SetTaskLogStreaming turns "task-log-line" events with the raw nuclei output of a task on or off
+func (a *App) SetTaskLogStreaming(taskID int64, enabled bool) error {
+	if err := a.authorize(auth.PermRunScans); err != nil {
 		return err
 	}
-	
-	// Save the updated config if it was changed
-	return config.SaveConfig(a.config)
+	if a.jsonTaskManager == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	a.jsonTaskManager.SetTaskLogStreaming(taskID, enabled)
+	return nil
 }
 
-// NucleiTestResult represents the result of testing a nuclei path
-type NucleiTestResult struct {
-	Valid   bool   `json:"valid"`
-	Version string `json:"version"`
-	Error   string `json:"error,omitempty"`
+// GetLiveProgress returns the current progress of a task; for running tasks it includes the
+// last event sequence number so missed events can be fetched with GetTaskEvents
+func (a *App) GetLiveProgress(taskID int64) (*scanner.LiveProgress, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetLiveProgress(taskID)
 }
 
-// ProxyTestResult represents the result of a proxy test
-type ProxyTestResult struct {
-	URL         string `json:"url"`
-	Available   bool   `json:"available"`
-	ResponseTime int64  `json:"response_time"` // in milliseconds
-	Error       string `json:"error,omitempty"`
+// ReattachScanEvents re-registers the event handlers of all running tasks (call after a page
+// reload) and returns their live progress
+func (a *App) ReattachScanEvents() ([]*scanner.LiveProgress, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, nil
+	}
+	live := a.jsonTaskManager.ReattachEventHandlers(func(event *scanner.ScanEvent) {
+		runtime.EventsEmit(a.ctx, "scan-event", event)
+	})
+	runtime.LogInfo(a.ctx, fmt.Sprintf("重新关联 %d 个运行中任务的事件", len(live)))
+	return live, nil
 }
 
-// ProxyTestResults represents the results of testing multiple proxies
-type ProxyTestResults struct {
-	Results []ProxyTestResult `json:"results"`
-	Summary struct {
-		Total     int `json:"total"`
-		Available int `json:"available"`
-		Failed    int `json:"failed"`
-	} `json:"summary"`
+// ListEnhancedLogs lists the structured log files in ~/.wepoc/logs/enhanced, newest first
+func (a *App) ListEnhancedLogs() ([]*scanner.EnhancedLogFile, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	return scanner.ListEnhancedLogs()
 }
 
-// TestNucleiPath tests a user-specified nuclei path
-func (a *App) TestNucleiPath(userPath string) NucleiTestResult {
-	valid, version, err := config.ValidateUserNucleiPath(userPath)
-	result := NucleiTestResult{
-		Valid:   valid,
-		Version: version,
+// ReadEnhancedLog returns the last limit entries of an enhanced log file (all when limit <= 0)
+func (a *App) ReadEnhancedLog(name string, limit int) ([]*scanner.EnhancedLogEntry, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	return scanner.ReadEnhancedLog(name, limit)
+}
+
+// DeleteEnhancedLogs deletes enhanced log files and returns how many were removed
+func (a *App) DeleteEnhancedLogs(names []string) (int, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return 0, err
+	}
+	deleted, err := scanner.DeleteEnhancedLogs(names)
+	runtime.LogInfo(a.ctx, fmt.Sprintf("删除 %d 个增强日志文件", deleted))
+	return deleted, err
+}
+
+// GetRateProfiles returns the built-in scan rate profiles selectable per task
+func (a *App) GetRateProfiles() []scanner.RateProfile {
+	return scanner.RateProfiles
+}
+
+// GetDiskUsage returns the disk usage of the wepoc workspace per directory
+func (a *App) GetDiskUsage() (*scanner.DiskUsage, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetDiskUsage()
+}
+
+// CleanWorkspace removes leftover temp directories and files of deleted tasks and reports
+// the reclaimed space
+func (a *App) CleanWorkspace() (*scanner.CleanupReport, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
 	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	report, err := a.jsonTaskManager.CleanWorkspace()
 	if err != nil {
-		result.Error = err.Error()
+		return nil, err
 	}
-	return result
+	runtime.LogInfo(a.ctx, fmt.Sprintf("清理工作区完成，释放 %s", report.Reclaimed))
+	return report, nil
 }
 
-// SetNucleiPath sets a new nuclei path after validation
-func (a *App) SetNucleiPath(newPath string) error {
-	// Normalize the path
-	normalizedPath := filepath.Clean(newPath)
-	
-	// First validate the new path
-	valid, version, err := config.ValidateUserNucleiPath(normalizedPath)
+// GetStorageReport returns the workspace usage broken down by tasks/results/logs/templates
+func (a *App) GetStorageReport() (*scanner.StorageReport, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.jsonTaskManager.GetStorageReport()
+}
+
+// ApplyRetentionPolicy deletes and archives finished tasks according to the retention settings
+func (a *App) ApplyRetentionPolicy() (*scanner.RetentionReport, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	report, err := a.jsonTaskManager.ApplyRetention()
 	if err != nil {
-		return fmt.Errorf("invalid nuclei path: %w", err)
+		return nil, err
 	}
-	
-	if !valid {
-		return fmt.Errorf("nuclei path validation failed")
+	runtime.LogInfo(a.ctx, fmt.Sprintf("保留策略: 删除 %d 个任务, 归档 %d 个任务, 释放 %s", len(report.DeletedTasks), len(report.ArchivedTasks), report.Reclaimed))
+	return report, nil
+}
+
+// ExportWorkspace backs up config, template database, templates and task results into a
+// single zip archive; opens a save dialog when path is empty
+func (a *App) ExportWorkspace(path string) (*config.BackupResult, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
 	}
-	
-	// Update the config with normalized path
-	a.config.NucleiPath = normalizedPath
-	
-	// Save the updated config
-	if err := config.SaveConfig(a.config); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
+	if a.db == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
 	}
-	
-	// Update JSONTaskManager config
-	if a.jsonTaskManager != nil {
-		a.jsonTaskManager.UpdateConfig(a.config)
+	if path == "" {
+		selected, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			DefaultFilename: fmt.Sprintf("wepoc_backup_%s.zip", time.Now().Format("20060102_150405")),
+			Title:           "备份工作区",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "Zip Files (*.zip)", Pattern: "*.zip"},
+			},
+		})
+		if err != nil || selected == "" {
+			return nil, i18n.Errorf(i18n.ErrCancelled)
+		}
+		path = selected
+	}
+
+	runtime.LogInfo(a.ctx, fmt.Sprintf("备份工作区到: %s", path))
+	result, err := config.ExportWorkspace(path, a.db.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	a.audit(scanner.AuditExported, 0, "workspace to "+path)
+	return result, nil
+}
+
+// ImportWorkspace restores a workspace backup (the current workspace is snapshotted first);
+// opens a file dialog when path is empty
+func (a *App) ImportWorkspace(path string) (*config.BackupResult, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
+	}
+	if a.db == nil || a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	if len(a.jsonTaskManager.RunningTaskIDs()) > 0 {
+		return nil, fmt.Errorf("有任务正在扫描，请先停止所有任务再恢复工作区")
+	}
+	if path == "" {
+		selected, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: "恢复工作区",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "Zip Files (*.zip)", Pattern: "*.zip"},
+			},
+		})
+		if err != nil || selected == "" {
+			return nil, i18n.Errorf(i18n.ErrCancelled)
+		}
+		path = selected
+	}
+
+	runtime.LogInfo(a.ctx, fmt.Sprintf("从备份恢复工作区: %s", path))
+	result, err := config.ImportWorkspace(path, a.db.Snapshot, func(dbPath, sourceDir string) error {
+		if err := a.db.Restore(dbPath); err != nil {
+			return err
+		}
+		wepocDir, err := config.GetWepocDir()
+		if err != nil {
+			return err
+		}
+		if err := a.db.RebaseTemplatePaths(sourceDir, wepocDir); err != nil {
+			return err
+		}
+		return a.db.RebaseTaskPaths(sourceDir, wepocDir)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 重新加载恢复后的配置和任务
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load restored config: %w", err)
+	}
+	if err := config.ValidateNucleiPath(cfg); err != nil {
+		runtime.LogWarningf(a.ctx, "Nuclei path validation failed: %v", err)
+	}
+	a.config = cfg
+	a.jsonTaskManager.UpdateConfig(cfg)
+	if err := a.jsonTaskManager.ReloadTasks(); err != nil {
+		return nil, err
+	}
+
+	a.audit(scanner.AuditWorkspaceRestored, 0, path)
+	runtime.LogInfo(a.ctx, fmt.Sprintf("工作区恢复完成，恢复前的快照: %s", result.SafetySnapshot))
+	return result, nil
+}
+
+// ExportTaskJSON writes a task with its result and HTTP request logs to a JSON file;
+// opens a save dialog when path is empty
+func (a *App) ExportTaskJSON(taskID int64, path string) (string, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return "", err
+	}
+	if a.jsonTaskManager == nil {
+		return "", i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	if path == "" {
+		selected, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			DefaultFilename: fmt.Sprintf("wepoc_task_%d.json", taskID),
+			Title:           "导出任务数据",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "JSON Files (*.json)", Pattern: "*.json"},
+			},
+		})
+		if err != nil || selected == "" {
+			return "", i18n.Errorf(i18n.ErrCancelled)
+		}
+		path = selected
+	}
+
+	runtime.LogInfo(a.ctx, fmt.Sprintf("导出任务 %d 到: %s", taskID, path))
+	if err := a.jsonTaskManager.ExportTaskJSON(taskID, path); err != nil {
+		return "", err
+	}
+	a.audit(scanner.AuditExported, taskID, "task to "+path)
+	return path, nil
+}
+
+// ExportDebugBundle zips the config, logs and nuclei stderr of a task together with
+// environment info (secrets redacted) for attaching to an issue; opens a save dialog when
+// path is empty
+func (a *App) ExportDebugBundle(taskID int64, path string) (*scanner.DebugBundleResult, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.jsonTaskManager == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	if path == "" {
+		selected, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			DefaultFilename: fmt.Sprintf("wepoc_debug_task_%d_%s.zip", taskID, time.Now().Format("20060102_150405")),
+			Title:           "导出诊断包",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "Zip Files (*.zip)", Pattern: "*.zip"},
+			},
+		})
+		if err != nil || selected == "" {
+			return nil, i18n.Errorf(i18n.ErrCancelled)
+		}
+		path = selected
+	}
+
+	secrets := config.SecretValues(a.config)
+	redacted := config.RedactedConfig(a.config)
+	redacted.NucleiConfig.ProxyURL = scanner.MaskProxyURL(a.config.NucleiConfig.ProxyURL)
+	redacted.NucleiConfig.ProxyList = make([]string, len(a.config.NucleiConfig.ProxyList))
+	for i, proxyURL := range append([]string{a.config.NucleiConfig.ProxyURL}, a.config.NucleiConfig.ProxyList...) {
+		if i > 0 {
+			redacted.NucleiConfig.ProxyList[i-1] = scanner.MaskProxyURL(proxyURL)
+		}
+		if parsed, err := url.Parse(proxyURL); err == nil && parsed.User != nil {
+			if password, ok := parsed.User.Password(); ok && password != "" {
+				secrets = append(secrets, password)
+			}
+		}
+	}
+
+	_, nucleiVersion, nucleiErr := config.CheckNucleiInstalled(a.config.NucleiPath)
+	environment := map[string]interface{}{
+		"app":            a.GetAppInfo(),
+		"os":             goruntime.GOOS,
+		"arch":           goruntime.GOARCH,
+		"go_version":     goruntime.Version(),
+		"cpus":           goruntime.NumCPU(),
+		"nuclei_path":    a.config.NucleiPath,
+		"nuclei_version": nucleiVersion,
+		"config":         redacted,
+		"generated_at":   time.Now(),
+	}
+	if nucleiErr != nil {
+		environment["nuclei_error"] = nucleiErr.Error()
+	}
+
+	runtime.LogInfo(a.ctx, fmt.Sprintf("导出任务 %d 的诊断包到: %s", taskID, path))
+	result, err := a.jsonTaskManager.ExportDebugBundle(taskID, path, environment, secrets)
+	if err != nil {
+		return nil, err
+	}
+	a.audit(scanner.AuditExported, taskID, "debug bundle to "+path)
+	return result, nil
+}
+
+// GetTemplateDetail returns the parsed info, requests, matchers, extractors and required
+// variables of a template
+func (a *App) GetTemplateDetail(templateID string) (*scanner.TemplateDetail, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.db == nil || a.templateParser == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	template, err := a.db.GetTemplateByTemplateID(templateID)
+	if err != nil {
+		return nil, err
+	}
+	detail, err := a.templateParser.ParseTemplateDetail(template.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	detail.Template = template
+	return detail, nil
+}
+
+// GetWorkflows returns the workflows of the template library, listed separately from templates
+func (a *App) GetWorkflows(sortBy string, filter models.TemplateFilter) ([]*models.Template, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	filter.Kind = models.TemplateKindWorkflow
+	return a.db.ListTemplates(sortBy, filter)
+}
+
+// GetWorkflowDetail returns the steps of a workflow and which referenced templates are in the library
+func (a *App) GetWorkflowDetail(templateID string) (*scanner.WorkflowDetail, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.db == nil || a.templateParser == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	template, err := a.db.GetTemplateByTemplateID(templateID)
+	if err != nil {
+		return nil, err
+	}
+	detail, err := a.templateParser.ParseWorkflow(template.FilePath, a.config.POCDirectory)
+	if err != nil {
+		return nil, err
+	}
+	detail.Template = template
+	return detail, nil
+}
+
+// LintTemplate checks a template of the library for quality problems beyond nuclei -validate
+func (a *App) LintTemplate(templateID string) (*scanner.TemplateLintResult, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if a.db == nil || a.templateParser == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	template, err := a.db.GetTemplateByTemplateID(templateID)
+	if err != nil {
+		return nil, err
+	}
+	return a.templateParser.LintTemplate(template.FilePath)
+}
+
+// templateDrafter creates the AI template drafter; drafts are kept in ~/.wepoc/drafts
+func (a *App) templateDrafter() (*scanner.TemplateDrafter, error) {
+	if a.templateParser == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	wepocDir, err := config.GetWepocDir()
+	if err != nil {
+		return nil, err
+	}
+	return scanner.NewTemplateDrafter(a.config.AI, a.templateParser, a.config.NucleiPath, filepath.Join(wepocDir, "drafts")), nil
+}
+
+// DraftTemplateFromAdvisory asks the configured LLM to draft a template from a CVE
+// description or advisory text and validates it. The draft is not added to the template
+// library; the frontend opens it in the POC editor (template-draft-ready event) for review.
+func (a *App) DraftTemplateFromAdvisory(advisory string) (*scanner.TemplateDraft, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
+	drafter, err := a.templateDrafter()
+	if err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, "使用AI生成模板草稿")
+	draft, err := drafter.Draft(advisory)
+	if err != nil {
+		return nil, err
+	}
+	runtime.EventsEmit(a.ctx, "template-draft-ready", draft)
+	return draft, nil
+}
+
+// SaveTemplateDraft saves an edited draft and validates it again
+func (a *App) SaveTemplateDraft(draftPath string, content string) (*scanner.TemplateDraft, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
+	drafter, err := a.templateDrafter()
+	if err != nil {
+		return nil, err
+	}
+	return drafter.SaveDraft(draftPath, content)
+}
+
+// AcceptTemplateDraft adds a reviewed draft to the template library after it passes
+// nuclei -validate
+func (a *App) AcceptTemplateDraft(draftPath string) (*models.Template, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	drafter, err := a.templateDrafter()
+	if err != nil {
+		return nil, err
+	}
+	template, err := drafter.Accept(draftPath, a.config.POCDirectory)
+	if err != nil {
+		return nil, err
 	}
-	
-	runtime.LogInfof(a.ctx, "Nuclei path updated to: %s (version: %s)", normalizedPath, version)
-	return nil
+	if err := a.db.InsertTemplate(template); err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("AI模板草稿已加入模板库: %s", template.TemplateID))
+	a.audit(scanner.AuditTemplatesImported, 0, "draft "+template.TemplateID)
+	return template, nil
 }
 
-// ReloadConfig reloads the configuration and updates task managers
-func (a *App) ReloadConfig() error {
-	// Reload config from file
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return fmt.Errorf("failed to reload config: %w", err)
-	}
-	
-	// Update app config
-	a.config = cfg
-	
-	// Update JSONTaskManager config
-	if a.jsonTaskManager != nil {
-		a.jsonTaskManager.UpdateConfig(cfg)
+// DiscardTemplateDraft deletes a draft
+func (a *App) DiscardTemplateDraft(draftPath string) error {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return err
 	}
-	
-	// Update legacy task manager config
-	if a.taskManager != nil {
-		a.taskManager.UpdateConfig(cfg)
+	drafter, err := a.templateDrafter()
+	if err != nil {
+		return err
 	}
-	
-	runtime.LogInfof(a.ctx, "Configuration reloaded successfully")
-	return nil
+	return drafter.Discard(draftPath)
 }
 
-// GetAppInfo returns application information
-func (a *App) GetAppInfo() map[string]string {
-	return map[string]string{
-		"version": "1.0.0",
-		"name":    "WePOC",
-		"author":  "Security Team",
+// GetTemplateLibraryStats returns template counts by severity, tag and protocol and the
+// most recently added templates; topTags and recent limit the lists (0 = 50 and 10)
+func (a *App) GetTemplateLibraryStats(topTags, recent int) (*models.TemplateLibraryStats, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
 	}
+	if a.db == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	return a.db.GetTemplateLibraryStats(topTags, recent)
 }
 
-// TestProxies tests the availability of proxy servers
-func (a *App) TestProxies(proxyList []string) *ProxyTestResults {
-	results := &ProxyTestResults{
-		Results: make([]ProxyTestResult, 0, len(proxyList)),
+// GetTemplateEffectiveness returns how often each template was scanned and how many findings
+// it produced across all tasks, sorted by sortBy (default: highest hit rate first). Use
+// filter.NeverFired to find templates that never matched.
+func (a *App) GetTemplateEffectiveness(sortBy string, filter models.TemplateEffectivenessFilter) ([]*models.TemplateEffectiveness, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
 	}
-	
-	// Test each proxy
-	for _, proxyURL := range proxyList {
-		if strings.TrimSpace(proxyURL) == "" {
-			continue
-		}
-		
-		result := a.testSingleProxy(proxyURL)
-		results.Results = append(results.Results, result)
-		
-		if result.Available {
-			results.Summary.Available++
-		} else {
-			results.Summary.Failed++
-		}
+	if a.db == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
 	}
-	
-	results.Summary.Total = len(results.Results)
-	return results
+	return a.db.GetTemplateEffectiveness(sortBy, filter)
 }
 
-// testSingleProxy tests a single proxy server
-func (a *App) testSingleProxy(proxyURL string) ProxyTestResult {
-	result := ProxyTestResult{
-		URL: proxyURL,
+// SetTemplateTrust sets the trust level (official/internal/unverified) of templates
+func (a *App) SetTemplateTrust(templateIDs []string, trust string) (int, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return 0, err
 	}
-	
-	// Parse proxy URL
-	parsedURL, err := url.Parse(proxyURL)
-	if err != nil {
-		result.Error = fmt.Sprintf("Invalid proxy URL: %v", err)
-		return result
+	if a.db == nil {
+		return 0, i18n.Errorf(i18n.ErrNotInitialized)
 	}
-	
-	// Create HTTP client with proxy
-	transport := &http.Transport{}
-	
-	// Set proxy based on scheme
-	switch parsedURL.Scheme {
-	case "http", "https":
-		transport.Proxy = http.ProxyURL(parsedURL)
-	case "socks5":
-		// For SOCKS5, we'll test TCP connection directly
-		return a.testSOCKS5Proxy(parsedURL)
+	switch trust {
+	case models.TrustOfficial, models.TrustInternal, models.TrustUnverified:
 	default:
-		result.Error = "Unsupported proxy scheme"
-		return result
-	}
-	
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   10 * time.Second,
+		return 0, fmt.Errorf("未知的信任级别: %s", trust)
 	}
-	
-	// Test proxy by making a request to a test URL
-	start := time.Now()
-	resp, err := client.Get("http://httpbin.org/ip")
-	responseTime := time.Since(start).Milliseconds()
-	
+	runtime.LogInfo(a.ctx, fmt.Sprintf("设置 %d 个模板的信任级别: %s", len(templateIDs), trust))
+	count, err := a.db.SetTemplateTrust(templateIDs, trust)
 	if err != nil {
-		result.Error = fmt.Sprintf("Connection failed: %v", err)
-		result.ResponseTime = responseTime
-		return result
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode == 200 {
-		result.Available = true
-		result.ResponseTime = responseTime
-	} else {
-		result.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
-		result.ResponseTime = responseTime
+		return 0, err
 	}
-	
-	return result
+	a.audit(scanner.AuditTemplateEdited, 0, fmt.Sprintf("trust of %d templates: %s", count, trust))
+	return count, nil
 }
 
-// testSOCKS5Proxy tests SOCKS5 proxy by attempting TCP connection
-func (a *App) testSOCKS5Proxy(parsedURL *url.URL) ProxyTestResult {
-	result := ProxyTestResult{
-		URL: parsedURL.String(),
+// GenerateTemplateSigningKey creates an Ed25519 key pair for signing template directories;
+// add the public key to the trusted signers and keep the private key safe
+func (a *App) GenerateTemplateSigningKey() (*scanner.SigningKeyPair, error) {
+	if err := a.authorize(auth.PermManageConfig); err != nil {
+		return nil, err
 	}
-	
-	// Extract host and port
-	host := parsedURL.Hostname()
-	port := parsedURL.Port()
-	if port == "" {
-		port = "1080" // Default SOCKS5 port
+	return scanner.GenerateSigningKey()
+}
+
+// SignTemplateDirectory writes a signed manifest (wepoc-manifest.json) into a template
+// directory; an empty dirPath opens a directory dialog
+func (a *App) SignTemplateDirectory(dirPath, signer, privateKey string) (*scanner.TemplateManifest, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
 	}
-	
-	// Test TCP connection
-	start := time.Now()
-	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), 10*time.Second)
-	responseTime := time.Since(start).Milliseconds()
-	
-	if err != nil {
-		result.Error = fmt.Sprintf("Connection failed: %v", err)
-		result.ResponseTime = responseTime
-		return result
+	if dirPath == "" {
+		selected, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{Title: "选择要签名的模板目录"})
+		if err != nil {
+			return nil, err
+		}
+		if selected == "" {
+			return nil, fmt.Errorf("未选择目录")
+		}
+		dirPath = selected
 	}
-	defer conn.Close()
-	
-	result.Available = true
-	result.ResponseTime = responseTime
-	return result
+	return scanner.SignTemplateDirectory(dirPath, signer, privateKey)
 }
 
-// GetTaskHTTPLogs returns HTTP request logs for a specific task
-func (a *App) GetTaskHTTPLogs(taskID int64) ([]*scanner.HTTPRequestLog, error) {
-	runtime.LogInfo(a.ctx, fmt.Sprintf("获取任务 %d 的HTTP请求日志", taskID))
-	return a.jsonTaskManager.GetHTTPRequestLogs(taskID)
+// VerifyTemplateDirectory checks the signed manifest of a template directory against the
+// configured trusted signers without importing it
+func (a *App) VerifyTemplateDirectory(dirPath string) (*scanner.ManifestVerification, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	return scanner.VerifyTemplateManifest(dirPath, a.config.TemplateTrust.Signers), nil
 }
 
 // GetPOCTemplateContent returns the raw YAML content of a POC template
 func (a *App) GetPOCTemplateContent(templatePath string) (string, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return "", err
+	}
 	runtime.LogInfo(a.ctx, fmt.Sprintf("读取POC模板内容: %s", templatePath))
 
 	// 读取文件内容
@@ -962,10 +3728,13 @@ func (a *App) GetPOCTemplateContent(templatePath string) (string, error) {
 
 // ExportTaskResultAsJSON exports scan result as JSON file
 func (a *App) ExportTaskResultAsJSON(taskID int64) (string, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return "", err
+	}
 	runtime.LogInfo(a.ctx, fmt.Sprintf("导出任务 %d 的结果为JSON", taskID))
 
-	// 获取扫描结果
-	result, err := a.jsonTaskManager.GetTaskResult(taskID)
+	// 获取扫描结果（包含漏洞附件）
+	result, err := a.jsonTaskManager.ExportTaskResult(taskID)
 	if err != nil {
 		return "", fmt.Errorf("failed to get task result: %w", err)
 	}
@@ -996,7 +3765,7 @@ func (a *App) ExportTaskResultAsJSON(taskID int64) (string, error) {
 	})
 
 	if err != nil || savePath == "" {
-		return "", fmt.Errorf("用户取消导出")
+		return "", i18n.Errorf(i18n.ErrCancelled)
 	}
 
 	// 序列化为JSON
@@ -1005,12 +3774,70 @@ func (a *App) ExportTaskResultAsJSON(taskID int64) (string, error) {
 		return "", fmt.Errorf("failed to marshal data: %w", err)
 	}
 
-	// 写入文件
-	if err := os.WriteFile(savePath, jsonData, 0644); err != nil {
+	// 屏蔽任务自定义请求头/Cookie中的敏感值
+	jsonData = []byte(scanner.MaskSecrets(string(jsonData), a.jsonTaskManager.TaskSecretValues(taskID)))
+
+	// 写入文件，附带结果、HTTP日志和附件的哈希清单及 .sha256 校验文件
+	if err := scanner.WriteSealedExport(savePath, jsonData); err != nil {
+		return "", err
+	}
+
+	runtime.LogInfo(a.ctx, fmt.Sprintf("✅ 导出成功: %s", savePath))
+	a.audit(scanner.AuditExported, taskID, "results to "+savePath)
+	return savePath, nil
+}
+
+// VerifyExport checks a sealed JSON export (result or task export) against its manifest
+// and .sha256 checksum file; opens a file dialog when path is empty
+func (a *App) VerifyExport(path string) (*scanner.ExportVerification, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		selected, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: "选择要校验的导出文件",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "JSON Files (*.json)", Pattern: "*.json"},
+			},
+		})
+		if err != nil || selected == "" {
+			return nil, i18n.Errorf(i18n.ErrCancelled)
+		}
+		path = selected
+	}
+	return scanner.VerifyExport(path)
+}
+
+// ExportTaskTrafficAsHAR exports the captured HTTP traffic of a task as a HAR file
+func (a *App) ExportTaskTrafficAsHAR(taskID int64) (string, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return "", err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("导出任务 %d 的HTTP流量为HAR", taskID))
+
+	harData, err := a.jsonTaskManager.BuildTaskHAR(taskID)
+	if err != nil {
+		return "", fmt.Errorf("failed to build HAR: %w", err)
+	}
+
+	savePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("task_%d_traffic.har", taskID),
+		Title:           "导出HTTP流量",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "HAR Files (*.har)", Pattern: "*.har"},
+		},
+	})
+
+	if err != nil || savePath == "" {
+		return "", i18n.Errorf(i18n.ErrCancelled)
+	}
+
+	if err := os.WriteFile(savePath, harData, 0644); err != nil {
 		return "", fmt.Errorf("failed to write file: %w", err)
 	}
 
 	runtime.LogInfo(a.ctx, fmt.Sprintf("✅ 导出成功: %s", savePath))
+	a.audit(scanner.AuditExported, taskID, "HTTP traffic to "+savePath)
 	return savePath, nil
 }
 
@@ -1023,67 +3850,89 @@ type TestSinglePOCParams struct {
 	InteractshURL   string `json:"interactsh_url"`   // Interactsh server URL
 	InteractshToken string `json:"interactsh_token"` // Interactsh token
 	ProxyURL        string `json:"proxy_url"`        // Proxy server URL
+	Variables       map[string]string `json:"variables"` // Template variables (-var)
+	Targets         []string `json:"targets"`          // Additional targets tested in the same run
+	TargetTaskID    int64    `json:"target_task_id"`   // Also test the targets of this saved task
 }
 
-// TestSinglePOC tests a single POC template with custom parameters
-func (a *App) TestSinglePOC(params TestSinglePOCParams) (map[string]interface{}, error) {
-	runtime.LogInfo(a.ctx, fmt.Sprintf("测试单个POC: target=%s", params.Target))
+// singlePOCTargets returns the normalized, deduplicated targets of a single POC test:
+// Target, Targets and the targets of TargetTaskID
+func (a *App) singlePOCTargets(params TestSinglePOCParams) ([]string, error) {
+	inputs := append([]string{params.Target}, params.Targets...)
+	if params.TargetTaskID > 0 {
+		if a.jsonTaskManager == nil {
+			return nil, i18n.Errorf(i18n.ErrNotInitialized)
+		}
+		task, err := a.jsonTaskManager.GetTaskByID(params.TargetTaskID)
+		if err != nil {
+			return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+		}
+		inputs = append(inputs, task.Targets...)
+	}
+
+	return scanner.NormalizeTargets(inputs)
+}
 
+// singlePOCArgs writes the template (and, for several targets, the target list) of a single
+// POC test to temporary files and builds the nuclei arguments for it; the caller runs cleanup
+// to remove the files. The probe results of the targets are returned for per-target outcomes.
+func (a *App) singlePOCArgs(params TestSinglePOCParams) ([]string, []*scanner.ProbeResult, func(), error) {
 	if params.TemplateContent == "" {
-		return nil, fmt.Errorf("模板内容不能为空")
-	}
-
-	if params.Target == "" {
-		return nil, fmt.Errorf("目标URL不能为空")
-	}
-
-	// Normalize target URL - add protocol if missing
-	target := params.Target
-	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
-		// Check if it looks like a host:port format
-		if strings.Contains(target, ":") && !strings.Contains(target, "://") {
-			// For host:port format, try to determine protocol
-			// Default to http for common web ports, otherwise use the target as-is
-			parts := strings.Split(target, ":")
-			if len(parts) == 2 {
-				port := parts[1]
-				switch port {
-				case "80", "8080", "8000", "3000", "5000":
-					target = "http://" + target
-				case "443", "8443":
-					target = "https://" + target
-				default:
-					// For other ports like Redis (6379), keep as-is without protocol
-					// Nuclei can handle raw host:port for network protocols
-				}
-			}
-		} else {
-			// Plain hostname, default to http
-			target = "http://" + target
-		}
+		return nil, nil, nil, fmt.Errorf("模板内容不能为空")
+	}
+
+	targets, err := a.singlePOCTargets(params)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(targets) == 0 {
+		return nil, nil, nil, fmt.Errorf("目标URL不能为空")
 	}
 
-	runtime.LogInfo(a.ctx, fmt.Sprintf("标准化目标: %s -> %s", params.Target, target))
+	// Normalize targets - probe https/http for bare host[:port] targets
+	probe := scanner.ProbeTargets(a.ctx, targets, params.ProxyURL, 0, 10*time.Second)
+	scanTargets := make([]string, 0, len(targets))
+	for _, result := range probe.Results {
+		if !result.Reachable {
+			runtime.LogWarning(a.ctx, fmt.Sprintf("目标协议探测失败: %s (%s)", result.Input, result.Error))
+		}
+		// Non-HTTP services (e.g. Redis 6379) keep the raw host:port for network templates
+		scanTargets = append(scanTargets, result.Target)
+		runtime.LogInfo(a.ctx, fmt.Sprintf("标准化目标: %s -> %s", result.Input, result.Target))
+	}
 
 	// Create temporary template file
 	tmpDir := filepath.Join(os.TempDir(), "wepoc-poc-test")
 	if err := os.MkdirAll(tmpDir, 0755); err != nil {
-		return nil, fmt.Errorf("无法创建临时目录: %w", err)
+		return nil, nil, nil, fmt.Errorf("无法创建临时目录: %w", err)
 	}
 
 	tmpFile := filepath.Join(tmpDir, fmt.Sprintf("test-poc-%d.yaml", time.Now().UnixNano()))
 	if err := os.WriteFile(tmpFile, []byte(params.TemplateContent), 0644); err != nil {
-		return nil, fmt.Errorf("无法创建临时模板文件: %w", err)
+		return nil, nil, nil, fmt.Errorf("无法创建临时模板文件: %w", err)
+	}
+	files := []string{tmpFile}
+	cleanup := func() {
+		for _, file := range files {
+			os.Remove(file)
+		}
 	}
-	defer os.Remove(tmpFile)
 
-	// Build nuclei command arguments
-	args := []string{
-		"-t", tmpFile,
-		"-u", target,
-		"-jsonl", // Use JSONL format (newer Nuclei versions)
+	targetArgs := []string{"-u", scanTargets[0]}
+	if len(scanTargets) > 1 {
+		targetsFile := strings.TrimSuffix(tmpFile, ".yaml") + "-targets.txt"
+		if err := os.WriteFile(targetsFile, []byte(strings.Join(scanTargets, "\n")), 0644); err != nil {
+			cleanup()
+			return nil, nil, nil, fmt.Errorf("无法创建目标列表文件: %w", err)
+		}
+		files = append(files, targetsFile)
+		targetArgs = []string{"-l", targetsFile}
 	}
 
+	// Build nuclei command arguments
+	args := append([]string{"-t", tmpFile}, targetArgs...)
+	args = append(args, "-jsonl") // Use JSONL format (newer Nuclei versions)
+
 	// Add concurrency
 	if params.Concurrency > 0 {
 		args = append(args, "-c", fmt.Sprintf("%d", params.Concurrency))
@@ -1097,16 +3946,73 @@ func (a *App) TestSinglePOC(params TestSinglePOCParams) (map[string]interface{},
 	// Add interactsh configuration
 	if params.InteractshURL != "" {
 		args = append(args, "-iserver", params.InteractshURL)
+	} else if a.config.Offline {
+		args = append(args, "-no-interactsh") // 离线模式下不使用公共Interactsh服务器
 	}
 	if params.InteractshToken != "" {
 		args = append(args, "-itoken", params.InteractshToken)
 	}
+	if a.config.Offline {
+		args = append(args, "-disable-update-check")
+	}
 
 	// Add proxy
 	if params.ProxyURL != "" {
 		args = append(args, "-proxy", params.ProxyURL)
 	}
 
+	// Add template variables
+	args = append(args, scanner.VarArgs(params.Variables)...)
+
+	return args, probe.Results, cleanup, nil
+}
+
+// StartSinglePOCDebug runs a single POC test with nuclei -debug without blocking; each
+// request, response, result and log line is emitted as a "poc-debug" event as it happens and
+// the run ends with a "done" event. Returns the session ID for CancelSinglePOCDebug.
+func (a *App) StartSinglePOCDebug(params TestSinglePOCParams) (string, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return "", err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("调试单个POC: target=%s", params.Target))
+
+	args, _, cleanup, err := a.singlePOCArgs(params)
+	if err != nil {
+		return "", err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("Nuclei命令: %s %s -debug", a.config.NucleiPath, strings.Join(args, " ")))
+
+	sessionID, err := scanner.StartPOCDebug(a.config.NucleiPath, args, func(event *scanner.POCDebugEvent) {
+		runtime.EventsEmit(a.ctx, "poc-debug", event)
+	}, cleanup)
+	if err != nil {
+		cleanup()
+		return "", err
+	}
+	return sessionID, nil
+}
+
+// CancelSinglePOCDebug stops a running single POC debug session
+func (a *App) CancelSinglePOCDebug(sessionID string) error {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return err
+	}
+	return scanner.CancelPOCDebug(sessionID)
+}
+
+// TestSinglePOC tests a single POC template with custom parameters
+func (a *App) TestSinglePOC(params TestSinglePOCParams) (map[string]interface{}, error) {
+	if err := a.authorize(auth.PermRunScans); err != nil {
+		return nil, err
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("测试单个POC: target=%s", params.Target))
+
+	args, probes, cleanup, err := a.singlePOCArgs(params)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
 	runtime.LogInfo(a.ctx, fmt.Sprintf("Nuclei命令: %s %s", a.config.NucleiPath, strings.Join(args, " ")))
 
 	// Execute nuclei command
@@ -1122,6 +4028,8 @@ func (a *App) TestSinglePOC(params TestSinglePOCParams) (map[string]interface{},
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("无法启动Nuclei: %w", err)
 	}
+	untrack := scanner.TrackProcess(cmd)
+	defer untrack()
 
 	// Wait for completion or timeout
 	done := make(chan error, 1)
@@ -1183,6 +4091,7 @@ func (a *App) TestSinglePOC(params TestSinglePOCParams) (map[string]interface{},
 		"results":       results,
 		"raw_output":    output,
 		"stderr":        stderrOutput,
+		"targets":       scanner.SinglePOCOutcomes(probes, results),
 	}
 
 	if execErr != nil {
@@ -1234,8 +4143,72 @@ func (a *App) TestSinglePOC(params TestSinglePOCParams) (map[string]interface{},
 	return response, nil
 }
 
-// SavePOCTemplate saves modified POC template content to file
+// CreatePOCFromContent adds a template given as raw YAML (e.g. pasted from a chat) to the
+// POC directory after nuclei -validate, named after its id, and inserts it into the database
+func (a *App) CreatePOCFromContent(content string) (*models.Template, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return nil, err
+	}
+	if a.db == nil || a.templateParser == nil {
+		return nil, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	template, err := a.templateParser.CreateTemplateFromContent(content, a.config.POCDirectory, a.config.NucleiPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.db.InsertTemplate(template); err != nil {
+		os.Remove(template.FilePath)
+		return nil, fmt.Errorf("failed to save template to database: %w", err)
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("已从粘贴内容创建POC模板: %s", template.FilePath))
+	a.audit(scanner.AuditTemplatesImported, 0, "pasted "+template.TemplateID)
+	return template, nil
+}
+
+// IndexTemplateRoot adds the templates of a registered template root to the template library.
+// The templates stay in the root directory, so tasks reference them by their path there.
+func (a *App) IndexTemplateRoot(name string) (int, error) {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return 0, err
+	}
+	if a.db == nil || a.templateParser == nil {
+		return 0, i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	var root *models.TemplateRoot
+	for i := range a.config.TemplateRoots {
+		if strings.EqualFold(a.config.TemplateRoots[i].Name, name) {
+			root = &a.config.TemplateRoots[i]
+			break
+		}
+	}
+	if root == nil {
+		return 0, i18n.Errorf(i18n.ErrTemplateRootNotFound, name)
+	}
+
+	templates, errs := a.templateParser.ScanDirectory(root.Path)
+	if len(errs) > 0 {
+		runtime.LogInfo(a.ctx, fmt.Sprintf("模板根目录 %s 中 %d 个模板无法解析", root.Name, len(errs)))
+	}
+	manifest := scanner.VerifyTemplateManifest(root.Path, a.config.TemplateTrust.Signers)
+	for _, template := range templates {
+		template.Trust = manifest.TrustOf(template.FilePath)
+	}
+	if len(templates) > 0 {
+		if err := a.db.BatchInsertTemplates(templates); err != nil {
+			return 0, fmt.Errorf("failed to save templates to database: %w", err)
+		}
+	}
+	runtime.LogInfo(a.ctx, fmt.Sprintf("已索引模板根目录 %s: %d 个模板", root.Name, len(templates)))
+	a.audit(scanner.AuditTemplatesImported, 0, fmt.Sprintf("root %s (%d)", root.Name, len(templates)))
+	return len(templates), nil
+}
+
+// SavePOCTemplate saves modified POC template content to file; the edited template is no
+// longer covered by its signature and becomes unverified
 func (a *App) SavePOCTemplate(templatePath string, content string) error {
+	if err := a.authorize(auth.PermManageTemplates); err != nil {
+		return err
+	}
 	runtime.LogInfo(a.ctx, fmt.Sprintf("保存POC模板: %s", templatePath))
 
 	if templatePath == "" {
@@ -1274,11 +4247,37 @@ func (a *App) SavePOCTemplate(templatePath string, content string) error {
 	if err := os.WriteFile(templatePath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("无法保存模板文件: %w", err)
 	}
+	a.audit(scanner.AuditTemplateEdited, 0, templatePath)
+	if err := a.refreshEditedTemplate(templatePath); err != nil {
+		return err
+	}
 
 	runtime.LogInfo(a.ctx, "✅ POC模板保存成功")
 	return nil
 }
 
+// refreshEditedTemplate updates the library entry of a template edited in the app: the
+// signature no longer covers the content, so the template becomes unverified, and its
+// metadata, protocols and search index are taken from the new content
+func (a *App) refreshEditedTemplate(templatePath string) error {
+	if a.db == nil || a.templateParser == nil {
+		return i18n.Errorf(i18n.ErrNotInitialized)
+	}
+	template, err := a.templateParser.ParseTemplate(templatePath)
+	if err != nil {
+		runtime.LogWarning(a.ctx, fmt.Sprintf("无法解析编辑后的模板，仅重置信任级别: %v", err))
+		return a.db.SetTemplateTrustByPath(templatePath, models.TrustUnverified)
+	}
+	template.Trust = models.TrustUnverified
+	if err := a.db.UpdateTemplate(template); err != nil {
+		return err
+	}
+	if lint, err := a.templateParser.LintTemplate(templatePath); err == nil && len(lint.Issues) > 0 {
+		runtime.LogWarning(a.ctx, fmt.Sprintf("模板 %s 有 %d 个检查问题", template.TemplateID, len(lint.Issues)))
+	}
+	return nil
+}
+
 // copyFile copies a file from src to dst
 func copyFile(src, dst string) error {
 	data, err := os.ReadFile(src)
@@ -1290,6 +4289,9 @@ func copyFile(src, dst string) error {
 
 // SaveCSVFile opens a save dialog and returns the selected file path
 func (a *App) SaveCSVFile(defaultFilename string, csvContent string) (string, error) {
+	if err := a.authorize(auth.PermViewResults); err != nil {
+		return "", err
+	}
 	runtime.LogInfo(a.ctx, fmt.Sprintf("打开保存对话框: %s", defaultFilename))
 
 	// Open save file dialog
@@ -1303,7 +4305,7 @@ func (a *App) SaveCSVFile(defaultFilename string, csvContent string) (string, er
 	})
 
 	if err != nil || savePath == "" {
-		return "", fmt.Errorf("用户取消保存")
+		return "", i18n.Errorf(i18n.ErrCancelled)
 	}
 
 	// Add BOM for Excel Chinese support
@@ -1316,5 +4318,6 @@ func (a *App) SaveCSVFile(defaultFilename string, csvContent string) (string, er
 	}
 
 	runtime.LogInfo(a.ctx, fmt.Sprintf("✅ CSV文件保存成功: %s", savePath))
+	a.audit(scanner.AuditExported, 0, "CSV to "+savePath)
 	return savePath, nil
 }
@@ -0,0 +1,374 @@
+// Package auth manages the local user accounts and roles of multi-user mode
+package auth
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"wepoc/internal/i18n"
+)
+
+// Role is the role of a user
+type Role string
+
+// Roles
+const (
+	RoleAdmin    Role = "admin"    // 管理模板、配置和用户，并可执行所有操作
+	RoleOperator Role = "operator" // 创建和运行扫描任务
+	RoleViewer   Role = "viewer"   // 只能浏览任务和结果
+)
+
+// Permission is an action guarded by roles
+type Permission string
+
+// Permissions
+const (
+	PermViewResults     Permission = "view_results"
+	PermRunScans        Permission = "run_scans"
+	PermManageTemplates Permission = "manage_templates"
+	PermManageConfig    Permission = "manage_config"
+	PermManageUsers     Permission = "manage_users"
+)
+
+// rolePermissions lists the permissions of each role
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin:    {PermViewResults, PermRunScans, PermManageTemplates, PermManageConfig, PermManageUsers},
+	RoleOperator: {PermViewResults, PermRunScans},
+	RoleViewer:   {PermViewResults},
+}
+
+// Valid reports whether the role exists
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can reports whether the role grants a permission
+func (r Role) Can(permission Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions returns the permissions of the role
+func (r Role) Permissions() []Permission {
+	return append([]Permission{}, rolePermissions[r]...)
+}
+
+const (
+	minPasswordLength    = 8
+	passwordKDFIteration = 600000
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._@-]{2,64}$`)
+
+// user is a stored account
+type user struct {
+	Username     string     `json:"username"`
+	Role         Role       `json:"role"`
+	PasswordHash string     `json:"password_hash"` // PBKDF2-SHA256（base64）
+	Salt         string     `json:"salt"`          // base64
+	Iterations   int        `json:"iterations"`
+	Disabled     bool       `json:"disabled,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	LastLogin    *time.Time `json:"last_login,omitempty"`
+}
+
+// UserInfo is a user account without its password hash
+type UserInfo struct {
+	Username  string     `json:"username"`
+	Role      Role       `json:"role"`
+	Disabled  bool       `json:"disabled"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastLogin *time.Time `json:"last_login,omitempty"`
+}
+
+func (u *user) info() *UserInfo {
+	return &UserInfo{Username: u.Username, Role: u.Role, Disabled: u.Disabled, CreatedAt: u.CreatedAt, LastLogin: u.LastLogin}
+}
+
+// UserStore persists the user accounts in ~/.wepoc/users.json. Multi-user mode is on as
+// soon as an account exists.
+type UserStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewUserStore creates a store backed by users.json in the given directory
+func NewUserStore(dir string) *UserStore {
+	return &UserStore{path: filepath.Join(dir, "users.json")}
+}
+
+// Enabled reports whether multi-user mode is on (at least one account exists)
+func (s *UserStore) Enabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users, err := s.load()
+	// 用户文件损坏时按已启用处理，避免绕过登录
+	return err != nil || len(users) > 0
+}
+
+// List returns the accounts sorted by name
+func (s *UserStore) List() ([]*UserInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*UserInfo, 0, len(users))
+	for _, u := range users {
+		infos = append(infos, u.info())
+	}
+	return infos, nil
+}
+
+// Get returns an account by name
+func (s *UserStore) Get(username string) (*UserInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	u := findUser(users, username)
+	if u == nil {
+		return nil, i18n.Errorf(i18n.ErrUserNotFound, username)
+	}
+	return u.info(), nil
+}
+
+// Create adds an account; the first account must be an admin
+func (s *UserStore) Create(username, password string, role Role) (*UserInfo, error) {
+	username = strings.TrimSpace(username)
+	if !usernamePattern.MatchString(username) {
+		return nil, i18n.Errorf(i18n.ErrInvalidUsername, username)
+	}
+	if !role.Valid() {
+		return nil, i18n.Errorf(i18n.ErrInvalidRole, role)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if findUser(users, username) != nil {
+		return nil, i18n.Errorf(i18n.ErrUserExists, username)
+	}
+	if len(users) == 0 && role != RoleAdmin {
+		return nil, i18n.Errorf(i18n.ErrLastAdmin)
+	}
+
+	now := time.Now()
+	u := &user{Username: username, Role: role, CreatedAt: now, UpdatedAt: now}
+	if err := u.setPassword(password); err != nil {
+		return nil, err
+	}
+	users = append(users, u)
+	if err := s.save(users); err != nil {
+		return nil, err
+	}
+	return u.info(), nil
+}
+
+// Update changes the role and disabled state of an account; the last enabled admin cannot
+// be demoted or disabled
+func (s *UserStore) Update(username string, role Role, disabled bool) (*UserInfo, error) {
+	if !role.Valid() {
+		return nil, i18n.Errorf(i18n.ErrInvalidRole, role)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	u := findUser(users, username)
+	if u == nil {
+		return nil, i18n.Errorf(i18n.ErrUserNotFound, username)
+	}
+	previousRole, previousDisabled := u.Role, u.Disabled
+	u.Role, u.Disabled = role, disabled
+	if activeAdmins(users) == 0 {
+		u.Role, u.Disabled = previousRole, previousDisabled
+		return nil, i18n.Errorf(i18n.ErrLastAdmin)
+	}
+	u.UpdatedAt = time.Now()
+	if err := s.save(users); err != nil {
+		return nil, err
+	}
+	return u.info(), nil
+}
+
+// SetPassword replaces the password of an account
+func (s *UserStore) SetPassword(username, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users, err := s.load()
+	if err != nil {
+		return err
+	}
+	u := findUser(users, username)
+	if u == nil {
+		return i18n.Errorf(i18n.ErrUserNotFound, username)
+	}
+	if err := u.setPassword(password); err != nil {
+		return err
+	}
+	u.UpdatedAt = time.Now()
+	return s.save(users)
+}
+
+// Delete removes an account; the last enabled admin cannot be deleted. Deleting every
+// account turns multi-user mode off, so the last account is an admin and is kept.
+func (s *UserStore) Delete(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users, err := s.load()
+	if err != nil {
+		return err
+	}
+	remaining := make([]*user, 0, len(users))
+	for _, u := range users {
+		if !strings.EqualFold(u.Username, username) {
+			remaining = append(remaining, u)
+		}
+	}
+	if len(remaining) == len(users) {
+		return i18n.Errorf(i18n.ErrUserNotFound, username)
+	}
+	if activeAdmins(remaining) == 0 {
+		return i18n.Errorf(i18n.ErrLastAdmin)
+	}
+	return s.save(remaining)
+}
+
+// Authenticate checks a username and password and records the login time
+func (s *UserStore) Authenticate(username, password string) (*UserInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	u := findUser(users, strings.TrimSpace(username))
+	if u == nil {
+		// 未知用户也计算一次哈希，避免通过耗时判断用户是否存在
+		(&user{}).checkPassword(password)
+		return nil, i18n.Errorf(i18n.ErrInvalidCredentials)
+	}
+	if !u.checkPassword(password) || u.Disabled {
+		return nil, i18n.Errorf(i18n.ErrInvalidCredentials)
+	}
+	now := time.Now()
+	u.LastLogin = &now
+	if err := s.save(users); err != nil {
+		return nil, err
+	}
+	return u.info(), nil
+}
+
+// load reads the accounts (caller holds mu); a missing file means no accounts
+func (s *UserStore) load() ([]*user, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users: %w", err)
+	}
+	var users []*user
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal users: %w", err)
+	}
+	return users, nil
+}
+
+// save writes the accounts atomically (caller holds mu)
+func (s *UserStore) save(users []*user) error {
+	sort.Slice(users, func(i, j int) bool {
+		return strings.ToLower(users[i].Username) < strings.ToLower(users[j].Username)
+	})
+	data, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal users: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write users: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write users: %w", err)
+	}
+	return nil
+}
+
+// findUser looks an account up by name (case-insensitive)
+func findUser(users []*user, username string) *user {
+	for _, u := range users {
+		if strings.EqualFold(u.Username, username) {
+			return u
+		}
+	}
+	return nil
+}
+
+// activeAdmins counts the enabled admins
+func activeAdmins(users []*user) int {
+	count := 0
+	for _, u := range users {
+		if u.Role == RoleAdmin && !u.Disabled {
+			count++
+		}
+	}
+	return count
+}
+
+func (u *user) setPassword(password string) error {
+	if len([]rune(password)) < minPasswordLength {
+		return i18n.Errorf(i18n.ErrWeakPassword, minPasswordLength)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	hash, err := pbkdf2.Key(sha256.New, password, salt, passwordKDFIteration, 32)
+	if err != nil {
+		return err
+	}
+	u.Salt = base64.StdEncoding.EncodeToString(salt)
+	u.PasswordHash = base64.StdEncoding.EncodeToString(hash)
+	u.Iterations = passwordKDFIteration
+	return nil
+}
+
+func (u *user) checkPassword(password string) bool {
+	salt, _ := base64.StdEncoding.DecodeString(u.Salt)
+	expected, _ := base64.StdEncoding.DecodeString(u.PasswordHash)
+	iterations := u.Iterations
+	if iterations <= 0 {
+		iterations = passwordKDFIteration
+	}
+	hash, err := pbkdf2.Key(sha256.New, password, salt, iterations, 32)
+	return err == nil && len(expected) > 0 && subtle.ConstantTimeCompare(hash, expected) == 1
+}
@@ -0,0 +1,330 @@
+package config
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// backupFormatVersion is the version of the workspace archive layout
+const backupFormatVersion = 1
+
+const (
+	backupManifestName = "manifest.json"
+	backupDatabaseName = "wepoc.db"
+)
+
+// backupEntries are the parts of ~/.wepoc included in a workspace backup. Logs and
+// temporary files are left out; archives hold compressed results of older tasks.
+var backupEntries = []string{
+	"config.json",
+	secretKeyFile,
+	"credentials.json",
+	"users.json",
+	DefaultPOCDir,
+	"tasks",
+	"results",
+	"archives",
+	"attachments",
+	"wordlists",
+}
+
+// rebasedDirs are the backup entries whose JSON files contain absolute workspace paths
+var rebasedDirs = []string{"config.json", "tasks", "results"}
+
+// BackupManifest describes a workspace backup archive
+type BackupManifest struct {
+	FormatVersion int       `json:"format_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	SourceDir     string    `json:"source_dir"` // 备份时的 ~/.wepoc 路径，用于恢复时改写绝对路径
+	Entries       []string  `json:"entries"`    // 包含的顶层条目
+	Files         int       `json:"files"`
+	Bytes         int64     `json:"bytes"`
+}
+
+// BackupResult is returned by ExportWorkspace and ImportWorkspace
+type BackupResult struct {
+	Path           string          `json:"path"`                      // 备份文件路径
+	Manifest       *BackupManifest `json:"manifest"`                  // 备份清单
+	SafetySnapshot string          `json:"safety_snapshot,omitempty"` // 导入前自动创建的当前工作区快照
+}
+
+// ExportWorkspace bundles the config, template database, templates and task/result
+// data into a single zip archive. snapshotDB must write a consistent copy of the
+// template database to the given path.
+func ExportWorkspace(dstPath string, snapshotDB func(path string) error) (*BackupResult, error) {
+	wepocDir, err := GetWepocDir()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := os.OpenFile(dstPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer out.Close()
+
+	writer := zip.NewWriter(out)
+	manifest := &BackupManifest{
+		FormatVersion: backupFormatVersion,
+		CreatedAt:     time.Now(),
+		SourceDir:     wepocDir,
+		Entries:       []string{},
+	}
+
+	for _, entry := range backupEntries {
+		path := filepath.Join(wepocDir, entry)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := addBackupPath(writer, manifest, entry, path, dstPath); err != nil {
+			writer.Close()
+			os.Remove(dstPath)
+			return nil, err
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+
+	// 数据库可能正在使用，先生成一致的快照再写入
+	if snapshotDB != nil {
+		snapshot := filepath.Join(os.TempDir(), fmt.Sprintf("wepoc-backup-%d.db", time.Now().UnixNano()))
+		defer os.Remove(snapshot)
+		if err := snapshotDB(snapshot); err != nil {
+			writer.Close()
+			os.Remove(dstPath)
+			return nil, err
+		}
+		if err := addBackupPath(writer, manifest, backupDatabaseName, snapshot, dstPath); err != nil {
+			writer.Close()
+			os.Remove(dstPath)
+			return nil, err
+		}
+		manifest.Entries = append(manifest.Entries, backupDatabaseName)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		writer.Close()
+		os.Remove(dstPath)
+		return nil, fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+	manifestWriter, err := writer.Create(backupManifestName)
+	if err == nil {
+		_, err = manifestWriter.Write(data)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		os.Remove(dstPath)
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	logInfof("💾 工作区已备份: %s (%d 个文件)\n", dstPath, manifest.Files)
+	return &BackupResult{Path: dstPath, Manifest: manifest}, nil
+}
+
+// addBackupPath adds a file or directory tree to the archive under name
+func addBackupPath(writer *zip.Writer, manifest *BackupManifest, name, path, skip string) error {
+	return filepath.Walk(path, func(current string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || current == skip {
+			return nil
+		}
+		rel, err := filepath.Rel(path, current)
+		if err != nil {
+			return err
+		}
+		entryName := name
+		if rel != "." {
+			entryName = name + "/" + filepath.ToSlash(rel)
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = entryName
+		header.Method = zip.Deflate
+		dst, err := writer.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to backup: %w", entryName, err)
+		}
+		src, err := os.Open(current)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		if _, err := io.Copy(dst, src); err != nil {
+			return fmt.Errorf("failed to add %s to backup: %w", entryName, err)
+		}
+
+		manifest.Files++
+		manifest.Bytes += info.Size()
+		return nil
+	})
+}
+
+// readBackupManifest reads and validates the manifest of a backup archive
+func readBackupManifest(reader *zip.ReadCloser) (*BackupManifest, error) {
+	for _, file := range reader.File {
+		if file.Name != backupManifestName {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		var manifest BackupManifest
+		if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+			return nil, fmt.Errorf("无效的备份清单: %w", err)
+		}
+		if manifest.FormatVersion > backupFormatVersion {
+			return nil, fmt.Errorf("备份格式版本 %d 高于当前支持的版本 %d", manifest.FormatVersion, backupFormatVersion)
+		}
+		return &manifest, nil
+	}
+	return nil, fmt.Errorf("不是有效的wepoc备份文件（缺少 %s）", backupManifestName)
+}
+
+// ImportWorkspace restores a backup created by ExportWorkspace. The current workspace is
+// first snapshotted to ~/.wepoc/backups. Each top-level entry in the backup replaces the
+// existing one; absolute paths of the source machine are rewritten to this workspace.
+// restoreDB receives the extracted template database and the source workspace path.
+func ImportWorkspace(srcPath string, snapshotDB func(path string) error, restoreDB func(path, sourceDir string) error) (*BackupResult, error) {
+	wepocDir, err := GetWepocDir()
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := zip.OpenReader(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer reader.Close()
+
+	manifest, err := readBackupManifest(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// 导入前先备份当前工作区
+	backupsDir := filepath.Join(wepocDir, "backups")
+	if err := os.MkdirAll(backupsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backups directory: %w", err)
+	}
+	safety, err := ExportWorkspace(filepath.Join(backupsDir, fmt.Sprintf("pre_import_%s.zip", time.Now().Format("20060102_150405"))), snapshotDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot current workspace: %w", err)
+	}
+
+	staging, err := os.MkdirTemp(wepocDir, "import_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	for _, file := range reader.File {
+		if file.Name == backupManifestName || file.FileInfo().IsDir() {
+			continue
+		}
+		if err := extractBackupFile(file, staging); err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest.SourceDir != "" && manifest.SourceDir != wepocDir {
+		for _, entry := range rebasedDirs {
+			rebaseJSONPaths(filepath.Join(staging, entry), manifest.SourceDir, wepocDir)
+		}
+	}
+
+	for _, entry := range manifest.Entries {
+		staged := filepath.Join(staging, entry)
+		if _, err := os.Stat(staged); err != nil {
+			continue
+		}
+		if entry == backupDatabaseName {
+			if restoreDB != nil {
+				if err := restoreDB(staged, manifest.SourceDir); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		dst := filepath.Join(wepocDir, entry)
+		if err := os.RemoveAll(dst); err != nil {
+			return nil, fmt.Errorf("failed to replace %s: %w", entry, err)
+		}
+		if err := os.Rename(staged, dst); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", entry, err)
+		}
+	}
+
+	// 恢复的secret.key可能不同，需要重新解锁
+	forgetSecretKey()
+	logInfof("📦 工作区已从备份恢复: %s (%d 个文件)\n", srcPath, manifest.Files)
+	return &BackupResult{Path: srcPath, Manifest: manifest, SafetySnapshot: safety.Path}, nil
+}
+
+// extractBackupFile writes one archive entry below dir, rejecting paths that escape it
+func extractBackupFile(file *zip.File, dir string) error {
+	dst := filepath.Join(dir, filepath.FromSlash(file.Name))
+	if !strings.HasPrefix(dst, filepath.Clean(dir)+string(filepath.Separator)) {
+		return fmt.Errorf("备份中包含非法路径: %s", file.Name)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", file.Name, err)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", file.Name, err)
+	}
+	defer src.Close()
+
+	mode := file.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", file.Name, err)
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, src); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// rebaseJSONPaths rewrites occurrences of the source workspace path in JSON files
+func rebaseJSONPaths(path, oldDir, newDir string) {
+	oldJSON, _ := json.Marshal(oldDir)
+	newJSON, _ := json.Marshal(newDir)
+	// 去掉引号，只替换路径前缀
+	oldPrefix := string(oldJSON[1 : len(oldJSON)-1])
+	newPrefix := string(newJSON[1 : len(newJSON)-1])
+
+	filepath.Walk(path, func(current string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(current) != ".json" {
+			return nil
+		}
+		data, err := os.ReadFile(current)
+		if err != nil || !strings.Contains(string(data), oldPrefix) {
+			return nil
+		}
+		os.WriteFile(current, []byte(strings.ReplaceAll(string(data), oldPrefix, newPrefix)), info.Mode().Perm())
+		return nil
+	})
+}
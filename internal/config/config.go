@@ -7,10 +7,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"time"
 
+	"wepoc/internal/i18n"
 	"wepoc/internal/models"
 )
 
@@ -20,6 +22,7 @@ const (
 	DefaultDatabaseName  = "wepoc.db"
 	DefaultMaxConcurrency = 3
 	DefaultTimeout       = 10
+	DefaultBurpProxyURL  = "http://127.0.0.1:8080"
 )
 
 // GetUserHomeDir returns the user's home directory
@@ -66,6 +69,7 @@ func GetDefaultConfig() (*models.Config, error) {
 		NucleiPath:     nucleiPath,
 		MaxConcurrency: DefaultMaxConcurrency,
 		Timeout:        DefaultTimeout,
+		BurpProxyURL:   DefaultBurpProxyURL,
 		NucleiConfig: models.NucleiAdvancedConfig{
 			// Threading defaults
 			Concurrency:     25,
@@ -78,6 +82,8 @@ func GetDefaultConfig() (*models.Config, error) {
 			ProxyURL:       "",
 			ProxyList:      []string{},
 			ProxyInternal:  false,
+			ProxyUsername:  "",
+			ProxyPassword:  "",
 			
 			// DNS/OAST defaults
 			InteractshEnabled: true,
@@ -159,6 +165,21 @@ func LoadConfig() (*models.Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// Decrypt secrets stored in the config file
+	migrate := HasPlaintextSecrets(&config)
+	if err := decryptSecrets(&config); err != nil {
+		return nil, fmt.Errorf("failed to decrypt config secrets: %w", err)
+	}
+
+	// Encrypt secrets still stored in plaintext (older versions, manual edits)
+	if migrate {
+		if err := SaveConfig(&config); err != nil {
+			logWarnf("⚠️ 配置中的明文密钥加密失败: %v\n", err)
+		} else {
+			logInfof("🔐 已加密配置文件中的明文密钥\n")
+		}
+	}
+
 	return &config, nil
 }
 
@@ -171,8 +192,19 @@ func SaveConfig(config *models.Config) error {
 
 	configPath := filepath.Join(wepocDir, "config.json")
 
+	// While the secret key is locked the loaded secrets are blank; saving would erase them
+	if status, err := GetSecretStoreStatus(); err == nil && status.Locked {
+		return i18n.Errorf(i18n.ErrSecretsLocked)
+	}
+
+	// Encrypt secrets before writing them to disk
+	stored, err := encryptSecrets(config)
+	if err != nil {
+		return err
+	}
+
 	// Marshal config to JSON
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := json.MarshalIndent(stored, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -181,10 +213,34 @@ func SaveConfig(config *models.Config) error {
 	if err := os.WriteFile(configPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
+	rememberConfigDigest(data)
 
 	return nil
 }
 
+// ChangedFields returns the JSON names of the top-level settings that differ between two
+// configs, e.g. to record what a save changed without recording secret values
+func ChangedFields(before, after *models.Config) []string {
+	changed := []string{}
+	if before == nil || after == nil {
+		return changed
+	}
+	oldValue := reflect.ValueOf(*before)
+	newValue := reflect.ValueOf(*after)
+	for i := 0; i < oldValue.NumField(); i++ {
+		if reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			continue
+		}
+		field := oldValue.Type().Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+		changed = append(changed, name)
+	}
+	return changed
+}
+
 // EnsureDirectories ensures all required directories exist
 func EnsureDirectories(config *models.Config) error {
 	dirs := []string{
@@ -213,6 +269,10 @@ func findNucleiBinary() (string, error) {
 		filepath.Join(os.Getenv("HOME"), ".local", "bin", "nuclei"), // User local bin
 		filepath.Join(os.Getenv("HOME"), "bin", "nuclei"), // User bin
 	}
+	// Installed from a local release archive
+	if installed, err := InstalledNucleiPath(); err == nil {
+		locations = append(locations, installed)
+	}
 
 	for _, path := range locations {
 		if _, err := os.Stat(path); err == nil {
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// configWatchInterval is how often config.json is checked for external edits
+const configWatchInterval = 2 * time.Second
+
+var (
+	configDigestMu sync.Mutex
+	configDigest   [sha256.Size]byte // config.json内容的摘要（最近一次由本程序写入或重新加载）
+)
+
+// rememberConfigDigest records the content of config.json as known, so that the watcher
+// does not report the application's own writes
+func rememberConfigDigest(data []byte) {
+	configDigestMu.Lock()
+	defer configDigestMu.Unlock()
+	configDigest = sha256.Sum256(data)
+}
+
+// ConfigWatcher reloads config.json when it is edited outside the application (by hand or by
+// another tool). The file is polled, so editors replacing it atomically are seen as well.
+type ConfigWatcher struct {
+	path     string
+	onChange func(*models.Config)
+	onError  func(error)
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// WatchConfig starts watching config.json; onChange receives the reloaded config and onError
+// the errors of edits that cannot be loaded (e.g. invalid JSON while the file is being edited)
+func WatchConfig(onChange func(*models.Config), onError func(error)) (*ConfigWatcher, error) {
+	wepocDir, err := GetWepocDir()
+	if err != nil {
+		return nil, err
+	}
+	watcher := &ConfigWatcher{
+		path:     filepath.Join(wepocDir, "config.json"),
+		onChange: onChange,
+		onError:  onError,
+		stop:     make(chan struct{}),
+	}
+	if data, err := os.ReadFile(watcher.path); err == nil {
+		rememberConfigDigest(data)
+	}
+	go watcher.run()
+	return watcher, nil
+}
+
+// Stop stops watching
+func (w *ConfigWatcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+func (w *ConfigWatcher) run() {
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+
+	var lastModTime time.Time
+	var lastSize int64 = -1
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(w.path)
+		if err != nil || (info.ModTime().Equal(lastModTime) && info.Size() == lastSize) {
+			continue
+		}
+		lastModTime, lastSize = info.ModTime(), info.Size()
+		w.check()
+	}
+}
+
+// check reloads the config when its content differs from the known content
+func (w *ConfigWatcher) check() {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return
+	}
+	digest := sha256.Sum256(data)
+	configDigestMu.Lock()
+	known := digest == configDigest
+	configDigest = digest // 无法加载的内容也只报告一次，等待下一次修改
+	configDigestMu.Unlock()
+	if known {
+		return
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		if w.onError != nil {
+			w.onError(err)
+		}
+		return
+	}
+	if w.onChange != nil {
+		w.onChange(cfg)
+	}
+}
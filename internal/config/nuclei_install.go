@@ -0,0 +1,136 @@
+package config
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// nucleiBinDir is where nuclei binaries installed from local archives are kept
+const nucleiBinDir = "bin"
+
+// nucleiExecutableName is the file name of the nuclei binary on this platform
+func nucleiExecutableName() string {
+	if runtime.GOOS == "windows" {
+		return "nuclei.exe"
+	}
+	return "nuclei"
+}
+
+// InstalledNucleiPath returns where InstallNucleiFromArchive puts the nuclei binary
+func InstalledNucleiPath() (string, error) {
+	wepocDir, err := GetWepocDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(wepocDir, nucleiBinDir, nucleiExecutableName()), nil
+}
+
+// InstallNucleiFromArchive installs the nuclei binary of a local release archive (.zip or
+// .tar.gz as published on GitHub) into ~/.wepoc/bin, so that nuclei can be set up without
+// network access. The binary must run on this platform before it replaces an installed one;
+// the installed path and its version are returned.
+func InstallNucleiFromArchive(archivePath string) (string, string, error) {
+	target, err := InstalledNucleiPath()
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create bin directory: %w", err)
+	}
+
+	// 保留可执行文件扩展名，Windows下据此判断是否可执行
+	staged, err := os.CreateTemp(filepath.Dir(target), ".nuclei-*"+filepath.Ext(target))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(staged.Name())
+
+	lower := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		err = copyNucleiFromZip(archivePath, staged)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		err = copyNucleiFromTarGz(archivePath, staged)
+	default:
+		err = fmt.Errorf("不支持的压缩包格式，请选择 .zip 或 .tar.gz 文件")
+	}
+	if closeErr := staged.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.Chmod(staged.Name(), 0755); err != nil {
+		return "", "", err
+	}
+
+	_, version, err := ValidateUserNucleiPath(staged.Name())
+	if err != nil {
+		return "", "", fmt.Errorf("压缩包中的nuclei无法在当前系统运行（%s/%s）: %w", runtime.GOOS, runtime.GOARCH, err)
+	}
+	if err := os.Rename(staged.Name(), target); err != nil {
+		return "", "", fmt.Errorf("failed to install nuclei: %w", err)
+	}
+	logInfof("✅ 已从 %s 安装nuclei %s: %s\n", archivePath, version, target)
+	return target, version, nil
+}
+
+// copyNucleiFromZip copies the nuclei executable of a zip archive to dst
+func copyNucleiFromZip(archivePath string, dst io.Writer) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("无效的zip文件: %w", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || path.Base(file.Name) != nucleiExecutableName() {
+			continue
+		}
+		src, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s from archive: %w", file.Name, err)
+		}
+		defer src.Close()
+		_, err = io.Copy(dst, src)
+		return err
+	}
+	return fmt.Errorf("压缩包中没有找到 %s", nucleiExecutableName())
+}
+
+// copyNucleiFromTarGz copies the nuclei executable of a tar.gz archive to dst
+func copyNucleiFromTarGz(archivePath string, dst io.Writer) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("无效的tar.gz文件: %w", err)
+	}
+	defer gz.Close()
+
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return fmt.Errorf("压缩包中没有找到 %s", nucleiExecutableName())
+		}
+		if err != nil {
+			return fmt.Errorf("无效的tar.gz文件: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == nucleiExecutableName() {
+			_, err = io.Copy(dst, reader)
+			return err
+		}
+	}
+}
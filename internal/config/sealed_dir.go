@@ -0,0 +1,348 @@
+package config
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"wepoc/internal/i18n"
+)
+
+// sealedMagic starts a sealed directory file
+const sealedMagic = "WEPOCSEAL1"
+
+// sealedChunkSize is the plaintext size of one encrypted chunk
+const sealedChunkSize = 1024 * 1024
+
+// SealedDirPaths returns the workspace directories nuclei writes to during scans (results and
+// logs), mapped to where each is kept while it is sealed
+func SealedDirPaths() (map[string]string, error) {
+	wepocDir, err := GetWepocDir()
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]string)
+	for _, name := range []string{DefaultResultsDir, "logs"} {
+		paths[filepath.Join(wepocDir, name)] = filepath.Join(wepocDir, name+".sealed")
+	}
+	return paths, nil
+}
+
+// SealDirectory zips a directory, encrypts it with the secret key into sealedPath and removes
+// the plaintext files. The key must be passphrase protected and unlocked; an empty
+// directory is left as it is. It returns the number of sealed files.
+func SealDirectory(dir, sealedPath string) (int, error) {
+	status, err := GetSecretStoreStatus()
+	if err != nil {
+		return 0, err
+	}
+	if !status.Protected {
+		return 0, i18n.Errorf(i18n.ErrSecretsNotProtected)
+	}
+	key, err := loadSecretKey()
+	if err != nil {
+		return 0, err
+	}
+
+	manifest := &BackupManifest{}
+	archive, err := os.CreateTemp(filepath.Dir(sealedPath), ".seal-*.zip")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp archive: %w", err)
+	}
+	defer os.Remove(archive.Name())
+	writer := zip.NewWriter(archive)
+	err = addBackupPath(writer, manifest, ".", dir, "")
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		archive.Close()
+		return 0, fmt.Errorf("failed to archive %s: %w", dir, err)
+	}
+	if manifest.Files == 0 {
+		archive.Close()
+		return 0, nil
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		archive.Close()
+		return 0, err
+	}
+
+	tmp := sealedPath + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		archive.Close()
+		return 0, fmt.Errorf("failed to create sealed file: %w", err)
+	}
+	err = encryptStream(out, archive, key)
+	archive.Close()
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to encrypt %s: %w", dir, err)
+	}
+	if err := os.Rename(tmp, sealedPath); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to write sealed file: %w", err)
+	}
+
+	// 密文已落盘，再删除明文
+	if err := os.RemoveAll(dir); err != nil {
+		return manifest.Files, fmt.Errorf("failed to remove plaintext files: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return manifest.Files, err
+	}
+	logInfof("🔒 已加密目录 %s (%d 个文件)\n", dir, manifest.Files)
+	return manifest.Files, nil
+}
+
+// UnsealDirectory decrypts a file written by SealDirectory back into dir and removes it;
+// a missing sealed file is not an error
+func UnsealDirectory(sealedPath, dir string) error {
+	in, err := os.Open(sealedPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open sealed file: %w", err)
+	}
+	defer in.Close()
+
+	key, err := loadSecretKey()
+	if err != nil {
+		return err
+	}
+	archive, err := os.CreateTemp(filepath.Dir(sealedPath), ".unseal-*.zip")
+	if err != nil {
+		return fmt.Errorf("failed to create temp archive: %w", err)
+	}
+	defer os.Remove(archive.Name())
+	err = decryptStream(archive, in, key)
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", sealedPath, err)
+	}
+
+	reader, err := zip.OpenReader(archive.Name())
+	if err != nil {
+		return fmt.Errorf("failed to open decrypted archive: %w", err)
+	}
+	defer reader.Close()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, file := range reader.File {
+		if err := extractBackupFile(file, dir); err != nil {
+			return err
+		}
+	}
+
+	in.Close()
+	if err := os.Remove(sealedPath); err != nil {
+		return fmt.Errorf("failed to remove sealed file: %w", err)
+	}
+	logInfof("🔓 已解密目录 %s (%d 个文件)\n", dir, len(reader.File))
+	return nil
+}
+
+// SealFile encrypts a file in place with the secret key, so evidence written while the app
+// is open (attachments, task archives) never sits on disk in the clear; sealed files are
+// left as they are
+func SealFile(path string) error {
+	if sealed, err := isSealedFile(path); err != nil || sealed {
+		return err
+	}
+	key, err := loadSecretKey()
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return writeSealed(path, in, key)
+}
+
+// WriteSealedFile encrypts data with the secret key into path, without the plaintext ever
+// being written to disk
+func WriteSealedFile(path string, data []byte) error {
+	key, err := loadSecretKey()
+	if err != nil {
+		return err
+	}
+	return writeSealed(path, bytes.NewReader(data), key)
+}
+
+// writeSealed encrypts src into a temporary file (mode 0600) that then replaces path
+func writeSealed(path string, src io.Reader, key []byte) error {
+	tmp := path + ".sealing"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create sealed file: %w", err)
+	}
+	err = encryptStream(out, src, key)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if closer, ok := src.(io.Closer); ok {
+		// Windows不能替换仍被打开的文件
+		closer.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to encrypt %s: %w", path, err)
+	}
+	return nil
+}
+
+// ReadSealedFile reads a file written by SealFile; plain files are returned unchanged
+func ReadSealedFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !bytes.HasPrefix(data, []byte(sealedMagic)) {
+		return data, err
+	}
+	key, err := loadSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	var plain bytes.Buffer
+	if err := decryptStream(&plain, bytes.NewReader(data), key); err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	return plain.Bytes(), nil
+}
+
+// isSealedFile reports whether a file starts with the sealed magic
+func isSealedFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	header := make([]byte, len(sealedMagic))
+	if _, err := io.ReadFull(file, header); err != nil {
+		return false, nil
+	}
+	return string(header) == sealedMagic, nil
+}
+
+// encryptStream writes the magic, a random nonce prefix and the AES-GCM sealed chunks of src.
+// Each chunk is length prefixed; its nonce ends with the chunk counter and the last chunk is
+// marked in the additional data, so reordered or truncated files fail to decrypt.
+func encryptStream(dst io.Writer, src io.Reader, key []byte) error {
+	gcm, err := newStreamCipher(key)
+	if err != nil {
+		return err
+	}
+	prefix := make([]byte, gcm.NonceSize()-8)
+	if _, err := rand.Read(prefix); err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(dst)
+	writer.WriteString(sealedMagic)
+	writer.Write(prefix)
+
+	reader := bufio.NewReaderSize(src, sealedChunkSize)
+	chunk := make([]byte, sealedChunkSize)
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(reader, chunk)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+		_, peekErr := reader.Peek(1)
+		final := peekErr != nil
+
+		sealed := gcm.Seal(nil, streamNonce(prefix, counter), chunk[:n], streamAAD(final))
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(sealed)))
+		writer.Write(length[:])
+		if _, err := writer.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return writer.Flush()
+		}
+	}
+}
+
+// decryptStream reverses encryptStream
+func decryptStream(dst io.Writer, src io.Reader, key []byte) error {
+	gcm, err := newStreamCipher(key)
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(src)
+	header := make([]byte, len(sealedMagic)+gcm.NonceSize()-8)
+	if _, err := io.ReadFull(reader, header); err != nil || string(header[:len(sealedMagic)]) != sealedMagic {
+		return fmt.Errorf("not a sealed file")
+	}
+	prefix := header[len(sealedMagic):]
+
+	for counter := uint64(0); ; counter++ {
+		var length [4]byte
+		if _, err := io.ReadFull(reader, length[:]); err != nil {
+			return fmt.Errorf("sealed file is truncated")
+		}
+		size := binary.BigEndian.Uint32(length[:])
+		if size > sealedChunkSize+uint32(gcm.Overhead()) {
+			return fmt.Errorf("sealed file is corrupted")
+		}
+		sealed := make([]byte, size)
+		if _, err := io.ReadFull(reader, sealed); err != nil {
+			return fmt.Errorf("sealed file is truncated")
+		}
+		_, peekErr := reader.Peek(1)
+		final := errors.Is(peekErr, io.EOF)
+
+		plain, err := gcm.Open(nil, streamNonce(prefix, counter), sealed, streamAAD(final))
+		if err != nil {
+			return fmt.Errorf("sealed file is corrupted or was sealed with another key")
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+func newStreamCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func streamNonce(prefix []byte, counter uint64) []byte {
+	nonce := make([]byte, len(prefix)+8)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint64(nonce[len(prefix):], counter)
+	return nonce
+}
+
+func streamAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
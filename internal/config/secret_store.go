@@ -0,0 +1,270 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"wepoc/internal/i18n"
+)
+
+// secretKDFIterations is the PBKDF2-SHA256 work factor of passphrase-derived keys
+const secretKDFIterations = 600000
+
+// secretKeyEnvelope is the secret.key format once the key is protected by a passphrase; a
+// file holding 32 raw bytes is an unprotected key
+type secretKeyEnvelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`        // pbkdf2-sha256
+	Iterations int    `json:"iterations"` // PBKDF2迭代次数
+	Salt       string `json:"salt"`       // base64
+	Key        string `json:"key"`        // 用口令派生的密钥以AES-GCM加密的数据密钥（base64，前缀为nonce）
+}
+
+var (
+	secretKeyMu sync.Mutex
+	unlockedKey []byte // 输入口令后解出的数据密钥
+)
+
+// SecretStoreStatus reports how the key encrypting config secrets is protected
+type SecretStoreStatus struct {
+	Protected bool `json:"protected"` // 密钥受口令保护
+	Locked    bool `json:"locked"`    // 尚未输入口令，加密的配置项暂不可用且配置无法保存
+}
+
+// GetSecretStoreStatus returns whether the secret key is passphrase protected and unlocked
+func GetSecretStoreStatus() (*SecretStoreStatus, error) {
+	secretKeyMu.Lock()
+	defer secretKeyMu.Unlock()
+
+	envelope, _, err := readSecretKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	return &SecretStoreStatus{Protected: envelope != nil, Locked: envelope != nil && unlockedKey == nil}, nil
+}
+
+// UnlockSecrets unlocks a passphrase protected secret key for this session
+func UnlockSecrets(passphrase string) error {
+	secretKeyMu.Lock()
+	defer secretKeyMu.Unlock()
+
+	envelope, _, err := readSecretKeyFile()
+	if err != nil {
+		return err
+	}
+	if envelope == nil {
+		return nil
+	}
+	key, err := openSecretKeyEnvelope(envelope, passphrase)
+	if err != nil {
+		return err
+	}
+	unlockedKey = key
+	return nil
+}
+
+// SetSecretPassphrase protects the secret key with a new passphrase, or removes the
+// protection when newPassphrase is empty. The key itself is kept, so the secrets in
+// config.json stay valid; oldPassphrase is required whenever the key is protected.
+func SetSecretPassphrase(oldPassphrase, newPassphrase string) error {
+	secretKeyMu.Lock()
+	defer secretKeyMu.Unlock()
+
+	envelope, key, err := readSecretKeyFile()
+	if err != nil {
+		return err
+	}
+	if envelope != nil {
+		if key, err = openSecretKeyEnvelope(envelope, oldPassphrase); err != nil {
+			return err
+		}
+	}
+	if key == nil {
+		if key, err = newSecretKey(); err != nil {
+			return err
+		}
+	}
+
+	data := key
+	if newPassphrase != "" {
+		sealed, err := sealSecretKeyEnvelope(key, newPassphrase)
+		if err != nil {
+			return err
+		}
+		if data, err = json.MarshalIndent(sealed, "", "  "); err != nil {
+			return fmt.Errorf("failed to marshal secret key: %w", err)
+		}
+	}
+	if err := writeSecretKeyFile(data); err != nil {
+		return err
+	}
+	unlockedKey = nil
+	if newPassphrase != "" {
+		unlockedKey = key
+	}
+	return nil
+}
+
+// forgetSecretKey drops the unlocked key, e.g. after a workspace restore replaced secret.key
+func forgetSecretKey() {
+	secretKeyMu.Lock()
+	defer secretKeyMu.Unlock()
+	unlockedKey = nil
+}
+
+// loadSecretKey returns the key encrypting config secrets, creating an unprotected key on
+// first use; a protected key must be unlocked first
+func loadSecretKey() ([]byte, error) {
+	secretKeyMu.Lock()
+	defer secretKeyMu.Unlock()
+
+	if unlockedKey != nil {
+		return unlockedKey, nil
+	}
+	envelope, key, err := readSecretKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	if envelope != nil {
+		return nil, i18n.Errorf(i18n.ErrSecretsLocked)
+	}
+	if key != nil {
+		return key, nil
+	}
+
+	if key, err = newSecretKey(); err != nil {
+		return nil, err
+	}
+	if err := writeSecretKeyFile(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// readSecretKeyFile reads secret.key: an envelope when it is passphrase protected, the raw
+// key otherwise, neither when it does not exist yet
+func readSecretKeyFile() (*secretKeyEnvelope, []byte, error) {
+	path, err := secretKeyPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read secret key: %w", err)
+	}
+	if len(data) == 32 {
+		return nil, data, nil
+	}
+	var envelope secretKeyEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.Key == "" {
+		// 损坏的密钥文件无法解密已有的配置项，不自动覆盖
+		return nil, nil, fmt.Errorf("invalid secret key file: %s", path)
+	}
+	return &envelope, nil, nil
+}
+
+// writeSecretKeyFile replaces secret.key atomically
+func writeSecretKeyFile(data []byte) error {
+	path, err := secretKeyPath()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write secret key: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write secret key: %w", err)
+	}
+	return nil
+}
+
+func secretKeyPath() (string, error) {
+	wepocDir, err := GetWepocDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(wepocDir, secretKeyFile), nil
+}
+
+func newSecretKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate secret key: %w", err)
+	}
+	return key, nil
+}
+
+// passphraseCipher derives the AES-GCM cipher wrapping the data key from a passphrase
+func passphraseCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	kek, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sealSecretKeyEnvelope(key []byte, passphrase string) (*secretKeyEnvelope, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := passphraseCipher(passphrase, salt, secretKDFIterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &secretKeyEnvelope{
+		Version:    1,
+		KDF:        "pbkdf2-sha256",
+		Iterations: secretKDFIterations,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Key:        base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, key, nil)),
+	}, nil
+}
+
+func openSecretKeyEnvelope(envelope *secretKeyEnvelope, passphrase string) ([]byte, error) {
+	if envelope.KDF != "pbkdf2-sha256" || envelope.Iterations <= 0 {
+		return nil, fmt.Errorf("unsupported secret key format: %s", envelope.KDF)
+	}
+	salt, err := base64.StdEncoding.DecodeString(envelope.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key salt: %w", err)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(envelope.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %w", err)
+	}
+	gcm, err := passphraseCipher(passphrase, salt, envelope.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid secret key: too short")
+	}
+	key, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrSecretPassphrase)
+	}
+	return key, nil
+}
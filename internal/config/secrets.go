@@ -0,0 +1,272 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"wepoc/internal/i18n"
+	"wepoc/internal/models"
+)
+
+// encryptedPrefix marks config values that are stored encrypted on disk
+const encryptedPrefix = "enc:"
+
+// secretKeyFile is the local key used to encrypt secrets in config.json, optionally
+// protected by a passphrase (see secret_store.go)
+const secretKeyFile = "secret.key"
+
+// EncryptSecret encrypts a secret for storage in the config file
+func EncryptSecret(plain string) (string, error) {
+	if plain == "" || strings.HasPrefix(plain, encryptedPrefix) {
+		return plain, nil
+	}
+
+	key, err := loadSecretKey()
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret decrypts a value produced by EncryptSecret (plain values are returned unchanged)
+func DecryptSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+
+	key, err := loadSecretKey()
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("encrypted value too short")
+	}
+
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plain), nil
+}
+
+// ExportSigningKey returns the Ed25519 key signing the manifests of JSON exports. It is
+// derived from the secret key, so it needs no storage of its own and is only available while
+// the key is unlocked.
+func ExportSigningKey() (ed25519.PrivateKey, error) {
+	key, err := loadSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("wepoc-export-signing-v1"))
+	return ed25519.NewKeyFromSeed(mac.Sum(nil)), nil
+}
+
+// AuditChainKey returns the HMAC key chaining the entries of the audit log. It is derived
+// from the secret key, so the chain cannot be recomputed after editing the log without it.
+func AuditChainKey() ([]byte, error) {
+	key, err := loadSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("wepoc-audit-chain-v1"))
+	return mac.Sum(nil), nil
+}
+
+// secretFields returns pointers to all config fields that are stored encrypted
+func secretFields(config *models.Config) []*string {
+	fields := []*string{
+		&config.NucleiConfig.ProxyPassword,
+		&config.NucleiConfig.InteractshToken,
+		&config.AI.APIKey,
+		&config.Uncover.FofaKey,
+		&config.Uncover.HunterKey,
+		&config.Uncover.ShodanKey,
+		&config.Forwarding.Splunk.Token,
+		&config.Forwarding.Elasticsearch.Password,
+		&config.Forwarding.Elasticsearch.APIKey,
+		&config.Forwarding.EventBus.Password,
+		&config.Forwarding.EventBus.Token,
+	}
+	for i := range config.Profiles {
+		fields = append(fields, &config.Profiles[i].ProxyPassword, &config.Profiles[i].InteractshToken)
+	}
+	return fields
+}
+
+// proxyFields returns pointers to the proxy URLs, which are stored encrypted when they carry
+// a password (callers modifying them must own a copy of the proxy lists)
+func proxyFields(config *models.Config) []*string {
+	fields := []*string{&config.NucleiConfig.ProxyURL}
+	for i := range config.NucleiConfig.ProxyList {
+		fields = append(fields, &config.NucleiConfig.ProxyList[i])
+	}
+	for i := range config.Profiles {
+		profile := &config.Profiles[i]
+		fields = append(fields, &profile.ProxyURL)
+		for j := range profile.ProxyList {
+			fields = append(fields, &profile.ProxyList[j])
+		}
+	}
+	return fields
+}
+
+// proxyPassword returns the password embedded in a proxy URL
+func proxyPassword(proxyURL string) string {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil || parsed.User == nil {
+		return ""
+	}
+	password, _ := parsed.User.Password()
+	return password
+}
+
+// copyConfig returns a copy of the config that can be modified without touching the original
+// secret fields
+func copyConfig(config *models.Config) *models.Config {
+	copied := *config
+	copied.NucleiConfig.ProxyList = append([]string(nil), config.NucleiConfig.ProxyList...)
+	copied.Profiles = append([]models.ConfigProfile(nil), config.Profiles...)
+	for i := range copied.Profiles {
+		copied.Profiles[i].ProxyList = append([]string(nil), config.Profiles[i].ProxyList...)
+	}
+	copied.TemplateRoots = append([]models.TemplateRoot(nil), config.TemplateRoots...)
+	return &copied
+}
+
+// SecretValues returns the configured secrets, for masking them in logs and exports
+func SecretValues(config *models.Config) []string {
+	var secrets []string
+	for _, field := range secretFields(config) {
+		if *field != "" {
+			secrets = append(secrets, *field)
+		}
+	}
+	for _, field := range proxyFields(config) {
+		if password := proxyPassword(*field); password != "" {
+			secrets = append(secrets, password)
+		}
+	}
+	return secrets
+}
+
+// RedactedConfig returns a copy of the config with its secrets replaced by "***"
+func RedactedConfig(config *models.Config) *models.Config {
+	redacted := copyConfig(config)
+	for _, field := range secretFields(redacted) {
+		if *field != "" {
+			*field = "***"
+		}
+	}
+	for _, field := range proxyFields(redacted) {
+		if parsed, err := url.Parse(*field); err == nil && proxyPassword(*field) != "" {
+			parsed.User = url.UserPassword(parsed.User.Username(), "***")
+			*field = parsed.String()
+		}
+	}
+	return redacted
+}
+
+// HasPlaintextSecrets reports whether a config read from disk still holds secrets that are
+// not encrypted (written by an older version or edited by hand)
+func HasPlaintextSecrets(config *models.Config) bool {
+	for _, field := range secretFields(config) {
+		if *field != "" && !strings.HasPrefix(*field, encryptedPrefix) {
+			return true
+		}
+	}
+	for _, field := range proxyFields(config) {
+		if proxyPassword(*field) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptSecrets returns a copy of the config with its secrets encrypted
+func encryptSecrets(config *models.Config) (*models.Config, error) {
+	stored := copyConfig(config)
+	fields := secretFields(stored)
+	for _, field := range proxyFields(stored) {
+		if proxyPassword(*field) != "" {
+			fields = append(fields, field)
+		}
+	}
+	for _, field := range fields {
+		encrypted, err := EncryptSecret(*field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+		}
+		*field = encrypted
+	}
+	return stored, nil
+}
+
+// decryptSecrets decrypts the secrets of a config loaded from disk in place. While the secret
+// key is locked the encrypted secrets are cleared and proxies with credentials are left out.
+func decryptSecrets(config *models.Config) error {
+	fields := append(secretFields(config), proxyFields(config)...)
+	for _, field := range fields {
+		plain, err := DecryptSecret(*field)
+		if i18n.CodeOf(err) == i18n.ErrSecretsLocked {
+			plain, err = "", nil
+		}
+		if err != nil {
+			return err
+		}
+		*field = plain
+	}
+
+	config.NucleiConfig.ProxyList = withoutEmptyProxies(config.NucleiConfig.ProxyList)
+	for i := range config.Profiles {
+		config.Profiles[i].ProxyList = withoutEmptyProxies(config.Profiles[i].ProxyList)
+	}
+	return nil
+}
+
+// withoutEmptyProxies drops the proxies cleared by decryptSecrets (in place)
+func withoutEmptyProxies(list []string) []string {
+	proxies := list[:0]
+	for _, proxy := range list {
+		if proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -45,7 +45,12 @@ const (
 )
 
 type Database struct {
-	db *sql.DB
+	db   *sql.DB
+	path string
+
+	// 结果、HTTP日志、漏洞和进度文档的加密函数（见SetDocumentCipher）
+	sealDocument func(string) (string, error)
+	openDocument func(string) (string, error)
 }
 
 // NewDatabase creates a new database connection
@@ -57,7 +62,7 @@ func NewDatabase(dbPath string) (*Database, error) {
 	}
 
 	// Open database connection
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", dataSourceName(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -68,32 +73,101 @@ func NewDatabase(dbPath string) (*Database, error) {
 	}
 
 	// Initialize database
-	database := &Database{db: db}
-	if err := database.initTables(); err != nil {
-		return nil, fmt.Errorf("failed to initialize tables: %w", err)
+	database := &Database{db: db, path: dbPath}
+	if err := database.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	if err := database.ensureTemplateIndex(); err != nil {
+		return nil, fmt.Errorf("failed to build template index: %w", err)
 	}
 
 	return database, nil
 }
 
-// initTables creates database tables if they don't exist
-func (d *Database) initTables() error {
-	// Create templates table
-	if _, err := d.db.Exec(createTemplatesTable); err != nil {
-		return fmt.Errorf("failed to create templates table: %w", err)
+// Close closes the database connection
+func (d *Database) Close() error {
+	return d.db.Close()
+}
+
+// Snapshot writes a consistent copy of the database to dstPath
+func (d *Database) Snapshot(dstPath string) error {
+	os.Remove(dstPath)
+	if _, err := d.db.Exec("VACUUM INTO ?", dstPath); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the database with the file at srcPath and reopens the connection
+func (d *Database) Restore(srcPath string) error {
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read database backup: %w", err)
 	}
 
-	// Create scan_tasks table
-	if _, err := d.db.Exec(createScanTasksTable); err != nil {
-		return fmt.Errorf("failed to create scan_tasks table: %w", err)
+	if err := d.db.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
 	}
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		os.Remove(d.path + suffix)
+	}
+	writeErr := os.WriteFile(d.path, data, 0644)
 
+	// 无论写入是否成功都重新打开数据库，保证应用可继续使用
+	db, err := sql.Open("sqlite", dataSourceName(d.path))
+	if err != nil {
+		return fmt.Errorf("failed to reopen database: %w", err)
+	}
+	d.db = db
+	if writeErr != nil {
+		return fmt.Errorf("failed to write database: %w", writeErr)
+	}
+	// 旧版本的备份在这里升级到当前结构
+	if err := d.migrate(); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	if err := d.ensureTemplateIndex(); err != nil {
+		return fmt.Errorf("failed to build template index: %w", err)
+	}
 	return nil
 }
 
-// Close closes the database connection
-func (d *Database) Close() error {
-	return d.db.Close()
+// RebaseTemplatePaths rewrites template file paths starting with oldPrefix to newPrefix
+// (e.g. after restoring a workspace from another machine)
+func (d *Database) RebaseTemplatePaths(oldPrefix, newPrefix string) error {
+	if oldPrefix == "" || oldPrefix == newPrefix {
+		return nil
+	}
+	_, err := d.db.Exec(
+		"UPDATE templates SET file_path = ? || substr(file_path, ?) WHERE substr(file_path, 1, ?) = ?",
+		newPrefix, len(oldPrefix)+1, len(oldPrefix), oldPrefix,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to rebase template paths: %w", err)
+	}
+	return nil
+}
+
+// IntegrityCheck runs SQLite's integrity check and returns the problems it reports (none
+// when the database is healthy)
+func (d *Database) IntegrityCheck() ([]string, error) {
+	rows, err := d.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("failed to scan integrity check: %w", err)
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	return problems, rows.Err()
 }
 
 // GetDB returns the underlying database connection
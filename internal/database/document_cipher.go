@@ -0,0 +1,126 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sealedDocumentTables are the tables whose documents hold scan data (findings with their
+// requests and responses, HTTP request logs) and are encrypted by the document cipher
+var sealedDocumentTables = []string{"task_results", "http_request_logs", "task_progress", "findings"}
+
+// SetDocumentCipher sets the functions encrypting and decrypting the documents of the
+// scan data tables. open must accept plain JSON documents unchanged, so rows written before
+// encryption was turned on stay readable; a nil seal stores new documents unencrypted.
+func (d *Database) SetDocumentCipher(seal, open func(string) (string, error)) {
+	d.sealDocument = seal
+	d.openDocument = open
+}
+
+// seal encrypts a document for storage
+func (d *Database) seal(data []byte) (string, error) {
+	if d.sealDocument == nil {
+		return string(data), nil
+	}
+	sealed, err := d.sealDocument(string(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt document: %w", err)
+	}
+	return sealed, nil
+}
+
+// open decrypts a stored document
+func (d *Database) open(data string) ([]byte, error) {
+	if d.openDocument == nil || isPlainDocument(data) {
+		return []byte(data), nil
+	}
+	plain, err := d.openDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt document: %w", err)
+	}
+	return []byte(plain), nil
+}
+
+// isPlainDocument reports whether a stored document is unencrypted JSON
+func isPlainDocument(data string) bool {
+	data = strings.TrimSpace(data)
+	return strings.HasPrefix(data, "{") || strings.HasPrefix(data, "[")
+}
+
+// SealDocuments encrypts the documents still stored in plain text (e.g. after encryption was
+// turned on) and returns how many were encrypted
+func (d *Database) SealDocuments() (int, error) {
+	if d.sealDocument == nil {
+		return 0, nil
+	}
+	sealed := 0
+	for _, table := range sealedDocumentTables {
+		n, err := d.rewriteDocuments(table, false, func(data []byte) []byte { return data })
+		sealed += n
+		if err != nil {
+			return sealed, err
+		}
+	}
+	if sealed > 0 {
+		// 明文仍留在空闲页中，重建数据库文件将其清除
+		if _, err := d.db.Exec("VACUUM"); err != nil {
+			return sealed, fmt.Errorf("failed to vacuum database: %w", err)
+		}
+	}
+	return sealed, nil
+}
+
+// rewriteDocuments applies rewrite to the encrypted (sealed) or the plain documents of a
+// table in one transaction and stores them again with the current cipher. It returns the
+// number of rewritten rows.
+func (d *Database) rewriteDocuments(table string, sealed bool, rewrite func([]byte) []byte) (int, error) {
+	rows, err := d.db.Query("SELECT rowid, data FROM " + table)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	type row struct {
+		id   int64
+		data string
+	}
+	var pending []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.data); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		if isPlainDocument(r.data) != sealed {
+			pending = append(pending, r)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for _, r := range pending {
+		plain, err := d.open(r.data)
+		if err != nil {
+			return 0, err
+		}
+		data, err := d.seal(rewrite(plain))
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec("UPDATE "+table+" SET data = ? WHERE rowid = ?", data, r.id); err != nil {
+			return 0, fmt.Errorf("failed to update %s: %w", table, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit %s: %w", table, err)
+	}
+	return len(pending), nil
+}
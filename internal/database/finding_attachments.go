@@ -0,0 +1,119 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// createFindingAttachmentsTable stores the evidence attached to findings; the files live in
+// the attachments directory under file_name
+const createFindingAttachmentsTable = `
+	CREATE TABLE IF NOT EXISTS finding_attachments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		dedup_key TEXT NOT NULL,
+		task_id INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		name TEXT NOT NULL,
+		file_name TEXT,
+		size INTEGER DEFAULT 0,
+		note TEXT,
+		created_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_finding_attachments_key ON finding_attachments(dedup_key);
+`
+
+// attachmentColumns is the column list scanned by scanAttachment
+const attachmentColumns = "id, dedup_key, task_id, kind, name, COALESCE(file_name, ''), size, COALESCE(note, ''), created_at"
+
+// scanAttachment reads a row selected with attachmentColumns
+func scanAttachment(scan func(dest ...interface{}) error) (*models.FindingAttachment, error) {
+	attachment := &models.FindingAttachment{}
+	err := scan(&attachment.ID, &attachment.DedupKey, &attachment.TaskID, &attachment.Kind,
+		&attachment.Name, &attachment.FileName, &attachment.Size, &attachment.Note, &attachment.CreatedAt)
+	return attachment, err
+}
+
+// AddFindingAttachment stores an attachment and sets its ID
+func (d *Database) AddFindingAttachment(attachment *models.FindingAttachment) error {
+	result, err := d.db.Exec(`
+		INSERT INTO finding_attachments (dedup_key, task_id, kind, name, file_name, size, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, attachment.DedupKey, attachment.TaskID, attachment.Kind, attachment.Name, attachment.FileName,
+		attachment.Size, attachment.Note, attachment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add finding attachment: %w", err)
+	}
+	attachment.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetFindingAttachment returns an attachment by ID, or ErrNotFound
+func (d *Database) GetFindingAttachment(id int64) (*models.FindingAttachment, error) {
+	attachment, err := scanAttachment(d.db.QueryRow("SELECT "+attachmentColumns+" FROM finding_attachments WHERE id = ?", id).Scan)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get finding attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+// ListFindingAttachments returns the attachments of the given dedup keys in the order they
+// were added
+func (d *Database) ListFindingAttachments(dedupKeys ...string) ([]*models.FindingAttachment, error) {
+	attachments := []*models.FindingAttachment{}
+	if len(dedupKeys) == 0 {
+		return attachments, nil
+	}
+
+	args := make([]interface{}, len(dedupKeys))
+	for i, key := range dedupKeys {
+		args[i] = key
+	}
+	rows, err := d.db.Query("SELECT "+attachmentColumns+" FROM finding_attachments WHERE dedup_key IN (?"+
+		strings.Repeat(", ?", len(dedupKeys)-1)+") ORDER BY id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query finding attachments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attachment, err := scanAttachment(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan finding attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// TaskAttachmentFiles returns the file names of the attachments added from a task
+func (d *Database) TaskAttachmentFiles(taskID int64) ([]string, error) {
+	rows, err := d.db.Query("SELECT file_name FROM finding_attachments WHERE task_id = ? AND COALESCE(file_name, '') != ''", taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query finding attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var files []string
+	for rows.Next() {
+		var file string
+		if err := rows.Scan(&file); err != nil {
+			return nil, fmt.Errorf("failed to scan finding attachment: %w", err)
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+// DeleteFindingAttachment removes an attachment record
+func (d *Database) DeleteFindingAttachment(id int64) error {
+	if _, err := d.db.Exec("DELETE FROM finding_attachments WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete finding attachment: %w", err)
+	}
+	return nil
+}
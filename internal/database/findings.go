@@ -0,0 +1,173 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// createFindingsTable stores every unique finding across scans keyed on its dedup key. Rows
+// are kept when tasks are deleted so first-seen times cover the whole history.
+const createFindingsTable = `
+	CREATE TABLE IF NOT EXISTS findings (
+		dedup_key TEXT PRIMARY KEY,
+		template_id TEXT NOT NULL,
+		matched_at TEXT NOT NULL,
+		severity TEXT,
+		first_seen DATETIME NOT NULL,
+		last_seen DATETIME NOT NULL,
+		first_task_id INTEGER NOT NULL,
+		last_task_id INTEGER NOT NULL,
+		last_run_started_at DATETIME NOT NULL,
+		occurrences INTEGER NOT NULL DEFAULT 1,
+		data TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_findings_template ON findings(template_id);
+	CREATE INDEX IF NOT EXISTS idx_findings_last_seen ON findings(last_seen);
+`
+
+// findingSortColumns maps the sort keys accepted by ListFindings to SQL expressions
+var findingSortColumns = map[string]string{
+	"first_seen":  "first_seen",
+	"last_seen":   "last_seen",
+	"occurrences": "occurrences",
+	"severity":    severityRank,
+	"template_id": "template_id COLLATE NOCASE",
+}
+
+// RecordFindings merges the findings of one scan run of a task into the findings table and
+// returns the stored records by dedup key. Recording the same run again (e.g. a resumed scan)
+// does not count it twice.
+func (d *Database) RecordFindings(taskID int64, runStartedAt time.Time, results []*models.NucleiResult) (map[string]*models.Finding, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO findings (dedup_key, template_id, matched_at, severity, first_seen, last_seen,
+			first_task_id, last_task_id, last_run_started_at, occurrences, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?)
+		ON CONFLICT(dedup_key) DO UPDATE SET
+			severity = excluded.severity,
+			first_seen = MIN(first_seen, excluded.first_seen),
+			last_seen = MAX(last_seen, excluded.last_seen),
+			occurrences = occurrences + CASE
+				WHEN last_task_id = excluded.last_task_id AND last_run_started_at = excluded.last_run_started_at
+				THEN 0 ELSE 1 END,
+			last_task_id = excluded.last_task_id,
+			last_run_started_at = excluded.last_run_started_at,
+			data = excluded.data
+	`
+	// 统一为UTC，使时间按字符串比较时有序
+	runStartedAt = runStartedAt.UTC()
+	for _, result := range results {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal finding: %w", err)
+		}
+		sealed, err := d.seal(data)
+		if err != nil {
+			return nil, err
+		}
+		firstSeen, lastSeen := result.Timestamp.UTC(), result.Timestamp.UTC()
+		if result.FirstSeen != nil {
+			firstSeen = result.FirstSeen.UTC()
+		}
+		if result.LastSeen != nil {
+			lastSeen = result.LastSeen.UTC()
+		}
+		_, err = tx.Exec(query,
+			result.DedupKey, result.TemplateID, result.MatchedAt, strings.ToLower(result.Info.Severity),
+			firstSeen, lastSeen, taskID, taskID, runStartedAt, sealed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record finding: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit findings: %w", err)
+	}
+
+	records := make(map[string]*models.Finding, len(results))
+	for _, result := range results {
+		if _, done := records[result.DedupKey]; done {
+			continue
+		}
+		record, err := d.GetFinding(result.DedupKey)
+		if err != nil {
+			return nil, err
+		}
+		records[result.DedupKey] = record
+	}
+	return records, nil
+}
+
+// findingColumns is the column list scanned by scanFinding
+const findingColumns = "dedup_key, template_id, matched_at, COALESCE(severity, ''), first_seen, last_seen, first_task_id, last_task_id, occurrences, data"
+
+// scanFinding reads a row selected with findingColumns
+func (d *Database) scanFinding(scan func(dest ...interface{}) error) (*models.Finding, error) {
+	finding := &models.Finding{}
+	var data string
+	err := scan(&finding.DedupKey, &finding.TemplateID, &finding.MatchedAt, &finding.Severity,
+		&finding.FirstSeen, &finding.LastSeen, &finding.FirstTaskID, &finding.LastTaskID,
+		&finding.Occurrences, &data)
+	if err != nil {
+		return nil, err
+	}
+	document, err := d.open(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(document, &finding.Result); err != nil {
+		return nil, fmt.Errorf("failed to decode finding: %w", err)
+	}
+	return finding, nil
+}
+
+// GetFinding returns the stored record of a dedup key, or ErrNotFound
+func (d *Database) GetFinding(dedupKey string) (*models.Finding, error) {
+	finding, err := d.scanFinding(d.db.QueryRow("SELECT "+findingColumns+" FROM findings WHERE dedup_key = ?", dedupKey).Scan)
+	if err == ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get finding: %w", err)
+	}
+	return finding, nil
+}
+
+// ListFindings returns all unique findings sorted by sortBy (first_seen, last_seen,
+// occurrences, severity or template_id; prefix "-" for descending), newest first by default
+func (d *Database) ListFindings(sortBy string) ([]*models.Finding, error) {
+	orderBy := "last_seen DESC, dedup_key DESC"
+	direction := "ASC"
+	if strings.HasPrefix(sortBy, "-") {
+		direction = "DESC"
+		sortBy = sortBy[1:]
+	}
+	if column, ok := findingSortColumns[sortBy]; ok {
+		orderBy = fmt.Sprintf("%s %s, dedup_key %s", column, direction, direction)
+	}
+
+	rows, err := d.db.Query("SELECT " + findingColumns + " FROM findings ORDER BY " + orderBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query findings: %w", err)
+	}
+	defer rows.Close()
+
+	findings := []*models.Finding{}
+	for rows.Next() {
+		finding, err := d.scanFinding(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan finding: %w", err)
+		}
+		findings = append(findings, finding)
+	}
+	return findings, rows.Err()
+}
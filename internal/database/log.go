@@ -0,0 +1,32 @@
+package database
+
+import "fmt"
+
+// logHandler receives the background messages of the package (see SetLogHandler)
+var logHandler func(level, message string)
+
+// SetLogHandler routes the background messages of the package to the application's level
+// logger; until it is set they are printed to stdout
+func SetLogHandler(handler func(level, message string)) {
+	logHandler = handler
+}
+
+// logMessage formats a message and hands it to the log handler
+func logMessage(level, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if logHandler == nil {
+		fmt.Print(message)
+		return
+	}
+	logHandler(level, message)
+}
+
+// logInfof logs an informational message
+func logInfof(format string, args ...interface{}) {
+	logMessage("info", format, args...)
+}
+
+// logWarnf logs a warning message
+func logWarnf(format string, args ...interface{}) {
+	logMessage("warn", format, args...)
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// busyTimeout is how long a connection waits for a lock held by another connection
+const busyTimeout = 5 * time.Second
+
+const createSchemaMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+`
+
+// migration is one versioned schema change. Migrations run in order inside a transaction
+// and are never edited once released; schema changes are added as new migrations.
+type migration struct {
+	version int
+	name    string
+	up      string
+}
+
+// migrations is the schema history. Version 1 and 2 use IF NOT EXISTS because databases
+// created before versioning already contain those tables.
+var migrations = []migration{
+	{version: 1, name: "templates and scan_tasks", up: createTemplatesTable + createScanTasksTable},
+	{version: 2, name: "task storage", up: createTaskStoreTables},
+	{version: 3, name: "template listing indexes", up: `
+		CREATE INDEX IF NOT EXISTS idx_templates_name ON templates(name COLLATE NOCASE);
+		CREATE INDEX IF NOT EXISTS idx_templates_created_at ON templates(created_at);
+	`},
+	{version: 4, name: "template full-text search", up: createTemplateSearchIndex},
+	{version: 5, name: "template categories and favorites", up: addTemplateCategories},
+	{version: 6, name: "template sources", up: createTemplateSourcesTable},
+	{version: 7, name: "task template snapshots", up: createTemplateSnapshotTables},
+	{version: 8, name: "template scan statistics", up: createTemplateStatsTable},
+	{version: 9, name: "template trust levels", up: addTemplateTrust},
+	{version: 10, name: "template protocols", up: addTemplateProtocols},
+	{version: 11, name: "finding deduplication", up: createFindingsTable},
+	{version: 12, name: "finding attachments", up: createFindingAttachmentsTable},
+	{version: 13, name: "task id sequence", up: createTaskIDSequence},
+}
+
+// AppliedMigration is a schema migration recorded in the database
+type AppliedMigration struct {
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// dataSourceName returns the connection string of a database file: WAL journaling so
+// readers don't block the writer, a busy timeout instead of immediate SQLITE_BUSY errors,
+// and immediate write transactions so concurrent writers queue on the busy timeout
+func dataSourceName(dbPath string) string {
+	return fmt.Sprintf("%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_txlock=immediate",
+		dbPath, busyTimeout.Milliseconds())
+}
+
+// migrate applies all migrations newer than the current schema version
+func (d *Database) migrate() error {
+	if _, err := d.db.Exec(createSchemaMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	current, err := d.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := d.applyMigration(m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+		logInfof("🗄️ 数据库迁移: v%d %s\n", m.version, m.name)
+	}
+	return nil
+}
+
+// applyMigration runs a migration and records it in one transaction
+func (d *Database) applyMigration(m migration) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.up); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.version, m.name); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+	return tx.Commit()
+}
+
+// SchemaVersion returns the version of the newest applied migration (0 for a new database)
+func (d *Database) SchemaVersion() (int, error) {
+	var version sql.NullInt64
+	if err := d.db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to query schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// AppliedMigrations lists the applied schema migrations in order
+func (d *Database) AppliedMigrations() ([]*AppliedMigration, error) {
+	rows, err := d.db.Query("SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var applied []*AppliedMigration
+	for rows.Next() {
+		m := &AppliedMigration{}
+		if err := rows.Scan(&m.Version, &m.Name, &m.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema migration: %w", err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// resultSortColumns maps the sort keys accepted by ListTaskResults to SQL expressions
+var resultSortColumns = map[string]string{
+	"created_at":  "created_at",
+	"found_vulns": "found_vulns",
+	"status":      "status COLLATE NOCASE",
+}
+
+// resultOrderBy builds the ORDER BY clause for a sort key; a leading "-" sorts descending.
+// Unknown keys fall back to newest first.
+func resultOrderBy(sortBy string) string {
+	direction := "ASC"
+	if strings.HasPrefix(sortBy, "-") {
+		direction = "DESC"
+		sortBy = sortBy[1:]
+	}
+	column, ok := resultSortColumns[sortBy]
+	if !ok {
+		return "created_at DESC, task_id DESC"
+	}
+	return fmt.Sprintf("%s %s, task_id %s", column, direction, direction)
+}
+
+// resultWhere builds the WHERE clause and arguments of a task result filter. The time range
+// is applied by the caller on the decoded documents.
+func resultWhere(filter models.TaskResultFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if !filter.IncludeEmpty {
+		conditions = append(conditions, "found_vulns > 0")
+	}
+
+	var statuses []string
+	for _, status := range filter.Statuses {
+		if status = strings.ToLower(strings.TrimSpace(status)); status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+	if len(statuses) > 0 {
+		conditions = append(conditions, "LOWER(status) IN (?"+strings.Repeat(", ?", len(statuses)-1)+")")
+		for _, status := range statuses {
+			args = append(args, status)
+		}
+	}
+
+	if filter.MinVulns > 0 {
+		conditions = append(conditions, "found_vulns >= ?")
+		args = append(args, filter.MinVulns)
+	}
+	if filter.MaxVulns > 0 {
+		conditions = append(conditions, "found_vulns <= ?")
+		args = append(args, filter.MaxVulns)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// ListTaskResults returns the result documents matching a filter, sorted by sortBy
+// (created_at, found_vulns or status; prefix "-" for descending)
+func (d *Database) ListTaskResults(sortBy string, filter models.TaskResultFilter) ([][]byte, error) {
+	where, args := resultWhere(filter)
+	return d.queryDocuments("SELECT data FROM task_results"+where+" ORDER BY "+resultOrderBy(sortBy), args...)
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// taskSortColumns maps the sort keys accepted by ListTasksFiltered to SQL expressions
+var taskSortColumns = map[string]string{
+	"created_at":  "created_at",
+	"updated_at":  "updated_at",
+	"end_time":    "end_time",
+	"name":        "name COLLATE NOCASE",
+	"found_vulns": "found_vulns",
+	"status":      "status COLLATE NOCASE",
+}
+
+// taskOrderBy builds the ORDER BY clause for a sort key; a leading "-" sorts descending.
+// Unknown keys fall back to newest first.
+func taskOrderBy(sortBy string) string {
+	direction := "ASC"
+	if strings.HasPrefix(sortBy, "-") {
+		direction = "DESC"
+		sortBy = sortBy[1:]
+	}
+	column, ok := taskSortColumns[sortBy]
+	if !ok {
+		return "created_at DESC, id DESC"
+	}
+	return fmt.Sprintf("%s %s, id %s", column, direction, direction)
+}
+
+// taskWhere builds the WHERE clause and arguments of a task filter. Labels and the time
+// range are applied by the caller on the decoded documents.
+func taskWhere(filter models.TaskFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if keyword := strings.TrimSpace(filter.Keyword); keyword != "" {
+		conditions = append(conditions, "name LIKE ?")
+		args = append(args, "%"+keyword+"%")
+	}
+
+	var statuses []string
+	for _, status := range filter.Statuses {
+		if status = strings.ToLower(strings.TrimSpace(status)); status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+	if len(statuses) > 0 {
+		conditions = append(conditions, "LOWER(status) IN (?"+strings.Repeat(", ?", len(statuses)-1)+")")
+		for _, status := range statuses {
+			args = append(args, status)
+		}
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// ListTasksFiltered returns the task documents matching a filter, sorted by sortBy
+// (created_at, updated_at, end_time, name, found_vulns or status; prefix "-" for descending)
+func (d *Database) ListTasksFiltered(sortBy string, filter models.TaskFilter) ([][]byte, error) {
+	where, args := taskWhere(filter)
+	return d.queryDocuments("SELECT data FROM tasks"+where+" ORDER BY "+taskOrderBy(sortBy), args...)
+}
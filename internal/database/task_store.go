@@ -0,0 +1,414 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Task storage tables. Tasks, results, HTTP request logs and progress snapshots are
+// stored as JSON documents; the columns next to them exist for filtering and sorting.
+const createTaskStoreTables = `
+	CREATE TABLE IF NOT EXISTS tasks (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		status TEXT NOT NULL,
+		found_vulns INTEGER DEFAULT 0,
+		archived INTEGER DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		end_time DATETIME,
+		data TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
+	CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON tasks(created_at);
+
+	CREATE TABLE IF NOT EXISTS task_results (
+		task_id INTEGER PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+		status TEXT,
+		found_vulns INTEGER DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_task_results_found_vulns ON task_results(found_vulns);
+
+	CREATE TABLE IF NOT EXISTS http_request_logs (
+		task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		seq INTEGER NOT NULL,
+		template_id TEXT,
+		target TEXT,
+		status_code INTEGER,
+		is_vuln_found INTEGER DEFAULT 0,
+		data TEXT NOT NULL,
+		PRIMARY KEY (task_id, seq)
+	);
+	CREATE INDEX IF NOT EXISTS idx_http_request_logs_template ON http_request_logs(task_id, template_id);
+
+	CREATE TABLE IF NOT EXISTS task_progress (
+		task_id INTEGER PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+		updated_at DATETIME NOT NULL,
+		data TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS storage_migrations (
+		name TEXT PRIMARY KEY,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+`
+
+// createTaskIDSequence records the highest task ID ever used, so IDs of deleted tasks are not
+// handed out again (findings, attachments and exports keep referring to them). Databases
+// from before the sequence start from the highest ID still referenced.
+const createTaskIDSequence = `
+	CREATE TABLE IF NOT EXISTS task_id_sequence (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		last_id INTEGER NOT NULL
+	);
+	INSERT OR IGNORE INTO task_id_sequence (id, last_id) SELECT 1, MAX(
+		COALESCE((SELECT MAX(id) FROM tasks), 0),
+		COALESCE((SELECT MAX(last_task_id) FROM findings), 0),
+		COALESCE((SELECT MAX(task_id) FROM finding_attachments), 0)
+	);
+`
+
+// ErrNotFound is returned when a stored task document does not exist
+var ErrNotFound = sql.ErrNoRows
+
+// TaskRecord is a task document with its indexed columns
+type TaskRecord struct {
+	ID         int64
+	Name       string
+	Status     string
+	FoundVulns int
+	Archived   bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	EndTime    *time.Time
+	Data       []byte // TaskConfig JSON
+}
+
+// HTTPLogRecord is one stored HTTP request/response pair of a task
+type HTTPLogRecord struct {
+	Seq         int64
+	TemplateID  string
+	Target      string
+	StatusCode  int
+	IsVulnFound bool
+	Data        []byte // HTTPRequestLog JSON
+}
+
+// SaveTask inserts or replaces a task document
+func (d *Database) SaveTask(record *TaskRecord) error {
+	query := `
+		INSERT INTO tasks (id, name, status, found_vulns, archived, created_at, updated_at, end_time, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name, status = excluded.status, found_vulns = excluded.found_vulns,
+			archived = excluded.archived, updated_at = excluded.updated_at, end_time = excluded.end_time,
+			data = excluded.data
+	`
+	_, err := d.db.Exec(query,
+		record.ID,
+		record.Name,
+		record.Status,
+		record.FoundVulns,
+		record.Archived,
+		record.CreatedAt,
+		record.UpdatedAt,
+		record.EndTime,
+		string(record.Data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save task: %w", err)
+	}
+	return nil
+}
+
+// GetTask returns the document of a task, or ErrNotFound
+func (d *Database) GetTask(id int64) ([]byte, error) {
+	var data string
+	err := d.db.QueryRow("SELECT data FROM tasks WHERE id = ?", id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	return []byte(data), nil
+}
+
+// ListTasks returns the documents of all tasks, newest first
+func (d *Database) ListTasks() ([][]byte, error) {
+	return d.queryDocuments("SELECT data FROM tasks ORDER BY created_at DESC, id DESC")
+}
+
+// TaskExists reports whether a task is stored
+func (d *Database) TaskExists(id int64) (bool, error) {
+	var count int
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE id = ?", id).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to query task: %w", err)
+	}
+	return count > 0, nil
+}
+
+// MaxTaskID returns the highest task ID ever used, including deleted tasks (0 when there
+// are none)
+func (d *Database) MaxTaskID() (int64, error) {
+	var maxID sql.NullInt64
+	query := "SELECT MAX(COALESCE((SELECT MAX(id) FROM tasks), 0), COALESCE((SELECT last_id FROM task_id_sequence), 0))"
+	if err := d.db.QueryRow(query).Scan(&maxID); err != nil {
+		return 0, fmt.Errorf("failed to query max task id: %w", err)
+	}
+	return maxID.Int64, nil
+}
+
+// DeleteTask removes a task together with its result, HTTP logs, progress, template snapshots
+// and finding attachments; its ID is kept in the task ID sequence so it is never reused
+func (d *Database) DeleteTask(id int64) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE task_id_sequence SET last_id = MAX(last_id, ?) WHERE id = 1", id); err != nil {
+		return fmt.Errorf("failed to update task id sequence: %w", err)
+	}
+	for _, table := range []string{"task_results", "http_request_logs", "task_progress", "task_template_snapshots", "finding_attachments"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE task_id = ?", id); err != nil {
+			return fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM tasks WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	if _, err := tx.Exec(pruneTemplateBlobs); err != nil {
+		return fmt.Errorf("failed to prune template contents: %w", err)
+	}
+	return tx.Commit()
+}
+
+// SaveTaskResult inserts or replaces the result document of a task
+func (d *Database) SaveTaskResult(taskID int64, status string, foundVulns int, createdAt time.Time, data []byte) error {
+	query := `
+		INSERT INTO task_results (task_id, status, found_vulns, created_at, data)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(task_id) DO UPDATE SET
+			status = excluded.status, found_vulns = excluded.found_vulns,
+			created_at = excluded.created_at, data = excluded.data
+	`
+	sealed, err := d.seal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := d.db.Exec(query, taskID, status, foundVulns, createdAt, sealed); err != nil {
+		return fmt.Errorf("failed to save task result: %w", err)
+	}
+	return nil
+}
+
+// GetTaskResult returns the result document of a task, or ErrNotFound
+func (d *Database) GetTaskResult(taskID int64) ([]byte, error) {
+	var data string
+	err := d.db.QueryRow("SELECT data FROM task_results WHERE task_id = ?", taskID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task result: %w", err)
+	}
+	return d.open(data)
+}
+
+// ListTaskResultsWithVulns returns the result documents that contain findings, newest first
+func (d *Database) ListTaskResultsWithVulns() ([][]byte, error) {
+	return d.queryDocuments("SELECT data FROM task_results WHERE found_vulns > 0 ORDER BY created_at DESC, task_id DESC")
+}
+
+// DeleteTaskResult removes the result document of a task
+func (d *Database) DeleteTaskResult(taskID int64) error {
+	if _, err := d.db.Exec("DELETE FROM task_results WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("failed to delete task result: %w", err)
+	}
+	return nil
+}
+
+// ReplaceHTTPLogs replaces all stored HTTP request logs of a task in one transaction
+func (d *Database) ReplaceHTTPLogs(taskID int64, logs []*HTTPLogRecord) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM http_request_logs WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("failed to clear HTTP logs: %w", err)
+	}
+	stmt, err := tx.Prepare(`
+		INSERT INTO http_request_logs (task_id, seq, template_id, target, status_code, is_vuln_found, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, log := range logs {
+		data, err := d.seal(log.Data)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(taskID, log.Seq, log.TemplateID, log.Target, log.StatusCode, log.IsVulnFound, data); err != nil {
+			return fmt.Errorf("failed to insert HTTP log: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetHTTPLogs returns the HTTP request log documents of a task in request order
+func (d *Database) GetHTTPLogs(taskID int64) ([][]byte, error) {
+	return d.queryDocuments("SELECT data FROM http_request_logs WHERE task_id = ? ORDER BY seq", taskID)
+}
+
+// CountHTTPLogsByTarget returns the number of logged HTTP requests of a task per target
+func (d *Database) CountHTTPLogsByTarget(taskID int64) (map[string]int, error) {
+	rows, err := d.db.Query("SELECT COALESCE(target, ''), COUNT(*) FROM http_request_logs WHERE task_id = ? GROUP BY target", taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count HTTP logs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var target string
+		var count int
+		if err := rows.Scan(&target, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan HTTP log count: %w", err)
+		}
+		counts[target] = count
+	}
+	return counts, rows.Err()
+}
+
+// DeleteHTTPLogs removes the HTTP request logs of a task
+func (d *Database) DeleteHTTPLogs(taskID int64) error {
+	if _, err := d.db.Exec("DELETE FROM http_request_logs WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("failed to delete HTTP logs: %w", err)
+	}
+	return nil
+}
+
+// SaveTaskProgress stores the latest progress snapshot of a task
+func (d *Database) SaveTaskProgress(taskID int64, data []byte) error {
+	query := `
+		INSERT INTO task_progress (task_id, updated_at, data) VALUES (?, ?, ?)
+		ON CONFLICT(task_id) DO UPDATE SET updated_at = excluded.updated_at, data = excluded.data
+	`
+	sealed, err := d.seal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := d.db.Exec(query, taskID, time.Now(), sealed); err != nil {
+		return fmt.Errorf("failed to save task progress: %w", err)
+	}
+	return nil
+}
+
+// GetTaskProgress returns the latest progress snapshot of a task, or ErrNotFound
+func (d *Database) GetTaskProgress(taskID int64) ([]byte, error) {
+	var data string
+	err := d.db.QueryRow("SELECT data FROM task_progress WHERE task_id = ?", taskID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task progress: %w", err)
+	}
+	return d.open(data)
+}
+
+// TaskStorageSize returns the bytes a task occupies in the database (documents only)
+func (d *Database) TaskStorageSize(taskID int64) (int64, error) {
+	query := `
+		SELECT
+			COALESCE((SELECT LENGTH(data) FROM tasks WHERE id = ?), 0) +
+			COALESCE((SELECT LENGTH(data) FROM task_results WHERE task_id = ?), 0) +
+			COALESCE((SELECT SUM(LENGTH(data)) FROM http_request_logs WHERE task_id = ?), 0) +
+			COALESCE((SELECT LENGTH(data) FROM task_progress WHERE task_id = ?), 0)
+	`
+	var size int64
+	if err := d.db.QueryRow(query, taskID, taskID, taskID, taskID).Scan(&size); err != nil {
+		return 0, fmt.Errorf("failed to query task storage size: %w", err)
+	}
+	return size, nil
+}
+
+// RebaseTaskPaths rewrites workspace paths starting with oldPrefix in task and result
+// documents (e.g. after restoring a workspace from another machine)
+func (d *Database) RebaseTaskPaths(oldPrefix, newPrefix string) error {
+	if oldPrefix == "" || oldPrefix == newPrefix {
+		return nil
+	}
+	// 文档中的路径经过JSON转义（如Windows反斜杠），替换前同样转义
+	oldJSON, _ := json.Marshal(oldPrefix)
+	newJSON, _ := json.Marshal(newPrefix)
+	oldEscaped := string(oldJSON[1 : len(oldJSON)-1])
+	newEscaped := string(newJSON[1 : len(newJSON)-1])
+
+	for _, table := range []string{"tasks", "task_results"} {
+		query := "UPDATE " + table + " SET data = REPLACE(data, ?, ?) WHERE instr(data, ?) > 0"
+		if _, err := d.db.Exec(query, oldEscaped, newEscaped, oldEscaped); err != nil {
+			return fmt.Errorf("failed to rebase paths in %s: %w", table, err)
+		}
+	}
+	// 加密的结果文档需解密后替换
+	if d.openDocument != nil {
+		_, err := d.rewriteDocuments("task_results", true, func(data []byte) []byte {
+			return []byte(strings.ReplaceAll(string(data), oldEscaped, newEscaped))
+		})
+		return err
+	}
+	return nil
+}
+
+// MigrationApplied reports whether a named storage migration has already run
+func (d *Database) MigrationApplied(name string) (bool, error) {
+	var count int
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM storage_migrations WHERE name = ?", name).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to query storage migrations: %w", err)
+	}
+	return count > 0, nil
+}
+
+// MarkMigrationApplied records that a named storage migration has run
+func (d *Database) MarkMigrationApplied(name string) error {
+	if _, err := d.db.Exec("INSERT OR IGNORE INTO storage_migrations (name) VALUES (?)", name); err != nil {
+		return fmt.Errorf("failed to record storage migration: %w", err)
+	}
+	return nil
+}
+
+// queryDocuments runs a query selecting a single data column
+func (d *Database) queryDocuments(query string, args ...interface{}) ([][]byte, error) {
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents [][]byte
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		document, err := d.open(data)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	return documents, rows.Err()
+}
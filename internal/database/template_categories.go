@@ -0,0 +1,92 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// UncategorizedFilter selects templates without a category in TemplateFilter.Category
+const UncategorizedFilter = "-"
+
+const addTemplateCategories = `
+	ALTER TABLE templates ADD COLUMN category TEXT NOT NULL DEFAULT '';
+	ALTER TABLE templates ADD COLUMN favorite INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX IF NOT EXISTS idx_templates_category ON templates(category);
+	CREATE INDEX IF NOT EXISTS idx_templates_favorite ON templates(favorite) WHERE favorite = 1;
+`
+
+// normalizeCategory trims a category path and uses forward slashes between levels
+func normalizeCategory(category string) string {
+	parts := strings.FieldsFunc(category, func(r rune) bool { return r == '/' || r == '\\' })
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return strings.Trim(strings.Join(parts, "/"), "/")
+}
+
+// categoryCondition matches a category and its subcategories
+func categoryCondition(category string) (string, []interface{}) {
+	if category == UncategorizedFilter {
+		return "category = ''", nil
+	}
+	category = normalizeCategory(category)
+	return "(category = ? OR category LIKE ?)", []interface{}{category, category + "/%"}
+}
+
+// ListTemplateCategories returns every category with its template count, sorted by name
+func (d *Database) ListTemplateCategories() ([]*models.TemplateCategory, error) {
+	rows, err := d.db.Query("SELECT category, COUNT(*) FROM templates GROUP BY category ORDER BY category COLLATE NOCASE")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query template categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []*models.TemplateCategory{}
+	for rows.Next() {
+		category := &models.TemplateCategory{}
+		if err := rows.Scan(&category.Name, &category.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan template category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+// SetTemplateCategory assigns a category to templates (by template ID); an empty category
+// moves them to uncategorized. Returns the number of templates updated.
+func (d *Database) SetTemplateCategory(templateIDs []string, category string) (int, error) {
+	if len(templateIDs) == 0 {
+		return 0, nil
+	}
+	args := []interface{}{normalizeCategory(category)}
+	for _, id := range templateIDs {
+		args = append(args, id)
+	}
+	result, err := d.db.Exec(
+		"UPDATE templates SET category = ? WHERE template_id IN (?"+strings.Repeat(", ?", len(templateIDs)-1)+")",
+		args...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set template category: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// SetTemplateFavorite marks or unmarks a template as favorite
+func (d *Database) SetTemplateFavorite(templateID string, favorite bool) error {
+	result, err := d.db.Exec("UPDATE templates SET favorite = ? WHERE template_id = ?", favorite, templateID)
+	if err != nil {
+		return fmt.Errorf("failed to update favorite: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("template not found")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// templateProtocols maps each protocol to the top-level template keys that declare it, in
+// the order the scanner reports them
+var templateProtocols = []struct {
+	name string
+	keys []string
+}{
+	{"http", []string{"http", "requests"}},
+	{"dns", []string{"dns"}},
+	{"network", []string{"network", "tcp"}},
+	{"ssl", []string{"ssl"}},
+	{"websocket", []string{"websocket"}},
+	{"whois", []string{"whois"}},
+	{"javascript", []string{"javascript"}},
+	{"code", []string{"code"}},
+	{"headless", []string{"headless"}},
+	{"file", []string{"file"}},
+	{"workflows", []string{"workflows"}},
+}
+
+// addTemplateProtocols stores the protocols of each template (comma separated). Existing
+// templates are filled in from the top-level keys of their indexed YAML content; new ones
+// get the protocols detected by the template parser.
+var addTemplateProtocols = `
+	ALTER TABLE templates ADD COLUMN protocols TEXT NOT NULL DEFAULT '';
+	UPDATE templates SET protocols = ` + protocolsFromContent("f.content") + `
+	FROM templates_fts f WHERE f.rowid = templates.id;
+`
+
+// protocolsFromContent builds an SQL expression listing the protocols of a template from
+// its YAML content; top-level keys start a line (the first line holds the id)
+func protocolsFromContent(column string) string {
+	parts := make([]string, 0, len(templateProtocols))
+	for _, protocol := range templateProtocols {
+		var conditions []string
+		for _, key := range protocol.keys {
+			conditions = append(conditions, fmt.Sprintf("%s LIKE '%%' || char(10) || '%s:%%'", column, key))
+		}
+		parts = append(parts, fmt.Sprintf("CASE WHEN %s THEN ',%s' ELSE '' END", strings.Join(conditions, " OR "), protocol.name))
+	}
+	return "LTRIM(" + strings.Join(parts, " || ") + ", ',')"
+}
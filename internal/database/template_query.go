@@ -0,0 +1,243 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+const (
+	// defaultTemplatePageSize is used when a page is requested without a limit
+	defaultTemplatePageSize = 50
+	// maxTemplatePageSize bounds a single page request
+	maxTemplatePageSize = 1000
+)
+
+// templateColumns is the column list scanned by scanTemplates
+const templateColumns = "id, template_id, name, severity, tags, author, file_path, description, category, favorite, trust, protocols, created_at"
+
+// severityRank orders severities from most to least severe
+const severityRank = `CASE LOWER(severity)
+	WHEN 'critical' THEN 5 WHEN 'high' THEN 4 WHEN 'medium' THEN 3
+	WHEN 'low' THEN 2 WHEN 'info' THEN 1 ELSE 0 END`
+
+// templateSortColumns maps the sort keys accepted by GetTemplatesPage to SQL expressions
+var templateSortColumns = map[string]string{
+	"name":        "name COLLATE NOCASE",
+	"template_id": "template_id COLLATE NOCASE",
+	"severity":    severityRank,
+	"author":      "author COLLATE NOCASE",
+	"category":    "category COLLATE NOCASE",
+	"created_at":  "created_at",
+}
+
+// templateOrderBy builds the ORDER BY clause for a sort key; a leading "-" sorts descending.
+// Unknown keys fall back to newest first.
+func templateOrderBy(sortBy string) string {
+	direction := "ASC"
+	if strings.HasPrefix(sortBy, "-") {
+		direction = "DESC"
+		sortBy = sortBy[1:]
+	}
+	column, ok := templateSortColumns[sortBy]
+	if !ok {
+		return "created_at DESC, id DESC"
+	}
+	return fmt.Sprintf("%s %s, id %s", column, direction, direction)
+}
+
+// templateWhere builds the WHERE clause and arguments of a template filter
+func templateWhere(filter models.TemplateFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if keyword := strings.TrimSpace(filter.Keyword); keyword != "" {
+		if match, ok := ftsMatchQuery(keyword); ok {
+			conditions = append(conditions, "id IN (SELECT rowid FROM templates_fts WHERE templates_fts MATCH ?)")
+			args = append(args, match)
+		} else {
+			// 太短的词无法使用三元组索引，退回到元数据的模糊匹配
+			pattern := "%" + keyword + "%"
+			conditions = append(conditions, "(name LIKE ? OR description LIKE ? OR tags LIKE ? OR template_id LIKE ? OR author LIKE ?)")
+			args = append(args, pattern, pattern, pattern, pattern, pattern)
+		}
+	}
+
+	var severities []string
+	for _, severity := range filter.Severities {
+		if severity = strings.ToLower(strings.TrimSpace(severity)); severity != "" {
+			severities = append(severities, severity)
+		}
+	}
+	if len(severities) > 0 {
+		conditions = append(conditions, "LOWER(severity) IN (?"+strings.Repeat(", ?", len(severities)-1)+")")
+		for _, severity := range severities {
+			args = append(args, severity)
+		}
+	}
+
+	// 标签以逗号分隔存储，两端补逗号后按完整标签匹配
+	for _, tag := range filter.Tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			conditions = append(conditions, "(',' || REPLACE(LOWER(tags), ' ', '') || ',') LIKE ?")
+			args = append(args, "%,"+tag+",%")
+		}
+	}
+
+	if author := strings.TrimSpace(filter.Author); author != "" {
+		conditions = append(conditions, "author LIKE ?")
+		args = append(args, "%"+author+"%")
+	}
+
+	if filter.Category != "" {
+		condition, categoryArgs := categoryCondition(filter.Category)
+		conditions = append(conditions, condition)
+		args = append(args, categoryArgs...)
+	}
+
+	if filter.Favorites {
+		conditions = append(conditions, "favorite = 1")
+	}
+
+	if filter.Trust != "" {
+		conditions = append(conditions, "trust = ?")
+		args = append(args, filter.Trust)
+	}
+
+	if protocol := strings.ToLower(strings.TrimSpace(filter.Protocol)); protocol != "" {
+		conditions = append(conditions, "(',' || protocols || ',') LIKE ?")
+		args = append(args, "%,"+protocol+",%")
+	}
+
+	// 工作流与普通模板分开列出
+	switch filter.Kind {
+	case models.TemplateKindAll:
+	case models.TemplateKindWorkflow:
+		conditions = append(conditions, "(',' || protocols || ',') LIKE '%,workflows,%'")
+	default:
+		conditions = append(conditions, "(',' || protocols || ',') NOT LIKE '%,workflows,%'")
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// CountTemplates returns the number of templates matching a filter
+func (d *Database) CountTemplates(filter models.TemplateFilter) (int, error) {
+	where, args := templateWhere(filter)
+	var count int
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM templates"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count templates: %w", err)
+	}
+	return count, nil
+}
+
+// GetTemplatesPage returns one page of the templates matching a filter, sorted by sortBy
+// (name, template_id, severity, author, category or created_at; prefix "-" for descending)
+func (d *Database) GetTemplatesPage(offset, limit int, sortBy string, filter models.TemplateFilter) (*models.TemplatePage, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = defaultTemplatePageSize
+	}
+	if limit > maxTemplatePageSize {
+		limit = maxTemplatePageSize
+	}
+
+	total, err := d.CountTemplates(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := templateWhere(filter)
+	query := "SELECT " + templateColumns + " FROM templates" + where +
+		" ORDER BY " + templateOrderBy(sortBy) + " LIMIT ? OFFSET ?"
+	rows, err := d.db.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+
+	items, err := scanTemplates(rows)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TemplatePage{Items: items, Total: total, Offset: offset, Limit: limit}, nil
+}
+
+// ListTemplates returns all templates matching a filter without paging
+func (d *Database) ListTemplates(sortBy string, filter models.TemplateFilter) ([]*models.Template, error) {
+	where, args := templateWhere(filter)
+	rows, err := d.db.Query("SELECT "+templateColumns+" FROM templates"+where+" ORDER BY "+templateOrderBy(sortBy), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+	return scanTemplates(rows)
+}
+
+// scanTemplates reads rows selected with templateColumns
+func scanTemplates(rows *sql.Rows) ([]*models.Template, error) {
+	templates := []*models.Template{}
+	for rows.Next() {
+		template := &models.Template{}
+		err := rows.Scan(
+			&template.ID,
+			&template.TemplateID,
+			&template.Name,
+			&template.Severity,
+			&template.Tags,
+			&template.Author,
+			&template.FilePath,
+			&template.Description,
+			&template.Category,
+			&template.Favorite,
+			&template.Trust,
+			&template.Protocols,
+			&template.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+	return templates, rows.Err()
+}
+
+// pathQueryBatch bounds the number of paths per query (SQLite limits bound parameters)
+const pathQueryBatch = 500
+
+// GetTemplatesByPath returns the templates stored at the given file paths, keyed by the
+// cleaned path; paths that are not in the library are missing from the result
+func (d *Database) GetTemplatesByPath(filePaths []string) (map[string]*models.Template, error) {
+	templates := make(map[string]*models.Template, len(filePaths))
+	for start := 0; start < len(filePaths); start += pathQueryBatch {
+		batch := filePaths[start:min(start+pathQueryBatch, len(filePaths))]
+		args := make([]interface{}, 0, len(batch))
+		for _, path := range batch {
+			args = append(args, filepath.Clean(path))
+		}
+		rows, err := d.db.Query(
+			"SELECT "+templateColumns+" FROM templates WHERE file_path IN (?"+strings.Repeat(", ?", len(batch)-1)+")",
+			args...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query templates: %w", err)
+		}
+		found, err := scanTemplates(rows)
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, template := range found {
+			templates[filepath.Clean(template.FilePath)] = template
+		}
+	}
+	return templates, nil
+}
@@ -0,0 +1,177 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"wepoc/internal/models"
+)
+
+// createTemplateSearchIndex adds the template description and an FTS5 index over the
+// metadata and raw YAML of every template. The trigram tokenizer matches substrings, so
+// paths, headers and CJK text are found without word boundaries; queries need 3+ characters.
+const createTemplateSearchIndex = `
+	ALTER TABLE templates ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+	CREATE VIRTUAL TABLE IF NOT EXISTS templates_fts USING fts5(
+		template_id, name, description, tags, author, content,
+		tokenize = 'trigram'
+	);
+
+	CREATE TRIGGER IF NOT EXISTS templates_fts_delete AFTER DELETE ON templates BEGIN
+		DELETE FROM templates_fts WHERE rowid = old.id;
+	END;
+`
+
+// minSearchTermLength is the shortest term the trigram index can match
+const minSearchTermLength = 3
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// indexTemplate (re)indexes a stored template together with the content of its YAML file
+func indexTemplate(exec execer, templateID, filePath string) error {
+	content, _ := os.ReadFile(filePath)
+	if _, err := exec.Exec("DELETE FROM templates_fts WHERE rowid = (SELECT id FROM templates WHERE template_id = ?)", templateID); err != nil {
+		return fmt.Errorf("failed to index template %s: %w", templateID, err)
+	}
+	_, err := exec.Exec(`
+		INSERT INTO templates_fts (rowid, template_id, name, description, tags, author, content)
+		SELECT id, template_id, name, description, tags, author, ? FROM templates WHERE template_id = ?
+	`, string(content), templateID)
+	if err != nil {
+		return fmt.Errorf("failed to index template %s: %w", templateID, err)
+	}
+	return nil
+}
+
+// ensureTemplateIndex rebuilds the search index when it is out of sync with the templates
+// table (after upgrading from a version without it or restoring an older backup)
+func (d *Database) ensureTemplateIndex() error {
+	var templates, indexed int
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM templates").Scan(&templates); err != nil {
+		return fmt.Errorf("failed to count templates: %w", err)
+	}
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM templates_fts").Scan(&indexed); err != nil {
+		return fmt.Errorf("failed to count indexed templates: %w", err)
+	}
+	if templates == indexed {
+		return nil
+	}
+	return d.RebuildTemplateIndex()
+}
+
+// RebuildTemplateIndex re-reads every template file and rebuilds the full-text index
+func (d *Database) RebuildTemplateIndex() error {
+	rows, err := d.db.Query("SELECT template_id, file_path FROM templates")
+	if err != nil {
+		return fmt.Errorf("failed to query templates: %w", err)
+	}
+	paths := make(map[string]string)
+	for rows.Next() {
+		var templateID, filePath string
+		if err := rows.Scan(&templateID, &filePath); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan template: %w", err)
+		}
+		paths[templateID] = filePath
+	}
+	rows.Close()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM templates_fts"); err != nil {
+		return fmt.Errorf("failed to clear template index: %w", err)
+	}
+	for templateID, filePath := range paths {
+		if err := indexTemplate(tx, templateID, filePath); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logInfof("🔎 模板全文索引已重建: %d 个模板\n", len(paths))
+	return nil
+}
+
+// ftsMatchQuery turns user input into an FTS5 query matching all terms as substrings.
+// It reports false when a term is too short for the trigram index.
+func ftsMatchQuery(keyword string) (string, bool) {
+	terms := strings.Fields(keyword)
+	if len(terms) == 0 {
+		return "", false
+	}
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) < minSearchTermLength {
+			return "", false
+		}
+		// 每个词作为短语查询，避免 - : * 等字符被当作FTS语法
+		quoted = append(quoted, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+	}
+	return strings.Join(quoted, " AND "), true
+}
+
+// SearchTemplateContent finds templates whose metadata or YAML body contains all words of
+// query and returns them with a highlighted excerpt of the match, best matches first
+func (d *Database) SearchTemplateContent(query string, limit int) ([]*models.TemplateSearchHit, error) {
+	match, ok := ftsMatchQuery(query)
+	if !ok {
+		return nil, fmt.Errorf("搜索词至少需要 %d 个字符", minSearchTermLength)
+	}
+	if limit <= 0 || limit > maxTemplatePageSize {
+		limit = defaultTemplatePageSize
+	}
+
+	rows, err := d.db.Query(`
+		SELECT t.id, t.template_id, t.name, t.severity, t.tags, t.author, t.file_path, t.description, t.category, t.favorite, t.trust, t.protocols, t.created_at,
+			snippet(templates_fts, -1, '[', ']', '…', 64)
+		FROM templates_fts
+		JOIN templates t ON t.id = templates_fts.rowid
+		WHERE templates_fts MATCH ?
+		ORDER BY rank
+		LIMIT ?
+	`, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search templates: %w", err)
+	}
+	defer rows.Close()
+
+	hits := []*models.TemplateSearchHit{}
+	for rows.Next() {
+		template := &models.Template{}
+		hit := &models.TemplateSearchHit{Template: template}
+		err := rows.Scan(
+			&template.ID,
+			&template.TemplateID,
+			&template.Name,
+			&template.Severity,
+			&template.Tags,
+			&template.Author,
+			&template.FilePath,
+			&template.Description,
+			&template.Category,
+			&template.Favorite,
+			&template.Trust,
+			&template.Protocols,
+			&template.CreatedAt,
+			&hit.Snippet,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// createTemplateSnapshotTables stores the templates a task was started with. Template
+// contents are content-addressed, so tasks sharing a template version share one blob.
+const createTemplateSnapshotTables = `
+	CREATE TABLE IF NOT EXISTS template_blobs (
+		hash TEXT PRIMARY KEY,
+		content BLOB NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS task_template_snapshots (
+		task_id INTEGER NOT NULL,
+		poc TEXT NOT NULL,
+		file_path TEXT NOT NULL,
+		hash TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (task_id, poc)
+	);
+	CREATE INDEX IF NOT EXISTS idx_task_template_snapshots_hash ON task_template_snapshots(hash);
+`
+
+// pruneTemplateBlobs removes template contents no longer referenced by any snapshot
+const pruneTemplateBlobs = "DELETE FROM template_blobs WHERE hash NOT IN (SELECT hash FROM task_template_snapshots)"
+
+// TemplateSnapshotEntry is one template of a task's snapshot
+type TemplateSnapshotEntry struct {
+	POC       string // 任务中的模板引用
+	FilePath  string // 记录快照时的模板文件
+	Hash      string // SHA-256
+	Content   []byte
+	CreatedAt time.Time
+}
+
+// SaveTemplateSnapshot replaces the template snapshot of a task
+func (d *Database) SaveTemplateSnapshot(taskID int64, entries []*TemplateSnapshotEntry) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM task_template_snapshots WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("failed to clear template snapshot: %w", err)
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO template_blobs (hash, content) VALUES (?, ?)", entry.Hash, entry.Content); err != nil {
+			return fmt.Errorf("failed to store template content: %w", err)
+		}
+		_, err := tx.Exec(
+			"INSERT OR REPLACE INTO task_template_snapshots (task_id, poc, file_path, hash, created_at) VALUES (?, ?, ?, ?, ?)",
+			taskID, entry.POC, entry.FilePath, entry.Hash, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store template snapshot: %w", err)
+		}
+	}
+	if _, err := tx.Exec(pruneTemplateBlobs); err != nil {
+		return fmt.Errorf("failed to prune template contents: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GetTemplateSnapshot returns the template snapshot of a task with the template contents;
+// the result is empty when the task has no snapshot
+func (d *Database) GetTemplateSnapshot(taskID int64) ([]*TemplateSnapshotEntry, error) {
+	rows, err := d.db.Query(`
+		SELECT s.poc, s.file_path, s.hash, b.content, s.created_at
+		FROM task_template_snapshots s
+		JOIN template_blobs b ON b.hash = s.hash
+		WHERE s.task_id = ?
+		ORDER BY s.poc
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query template snapshot: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*TemplateSnapshotEntry
+	for rows.Next() {
+		entry := &TemplateSnapshotEntry{}
+		if err := rows.Scan(&entry.POC, &entry.FilePath, &entry.Hash, &entry.Content, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan template snapshot: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
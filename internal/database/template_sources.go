@@ -0,0 +1,96 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"wepoc/internal/models"
+)
+
+const createTemplateSourcesTable = `
+	CREATE TABLE IF NOT EXISTS template_sources (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		url TEXT NOT NULL,
+		branch TEXT NOT NULL DEFAULT '',
+		subpath TEXT NOT NULL DEFAULT '',
+		revision TEXT NOT NULL DEFAULT '',
+		files TEXT NOT NULL DEFAULT '[]',
+		synced_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(kind, url, branch, subpath)
+	);
+`
+
+const templateSourceColumns = "id, kind, url, branch, subpath, revision, files, synced_at, created_at"
+
+// GetTemplateSource returns the source with the given location, or ErrNotFound
+func (d *Database) GetTemplateSource(kind, url, branch, subpath string) (*models.TemplateSource, error) {
+	row := d.db.QueryRow("SELECT "+templateSourceColumns+" FROM template_sources WHERE kind = ? AND url = ? AND branch = ? AND subpath = ?",
+		kind, url, branch, subpath)
+	source, err := scanTemplateSource(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return source, err
+}
+
+// ListTemplateSources returns all template sources, most recently synced first
+func (d *Database) ListTemplateSources() ([]*models.TemplateSource, error) {
+	rows, err := d.db.Query("SELECT " + templateSourceColumns + " FROM template_sources ORDER BY synced_at DESC, id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query template sources: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []*models.TemplateSource{}
+	for rows.Next() {
+		source, err := scanTemplateSource(rows)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, rows.Err()
+}
+
+// SaveTemplateSource inserts or updates a source after a sync and sets its ID
+func (d *Database) SaveTemplateSource(source *models.TemplateSource) error {
+	files, err := json.Marshal(source.Files)
+	if err != nil {
+		return fmt.Errorf("failed to marshal source files: %w", err)
+	}
+	err = d.db.QueryRow(`
+		INSERT INTO template_sources (kind, url, branch, subpath, revision, files, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(kind, url, branch, subpath) DO UPDATE SET
+			revision = excluded.revision, files = excluded.files, synced_at = excluded.synced_at
+		RETURNING id
+	`, source.Kind, source.URL, source.Branch, source.Subpath, source.Revision, string(files), source.SyncedAt).Scan(&source.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save template source: %w", err)
+	}
+	return nil
+}
+
+// scanTemplateSource reads a row selected with templateSourceColumns
+func scanTemplateSource(row interface{ Scan(...interface{}) error }) (*models.TemplateSource, error) {
+	source := &models.TemplateSource{}
+	var files string
+	var syncedAt sql.NullTime
+	err := row.Scan(&source.ID, &source.Kind, &source.URL, &source.Branch, &source.Subpath,
+		&source.Revision, &files, &syncedAt, &source.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan template source: %w", err)
+	}
+	json.Unmarshal([]byte(files), &source.Files)
+	if syncedAt.Valid {
+		t := syncedAt.Time
+		source.SyncedAt = &t
+	}
+	return source, nil
+}
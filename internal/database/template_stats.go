@@ -0,0 +1,146 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// createTemplateStatsTable stores per scan run how many findings each scanned template
+// produced. Rows are kept when tasks are deleted so the statistics cover the whole history.
+const createTemplateStatsTable = `
+	CREATE TABLE IF NOT EXISTS template_scan_stats (
+		task_id INTEGER NOT NULL,
+		run_started_at DATETIME NOT NULL,
+		template_id TEXT NOT NULL,
+		findings INTEGER NOT NULL DEFAULT 0,
+		scanned_at DATETIME NOT NULL,
+		PRIMARY KEY (task_id, run_started_at, template_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_template_scan_stats_template ON template_scan_stats(template_id);
+`
+
+// effectivenessSortColumns maps the sort keys accepted by GetTemplateEffectiveness to SQL expressions
+var effectivenessSortColumns = map[string]string{
+	"hit_rate":        "hit_rate",
+	"findings":        "findings",
+	"scans":           "scans",
+	"last_scanned_at": "last_scanned_at",
+	"last_hit_at":     "last_hit_at",
+	"name":            "t.name COLLATE NOCASE",
+	"severity":        strings.ReplaceAll(severityRank, "severity", "t.severity"),
+}
+
+// RecordTemplateRun stores the templates scanned by one run of a task with their number of
+// findings; saving the same run again replaces its previous record
+func (d *Database) RecordTemplateRun(taskID int64, startedAt, scannedAt time.Time, findings map[string]int) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 统一为UTC（同时去掉单调时钟读数），使同一次运行的记录可以匹配、MAX()按时间排序
+	startedAt, scannedAt = startedAt.UTC(), scannedAt.UTC()
+	if _, err := tx.Exec("DELETE FROM template_scan_stats WHERE task_id = ? AND run_started_at = ?", taskID, startedAt); err != nil {
+		return fmt.Errorf("failed to clear template statistics: %w", err)
+	}
+	for templateID, count := range findings {
+		_, err := tx.Exec(
+			"INSERT INTO template_scan_stats (task_id, run_started_at, template_id, findings, scanned_at) VALUES (?, ?, ?, ?, ?)",
+			taskID, startedAt, templateID, count, scannedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store template statistics: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetTemplateEffectiveness returns the scan and finding counts of the templates matching a
+// filter, sorted by sortBy (hit_rate, findings, scans, last_scanned_at, last_hit_at, name or
+// severity; prefix "-" for descending). Templates that were never scanned have zero scans.
+func (d *Database) GetTemplateEffectiveness(sortBy string, filter models.TemplateEffectivenessFilter) ([]*models.TemplateEffectiveness, error) {
+	where, args := templateWhere(filter.Templates)
+
+	var conditions []string
+	if filter.MinScans > 0 {
+		conditions = append(conditions, "scans >= ?")
+		args = append(args, filter.MinScans)
+	}
+	if filter.NeverFired {
+		conditions = append(conditions, "scans > 0 AND findings = 0")
+	}
+	having := ""
+	if len(conditions) > 0 {
+		having = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	direction := "DESC"
+	if sortBy == "" {
+		sortBy = "-hit_rate"
+	}
+	if strings.HasPrefix(sortBy, "-") {
+		sortBy = sortBy[1:]
+	} else {
+		direction = "ASC"
+	}
+	column, ok := effectivenessSortColumns[sortBy]
+	if !ok {
+		column, direction = "hit_rate", "DESC"
+	}
+	orderBy := fmt.Sprintf("%s %s, findings DESC, t.name COLLATE NOCASE ASC", column, direction)
+
+	query := `
+		SELECT t.id, t.template_id, t.name, t.severity, t.category, t.file_path,
+			scans, findings, hit_scans, hit_rate, last_scanned_at, last_hit_at
+		FROM (SELECT * FROM templates` + where + `) t
+		JOIN (
+			SELECT t.id AS ref,
+				COUNT(s.template_id) AS scans,
+				COALESCE(SUM(s.findings), 0) AS findings,
+				COALESCE(SUM(s.findings > 0), 0) AS hit_scans,
+				COALESCE(CAST(SUM(s.findings > 0) AS REAL) / NULLIF(COUNT(s.template_id), 0), 0) AS hit_rate,
+				MAX(s.scanned_at) AS last_scanned_at,
+				MAX(CASE WHEN s.findings > 0 THEN s.scanned_at END) AS last_hit_at
+			FROM templates t
+			LEFT JOIN template_scan_stats s ON s.template_id = t.template_id
+			GROUP BY t.id
+		) stats ON stats.ref = t.id` + having + `
+		ORDER BY ` + orderBy
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query template effectiveness: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*models.TemplateEffectiveness{}
+	for rows.Next() {
+		item := &models.TemplateEffectiveness{}
+		var lastScanned, lastHit *string
+		err := rows.Scan(&item.ID, &item.TemplateID, &item.Name, &item.Severity, &item.Category, &item.FilePath,
+			&item.Scans, &item.Findings, &item.HitScans, &item.HitRate, &lastScanned, &lastHit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template effectiveness: %w", err)
+		}
+		item.LastScannedAt = parseStatsTime(lastScanned)
+		item.LastHitAt = parseStatsTime(lastHit)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// parseStatsTime parses a time aggregated by MAX(), which the driver returns as text
+func parseStatsTime(value *string) *time.Time {
+	if value == nil {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", *value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+const (
+	// defaultTopTags is the number of tags returned by GetTemplateLibraryStats by default
+	defaultTopTags = 50
+	// defaultRecentTemplates is the number of recently added templates returned by default
+	defaultRecentTemplates = 10
+)
+
+// librarySeverities are the severities reported by GetTemplateLibraryStats, most severe first
+var librarySeverities = []string{"critical", "high", "medium", "low", "info", "unknown"}
+
+// GetTemplateLibraryStats counts the templates of the library by severity, tag and protocol
+// and returns the most recently added ones. topTags and recent limit the tag counts and the
+// recent templates (0 = defaults).
+func (d *Database) GetTemplateLibraryStats(topTags, recent int) (*models.TemplateLibraryStats, error) {
+	if topTags <= 0 {
+		topTags = defaultTopTags
+	}
+	if recent <= 0 {
+		recent = defaultRecentTemplates
+	}
+	stats := &models.TemplateLibraryStats{}
+
+	if err := d.db.QueryRow("SELECT COUNT(*), COALESCE(SUM(favorite), 0) FROM templates").Scan(&stats.Total, &stats.Favorites); err != nil {
+		return nil, fmt.Errorf("failed to count templates: %w", err)
+	}
+
+	var err error
+	if stats.BySeverity, err = d.severityCounts(); err != nil {
+		return nil, err
+	}
+	if stats.ByTag, err = d.tagCounts(topTags); err != nil {
+		return nil, err
+	}
+	if stats.ByProtocol, err = d.protocolCounts(); err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.Query("SELECT "+templateColumns+" FROM templates ORDER BY created_at DESC, id DESC LIMIT ?", recent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent templates: %w", err)
+	}
+	defer rows.Close()
+	if stats.Recent, err = scanTemplates(rows); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// severityCounts counts templates per severity; every known severity is listed, unknown
+// and empty severities are counted as unknown
+func (d *Database) severityCounts() ([]*models.TemplateStatCount, error) {
+	rows, err := d.db.Query(`
+		SELECT CASE WHEN LOWER(severity) IN ('critical', 'high', 'medium', 'low', 'info') THEN LOWER(severity) ELSE 'unknown' END AS level,
+			COUNT(*)
+		FROM templates GROUP BY level
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count templates by severity: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var level string
+		var count int
+		if err := rows.Scan(&level, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan severity count: %w", err)
+		}
+		counts[level] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.TemplateStatCount, 0, len(librarySeverities))
+	for _, severity := range librarySeverities {
+		result = append(result, &models.TemplateStatCount{Name: severity, Count: counts[severity]})
+	}
+	return result, nil
+}
+
+// tagCounts counts templates per tag (tags are stored comma separated), most used first
+func (d *Database) tagCounts(limit int) ([]*models.TemplateStatCount, error) {
+	rows, err := d.db.Query(`
+		WITH RECURSIVE split(tag, rest) AS (
+			SELECT '', LOWER(REPLACE(tags, ' ', '')) || ',' FROM templates WHERE tags != ''
+			UNION ALL
+			SELECT SUBSTR(rest, 1, INSTR(rest, ',') - 1), SUBSTR(rest, INSTR(rest, ',') + 1)
+			FROM split WHERE rest != ''
+		)
+		SELECT tag, COUNT(*) AS count FROM split WHERE tag != ''
+		GROUP BY tag ORDER BY count DESC, tag LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count templates by tag: %w", err)
+	}
+	defer rows.Close()
+
+	result := []*models.TemplateStatCount{}
+	for rows.Next() {
+		count := &models.TemplateStatCount{}
+		if err := rows.Scan(&count.Name, &count.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag count: %w", err)
+		}
+		result = append(result, count)
+	}
+	return result, rows.Err()
+}
+
+// protocolCounts counts the templates using each protocol; a template with several
+// protocols is counted for each of them
+func (d *Database) protocolCounts() ([]*models.TemplateStatCount, error) {
+	columns := make([]string, 0, len(templateProtocols))
+	for _, protocol := range templateProtocols {
+		columns = append(columns, fmt.Sprintf("COALESCE(SUM((',' || protocols || ',') LIKE '%%,%s,%%'), 0)", protocol.name))
+	}
+
+	values := make([]int, len(templateProtocols))
+	targets := make([]interface{}, len(values))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	if err := d.db.QueryRow("SELECT " + strings.Join(columns, ", ") + " FROM templates").Scan(targets...); err != nil {
+		return nil, fmt.Errorf("failed to count templates by protocol: %w", err)
+	}
+
+	result := make([]*models.TemplateStatCount, 0, len(templateProtocols))
+	for i, protocol := range templateProtocols {
+		result = append(result, &models.TemplateStatCount{Name: protocol.name, Count: values[i]})
+	}
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// addTemplateTrust adds the trust level of templates; existing templates are unverified
+const addTemplateTrust = `
+	ALTER TABLE templates ADD COLUMN trust TEXT NOT NULL DEFAULT 'unverified';
+	CREATE INDEX IF NOT EXISTS idx_templates_trust ON templates(trust);
+`
+
+// templateTrust returns a valid trust level, unverified when it is empty or unknown
+func templateTrust(trust string) string {
+	switch trust {
+	case models.TrustOfficial, models.TrustInternal:
+		return trust
+	default:
+		return models.TrustUnverified
+	}
+}
+
+// SetTemplateTrust sets the trust level of templates (by template ID). Returns the number
+// of templates updated.
+func (d *Database) SetTemplateTrust(templateIDs []string, trust string) (int, error) {
+	if len(templateIDs) == 0 {
+		return 0, nil
+	}
+	args := []interface{}{templateTrust(trust)}
+	for _, id := range templateIDs {
+		args = append(args, id)
+	}
+	result, err := d.db.Exec(
+		"UPDATE templates SET trust = ? WHERE template_id IN (?"+strings.Repeat(", ?", len(templateIDs)-1)+")",
+		args...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set template trust: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// SetTemplateTrustByPath sets the trust level of the template stored at a file path
+func (d *Database) SetTemplateTrustByPath(filePath, trust string) error {
+	if _, err := d.db.Exec("UPDATE templates SET trust = ? WHERE file_path = ?", templateTrust(trust), filePath); err != nil {
+		return fmt.Errorf("failed to set template trust: %w", err)
+	}
+	return nil
+}
@@ -10,8 +10,8 @@ import (
 // InsertTemplate inserts a new template into the database
 func (d *Database) InsertTemplate(template *models.Template) error {
 	query := `
-		INSERT INTO templates (template_id, name, severity, tags, author, file_path)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO templates (template_id, name, severity, tags, author, file_path, description, category, trust, protocols)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	result, err := d.db.Exec(query,
 		template.TemplateID,
@@ -20,6 +20,10 @@ func (d *Database) InsertTemplate(template *models.Template) error {
 		template.Tags,
 		template.Author,
 		template.FilePath,
+		template.Description,
+		template.Category,
+		templateTrust(template.Trust),
+		template.Protocols,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert template: %w", err)
@@ -30,13 +34,13 @@ func (d *Database) InsertTemplate(template *models.Template) error {
 		return err
 	}
 	template.ID = id
-	return nil
+	return indexTemplate(d.db, template.TemplateID, template.FilePath)
 }
 
 // GetTemplateByID retrieves a template by its database ID
 func (d *Database) GetTemplateByID(id int64) (*models.Template, error) {
 	query := `
-		SELECT id, template_id, name, severity, tags, author, file_path, created_at
+		SELECT id, template_id, name, severity, tags, author, file_path, description, category, favorite, trust, protocols, created_at
 		FROM templates
 		WHERE id = ?
 	`
@@ -49,6 +53,11 @@ func (d *Database) GetTemplateByID(id int64) (*models.Template, error) {
 		&template.Tags,
 		&template.Author,
 		&template.FilePath,
+		&template.Description,
+		&template.Category,
+		&template.Favorite,
+		&template.Trust,
+		&template.Protocols,
 		&template.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -63,7 +72,7 @@ func (d *Database) GetTemplateByID(id int64) (*models.Template, error) {
 // GetTemplateByTemplateID retrieves a template by its template_id
 func (d *Database) GetTemplateByTemplateID(templateID string) (*models.Template, error) {
 	query := `
-		SELECT id, template_id, name, severity, tags, author, file_path, created_at
+		SELECT id, template_id, name, severity, tags, author, file_path, description, category, favorite, trust, protocols, created_at
 		FROM templates
 		WHERE template_id = ?
 	`
@@ -76,6 +85,11 @@ func (d *Database) GetTemplateByTemplateID(templateID string) (*models.Template,
 		&template.Tags,
 		&template.Author,
 		&template.FilePath,
+		&template.Description,
+		&template.Category,
+		&template.Favorite,
+		&template.Trust,
+		&template.Protocols,
 		&template.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -90,7 +104,7 @@ func (d *Database) GetTemplateByTemplateID(templateID string) (*models.Template,
 // GetAllTemplates retrieves all templates from the database
 func (d *Database) GetAllTemplates() ([]*models.Template, error) {
 	query := `
-		SELECT id, template_id, name, severity, tags, author, file_path, created_at
+		SELECT id, template_id, name, severity, tags, author, file_path, description, category, favorite, trust, protocols, created_at
 		FROM templates
 		ORDER BY created_at DESC
 	`
@@ -111,6 +125,11 @@ func (d *Database) GetAllTemplates() ([]*models.Template, error) {
 			&template.Tags,
 			&template.Author,
 			&template.FilePath,
+			&template.Description,
+			&template.Category,
+			&template.Favorite,
+			&template.Trust,
+			&template.Protocols,
 			&template.CreatedAt,
 		)
 		if err != nil {
@@ -121,25 +140,15 @@ func (d *Database) GetAllTemplates() ([]*models.Template, error) {
 	return templates, nil
 }
 
-// SearchTemplates searches templates by name, tags, or severity
+// SearchTemplates searches templates by keyword (full text over metadata and YAML content)
+// and severity
 func (d *Database) SearchTemplates(keyword string, severity string) ([]*models.Template, error) {
-	query := `
-		SELECT id, template_id, name, severity, tags, author, file_path, created_at
-		FROM templates
-		WHERE (name LIKE ? OR tags LIKE ? OR template_id LIKE ?)
-	`
-	args := []interface{}{
-		"%" + keyword + "%",
-		"%" + keyword + "%",
-		"%" + keyword + "%",
-	}
-
+	filter := models.TemplateFilter{Keyword: keyword}
 	if severity != "" {
-		query += " AND severity = ?"
-		args = append(args, severity)
+		filter.Severities = []string{severity}
 	}
-
-	query += " ORDER BY severity DESC, created_at DESC"
+	where, args := templateWhere(filter)
+	query := "SELECT " + templateColumns + " FROM templates" + where + " ORDER BY severity DESC, created_at DESC"
 
 	rows, err := d.db.Query(query, args...)
 	if err != nil {
@@ -147,25 +156,7 @@ func (d *Database) SearchTemplates(keyword string, severity string) ([]*models.T
 	}
 	defer rows.Close()
 
-	var templates []*models.Template
-	for rows.Next() {
-		template := &models.Template{}
-		err := rows.Scan(
-			&template.ID,
-			&template.TemplateID,
-			&template.Name,
-			&template.Severity,
-			&template.Tags,
-			&template.Author,
-			&template.FilePath,
-			&template.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan template: %w", err)
-		}
-		templates = append(templates, template)
-	}
-	return templates, nil
+	return scanTemplates(rows)
 }
 
 // DeleteTemplate deletes a template by ID
@@ -186,7 +177,62 @@ func (d *Database) DeleteTemplate(id int64) error {
 	return nil
 }
 
-// BatchInsertTemplates inserts multiple templates at once
+// UpdateTemplateTags replaces the stored tags of a template and reindexes its file
+func (d *Database) UpdateTemplateTags(templateID string, tags string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var filePath string
+	if err := tx.QueryRow("SELECT file_path FROM templates WHERE template_id = ?", templateID).Scan(&filePath); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("template not found")
+		}
+		return fmt.Errorf("failed to get template: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE templates SET tags = ? WHERE template_id = ?", tags, templateID); err != nil {
+		return fmt.Errorf("failed to update template tags: %w", err)
+	}
+	if err := indexTemplate(tx, templateID, filePath); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateTemplate refreshes the metadata and trust level of the template stored at
+// template.FilePath after its file changed. Category and favorite are kept.
+func (d *Database) UpdateTemplate(template *models.Template) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE templates SET template_id = ?, name = ?, severity = ?, tags = ?, author = ?, description = ?, trust = ?, protocols = ?
+		WHERE file_path = ?
+	`, template.TemplateID, template.Name, template.Severity, template.Tags, template.Author, template.Description,
+		templateTrust(template.Trust), template.Protocols, template.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to update template %s: %w", template.TemplateID, err)
+	}
+	if err := indexTemplate(tx, template.TemplateID, template.FilePath); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteTemplateByPath removes the template stored at a file path
+func (d *Database) DeleteTemplateByPath(filePath string) error {
+	if _, err := d.db.Exec("DELETE FROM templates WHERE file_path = ?", filePath); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+// BatchInsertTemplates inserts multiple templates in a single transaction; existing template IDs are skipped
 func (d *Database) BatchInsertTemplates(templates []*models.Template) error {
 	tx, err := d.db.Begin()
 	if err != nil {
@@ -195,8 +241,8 @@ func (d *Database) BatchInsertTemplates(templates []*models.Template) error {
 	defer tx.Rollback()
 
 	stmt, err := tx.Prepare(`
-		INSERT OR IGNORE INTO templates (template_id, name, severity, tags, author, file_path)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT OR IGNORE INTO templates (template_id, name, severity, tags, author, file_path, description, category, trust, protocols)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
@@ -211,10 +257,17 @@ func (d *Database) BatchInsertTemplates(templates []*models.Template) error {
 			template.Tags,
 			template.Author,
 			template.FilePath,
+			template.Description,
+			template.Category,
+			templateTrust(template.Trust),
+			template.Protocols,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert template %s: %w", template.TemplateID, err)
 		}
+		if err := indexTemplate(tx, template.TemplateID, template.FilePath); err != nil {
+			return err
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -0,0 +1,273 @@
+// Package i18n provides the error codes and zh/en message catalogs of backend messages, so
+// that the frontend can localize errors and events by code
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Code identifies a backend message independent of its language
+type Code string
+
+// Message codes
+const (
+	ErrNotInitialized          Code = "app.not_initialized"
+	ErrCancelled               Code = "dialog.cancelled"
+	ErrTaskLoad                Code = "task.load_failed"
+	ErrTaskRunning             Code = "task.running"
+	ErrTaskLocked              Code = "task.locked"
+	ErrSecretsLocked           Code = "secrets.locked"
+	ErrSecretPassphrase        Code = "secrets.wrong_passphrase"
+	ErrSecretsNotProtected     Code = "secrets.not_protected"
+	ErrEncryptResultsNeedsLock Code = "secrets.encrypt_results_needs_lock"
+	ErrAppLocked               Code = "app.locked"
+	ErrOffline                 Code = "app.offline"
+
+	ErrConfigProfileNotFound Code = "config_profile.not_found"
+	ErrConfigProfileName     Code = "config_profile.invalid_name"
+	ErrConfigProfileExists   Code = "config_profile.exists"
+	ErrTemplateRootNotFound  Code = "template_root.not_found"
+
+	ErrAuthRequired       Code = "auth.login_required"
+	ErrPermissionDenied   Code = "auth.permission_denied"
+	ErrInvalidCredentials Code = "auth.invalid_credentials"
+	ErrUserExists         Code = "auth.user_exists"
+	ErrUserNotFound       Code = "auth.user_not_found"
+	ErrInvalidUsername    Code = "auth.invalid_username"
+	ErrInvalidRole        Code = "auth.invalid_role"
+	ErrWeakPassword       Code = "auth.weak_password"
+	ErrLastAdmin          Code = "auth.last_admin"
+
+	ErrTargetMissingBracket    Code = "target.ipv6_missing_bracket"
+	ErrTargetBracketSuffix     Code = "target.ipv6_bracket_suffix"
+	ErrTargetInvalidIPv6       Code = "target.ipv6_invalid"
+	ErrTargetIPv6Port          Code = "target.ipv6_unbracketed_port"
+	ErrTargetIPv6URL           Code = "target.ipv6_url_unbracketed"
+	ErrTargetInvalidURL        Code = "target.url_invalid"
+	ErrTargetInvalidPort       Code = "target.port_invalid"
+	ErrTargetMissingHost       Code = "target.host_missing"
+	ErrTargetCIDRTooLarge      Code = "target.cidr_too_large"
+	MsgScanWaitingWindow       Code = "scan.waiting_window"
+	MsgResourceDelayed         Code = "resource.delayed"
+	MsgResourceReducedParallel Code = "resource.reduced_concurrency"
+)
+
+// Supported languages; DefaultLanguage is used for unknown languages and missing messages
+const (
+	LanguageZh      = "zh"
+	LanguageEn      = "en"
+	DefaultLanguage = LanguageZh
+)
+
+// catalogs maps language -> code -> fmt format of the message
+var catalogs = map[string]map[Code]string{
+	LanguageZh: {
+		ErrNotInitialized:          "应用未正确初始化",
+		ErrCancelled:               "用户取消操作",
+		ErrTaskLoad:                "加载任务失败",
+		ErrTaskRunning:             "任务正在扫描，无法修改",
+		ErrTaskLocked:              "任务 %d 已作为证据锁定，无法修改或删除",
+		ErrSecretsLocked:           "配置密钥已加锁，请先输入口令解锁",
+		ErrSecretPassphrase:        "口令错误",
+		ErrSecretsNotProtected:     "请先为配置密钥设置口令",
+		ErrEncryptResultsNeedsLock: "加密结果目录需要同时开启启动时口令验证",
+		ErrAppLocked:               "应用已加锁，请先输入口令",
+		ErrOffline:                 "离线模式下无法使用需要访问外网的功能",
+
+		ErrConfigProfileNotFound: "配置方案 %s 不存在",
+		ErrConfigProfileName:     "配置方案名称不能为空",
+		ErrConfigProfileExists:   "配置方案 %s 已存在",
+		ErrTemplateRootNotFound:  "模板根目录 %s 不存在",
+
+		ErrAuthRequired:       "请先登录",
+		ErrPermissionDenied:   "用户 %s（%s）无权执行此操作",
+		ErrInvalidCredentials: "用户名或口令错误",
+		ErrUserExists:         "用户 %s 已存在",
+		ErrUserNotFound:       "用户 %s 不存在",
+		ErrInvalidUsername:    "无效的用户名 %s（2-64个字母、数字或 . _ @ -）",
+		ErrInvalidRole:        "无效的角色 %s（admin/operator/viewer）",
+		ErrWeakPassword:       "口令至少需要 %d 个字符",
+		ErrLastAdmin:          "至少需要保留一个启用的管理员",
+
+		ErrTargetMissingBracket:    "无效的目标 %s: IPv6地址缺少右方括号",
+		ErrTargetBracketSuffix:     "无效的目标 %s: 方括号后只能跟 :端口",
+		ErrTargetInvalidIPv6:       "无效的目标 %s: 无效的IPv6地址",
+		ErrTargetIPv6Port:          "无效的目标 %s: 无效的IPv6地址（带端口的IPv6地址需写成 [地址]:端口）",
+		ErrTargetIPv6URL:           "无效的目标 %s: URL中的IPv6地址需用方括号括起，如 http://[2001:db8::1]:8080",
+		ErrTargetInvalidURL:        "无效的目标URL %s",
+		ErrTargetInvalidPort:       "无效的目标 %s: 端口无效: %s",
+		ErrTargetMissingHost:       "无效的目标 %s: 缺少主机名",
+		ErrTargetCIDRTooLarge:      "CIDR %s 包含的地址过多（最多 %d 个，IPv4请使用 /16、IPv6请使用 /112 或更小的网段）",
+		MsgScanWaitingWindow:       "任务 %d 不在扫描时间窗口内，等待窗口开启",
+		MsgResourceDelayed:         "任务 %d 延迟启动: %s",
+		MsgResourceReducedParallel: "任务 %d 等待资源超时，降低并发后启动: %s",
+	},
+	LanguageEn: {
+		ErrNotInitialized:          "Application not initialized properly",
+		ErrCancelled:               "Cancelled by user",
+		ErrTaskLoad:                "Failed to load task",
+		ErrTaskRunning:             "Cannot modify a task while it is scanning",
+		ErrTaskLocked:              "Task %d is locked as evidence and cannot be modified or deleted",
+		ErrSecretsLocked:           "The config secrets are locked; unlock them with the passphrase first",
+		ErrSecretPassphrase:        "Wrong passphrase",
+		ErrSecretsNotProtected:     "Set a passphrase for the config secret key first",
+		ErrEncryptResultsNeedsLock: "Encrypting the results directory requires the startup passphrase gate",
+		ErrAppLocked:               "The application is locked; enter the passphrase first",
+		ErrOffline:                 "This feature needs network access and is disabled in offline mode",
+
+		ErrConfigProfileNotFound: "Config profile %s does not exist",
+		ErrConfigProfileName:     "The config profile needs a name",
+		ErrConfigProfileExists:   "Config profile %s already exists",
+		ErrTemplateRootNotFound:  "Template root %s does not exist",
+
+		ErrAuthRequired:       "Please log in first",
+		ErrPermissionDenied:   "User %s (%s) is not allowed to do this",
+		ErrInvalidCredentials: "Wrong username or password",
+		ErrUserExists:         "User %s already exists",
+		ErrUserNotFound:       "User %s does not exist",
+		ErrInvalidUsername:    "Invalid username %s (2-64 letters, digits or . _ @ -)",
+		ErrInvalidRole:        "Invalid role %s (admin/operator/viewer)",
+		ErrWeakPassword:       "The password needs at least %d characters",
+		ErrLastAdmin:          "At least one enabled admin is required",
+
+		ErrTargetMissingBracket:    "Invalid target %s: the IPv6 address is missing its closing bracket",
+		ErrTargetBracketSuffix:     "Invalid target %s: only :port may follow the closing bracket",
+		ErrTargetInvalidIPv6:       "Invalid target %s: invalid IPv6 address",
+		ErrTargetIPv6Port:          "Invalid target %s: invalid IPv6 address (write IPv6 addresses with a port as [address]:port)",
+		ErrTargetIPv6URL:           "Invalid target %s: IPv6 addresses in URLs must be bracketed, e.g. http://[2001:db8::1]:8080",
+		ErrTargetInvalidURL:        "Invalid target URL %s",
+		ErrTargetInvalidPort:       "Invalid target %s: invalid port: %s",
+		ErrTargetMissingHost:       "Invalid target %s: missing host",
+		ErrTargetCIDRTooLarge:      "CIDR %s contains too many addresses (at most %d; use /16 or smaller for IPv4 and /112 or smaller for IPv6)",
+		MsgScanWaitingWindow:       "Task %d is outside its scan window and waits for it to open",
+		MsgResourceDelayed:         "Task %d start delayed: %s",
+		MsgResourceReducedParallel: "Task %d waited too long for resources and starts with reduced concurrency: %s",
+	},
+}
+
+var (
+	languageMu sync.RWMutex
+	language   = DefaultLanguage
+)
+
+// normalizeLanguage maps a language tag such as "en-US" to a supported language
+func normalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	for supported := range catalogs {
+		if lang == supported || strings.HasPrefix(lang, supported+"-") || strings.HasPrefix(lang, supported+"_") {
+			return supported
+		}
+	}
+	return DefaultLanguage
+}
+
+// SetLanguage sets the language of error messages and console output
+func SetLanguage(lang string) {
+	languageMu.Lock()
+	defer languageMu.Unlock()
+	language = normalizeLanguage(lang)
+}
+
+// Language returns the current language
+func Language() string {
+	languageMu.RLock()
+	defer languageMu.RUnlock()
+	return language
+}
+
+// Message formats the message of a code in the given language, falling back to the default
+// language and then to the code itself
+func Message(lang string, code Code, args ...interface{}) string {
+	format, ok := catalogs[normalizeLanguage(lang)][code]
+	if !ok {
+		if format, ok = catalogs[DefaultLanguage][code]; !ok {
+			return string(code)
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Sprintf formats the message of a code in the current language
+func Sprintf(code Code, args ...interface{}) string {
+	return Message(Language(), code, args...)
+}
+
+// Catalog returns the message formats of a language, for the frontend to localize codes
+func Catalog(lang string) map[Code]string {
+	catalog := make(map[Code]string, len(catalogs[DefaultLanguage]))
+	for code, format := range catalogs[DefaultLanguage] {
+		catalog[code] = format
+	}
+	for code, format := range catalogs[normalizeLanguage(lang)] {
+		catalog[code] = format
+	}
+	return catalog
+}
+
+// Error is an error with a message code and the arguments of its message
+type Error struct {
+	Code Code
+	Args []interface{}
+	Err  error // 底层错误（可选）
+}
+
+// Errorf returns an error with the given code and message arguments
+func Errorf(code Code, args ...interface{}) *Error {
+	return &Error{Code: code, Args: args}
+}
+
+// Wrap returns an error with the given code wrapping err
+func Wrap(code Code, err error, args ...interface{}) *Error {
+	return &Error{Code: code, Args: args, Err: err}
+}
+
+// Error returns the message in the current language
+func (e *Error) Error() string {
+	message := Sprintf(e.Code, e.Args...)
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the code of an error, or "" for errors without a code
+func CodeOf(err error) Code {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	return ""
+}
+
+// ErrorResponse is how coded errors reach the frontend
+type ErrorResponse struct {
+	Code    Code          `json:"code"`
+	Message string        `json:"message"`          // 当前语言的消息
+	Args    []interface{} `json:"args,omitempty"`   // 消息参数，用于前端按目录本地化
+	Detail  string        `json:"detail,omitempty"` // 底层错误
+}
+
+// FormatError formats errors returned by bindings: coded errors become an ErrorResponse,
+// other errors stay plain strings
+func FormatError(err error) any {
+	var coded *Error
+	if !errors.As(err, &coded) {
+		return err.Error()
+	}
+	response := &ErrorResponse{Code: coded.Code, Message: Sprintf(coded.Code, coded.Args...), Args: coded.Args}
+	if coded.Err != nil {
+		response.Detail = coded.Err.Error()
+	}
+	return response
+}
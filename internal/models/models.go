@@ -6,14 +6,166 @@ import (
 
 // Template represents a Nuclei template/POC
 type Template struct {
-	ID         int64     `json:"id"`
-	TemplateID string    `json:"template_id"` // Unique template ID from YAML
-	Name       string    `json:"name"`
-	Severity   string    `json:"severity"`
-	Tags       string    `json:"tags"`      // JSON array string
-	Author     string    `json:"author"`
-	FilePath   string    `json:"file_path"` // Path to template file
-	CreatedAt  time.Time `json:"created_at"`
+	ID          int64     `json:"id"`
+	TemplateID  string    `json:"template_id"` // Unique template ID from YAML
+	Name        string    `json:"name"`
+	Severity    string    `json:"severity"`
+	Tags        string    `json:"tags"`        // JSON array string
+	Author      string    `json:"author"`
+	FilePath    string    `json:"file_path"`   // Path to template file
+	Description string    `json:"description"` // info.description
+	Category    string    `json:"category"`    // 分类，导入时取自来源目录（如 http/cves），可手动修改
+	Favorite    bool      `json:"favorite"`    // 已收藏
+	Trust       string    `json:"trust"`       // 信任级别：official/internal/unverified
+	Protocols   string    `json:"protocols"`   // 模板包含的请求类型，逗号分隔（如 http,headless）
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Template trust levels
+const (
+	TrustOfficial   = "official"   // 由官方密钥签名
+	TrustInternal   = "internal"   // 由内部密钥签名
+	TrustUnverified = "unverified" // 未签名或签名无效
+)
+
+// TemplateFilter narrows a template listing; empty fields match everything
+type TemplateFilter struct {
+	Keyword    string   `json:"keyword"`    // 全文匹配名称、描述、标签、模板ID、作者和YAML内容
+	Severities []string `json:"severities"` // 严重程度（任一匹配）
+	Tags       []string `json:"tags"`       // 标签（全部匹配）
+	Author     string   `json:"author"`
+	Category   string   `json:"category"`  // 分类（包含子分类），"-" 表示未分类
+	Favorites  bool     `json:"favorites"` // 仅收藏的模板
+	Trust      string   `json:"trust"`     // 信任级别
+	Protocol   string   `json:"protocol"`  // 包含该请求类型（http/dns/network/headless/code/...）
+	Kind       string   `json:"kind"`      // 模板类型：空为普通模板，workflow 为工作流，all 为全部
+}
+
+// TaskResultFilter narrows a task result listing; empty fields match everything
+type TaskResultFilter struct {
+	IncludeEmpty bool       `json:"include_empty"`  // 包含未发现漏洞的结果
+	Statuses     []string   `json:"statuses"`       // 任务状态（任一匹配）
+	From         *time.Time `json:"from,omitempty"` // 创建时间下限
+	To           *time.Time `json:"to,omitempty"`   // 创建时间上限
+	MinVulns     int        `json:"min_vulns"`      // 最少漏洞数
+	MaxVulns     int        `json:"max_vulns"`      // 最多漏洞数（0 表示不限）
+}
+
+// TaskFilter narrows a task listing; empty fields match everything
+type TaskFilter struct {
+	Keyword  string     `json:"keyword"`        // 任务名称包含
+	Labels   []string   `json:"labels"`         // 标签（全部匹配，不区分大小写）
+	Statuses []string   `json:"statuses"`       // 任务状态（任一匹配）
+	From     *time.Time `json:"from,omitempty"` // 创建时间下限
+	To       *time.Time `json:"to,omitempty"`   // 创建时间上限
+}
+
+// Template kinds accepted by TemplateFilter.Kind
+const (
+	TemplateKindWorkflow = "workflow" // 工作流
+	TemplateKindAll      = "all"      // 普通模板和工作流
+)
+
+// TemplateCategory is a category with its number of templates
+type TemplateCategory struct {
+	Name  string `json:"name"` // 空字符串表示未分类
+	Count int    `json:"count"`
+}
+
+// TemplateStatCount is the number of templates with a severity, tag or protocol
+type TemplateStatCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// TemplateLibraryStats summarizes the template library
+type TemplateLibraryStats struct {
+	Total      int                  `json:"total"`
+	Favorites  int                  `json:"favorites"`
+	BySeverity []*TemplateStatCount `json:"by_severity"` // critical → unknown
+	ByTag      []*TemplateStatCount `json:"by_tag"`      // 使用最多的标签在前
+	ByProtocol []*TemplateStatCount `json:"by_protocol"` // 含多种协议的模板在每种协议下各计一次
+	Recent     []*Template          `json:"recent"`      // 最近添加的模板
+}
+
+// TemplatePage is one page of a template listing
+type TemplatePage struct {
+	Items  []*Template `json:"items"`
+	Total  int         `json:"total"` // 符合筛选条件的模板总数
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+}
+
+// TemplateSearchHit is a full-text search match with an excerpt of the matched YAML
+type TemplateSearchHit struct {
+	Template *Template `json:"template"`
+	Snippet  string    `json:"snippet"` // 命中内容片段，匹配部分用 [] 标出
+}
+
+// TemplateSource is a Git repository or zip URL that templates are synced from
+type TemplateSource struct {
+	ID        int64      `json:"id"`
+	Kind      string     `json:"kind"` // git、zip 或 archive（本地压缩包）
+	URL       string     `json:"url"`
+	Branch    string     `json:"branch"`
+	Subpath   string     `json:"subpath"`  // 仓库内的模板目录
+	Revision  string     `json:"revision"` // 上次同步的提交或zip内容的哈希
+	Files     []string   `json:"files"`    // 上次同步导入的模板文件名，用于删除已从来源移除的模板
+	SyncedAt  *time.Time `json:"synced_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TemplateEffectiveness is how often a template was scanned and how many findings it produced
+type TemplateEffectiveness struct {
+	ID            int64      `json:"id"`
+	TemplateID    string     `json:"template_id"`
+	Name          string     `json:"name"`
+	Severity      string     `json:"severity"`
+	Category      string     `json:"category"`
+	FilePath      string     `json:"file_path"`
+	Scans         int        `json:"scans"`     // 被扫描的任务次数
+	Findings      int        `json:"findings"`  // 历史发现总数
+	HitScans      int        `json:"hit_scans"` // 有发现的扫描次数
+	HitRate       float64    `json:"hit_rate"`  // 命中率：hit_scans / scans
+	LastScannedAt *time.Time `json:"last_scanned_at,omitempty"`
+	LastHitAt     *time.Time `json:"last_hit_at,omitempty"`
+}
+
+// TemplateEffectivenessFilter narrows the template effectiveness statistics
+type TemplateEffectivenessFilter struct {
+	Templates  TemplateFilter `json:"templates"`   // 模板筛选条件
+	MinScans   int            `json:"min_scans"`   // 至少被扫描的次数
+	NeverFired bool           `json:"never_fired"` // 仅被扫描过但从未命中的模板
+}
+
+// Finding is a unique finding across all scans, identified by template ID, matched-at
+// location and extracted results
+type Finding struct {
+	DedupKey    string        `json:"dedup_key"`
+	TemplateID  string        `json:"template_id"`
+	MatchedAt   string        `json:"matched_at"`
+	Severity    string        `json:"severity"`
+	FirstSeen   time.Time     `json:"first_seen"`    // 首次发现时间
+	LastSeen    time.Time     `json:"last_seen"`     // 最近一次发现时间
+	FirstTaskID int64         `json:"first_task_id"` // 首次发现的任务
+	LastTaskID  int64         `json:"last_task_id"`  // 最近一次发现的任务
+	Occurrences int           `json:"occurrences"`   // 发现该漏洞的扫描次数
+	Result      *NucleiResult `json:"result"`        // 最近一次的发现
+}
+
+// FindingAttachment is an evidence file (screenshot, exported packet) or note attached to a
+// finding; attachments belong to the finding's dedup key so they survive rescans
+type FindingAttachment struct {
+	ID        int64     `json:"id"`
+	DedupKey  string    `json:"dedup_key"`
+	TaskID    int64     `json:"task_id"`             // 添加附件时所在的任务
+	Kind      string    `json:"kind"`                // screenshot / packet / note / file
+	Name      string    `json:"name"`                // 原始文件名
+	FileName  string    `json:"file_name,omitempty"` // 附件目录中保存的文件名
+	Size      int64     `json:"size"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      []byte    `json:"data,omitempty"` // 导出报告时内嵌的文件内容
 }
 
 // ScanTask represents a scanning task
@@ -51,12 +203,31 @@ type NucleiResult struct {
 	Type         string                 `json:"type"`
 	Host         string                 `json:"host"`
 	MatchedAt    string                 `json:"matched-at"`
+	MatcherName  string                 `json:"matcher-name,omitempty"`
 	ExtractedResults []string           `json:"extracted-results,omitempty"`
 	Request      string                 `json:"request,omitempty"`
 	Response     string                 `json:"response,omitempty"`
 	Timestamp    time.Time              `json:"timestamp"`
 	CurlCommand  string                 `json:"curl-command,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	Verification *FindingVerification   `json:"verification,omitempty"` // 最近一次复核结果
+	DedupKey     string                 `json:"dedup-key,omitempty"`    // 去重键：模板ID + 命中地址 + 提取结果
+	FirstSeen    *time.Time             `json:"first-seen,omitempty"`   // 所有扫描中首次发现时间
+	LastSeen     *time.Time             `json:"last-seen,omitempty"`    // 所有扫描中最近发现时间
+	Duplicates   int                    `json:"duplicates,omitempty"`   // 本次扫描中合并的重复结果数
+	Attachments  []*FindingAttachment   `json:"attachments,omitempty"`  // 导出报告时附带的证据
+	BehindWAF    string                 `json:"behind-waf,omitempty"`   // 目标所在的WAF/CDN
+	ReviewNote   string                 `json:"review-note,omitempty"`  // 需要人工确认的原因
+	MatcherStatus *bool                 `json:"matcher-status,omitempty"` // nuclei -ms：false为未命中的执行记录
+}
+
+// FindingVerification records the last re-run of a finding's template against its matched-at URL
+type FindingVerification struct {
+	VerifiedAt      time.Time `json:"verified_at"`      // 复核时间
+	Status          string    `json:"status"`           // vulnerable / not_vulnerable / error
+	StillVulnerable bool      `json:"still_vulnerable"` // 是否仍存在漏洞
+	Duration        int64     `json:"duration_ms"`      // 复核耗时（毫秒）
+	Error           string    `json:"error,omitempty"`
 }
 
 // NucleiInfo contains template metadata
@@ -78,9 +249,235 @@ type Config struct {
 	NucleiPath     string `json:"nuclei_path"`     // Path to nuclei binary
 	MaxConcurrency int    `json:"max_concurrency"` // Max concurrent tasks
 	Timeout        int    `json:"timeout"`         // Request timeout in seconds
+	BurpProxyURL   string `json:"burp_proxy_url"`  // Burp listener used by "send to Burp"
+	ProgressIntervalMs int `json:"progress_interval_ms"` // Min interval between progress events (0 = 500ms)
+	DefaultRateProfile string `json:"default_rate_profile"` // Rate profile for tasks without their own (stealth/normal/aggressive, empty = normal)
+	MaxScanCPUPercent  int    `json:"max_scan_cpu_percent"` // CPU budget of all nuclei processes, % of the machine (0 = 80)
+	MaxScanMemoryMB    int    `json:"max_scan_memory_mb"`   // Memory budget of all nuclei processes in MB (0 = 2048)
+	Language           string `json:"language"`             // Language of backend messages: zh/en (empty = zh)
+	Offline            bool   `json:"offline"`              // Air-gapped mode: no update checks, public interactsh, notifications or other outbound feature calls
+	ActiveProfile      string `json:"active_profile"`       // Config profile the settings were last switched to (empty = none)
+	RequestConfirmThreshold int `json:"request_confirm_threshold"` // Estimated requests above which starting a task needs confirmation (0 = 1000000, -1 = never)
 	
 	// Advanced Nuclei Configuration
 	NucleiConfig NucleiAdvancedConfig `json:"nuclei_config"` // Advanced Nuclei settings
+
+	// Workspace retention
+	Retention RetentionConfig `json:"retention"` // Task retention and archiving
+
+	// Enhanced logs
+	Logging LoggingConfig `json:"logging"` // Level, rotation and retention of the structured logs in ~/.wepoc/logs/enhanced
+
+	// HTTP request logs
+	HTTPLogs HTTPLogConfig `json:"http_logs"` // Size cap and binary filtering of the responses captured with -debug
+
+	// AI-assisted template drafting
+	AI AIConfig `json:"ai"` // Optional LLM used to draft templates
+
+	// Template signing
+	TemplateTrust TemplateTrustConfig `json:"template_trust"` // Signers of template manifests and unverified template policy
+
+	// Headless templates
+	Headless HeadlessConfig `json:"headless"` // Browser used by tasks with headless templates enabled
+
+	// Code protocol templates
+	CodeTemplates CodeTemplateConfig `json:"code_templates"` // Local command execution by code templates
+
+	// Follow-up template chaining
+	FollowUp FollowUpConfig `json:"follow_up"` // Templates queued automatically against hosts where a template fired
+
+	// Search engine target discovery
+	Uncover UncoverConfig `json:"uncover"` // API keys of the search engines used to create tasks from queries
+
+	// Application lock
+	Security SecurityConfig `json:"security"` // Startup passphrase gate and results encryption at rest
+
+	// SIEM result forwarding
+	Forwarding ForwardingConfig `json:"forwarding"` // Connectors findings are forwarded to as they are found
+
+	// Config profiles
+	Profiles []ConfigProfile `json:"profiles"` // Named environments (e.g. internal network, external via proxy) to switch to or reference from tasks
+
+	// Additional template roots
+	TemplateRoots []TemplateRoot `json:"template_roots"` // Template directories besides the POC directory (e.g. an internal repository)
+}
+
+// TemplateRoot is a named template directory. Template paths of the form "name:relative/path"
+// and relative paths of tasks that select the root resolve against it.
+type TemplateRoot struct {
+	Name        string `json:"name"` // 2-32个字母、数字、_或-，"default"表示POC目录
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+// ConfigProfile bundles the settings that depend on the network a scan runs from. Switching
+// to a profile copies them into the config; tasks referencing a profile scan with them
+// without changing the config.
+type ConfigProfile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	NucleiPath  string `json:"nuclei_path"`  // nuclei可执行文件，为空表示沿用当前配置
+	RateProfile string `json:"rate_profile"` // 默认速率配置（stealth/normal/aggressive），为空表示沿用当前配置
+
+	// 代理
+	ProxyEnabled  bool     `json:"proxy_enabled"`
+	ProxyURL      string   `json:"proxy_url"`
+	ProxyList     []string `json:"proxy_list"`
+	ProxyInternal bool     `json:"proxy_internal"`
+	ProxyUsername string   `json:"proxy_username"`
+	ProxyPassword string   `json:"proxy_password"` // 加密存储
+
+	// Interactsh
+	InteractshEnabled bool   `json:"interactsh_enabled"`
+	InteractshServer  string `json:"interactsh_server"`
+	InteractshToken   string `json:"interactsh_token"` // 加密存储
+	InteractshDisable bool   `json:"interactsh_disable"`
+}
+
+// SecurityConfig protects the workspace on shared machines; both options need the config
+// secret key to be passphrase protected
+type SecurityConfig struct {
+	LockOnStartup  bool `json:"lock_on_startup"` // Require the passphrase before the workspace is opened
+	EncryptResults bool `json:"encrypt_results"` // Encrypt stored results, HTTP logs and evidence, and seal the results and logs directories while the app is closed (needs LockOnStartup)
+}
+
+// ForwardingConfig configures the connectors that forward findings to a SIEM in near-real-time
+type ForwardingConfig struct {
+	Syslog        SyslogForwardConfig        `json:"syslog"`
+	Splunk        SplunkForwardConfig        `json:"splunk"`
+	Elasticsearch ElasticsearchForwardConfig `json:"elasticsearch"`
+	EventBus      EventBusConfig             `json:"event_bus"`
+	TaskSummaries bool                       `json:"task_summaries"` // Also forward a summary event when a task finishes
+	QueueSize     int                        `json:"queue_size"`     // Events buffered per connector; the oldest are dropped when full (0 = 10000)
+	MaxRetries    int                        `json:"max_retries"`    // Delivery attempts of a batch before it is dropped (0 = 5)
+}
+
+// SyslogForwardConfig sends RFC 5424 messages to a syslog collector
+type SyslogForwardConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Address  string `json:"address"`  // Collector host:port (port defaults to 514, 6514 for tls)
+	Protocol string `json:"protocol"` // udp/tcp/tls (empty = udp)
+	AppName  string `json:"app_name"` // APP-NAME field of the messages (empty = wepoc)
+}
+
+// SplunkForwardConfig sends events to a Splunk HTTP Event Collector
+type SplunkForwardConfig struct {
+	Enabled       bool   `json:"enabled"`
+	URL           string `json:"url"`             // HEC base URL, e.g. https://splunk:8088 (/services/collector/event is appended when missing)
+	Token         string `json:"token"`           // HEC token (encrypted on disk)
+	Index         string `json:"index"`           // Target index (empty = the token's default index)
+	SourceType    string `json:"source_type"`     // Sourcetype of the events (empty = wepoc:finding / wepoc:task)
+	TLSSkipVerify bool   `json:"tls_skip_verify"` // Accept self-signed HEC certificates
+}
+
+// ElasticsearchForwardConfig indexes events into Elasticsearch/OpenSearch with the bulk API
+type ElasticsearchForwardConfig struct {
+	Enabled       bool   `json:"enabled"`
+	URL           string `json:"url"`             // Cluster URL, e.g. https://es:9200
+	Index         string `json:"index"`           // Index or data stream name (empty = wepoc-findings)
+	Username      string `json:"username"`        // Basic auth user (ignored when an API key is set)
+	Password      string `json:"password"`        // Basic auth password (encrypted on disk)
+	APIKey        string `json:"api_key"`         // Base64 "id:key" API key (encrypted on disk)
+	TLSSkipVerify bool   `json:"tls_skip_verify"` // Accept self-signed cluster certificates
+}
+
+// FollowUpConfig configures follow-up tasks started automatically from findings
+type FollowUpConfig struct {
+	Rules    []FollowUpRule `json:"rules"`
+	MaxDepth int            `json:"max_depth"` // Max chain length: follow-up tasks of follow-up tasks (0 = 1, no chaining)
+}
+
+// FollowUpRule queues templates against every host on which one of its triggers fired
+type FollowUpRule struct {
+	Name      string   `json:"name"`
+	Enabled   bool     `json:"enabled"`
+	Triggers  []string `json:"triggers"`  // Template IDs, optionally "template-id:matcher-name"; * wildcards allowed (e.g. tech-detect:wordpress)
+	Templates []string `json:"templates"` // Template IDs queued against the host
+	Tags      []string `json:"tags"`      // Templates with any of these tags are queued as well
+}
+
+// CodeTemplateConfig controls code protocol templates, which run commands on this machine
+type CodeTemplateConfig struct {
+	Enabled bool              `json:"enabled"` // Allow tasks to run code templates (-code)
+	Env     map[string]string `json:"env"`     // Extra nuclei environment, e.g. NUCLEI_SIGNATURE_PUBLIC_KEY for self-signed templates
+}
+
+// HeadlessConfig configures the browser nuclei drives for headless templates
+type HeadlessConfig struct {
+	BrowserPath        string `json:"browser_path"`        // Chrome/Chromium executable (empty = auto-detect)
+	ChromiumRevision   int    `json:"chromium_revision"`   // Chromium snapshot downloaded as managed browser (0 = default)
+	PageTimeout        int    `json:"page_timeout"`        // Seconds to wait for a page (-page-timeout, 0 = nuclei default)
+	ShowBrowser        bool   `json:"show_browser"`        // Show the browser window while scanning (-show-browser)
+	FindingScreenshots bool   `json:"finding_screenshots"` // Screenshot the matched-at URL of new HTTP findings as evidence
+}
+
+// UncoverConfig holds the search engine API keys used to discover targets from a query
+type UncoverConfig struct {
+	FofaEmail  string `json:"fofa_email"`  // FOFA account email (only needed by old FOFA keys)
+	FofaKey    string `json:"fofa_key"`    // FOFA API key (encrypted on disk)
+	HunterKey  string `json:"hunter_key"`  // Qianxin Hunter API key (encrypted on disk)
+	ShodanKey  string `json:"shodan_key"`  // Shodan API key (encrypted on disk)
+	MaxResults int    `json:"max_results"` // Upper bound of results fetched per query (0 = 1000)
+}
+
+// AIConfig configures the optional LLM used to draft templates from advisories
+type AIConfig struct {
+	Enabled        bool   `json:"enabled"`
+	Endpoint       string `json:"endpoint"`        // OpenAI-compatible API base URL or chat completions URL
+	APIKey         string `json:"api_key"`         // API key (encrypted on disk)
+	Model          string `json:"model"`           // Model name sent with each request
+	TimeoutSeconds int    `json:"timeout_seconds"` // Request timeout (0 = 120s)
+}
+
+// TemplateTrustConfig holds the keys trusted to sign template manifests and whether
+// unverified templates may run
+type TemplateTrustConfig struct {
+	Signers            []TrustedSigner `json:"signers"`             // Public keys accepted for manifest signatures
+	BlockUnverified    bool            `json:"block_unverified"`    // Refuse to start tasks containing unverified templates
+	ProductionProfiles []string        `json:"production_profiles"` // Rate profiles treated as production; the block only applies to them (empty = all tasks)
+}
+
+// TrustedSigner is a key trusted to sign template manifests
+type TrustedSigner struct {
+	Name      string `json:"name"`       // Signer name written in the manifest
+	PublicKey string `json:"public_key"` // Base64 Ed25519 public key
+	Level     string `json:"level"`      // Trust level of templates it signs (official/internal)
+}
+
+// RetentionConfig controls automatic cleanup of finished tasks (0 disables a rule)
+type RetentionConfig struct {
+	KeepTasks         int `json:"keep_tasks"`          // Keep only the newest N finished tasks
+	KeepDays          int `json:"keep_days"`           // Delete finished tasks older than N days
+	CompressAfterDays int `json:"compress_after_days"` // Zip results/logs of tasks finished more than N days ago
+}
+
+// LoggingConfig configures the structured enhanced logs
+type LoggingConfig struct {
+	Level      string `json:"level"`        // Minimum level written: trace/debug/info/warn/error (empty = info)
+	MaxSizeMB  int    `json:"max_size_mb"`  // Rotate a log file once it exceeds this size (0 = 10MB)
+	MaxBackups int    `json:"max_backups"`  // Rotated files kept per log file (0 = 3)
+	MaxAgeDays int    `json:"max_age_days"` // Delete log files not written for N days (0 = 14)
+}
+
+// HTTPLogConfig bounds the responses kept in the HTTP request logs of a task
+type HTTPLogConfig struct {
+	MaxResponseKB  int  `json:"max_response_kb"`  // Responses above this size are truncated (0 = 256KB, -1 = unlimited)
+	CaptureBinary  bool `json:"capture_binary"`   // Keep bodies of binary responses (images, archives...) instead of omitting them
+	KeepFullBodies bool `json:"keep_full_bodies"` // Save complete truncated/binary responses to logs/task_<id>_responses.jsonl
+}
+
+// EventBusConfig publishes the scan event stream (progress, vuln_found, completed...) to a
+// message bus for external subscribers
+type EventBusConfig struct {
+	Enabled       bool     `json:"enabled"`
+	Type          string   `json:"type"`            // nats / kafka (through a Kafka REST proxy)
+	URL           string   `json:"url"`             // nats://host:4222 (tls://host:4222 for TLS) or REST proxy URL, e.g. http://host:8082
+	Subject       string   `json:"subject"`         // NATS subject prefix, events go to <subject>.<task_id>.<event_type>; Kafka topic (empty = wepoc.scan)
+	Username      string   `json:"username"`        // NATS user / REST proxy basic auth user
+	Password      string   `json:"password"`        // Password (encrypted on disk)
+	Token         string   `json:"token"`           // NATS auth token (encrypted on disk)
+	EventTypes    []string `json:"event_types"`     // Event types published (empty = all)
+	TLSSkipVerify bool     `json:"tls_skip_verify"` // Accept self-signed server certificates
 }
 
 // NucleiAdvancedConfig contains advanced Nuclei scanning parameters
@@ -96,6 +493,18 @@ type NucleiAdvancedConfig struct {
 	ProxyURL           string   `json:"proxy_url"`            // Single proxy URL
 	ProxyList          []string `json:"proxy_list"`           // List of proxy URLs
 	ProxyInternal      bool     `json:"proxy_internal"`       // Proxy internal requests
+	ProxyUsername      string   `json:"proxy_username"`       // Proxy auth username (used when URL has none)
+	ProxyPassword      string   `json:"proxy_password"`       // Proxy auth password (encrypted on disk)
+	
+	// Client Certificate (mTLS) Configuration
+	ClientCertFile     string   `json:"client_cert_file"`     // Client certificate (-client-cert)
+	ClientKeyFile      string   `json:"client_key_file"`      // Client private key (-client-key)
+	ClientCAFile       string   `json:"client_ca_file"`       // CA bundle (-client-ca)
+	
+	// TLS Configuration
+	TLSSkipVerify      bool     `json:"tls_skip_verify"`      // Tolerate invalid/malformed certificates (-ztls)
+	TLSMinVersion      string   `json:"tls_min_version"`      // Force minimum TLS version 1.0-1.3 (1.0/1.1 use -ztls)
+	TLSServerName      string   `json:"tls_server_name"`      // Custom SNI (-sni)
 	
 	// DNS/OAST Configuration
 	InteractshEnabled  bool   `json:"interactsh_enabled"`   // Enable Interactsh
@@ -103,12 +512,34 @@ type NucleiAdvancedConfig struct {
 	InteractshToken    string `json:"interactsh_token"`     // Interactsh auth token
 	InteractshDisable  bool   `json:"interactsh_disable"`   // Disable Interactsh completely
 	
+	// Resolver Configuration
+	Resolvers          []string       `json:"resolvers"`          // DNS servers (ip[:port]) passed via a generated -r file
+	SystemResolvers    bool           `json:"system_resolvers"`   // Fall back to the system resolver (-sr)
+	HostOverrides      []HostOverride `json:"host_overrides"`     // hosts-style name -> IP overrides ("*.corp.local" matches subdomains)
+	
 	// Additional Options
 	Retries            int  `json:"retries"`              // Number of retries
 	MaxHostError       int  `json:"max_host_error"`       // Max host errors
 	DisableUpdateCheck bool `json:"disable_update_check"` // Disable update check
 	FollowRedirects    bool `json:"follow_redirects"`     // Follow HTTP redirects
 	MaxRedirects       int  `json:"max_redirects"`        // Max redirects to follow
+
+	// Evasion (applied by a local proxy between nuclei and the targets)
+	Evasion EvasionConfig `json:"evasion"` // Default evasion settings of tasks without their own
+}
+
+
+// HostOverride maps a host name to an IP like an /etc/hosts entry
+type HostOverride struct {
+	Host string `json:"host"`
+	IP   string `json:"ip"`
+}
+
+// EvasionConfig holds request rewriting used to avoid simple WAF/IDS fingerprinting
+type EvasionConfig struct {
+	RandomUserAgent     bool `json:"random_user_agent"`     // Rotate browser User-Agents per request
+	DelayJitterMs       int  `json:"delay_jitter_ms"`       // Random delay of up to this many ms before each request (0 = none)
+	RandomizeHeaderCase bool `json:"randomize_header_case"` // Randomize the case of header names (HTTP/1.1 only)
 }
 
 // ScanLog represents a log entry during scanning
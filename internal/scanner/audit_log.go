@@ -0,0 +1,419 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Audit log actions
+const (
+	AuditCodeTemplatesConfirmed = "code_templates_confirmed" // 用户确认执行任务中的code模板
+	AuditCodeTemplatesExecuted  = "code_templates_executed"  // 以 -code 启动扫描
+	AuditRequestsConfirmed      = "requests_confirmed"       // 用户确认（或管理员放行）超过阈值的预估请求量
+	AuditLogin                  = "login"
+	AuditLogout                 = "logout"
+	AuditPermissionDenied       = "permission_denied"
+	AuditUserChanged            = "user_changed" // 创建、修改或删除用户
+	AuditConfigSaved            = "config_saved"
+	AuditConfigReloaded         = "config_reloaded" // 外部修改config.json后自动重新加载
+	AuditTaskCreated            = "task_created"
+	AuditTaskStarted            = "task_started"
+	AuditTaskStopped            = "task_stopped"
+	AuditTaskDeleted            = "task_deleted"
+	AuditTaskNotesEdited        = "task_notes_edited" // 修改任务的测试范围、备注或管理层摘要
+	AuditTaskLocked             = "task_locked"       // 任务作为证据锁定
+	AuditTaskUnlocked           = "task_unlocked"
+	AuditTemplatesImported      = "templates_imported"
+	AuditTemplatesDeleted       = "templates_deleted"
+	AuditWorkspaceRestored      = "workspace_restored"
+	AuditTemplateEdited         = "template_edited" // 修改模板内容、标签、分类或信任级别
+	AuditExported               = "exported"        // 导出结果、流量、模板或工作区
+	AuditCredentialsChanged     = "credentials_changed"
+	AuditPassphraseChanged      = "passphrase_changed"
+)
+
+// AuditEntry is a security relevant action recorded in the append-only audit log. Entries
+// are hash chained: each one carries the hash of its predecessor, so that editing, removing
+// or reordering entries is detected by VerifyAuditLog. The hashes are keyed with a key
+// derived from the secret key, so an edited log cannot simply be rehashed.
+type AuditEntry struct {
+	Seq       int64     `json:"seq,omitempty"` // 序号，从1开始
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`
+	User      string    `json:"user"` // 多用户模式下为登录用户，否则为操作系统用户
+	TaskID    int64     `json:"task_id,omitempty"`
+	TaskName  string    `json:"task_name,omitempty"`
+	Templates []string  `json:"templates,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	PrevHash  string    `json:"prev_hash,omitempty"` // 上一条记录的哈希
+	Keyed     bool      `json:"keyed,omitempty"`     // 哈希为HMAC-SHA256，旧记录为SHA-256
+	Hash      string    `json:"hash,omitempty"`      // HMAC-SHA256(prev_hash + 本条记录)
+}
+
+// auditMu serializes writes to the audit log
+var auditMu sync.Mutex
+
+// auditChain is the tail of an audit log file as of the last append (guarded by auditMu)
+type auditChain struct {
+	size int64 // 文件大小，不一致时说明文件被外部修改，需要重新读取
+	seq  int64
+	link string // 下一条记录的 prev_hash
+}
+
+var auditChains = make(map[string]*auditChain)
+
+// auditChainKey derives the key of the audit log hashes (see SetAuditChainKey)
+var (
+	auditChainKeyMu sync.RWMutex
+	auditChainKey   func() ([]byte, error)
+)
+
+// SetAuditChainKey sets the function deriving the HMAC key of the audit log hashes from the
+// secret key. Like the export signing key it is derived at each use, so it follows the
+// secret key when it is unlocked, changed or restored later.
+func SetAuditChainKey(derive func() ([]byte, error)) {
+	auditChainKeyMu.Lock()
+	auditChainKey = derive
+	auditChainKeyMu.Unlock()
+}
+
+// currentAuditChainKey derives the audit log key
+func currentAuditChainKey() ([]byte, error) {
+	auditChainKeyMu.RLock()
+	derive := auditChainKey
+	auditChainKeyMu.RUnlock()
+	if derive == nil {
+		return nil, fmt.Errorf("audit chain key is not configured")
+	}
+	key, err := derive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive audit chain key: %w", err)
+	}
+	return key, nil
+}
+
+// auditLogFile returns the path of the audit log
+func auditLogFile(logsDir string) string {
+	return filepath.Join(logsDir, "audit.jsonl")
+}
+
+// auditEntryHash computes the hash of an entry chained to its PrevHash: an HMAC with key for
+// keyed entries, a plain SHA-256 for entries written before the chain was keyed
+func auditEntryHash(entry *AuditEntry, key []byte) string {
+	unsigned := *entry
+	unsigned.Hash = ""
+	data, _ := json.Marshal(&unsigned)
+	if !entry.Keyed {
+		sum := sha256.Sum256(append([]byte(entry.PrevHash), data...))
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(entry.PrevHash))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// auditLineLink is what the entry following a line chains to: its hash, or for entries
+// written before the log was chained the hash of the raw line
+func auditLineLink(line []byte, entry *AuditEntry) string {
+	if entry.Hash != "" {
+		return entry.Hash
+	}
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// scanAuditLog calls fn for every non-empty line of the audit log (caller holds auditMu);
+// a missing log has no lines
+func scanAuditLog(path string, fn func(lineNo int, line []byte) error) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := fn(lineNo, scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return nil
+}
+
+// readAuditChain reads the sequence number and link of the last entry of the audit log
+func readAuditChain(path string) (*auditChain, error) {
+	chain := &auditChain{}
+	err := scanAuditLog(path, func(_ int, line []byte) error {
+		var entry AuditEntry
+		if json.Unmarshal(line, &entry) != nil {
+			return nil
+		}
+		chain.seq++
+		if entry.Seq > 0 {
+			chain.seq = entry.Seq
+		}
+		chain.link = auditLineLink(line, &entry)
+		return nil
+	})
+	return chain, err
+}
+
+// appendAuditLog appends an entry to the audit log, filling in the time, user and chain
+func appendAuditLog(logsDir string, entry *AuditEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.User == "" {
+		if current, err := user.Current(); err == nil {
+			entry.User = current.Username
+		}
+	}
+
+	key, err := currentAuditChainKey()
+	if err != nil {
+		return err
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	path := auditLogFile(logsDir)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+	chain := auditChains[path]
+	if chain == nil || chain.size != info.Size() {
+		if chain, err = readAuditChain(path); err != nil {
+			return err
+		}
+	}
+
+	entry.Seq = chain.seq + 1
+	entry.PrevHash = chain.link
+	entry.Keyed = true
+	entry.Hash = auditEntryHash(entry, key)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		delete(auditChains, path)
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	auditChains[path] = &auditChain{size: info.Size() + int64(len(data)) + 1, seq: entry.Seq, link: entry.Hash}
+	return nil
+}
+
+// SetAuditUser sets the logged-in user that audit entries are attributed to ("" = the
+// operating system user)
+func (tm *JSONTaskManager) SetAuditUser(username string) {
+	tm.handlersMu.Lock()
+	defer tm.handlersMu.Unlock()
+	tm.auditUser = username
+}
+
+// Audit records an action performed through the application in the audit log
+func (tm *JSONTaskManager) Audit(action string, taskID int64, detail string) {
+	entry := &AuditEntry{Action: action, TaskID: taskID, Detail: detail}
+	if taskID > 0 {
+		if task, err := tm.GetTaskByID(taskID); err == nil {
+			entry.TaskName = task.Name
+		}
+	}
+	tm.audit(entry)
+}
+
+// audit records an entry in the audit log, printing rather than failing on errors
+func (tm *JSONTaskManager) audit(entry *AuditEntry) {
+	if entry.User == "" {
+		tm.handlersMu.RLock()
+		entry.User = tm.auditUser
+		tm.handlersMu.RUnlock()
+	}
+	if err := appendAuditLog(tm.logsDir, entry); err != nil {
+		logWarnf("⚠️ 写入审计日志失败: %v\n", err)
+		return
+	}
+	logInfof("📝 审计: %s (任务 %d)\n", entry.Action, entry.TaskID)
+}
+
+// GetAuditLog returns the most recent audit entries, newest first (limit <= 0 returns all)
+func (tm *JSONTaskManager) GetAuditLog(limit int) ([]*AuditEntry, error) {
+	page, err := tm.QueryAuditLog(0, max(limit, 0), AuditLogFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// AuditLogFilter narrows an audit log query; empty fields match everything
+type AuditLogFilter struct {
+	Actions []string   `json:"actions"`        // 操作类型（任一匹配）
+	User    string     `json:"user"`           // 用户（不区分大小写）
+	TaskID  int64      `json:"task_id"`        // 任务ID
+	From    *time.Time `json:"from,omitempty"` // 时间下限
+	To      *time.Time `json:"to,omitempty"`   // 时间上限
+	Search  string     `json:"search"`         // 在任务名称、模板和详情中查找（不区分大小写）
+}
+
+func (f *AuditLogFilter) matches(entry *AuditEntry) bool {
+	if len(f.Actions) > 0 && !containsString(f.Actions, entry.Action) {
+		return false
+	}
+	if f.User != "" && !strings.EqualFold(f.User, entry.User) {
+		return false
+	}
+	if f.TaskID > 0 && f.TaskID != entry.TaskID {
+		return false
+	}
+	if f.From != nil && entry.Time.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && entry.Time.After(*f.To) {
+		return false
+	}
+	if search := strings.ToLower(strings.TrimSpace(f.Search)); search != "" {
+		text := strings.ToLower(entry.TaskName + "\n" + entry.Detail + "\n" + strings.Join(entry.Templates, "\n"))
+		if !strings.Contains(text, search) {
+			return false
+		}
+	}
+	return true
+}
+
+// AuditLogPage is one page of an audit log query
+type AuditLogPage struct {
+	Items  []*AuditEntry `json:"items"`
+	Total  int           `json:"total"` // 符合筛选条件的记录总数
+	Offset int           `json:"offset"`
+	Limit  int           `json:"limit"`
+}
+
+// QueryAuditLog returns a page of the audit entries matching the filter, newest first
+// (limit <= 0 returns all)
+func (tm *JSONTaskManager) QueryAuditLog(offset, limit int, filter AuditLogFilter) (*AuditLogPage, error) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	entries := []*AuditEntry{}
+	err := scanAuditLog(auditLogFile(tm.logsDir), func(_ int, line []byte) error {
+		var entry AuditEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil // 跳过损坏的行
+		}
+		if filter.matches(&entry) {
+			entries = append(entries, &entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	page := &AuditLogPage{Items: []*AuditEntry{}, Total: len(entries), Offset: max(offset, 0), Limit: limit}
+	if page.Offset < len(entries) {
+		entries = entries[page.Offset:]
+		if limit > 0 && len(entries) > limit {
+			entries = entries[:limit]
+		}
+		page.Items = entries
+	}
+	return page, nil
+}
+
+// AuditVerification is the result of checking the hash chain of the audit log
+type AuditVerification struct {
+	Valid     bool   `json:"valid"`
+	Entries   int    `json:"entries"`             // 已检查的记录数
+	Unchained int    `json:"unchained"`           // 启用哈希链之前写入的记录数
+	Unkeyed   int    `json:"unkeyed"`             // 哈希链使用密钥之前写入的记录数
+	BrokenAt  int    `json:"broken_at,omitempty"` // 第一条校验失败的记录所在行
+	Error     string `json:"error,omitempty"`
+}
+
+// VerifyAuditLog checks that no entry of the audit log was edited, removed or reordered.
+// Entries removed from the end of the log cannot be detected from the file alone.
+func (tm *JSONTaskManager) VerifyAuditLog() (*AuditVerification, error) {
+	key, err := currentAuditChainKey()
+	if err != nil {
+		return nil, err
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	result := &AuditVerification{Valid: true}
+	link := ""
+	chained, keyed := false, false
+	errBroken := errors.New("broken")
+	fail := func(lineNo int, reason string) error {
+		result.Valid = false
+		result.BrokenAt = lineNo
+		result.Error = reason
+		return errBroken
+	}
+	err = scanAuditLog(auditLogFile(tm.logsDir), func(lineNo int, line []byte) error {
+		var entry AuditEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fail(lineNo, "invalid entry")
+		}
+		result.Entries++
+		switch {
+		case entry.Hash == "" && chained:
+			return fail(lineNo, "entry without hash inserted")
+		case entry.Hash == "":
+			result.Unchained++
+		case !entry.Keyed && keyed:
+			return fail(lineNo, "entry without key inserted")
+		case entry.PrevHash != link:
+			return fail(lineNo, "previous entry was removed or reordered")
+		case !hmac.Equal([]byte(auditEntryHash(&entry, key)), []byte(entry.Hash)):
+			return fail(lineNo, "entry was modified")
+		default:
+			chained = true
+			keyed = entry.Keyed
+			if !keyed {
+				result.Unkeyed++
+			}
+		}
+		link = auditLineLink(line, &entry)
+		return nil
+	})
+	if err != nil && err != errBroken {
+		return nil, err
+	}
+	return result, nil
+}
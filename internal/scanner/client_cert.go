@@ -0,0 +1,137 @@
+package scanner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// ClientCertConfig holds the files used for mutual-TLS client authentication
+type ClientCertConfig struct {
+	CertFile string `json:"cert_file"` // 客户端证书（PEM）
+	KeyFile  string `json:"key_file"`  // 客户端私钥（PEM）
+	CAFile   string `json:"ca_file"`   // CA证书（PEM，可选）
+}
+
+// Enabled reports whether a client certificate is configured
+func (c ClientCertConfig) Enabled() bool {
+	return strings.TrimSpace(c.CertFile) != "" && strings.TrimSpace(c.KeyFile) != ""
+}
+
+// NucleiArgs returns nuclei's -client-cert/-client-key/-client-ca arguments
+func (c ClientCertConfig) NucleiArgs() []string {
+	if !c.Enabled() {
+		return nil
+	}
+	args := []string{"-client-cert", c.CertFile, "-client-key", c.KeyFile}
+	if strings.TrimSpace(c.CAFile) != "" {
+		args = append(args, "-client-ca", c.CAFile)
+	}
+	return args
+}
+
+// Load reads the certificate/key pair and the optional CA bundle
+func (c ClientCertConfig) Load() (*tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	if strings.TrimSpace(c.CAFile) == "" {
+		return &cert, nil, nil
+	}
+	caData, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return nil, nil, fmt.Errorf("CA bundle contains no valid PEM certificates")
+	}
+	return &cert, pool, nil
+}
+
+// apply adds the client certificate to a TLS config
+func (c ClientCertConfig) apply(tlsConfig *tls.Config) error {
+	if !c.Enabled() {
+		return nil
+	}
+	cert, pool, err := c.Load()
+	if err != nil {
+		return err
+	}
+	tlsConfig.Certificates = []tls.Certificate{*cert}
+	if pool != nil {
+		tlsConfig.RootCAs = pool
+	}
+	return nil
+}
+
+// ClientCertFromConfig returns the global client certificate settings
+func ClientCertFromConfig(config *models.Config) ClientCertConfig {
+	if config == nil {
+		return ClientCertConfig{}
+	}
+	return ClientCertConfig{
+		CertFile: config.NucleiConfig.ClientCertFile,
+		KeyFile:  config.NucleiConfig.ClientKeyFile,
+		CAFile:   config.NucleiConfig.ClientCAFile,
+	}
+}
+
+// ClientCertValidation describes a parsed client certificate
+type ClientCertValidation struct {
+	Valid    bool   `json:"valid"`
+	Subject  string `json:"subject"`   // 证书主题
+	Issuer   string `json:"issuer"`    // 颁发者
+	NotAfter string `json:"not_after"` // 过期时间
+	Expired  bool   `json:"expired"`   // 是否已过期
+	CACount  int    `json:"ca_count"`  // CA证书数量
+	Error    string `json:"error,omitempty"`
+}
+
+// ValidateClientCert loads the certificate files and reports their details
+func ValidateClientCert(c ClientCertConfig) *ClientCertValidation {
+	result := &ClientCertValidation{}
+	if !c.Enabled() {
+		result.Error = "请同时指定客户端证书和私钥"
+		return result
+	}
+
+	cert, pool, err := c.Load()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to parse certificate: %v", err)
+		return result
+	}
+	result.Valid = true
+	result.Subject = leaf.Subject.String()
+	result.Issuer = leaf.Issuer.String()
+	result.NotAfter = leaf.NotAfter.Format("2006-01-02 15:04:05")
+	result.Expired = leaf.NotAfter.Before(time.Now())
+	if pool != nil {
+		caData, _ := os.ReadFile(c.CAFile)
+		result.CACount = strings.Count(string(caData), "BEGIN CERTIFICATE")
+	}
+	return result
+}
+
+// clientCertForTask returns the task's client certificate, falling back to the global one
+func (tm *JSONTaskManager) clientCertForTask(task *TaskConfig) ClientCertConfig {
+	if task != nil && task.Options.ClientCert.Enabled() {
+		return task.Options.ClientCert
+	}
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return ClientCertFromConfig(tm.config)
+}
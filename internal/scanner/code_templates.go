@@ -0,0 +1,199 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"wepoc/internal/i18n"
+	"wepoc/internal/models"
+)
+
+// ErrCodeConfirmationRequired is returned when a task would run code templates that the
+// user has not confirmed (or that changed since they were confirmed)
+var ErrCodeConfirmationRequired = errors.New("code templates require confirmation")
+
+// CodeConfirmation records that the user accepted running the code templates of a task
+type CodeConfirmation struct {
+	ConfirmedAt time.Time         `json:"confirmed_at"`
+	Templates   map[string]string `json:"templates"` // POC -> 确认时的模板内容哈希
+}
+
+// CodeTemplate is a code protocol template selected in a task
+type CodeTemplate struct {
+	POC        string `json:"poc"`
+	TemplateID string `json:"template_id"`
+	Name       string `json:"name"`
+	FilePath   string `json:"file_path"`
+	Hash       string `json:"hash"`      // 模板内容哈希（SHA-256）
+	Confirmed  bool   `json:"confirmed"` // 当前内容已被确认
+}
+
+// codeTemplates returns the code protocol templates among the POCs of a task
+func (tm *JSONTaskManager) codeTemplates(task *TaskConfig) []*CodeTemplate {
+	code := []*CodeTemplate{}
+	for i, template := range tm.selectedTemplates(task.POCs, task.Options.TemplateRoot) {
+		if template == nil || !containsString(strings.Split(template.Protocols, ","), "code") {
+			continue
+		}
+		entry := &CodeTemplate{POC: task.POCs[i], TemplateID: template.TemplateID, Name: template.Name, FilePath: template.FilePath}
+		if content, err := os.ReadFile(task.templatePath(task.POCs[i])); err == nil {
+			entry.Hash = contentHash(content)
+		}
+		if task.CodeConfirmation != nil && entry.Hash != "" {
+			entry.Confirmed = task.CodeConfirmation.Templates[entry.POC] == entry.Hash
+		}
+		code = append(code, entry)
+	}
+	return code
+}
+
+// checkCodeTemplates refuses to start a task that runs code templates unless they are
+// allowed in the settings and every one of them was confirmed in its current version
+func (tm *JSONTaskManager) checkCodeTemplates(task *TaskConfig) error {
+	if !task.Options.CodeTemplates {
+		return nil
+	}
+	code := tm.codeTemplates(task)
+	if len(code) == 0 {
+		return nil
+	}
+	if tm.config == nil || !tm.config.CodeTemplates.Enabled {
+		return fmt.Errorf("任务包含 %d 个code模板，设置中未允许执行code模板", len(code))
+	}
+	var unconfirmed []string
+	for _, template := range code {
+		if !template.Confirmed {
+			unconfirmed = append(unconfirmed, template.POC)
+		}
+	}
+	if len(unconfirmed) > 0 {
+		return fmt.Errorf("%w: 任务包含 %d 个未确认（或确认后被修改）的code模板，这些模板会在本机执行命令",
+			ErrCodeConfirmationRequired, len(unconfirmed))
+	}
+	return nil
+}
+
+// GetTaskCodeTemplates returns the code templates of a task and whether each is confirmed,
+// to be shown before the user confirms them
+func (tm *JSONTaskManager) GetTaskCodeTemplates(taskID int64) ([]*CodeTemplate, error) {
+	task, err := tm.GetTaskByID(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+	return tm.codeTemplates(task), nil
+}
+
+// ConfirmCodeTemplates records the user's confirmation to run the current version of the
+// code templates of a task and writes it to the audit log
+func (tm *JSONTaskManager) ConfirmCodeTemplates(taskID int64) (*TaskConfig, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.loadTaskConfig(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+	if task.Status == "running" {
+		return nil, i18n.Errorf(i18n.ErrTaskRunning)
+	}
+	if err := task.checkUnlocked(); err != nil {
+		return nil, err
+	}
+	if tm.config == nil || !tm.config.CodeTemplates.Enabled {
+		return nil, fmt.Errorf("设置中未允许执行code模板")
+	}
+
+	code := tm.codeTemplates(task)
+	if len(code) == 0 {
+		return nil, fmt.Errorf("任务 %d 不包含code模板", taskID)
+	}
+	confirmation := &CodeConfirmation{ConfirmedAt: time.Now(), Templates: make(map[string]string, len(code))}
+	templates := make([]string, 0, len(code))
+	for _, template := range code {
+		if template.Hash == "" {
+			return nil, fmt.Errorf("无法读取code模板: %s", template.POC)
+		}
+		confirmation.Templates[template.POC] = template.Hash
+		templates = append(templates, template.POC)
+	}
+	sort.Strings(templates)
+
+	task.CodeConfirmation = confirmation
+	task.Options.CodeTemplates = true
+	task.UpdatedAt = time.Now()
+	tm.checkFilteredTemplates(task)
+	if err := tm.saveTaskConfig(task); err != nil {
+		return nil, fmt.Errorf("failed to save task: %w", err)
+	}
+
+	tm.audit(&AuditEntry{
+		Action:    AuditCodeTemplatesConfirmed,
+		TaskID:    task.ID,
+		TaskName:  task.Name,
+		Templates: templates,
+		Detail:    fmt.Sprintf("确认执行 %d 个code模板", len(templates)),
+	})
+	return task, nil
+}
+
+// codeArgs returns -code for tasks running code templates and the environment configured
+// for them, recording the execution in the audit log. The confirmation is checked again
+// against the template files passed to nuclei, whatever path launched the scan; -code is
+// left out when a code template is not confirmed in its current version.
+func (sns *SimpleNucleiScanner) codeArgs(config *models.Config) []string {
+	if !sns.task.Options.CodeTemplates || config == nil || !config.CodeTemplates.Enabled {
+		return nil
+	}
+	if sns.manager == nil {
+		return nil
+	}
+	if unconfirmed := sns.unconfirmedCodeTemplates(); len(unconfirmed) > 0 {
+		message := fmt.Sprintf("%d 个code模板未确认（或确认后被修改），本次扫描不启用 -code: %s",
+			len(unconfirmed), strings.Join(unconfirmed, ", "))
+		logWarnf("⚠️ %s\n", message)
+		sns.addLog("WARN", "", "", message, "", "", false)
+		return nil
+	}
+	for key, value := range config.CodeTemplates.Env {
+		sns.extraEnv = append(sns.extraEnv, key+"="+value)
+	}
+	sort.Strings(sns.extraEnv)
+
+	var templates []string
+	if sns.task.CodeConfirmation != nil {
+		for poc := range sns.task.CodeConfirmation.Templates {
+			templates = append(templates, poc)
+		}
+		sort.Strings(templates)
+	}
+	sns.manager.audit(&AuditEntry{
+		Action:    AuditCodeTemplatesExecuted,
+		TaskID:    sns.task.ID,
+		TaskName:  sns.task.Name,
+		Templates: templates,
+		Detail:    fmt.Sprintf("以 -code 启动扫描，目标 %d 个", len(sns.task.Targets)),
+	})
+	logWarnf("⚠️ 启用code模板（本机执行命令）: %d 个\n", len(templates))
+	return []string{"-code"}
+}
+
+// unconfirmedCodeTemplates returns the code templates of the scan whose file, as passed to
+// nuclei (the snapshot copy when rescanning a snapshot), differs from the confirmed version
+func (sns *SimpleNucleiScanner) unconfirmedCodeTemplates() []string {
+	var unconfirmed []string
+	for _, template := range sns.manager.codeTemplates(sns.task) {
+		hash := ""
+		if content, err := os.ReadFile(sns.templateFile(template.POC)); err == nil {
+			hash = contentHash(content)
+		}
+		confirmation := sns.task.CodeConfirmation
+		if hash == "" || confirmation == nil || confirmation.Templates[template.POC] != hash {
+			unconfirmed = append(unconfirmed, template.POC)
+		}
+	}
+	return unconfirmed
+}
@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+
+	"wepoc/internal/i18n"
+	"wepoc/internal/models"
+)
+
+// LookupConfigProfile finds a config profile by name (case-insensitive)
+func LookupConfigProfile(config *models.Config, name string) (models.ConfigProfile, bool) {
+	name = strings.TrimSpace(name)
+	if config == nil || name == "" {
+		return models.ConfigProfile{}, false
+	}
+	for _, profile := range config.Profiles {
+		if strings.EqualFold(profile.Name, name) {
+			return profile, true
+		}
+	}
+	return models.ConfigProfile{}, false
+}
+
+// ValidateConfigProfile checks a profile before it is saved
+func ValidateConfigProfile(profile models.ConfigProfile) error {
+	if strings.TrimSpace(profile.Name) == "" {
+		return i18n.Errorf(i18n.ErrConfigProfileName)
+	}
+	if profile.RateProfile != "" {
+		if _, ok := LookupRateProfile(profile.RateProfile); !ok {
+			return fmt.Errorf("未知的速率配置: %s", profile.RateProfile)
+		}
+	}
+	return nil
+}
+
+// ConfigProfileFromConfig captures the nuclei path, proxy, interactsh and rate settings of a
+// config as a profile
+func ConfigProfileFromConfig(config *models.Config, name, description string) models.ConfigProfile {
+	nucleiConfig := config.NucleiConfig
+	return models.ConfigProfile{
+		Name:              strings.TrimSpace(name),
+		Description:       description,
+		NucleiPath:        config.NucleiPath,
+		RateProfile:       config.DefaultRateProfile,
+		ProxyEnabled:      nucleiConfig.ProxyEnabled,
+		ProxyURL:          nucleiConfig.ProxyURL,
+		ProxyList:         append([]string(nil), nucleiConfig.ProxyList...),
+		ProxyInternal:     nucleiConfig.ProxyInternal,
+		ProxyUsername:     nucleiConfig.ProxyUsername,
+		ProxyPassword:     nucleiConfig.ProxyPassword,
+		InteractshEnabled: nucleiConfig.InteractshEnabled,
+		InteractshServer:  nucleiConfig.InteractshServer,
+		InteractshToken:   nucleiConfig.InteractshToken,
+		InteractshDisable: nucleiConfig.InteractshDisable,
+	}
+}
+
+// ApplyConfigProfile returns a copy of the config with the settings of a profile. The proxy
+// and interactsh settings are replaced as a whole (a profile without proxy scans directly);
+// an empty nuclei path or rate profile keeps the configured one.
+func ApplyConfigProfile(config *models.Config, profile models.ConfigProfile) *models.Config {
+	applied := *config
+	applied.ActiveProfile = profile.Name
+	if profile.NucleiPath != "" {
+		applied.NucleiPath = profile.NucleiPath
+	}
+	if profile.RateProfile != "" {
+		applied.DefaultRateProfile = profile.RateProfile
+	}
+
+	nucleiConfig := &applied.NucleiConfig
+	nucleiConfig.ProxyEnabled = profile.ProxyEnabled
+	nucleiConfig.ProxyURL = profile.ProxyURL
+	nucleiConfig.ProxyList = append([]string(nil), profile.ProxyList...)
+	nucleiConfig.ProxyInternal = profile.ProxyInternal
+	nucleiConfig.ProxyUsername = profile.ProxyUsername
+	nucleiConfig.ProxyPassword = profile.ProxyPassword
+	nucleiConfig.InteractshEnabled = profile.InteractshEnabled
+	nucleiConfig.InteractshServer = profile.InteractshServer
+	nucleiConfig.InteractshToken = profile.InteractshToken
+	nucleiConfig.InteractshDisable = profile.InteractshDisable
+	return &applied
+}
+
+// configForTask returns the config a task scans with: the current config, with the task's
+// config profile applied when it references one. A missing profile is an error rather than a
+// silent fallback, since the current settings may route the scan through the wrong network.
+func (tm *JSONTaskManager) configForTask(task *TaskConfig) (*models.Config, error) {
+	if tm.config == nil || task == nil || task.Options.ConfigProfile == "" {
+		return tm.config, nil
+	}
+	profile, ok := LookupConfigProfile(tm.config, task.Options.ConfigProfile)
+	if !ok {
+		return nil, i18n.Errorf(i18n.ErrConfigProfileNotFound, task.Options.ConfigProfile)
+	}
+	return ApplyConfigProfile(tm.config, profile), nil
+}
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// consoleLogger records the background messages of the scanner package (component
+// "console"), so that they end up in the enhanced logs rather than only on stdout
+var (
+	consoleLoggerOnce sync.Once
+	consoleLogger     *EnhancedLogger
+)
+
+// appLogger returns the console logger, or nil when its log file cannot be created
+func appLogger() *EnhancedLogger {
+	consoleLoggerOnce.Do(func() {
+		logger, err := NewEnhancedLogger(0, "console")
+		if err != nil {
+			fmt.Printf("⚠️ Failed to create console logger: %v\n", err)
+			return
+		}
+		consoleLogger = logger
+	})
+	return consoleLogger
+}
+
+// logf writes a message at the given level to the console logger and, when the level is
+// enabled, to stdout for the wails dev console
+func logf(level LogLevel, format string, args ...interface{}) {
+	minLevel, _, _ := currentLoggingConfig()
+	if level < minLevel {
+		return
+	}
+	message := fmt.Sprintf(format, args...)
+	fmt.Print(message)
+	if logger := appLogger(); logger != nil {
+		logger.record(level, strings.TrimRight(message, "\n"))
+	}
+}
+
+// logInfof logs an informational console message
+func logInfof(format string, args ...interface{}) {
+	logf(LogLevelInfo, format, args...)
+}
+
+// logWarnf logs a warning console message
+func logWarnf(format string, args ...interface{}) {
+	logf(LogLevelWarn, format, args...)
+}
+
+// logErrorf logs an error console message
+func logErrorf(format string, args ...interface{}) {
+	logf(LogLevelError, format, args...)
+}
+
+// ConsoleLog writes a message of another package (e.g. config, database) at a level named as
+// accepted by ParseLogLevel, so that it is filtered and recorded like the scanner's own
+func ConsoleLog(level, message string) {
+	parsed, _ := ParseLogLevel(level)
+	logf(parsed, "%s", message)
+}
@@ -0,0 +1,348 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CredentialPair is a single username/password combination
+type CredentialPair struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// CredentialDictionary maps a service (e.g. tomcat, grafana) to default credentials
+type CredentialDictionary struct {
+	Services map[string][]CredentialPair `json:"services"` // 服务名 -> 凭证列表
+}
+
+// Payload keys used by default-login templates for usernames and passwords
+var (
+	usernamePayloadKeys = []string{"username", "usernames", "user", "users", "login"}
+	passwordPayloadKeys = []string{"password", "passwords", "pass", "passwd", "pwd"}
+)
+
+// CredentialStore persists the credential dictionary in ~/.wepoc/credentials.json
+type CredentialStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewCredentialStore creates a store backed by ~/.wepoc/credentials.json
+func NewCredentialStore() (*CredentialStore, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	wepocDir := filepath.Join(homeDir, ".wepoc")
+	if err := os.MkdirAll(wepocDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create wepoc directory: %w", err)
+	}
+
+	return &CredentialStore{path: filepath.Join(wepocDir, "credentials.json")}, nil
+}
+
+// Load reads the dictionary; a missing file yields an empty dictionary
+func (s *CredentialStore) Load() (*CredentialDictionary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// load reads the dictionary (caller holds mu)
+func (s *CredentialStore) load() (*CredentialDictionary, error) {
+	dict := &CredentialDictionary{Services: make(map[string][]CredentialPair)}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return dict, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential dictionary: %w", err)
+	}
+	if err := json.Unmarshal(data, dict); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal credential dictionary: %w", err)
+	}
+	if dict.Services == nil {
+		dict.Services = make(map[string][]CredentialPair)
+	}
+	return dict, nil
+}
+
+// save writes the dictionary (caller holds mu)
+func (s *CredentialStore) save(dict *CredentialDictionary) error {
+	data, err := json.MarshalIndent(dict, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credential dictionary: %w", err)
+	}
+	// 凭证文件仅当前用户可读
+	return os.WriteFile(s.path, data, 0600)
+}
+
+// SetService replaces the credentials of a service (service names are case-insensitive)
+func (s *CredentialStore) SetService(service string, pairs []CredentialPair) (*CredentialDictionary, error) {
+	service = strings.ToLower(strings.TrimSpace(service))
+	if service == "" {
+		return nil, fmt.Errorf("服务名不能为空")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dict, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	cleaned := make([]CredentialPair, 0, len(pairs))
+	seen := make(map[CredentialPair]bool)
+	for _, pair := range pairs {
+		if pair.Username == "" && pair.Password == "" {
+			continue
+		}
+		if !seen[pair] {
+			seen[pair] = true
+			cleaned = append(cleaned, pair)
+		}
+	}
+	dict.Services[service] = cleaned
+
+	if err := s.save(dict); err != nil {
+		return nil, err
+	}
+	return dict, nil
+}
+
+// DeleteService removes a service from the dictionary
+func (s *CredentialStore) DeleteService(service string) (*CredentialDictionary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dict, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	delete(dict.Services, strings.ToLower(strings.TrimSpace(service)))
+
+	if err := s.save(dict); err != nil {
+		return nil, err
+	}
+	return dict, nil
+}
+
+// credentialsFor returns the dictionary entries matching a default-login template,
+// matched by service name against the template ID and tags
+func (d *CredentialDictionary) credentialsFor(templateID string, tags []string) []CredentialPair {
+	templateID = strings.ToLower(templateID)
+	tagSet := make(map[string]bool)
+	for _, tag := range tags {
+		tagSet[strings.ToLower(strings.TrimSpace(tag))] = true
+	}
+	if !tagSet["default-login"] && !strings.Contains(templateID, "default-login") {
+		return nil
+	}
+
+	services := make([]string, 0, len(d.Services))
+	for service := range d.Services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	var pairs []CredentialPair
+	for _, service := range services {
+		if tagSet[service] || strings.HasPrefix(templateID, service+"-") {
+			pairs = append(pairs, d.Services[service]...)
+		}
+	}
+	return pairs
+}
+
+// rewriteTemplateCredentials appends the dictionary credentials to the username/password
+// payloads of a default-login template. Returns the rewritten YAML and whether it changed.
+func (d *CredentialDictionary) rewriteTemplateCredentials(content []byte) ([]byte, bool, error) {
+	if len(d.Services) == 0 {
+		return content, false, nil
+	}
+
+	var meta struct {
+		ID   string `yaml:"id"`
+		Info struct {
+			Tags interface{} `yaml:"tags"`
+		} `yaml:"info"`
+	}
+	if err := yaml.Unmarshal(content, &meta); err != nil {
+		return nil, false, err
+	}
+
+	var tags []string
+	switch v := meta.Info.Tags.(type) {
+	case string:
+		tags = strings.Split(v, ",")
+	case []interface{}:
+		for _, tag := range v {
+			tags = append(tags, fmt.Sprintf("%v", tag))
+		}
+	}
+
+	pairs := d.credentialsFor(meta.ID, tags)
+	if len(pairs) == 0 {
+		return content, false, nil
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, false, err
+	}
+
+	changed := false
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.MappingNode {
+			if injectCredentialPayloads(node, pairs) {
+				changed = true
+			}
+			for i := 1; i < len(node.Content); i += 2 {
+				walk(node.Content[i])
+			}
+			return
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(&root)
+
+	if !changed {
+		return content, false, nil
+	}
+	data, err := marshalYAMLNode(&root)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// injectCredentialPayloads extends the payloads of a single request block. With the
+// clusterbomb attack usernames and passwords are extended independently; otherwise the
+// lists are extended pairwise and the attack switched to pitchfork.
+func injectCredentialPayloads(request *yaml.Node, pairs []CredentialPair) bool {
+	payloads := mappingValue(request, "payloads")
+	if payloads == nil || payloads.Kind != yaml.MappingNode {
+		return false
+	}
+
+	var userNode, passNode *yaml.Node
+	for _, key := range usernamePayloadKeys {
+		if node := mappingValue(payloads, key); node != nil && node.Kind == yaml.SequenceNode {
+			userNode = node
+			break
+		}
+	}
+	for _, key := range passwordPayloadKeys {
+		if node := mappingValue(payloads, key); node != nil && node.Kind == yaml.SequenceNode {
+			passNode = node
+			break
+		}
+	}
+	if userNode == nil || passNode == nil {
+		return false
+	}
+
+	attack := mappingValue(request, "attack")
+	if (attack != nil && attack.Value == "clusterbomb") || len(userNode.Content) != len(passNode.Content) {
+		for _, pair := range pairs {
+			appendUniqueScalar(userNode, pair.Username)
+			appendUniqueScalar(passNode, pair.Password)
+		}
+		if attack == nil {
+			setMappingValue(request, "attack", "clusterbomb")
+		} else {
+			attack.Value = "clusterbomb"
+		}
+		return true
+	}
+
+	existing := make(map[CredentialPair]bool)
+	for i := range userNode.Content {
+		existing[CredentialPair{Username: userNode.Content[i].Value, Password: passNode.Content[i].Value}] = true
+	}
+	for _, pair := range pairs {
+		if existing[pair] {
+			continue
+		}
+		existing[pair] = true
+		userNode.Content = append(userNode.Content, stringScalar(pair.Username))
+		passNode.Content = append(passNode.Content, stringScalar(pair.Password))
+	}
+	if attack == nil {
+		setMappingValue(request, "attack", "pitchfork")
+	} else {
+		attack.Value = "pitchfork"
+	}
+	return true
+}
+
+// mappingValue returns the value node for key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue appends a string key/value to a mapping node
+func setMappingValue(node *yaml.Node, key, value string) {
+	node.Content = append(node.Content, stringScalar(key), stringScalar(value))
+}
+
+// appendUniqueScalar appends value to a sequence node unless already present
+func appendUniqueScalar(seq *yaml.Node, value string) {
+	for _, item := range seq.Content {
+		if item.Value == value {
+			return
+		}
+	}
+	seq.Content = append(seq.Content, stringScalar(value))
+}
+
+// stringScalar creates a string scalar node (quoted where YAML needs it)
+func stringScalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// GetCredentialDictionary returns the managed default-credential dictionary
+func (tm *JSONTaskManager) GetCredentialDictionary() (*CredentialDictionary, error) {
+	store, err := NewCredentialStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Load()
+}
+
+// SetServiceCredentials replaces the credentials of a service in the dictionary
+func (tm *JSONTaskManager) SetServiceCredentials(service string, pairs []CredentialPair) (*CredentialDictionary, error) {
+	store, err := NewCredentialStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.SetService(service, pairs)
+}
+
+// DeleteServiceCredentials removes a service from the dictionary
+func (tm *JSONTaskManager) DeleteServiceCredentials(service string) (*CredentialDictionary, error) {
+	store, err := NewCredentialStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.DeleteService(service)
+}
@@ -0,0 +1,151 @@
+package scanner
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"wepoc/internal/i18n"
+)
+
+// DebugBundleResult describes an exported troubleshooting bundle
+type DebugBundleResult struct {
+	Path  string   `json:"path"`  // 导出的zip文件
+	Files []string `json:"files"` // 包含的文件
+	Size  string   `json:"size"`  // 文件大小（可读格式）
+}
+
+// ExportDebugBundle zips everything needed to troubleshoot a task into one file: the task
+// config, its debug/error logs, enhanced logs, the nuclei stderr lines and the environment
+// info supplied by the caller. Header/cookie values, tokens, login requests and the given
+// secrets are masked in every file.
+func (tm *JSONTaskManager) ExportDebugBundle(taskID int64, path string, environment map[string]interface{}, secrets []string) (*DebugBundleResult, error) {
+	task, err := tm.GetTaskByID(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+	secrets = append(task.Options.SecretValues(), secrets...)
+	if token := task.Options.InteractshToken; token != "" {
+		secrets = append(secrets, token)
+	}
+
+	paths := []string{task.LogFile}
+	for _, pattern := range []string{
+		filepath.Join(tm.logsDir, fmt.Sprintf("scan_debug_%d_*.log", taskID)),
+		filepath.Join(tm.logsDir, fmt.Sprintf("scan_error_%d_*.log", taskID)),
+		filepath.Join(tm.logsDir, "enhanced", fmt.Sprintf("task_%d_*.log", taskID)),
+	} {
+		matches, _ := filepath.Glob(pattern)
+		paths = append(paths, matches...)
+	}
+	files := map[string]string{}
+	for _, file := range paths {
+		if rel, err := filepath.Rel(tm.logsDir, file); err == nil && file != "" && !strings.HasPrefix(rel, "..") {
+			files["logs/"+filepath.ToSlash(rel)] = file
+		}
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create debug bundle: %w", err)
+	}
+	writer := zip.NewWriter(out)
+	result := &DebugBundleResult{Path: path, Files: []string{}}
+	add := func(name string, data []byte) error {
+		if err := addBytesToZip(writer, name, []byte(MaskSecrets(string(data), secrets))); err != nil {
+			return err
+		}
+		result.Files = append(result.Files, name)
+		return nil
+	}
+
+	writeErr := func() error {
+		taskData, err := json.MarshalIndent(redactedTask(task), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal task: %w", err)
+		}
+		if err := add("task.json", taskData); err != nil {
+			return err
+		}
+		if environment != nil {
+			envData, err := json.MarshalIndent(environment, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal environment: %w", err)
+			}
+			if err := add("environment.json", envData); err != nil {
+				return err
+			}
+		}
+
+		var stderr strings.Builder
+		for _, name := range sortedKeys(files) {
+			data, err := os.ReadFile(files[name])
+			if err != nil {
+				continue
+			}
+			if err := add(name, data); err != nil {
+				return err
+			}
+			if strings.Contains(filepath.Base(name), "scan_debug_") {
+				stderr.WriteString(stderrLines(data))
+			}
+		}
+		if stderr.Len() > 0 {
+			return add("nuclei_stderr.log", []byte(stderr.String()))
+		}
+		return nil
+	}()
+	closeErr := writer.Close()
+	if err := out.Close(); closeErr == nil {
+		closeErr = err
+	}
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		os.Remove(path)
+		return nil, writeErr
+	}
+
+	sort.Strings(result.Files)
+	if info, err := os.Stat(path); err == nil {
+		result.Size = formatBytes(info.Size())
+	}
+	logInfof("🧰 任务 %d 的诊断包已导出: %s（%d 个文件）\n", taskID, path, len(result.Files))
+	return result, nil
+}
+
+// redactedTask returns a copy of the task without its credentials
+func redactedTask(task *TaskConfig) *TaskConfig {
+	redacted := *task
+	options := redacted.Options
+	if options.InteractshToken != "" {
+		options.InteractshToken = "***"
+	}
+	if options.Login != nil {
+		login := *options.Login
+		login.RawRequest = "***"
+		options.Login = &login
+	}
+	redacted.Options = options
+	return &redacted
+}
+
+// stderrLines returns the nuclei stderr lines captured in a debug log
+func stderrLines(data []byte) string {
+	var lines strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.Contains(line, "[STDERR]") {
+			lines.WriteString(line)
+			lines.WriteByte('\n')
+		}
+	}
+	return lines.String()
+}
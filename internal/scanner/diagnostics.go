@@ -0,0 +1,144 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Diagnostic check statuses, from best to worst
+const (
+	DiagnosticOK      = "ok"
+	DiagnosticSkipped = "skipped"
+	DiagnosticWarning = "warning"
+	DiagnosticError   = "error"
+)
+
+// Free disk space thresholds of the workspace check
+const (
+	diskFreeWarning = 1 << 30   // 低于1GB提示
+	diskFreeError   = 100 << 20 // 低于100MB报错
+)
+
+// DiagnosticCheck is the outcome of one self-diagnostics check
+type DiagnosticCheck struct {
+	Name       string `json:"name"`             // 检查项标识
+	Title      string `json:"title"`            // 显示名称
+	Status     string `json:"status"`           // ok / skipped / warning / error
+	Message    string `json:"message"`          // 结果说明
+	Detail     string `json:"detail,omitempty"` // 附加信息（版本、路径、错误详情等）
+	DurationMs int64  `json:"duration_ms"`      // 检查耗时
+}
+
+// DiagnosticsReport is the self-diagnostics report rendered by the settings page
+type DiagnosticsReport struct {
+	Status      string             `json:"status"` // 所有检查项中最差的状态
+	Checks      []*DiagnosticCheck `json:"checks"`
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+// DiagnosticFunc runs one check and fills in its status, message and detail
+type DiagnosticFunc func(check *DiagnosticCheck)
+
+// Diagnostic is a named check of RunDiagnostics
+type Diagnostic struct {
+	Name  string
+	Title string
+	Run   DiagnosticFunc
+}
+
+// diagnosticRank orders statuses so that the report carries the worst one
+var diagnosticRank = map[string]int{DiagnosticOK: 0, DiagnosticSkipped: 1, DiagnosticWarning: 2, DiagnosticError: 3}
+
+// RunDiagnostics runs the checks concurrently (network checks may take several seconds)
+// and returns them in the given order
+func RunDiagnostics(diagnostics []Diagnostic) *DiagnosticsReport {
+	report := &DiagnosticsReport{Status: DiagnosticOK, Checks: make([]*DiagnosticCheck, len(diagnostics))}
+
+	var wg sync.WaitGroup
+	for i, diagnostic := range diagnostics {
+		wg.Add(1)
+		go func(i int, diagnostic Diagnostic) {
+			defer wg.Done()
+			check := &DiagnosticCheck{Name: diagnostic.Name, Title: diagnostic.Title, Status: DiagnosticOK}
+			start := time.Now()
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						check.Status = DiagnosticError
+						check.Message = fmt.Sprintf("检查异常: %v", r)
+					}
+				}()
+				diagnostic.Run(check)
+			}()
+			check.DurationMs = time.Since(start).Milliseconds()
+			report.Checks[i] = check
+		}(i, diagnostic)
+	}
+	wg.Wait()
+
+	for _, check := range report.Checks {
+		if diagnosticRank[check.Status] > diagnosticRank[report.Status] {
+			report.Status = check.Status
+		}
+	}
+	report.GeneratedAt = time.Now()
+	return report
+}
+
+// CheckDirWritable fails the check when a file cannot be created in dir
+func CheckDirWritable(check *DiagnosticCheck, dir string) {
+	check.Detail = dir
+	if dir == "" {
+		check.Status = DiagnosticError
+		check.Message = "未配置目录"
+		return
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		check.Status = DiagnosticError
+		check.Message = fmt.Sprintf("无法创建目录: %v", err)
+		return
+	}
+	file, err := os.CreateTemp(dir, ".wepoc-write-test-*")
+	if err != nil {
+		check.Status = DiagnosticError
+		check.Message = fmt.Sprintf("目录不可写: %v", err)
+		return
+	}
+	file.Close()
+	os.Remove(file.Name())
+	check.Message = "目录可写"
+}
+
+// CheckDiskFree warns when the filesystem of path is running out of space
+func CheckDiskFree(check *DiagnosticCheck, path string) {
+	// 路径可能尚未创建，向上查找存在的目录
+	for dir := path; dir != ""; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			path = dir
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	free, err := diskFree(path)
+	if err != nil {
+		check.Status = DiagnosticWarning
+		check.Message = fmt.Sprintf("无法获取剩余空间: %v", err)
+		return
+	}
+
+	check.Detail = path
+	check.Message = fmt.Sprintf("剩余空间 %s", formatBytes(int64(free)))
+	switch {
+	case free < diskFreeError:
+		check.Status = DiagnosticError
+		check.Message += "，空间不足，扫描结果可能无法写入"
+	case free < diskFreeWarning:
+		check.Status = DiagnosticWarning
+		check.Message += "，空间较少"
+	}
+}
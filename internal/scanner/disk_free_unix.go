@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package scanner
+
+import "syscall"
+
+// diskFree returns the bytes available to the current user on the filesystem of path
+func diskFree(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
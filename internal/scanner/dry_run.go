@@ -0,0 +1,252 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"wepoc/internal/i18n"
+)
+
+// nucleiDefaultRateLimit is nuclei's default -rl (requests per second), used by the normal rate profile
+const nucleiDefaultRateLimit = 150
+
+// DryRunTemplate is the estimate for a single template
+type DryRunTemplate struct {
+	TemplateID string `json:"template_id"`
+	Path       string `json:"path"`
+	Protocol   string `json:"protocol"`         // http/dns/network/ssl/...
+	Requests   int    `json:"requests"`         // 每个目标的预估请求数
+	Status     string `json:"status"`           // loaded / filtered / invalid
+	Reason     string `json:"reason,omitempty"` // 被过滤或无效的原因
+}
+
+// DryRunReport previews what a task would do without sending any traffic
+type DryRunReport struct {
+	TaskID            int64             `json:"task_id"`
+	SelectedTemplates int               `json:"selected_templates"`  // 选择的模板数量
+	LoadedTemplates   int               `json:"loaded_templates"`    // 实际会加载的模板数量
+	FilteredTemplates int               `json:"filtered_templates"`  // 会被Nuclei过滤的模板数量（code/headless/file）
+	InvalidTemplates  int               `json:"invalid_templates"`   // 无法读取或解析的模板数量
+	TargetCount       int               `json:"target_count"`        // 目标数量
+	RequestsPerTarget int               `json:"requests_per_target"` // 每个目标的预估请求数
+	EstimatedRequests int               `json:"estimated_requests"`  // 预估总请求数
+	RateLimit         int               `json:"rate_limit"`          // 估算使用的速率（请求/秒）
+	EstimatedSeconds  int64             `json:"estimated_seconds"`   // 预估耗时（秒）
+	EstimatedDuration string            `json:"estimated_duration"`  // 预估耗时（可读格式）
+	Templates         []*DryRunTemplate `json:"templates"`
+}
+
+// filteredProtocols are template protocols nuclei skips unless explicitly enabled
+var filteredProtocols = map[string]string{
+	"code":     "code协议模板需要 -code 参数",
+	"headless": "headless模板需要 -headless 参数",
+	"file":     "file协议模板不针对网络目标",
+}
+
+// filterReason reports whether nuclei skips templates of a protocol with the given task
+// options, and why
+func filterReason(protocol string, options TaskOptions) (string, bool) {
+	if (protocol == "headless" && options.Headless) || (protocol == "code" && options.CodeTemplates) {
+		return "", false
+	}
+	reason, filtered := filteredProtocols[protocol]
+	return reason, filtered
+}
+
+// requestProtocols lists the request-block keys of a template in priority order
+var requestProtocols = []string{"http", "requests", "dns", "network", "tcp", "ssl", "websocket", "whois", "javascript", "code", "headless", "file", "workflows"}
+
+// resolveTemplatePath converts a task POC entry into an absolute template path, resolving
+// relative paths against the POC directory
+func resolveTemplatePath(poc string) string {
+	return resolveTemplatePathIn(poc, "")
+}
+
+// templatePath converts a POC entry of the task into an absolute template path, resolving
+// relative paths against the template root selected for the task
+func (task *TaskConfig) templatePath(poc string) string {
+	return resolveTemplatePathIn(poc, task.Options.TemplateRoot)
+}
+
+// DryRunTask estimates how many templates will load, how many requests will be sent and
+// how long the scan will take, computed from the parsed templates without running nuclei
+func (tm *JSONTaskManager) DryRunTask(taskID int64) (*DryRunReport, error) {
+	task, err := tm.GetTaskByID(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+
+	report, err := tm.estimateTask(task)
+	if err != nil {
+		return nil, err
+	}
+
+	logInfof("🧪 任务 %d 预演: 加载 %d/%d 个模板, 预估 %d 个请求, 约 %s\n",
+		taskID, report.LoadedTemplates, report.SelectedTemplates, report.EstimatedRequests, report.EstimatedDuration)
+
+	return report, nil
+}
+
+// estimateTask computes the dry run report of a task
+func (tm *JSONTaskManager) estimateTask(task *TaskConfig) (*DryRunReport, error) {
+	config, err := tm.configForTask(task)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DryRunReport{
+		TaskID:            task.ID,
+		SelectedTemplates: len(task.POCs),
+		TargetCount:       len(task.Targets),
+		RateLimit:         EffectiveRateProfile(task.Options, config).RateLimit,
+		Templates:         make([]*DryRunTemplate, 0, len(task.POCs)),
+	}
+
+	wordlists, _ := NewWordlistManager()
+
+	for _, poc := range task.POCs {
+		path := task.templatePath(poc)
+		templatesDir := defaultTemplatesDir()
+		if _, rootDir, ok := templateRootOf(path); ok {
+			templatesDir = rootDir
+		}
+		entry := estimateTemplate(path, templatesDir, wordlists, task.Options)
+		report.Templates = append(report.Templates, entry)
+
+		switch entry.Status {
+		case "loaded":
+			report.LoadedTemplates++
+			report.RequestsPerTarget += entry.Requests
+		case "filtered":
+			report.FilteredTemplates++
+		default:
+			report.InvalidTemplates++
+		}
+	}
+
+	report.EstimatedRequests = report.RequestsPerTarget * report.TargetCount
+	report.EstimatedSeconds = int64((report.EstimatedRequests + report.RateLimit - 1) / report.RateLimit)
+	report.EstimatedDuration = (time.Duration(report.EstimatedSeconds) * time.Second).String()
+
+	return report, nil
+}
+
+// estimateTemplate parses a template and estimates its requests per target
+func estimateTemplate(path, templatesDir string, wordlists *WordlistManager, options TaskOptions) *DryRunTemplate {
+	entry := &DryRunTemplate{Path: path, TemplateID: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		entry.Status = "invalid"
+		entry.Reason = fmt.Sprintf("无法读取模板: %v", err)
+		return entry
+	}
+
+	var template map[string]interface{}
+	if err := yaml.Unmarshal(content, &template); err != nil {
+		entry.Status = "invalid"
+		entry.Reason = fmt.Sprintf("模板YAML解析失败: %v", err)
+		return entry
+	}
+	if id, ok := template["id"].(string); ok && id != "" {
+		entry.TemplateID = id
+	}
+
+	for _, protocol := range requestProtocols {
+		blocks, ok := template[protocol]
+		if !ok {
+			continue
+		}
+		if entry.Protocol == "" {
+			entry.Protocol = protocol
+		}
+		if reason, filtered := filterReason(protocol, options); filtered {
+			entry.Status = "filtered"
+			entry.Reason = reason
+			entry.Requests = 0
+			return entry
+		}
+
+		list, _ := blocks.([]interface{})
+		for _, block := range list {
+			entry.Requests += estimateBlockRequests(block, templatesDir, wordlists)
+		}
+	}
+
+	if entry.Protocol == "" {
+		entry.Status = "invalid"
+		entry.Reason = "模板未包含任何请求"
+		return entry
+	}
+	entry.Status = "loaded"
+	return entry
+}
+
+// estimateBlockRequests estimates the requests of one request block:
+// steps (raw/path entries) multiplied by payload combinations
+func estimateBlockRequests(block interface{}, templatesDir string, wordlists *WordlistManager) int {
+	request, ok := block.(map[string]interface{})
+	if !ok {
+		return 1
+	}
+
+	steps := 0
+	for _, key := range []string{"raw", "path", "inputs"} {
+		if list, ok := request[key].([]interface{}); ok {
+			steps += len(list)
+		}
+	}
+	if steps == 0 {
+		steps = 1
+	}
+
+	payloads, ok := request["payloads"].(map[string]interface{})
+	if !ok || len(payloads) == 0 {
+		return steps
+	}
+
+	attack, _ := request["attack"].(string)
+	combinations := 0
+	for _, value := range payloads {
+		count := payloadCount(value, templatesDir, wordlists)
+		switch {
+		case attack == "clusterbomb" && combinations == 0:
+			combinations = count
+		case attack == "clusterbomb":
+			combinations *= count
+		case count > combinations:
+			// batteringram / pitchfork 按最长的载荷列表迭代
+			combinations = count
+		}
+	}
+	if combinations == 0 {
+		combinations = 1
+	}
+	return steps * combinations
+}
+
+// payloadCount returns the number of values of a payload: list length or payload file line count
+func payloadCount(value interface{}, templatesDir string, wordlists *WordlistManager) int {
+	switch v := value.(type) {
+	case []interface{}:
+		return len(v)
+	case string:
+		candidates := []string{v, filepath.Join(templatesDir, v)}
+		if wordlists != nil {
+			if path, ok := wordlists.Resolve(v); ok {
+				candidates = append(candidates, path)
+			}
+		}
+		for _, candidate := range candidates {
+			if lines, err := countLines(candidate); err == nil {
+				return lines
+			}
+		}
+	}
+	return 1
+}
@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// enhancedLogPattern parses task_<id>_<component>_<timestamp>.log[.<backup>]
+var enhancedLogPattern = regexp.MustCompile(`^task_(\d+)_(.+)_(\d{4}-\d{2}-\d{2}_\d{2}-\d{2}-\d{2})\.log(?:\.(\d+))?$`)
+
+// EnhancedLogFile is one file in ~/.wepoc/logs/enhanced
+type EnhancedLogFile struct {
+	Name      string    `json:"name"`
+	TaskID    int64     `json:"task_id"`          // 0 表示非任务日志（如临时文件管理）
+	Component string    `json:"component"`        // 写入日志的组件
+	Backup    int       `json:"backup,omitempty"` // 轮转序号，0 表示当前文件
+	Bytes     int64     `json:"bytes"`
+	Size      string    `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+}
+
+// ListEnhancedLogs lists the enhanced log files, newest first
+func ListEnhancedLogs() ([]*EnhancedLogFile, error) {
+	dir, err := enhancedLogDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []*EnhancedLogFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	files := []*EnhancedLogFile{}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		file := &EnhancedLogFile{Name: entry.Name(), Bytes: info.Size(), Size: formatBytes(info.Size()), ModTime: info.ModTime()}
+		if match := enhancedLogPattern.FindStringSubmatch(entry.Name()); match != nil {
+			file.TaskID, _ = strconv.ParseInt(match[1], 10, 64)
+			file.Component = match[2]
+			file.Backup, _ = strconv.Atoi(match[4])
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime.After(files[j].ModTime)
+	})
+	return files, nil
+}
+
+// enhancedLogPath resolves a log file name inside the enhanced log directory
+func enhancedLogPath(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.Contains(name, "..") {
+		return "", fmt.Errorf("无效的日志文件名: %s", name)
+	}
+	dir, err := enhancedLogDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// ReadEnhancedLog returns the last limit entries of a log file (all when limit <= 0);
+// lines that are not JSON entries are returned as messages
+func ReadEnhancedLog(name string, limit int) ([]*EnhancedLogEntry, error) {
+	path, err := enhancedLogPath(name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	entries := []*EnhancedLogEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		entry := &EnhancedLogEntry{}
+		if err := json.Unmarshal([]byte(line), entry); err != nil {
+			entry = &EnhancedLogEntry{Level: LogLevelInfo.String(), Message: line}
+		}
+		entries = append(entries, entry)
+		if limit > 0 && len(entries) > limit {
+			entries = entries[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	return entries, nil
+}
+
+// DeleteEnhancedLogs deletes the given log files and returns how many were removed; files
+// still held open by a running scan may fail to delete on Windows and are skipped
+func DeleteEnhancedLogs(names []string) (int, error) {
+	deleted := 0
+	var failed []string
+	for _, name := range names {
+		path, err := enhancedLogPath(name)
+		if err != nil {
+			return deleted, err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			failed = append(failed, name)
+			continue
+		}
+		deleted++
+	}
+	if len(failed) > 0 {
+		return deleted, fmt.Errorf("%d 个日志文件删除失败（可能正在使用）: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return deleted, nil
+}
+
+// pruneEnhancedLogs deletes the enhanced log files not modified within maxAge
+func pruneEnhancedLogs(maxAge time.Duration) {
+	files, err := ListEnhancedLogs()
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, file := range files {
+		if time.Since(file.ModTime) > maxAge {
+			names = append(names, file.Name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	deleted, _ := DeleteEnhancedLogs(names)
+	if deleted > 0 {
+		logInfof("🧹 已删除 %d 个过期的增强日志文件\n", deleted)
+	}
+}
@@ -7,7 +7,10 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
+
+	"wepoc/internal/models"
 )
 
 // LogLevel represents different log levels
@@ -42,6 +45,73 @@ func (l LogLevel) String() string {
 	}
 }
 
+// ParseLogLevel parses a level name (trace/debug/info/warn/error/fatal, case-insensitive)
+func ParseLogLevel(name string) (LogLevel, bool) {
+	for level := LogLevelTrace; level <= LogLevelFatal; level++ {
+		if strings.EqualFold(strings.TrimSpace(name), level.String()) {
+			return level, true
+		}
+	}
+	if strings.EqualFold(strings.TrimSpace(name), "warning") {
+		return LogLevelWarn, true
+	}
+	return LogLevelInfo, false
+}
+
+// Defaults of the enhanced log rotation and retention
+const (
+	defaultLogMaxSizeMB  = 10
+	defaultLogMaxBackups = 3
+	defaultLogMaxAgeDays = 14
+)
+
+// loggingConfig holds the global enhanced log settings applied to new loggers
+var (
+	loggingConfigMu sync.RWMutex
+	loggingConfig   models.LoggingConfig
+)
+
+// SetLoggingConfig applies the global level and rotation settings to the loggers created
+// afterwards and deletes the enhanced logs older than the retention period
+func SetLoggingConfig(config models.LoggingConfig) {
+	loggingConfigMu.Lock()
+	loggingConfig = config
+	loggingConfigMu.Unlock()
+
+	maxAge := config.MaxAgeDays
+	if maxAge <= 0 {
+		maxAge = defaultLogMaxAgeDays
+	}
+	go pruneEnhancedLogs(time.Duration(maxAge) * 24 * time.Hour)
+}
+
+// currentLoggingConfig returns the global log settings with defaults filled in
+func currentLoggingConfig() (LogLevel, int64, int) {
+	loggingConfigMu.RLock()
+	config := loggingConfig
+	loggingConfigMu.RUnlock()
+
+	level, _ := ParseLogLevel(config.Level)
+	maxSize := config.MaxSizeMB
+	if maxSize <= 0 {
+		maxSize = defaultLogMaxSizeMB
+	}
+	maxBackups := config.MaxBackups
+	if maxBackups <= 0 {
+		maxBackups = defaultLogMaxBackups
+	}
+	return level, int64(maxSize) << 20, maxBackups
+}
+
+// enhancedLogDir returns ~/.wepoc/logs/enhanced
+func enhancedLogDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".wepoc", "logs", "enhanced"), nil
+}
+
 // EnhancedLogEntry represents a structured log entry
 type EnhancedLogEntry struct {
 	Timestamp    time.Time              `json:"timestamp"`
@@ -94,18 +164,20 @@ type EnhancedLogger struct {
 	logDir      string
 	minLevel    LogLevel
 	context     map[string]interface{}
+
+	mu         sync.Mutex // 保护日志文件的写入和轮转
+	size       int64      // 当前日志文件大小
+	maxSize    int64      // 超过该大小时轮转
+	maxBackups int        // 保留的轮转文件数
 }
 
 // NewEnhancedLogger creates a new enhanced logger
 func NewEnhancedLogger(taskID int64, component string) (*EnhancedLogger, error) {
-	// Get home directory
-	homeDir, err := os.UserHomeDir()
+	// Create logs directory
+	logDir, err := enhancedLogDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		return nil, err
 	}
-
-	// Create logs directory
-	logDir := filepath.Join(homeDir, ".wepoc", "logs", "enhanced")
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create log directory: %w", err)
 	}
@@ -120,13 +192,19 @@ func NewEnhancedLogger(taskID int64, component string) (*EnhancedLogger, error)
 		return nil, fmt.Errorf("failed to create log file: %w", err)
 	}
 
+	minLevel, maxSize, maxBackups := currentLoggingConfig()
 	logger := &EnhancedLogger{
-		taskID:    taskID,
-		component: component,
-		logFile:   logFile,
-		logDir:    logDir,
-		minLevel:  LogLevelInfo,
-		context:   make(map[string]interface{}),
+		taskID:     taskID,
+		component:  component,
+		logFile:    logFile,
+		logDir:     logDir,
+		minLevel:   minLevel,
+		context:    make(map[string]interface{}),
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if info, err := logFile.Stat(); err == nil {
+		logger.size = info.Size()
 	}
 
 	// Log initialization
@@ -184,6 +262,12 @@ func (el *EnhancedLogger) Fatal(message string, err error, context ...map[string
 	el.log(LogLevelFatal, message, err, context...)
 }
 
+// record writes a message without echoing it to the console (used by the console logger,
+// which prints the message itself)
+func (el *EnhancedLogger) record(level LogLevel, message string) {
+	el.writeLogEntry(el.createLogEntry(level, message, nil))
+}
+
 // LogCommand logs command execution details
 func (el *EnhancedLogger) LogCommand(cmd *CommandInfo, message string, context ...map[string]interface{}) {
 	entry := el.createLogEntry(LogLevelInfo, message, nil, context...)
@@ -291,23 +375,63 @@ func (el *EnhancedLogger) getEnvironmentInfo() *EnvironmentInfo {
 	}
 }
 
-// writeLogEntry writes the log entry to file
+// writeLogEntry writes the log entry to file, rotating the file when it grows too large
 func (el *EnhancedLogger) writeLogEntry(entry *EnhancedLogEntry) {
-	if el.logFile == nil {
-		return
-	}
-
 	data, err := json.Marshal(entry)
 	if err != nil {
 		fmt.Printf("❌ Failed to marshal log entry: %v\n", err)
 		return
 	}
+	data = append(data, '\n')
+
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	if el.logFile == nil {
+		return
+	}
+	if el.maxSize > 0 && el.size > 0 && el.size+int64(len(data)) > el.maxSize {
+		if err := el.rotate(); err != nil {
+			fmt.Printf("❌ Failed to rotate log file: %v\n", err)
+			if el.logFile == nil {
+				return
+			}
+		}
+	}
 
-	if _, err := el.logFile.Write(append(data, '\n')); err != nil {
+	n, err := el.logFile.Write(data)
+	el.size += int64(n)
+	if err != nil {
 		fmt.Printf("❌ Failed to write log entry: %v\n", err)
 	}
 }
 
+// rotate renames the current log file to <name>.1 (shifting older backups and dropping the
+// oldest) and starts a new file; the caller holds el.mu
+func (el *EnhancedLogger) rotate() error {
+	path := el.logFile.Name()
+	el.logFile.Close()
+	el.logFile = nil
+
+	os.Remove(fmt.Sprintf("%s.%d", path, el.maxBackups))
+	for i := el.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	renameErr := os.Rename(path, path+".1")
+
+	// 重命名失败时截断当前文件，避免无限增长
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if renameErr != nil {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+	el.logFile = file
+	el.size = 0
+	return renameErr
+}
+
 // printToConsole prints important log entries to console
 func (el *EnhancedLogger) printToConsole(entry *EnhancedLogEntry) {
 	timestamp := entry.Timestamp.Format("15:04:05")
@@ -338,15 +462,22 @@ func (el *EnhancedLogger) printToConsole(entry *EnhancedLogEntry) {
 
 // Close closes the log file
 func (el *EnhancedLogger) Close() error {
+	el.Info("Enhanced logger closing")
+
+	el.mu.Lock()
+	defer el.mu.Unlock()
 	if el.logFile != nil {
-		el.Info("Enhanced logger closing")
-		return el.logFile.Close()
+		err := el.logFile.Close()
+		el.logFile = nil
+		return err
 	}
 	return nil
 }
 
 // GetLogFilePath returns the path to the current log file
 func (el *EnhancedLogger) GetLogFilePath() string {
+	el.mu.Lock()
+	defer el.mu.Unlock()
 	if el.logFile != nil {
 		return el.logFile.Name()
 	}
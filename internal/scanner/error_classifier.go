@@ -0,0 +1,205 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error categories reported by ClassifyError
+const (
+	ErrorCategoryDNS               = "dns"                // 域名解析失败
+	ErrorCategoryTLS               = "tls"                // TLS握手/证书错误
+	ErrorCategoryConnectionRefused = "connection_refused" // 连接被拒绝
+	ErrorCategoryConnectionReset   = "connection_reset"   // 连接被重置/意外断开
+	ErrorCategoryTimeout           = "timeout"            // 超时
+	ErrorCategoryUnreachable       = "unreachable"        // 网络不可达
+	ErrorCategoryProxy             = "proxy"              // 代理错误
+	ErrorCategoryTemplateSyntax    = "template_syntax"    // 模板语法/加载错误
+	ErrorCategoryHeadless          = "headless"           // 浏览器启动或页面操作失败
+	ErrorCategoryOther             = "other"              // 其他
+)
+
+// maxErrorSamples is how many example lines are kept per category
+const maxErrorSamples = 5
+
+// errorRule maps message fragments to a category; rules are checked in order
+type errorRule struct {
+	category  string
+	fragments []string
+}
+
+// errorRules is ordered so that the more specific cause wins, e.g. "proxyconnect tcp:
+// connection refused" is a proxy error rather than a refused target connection
+var errorRules = []errorRule{
+	{ErrorCategoryTemplateSyntax, []string{"could not parse template", "could not load template", "error occurred loading template", "yaml:", "invalid template", "template validation", "unmarshal"}},
+	{ErrorCategoryHeadless, []string{"headless", "chromium", "google-chrome", "browser", "devtools", "page timeout"}},
+	{ErrorCategoryProxy, []string{"proxyconnect", "proxy authentication", "socks", "407", "proxy"}},
+	{ErrorCategoryDNS, []string{"no such host", "could not resolve", "server misbehaving", "dns", "lookup "}},
+	{ErrorCategoryTLS, []string{"tls:", "x509", "handshake", "certificate", "ssl"}},
+	{ErrorCategoryConnectionRefused, []string{"connection refused", "actively refused"}},
+	{ErrorCategoryTimeout, []string{"deadline exceeded", "timeout", "timed out", "i/o timeout"}},
+	{ErrorCategoryConnectionReset, []string{"connection reset", "broken pipe", "unexpected eof", "forcibly closed", "eof"}},
+	{ErrorCategoryUnreachable, []string{"no route to host", "network is unreachable", "host is down"}},
+}
+
+// ClassifyError maps a nuclei error message to an error category
+func ClassifyError(message string) string {
+	lower := strings.ToLower(message)
+	for _, rule := range errorRules {
+		for _, fragment := range rule.fragments {
+			if strings.Contains(lower, fragment) {
+				return rule.category
+			}
+		}
+	}
+	return ErrorCategoryOther
+}
+
+// isErrorLine reports whether a nuclei stderr line is an error or warning worth classifying
+func isErrorLine(line string) bool {
+	return strings.Contains(line, "[ERR]") || strings.Contains(line, "[FTL]") ||
+		(strings.Contains(line, "[WRN]") && (strings.Contains(line, "Could not") || strings.Contains(strings.ToLower(line), "error")))
+}
+
+// TaskErrorSummary counts the errors of a task scan by category
+type TaskErrorSummary struct {
+	TaskID     int64               `json:"task_id"`
+	Total      int                 `json:"total"`      // 错误总数
+	Categories map[string]int      `json:"categories"` // 类别 -> 次数
+	Samples    map[string][]string `json:"samples"`    // 类别 -> 示例错误
+	UpdatedAt  time.Time           `json:"updated_at"`
+}
+
+// newTaskErrorSummary creates an empty summary
+func newTaskErrorSummary(taskID int64) *TaskErrorSummary {
+	return &TaskErrorSummary{
+		TaskID:     taskID,
+		Categories: make(map[string]int),
+		Samples:    make(map[string][]string),
+	}
+}
+
+// add classifies and counts one error message
+func (s *TaskErrorSummary) add(message string) string {
+	category := ClassifyError(message)
+	s.Categories[category]++
+	s.Total++
+	if len(s.Samples[category]) < maxErrorSamples {
+		s.Samples[category] = append(s.Samples[category], message)
+	}
+	s.UpdatedAt = time.Now()
+	return category
+}
+
+// TopCategory returns the most frequent category, or "" when there are no errors
+func (s *TaskErrorSummary) TopCategory() string {
+	categories := make([]string, 0, len(s.Categories))
+	for category := range s.Categories {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if s.Categories[categories[i]] != s.Categories[categories[j]] {
+			return s.Categories[categories[i]] > s.Categories[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+	if len(categories) == 0 {
+		return ""
+	}
+	return categories[0]
+}
+
+// errorCollector accumulates a task's error summary while nuclei runs
+type errorCollector struct {
+	summary *TaskErrorSummary
+	mu      sync.Mutex
+}
+
+// newErrorCollector creates a collector for a task
+func newErrorCollector(taskID int64) *errorCollector {
+	return &errorCollector{summary: newTaskErrorSummary(taskID)}
+}
+
+// observe classifies a stderr line if it reports an error
+func (c *errorCollector) observe(line string) {
+	if !isErrorLine(line) {
+		return
+	}
+	c.record(line)
+}
+
+// record classifies and counts an error message
+func (c *errorCollector) record(message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.summary.add(message)
+}
+
+// save writes the summary to task_<id>_errors.json in the logs directory of the manager
+func (c *errorCollector) save(tm *JSONTaskManager) error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c.summary, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal error summary: %w", err)
+	}
+	return tm.writeEvidenceFile(errorSummaryFile(tm.logsDir, c.summary.TaskID), data)
+}
+
+// errorSummaryFile returns the path of a task's error summary
+func errorSummaryFile(logsDir string, taskID int64) string {
+	return filepath.Join(logsDir, fmt.Sprintf("task_%d_errors.json", taskID))
+}
+
+// GetTaskErrorSummary returns the error counts per category of a task's last scan.
+// Tasks scanned before summaries were recorded fall back to their latest debug log.
+func (tm *JSONTaskManager) GetTaskErrorSummary(taskID int64) (*TaskErrorSummary, error) {
+	data, err := tm.readEvidenceFile(errorSummaryFile(tm.logsDir, taskID))
+	if err == nil {
+		summary := newTaskErrorSummary(taskID)
+		if err := json.Unmarshal(data, summary); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal error summary: %w", err)
+		}
+		return summary, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read error summary: %w", err)
+	}
+
+	return summarizeDebugLog(tm.logsDir, taskID)
+}
+
+// summarizeDebugLog classifies the errors in the latest scan_debug_<id>_*.log of a task
+func summarizeDebugLog(logsDir string, taskID int64) (*TaskErrorSummary, error) {
+	summary := newTaskErrorSummary(taskID)
+
+	matches, _ := filepath.Glob(filepath.Join(logsDir, fmt.Sprintf("scan_debug_%d_*.log", taskID)))
+	if len(matches) == 0 {
+		return summary, nil
+	}
+	// 文件名中的时间戳格式可按字典序排序
+	sort.Strings(matches)
+
+	file, err := os.Open(matches[len(matches)-1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to open debug log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "[STDERR]") && isErrorLine(line) {
+			summary.add(line)
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,112 @@
+package scanner
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// evasionUserAgents are the browser User-Agents rotated when random UA is enabled
+var evasionUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36 Edg/123.0.0.0",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36",
+}
+
+// fixedCaseHeaders are written by net/http itself (looked up by canonical name), so their
+// case must not be randomized or they would be sent twice
+var fixedCaseHeaders = map[string]bool{
+	"Host": true, "User-Agent": true, "Content-Length": true,
+	"Transfer-Encoding": true, "Trailer": true, "Connection": true, "Accept-Encoding": true,
+}
+
+// evasionEnabled reports whether any evasion setting is on
+func evasionEnabled(config models.EvasionConfig) bool {
+	return config.RandomUserAgent || config.DelayJitterMs > 0 || config.RandomizeHeaderCase
+}
+
+// evasionConfig returns the evasion settings of the task, falling back to the global settings
+func (sns *SimpleNucleiScanner) evasionConfig() models.EvasionConfig {
+	if sns.task.Options.Evasion != nil {
+		return *sns.task.Options.Evasion
+	}
+	if sns.manager != nil && sns.manager.config != nil {
+		return sns.manager.config.NucleiConfig.Evasion
+	}
+	return models.EvasionConfig{}
+}
+
+// requestEvasion rewrites the requests forwarded by the session proxy
+type requestEvasion struct {
+	config models.EvasionConfig
+	// keepUserAgent is set when the task sends its own User-Agent, which is not rotated
+	keepUserAgent bool
+}
+
+// newRequestEvasion returns the rewriting for the given settings, or nil when all are off
+func newRequestEvasion(config models.EvasionConfig, options TaskOptions) *requestEvasion {
+	if !evasionEnabled(config) {
+		return nil
+	}
+	evasion := &requestEvasion{config: config}
+	for _, header := range options.Headers {
+		if strings.EqualFold(strings.TrimSpace(header.Name), "User-Agent") {
+			evasion.keepUserAgent = true
+		}
+	}
+	return evasion
+}
+
+// delay waits a random time up to the configured jitter before a request
+func (e *requestEvasion) delay() {
+	if e.config.DelayJitterMs > 0 {
+		time.Sleep(time.Duration(rand.Intn(e.config.DelayJitterMs+1)) * time.Millisecond)
+	}
+}
+
+// apply rotates the User-Agent and randomizes the case of header names of a request
+func (e *requestEvasion) apply(req *http.Request) {
+	if e.config.RandomUserAgent && !e.keepUserAgent {
+		req.Header.Set("User-Agent", evasionUserAgents[rand.Intn(len(evasionUserAgents))])
+	}
+	if !e.config.RandomizeHeaderCase {
+		return
+	}
+	headers := make(http.Header, len(req.Header))
+	for name, values := range req.Header {
+		if fixedCaseHeaders[http.CanonicalHeaderKey(name)] {
+			headers[name] = values
+			continue
+		}
+		// 直接写入map以保留大小写，net/http在HTTP/1.1下按原样发送
+		headers[randomCase(name)] = values
+	}
+	req.Header = headers
+}
+
+// randomCase flips the case of each letter of s at random
+func randomCase(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if rand.Intn(2) == 0 {
+			continue
+		}
+		switch {
+		case c >= 'a' && c <= 'z':
+			b[i] = c - 'a' + 'A'
+		case c >= 'A' && c <= 'Z':
+			b[i] = c - 'A' + 'a'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,304 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"wepoc/internal/models"
+)
+
+const defaultEventBusSubject = "wepoc.scan"
+
+// newEventBusConnector creates the publisher of the configured message bus
+func newEventBusConnector(config models.EventBusConfig) (forwardConnector, error) {
+	subject := strings.TrimSpace(config.Subject)
+	if subject == "" {
+		subject = defaultEventBusSubject
+	}
+	switch strings.ToLower(strings.TrimSpace(config.Type)) {
+	case "nats", "":
+		return newNATSConnector(config, subject)
+	case "kafka":
+		return newKafkaRESTConnector(config, subject)
+	}
+	return nil, fmt.Errorf("不支持的消息总线类型: %s", config.Type)
+}
+
+// natsConnector publishes events with the NATS core protocol; every batch ends with a
+// PING/PONG round trip so that a delivered batch is confirmed by the server
+type natsConnector struct {
+	address    string
+	useTLS     bool
+	skipVerify bool
+	subject    string
+	connect    []byte // CONNECT命令
+	mu         sync.Mutex
+	conn       net.Conn
+	reader     *bufio.Reader
+}
+
+func newNATSConnector(config models.EventBusConfig, subject string) (*natsConnector, error) {
+	raw := strings.TrimSpace(config.URL)
+	if raw == "" {
+		return nil, fmt.Errorf("NATS地址不能为空")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "nats://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Hostname() == "" || (parsed.Scheme != "nats" && parsed.Scheme != "tls") {
+		return nil, fmt.Errorf("无效的NATS地址: %s", config.URL)
+	}
+	port := parsed.Port()
+	if port == "" {
+		port = "4222"
+	}
+	if strings.ContainsAny(subject, " \t\r\n*>") {
+		return nil, fmt.Errorf("无效的NATS主题: %s", subject)
+	}
+
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "wepoc",
+		"lang":     "go",
+		"version":  "1.0",
+		"protocol": 0,
+	}
+	if config.Token != "" {
+		options["auth_token"] = config.Token
+	} else if config.Username != "" {
+		options["user"] = config.Username
+		options["pass"] = config.Password
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CONNECT options: %w", err)
+	}
+	return &natsConnector{
+		address:    net.JoinHostPort(parsed.Hostname(), port),
+		useTLS:     parsed.Scheme == "tls",
+		skipVerify: config.TLSSkipVerify,
+		subject:    subject,
+		connect:    []byte("CONNECT " + string(connect) + "\r\n"),
+	}, nil
+}
+
+func (c *natsConnector) Name() string { return "nats" }
+func (c *natsConnector) Target() string {
+	scheme := "nats"
+	if c.useTLS {
+		scheme = "tls"
+	}
+	return scheme + "://" + c.address + " (" + c.subject + ")"
+}
+
+func (c *natsConnector) Send(ctx context.Context, events []*ForwardEvent) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		if err := c.dial(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	}
+
+	var buf bytes.Buffer
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return &permanentForwardError{err: fmt.Errorf("failed to marshal event: %w", err)}
+		}
+		fmt.Fprintf(&buf, "PUB %s.%d.%s %d\r\n", c.subject, event.TaskID, natsToken(event.Type), len(payload))
+		buf.Write(payload)
+		buf.WriteString("\r\n")
+	}
+	buf.WriteString("PING\r\n")
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		c.reset()
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	if err := c.awaitPong(); err != nil {
+		c.reset()
+		return err
+	}
+	return nil
+}
+
+// dial connects, upgrades to TLS when required and authenticates
+func (c *natsConnector) dial(ctx context.Context) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	reader := bufio.NewReader(conn)
+
+	// 服务器连接后首先发送 INFO
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected NATS greeting: %q %v", strings.TrimSpace(line), err)
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info)
+	if c.useTLS || info.TLSRequired {
+		host, _, _ := net.SplitHostPort(c.address)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host, InsecureSkipVerify: c.skipVerify})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return fmt.Errorf("NATS TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	c.conn = conn
+	c.reader = reader
+	if _, err := conn.Write(append(c.connect, "PING\r\n"...)); err != nil {
+		c.reset()
+		return fmt.Errorf("failed to send NATS CONNECT: %w", err)
+	}
+	if err := c.awaitPong(); err != nil {
+		c.reset()
+		return &permanentForwardError{err: err}
+	}
+	return nil
+}
+
+// awaitPong reads until the server answers our PING, replying to the server's own PINGs
+func (c *natsConnector) awaitPong() error {
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read NATS response: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			c.conn.Write([]byte("PONG\r\n"))
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS error: %s", strings.Trim(strings.TrimPrefix(line, "-ERR"), " '"))
+		}
+	}
+}
+
+func (c *natsConnector) reset() {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	c.conn = nil
+	c.reader = nil
+}
+
+func (c *natsConnector) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reset()
+}
+
+// natsToken makes an event type usable as a subject token
+func natsToken(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '*' || r == '>' || r <= ' ' {
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+// kafkaRESTConnector produces events to a Kafka topic through a Kafka REST proxy (v2 API),
+// keyed by task ID so that the events of a task stay ordered within a partition
+type kafkaRESTConnector struct {
+	endpoint      string
+	authorization string
+	client        *http.Client
+}
+
+func newKafkaRESTConnector(config models.EventBusConfig, topic string) (*kafkaRESTConnector, error) {
+	parsed, err := parseForwardURL(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ContainsAny(topic, " /\\*>") {
+		return nil, fmt.Errorf("无效的Kafka主题: %s", topic)
+	}
+	parsed.Path += "/topics/" + topic
+
+	authorization := ""
+	if config.Username != "" {
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(config.Username, config.Password)
+		authorization = req.Header.Get("Authorization")
+	} else if config.Token != "" {
+		authorization = "Bearer " + config.Token
+	}
+	return &kafkaRESTConnector{
+		endpoint:      parsed.String(),
+		authorization: authorization,
+		client:        newForwardHTTPClient(config.TLSSkipVerify),
+	}, nil
+}
+
+func (c *kafkaRESTConnector) Name() string   { return "kafka" }
+func (c *kafkaRESTConnector) Target() string { return c.endpoint }
+
+func (c *kafkaRESTConnector) Send(ctx context.Context, events []*ForwardEvent) error {
+	records := make([]map[string]interface{}, 0, len(events))
+	for _, event := range events {
+		records = append(records, map[string]interface{}{
+			"key":   strconv.FormatInt(event.TaskID, 10),
+			"value": event,
+		})
+	}
+	payload, err := json.Marshal(map[string]interface{}{"records": records})
+	if err != nil {
+		return &permanentForwardError{err: fmt.Errorf("failed to marshal events: %w", err)}
+	}
+
+	body, err := postForward(ctx, c.client, c.endpoint, "application/vnd.kafka.json.v2+json", c.authorization, payload)
+	if err != nil {
+		return err
+	}
+	var response struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+	for _, offset := range response.Offsets {
+		if offset.ErrorCode != nil && *offset.ErrorCode != 0 {
+			return fmt.Errorf("Kafka produce error %d: %s", *offset.ErrorCode, offset.Error)
+		}
+	}
+	return nil
+}
+
+func (c *kafkaRESTConnector) Close() {
+	c.client.CloseIdleConnections()
+}
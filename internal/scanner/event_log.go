@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// EventLog persists the events of a task scan as JSON lines so that missed events can be
+// replayed by sequence number (e.g. when the event channel is full or a client reconnects)
+type EventLog struct {
+	path string
+	file *os.File
+	seq  int64
+	seal func([]byte) ([]byte, error) // 加密写入的事件行，见SetRecordCipher
+	mu   sync.Mutex
+}
+
+// eventLogFile returns the path of a task's event log
+func eventLogFile(logsDir string, taskID int64) string {
+	return filepath.Join(logsDir, fmt.Sprintf("task_%d_events.jsonl", taskID))
+}
+
+// openEventLog opens the event log of a task. A new scan starts an empty log; a resumed scan
+// appends to it and continues its sequence numbers, so clients catching up by sequence
+// number don't miss the events of the resumed part.
+func (tm *JSONTaskManager) openEventLog(taskID int64, resume bool) (*EventLog, error) {
+	path := eventLogFile(tm.logsDir, taskID)
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	var seq int64
+	if resume {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		seq = tm.lastEventSeq(taskID)
+	}
+	file, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	// 旧版本以0644创建的日志同样收紧权限
+	file.Chmod(0600)
+	return &EventLog{path: path, file: file, seq: seq, seal: tm.sealLogRecord}, nil
+}
+
+// lastEventSeq returns the sequence number of the last event in a task's log (0 for a
+// missing log)
+func (tm *JSONTaskManager) lastEventSeq(taskID int64) int64 {
+	events, err := tm.readEventLog(taskID, 0)
+	if err != nil || len(events) == 0 {
+		return 0
+	}
+	return events[len(events)-1].Seq
+}
+
+// loggedEvent is a ScanEvent as written to the log, with its payload still encoded
+type loggedEvent struct {
+	ScanEvent
+	Data json.RawMessage `json:"data"`
+}
+
+// decodeEventData decodes the payload of a logged event into the type it was emitted with,
+// so replayed events are handled like live ones
+func decodeEventData(eventType string, raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	if eventType == "progress" {
+		progress := &ScanProgress{}
+		if err := json.Unmarshal(raw, progress); err == nil {
+			return progress
+		}
+	}
+	var data interface{}
+	json.Unmarshal(raw, &data)
+	return data
+}
+
+// Append assigns the next sequence number to the event and writes it to the log
+func (l *EventLog) Append(event *ScanEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	event.Seq = l.seq
+	if l.file == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if l.seal != nil {
+		if data, err = l.seal(data); err != nil {
+			return err
+		}
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+// Close closes the log file
+func (l *EventLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// readEventLog returns the events logged for a task with a sequence number greater than
+// afterSeq
+func (tm *JSONTaskManager) readEventLog(taskID int64, afterSeq int64) ([]*ScanEvent, error) {
+	file, err := os.Open(eventLogFile(tm.logsDir, taskID))
+	if os.IsNotExist(err) {
+		return []*ScanEvent{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer file.Close()
+
+	events := []*ScanEvent{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var logged loggedEvent
+		if err := json.Unmarshal(tm.openLogRecord(scanner.Bytes()), &logged); err != nil {
+			// 最后一行可能正在写入，跳过
+			continue
+		}
+		if logged.Seq > afterSeq {
+			event := logged.ScanEvent
+			event.Data = decodeEventData(event.EventType, logged.Data)
+			events = append(events, &event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event log: %w", err)
+	}
+	return events, nil
+}
+
+// GetTaskEvents returns the events of a task's current/last scan (including the parts before
+// it was paused and resumed) after the given sequence number, so a client that missed events
+// can catch up
+func (tm *JSONTaskManager) GetTaskEvents(taskID int64, afterSeq int64) ([]*ScanEvent, error) {
+	return tm.readEventLog(taskID, afterSeq)
+}
@@ -0,0 +1,329 @@
+package scanner
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// exportManifestKey is the top-level field of a sealed export holding its manifest
+const exportManifestKey = "manifest"
+
+// ExportManifest lists the SHA-256 hashes of the sections of a JSON export and of the
+// evidence attached to its findings, signed with the installation's export key, so that a
+// delivered export can be checked for changes
+type ExportManifest struct {
+	Algorithm   string              `json:"algorithm"` // sha256
+	SealedAt    time.Time           `json:"sealed_at"`
+	Sections    map[string]string   `json:"sections"` // 顶层字段 -> 紧凑JSON的哈希
+	Attachments []*AttachmentDigest `json:"attachments,omitempty"`
+	Signer      string              `json:"signer,omitempty"`    // Base64 Ed25519 公钥
+	Signature   string              `json:"signature,omitempty"` // 对清单（不含本字段）的Base64 Ed25519 签名
+}
+
+// exportSigningKey derives the key signing the manifests of JSON exports (see
+// SetExportSigningKey)
+var (
+	exportSigningKeyMu sync.RWMutex
+	exportSigningKey   func() (ed25519.PrivateKey, error)
+)
+
+// SetExportSigningKey sets the function deriving the key signing export manifests from the
+// secret key; VerifyExport only accepts manifests signed with it. The key is derived at each
+// use, so it follows the secret key when it is unlocked, changed or restored later.
+func SetExportSigningKey(derive func() (ed25519.PrivateKey, error)) {
+	exportSigningKeyMu.Lock()
+	exportSigningKey = derive
+	exportSigningKeyMu.Unlock()
+}
+
+// currentExportSigningKey derives the export signing key
+func currentExportSigningKey() (ed25519.PrivateKey, error) {
+	exportSigningKeyMu.RLock()
+	derive := exportSigningKey
+	exportSigningKeyMu.RUnlock()
+	if derive == nil {
+		return nil, fmt.Errorf("export signing key is not configured")
+	}
+	return derive()
+}
+
+// manifestSigningPayload is the signed content of a manifest: its JSON without the signature
+func manifestSigningPayload(manifest *ExportManifest) ([]byte, error) {
+	unsigned := *manifest
+	unsigned.Signature = ""
+	return json.Marshal(&unsigned)
+}
+
+// signExportManifest signs a manifest with the export signing key
+func signExportManifest(manifest *ExportManifest) error {
+	key, err := currentExportSigningKey()
+	if err != nil {
+		return fmt.Errorf("export signing key is not available: %w", err)
+	}
+	manifest.Signer = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	payload, err := manifestSigningPayload(manifest)
+	if err != nil {
+		return err
+	}
+	manifest.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+	return nil
+}
+
+// verifyExportManifest checks the signature of a manifest against the export signing key
+func verifyExportManifest(manifest *ExportManifest) error {
+	key, err := currentExportSigningKey()
+	if err != nil {
+		return fmt.Errorf("无法校验签名：导出签名密钥不可用: %w", err)
+	}
+	if manifest.Signature == "" {
+		return fmt.Errorf("校验清单未签名")
+	}
+	public := key.Public().(ed25519.PublicKey)
+	if manifest.Signer != base64.StdEncoding.EncodeToString(public) {
+		return fmt.Errorf("校验清单不是由本机的导出密钥签名")
+	}
+	signature, err := base64.StdEncoding.DecodeString(manifest.Signature)
+	if err != nil {
+		return fmt.Errorf("签名格式无效")
+	}
+	payload, err := manifestSigningPayload(manifest)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(public, payload, signature) {
+		return fmt.Errorf("校验清单签名无效，清单已被修改")
+	}
+	return nil
+}
+
+// AttachmentDigest is the hash of an evidence file embedded in an export
+type AttachmentDigest struct {
+	Section  string `json:"section"` // 附件所在的顶层字段
+	DedupKey string `json:"dedup_key"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+// ExportCheck is the verification outcome of one section, attachment or the whole file
+type ExportCheck struct {
+	Name     string `json:"name"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+	Valid    bool   `json:"valid"`
+}
+
+// ExportVerification is the result of verifying a sealed export
+type ExportVerification struct {
+	Path           string         `json:"path"`
+	Valid          bool           `json:"valid"`               // 所有校验均通过
+	SealedAt       *time.Time     `json:"sealed_at,omitempty"` // 清单生成时间
+	Signer         string         `json:"signer,omitempty"`    // 清单签名者的公钥
+	SignatureValid bool           `json:"signature_valid"`     // 清单由本机导出密钥签名且未被修改
+	File           *ExportCheck   `json:"file,omitempty"`      // 与 .sha256 校验文件比对，缺少校验文件时为空
+	Sections       []*ExportCheck `json:"sections"`
+	Attachments    []*ExportCheck `json:"attachments"`
+	Errors         []string       `json:"errors"`
+}
+
+// sha256Hex returns the hex SHA-256 of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// sectionDigest hashes a JSON value in compact form, so that indentation does not matter
+func sectionDigest(raw json.RawMessage) (string, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return "", err
+	}
+	return sha256Hex(compact.Bytes()), nil
+}
+
+// sectionAttachments returns the digests of the evidence embedded in the findings of a
+// result section (a TaskResult)
+func sectionAttachments(section string, raw json.RawMessage) []*AttachmentDigest {
+	var result struct {
+		Vulnerabilities []*models.NucleiResult `json:"vulnerabilities"`
+	}
+	if json.Unmarshal(raw, &result) != nil {
+		return nil
+	}
+	var digests []*AttachmentDigest
+	for _, vuln := range result.Vulnerabilities {
+		for _, attachment := range vuln.Attachments {
+			if len(attachment.Data) == 0 {
+				continue
+			}
+			digests = append(digests, &AttachmentDigest{
+				Section:  section,
+				DedupKey: attachment.DedupKey,
+				Name:     attachment.Name,
+				Size:     int64(len(attachment.Data)),
+				SHA256:   sha256Hex(attachment.Data),
+			})
+		}
+	}
+	return digests
+}
+
+// buildExportManifest hashes the top-level sections of an export and its attachments
+func buildExportManifest(sections map[string]json.RawMessage) (*ExportManifest, error) {
+	manifest := &ExportManifest{Algorithm: "sha256", SealedAt: time.Now(), Sections: make(map[string]string)}
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		if name != exportManifestKey {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		digest, err := sectionDigest(sections[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash export section %s: %w", name, err)
+		}
+		manifest.Sections[name] = digest
+		manifest.Attachments = append(manifest.Attachments, sectionAttachments(name, sections[name])...)
+	}
+	return manifest, nil
+}
+
+// WriteSealedExport adds a signed manifest with the hashes of the sections and attachments
+// of a JSON export (a top-level object) and writes it to path, together with a sha256sum
+// style checksum file of the complete export (path + ".sha256"). The checksum file only
+// detects accidental damage; the manifest signature is what proves the export unchanged.
+func WriteSealedExport(path string, data []byte) error {
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return fmt.Errorf("failed to parse export: %w", err)
+	}
+	manifest, err := buildExportManifest(sections)
+	if err != nil {
+		return err
+	}
+	if err := signExportManifest(manifest); err != nil {
+		return err
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal export manifest: %w", err)
+	}
+	sections[exportManifestKey] = manifestData
+
+	sealed, err := json.MarshalIndent(sections, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal export: %w", err)
+	}
+	if err := os.WriteFile(path, sealed, 0644); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	checksum := fmt.Sprintf("%s  %s\n", sha256Hex(sealed), filepath.Base(path))
+	if err := os.WriteFile(path+".sha256", []byte(checksum), 0644); err != nil {
+		return fmt.Errorf("failed to write export checksum: %w", err)
+	}
+	return nil
+}
+
+// VerifyExport checks a sealed export: the signature of its manifest, the hash of every
+// section and attachment against the manifest and, when the .sha256 file is next to it, the
+// hash of the complete file
+func VerifyExport(path string) (*ExportVerification, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+	report := &ExportVerification{Path: path, Sections: []*ExportCheck{}, Attachments: []*ExportCheck{}, Errors: []string{}}
+
+	if checksum, err := os.ReadFile(path + ".sha256"); err == nil {
+		expected := ""
+		if fields := strings.Fields(string(checksum)); len(fields) > 0 {
+			expected = strings.ToLower(fields[0])
+		}
+		actual := sha256Hex(data)
+		report.File = &ExportCheck{Name: filepath.Base(path), Expected: expected, Actual: actual, Valid: expected == actual}
+		if !report.File.Valid {
+			report.Errors = append(report.Errors, "导出文件与校验文件中的哈希不一致")
+		}
+	}
+
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("failed to parse export: %w", err)
+	}
+	var manifest ExportManifest
+	if raw, ok := sections[exportManifestKey]; !ok || json.Unmarshal(raw, &manifest) != nil || manifest.Sections == nil {
+		report.Errors = append(report.Errors, "导出文件不包含校验清单")
+		return report, nil
+	}
+	report.SealedAt = &manifest.SealedAt
+	report.Signer = manifest.Signer
+	if err := verifyExportManifest(&manifest); err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	} else {
+		report.SignatureValid = true
+	}
+
+	actual, err := buildExportManifest(sections)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(manifest.Sections))
+	for name := range manifest.Sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		check := &ExportCheck{Name: name, Expected: manifest.Sections[name], Actual: actual.Sections[name]}
+		check.Valid = check.Expected == check.Actual
+		report.Sections = append(report.Sections, check)
+		if !check.Valid {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s 已被修改", name))
+		}
+	}
+	for name := range sections {
+		if _, ok := manifest.Sections[name]; !ok && name != exportManifestKey {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s 不在校验清单中", name))
+		}
+	}
+
+	// 附件按所在字段、漏洞和文件名对应
+	key := func(digest *AttachmentDigest) string {
+		return digest.Section + "\x00" + digest.DedupKey + "\x00" + digest.Name
+	}
+	present := make(map[string]*AttachmentDigest)
+	for _, digest := range actual.Attachments {
+		present[key(digest)] = digest
+	}
+	for _, expected := range manifest.Attachments {
+		check := &ExportCheck{Name: expected.Name, Expected: expected.SHA256}
+		if digest, ok := present[key(expected)]; ok {
+			check.Actual = digest.SHA256
+			delete(present, key(expected))
+		}
+		check.Valid = check.Expected == check.Actual
+		report.Attachments = append(report.Attachments, check)
+		if !check.Valid {
+			report.Errors = append(report.Errors, fmt.Sprintf("附件 %s 已被修改或删除", expected.Name))
+		}
+	}
+	for _, digest := range present {
+		report.Errors = append(report.Errors, fmt.Sprintf("附件 %s 不在校验清单中", digest.Name))
+	}
+
+	report.Valid = len(report.Errors) == 0
+	return report, nil
+}
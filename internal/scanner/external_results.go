@@ -0,0 +1,284 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// Scanner output formats accepted by ImportExternalResults
+const (
+	ResultFormatNuclei = "nuclei" // nuclei -jsonl
+	ResultFormatXray   = "xray"   // xray --json-output
+	ResultFormatAfrog  = "afrog"  // afrog -json
+)
+
+// xrayFinding is one finding of xray's JSON output
+type xrayFinding struct {
+	CreateTime int64  `json:"create_time"` // 毫秒时间戳
+	Plugin     string `json:"plugin"`      // poc-yaml-xxx 或 sqldet/... 等内置插件
+	Target     struct {
+		URL string `json:"url"`
+	} `json:"target"`
+	Detail struct {
+		Addr     string                 `json:"addr"`
+		Payload  string                 `json:"payload"`
+		Snapshot [][]string             `json:"snapshot"` // [请求, 响应] 对
+		Extra    map[string]interface{} `json:"extra"`
+	} `json:"detail"`
+}
+
+// afrogFinding is one finding of afrog's JSON output
+type afrogFinding struct {
+	IsVul      bool   `json:"isvul"`
+	Target     string `json:"target"`
+	FullTarget string `json:"fulltarget"`
+	PocInfo    struct {
+		ID          string   `json:"id"`
+		Name        string   `json:"infoname"`
+		Author      string   `json:"infoauthor"`
+		Severity    string   `json:"infoseg"`
+		Description string   `json:"infodescription"`
+		Reference   []string `json:"inforeference"`
+		Tags        string   `json:"infotags"`
+	} `json:"pocinfo"`
+	ResultList []struct {
+		Request    string `json:"request"`
+		Response   string `json:"response"`
+		FullTarget string `json:"fulltarget"`
+	} `json:"resultlist"`
+}
+
+// xrayPluginSeverity maps xray's built-in plugins (the part of the plugin name before the
+// first "/") to a severity; PoC plugins without a level in their output are rated high
+var xrayPluginSeverity = map[string]string{
+	"sqldet":           "critical",
+	"cmd-injection":    "critical",
+	"xxe":              "high",
+	"path-traversal":   "high",
+	"upload":           "high",
+	"ssrf":             "high",
+	"phantasm":         "high",
+	"struts":           "critical",
+	"thinkphp":         "critical",
+	"fastjson":         "critical",
+	"shiro":            "critical",
+	"brute-force":      "high",
+	"jsonp":            "medium",
+	"xss":              "medium",
+	"redirect":         "medium",
+	"crlf-injection":   "medium",
+	"baseline":         "low",
+	"dirscan":          "info",
+	"cors":             "low",
+	"sensitive-header": "info",
+}
+
+// normalizeImportedSeverity maps a severity of another scanner to nuclei's levels
+func normalizeImportedSeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical", "严重":
+		return "critical"
+	case "high", "高危":
+		return "high"
+	case "medium", "middle", "中危":
+		return "medium"
+	case "low", "低危":
+		return "low"
+	case "info", "information", "informational", "信息":
+		return "info"
+	default:
+		return "unknown"
+	}
+}
+
+// readJSONRecords decodes a JSON array, or a file with one JSON object per line, into records
+func readJSONRecords(path string, newRecord func() interface{}) ([]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+
+	var raws []json.RawMessage
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &raws); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	} else {
+		// 也支持逐行输出（jsonl）
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				raws = append(raws, line)
+			}
+		}
+	}
+
+	records := make([]interface{}, 0, len(raws))
+	for _, raw := range raws {
+		record := newRecord()
+		if err := json.Unmarshal(raw, record); err != nil {
+			// Skip invalid records
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// parseXrayResults reads the findings of an xray JSON output file
+func parseXrayResults(path string) ([]*models.NucleiResult, error) {
+	records, err := readJSONRecords(path, func() interface{} { return &xrayFinding{} })
+	if err != nil {
+		return nil, err
+	}
+
+	var vulns []*models.NucleiResult
+	for _, record := range records {
+		finding := record.(*xrayFinding)
+		matchedAt := finding.Detail.Addr
+		if matchedAt == "" {
+			matchedAt = finding.Target.URL
+		}
+		if finding.Plugin == "" || matchedAt == "" {
+			continue
+		}
+
+		severity := ""
+		if level, ok := finding.Detail.Extra["level"].(string); ok {
+			severity = normalizeImportedSeverity(level)
+		}
+		if severity == "" || severity == "unknown" {
+			category := strings.SplitN(finding.Plugin, "/", 2)[0]
+			if s, ok := xrayPluginSeverity[category]; ok {
+				severity = s
+			} else if strings.HasPrefix(finding.Plugin, "poc-yaml-") {
+				severity = "high"
+			} else {
+				severity = "unknown"
+			}
+		}
+
+		vuln := &models.NucleiResult{
+			TemplateID: finding.Plugin,
+			Info: models.NucleiInfo{
+				Name:     strings.TrimPrefix(finding.Plugin, "poc-yaml-"),
+				Author:   []string{},
+				Tags:     []string{ResultFormatXray},
+				Severity: severity,
+			},
+			Type:      "http",
+			Host:      finding.Target.URL,
+			MatchedAt: matchedAt,
+			Metadata:  map[string]interface{}{"source": ResultFormatXray},
+		}
+		if finding.CreateTime > 0 {
+			vuln.Timestamp = time.UnixMilli(finding.CreateTime)
+		}
+		if finding.Detail.Payload != "" {
+			vuln.ExtractedResults = []string{finding.Detail.Payload}
+		}
+		if len(finding.Detail.Snapshot) > 0 && len(finding.Detail.Snapshot[0]) >= 2 {
+			vuln.Request = finding.Detail.Snapshot[0][0]
+			vuln.Response = finding.Detail.Snapshot[0][1]
+		}
+		if len(finding.Detail.Extra) > 0 {
+			vuln.Metadata["extra"] = finding.Detail.Extra
+		}
+		vulns = append(vulns, vuln)
+	}
+	return vulns, nil
+}
+
+// parseAfrogResults reads the findings of an afrog JSON output file
+func parseAfrogResults(path string) ([]*models.NucleiResult, error) {
+	records, err := readJSONRecords(path, func() interface{} { return &afrogFinding{} })
+	if err != nil {
+		return nil, err
+	}
+
+	var vulns []*models.NucleiResult
+	for _, record := range records {
+		finding := record.(*afrogFinding)
+		matchedAt := finding.FullTarget
+		if matchedAt == "" {
+			matchedAt = finding.Target
+		}
+		if !finding.IsVul || finding.PocInfo.ID == "" || matchedAt == "" {
+			continue
+		}
+
+		info := models.NucleiInfo{
+			Name:        finding.PocInfo.Name,
+			Author:      []string{},
+			Tags:        []string{ResultFormatAfrog},
+			Description: finding.PocInfo.Description,
+			Severity:    normalizeImportedSeverity(finding.PocInfo.Severity),
+		}
+		if finding.PocInfo.Author != "" {
+			info.Author = strings.Split(finding.PocInfo.Author, ",")
+		}
+		for _, tag := range strings.Split(finding.PocInfo.Tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				info.Tags = append(info.Tags, tag)
+			}
+		}
+		if len(finding.PocInfo.Reference) > 0 {
+			info.Reference = finding.PocInfo.Reference
+		}
+
+		vuln := &models.NucleiResult{
+			TemplateID: finding.PocInfo.ID,
+			Info:       info,
+			Type:       "http",
+			Host:       finding.Target,
+			MatchedAt:  matchedAt,
+			Metadata:   map[string]interface{}{"source": ResultFormatAfrog},
+		}
+		// 多步PoC保留最后一步（命中）的请求和响应
+		if steps := finding.ResultList; len(steps) > 0 {
+			last := steps[len(steps)-1]
+			vuln.Request = last.Request
+			vuln.Response = last.Response
+			if last.FullTarget != "" {
+				vuln.MatchedAt = last.FullTarget
+			}
+		}
+		vulns = append(vulns, vuln)
+	}
+	return vulns, nil
+}
+
+// ImportExternalResults creates a completed task from the output of nuclei, xray or afrog
+// (format is one of the ResultFormat constants), so assessments that mixed several tools
+// can be reported from one place
+func (tm *JSONTaskManager) ImportExternalResults(path, format, taskName string) (*TaskConfig, error) {
+	var vulns []*models.NucleiResult
+	var err error
+	switch format {
+	case ResultFormatNuclei, "":
+		return tm.ImportNucleiResults(path, taskName)
+	case ResultFormatXray:
+		vulns, err = parseXrayResults(path)
+	case ResultFormatAfrog:
+		vulns, err = parseAfrogResults(path)
+	default:
+		return nil, fmt.Errorf("不支持的结果格式: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s output: %w", format, err)
+	}
+	if len(vulns) == 0 {
+		return nil, fmt.Errorf("%s 中没有可导入的漏洞结果", filepath.Base(path))
+	}
+	if taskName == "" {
+		taskName = fmt.Sprintf("导入(%s)-%s", format, filepath.Base(path))
+	}
+	return tm.importFindings(path, taskName, vulns)
+}
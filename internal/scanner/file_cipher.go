@@ -0,0 +1,223 @@
+package scanner
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SetFileCipher sets the functions encrypting evidence files (finding attachments, task
+// archives and the replay, OOB and error files of a task) as soon as they are written and
+// reading them back; write encrypts data straight into a file and read must return
+// unencrypted files unchanged. With a nil seal and write new files are stored unencrypted.
+func (tm *JSONTaskManager) SetFileCipher(seal func(path string) error, write func(path string, data []byte) error, read func(path string) ([]byte, error)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.sealFile = seal
+	tm.writeSealedFile = write
+	tm.readSealedFile = read
+	tm.oob.SetFileCipher(write, read)
+}
+
+// SetRecordCipher sets the functions encrypting and decrypting the lines of the append-only
+// logs of a task (its event log and complete responses); open must return unencrypted lines
+// unchanged. With a nil seal new lines are written unencrypted.
+func (tm *JSONTaskManager) SetRecordCipher(seal, open func(string) (string, error)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.sealRecord = seal
+	tm.openRecord = open
+}
+
+// writeEvidenceFile writes an evidence file readable only by the current user, encrypted
+// when encryption is turned on
+func (tm *JSONTaskManager) writeEvidenceFile(path string, data []byte) error {
+	if tm.writeSealedFile != nil {
+		return tm.writeSealedFile(path, data)
+	}
+	return writePrivateFile(path, data)
+}
+
+// writePrivateFile writes a file with mode 0600, also restricting a file that already existed
+func writePrivateFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
+// sealLogRecord encrypts a line of an append-only task log when encryption is turned on
+func (tm *JSONTaskManager) sealLogRecord(line []byte) ([]byte, error) {
+	if tm.sealRecord == nil {
+		return line, nil
+	}
+	sealed, err := tm.sealRecord(string(line))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt log record: %w", err)
+	}
+	return []byte(sealed), nil
+}
+
+// openLogRecord decrypts a line of an append-only task log; a line that cannot be decrypted
+// is returned as it is and skipped by the caller as malformed
+func (tm *JSONTaskManager) openLogRecord(line []byte) []byte {
+	if tm.openRecord == nil {
+		return line
+	}
+	opened, err := tm.openRecord(string(bytes.TrimSpace(line)))
+	if err != nil {
+		return line
+	}
+	return []byte(opened)
+}
+
+// sealEvidenceFile encrypts a newly written evidence file when encryption is turned on
+func (tm *JSONTaskManager) sealEvidenceFile(path string) error {
+	if tm.sealFile == nil {
+		return nil
+	}
+	if err := tm.sealFile(path); err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", path, err)
+	}
+	return nil
+}
+
+// readEvidenceFile reads an evidence file, decrypting it if it was sealed
+func (tm *JSONTaskManager) readEvidenceFile(path string) ([]byte, error) {
+	if tm.readSealedFile == nil {
+		return os.ReadFile(path)
+	}
+	return tm.readSealedFile(path)
+}
+
+// openTaskArchive opens the zip archive of a task, decrypting it if it was sealed
+func (tm *JSONTaskManager) openTaskArchive(path string) (*zip.Reader, error) {
+	data, err := tm.readEvidenceFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// SealEvidenceFiles encrypts the evidence files written before encryption was turned on:
+// attachments, task archives, the replay, OOB and error files of tasks and the lines of the
+// event logs and complete responses of tasks that are not running. Files and lines that are
+// already encrypted are left as they are.
+func (tm *JSONTaskManager) SealEvidenceFiles() error {
+	if tm.sealFile == nil {
+		return nil
+	}
+	for _, dir := range []string{tm.attachmentsDir(), tm.archivesDir()} {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil || info.IsDir() || strings.HasSuffix(path, ".tmp") {
+				return err
+			}
+			return tm.sealEvidenceFile(path)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tm.sealTaskLogs()
+}
+
+// sealTaskLogs encrypts the evidence files of tasks in the logs directory
+func (tm *JSONTaskManager) sealTaskLogs() error {
+	entries, err := os.ReadDir(tm.logsDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read logs directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		taskID, ok := taskIDFromName(name)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(tm.logsDir, name)
+		// 运行中的任务仍在写入，等下次开启时再加密
+		_, running := tm.runningScanner(taskID)
+		switch {
+		case strings.HasSuffix(name, "_replays.json"):
+			tm.replayMu.Lock()
+			err = tm.sealEvidenceFile(path)
+			tm.replayMu.Unlock()
+		case strings.HasSuffix(name, "_oob.json"):
+			err = tm.oob.sealInteractions(path, tm.sealEvidenceFile)
+		case strings.HasSuffix(name, "_errors.json") && !running:
+			err = tm.sealEvidenceFile(path)
+		case (strings.HasSuffix(name, "_events.jsonl") || strings.HasSuffix(name, "_responses.jsonl")) && !running:
+			err = tm.sealLogRecords(path)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sealLogRecords encrypts the unencrypted lines of an append-only task log
+func (tm *JSONTaskManager) sealLogRecords(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer in.Close()
+
+	tmp := path + ".sealing"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", tmp, err)
+	}
+	writer := bufio.NewWriter(out)
+	// 完整响应可能超过行扫描器的长度上限，按行读取不截断
+	reader := bufio.NewReader(in)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			sealed, sealErr := tm.sealLogRecord(line)
+			if sealErr == nil {
+				_, sealErr = writer.Write(append(sealed, '\n'))
+			}
+			if sealErr != nil {
+				out.Close()
+				os.Remove(tmp)
+				return sealErr
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			out.Close()
+			os.Remove(tmp)
+			return fmt.Errorf("failed to read %s: %w", path, readErr)
+		}
+	}
+	err = writer.Flush()
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	in.Close()
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to encrypt %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wepoc/internal/i18n"
+	"wepoc/internal/models"
+)
+
+// Attachment kinds
+const (
+	AttachmentScreenshot = "screenshot" // 截图
+	AttachmentPacket     = "packet"     // 导出的数据包
+	AttachmentNote       = "note"       // 文字备注
+	AttachmentFile       = "file"       // 其他文件
+)
+
+// maxAttachmentSize bounds a single evidence file
+const maxAttachmentSize = 50 << 20
+
+// attachmentKinds maps file extensions to attachment kinds
+var attachmentKinds = map[string]string{
+	".png": AttachmentScreenshot, ".jpg": AttachmentScreenshot, ".jpeg": AttachmentScreenshot,
+	".gif": AttachmentScreenshot, ".webp": AttachmentScreenshot, ".bmp": AttachmentScreenshot,
+	".txt": AttachmentPacket, ".http": AttachmentPacket, ".req": AttachmentPacket, ".har": AttachmentPacket,
+	".pcap": AttachmentPacket, ".pcapng": AttachmentPacket, ".saz": AttachmentPacket, ".xml": AttachmentPacket,
+}
+
+// attachmentsDir is where evidence files of findings are stored
+func (tm *JSONTaskManager) attachmentsDir() string {
+	return filepath.Join(filepath.Dir(tm.tasksDir), "attachments")
+}
+
+// taskFinding returns a finding of a task by its index in the result
+func (tm *JSONTaskManager) taskFinding(taskID int64, findingIndex int) (*models.NucleiResult, error) {
+	result, err := tm.GetTaskResult(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if findingIndex < 0 || findingIndex >= len(result.Vulnerabilities) {
+		return nil, fmt.Errorf("finding %d not found in task %d", findingIndex, taskID)
+	}
+	return result.Vulnerabilities[findingIndex], nil
+}
+
+// AddFindingAttachment copies an evidence file into the attachments directory and attaches
+// it to a finding; with an empty sourcePath only the note is attached
+func (tm *JSONTaskManager) AddFindingAttachment(taskID int64, findingIndex int, sourcePath, note string) (*models.FindingAttachment, error) {
+	task, err := tm.GetTaskByID(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+	if err := task.checkUnlocked(); err != nil {
+		return nil, err
+	}
+	vuln, err := tm.taskFinding(taskID, findingIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &models.FindingAttachment{
+		DedupKey:  vuln.DedupKey,
+		TaskID:    taskID,
+		Kind:      AttachmentNote,
+		Name:      "note",
+		Note:      strings.TrimSpace(note),
+		CreatedAt: time.Now(),
+	}
+	if attachment.DedupKey == "" {
+		attachment.DedupKey = FindingDedupKey(vuln)
+	}
+	if err := tm.checkFindingUnlocked(attachment.DedupKey); err != nil {
+		return nil, err
+	}
+
+	if sourcePath == "" {
+		if attachment.Note == "" {
+			return nil, fmt.Errorf("附件文件和备注不能同时为空")
+		}
+	} else {
+		if err := tm.storeAttachmentFile(attachment, sourcePath); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tm.db.AddFindingAttachment(attachment); err != nil {
+		if attachment.FileName != "" {
+			os.Remove(filepath.Join(tm.attachmentsDir(), attachment.FileName))
+		}
+		return nil, err
+	}
+	logInfof("📎 任务 %d 漏洞 #%d 添加附件: %s (%s)\n", taskID, findingIndex, attachment.Name, attachment.Kind)
+	return attachment, nil
+}
+
+// storeAttachmentFile copies the source file into the attachments directory
+func (tm *JSONTaskManager) storeAttachmentFile(attachment *models.FindingAttachment, sourcePath string) error {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return fmt.Errorf("无法读取附件文件: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("附件必须是文件: %s", sourcePath)
+	}
+	if info.Size() > maxAttachmentSize {
+		return fmt.Errorf("附件文件过大: %d MB（最大 %d MB）", info.Size()>>20, maxAttachmentSize>>20)
+	}
+
+	attachment.Name = filepath.Base(sourcePath)
+	attachment.Size = info.Size()
+	attachment.Kind = AttachmentFile
+	if kind, ok := attachmentKinds[strings.ToLower(filepath.Ext(sourcePath))]; ok {
+		attachment.Kind = kind
+	}
+	// 按去重键分目录保存，文件名加时间戳避免重名
+	attachment.FileName = filepath.ToSlash(filepath.Join(attachment.DedupKey[:16],
+		fmt.Sprintf("%d_%s", attachment.CreatedAt.UnixNano(), attachment.Name)))
+
+	dst := filepath.Join(tm.attachmentsDir(), filepath.FromSlash(attachment.FileName))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create attachments directory: %w", err)
+	}
+	src, err := os.Open(sourcePath)
+	if err != nil {
+		return fmt.Errorf("无法读取附件文件: %w", err)
+	}
+	defer src.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("failed to copy attachment: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	if err := tm.sealEvidenceFile(dst); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+// GetFindingAttachments returns the attachments of a finding, including those added while
+// viewing earlier scans of the same finding
+func (tm *JSONTaskManager) GetFindingAttachments(taskID int64, findingIndex int) ([]*models.FindingAttachment, error) {
+	vuln, err := tm.taskFinding(taskID, findingIndex)
+	if err != nil {
+		return nil, err
+	}
+	key := vuln.DedupKey
+	if key == "" {
+		key = FindingDedupKey(vuln)
+	}
+	return tm.db.ListFindingAttachments(key)
+}
+
+// ReadFindingAttachment returns an attachment with the content of its file
+func (tm *JSONTaskManager) ReadFindingAttachment(id int64) (*models.FindingAttachment, error) {
+	attachment, err := tm.db.GetFindingAttachment(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tm.loadAttachmentData(attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// loadAttachmentData reads the file of an attachment into Data
+func (tm *JSONTaskManager) loadAttachmentData(attachment *models.FindingAttachment) error {
+	if attachment.FileName == "" {
+		return nil
+	}
+	data, err := tm.readEvidenceFile(filepath.Join(tm.attachmentsDir(), filepath.FromSlash(attachment.FileName)))
+	if err != nil {
+		return fmt.Errorf("failed to read attachment %s: %w", attachment.Name, err)
+	}
+	attachment.Data = data
+	return nil
+}
+
+// DeleteFindingAttachment removes an attachment and its file; attachments of a finding
+// reported by a locked task are kept
+func (tm *JSONTaskManager) DeleteFindingAttachment(id int64) error {
+	attachment, err := tm.db.GetFindingAttachment(id)
+	if err != nil {
+		return err
+	}
+	if task, err := tm.GetTaskByID(attachment.TaskID); err == nil {
+		if err := task.checkUnlocked(); err != nil {
+			return err
+		}
+	}
+	if err := tm.checkFindingUnlocked(attachment.DedupKey); err != nil {
+		return err
+	}
+	if err := tm.db.DeleteFindingAttachment(id); err != nil {
+		return err
+	}
+	if attachment.FileName != "" {
+		if err := os.Remove(filepath.Join(tm.attachmentsDir(), filepath.FromSlash(attachment.FileName))); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete attachment file: %w", err)
+		}
+	}
+	return nil
+}
+
+// attachEvidence embeds the attachments (with file contents) into the findings of a result
+// for report exports
+func (tm *JSONTaskManager) attachEvidence(result *TaskResult) {
+	if len(result.Vulnerabilities) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(result.Vulnerabilities))
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.DedupKey == "" {
+			vuln.DedupKey = FindingDedupKey(vuln)
+		}
+		keys = append(keys, vuln.DedupKey)
+	}
+
+	attachments, err := tm.db.ListFindingAttachments(keys...)
+	if err != nil {
+		logWarnf("⚠️ 读取漏洞附件失败: %v\n", err)
+		return
+	}
+	byKey := make(map[string][]*models.FindingAttachment)
+	for _, attachment := range attachments {
+		if err := tm.loadAttachmentData(attachment); err != nil {
+			logWarnf("⚠️ %v\n", err)
+		}
+		byKey[attachment.DedupKey] = append(byKey[attachment.DedupKey], attachment)
+	}
+	for _, vuln := range result.Vulnerabilities {
+		vuln.Attachments = byKey[vuln.DedupKey]
+	}
+}
+
+// ExportTaskResult returns the result of a task for report exports, with the evidence
+// attached to its findings and the task's notes embedded
+func (tm *JSONTaskManager) ExportTaskResult(taskID int64) (*TaskResult, error) {
+	result, err := tm.GetTaskResult(taskID)
+	if err != nil {
+		return nil, err
+	}
+	tm.attachEvidence(result)
+	if task, err := tm.GetTaskByID(taskID); err == nil {
+		result.Notes = task.Notes
+	}
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"sort"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// FindingDedupKey identifies a finding by template ID, matched-at location and extracted
+// results, so the same issue found again (or emitted twice by nuclei) shares one key
+func FindingDedupKey(vuln *models.NucleiResult) string {
+	extracted := append([]string{}, vuln.ExtractedResults...)
+	sort.Strings(extracted)
+	parts := append([]string{vuln.TemplateID, vuln.MatchedAt}, extracted...)
+	return contentHash([]byte(strings.Join(parts, "\x00")))
+}
+
+// dedupFindings collapses duplicate findings of a scan, keeping the first occurrence and
+// recording the first/last time it was emitted and how many duplicates were merged
+func dedupFindings(vulns []*models.NucleiResult) []*models.NucleiResult {
+	unique := make([]*models.NucleiResult, 0, len(vulns))
+	byKey := make(map[string]*models.NucleiResult, len(vulns))
+	for _, vuln := range vulns {
+		key := FindingDedupKey(vuln)
+		seen := vuln.Timestamp
+		if existing, ok := byKey[key]; ok {
+			existing.Duplicates++
+			if seen.Before(*existing.FirstSeen) {
+				existing.FirstSeen = &seen
+			}
+			if seen.After(*existing.LastSeen) {
+				existing.LastSeen = &seen
+			}
+			continue
+		}
+		firstSeen, lastSeen := seen, seen
+		vuln.DedupKey = key
+		vuln.FirstSeen = &firstSeen
+		vuln.LastSeen = &lastSeen
+		byKey[key] = vuln
+		unique = append(unique, vuln)
+	}
+
+	if merged := len(vulns) - len(unique); merged > 0 {
+		logInfof("🧹 合并重复漏洞: %d 条\n", merged)
+	}
+	return unique
+}
+
+// recordFindings merges the findings of a result into the findings of all earlier scans and
+// stamps each finding with the first/last time it was seen in any scan
+func (tm *JSONTaskManager) recordFindings(result *TaskResult) {
+	if result.Status == "paused" || len(result.Vulnerabilities) == 0 {
+		return
+	}
+
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.DedupKey == "" {
+			vuln.DedupKey = FindingDedupKey(vuln)
+		}
+	}
+	records, err := tm.db.RecordFindings(result.TaskID, result.StartTime, result.Vulnerabilities)
+	if err != nil {
+		logWarnf("⚠️ 记录任务 %d 漏洞去重信息失败: %v\n", result.TaskID, err)
+		return
+	}
+	for _, vuln := range result.Vulnerabilities {
+		if record, ok := records[vuln.DedupKey]; ok {
+			firstSeen, lastSeen := record.FirstSeen, record.LastSeen
+			vuln.FirstSeen = &firstSeen
+			vuln.LastSeen = &lastSeen
+		}
+	}
+}
+
+// ListFindings returns the unique findings of all scans sorted by sortBy (first_seen,
+// last_seen, occurrences, severity or template_id; prefix "-" for descending)
+func (tm *JSONTaskManager) ListFindings(sortBy string) ([]*models.Finding, error) {
+	return tm.db.ListFindings(sortBy)
+}
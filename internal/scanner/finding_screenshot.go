@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+const (
+	// screenshotTimeout bounds capturing a single page
+	screenshotTimeout = 60 * time.Second
+	// maxScreenshotsPerScan bounds the screenshots taken after one scan
+	maxScreenshotsPerScan = 50
+	// screenshotNotePrefix marks screenshots taken automatically
+	screenshotNotePrefix = "自动截图: "
+)
+
+// CaptureScreenshot renders a URL with a headless Chrome/Chromium and writes a PNG to dst
+func CaptureScreenshot(ctx context.Context, browserPath, pageURL, proxyURL, dst string) error {
+	profileDir, err := os.MkdirTemp("", "wepoc-screenshot-*")
+	if err != nil {
+		return fmt.Errorf("failed to create browser profile: %w", err)
+	}
+	defer os.RemoveAll(profileDir)
+
+	args := []string{
+		"--headless=new",
+		"--disable-gpu",
+		"--hide-scrollbars",
+		"--ignore-certificate-errors",
+		"--no-first-run",
+		"--user-data-dir=" + profileDir,
+		"--window-size=1366,768",
+		"--screenshot=" + dst,
+	}
+	if os.Geteuid() == 0 {
+		// Chrome拒绝以root身份在沙箱中运行
+		args = append(args, "--no-sandbox")
+	}
+	if proxy := browserProxy(proxyURL); proxy != "" {
+		args = append(args, "--proxy-server="+proxy)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, screenshotTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, browserPath, append(args, pageURL)...)
+	hideWindowOnWindows(cmd)
+	output, err := cmd.CombinedOutput()
+	if _, statErr := os.Stat(dst); statErr == nil {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("浏览器截图失败: %v %s", err, lastLine(string(output)))
+	}
+	return fmt.Errorf("浏览器未生成截图")
+}
+
+// browserProxy converts a nuclei proxy URL to a --proxy-server value; Chrome does not accept
+// credentials in the proxy URL, so they are dropped
+func browserProxy(proxyURL string) string {
+	proxyURL = strings.TrimSpace(strings.Split(proxyURL, ",")[0])
+	if proxyURL == "" {
+		return ""
+	}
+	parsed, err := url.Parse(proxyURL)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// captureFindingScreenshots screenshots the matched-at URL of every HTTP finding of a result
+// that has no automatic screenshot yet and attaches it to the finding. Runs in the background
+// after the result is saved when enabled in the settings.
+func (tm *JSONTaskManager) captureFindingScreenshots(result *TaskResult) {
+	tm.mu.RLock()
+	var config models.HeadlessConfig
+	var proxyURL string
+	enabled := tm.config != nil && tm.config.Headless.FindingScreenshots
+	if enabled {
+		config = tm.config.Headless
+		if proxyArgs := NucleiProxyArgs(tm.config.NucleiConfig); len(proxyArgs) > 1 {
+			proxyURL = proxyArgs[1]
+		}
+	}
+	tm.mu.RUnlock()
+	if !enabled || result.Status == "paused" || len(result.Vulnerabilities) == 0 {
+		return
+	}
+
+	browser := DetectHeadlessBrowser(config)
+	if !browser.Available {
+		logWarnf("⚠️ 跳过漏洞截图: %s\n", browser.Message)
+		return
+	}
+
+	captured := 0
+	seen := make(map[string]bool)
+	for _, vuln := range result.Vulnerabilities {
+		if captured >= maxScreenshotsPerScan {
+			logWarnf("⚠️ 漏洞截图数量达到上限 (%d)，其余漏洞未截图\n", maxScreenshotsPerScan)
+			break
+		}
+		if vuln.DedupKey == "" || seen[vuln.DedupKey] {
+			continue
+		}
+		seen[vuln.DedupKey] = true
+		if !strings.HasPrefix(vuln.MatchedAt, "http://") && !strings.HasPrefix(vuln.MatchedAt, "https://") {
+			continue
+		}
+		if tm.hasAutoScreenshot(vuln.DedupKey) {
+			continue
+		}
+
+		if err := tm.attachScreenshot(result.TaskID, vuln, browser.Path, proxyURL); err != nil {
+			logWarnf("⚠️ 漏洞截图失败: %s: %v\n", vuln.MatchedAt, err)
+			continue
+		}
+		captured++
+	}
+	if captured > 0 {
+		logInfof("📸 任务 %d 已为 %d 个漏洞截图\n", result.TaskID, captured)
+	}
+}
+
+// hasAutoScreenshot reports whether a finding already has an automatic screenshot
+func (tm *JSONTaskManager) hasAutoScreenshot(dedupKey string) bool {
+	attachments, err := tm.db.ListFindingAttachments(dedupKey)
+	if err != nil {
+		return false
+	}
+	for _, attachment := range attachments {
+		if attachment.Kind == AttachmentScreenshot && strings.HasPrefix(attachment.Note, screenshotNotePrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// attachScreenshot captures the matched-at URL of a finding and stores it as an attachment
+func (tm *JSONTaskManager) attachScreenshot(taskID int64, vuln *models.NucleiResult, browserPath, proxyURL string) error {
+	if err := tm.checkFindingUnlocked(vuln.DedupKey); err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp("", "wepoc-screenshot-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tmpFile := filepath.Join(tmpDir, fmt.Sprintf("screenshot_%s.png", strings.ReplaceAll(targetHost(vuln.MatchedAt), ":", "_")))
+	if err := CaptureScreenshot(context.Background(), browserPath, vuln.MatchedAt, proxyURL, tmpFile); err != nil {
+		return err
+	}
+
+	attachment := &models.FindingAttachment{
+		DedupKey:  vuln.DedupKey,
+		TaskID:    taskID,
+		Note:      screenshotNotePrefix + vuln.MatchedAt,
+		CreatedAt: time.Now(),
+	}
+	if err := tm.storeAttachmentFile(attachment, tmpFile); err != nil {
+		return err
+	}
+	if err := tm.db.AddFindingAttachment(attachment); err != nil {
+		os.Remove(filepath.Join(tm.attachmentsDir(), filepath.FromSlash(attachment.FileName)))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"wepoc/internal/database"
+	"wepoc/internal/models"
+)
+
+// OutcomeError marks a verification whose nuclei run failed
+const OutcomeError = "error"
+
+// findingVerifyTimeout bounds a single verification run
+const findingVerifyTimeout = 5 * time.Minute
+
+// VerifyFinding re-runs only the template of a finding against its matched-at URL and
+// records the outcome and time on the finding, answering "is it still vulnerable?"
+func (tm *JSONTaskManager) VerifyFinding(ctx context.Context, taskID int64, findingIndex int) (*models.FindingVerification, error) {
+	result, err := tm.GetTaskResult(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if findingIndex < 0 || findingIndex >= len(result.Vulnerabilities) {
+		return nil, fmt.Errorf("finding %d not found in task %d", findingIndex, taskID)
+	}
+
+	vuln := result.Vulnerabilities[findingIndex]
+	target := strings.TrimSpace(vuln.MatchedAt)
+	if target == "" {
+		target = strings.TrimSpace(vuln.Host)
+	}
+	if target == "" {
+		return nil, fmt.Errorf("漏洞缺少命中地址，无法复核")
+	}
+
+	templatePath, err := tm.findingTemplatePath(vuln)
+	if err != nil {
+		return nil, err
+	}
+
+	logInfof("🔁 复核漏洞: 任务 %d #%d %s -> %s\n", taskID, findingIndex, vuln.TemplateID, target)
+	task, _ := tm.GetTaskByID(taskID)
+	var options TaskOptions
+	if task != nil {
+		options = task.Options
+	}
+	verification := tm.runVerification(ctx, vuln, templatePath, target, options, tm.tlsOptionsForTask(task))
+	if err := tm.saveFindingVerification(taskID, findingIndex, vuln, verification); err != nil {
+		return nil, err
+	}
+	return verification, nil
+}
+
+// findingTemplatePath locates the template of a finding, preferring the current copy in the
+// template library over the path nuclei reported (which may have been a temporary snapshot)
+func (tm *JSONTaskManager) findingTemplatePath(vuln *models.NucleiResult) (string, error) {
+	if vuln.TemplateID != "" {
+		if template, err := tm.db.GetTemplateByTemplateID(vuln.TemplateID); err == nil && template.FilePath != "" {
+			if _, err := os.Stat(template.FilePath); err == nil {
+				return template.FilePath, nil
+			}
+		}
+	}
+	if vuln.TemplatePath != "" {
+		for _, path := range []string{vuln.TemplatePath, resolveTemplatePath(vuln.TemplatePath)} {
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("模板 %s 不存在，无法复核", vuln.TemplateID)
+}
+
+// runVerification runs nuclei with the single template against the target and checks whether
+// the same template (and matcher, if the finding had one) fires again; out-of-band templates
+// use the same Interactsh settings as the task's scan
+func (tm *JSONTaskManager) runVerification(ctx context.Context, vuln *models.NucleiResult, templatePath, target string, options TaskOptions, tlsOptions TLSOptions) *models.FindingVerification {
+	verification := &models.FindingVerification{VerifiedAt: time.Now()}
+
+	tm.mu.RLock()
+	nucleiPath := "nuclei"
+	var args []string
+	if tm.config != nil {
+		if tm.config.NucleiPath != "" {
+			nucleiPath = tm.config.NucleiPath
+		}
+		if tm.config.Timeout > 0 {
+			args = append(args, "-timeout", fmt.Sprintf("%d", tm.config.Timeout))
+		}
+		args = append(args, NucleiProxyArgs(tm.config.NucleiConfig)...)
+		args = append(args, interactshArgs(tm.config, options)...)
+	}
+	tm.mu.RUnlock()
+	args = append(args, tlsOptions.NucleiArgs()...)
+	args = append([]string{"-t", templatePath, "-u", target, "-jsonl", "-silent", "-nc", "-disable-update-check"}, args...)
+
+	ctx, cancel := context.WithTimeout(ctx, findingVerifyTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, nucleiPath, args...)
+	hideWindowOnWindows(cmd)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		verification.Status = OutcomeError
+		verification.Error = fmt.Sprintf("无法启动Nuclei: %v", err)
+		return verification
+	}
+	untrack := TrackProcess(cmd)
+	runErr := cmd.Wait()
+	untrack()
+	verification.Duration = time.Since(start).Milliseconds()
+
+	if matchesFinding(vuln, stdout.Bytes()) {
+		verification.Status = OutcomeVulnerable
+		verification.StillVulnerable = true
+		return verification
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		verification.Status = OutcomeError
+		verification.Error = fmt.Sprintf("复核超时（%s）", findingVerifyTimeout)
+	case runErr != nil:
+		verification.Status = OutcomeError
+		verification.Error = strings.TrimSpace(fmt.Sprintf("%v %s", runErr, lastLine(stderr.String())))
+	default:
+		verification.Status = OutcomeNotVulnerable
+	}
+	return verification
+}
+
+// matchesFinding reports whether the JSONL output contains a result of the finding's template
+func matchesFinding(vuln *models.NucleiResult, output []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var result models.NucleiResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			continue
+		}
+		if vuln.TemplateID != "" && result.TemplateID != vuln.TemplateID {
+			continue
+		}
+		if vuln.MatcherName != "" && result.MatcherName != vuln.MatcherName {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// lastLine returns the last non-empty line of a command's output
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// saveFindingVerification stores the verification on the finding in the task result; the
+// result is reloaded so that changes made while nuclei was running are kept
+func (tm *JSONTaskManager) saveFindingVerification(taskID int64, findingIndex int, vuln *models.NucleiResult, verification *models.FindingVerification) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if task, err := tm.loadTaskConfig(taskID); err == nil {
+		if err := task.checkUnlocked(); err != nil {
+			return err
+		}
+	}
+
+	data, err := tm.db.GetTaskResult(taskID)
+	if errors.Is(err, database.ErrNotFound) {
+		return fmt.Errorf("已归档任务的结果无法更新，请先恢复任务")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load result: %w", err)
+	}
+
+	var result TaskResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	if findingIndex >= len(result.Vulnerabilities) ||
+		result.Vulnerabilities[findingIndex].TemplateID != vuln.TemplateID ||
+		result.Vulnerabilities[findingIndex].MatchedAt != vuln.MatchedAt {
+		return fmt.Errorf("finding %d of task %d changed during verification", findingIndex, taskID)
+	}
+
+	result.Vulnerabilities[findingIndex].Verification = verification
+	if err := tm.saveTaskResult(&result); err != nil {
+		return fmt.Errorf("failed to save verification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package scanner
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// SetFollowUpHandler sets the callback invoked for every follow-up task created from findings
+func (tm *JSONTaskManager) SetFollowUpHandler(handler func(*TaskConfig)) {
+	tm.handlersMu.Lock()
+	defer tm.handlersMu.Unlock()
+	tm.followUpHandler = handler
+}
+
+// followUpTriggered reports whether a finding matches a trigger: a template ID, optionally
+// followed by ":matcher-name", with * wildcards
+func followUpTriggered(trigger string, vuln *models.NucleiResult) bool {
+	trigger = strings.TrimSpace(trigger)
+	if trigger == "" {
+		return false
+	}
+	templatePattern, matcherPattern, hasMatcher := strings.Cut(trigger, ":")
+	if ok, _ := path.Match(templatePattern, vuln.TemplateID); !ok {
+		return false
+	}
+	if !hasMatcher {
+		return true
+	}
+	ok, _ := path.Match(matcherPattern, vuln.MatcherName)
+	return ok
+}
+
+// followUpHosts returns the hosts on which one of the rule's triggers fired
+func followUpHosts(rule models.FollowUpRule, vulns []*models.NucleiResult) []string {
+	hosts := []string{}
+	for _, vuln := range vulns {
+		host := vuln.Host
+		if host == "" {
+			host = vuln.MatchedAt
+		}
+		if host == "" || containsString(hosts, host) {
+			continue
+		}
+		for _, trigger := range rule.Triggers {
+			if followUpTriggered(trigger, vuln) {
+				hosts = append(hosts, host)
+				break
+			}
+		}
+	}
+	return hosts
+}
+
+// followUpTemplates resolves the templates queued by a rule to POCs, leaving out the
+// templates the parent task already ran
+func (tm *JSONTaskManager) followUpTemplates(rule models.FollowUpRule, scanned []string) ([]string, error) {
+	var templates []*models.Template
+	for _, templateID := range rule.Templates {
+		if templateID = strings.TrimSpace(templateID); templateID == "" {
+			continue
+		}
+		template, err := tm.db.GetTemplateByTemplateID(templateID)
+		if err != nil {
+			logWarnf("⚠️ 跟进规则 %s 的模板 %s 不在模板库中\n", rule.Name, templateID)
+			continue
+		}
+		templates = append(templates, template)
+	}
+	for _, tag := range rule.Tags {
+		if tag = strings.TrimSpace(tag); tag == "" {
+			continue
+		}
+		tagged, err := tm.db.ListTemplates("name", models.TemplateFilter{Tags: []string{tag}})
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, tagged...)
+	}
+
+	done := make(map[string]bool, len(scanned))
+	for _, poc := range scanned {
+		done[resolveTemplatePath(poc)] = true
+	}
+	pocs := []string{}
+	for _, template := range templates {
+		if !done[template.FilePath] && !containsString(pocs, template.FilePath) {
+			pocs = append(pocs, template.FilePath)
+		}
+	}
+	return pocs, nil
+}
+
+// queueFollowUps creates and starts a follow-up task for every enabled rule that fired in
+// a completed scan, running the rule's templates against the hosts it fired on
+func (tm *JSONTaskManager) queueFollowUps(task *TaskConfig, result *TaskResult) {
+	if result.Status != "completed" || len(result.Vulnerabilities) == 0 || tm.config == nil {
+		return
+	}
+	config := tm.config.FollowUp
+	maxDepth := config.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = 1
+	}
+
+	for _, rule := range config.Rules {
+		if !rule.Enabled {
+			continue
+		}
+		hosts := followUpHosts(rule, result.Vulnerabilities)
+		if len(hosts) == 0 {
+			continue
+		}
+		if task.FollowUpDepth >= maxDepth {
+			logWarnf("⚠️ 任务 %d 已达到跟进链长度上限 %d，跳过跟进规则 %s\n", task.ID, maxDepth, rule.Name)
+			continue
+		}
+		pocs, err := tm.followUpTemplates(rule, task.POCs)
+		if err != nil {
+			logWarnf("⚠️ 解析跟进规则 %s 的模板失败: %v\n", rule.Name, err)
+			continue
+		}
+		if len(pocs) == 0 {
+			logWarnf("⚠️ 跟进规则 %s 没有可执行的新模板\n", rule.Name)
+			continue
+		}
+
+		followUp, err := tm.createTask(&TaskConfig{
+			Name:          fmt.Sprintf("%s → %s", task.Name, rule.Name),
+			POCs:          pocs,
+			Targets:       hosts,
+			Options:       task.Options, // 沿用父任务的请求头、速率、代理等参数
+			FollowUpOf:    task.ID,
+			FollowUpRule:  rule.Name,
+			FollowUpDepth: task.FollowUpDepth + 1,
+		})
+		if err != nil {
+			logWarnf("⚠️ 创建跟进任务失败: %v\n", err)
+			continue
+		}
+		logInfof("🔗 规则 %s 命中 %d 个目标，创建跟进任务 %d（%d 个模板）\n", rule.Name, len(hosts), followUp.ID, len(pocs))
+		if err := tm.StartTask(followUp.ID); err != nil {
+			logWarnf("⚠️ 启动跟进任务 %d 失败: %v\n", followUp.ID, err)
+		}
+
+		tm.handlersMu.RLock()
+		handler := tm.followUpHandler
+		tm.handlersMu.RUnlock()
+		if handler != nil {
+			handler(followUp)
+		}
+	}
+}
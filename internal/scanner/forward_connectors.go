@@ -0,0 +1,360 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// syslogFacilityLocal0 is the facility of the forwarded syslog messages
+const syslogFacilityLocal0 = 16
+
+// forwardHostname is reported as the origin host of forwarded events
+func forwardHostname() string {
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return "-"
+}
+
+// syslogSeverity maps a finding severity to a syslog severity
+func syslogSeverity(severity string) int {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return 2
+	case "high":
+		return 3
+	case "medium":
+		return 4
+	case "low":
+		return 5
+	}
+	return 6
+}
+
+// syslogConnector sends events as RFC 5424 messages with a JSON body; TCP/TLS messages use
+// octet-counting framing (RFC 6587)
+type syslogConnector struct {
+	network  string
+	address  string
+	appName  string
+	hostname string
+	mu       sync.Mutex
+	conn     net.Conn
+}
+
+func newSyslogConnector(config models.SyslogForwardConfig) (*syslogConnector, error) {
+	network := strings.ToLower(strings.TrimSpace(config.Protocol))
+	if network == "" {
+		network = "udp"
+	}
+	defaultPort := "514"
+	switch network {
+	case "udp", "tcp":
+	case "tls":
+		defaultPort = "6514"
+	default:
+		return nil, fmt.Errorf("不支持的syslog协议: %s", config.Protocol)
+	}
+
+	address := strings.TrimSpace(config.Address)
+	if address == "" {
+		return nil, fmt.Errorf("syslog地址不能为空")
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(strings.Trim(address, "[]"), defaultPort)
+	}
+	appName := strings.TrimSpace(config.AppName)
+	if appName == "" {
+		appName = "wepoc"
+	}
+	return &syslogConnector{network: network, address: address, appName: appName, hostname: forwardHostname()}, nil
+}
+
+func (c *syslogConnector) Name() string   { return "syslog" }
+func (c *syslogConnector) Target() string { return c.network + "://" + c.address }
+
+func (c *syslogConnector) Send(ctx context.Context, events []*ForwardEvent) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		conn, err := c.dial(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		c.conn = conn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	}
+
+	for _, event := range events {
+		message, err := c.format(event)
+		if err != nil {
+			return &permanentForwardError{err: err}
+		}
+		if c.network != "udp" {
+			message = fmt.Sprintf("%d %s", len(message), message)
+		}
+		if _, err := io.WriteString(c.conn, message); err != nil {
+			// 连接失效，下次投递时重连
+			c.conn.Close()
+			c.conn = nil
+			return fmt.Errorf("failed to write syslog message: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *syslogConnector) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{}
+	if c.network == "tls" {
+		host, _, _ := net.SplitHostPort(c.address)
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		return tlsDialer.DialContext(ctx, "tcp", c.address)
+	}
+	return dialer.DialContext(ctx, c.network, c.address)
+}
+
+// format renders an event as "<PRI>1 TIMESTAMP HOSTNAME APP-NAME - MSGID - JSON"
+func (c *syslogConnector) format(event *ForwardEvent) (string, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal event: %w", err)
+	}
+	priority := syslogFacilityLocal0*8 + syslogSeverity(event.Severity)
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - %s", priority,
+		event.Timestamp.Format(time.RFC3339Nano), c.hostname, c.appName, event.Type, body), nil
+}
+
+func (c *syslogConnector) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// newForwardHTTPClient creates the HTTP client of the Splunk/Elasticsearch connectors
+func newForwardHTTPClient(skipVerify bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: skipVerify}
+	return &http.Client{Transport: transport, Timeout: forwardSendTimeout}
+}
+
+// parseForwardURL validates the base URL of an HTTP connector
+func parseForwardURL(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("URL不能为空")
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("无效的URL: %s", raw)
+	}
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	return parsed, nil
+}
+
+// postForward posts a payload and returns the response body; 429 and 5xx responses and
+// network errors can be retried, other non-2xx responses are permanent errors
+func postForward(ctx context.Context, client *http.Client, endpoint, contentType, authorization string, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, &permanentForwardError{err: fmt.Errorf("failed to create request: %w", err)}
+	}
+	req.Header.Set("Content-Type", contentType)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return body, nil
+	}
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body[:min(len(body), 512)])))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, err
+	}
+	return nil, &permanentForwardError{err: err}
+}
+
+// splunkConnector sends events to a Splunk HTTP Event Collector
+type splunkConnector struct {
+	endpoint   string
+	token      string
+	index      string
+	sourceType string
+	hostname   string
+	client     *http.Client
+}
+
+func newSplunkConnector(config models.SplunkForwardConfig) (*splunkConnector, error) {
+	parsed, err := parseForwardURL(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(parsed.Path, "/services/collector") {
+		parsed.Path += "/services/collector/event"
+	}
+	if strings.TrimSpace(config.Token) == "" {
+		return nil, fmt.Errorf("Splunk HEC token不能为空")
+	}
+	return &splunkConnector{
+		endpoint:   parsed.String(),
+		token:      strings.TrimSpace(config.Token),
+		index:      strings.TrimSpace(config.Index),
+		sourceType: strings.TrimSpace(config.SourceType),
+		hostname:   forwardHostname(),
+		client:     newForwardHTTPClient(config.TLSSkipVerify),
+	}, nil
+}
+
+func (c *splunkConnector) Name() string   { return "splunk" }
+func (c *splunkConnector) Target() string { return c.endpoint }
+
+func (c *splunkConnector) Send(ctx context.Context, events []*ForwardEvent) error {
+	var payload bytes.Buffer
+	encoder := json.NewEncoder(&payload)
+	for _, event := range events {
+		sourceType := c.sourceType
+		if sourceType == "" {
+			sourceType = "wepoc:finding"
+			if event.Type == ForwardEventTaskSummary {
+				sourceType = "wepoc:task"
+			}
+		}
+		entry := map[string]interface{}{
+			"time":       float64(event.Timestamp.UnixMilli()) / 1000,
+			"host":       c.hostname,
+			"source":     "wepoc",
+			"sourcetype": sourceType,
+			"event":      event,
+		}
+		if c.index != "" {
+			entry["index"] = c.index
+		}
+		if err := encoder.Encode(entry); err != nil {
+			return &permanentForwardError{err: fmt.Errorf("failed to marshal event: %w", err)}
+		}
+	}
+	_, err := postForward(ctx, c.client, c.endpoint, "application/json", "Splunk "+c.token, payload.Bytes())
+	return err
+}
+
+func (c *splunkConnector) Close() {
+	c.client.CloseIdleConnections()
+}
+
+// elasticsearchConnector indexes events with the bulk API
+type elasticsearchConnector struct {
+	endpoint      string
+	index         string
+	authorization string
+	client        *http.Client
+}
+
+func newElasticsearchConnector(config models.ElasticsearchForwardConfig) (*elasticsearchConnector, error) {
+	parsed, err := parseForwardURL(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	parsed.Path += "/_bulk"
+
+	index := strings.TrimSpace(config.Index)
+	if index == "" {
+		index = "wepoc-findings"
+	}
+	if index != strings.ToLower(index) || strings.ContainsAny(index, ` "*\<|,>/?`) {
+		return nil, fmt.Errorf("无效的索引名称: %s", index)
+	}
+
+	authorization := ""
+	if apiKey := strings.TrimSpace(config.APIKey); apiKey != "" {
+		authorization = "ApiKey " + apiKey
+	} else if config.Username != "" {
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(config.Username, config.Password)
+		authorization = req.Header.Get("Authorization")
+	}
+	return &elasticsearchConnector{
+		endpoint:      parsed.String(),
+		index:         index,
+		authorization: authorization,
+		client:        newForwardHTTPClient(config.TLSSkipVerify),
+	}, nil
+}
+
+func (c *elasticsearchConnector) Name() string   { return "elasticsearch" }
+func (c *elasticsearchConnector) Target() string { return c.endpoint + " (" + c.index + ")" }
+
+func (c *elasticsearchConnector) Send(ctx context.Context, events []*ForwardEvent) error {
+	var payload bytes.Buffer
+	encoder := json.NewEncoder(&payload)
+	// create 同时适用于普通索引和数据流
+	action := map[string]interface{}{"create": map[string]string{"_index": c.index}}
+	for _, event := range events {
+		if err := encoder.Encode(action); err != nil {
+			return &permanentForwardError{err: err}
+		}
+		if err := encoder.Encode(event); err != nil {
+			return &permanentForwardError{err: fmt.Errorf("failed to marshal event: %w", err)}
+		}
+	}
+
+	body, err := postForward(ctx, c.client, c.endpoint, "application/x-ndjson", c.authorization, payload.Bytes())
+	if err != nil {
+		return err
+	}
+	var response struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || !response.Errors {
+		return nil
+	}
+	// 部分文档被拒绝：重试会重复写入已成功的文档，按永久错误处理
+	failed := 0
+	reason := ""
+	for _, item := range response.Items {
+		for _, result := range item {
+			if result.Status >= 300 {
+				failed++
+				if reason == "" {
+					reason = result.Error.Type + ": " + result.Error.Reason
+				}
+			}
+		}
+	}
+	return &permanentForwardError{err: fmt.Errorf("%d/%d documents rejected: %s", failed, len(events), reason)}
+}
+
+func (c *elasticsearchConnector) Close() {
+	c.client.CloseIdleConnections()
+}
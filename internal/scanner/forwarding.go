@@ -0,0 +1,520 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+const (
+	defaultForwardQueueSize  = 10000
+	defaultForwardMaxRetries = 5
+	forwardBatchSize         = 100              // 单次投递的最大事件数
+	forwardFlushInterval     = time.Second      // 未攒满一批时的最长等待时间
+	forwardSendTimeout       = 15 * time.Second // 单次投递超时
+	forwardMaxBackoff        = 30 * time.Second // 重试间隔上限
+	forwardMaxFieldBytes     = 4096             // 转发时请求/响应内容的截断长度
+)
+
+// Forward event types
+const (
+	ForwardEventFinding     = "finding"
+	ForwardEventTaskSummary = "task_summary"
+	// 其余类型为发布到消息总线的扫描事件（progress、vuln_found、completed...）
+)
+
+// ForwardEvent is a finding or task summary sent to the SIEM connectors, or a scan event
+// published to the event bus
+type ForwardEvent struct {
+	Type      string      `json:"type"` // finding / task_summary / 扫描事件类型
+	Timestamp time.Time   `json:"@timestamp"`
+	TaskID    int64       `json:"task_id"`
+	TaskName  string      `json:"task_name,omitempty"`
+	Severity  string      `json:"severity,omitempty"`
+	Seq       int64       `json:"seq,omitempty"` // 扫描事件在任务内的序号
+	Data      interface{} `json:"data"`          // nuclei JSON结果、任务摘要或扫描事件数据
+}
+
+// isSIEMEvent reports whether the event goes to the SIEM connectors rather than the event bus
+func (e *ForwardEvent) isSIEMEvent() bool {
+	return e.Type == ForwardEventFinding || e.Type == ForwardEventTaskSummary
+}
+
+// ForwarderStatus reports the delivery state of one connector
+type ForwarderStatus struct {
+	Connector   string     `json:"connector"` // syslog / splunk / elasticsearch / nats / kafka
+	Target      string     `json:"target"`    // 投递地址
+	Queued      int        `json:"queued"`    // 等待投递的事件数
+	Sent        int64      `json:"sent"`
+	Failed      int64      `json:"failed"`  // 重试耗尽后丢弃的事件数
+	Dropped     int64      `json:"dropped"` // 队列已满时丢弃的最旧事件数
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// ForwardTestResult is the result of sending a test event through a connector
+type ForwardTestResult struct {
+	Connector string `json:"connector"`
+	Target    string `json:"target"`
+	Success   bool   `json:"success"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// forwardConnector delivers batches of events to one SIEM
+type forwardConnector interface {
+	Name() string
+	Target() string
+	Send(ctx context.Context, events []*ForwardEvent) error
+	Close()
+}
+
+// permanentForwardError marks a delivery failure that retrying cannot fix (bad credentials,
+// rejected documents)
+type permanentForwardError struct {
+	err error
+}
+
+func (e *permanentForwardError) Error() string { return e.err.Error() }
+func (e *permanentForwardError) Unwrap() error { return e.err }
+
+// Forwarder fans findings out to the configured SIEM connectors and scan events out to the
+// event bus. Every connector has its own bounded queue and delivery goroutine, so a slow or
+// unreachable SIEM never blocks a scan: when a queue is full the oldest events are dropped
+// and counted.
+type Forwarder struct {
+	mu      sync.RWMutex
+	config  models.ForwardingConfig
+	workers []*forwardWorker
+	hasBus  bool // 是否配置了消息总线，未配置时跳过扫描事件的转换
+}
+
+// NewForwarder creates a forwarder without connectors
+func NewForwarder() *Forwarder {
+	return &Forwarder{}
+}
+
+// Configure replaces the connectors when the forwarding config changed; events still queued
+// for the old connectors are flushed in the background before they are closed
+func (f *Forwarder) Configure(config models.ForwardingConfig) {
+	f.mu.Lock()
+	if reflect.DeepEqual(config, f.config) && f.workers != nil {
+		f.mu.Unlock()
+		return
+	}
+	old := f.workers
+	f.config = config
+	f.workers = []*forwardWorker{}
+	f.hasBus = false
+
+	connectors, errs := newForwardConnectors(config)
+	for _, err := range errs {
+		logErrorf("❌ 结果转发配置无效: %v\n", err)
+	}
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultForwardQueueSize
+	}
+	maxRetries := config.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultForwardMaxRetries
+	}
+	for _, connector := range connectors {
+		worker := newForwardWorker(connector, queueSize, maxRetries, forwardFilter(config, connector.Name()))
+		f.workers = append(f.workers, worker)
+		f.hasBus = f.hasBus || !isSIEMConnector(connector.Name())
+		go worker.run()
+		logInfof("📤 结果转发已启用: %s -> %s\n", connector.Name(), connector.Target())
+	}
+	f.mu.Unlock()
+
+	// 旧连接器在后台投递完剩余事件，不阻塞配置保存
+	go func() {
+		for _, worker := range old {
+			worker.stopAndWait()
+		}
+	}()
+}
+
+// Publish queues an event for every connector accepting it, without blocking
+func (f *Forwarder) Publish(event *ForwardEvent) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, worker := range f.workers {
+		if worker.accepts(event) {
+			worker.enqueue(event)
+		}
+	}
+}
+
+// publishesScanEvents reports whether an event bus is configured
+func (f *Forwarder) publishesScanEvents() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.hasBus
+}
+
+// isSIEMConnector reports whether a connector receives findings rather than scan events
+func isSIEMConnector(name string) bool {
+	return name == "syslog" || name == "splunk" || name == "elasticsearch"
+}
+
+// forwardFilter returns the events a connector receives: findings (and task summaries when
+// enabled) for the SIEM connectors, the configured scan event types for the event bus
+func forwardFilter(config models.ForwardingConfig, name string) func(*ForwardEvent) bool {
+	if isSIEMConnector(name) {
+		return func(event *ForwardEvent) bool {
+			return event.Type == ForwardEventFinding || (event.Type == ForwardEventTaskSummary && config.TaskSummaries)
+		}
+	}
+	types := map[string]bool{}
+	for _, eventType := range config.EventBus.EventTypes {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			types[eventType] = true
+		}
+	}
+	return func(event *ForwardEvent) bool {
+		return !event.isSIEMEvent() && (len(types) == 0 || types[event.Type])
+	}
+}
+
+// Status returns the delivery state of the active connectors
+func (f *Forwarder) Status() []*ForwarderStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	statuses := []*ForwarderStatus{}
+	for _, worker := range f.workers {
+		statuses = append(statuses, worker.snapshot())
+	}
+	return statuses
+}
+
+// Close flushes the queued events and closes all connectors (called on shutdown)
+func (f *Forwarder) Close() {
+	f.mu.Lock()
+	workers := f.workers
+	f.workers = nil
+	f.config = models.ForwardingConfig{}
+	f.mu.Unlock()
+
+	for _, worker := range workers {
+		worker.stopAndWait()
+	}
+}
+
+// TestForwardConnector sends a test event through one connector of the given config
+// (syslog/splunk/elasticsearch/eventbus), whether or not it is enabled
+func TestForwardConnector(ctx context.Context, config models.ForwardingConfig, name string) *ForwardTestResult {
+	result := &ForwardTestResult{Connector: name}
+	if err := requireOnline(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	switch name {
+	case "syslog":
+		config.Syslog.Enabled = true
+	case "splunk":
+		config.Splunk.Enabled = true
+	case "elasticsearch":
+		config.Elasticsearch.Enabled = true
+	case "eventbus":
+		config.EventBus.Enabled = true
+	default:
+		result.Error = fmt.Sprintf("未知的转发类型: %s", name)
+		return result
+	}
+
+	connector, err := newForwardConnector(config, name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer connector.Close()
+	result.Target = connector.Target()
+
+	event := &ForwardEvent{
+		Type:      "test",
+		Timestamp: time.Now(),
+		Severity:  "info",
+		Data:      map[string]interface{}{"message": "wepoc forwarding test"},
+	}
+	ctx, cancel := context.WithTimeout(ctx, forwardSendTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := connector.Send(ctx, []*ForwardEvent{event}); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	result.Success = true
+	return result
+}
+
+// newForwardConnectors creates the enabled connectors of the config
+func newForwardConnectors(config models.ForwardingConfig) ([]forwardConnector, []error) {
+	var connectors []forwardConnector
+	var errs []error
+	enabled := map[string]bool{
+		"syslog":        config.Syslog.Enabled,
+		"splunk":        config.Splunk.Enabled,
+		"elasticsearch": config.Elasticsearch.Enabled,
+		"eventbus":      config.EventBus.Enabled,
+	}
+	for _, name := range []string{"syslog", "splunk", "elasticsearch", "eventbus"} {
+		if !enabled[name] {
+			continue
+		}
+		connector, err := newForwardConnector(config, name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		connectors = append(connectors, connector)
+	}
+	return connectors, errs
+}
+
+// newForwardConnector creates one connector by name
+func newForwardConnector(config models.ForwardingConfig, name string) (forwardConnector, error) {
+	switch name {
+	case "syslog":
+		return newSyslogConnector(config.Syslog)
+	case "splunk":
+		return newSplunkConnector(config.Splunk)
+	case "elasticsearch":
+		return newElasticsearchConnector(config.Elasticsearch)
+	case "eventbus":
+		return newEventBusConnector(config.EventBus)
+	}
+	return nil, fmt.Errorf("未知的转发类型: %s", name)
+}
+
+// forwardWorker owns the queue and delivery goroutine of one connector
+type forwardWorker struct {
+	connector  forwardConnector
+	queue      chan *ForwardEvent
+	maxRetries int
+	accepts    func(*ForwardEvent) bool
+	stop       chan struct{}
+	done       chan struct{}
+	mu         sync.Mutex
+	status     ForwarderStatus
+}
+
+func newForwardWorker(connector forwardConnector, queueSize, maxRetries int, accepts func(*ForwardEvent) bool) *forwardWorker {
+	return &forwardWorker{
+		connector:  connector,
+		queue:      make(chan *ForwardEvent, queueSize),
+		maxRetries: maxRetries,
+		accepts:    accepts,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		status:     ForwarderStatus{Connector: connector.Name(), Target: connector.Target()},
+	}
+}
+
+// enqueue adds an event, dropping the oldest queued event when the queue is full
+func (w *forwardWorker) enqueue(event *ForwardEvent) {
+	for {
+		select {
+		case w.queue <- event:
+			return
+		default:
+		}
+		select {
+		case <-w.queue:
+			w.mu.Lock()
+			w.status.Dropped++
+			w.mu.Unlock()
+		default:
+		}
+	}
+}
+
+// run batches queued events and delivers them until stopped
+func (w *forwardWorker) run() {
+	defer close(w.done)
+	defer w.connector.Close()
+
+	ticker := time.NewTicker(forwardFlushInterval)
+	defer ticker.Stop()
+	batch := make([]*ForwardEvent, 0, forwardBatchSize)
+	for {
+		select {
+		case event := <-w.queue:
+			batch = append(batch, event)
+			if len(batch) < forwardBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		case <-w.stop:
+			w.flush(batch)
+			return
+		}
+		w.deliver(batch, w.maxRetries)
+		batch = batch[:0]
+	}
+}
+
+// flush delivers the queued events once each before the worker stops; after a failed batch
+// the rest is dropped so that shutdown does not wait on an unreachable SIEM
+func (w *forwardWorker) flush(batch []*ForwardEvent) {
+	for {
+		select {
+		case event := <-w.queue:
+			batch = append(batch, event)
+			if len(batch) < forwardBatchSize {
+				continue
+			}
+		default:
+			if len(batch) > 0 {
+				w.deliver(batch, 1)
+			}
+			return
+		}
+		if !w.deliver(batch, 1) {
+			w.mu.Lock()
+			w.status.Failed += int64(len(w.queue))
+			w.mu.Unlock()
+			return
+		}
+		batch = batch[:0]
+	}
+}
+
+// deliver sends a batch, retrying with exponential backoff; new events keep queueing (and the
+// oldest get dropped) while a SIEM is unreachable
+func (w *forwardWorker) deliver(batch []*ForwardEvent, attempts int) bool {
+	backoff := time.Second
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), forwardSendTimeout)
+		err = w.connector.Send(ctx, batch)
+		cancel()
+		if err == nil {
+			now := time.Now()
+			w.mu.Lock()
+			w.status.Sent += int64(len(batch))
+			w.status.LastSentAt = &now
+			w.mu.Unlock()
+			return true
+		}
+
+		var permanent *permanentForwardError
+		if errors.As(err, &permanent) || attempt == attempts {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-w.stop:
+			attempt = attempts
+		}
+		backoff = min(backoff*2, forwardMaxBackoff)
+	}
+
+	now := time.Now()
+	w.mu.Lock()
+	w.status.Failed += int64(len(batch))
+	w.status.LastError = err.Error()
+	w.status.LastErrorAt = &now
+	w.mu.Unlock()
+	logWarnf("⚠️ 转发 %d 个事件到 %s 失败: %v\n", len(batch), w.connector.Name(), err)
+	return false
+}
+
+func (w *forwardWorker) snapshot() *ForwarderStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	status := w.status
+	status.Queued = len(w.queue)
+	return &status
+}
+
+func (w *forwardWorker) stopAndWait() {
+	close(w.stop)
+	<-w.done
+}
+
+// forwardFinding queues a nuclei result for the SIEM connectors
+func (tm *JSONTaskManager) forwardFinding(task *TaskConfig, result map[string]interface{}) {
+	data := make(map[string]interface{}, len(result))
+	for key, value := range result {
+		// 请求/响应内容可能很大，只保留开头部分
+		if text, ok := value.(string); ok && (key == "request" || key == "response") && len(text) > forwardMaxFieldBytes {
+			value = text[:forwardMaxFieldBytes] + "...[truncated]"
+		}
+		data[key] = value
+	}
+	severity := ""
+	if info, ok := result["info"].(map[string]interface{}); ok {
+		severity, _ = info["severity"].(string)
+	}
+	tm.forwarder.Publish(&ForwardEvent{
+		Type:      ForwardEventFinding,
+		Timestamp: time.Now(),
+		TaskID:    task.ID,
+		TaskName:  task.Name,
+		Severity:  severity,
+		Data:      data,
+	})
+}
+
+// forwardTaskSummary queues the summary of a finished task (when summaries are enabled)
+func (tm *JSONTaskManager) forwardTaskSummary(task *TaskConfig) {
+	data := map[string]interface{}{
+		"status":         task.Status,
+		"target_count":   len(task.Targets),
+		"template_count": len(task.POCs),
+		"found_vulns":    task.FoundVulns,
+		"start_time":     task.StartTime,
+	}
+	if task.EndTime != nil {
+		data["end_time"] = *task.EndTime
+		data["duration_seconds"] = int64(task.EndTime.Sub(task.StartTime).Seconds())
+	}
+	tm.forwarder.Publish(&ForwardEvent{
+		Type:      ForwardEventTaskSummary,
+		Timestamp: time.Now(),
+		TaskID:    task.ID,
+		TaskName:  task.Name,
+		Data:      data,
+	})
+}
+
+// publishScanEvent queues a scan event for the event bus
+func (tm *JSONTaskManager) publishScanEvent(event *ScanEvent) {
+	if !tm.forwarder.publishesScanEvents() {
+		return
+	}
+	timestamp := event.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	tm.forwarder.Publish(&ForwardEvent{
+		Type:      event.EventType,
+		Timestamp: timestamp,
+		TaskID:    event.TaskID,
+		Seq:       event.Seq,
+		Data:      event.Data,
+	})
+}
+
+// GetForwardingStatus returns the delivery state of the SIEM and event bus connectors
+func (tm *JSONTaskManager) GetForwardingStatus() []*ForwarderStatus {
+	return tm.forwarder.Status()
+}
+
+// CloseForwarding flushes queued events to the SIEM connectors (called on shutdown)
+func (tm *JSONTaskManager) CloseForwarding() {
+	tm.forwarder.Close()
+}
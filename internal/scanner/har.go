@@ -0,0 +1,267 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HAR 1.2 structures (only the fields wepoc can fill from captured traffic)
+type HARFile struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            int64       `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type HARTimings struct {
+	Send    int64 `json:"send"`
+	Wait    int64 `json:"wait"`
+	Receive int64 `json:"receive"`
+}
+
+// rawHTTPResponse is a loosely parsed raw response dump
+type rawHTTPResponse struct {
+	Proto      string
+	StatusCode int
+	StatusText string
+	Headers    []HTTPHeader
+	Body       string
+}
+
+// parseRawHTTPResponse parses a raw HTTP response as dumped by nuclei -debug
+func parseRawHTTPResponse(raw string) *rawHTTPResponse {
+	resp := &rawHTTPResponse{Proto: "HTTP/1.1"}
+	raw = strings.TrimLeft(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	if raw == "" {
+		return resp
+	}
+
+	head := raw
+	if idx := strings.Index(raw, "\n\n"); idx != -1 {
+		head = raw[:idx]
+		resp.Body = raw[idx+2:]
+	}
+
+	lines := strings.Split(head, "\n")
+	statusLine := strings.SplitN(lines[0], " ", 3)
+	if len(statusLine) >= 2 && strings.HasPrefix(statusLine[0], "HTTP/") {
+		resp.Proto = statusLine[0]
+		resp.StatusCode, _ = strconv.Atoi(statusLine[1])
+		if len(statusLine) == 3 {
+			resp.StatusText = statusLine[2]
+		}
+	}
+
+	for _, line := range lines[1:] {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		resp.Headers = append(resp.Headers, HTTPHeader{
+			Name:  strings.TrimSpace(parts[0]),
+			Value: strings.TrimSpace(parts[1]),
+		})
+	}
+
+	return resp
+}
+
+// toHARHeaders converts parsed headers and returns the approximate header block size
+func toHARHeaders(headers []HTTPHeader) ([]HARNameValue, int) {
+	result := make([]HARNameValue, 0, len(headers))
+	size := 0
+	for _, h := range headers {
+		result = append(result, HARNameValue{Name: h.Name, Value: h.Value})
+		size += len(h.Name) + len(h.Value) + 4
+	}
+	return result, size
+}
+
+// findHeader returns the first header value matching name (case-insensitive)
+func findHeader(headers []HTTPHeader, name string) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// parseCookieHeader splits a Cookie header into name/value pairs
+func parseCookieHeader(value string) []HARNameValue {
+	cookies := []HARNameValue{}
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) == 2 && kv[0] != "" {
+			cookies = append(cookies, HARNameValue{Name: kv[0], Value: kv[1]})
+		}
+	}
+	return cookies
+}
+
+// httpLogToHAREntry converts one captured request/response pair into a HAR entry
+func httpLogToHAREntry(log *HTTPRequestLog) (HAREntry, error) {
+	rawReq, err := ParseRawHTTPRequest(log.Request)
+	if err != nil {
+		return HAREntry{}, err
+	}
+
+	targetURL, err := rawReq.BuildURL(log.Target)
+	if err != nil {
+		targetURL = log.Target
+	}
+
+	reqHeaders, reqHeadersSize := toHARHeaders(rawReq.Headers)
+	harReq := HARRequest{
+		Method:      rawReq.Method,
+		URL:         targetURL,
+		HTTPVersion: rawReq.Proto,
+		Cookies:     parseCookieHeader(rawReq.GetHeader("Cookie")),
+		Headers:     reqHeaders,
+		QueryString: []HARNameValue{},
+		HeadersSize: reqHeadersSize,
+		BodySize:    len(rawReq.Body),
+	}
+	if parsed, err := url.Parse(targetURL); err == nil {
+		for name, values := range parsed.Query() {
+			for _, value := range values {
+				harReq.QueryString = append(harReq.QueryString, HARNameValue{Name: name, Value: value})
+			}
+		}
+	}
+	if rawReq.Body != "" {
+		harReq.PostData = &HARPostData{
+			MimeType: rawReq.GetHeader("Content-Type"),
+			Text:     rawReq.Body,
+		}
+	}
+
+	rawResp := parseRawHTTPResponse(log.Response)
+	status := rawResp.StatusCode
+	if status == 0 {
+		status = log.StatusCode
+	}
+	respHeaders, respHeadersSize := toHARHeaders(rawResp.Headers)
+	harResp := HARResponse{
+		Status:      status,
+		StatusText:  rawResp.StatusText,
+		HTTPVersion: rawResp.Proto,
+		Cookies:     []HARNameValue{},
+		Headers:     respHeaders,
+		Content: HARContent{
+			Size:     len(rawResp.Body),
+			MimeType: findHeader(rawResp.Headers, "Content-Type"),
+			Text:     rawResp.Body,
+		},
+		RedirectURL: findHeader(rawResp.Headers, "Location"),
+		HeadersSize: respHeadersSize,
+		BodySize:    len(rawResp.Body),
+	}
+
+	comment := log.TemplateID
+	if log.IsVulnFound {
+		comment += " [vuln]"
+	}
+
+	return HAREntry{
+		StartedDateTime: log.Timestamp.Format(time.RFC3339Nano),
+		Time:            log.Duration,
+		Request:         harReq,
+		Response:        harResp,
+		Timings:         HARTimings{Send: 0, Wait: log.Duration, Receive: 0},
+		Comment:         comment,
+	}, nil
+}
+
+// BuildTaskHAR converts the captured traffic of a task into HAR JSON
+func (tm *JSONTaskManager) BuildTaskHAR(taskID int64) ([]byte, error) {
+	logs, err := tm.GetHTTPRequestLogs(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	har := HARFile{
+		Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "wepoc", Version: "1.0.0"},
+			Entries: make([]HAREntry, 0, len(logs)),
+		},
+	}
+	for _, log := range logs {
+		entry, err := httpLogToHAREntry(log)
+		if err != nil {
+			// 跳过无法解析的请求（如非HTTP协议的日志）
+			continue
+		}
+		har.Log.Entries = append(har.Log.Entries, entry)
+	}
+
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal HAR: %w", err)
+	}
+	// 导出时屏蔽任务自定义请求头/Cookie中的敏感值
+	return []byte(MaskSecrets(string(data), tm.TaskSecretValues(taskID))), nil
+}
@@ -0,0 +1,307 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// defaultChromiumRevision is the Chromium snapshot downloaded as managed browser; it is the
+// revision go-rod (nuclei's browser driver) is tested against
+const defaultChromiumRevision = 1321438
+
+// chromiumSnapshotURL is where Chromium snapshot builds are published
+const chromiumSnapshotURL = "https://storage.googleapis.com/chromium-browser-snapshots"
+
+// Size limits of an extracted Chromium archive (its largest file is the browser binary)
+const (
+	maxChromiumEntrySize = 1 << 30
+	maxChromiumTotalSize = 4 << 30
+)
+
+// Browser sources reported by DetectHeadlessBrowser
+const (
+	BrowserSourceConfigured = "configured" // 设置中指定的浏览器
+	BrowserSourceManaged    = "managed"    // wepoc下载的Chromium
+	BrowserSourceSystem     = "system"     // 系统安装的Chrome/Chromium
+)
+
+// HeadlessBrowserStatus describes the browser headless templates will use
+type HeadlessBrowserStatus struct {
+	Available bool   `json:"available"`
+	Path      string `json:"path,omitempty"`
+	Source    string `json:"source,omitempty"`  // configured/managed/system
+	Version   string `json:"version,omitempty"` // 浏览器版本（Windows上无法获取）
+	Message   string `json:"message"`
+}
+
+// chromiumSnapshot returns the snapshot platform, archive name and executable (relative to
+// the extraction directory) for this OS; ok is false where no managed download is offered
+func chromiumSnapshot() (platform, archive, executable string, ok bool) {
+	switch runtime.GOOS + "/" + runtime.GOARCH {
+	case "linux/amd64":
+		return "Linux_x64", "chrome-linux.zip", filepath.Join("chrome-linux", "chrome"), true
+	case "windows/amd64":
+		return "Win_x64", "chrome-win.zip", filepath.Join("chrome-win", "chrome.exe"), true
+	case "windows/386":
+		return "Win", "chrome-win.zip", filepath.Join("chrome-win", "chrome.exe"), true
+	}
+	return "", "", "", false
+}
+
+// systemBrowserCandidates are the executables looked up for an installed browser; they
+// mirror the paths nuclei searches with -system-chrome
+func systemBrowserCandidates() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+			"/Applications/Microsoft Edge.app/Contents/MacOS/Microsoft Edge",
+		}
+	case "windows":
+		candidates := []string{"chrome", "msedge"}
+		for _, env := range []string{"LOCALAPPDATA", "PROGRAMFILES", "PROGRAMFILES(X86)"} {
+			if dir := os.Getenv(env); dir != "" {
+				candidates = append(candidates,
+					filepath.Join(dir, "Google", "Chrome", "Application", "chrome.exe"),
+					filepath.Join(dir, "Chromium", "Application", "chrome.exe"),
+					filepath.Join(dir, "Microsoft", "Edge", "Application", "msedge.exe"),
+				)
+			}
+		}
+		return candidates
+	default:
+		return []string{"chrome", "google-chrome", "google-chrome-stable", "chromium", "chromium-browser", "microsoft-edge", "/snap/bin/chromium"}
+	}
+}
+
+// headlessBrowserDir returns the directory of managed Chromium downloads (~/.wepoc/browser)
+func headlessBrowserDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".wepoc", "browser"), nil
+}
+
+// managedBrowserPath returns the executable of the managed Chromium of a revision
+func managedBrowserPath(revision int) (string, bool) {
+	_, _, executable, ok := chromiumSnapshot()
+	if !ok {
+		return "", false
+	}
+	dir, err := headlessBrowserDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(dir, fmt.Sprintf("chromium-%d", revision), executable), true
+}
+
+// chromiumRevision returns the configured snapshot revision or the default
+func chromiumRevision(config models.HeadlessConfig) int {
+	if config.ChromiumRevision > 0 {
+		return config.ChromiumRevision
+	}
+	return defaultChromiumRevision
+}
+
+// DetectHeadlessBrowser finds the browser for headless templates: the configured path, then
+// the managed Chromium, then an installed Chrome/Chromium/Edge
+func DetectHeadlessBrowser(config models.HeadlessConfig) *HeadlessBrowserStatus {
+	status := &HeadlessBrowserStatus{}
+	found := func(path, source string) *HeadlessBrowserStatus {
+		status.Available = true
+		status.Path = path
+		status.Source = source
+		status.Version = browserVersion(path)
+		status.Message = fmt.Sprintf("使用浏览器: %s", path)
+		return status
+	}
+
+	if path := strings.TrimSpace(config.BrowserPath); path != "" {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return found(path, BrowserSourceConfigured)
+		}
+		status.Message = fmt.Sprintf("设置中的浏览器不存在: %s", path)
+		return status
+	}
+	if path, ok := managedBrowserPath(chromiumRevision(config)); ok {
+		if _, err := os.Stat(path); err == nil {
+			return found(path, BrowserSourceManaged)
+		}
+	}
+	for _, candidate := range systemBrowserCandidates() {
+		if path, err := exec.LookPath(candidate); err == nil {
+			return found(path, BrowserSourceSystem)
+		}
+	}
+
+	if _, _, _, ok := chromiumSnapshot(); ok {
+		status.Message = "未检测到Chrome/Chromium，可在设置中下载Chromium"
+	} else {
+		status.Message = "未检测到Chrome/Chromium，请安装Google Chrome"
+	}
+	return status
+}
+
+// browserVersion returns the output of --version (Chrome on Windows doesn't print it)
+func browserVersion(path string) string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, "--version")
+	hideWindowOnWindows(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// DownloadHeadlessBrowser downloads the Chromium snapshot into ~/.wepoc/browser so that
+// headless templates run without a system browser
+func DownloadHeadlessBrowser(config models.HeadlessConfig, progress BulkProgressFunc) (*HeadlessBrowserStatus, error) {
+	if err := requireOnline(); err != nil {
+		return nil, err
+	}
+	platform, archive, _, ok := chromiumSnapshot()
+	if !ok {
+		return nil, fmt.Errorf("当前系统不支持自动下载Chromium，请安装Google Chrome")
+	}
+	revision := chromiumRevision(config)
+	executable, _ := managedBrowserPath(revision)
+	if _, err := os.Stat(executable); err == nil {
+		return DetectHeadlessBrowser(models.HeadlessConfig{ChromiumRevision: revision}), nil
+	}
+
+	baseDir, err := headlessBrowserDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create browser directory: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/%d/%s", chromiumSnapshotURL, platform, revision, archive)
+	logInfof("🌐 下载Chromium: %s\n", url)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("下载Chromium失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载Chromium失败: HTTP %d", resp.StatusCode)
+	}
+
+	file, err := os.CreateTemp(baseDir, "chromium_*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	total := int(resp.ContentLength >> 20)
+	reader := io.Reader(resp.Body)
+	if progress != nil {
+		reader = &progressReader{reader: resp.Body, report: func(read int64) {
+			progress(int(read>>20), total, fmt.Sprintf("下载Chromium %d MB", read>>20))
+		}}
+	}
+	_, err = io.Copy(file, reader)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("下载Chromium失败: %w", err)
+	}
+
+	if progress != nil {
+		progress(total, total, "解压Chromium...")
+	}
+	target := filepath.Join(baseDir, fmt.Sprintf("chromium-%d", revision))
+	os.RemoveAll(target)
+	if err := extractZip(file.Name(), target, maxChromiumEntrySize, maxChromiumTotalSize); err != nil {
+		os.RemoveAll(target)
+		return nil, fmt.Errorf("解压Chromium失败: %w", err)
+	}
+
+	status := DetectHeadlessBrowser(models.HeadlessConfig{ChromiumRevision: revision})
+	if !status.Available {
+		return nil, fmt.Errorf("Chromium解压后未找到可执行文件")
+	}
+	logInfof("✅ Chromium已下载: %s\n", status.Path)
+	return status, nil
+}
+
+// progressReader reports the bytes read at most once per megabyte
+type progressReader struct {
+	reader   io.Reader
+	read     int64
+	reported int64
+	report   func(read int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read-r.reported >= 1<<20 {
+		r.reported = r.read
+		r.report(r.read)
+	}
+	return n, err
+}
+
+// headlessArgs returns the nuclei arguments of a task with headless templates enabled. A
+// configured or managed browser is passed with -system-chrome by putting its directory on
+// the PATH of the nuclei process; without a browser nuclei downloads Chromium itself.
+func (sns *SimpleNucleiScanner) headlessArgs(config *models.Config) []string {
+	if !sns.task.Options.Headless {
+		return nil
+	}
+	args := []string{"-headless"}
+	var headless models.HeadlessConfig
+	if config != nil {
+		headless = config.Headless
+	}
+	if headless.PageTimeout > 0 {
+		args = append(args, "-page-timeout", fmt.Sprintf("%d", headless.PageTimeout))
+	}
+	if headless.ShowBrowser {
+		args = append(args, "-show-browser")
+	}
+
+	browser := DetectHeadlessBrowser(headless)
+	if !browser.Available {
+		message := browser.Message + "，nuclei将尝试自动下载Chromium"
+		logWarnf("⚠️ %s\n", message)
+		sns.addLog("WARN", "", "", message, "", "", false)
+		sns.errorStats.record("headless browser not available: " + browser.Message)
+		return args
+	}
+	args = append(args, "-system-chrome")
+	if browser.Source != BrowserSourceSystem {
+		sns.browserDir = filepath.Dir(browser.Path)
+	}
+	logInfof("🌐 启用headless模板，浏览器: %s (%s)\n", browser.Path, browser.Source)
+	return args
+}
+
+// withPathPrefix returns env with dir prepended to PATH
+func withPathPrefix(env []string, dir string) []string {
+	current := os.Getenv("PATH")
+	for _, entry := range env {
+		if key, value, ok := strings.Cut(entry, "="); ok && strings.EqualFold(key, "PATH") {
+			current = value // 后出现的同名变量生效
+		}
+	}
+	return append(env, "PATH="+dir+string(os.PathListSeparator)+current)
+}
@@ -0,0 +1,147 @@
+package scanner
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// [INF] Skipped example.com:443 from target list as found unresponsive 30 times
+	// （新版本nuclei: "... as found unresponsive permanently: <reason>"）
+	hostSkippedPattern = regexp.MustCompile(`Skipped\s+(\S+)\s+from target list as found unresponsive(?:\s+(\d+)\s+times)?`)
+	// [WRN] [template-id] Could not execute request for http://example.com: ...
+	hostErrorTargetPattern = regexp.MustCompile(`(?i)(?:for|on|to)\s+((?:https?|wss?|tcp)://[^\s:,]+(?::\d+)?|[a-z0-9][a-z0-9.\-]*:\d+)`)
+)
+
+// HostErrorSummary is the error breakdown of a single target
+type HostErrorSummary struct {
+	Target     string         `json:"target"`                // 目标 host[:port]
+	Errors     map[string]int `json:"errors"`                // 错误类别 -> 次数
+	Total      int            `json:"total"`                 // 错误总数
+	LastError  string         `json:"last_error"`            // 最近一条错误
+	Skipped    bool           `json:"skipped"`               // 是否被nuclei因错误过多跳过（-mhe）
+	SkipReason string         `json:"skip_reason,omitempty"` // 跳过原因（出现最多的错误类别）
+}
+
+// hostErrorTracker collects per-target errors from nuclei stderr
+type hostErrorTracker struct {
+	hosts map[string]*HostErrorSummary
+	mu    sync.Mutex
+}
+
+// newHostErrorTracker creates an empty tracker
+func newHostErrorTracker() *hostErrorTracker {
+	return &hostErrorTracker{hosts: make(map[string]*HostErrorSummary)}
+}
+
+// hostKey normalizes a target to host[:port]
+func hostKey(target string) string {
+	if strings.Contains(target, "://") {
+		if parsed, err := url.Parse(target); err == nil && parsed.Host != "" {
+			return parsed.Host
+		}
+	}
+	return strings.TrimSuffix(target, "/")
+}
+
+// host returns the summary for a target (caller holds mu)
+func (t *hostErrorTracker) host(target string) *HostErrorSummary {
+	key := hostKey(target)
+	summary, exists := t.hosts[key]
+	if !exists {
+		summary = &HostErrorSummary{Target: key, Errors: make(map[string]int)}
+		t.hosts[key] = summary
+	}
+	return summary
+}
+
+// observe inspects a stderr line; it returns the target when the line reports that
+// nuclei dropped the host from the scan
+func (t *hostErrorTracker) observe(line string) (string, bool) {
+	if matches := hostSkippedPattern.FindStringSubmatch(line); len(matches) == 3 {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		summary := t.host(matches[1])
+		summary.Skipped = true
+		summary.SkipReason = summary.dominantCategory()
+		if summary.SkipReason == "" {
+			summary.SkipReason = "unresponsive"
+		}
+		if count, err := strconv.Atoi(matches[2]); err == nil && count > summary.Total {
+			// 部分错误可能未输出到stderr，以nuclei统计的次数为准
+			summary.Errors["unrecorded"] += count - summary.Total
+			summary.Total = count
+		}
+		return summary.Target, true
+	}
+
+	if !(strings.Contains(line, "[WRN]") || strings.Contains(line, "[ERR]")) || !strings.Contains(line, "Could not") {
+		return "", false
+	}
+	matches := hostErrorTargetPattern.FindStringSubmatch(line)
+	if len(matches) < 2 {
+		return "", false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	summary := t.host(matches[1])
+	summary.Errors[ClassifyError(line)]++
+	summary.Total++
+	summary.LastError = line
+	return "", false
+}
+
+// dominantCategory returns the most frequent error category
+func (s *HostErrorSummary) dominantCategory() string {
+	best, bestCount := "", 0
+	for category, count := range s.Errors {
+		if count > bestCount || (count == bestCount && category < best) {
+			best, bestCount = category, count
+		}
+	}
+	return best
+}
+
+// summaries returns all targets with errors, skipped targets first, then by error count
+func (t *hostErrorTracker) summaries() []*HostErrorSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]*HostErrorSummary, 0, len(t.hosts))
+	for _, summary := range t.hosts {
+		copied := *summary
+		copied.Errors = make(map[string]int, len(summary.Errors))
+		for category, count := range summary.Errors {
+			copied.Errors[category] = count
+		}
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Skipped != result[j].Skipped {
+			return result[i].Skipped
+		}
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Target < result[j].Target
+	})
+	return result
+}
+
+// skippedTargets returns the targets nuclei dropped from the scan
+func (t *hostErrorTracker) skippedTargets() []string {
+	var skipped []string
+	for _, summary := range t.summaries() {
+		if summary.Skipped {
+			skipped = append(skipped, summary.Target)
+		}
+	}
+	return skipped
+}
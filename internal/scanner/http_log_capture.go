@@ -0,0 +1,221 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"wepoc/internal/models"
+)
+
+// defaultMaxResponseKB is the captured response size of HTTP logs when the config sets none
+const defaultMaxResponseKB = 256
+
+// binaryContentTypes are content type prefixes whose bodies are not kept in HTTP logs
+var binaryContentTypes = []string{
+	"image/", "audio/", "video/", "font/",
+	"application/octet-stream", "application/pdf", "application/zip", "application/gzip",
+	"application/x-gzip", "application/x-tar", "application/x-7z", "application/x-rar",
+	"application/java-archive", "application/x-msdownload", "application/vnd.ms-", "application/msword",
+	"application/vnd.openxmlformats", "application/wasm", "application/x-shockwave-flash",
+}
+
+// responseCapture caps the responses stored in the HTTP logs of a task and optionally
+// keeps the complete ones on disk
+type responseCapture struct {
+	maxBytes      int                          // 0 表示不限制
+	captureBinary bool                         // 保留二进制响应体
+	fullFile      string                       // 完整响应文件，为空表示不保存
+	seal          func([]byte) ([]byte, error) // 加密写入的完整响应行，见SetRecordCipher
+}
+
+// fullResponseRecord is a line of the complete responses file of a task
+type fullResponseRecord struct {
+	ID       int64  `json:"id"`
+	Response string `json:"response"`
+}
+
+// fullResponsesFileName returns the complete responses file of a task inside the logs directory
+func fullResponsesFileName(taskID int64) string {
+	return fmt.Sprintf("task_%d_responses.jsonl", taskID)
+}
+
+// newResponseCapture returns the response capture of a task under the HTTP log config
+func newResponseCapture(config *models.Config, logsDir string, taskID int64) *responseCapture {
+	capture := &responseCapture{maxBytes: defaultMaxResponseKB * 1024}
+	if config == nil {
+		return capture
+	}
+	switch settings := config.HTTPLogs; {
+	case settings.MaxResponseKB < 0:
+		capture.maxBytes = 0
+	case settings.MaxResponseKB > 0:
+		capture.maxBytes = settings.MaxResponseKB * 1024
+	}
+	capture.captureBinary = config.HTTPLogs.CaptureBinary
+	if config.HTTPLogs.KeepFullBodies && logsDir != "" {
+		capture.fullFile = filepath.Join(logsDir, fullResponsesFileName(taskID))
+	}
+	return capture
+}
+
+// capture returns the response to store in the HTTP log and fills in its size, content
+// type and whether it was truncated or its binary body omitted
+func (c *responseCapture) capture(log *HTTPRequestLog, response string) string {
+	log.ResponseSize = len(response)
+	headers, body := splitHTTPResponse(response)
+	log.ContentType = responseContentType(headers)
+
+	captured := response
+	if isBinaryBody(log.ContentType, body) {
+		log.BinaryResponse = true
+		if !c.captureBinary && body != "" {
+			captured = headers + fmt.Sprintf("[wepoc: 已省略二进制响应体 (%s, %s)]\n", displayContentType(log.ContentType), formatBytes(int64(len(body))))
+		}
+	}
+	if c.maxBytes > 0 && len(captured) > c.maxBytes {
+		log.ResponseTruncated = true
+		captured = truncateUTF8(captured, c.maxBytes) + fmt.Sprintf("\n[wepoc: 响应已截断，原始大小 %s，仅保留前 %s]\n", formatBytes(int64(len(response))), formatBytes(int64(c.maxBytes)))
+	}
+
+	if captured != response && c.fullFile != "" {
+		if err := c.saveFull(log.ID, response); err != nil {
+			logWarnf("⚠️ 保存完整响应失败: %v\n", err)
+		} else {
+			log.FullResponseSaved = true
+		}
+	}
+	return captured
+}
+
+// saveFull appends a complete response to the responses file of the task
+func (c *responseCapture) saveFull(id int64, response string) error {
+	data, err := json.Marshal(&fullResponseRecord{ID: id, Response: response})
+	if err != nil {
+		return err
+	}
+	if c.seal != nil {
+		if data, err = c.seal(data); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(c.fullFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	file.Chmod(0600)
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// splitHTTPResponse splits a dumped response into its status line and headers (including
+// the blank line ending them) and its body
+func splitHTTPResponse(response string) (string, string) {
+	for _, separator := range []string{"\r\n\r\n", "\n\n"} {
+		if i := strings.Index(response, separator); i >= 0 {
+			return response[:i+len(separator)], response[i+len(separator):]
+		}
+	}
+	return response, ""
+}
+
+// responseContentType returns the lowercased media type of the Content-Type header
+func responseContentType(headers string) string {
+	for _, line := range strings.Split(headers, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "Content-Type") {
+			continue
+		}
+		mediaType, _, _ := strings.Cut(value, ";")
+		return strings.ToLower(strings.TrimSpace(mediaType))
+	}
+	return ""
+}
+
+// isBinaryBody reports whether a body is binary by its content type, or for unknown types by
+// NUL bytes or invalid UTF-8 in its first bytes
+func isBinaryBody(contentType, body string) bool {
+	for _, prefix := range binaryContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	// 文本类型以声明为准
+	if strings.HasPrefix(contentType, "text/") || strings.Contains(contentType, "json") ||
+		strings.Contains(contentType, "xml") || strings.Contains(contentType, "javascript") {
+		return false
+	}
+	sample := body
+	if len(sample) > 1024 {
+		sample = truncateUTF8(sample, 1024)
+	}
+	return strings.IndexByte(sample, 0) >= 0 || !utf8.ValidString(sample)
+}
+
+// displayContentType returns the content type shown in the binary body placeholder
+func displayContentType(contentType string) string {
+	if contentType == "" {
+		return "unknown"
+	}
+	return contentType
+}
+
+// truncateUTF8 returns at most n bytes of s without splitting a multi-byte character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n
+	for cut > 0 && cut > n-utf8.UTFMax && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
+
+// GetHTTPLogFullResponse returns the complete response of a logged HTTP request whose
+// stored response was truncated or had its binary body omitted
+func (tm *JSONTaskManager) GetHTTPLogFullResponse(taskID, logID int64) (string, error) {
+	var reader io.Reader
+	file, err := os.Open(filepath.Join(tm.logsDir, fullResponsesFileName(taskID)))
+	switch {
+	case err == nil:
+		defer file.Close()
+		reader = file
+	case os.IsNotExist(err):
+		// 已归档任务从压缩包中读取
+		data, archiveErr := tm.readArchivedFile(taskID, "logs/"+fullResponsesFileName(taskID))
+		if archiveErr != nil {
+			return "", fmt.Errorf("任务 %d 没有保存完整响应", taskID)
+		}
+		reader = bytes.NewReader(data)
+	default:
+		return "", fmt.Errorf("failed to open full responses: %w", err)
+	}
+
+	// 完整响应可能超过行扫描器的长度上限，按行读取不截断
+	buffered := bufio.NewReader(reader)
+	for {
+		line, err := buffered.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var record fullResponseRecord
+			if json.Unmarshal(tm.openLogRecord(line), &record) == nil && record.ID == logID {
+				return record.Response, nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read full responses: %w", err)
+		}
+	}
+	return "", fmt.Errorf("请求 #%d 没有保存完整响应", logID)
+}
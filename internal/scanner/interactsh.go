@@ -0,0 +1,377 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultInteractshServer is used when no custom server is configured (same pool as nuclei)
+const DefaultInteractshServer = "oast.pro"
+
+const (
+	interactshCorrelationIDLength = 20
+	interactshNonceLength         = 13
+	interactshIDAlphabet          = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// interactshEvent is a single interaction as returned by the interactsh server
+type interactshEvent struct {
+	Protocol      string    `json:"protocol"`
+	UniqueID      string    `json:"unique-id"`
+	FullID        string    `json:"full-id"`
+	QType         string    `json:"q-type"`
+	RawRequest    string    `json:"raw-request"`
+	RawResponse   string    `json:"raw-response"`
+	SMTPFrom      string    `json:"smtp-from"`
+	RemoteAddress string    `json:"remote-address"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// interactshPollResponse is the body returned by /poll
+type interactshPollResponse struct {
+	Data    []string `json:"data"`
+	Extra   []string `json:"extra"`
+	AESKey  string   `json:"aes_key"`
+	TLDData []string `json:"tld_data"`
+}
+
+// InteractshClient is a minimal interactsh protocol client (register/poll/deregister)
+type InteractshClient struct {
+	serverURL     *url.URL
+	token         string
+	httpClient    *http.Client
+	privateKey    *rsa.PrivateKey
+	secretKey     string
+	correlationID string
+}
+
+// NewInteractshClient creates a client for the given server ("oast.pro" or "https://host")
+func NewInteractshClient(server, token string) (*InteractshClient, error) {
+	server = strings.TrimSpace(server)
+	if server == "" {
+		server = DefaultInteractshServer
+	}
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	serverURL, err := url.Parse(server)
+	if err != nil || serverURL.Host == "" {
+		return nil, fmt.Errorf("invalid interactsh server: %s", server)
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate RSA key: %w", err)
+	}
+
+	secretKey, err := randomID(32)
+	if err != nil {
+		return nil, err
+	}
+	correlationID, err := randomID(interactshCorrelationIDLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InteractshClient{
+		serverURL:     serverURL,
+		token:         token,
+		httpClient:    &http.Client{Timeout: 15 * time.Second},
+		privateKey:    privateKey,
+		secretKey:     secretKey,
+		correlationID: correlationID,
+	}, nil
+}
+
+// randomID returns a random lowercase alphanumeric string, drawing every character
+// uniformly from the alphabet
+func randomID(length int) (string, error) {
+	buf := make([]byte, length)
+	alphabetSize := big.NewInt(int64(len(interactshIDAlphabet)))
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random ID: %w", err)
+		}
+		buf[i] = interactshIDAlphabet[n.Int64()]
+	}
+	return string(buf), nil
+}
+
+// Domain returns the OOB domain of the server
+func (c *InteractshClient) Domain() string {
+	return c.serverURL.Hostname()
+}
+
+// NewPayload returns a fresh payload host and its unique ID (correlation ID + nonce)
+func (c *InteractshClient) NewPayload() (string, string, error) {
+	nonce, err := randomID(interactshNonceLength)
+	if err != nil {
+		return "", "", err
+	}
+	uniqueID := c.correlationID + nonce
+	return uniqueID + "." + c.Domain(), uniqueID, nil
+}
+
+// post sends a JSON body to the given server path
+func (c *InteractshClient) post(ctx context.Context, path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.serverURL.String()+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("interactsh server rejected the token")
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("interactsh server returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// Register registers the client's public key and correlation ID with the server
+func (c *InteractshClient) Register(ctx context.Context) error {
+	pubBytes, err := x509.MarshalPKIXPublicKey(&c.privateKey.PublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: pubBytes})
+
+	err = c.post(ctx, "/register", map[string]string{
+		"public-key":     base64.StdEncoding.EncodeToString(pubPEM),
+		"secret-key":     c.secretKey,
+		"correlation-id": c.correlationID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register with %s: %w", c.Domain(), err)
+	}
+	return nil
+}
+
+// Deregister removes the session from the server
+func (c *InteractshClient) Deregister(ctx context.Context) error {
+	return c.post(ctx, "/deregister", map[string]string{
+		"secret-key":     c.secretKey,
+		"correlation-id": c.correlationID,
+	})
+}
+
+// Poll fetches and decrypts the interactions received since the last poll
+func (c *InteractshClient) Poll(ctx context.Context) ([]*interactshEvent, error) {
+	pollURL := fmt.Sprintf("%s/poll?id=%s&secret=%s", c.serverURL.String(), c.correlationID, c.secretKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pollURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to poll interactions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("interactsh poll returned %d", resp.StatusCode)
+	}
+
+	var pollResp interactshPollResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pollResp); err != nil {
+		return nil, fmt.Errorf("failed to decode poll response: %w", err)
+	}
+
+	var events []*interactshEvent
+	if len(pollResp.Data) > 0 {
+		aesKey, err := c.decryptAESKey(pollResp.AESKey)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range pollResp.Data {
+			plain, err := decryptInteraction(aesKey, item)
+			if err != nil {
+				continue
+			}
+			event := &interactshEvent{}
+			if err := json.Unmarshal(plain, event); err == nil {
+				events = append(events, event)
+			}
+		}
+	}
+	for _, item := range pollResp.Extra {
+		event := &interactshEvent{}
+		if err := json.Unmarshal([]byte(item), event); err == nil {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// decryptAESKey decrypts the per-poll AES key with the client's private key
+func (c *InteractshClient) decryptAESKey(encoded string) ([]byte, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AES key encoding: %w", err)
+	}
+	key, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, c.privateKey, encrypted, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt AES key: %w", err)
+	}
+	return key, nil
+}
+
+// decryptInteraction decrypts a single AES-CFB encrypted interaction
+func decryptInteraction(key []byte, encoded string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aes.BlockSize {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	iv := ciphertext[:aes.BlockSize]
+	ciphertext = ciphertext[aes.BlockSize:]
+	cipher.NewCFBDecrypter(block, iv).XORKeyStream(ciphertext, ciphertext)
+
+	return ciphertext, nil
+}
+
+// InteractshTestResult represents the outcome of an interactsh health check
+type InteractshTestResult struct {
+	Server     string   `json:"server"`     // 测试的服务器
+	Registered bool     `json:"registered"` // 是否注册成功
+	RoundTrip  bool     `json:"round_trip"` // 是否收到自身触发的交互
+	Protocols  []string `json:"protocols"`  // 收到交互的协议
+	Payload    string   `json:"payload"`    // 测试使用的载荷域名
+	LatencyMs  int64    `json:"latency_ms"` // 从触发到收到交互的耗时
+	Error      string   `json:"error,omitempty"`
+}
+
+// TestInteractshServer registers a session, triggers a DNS/HTTP interaction against
+// its own payload and polls until the interaction comes back
+func TestInteractshServer(ctx context.Context, server, token string) *InteractshTestResult {
+	result := &InteractshTestResult{Server: server, Protocols: []string{}}
+	if strings.TrimSpace(server) == "" {
+		result.Server = DefaultInteractshServer
+	}
+	if result.Server == DefaultInteractshServer {
+		if err := requireOnline(); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	client, err := NewInteractshClient(server, token)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if err := client.Register(ctx); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Registered = true
+	defer func() {
+		deregisterCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		client.Deregister(deregisterCtx)
+	}()
+
+	host, uniqueID, err := client.NewPayload()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Payload = host
+
+	// 触发DNS和HTTP交互（失败可忽略，只要服务器收到即可）
+	start := time.Now()
+	go func() {
+		triggerCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		net.DefaultResolver.LookupHost(triggerCtx, host)
+		if req, err := http.NewRequestWithContext(triggerCtx, http.MethodGet, "http://"+host, nil); err == nil {
+			if resp, err := http.DefaultClient.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(20 * time.Second)
+	seen := make(map[string]bool)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			result.Error = ctx.Err().Error()
+			return result
+		case <-time.After(2 * time.Second):
+		}
+
+		events, err := client.Poll(ctx)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		for _, event := range events {
+			if !strings.EqualFold(event.UniqueID, uniqueID) || seen[event.Protocol] {
+				continue
+			}
+			seen[event.Protocol] = true
+			result.Protocols = append(result.Protocols, event.Protocol)
+			if !result.RoundTrip {
+				result.RoundTrip = true
+				result.LatencyMs = time.Since(start).Milliseconds()
+			}
+		}
+		if result.RoundTrip {
+			result.Error = ""
+			return result
+		}
+	}
+
+	if result.Error == "" {
+		result.Error = "未在超时时间内收到交互，服务器可能无法被目标访问"
+	}
+	return result
+}
@@ -2,47 +2,89 @@ package scanner
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	"wepoc/internal/database"
+	"wepoc/internal/i18n"
 	"wepoc/internal/models"
 )
 
-// JSONTaskManager manages scanning tasks using JSON files instead of database
+// JSONTaskManager manages scanning tasks. Tasks, results, HTTP request logs and progress
+// snapshots are stored as JSON documents in SQLite; JSON files are only an export format.
 type JSONTaskManager struct {
-	tasksDir      string
-	resultsDir    string
-	logsDir       string
-	mu            sync.RWMutex
-	nextTaskID    int64
-	eventHandlers map[int64]func(*ScanEvent) // Task ID -> event handler
-	handlersMu    sync.RWMutex
-	config        *models.Config // Add configuration support
+	db              *database.Database
+	tasksDir        string // 旧版JSON任务目录，仅用于迁移
+	resultsDir      string
+	logsDir         string
+	mu              sync.RWMutex
+	nextTaskID      int64
+	eventHandlers   map[int64]func(*ScanEvent) // Task ID -> event handler
+	handlersMu      sync.RWMutex
+	config          *models.Config                 // Add configuration support
+	replayMu        sync.Mutex                     // 保护重放记录文件的读写
+	oob             *OOBManager                    // 内置Interactsh客户端及OOB交互记录
+	running         map[int64]*SimpleNucleiScanner // 正在运行的扫描器
+	runningMu       sync.RWMutex
+	shuttingDown    bool                                 // 程序正在退出：被停止的任务标记为interrupted（受runningMu保护）
+	defaultHandler  func(*ScanEvent)                     // 未注册专属处理器的任务使用的事件处理器
+	followUpHandler func(*TaskConfig)                    // 自动创建跟进任务时的回调
+	logLineHandler  func(*TaskLogLine)                   // 实时转发nuclei原始输出的回调
+	logStreams      map[int64]bool                       // 开启了原始输出实时转发的任务
+	forwarder       *Forwarder                           // 发现结果转发到SIEM（syslog/Splunk/Elasticsearch）
+	auditUser       string                               // 审计日志记录的登录用户（多用户模式）
+	sealFile        func(path string) error              // 加密证据文件（附件、归档），见SetFileCipher
+	writeSealedFile func(path string, data []byte) error // 加密写入证据文件（重放、OOB、错误汇总）
+	readSealedFile  func(path string) ([]byte, error)    // 读取加密或明文的证据文件
+	sealRecord      func(string) (string, error)         // 加密追加写入的任务日志行（事件、完整响应），见SetRecordCipher
+	openRecord      func(string) (string, error)
+	encryptSecret   func(string) (string, error) // 加密任务保存的密钥（Interactsh Token），见SetSecretCipher
+	decryptSecret   func(string) (string, error)
 }
 
-// TaskConfig represents a task configuration stored in JSON
+// TaskConfig represents a task configuration
 type TaskConfig struct {
-	ID                int64      `json:"id"`
-	Name              string     `json:"name"`
-	Status            string     `json:"status"` // pending, running, completed, failed
-	POCs              []string   `json:"pocs"`
-	Targets           []string   `json:"targets"`
-	TotalRequests     int        `json:"total_requests"`
-	CompletedRequests int        `json:"completed_requests"`
-	FoundVulns        int        `json:"found_vulns"`
-	StartTime         time.Time  `json:"start_time"`
-	EndTime           *time.Time `json:"end_time,omitempty"`
-	OutputFile        string     `json:"output_file"`
-	LogFile           string     `json:"log_file"`
-	CreatedAt         time.Time  `json:"created_at"`
-	UpdatedAt         time.Time  `json:"updated_at"`
-}
-
-// TaskResult represents the scan result stored in JSON
+	ID                  int64                `json:"id"`
+	Name                string               `json:"name"`
+	Status              string               `json:"status"` // pending, running, waiting_window, paused, completed, stopped, interrupted, failed
+	POCs                []string             `json:"pocs"`
+	Targets             []string             `json:"targets"`
+	TotalRequests       int                  `json:"total_requests"`
+	CompletedRequests   int                  `json:"completed_requests"`
+	FoundVulns          int                  `json:"found_vulns"`
+	StartTime           time.Time            `json:"start_time"`
+	EndTime             *time.Time           `json:"end_time,omitempty"`
+	OutputFile          string               `json:"output_file"` // 结果导出/归档时使用的文件名，结果本身保存在数据库中
+	LogFile             string               `json:"log_file"`
+	CreatedAt           time.Time            `json:"created_at"`
+	UpdatedAt           time.Time            `json:"updated_at"`
+	Options             TaskOptions          `json:"options"`                         // 任务级扫描参数覆盖
+	ResumeFile          string               `json:"resume_file,omitempty"`           // 超出扫描时间窗口暂停时nuclei写入的续扫文件
+	Archived            bool                 `json:"archived,omitempty"`              // 结果和日志已按保留策略压缩归档
+	UseTemplateSnapshot bool                 `json:"use_template_snapshot,omitempty"` // 本次扫描使用任务上次启动时记录的模板快照
+	FilteredTemplates   []*FilteredTemplate  `json:"filtered_templates,omitempty"`    // 创建或修改任务时检测到会被Nuclei过滤的模板
+	CodeConfirmation    *CodeConfirmation    `json:"code_confirmation,omitempty"`     // 用户对任务中code模板的执行确认
+	RequestConfirmation *RequestConfirmation `json:"request_confirmation,omitempty"`  // 用户对超过阈值的预估请求量的确认
+	Workflow            string               `json:"workflow,omitempty"`              // 执行的工作流文件（以 -w 运行，POCs为其引用的模板）
+	FollowUpOf          int64                `json:"follow_up_of,omitempty"`          // 由该任务的发现自动创建的跟进任务
+	FollowUpRule        string               `json:"follow_up_rule,omitempty"`        // 创建本任务的跟进规则
+	FollowUpDepth       int                  `json:"follow_up_depth,omitempty"`       // 跟进链长度（跟进任务为1，其跟进任务为2...）
+	DiscoveryQuery      string               `json:"discovery_query,omitempty"`       // 通过搜索引擎查询创建任务时的引擎和查询语句
+	Notes               *TaskNotes           `json:"notes,omitempty"`                 // 测试范围、测试备注和管理层摘要（随报告导出）
+	Description         string               `json:"description,omitempty"`           // 任务说明
+	Labels              []string             `json:"labels,omitempty"`                // 任务标签
+	Lock                *TaskLock            `json:"lock,omitempty"`                  // 作为证据锁定，禁止修改、重扫和删除
+	ImportedFrom        string               `json:"imported_from,omitempty"`         // 从外部扫描结果文件导入，无法重扫
+}
+
+// TaskResult represents the scan result of a task
 type TaskResult struct {
 	TaskID            int64                  `json:"task_id"`
 	TaskName          string                 `json:"task_name"`
@@ -63,19 +105,31 @@ type TaskResult struct {
 	CreatedAt         time.Time              `json:"created_at"`
 
 	// 新增：详细统计信息
-	ScannedTemplates    int      `json:"scanned_templates"`      // 实际扫描的模板数量
-	FilteredTemplates   int      `json:"filtered_templates"`     // 被Nuclei过滤的模板数量
-	SkippedTemplates    int      `json:"skipped_templates"`      // 被跳过的模板数量
-	FailedTemplates     int      `json:"failed_templates"`       // 扫描失败的模板数量
-	FilteredTemplateIDs []string `json:"filtered_template_ids"`  // 被过滤的模板ID列表
-	SkippedTemplateIDs  []string `json:"skipped_template_ids"`   // 被跳过的模板ID列表
-	FailedTemplateIDs   []string `json:"failed_template_ids"`    // 失败的模板ID列表
-	ScannedTemplateIDs  []string `json:"scanned_template_ids"`   // 已扫描的模板ID列表
-	HTTPRequests        int      `json:"http_requests"`          // 实际HTTP请求数量
-}
-
-// NewJSONTaskManager creates a new JSON-based task manager
-func NewJSONTaskManager(config *models.Config) (*JSONTaskManager, error) {
+	ScannedTemplates    int                 `json:"scanned_templates"`             // 实际扫描的模板数量
+	FilteredTemplates   int                 `json:"filtered_templates"`            // 被Nuclei过滤的模板数量
+	SkippedTemplates    int                 `json:"skipped_templates"`             // 被跳过的模板数量
+	FailedTemplates     int                 `json:"failed_templates"`              // 扫描失败的模板数量
+	FilteredTemplateIDs []string            `json:"filtered_template_ids"`         // 被过滤的模板ID列表
+	SkippedTemplateIDs  []string            `json:"skipped_template_ids"`          // 被跳过的模板ID列表
+	FailedTemplateIDs   []string            `json:"failed_template_ids"`           // 失败的模板ID列表
+	ScannedTemplateIDs  []string            `json:"scanned_template_ids"`          // 已扫描的模板ID列表
+	HTTPRequests        int                 `json:"http_requests"`                 // 实际HTTP请求数量
+	UnreachableTargets  []string            `json:"unreachable_targets,omitempty"` // 协议探测不可达的目标
+	TargetInfo          []*TargetInfo       `json:"target_info,omitempty"`         // 探测时采集的目标标题、Server头和favicon哈希
+	SlowestTemplates    []*TemplateTiming   `json:"slowest_templates,omitempty"`   // 耗时最长的模板
+	HostErrors          []*HostErrorSummary `json:"host_errors,omitempty"`         // 每个目标的错误分类统计
+	SkippedTargets      []string            `json:"skipped_targets,omitempty"`     // 因错误过多被nuclei跳过的目标
+	Notes               *TaskNotes          `json:"notes,omitempty"`               // 导出报告时附带的任务说明
+	Locked              bool                `json:"locked,omitempty"`              // 任务已作为证据锁定
+}
+
+// NewJSONTaskManager creates a task manager storing its data in db; task data left in the
+// JSON files of older versions is migrated into the database
+func NewJSONTaskManager(config *models.Config, db *database.Database) (*JSONTaskManager, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is required")
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
@@ -93,96 +147,121 @@ func NewJSONTaskManager(config *models.Config) (*JSONTaskManager, error) {
 		}
 	}
 
-	// Find the next task ID
-	nextID, err := findNextTaskID(tasksDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find next task ID: %w", err)
-	}
-
-	return &JSONTaskManager{
+	tm := &JSONTaskManager{
+		db:            db,
 		tasksDir:      tasksDir,
 		resultsDir:    resultsDir,
 		logsDir:       logsDir,
-		nextTaskID:    nextID,
 		eventHandlers: make(map[int64]func(*ScanEvent)),
+		running:       make(map[int64]*SimpleNucleiScanner),
 		config:        config,
-	}, nil
-}
+		oob:           NewOOBManager(logsDir),
+		forwarder:     NewForwarder(),
+	}
+	if config != nil {
+		SetLoggingConfig(config.Logging)
+		SetOfflineMode(config.Offline)
+		SetTemplateRoots(config)
+		tm.forwarder.Configure(forwardingConfig(config))
+	}
 
-// findNextTaskID finds the next available task ID
-func findNextTaskID(tasksDir string) (int64, error) {
-	files, err := filepath.Glob(filepath.Join(tasksDir, "task_*.json"))
-	if err != nil {
-		return 1, nil // Start from 1 if no files exist
+	if err := tm.migrateStorage(); err != nil {
+		return nil, fmt.Errorf("failed to migrate task storage: %w", err)
 	}
+	tm.recoverInterruptedTasks()
 
-	maxID := int64(0)
-	for _, file := range files {
-		filename := filepath.Base(file)
-		if len(filename) > 10 && filename[:5] == "task_" {
-			idStr := filename[5 : len(filename)-5] // Remove "task_" and ".json"
-			if id, err := strconv.ParseInt(idStr, 10, 64); err == nil {
-				if id > maxID {
-					maxID = id
-				}
-			}
-		}
+	// Find the next task ID
+	nextID, err := tm.findNextTaskID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find next task ID: %w", err)
 	}
+	tm.nextTaskID = nextID
 
+	return tm, nil
+}
+
+// findNextTaskID finds the next available task ID
+func (tm *JSONTaskManager) findNextTaskID() (int64, error) {
+	maxID, err := tm.db.MaxTaskID()
+	if err != nil {
+		return 0, err
+	}
 	return maxID + 1, nil
 }
 
 // CreateTask creates a new scanning task
 func (tm *JSONTaskManager) CreateTask(pocs []string, targets []string, taskName string) (*TaskConfig, error) {
-	fmt.Printf("=== JSONTaskManager.CreateTask called ===\n")
-	fmt.Printf("POCs: %v\n", pocs)
-	fmt.Printf("Targets: %v\n", targets)
-	fmt.Printf("TaskName: %s\n", taskName)
+	logInfof("=== JSONTaskManager.CreateTask called ===\n")
+	logInfof("POCs: %v\n", pocs)
+	logInfof("Targets: %v\n", targets)
+	logInfof("TaskName: %s\n", taskName)
+
+	return tm.createTask(&TaskConfig{Name: taskName, POCs: pocs, Targets: targets})
+}
+
+// createTask assigns an ID to a task filled in by the caller (name, POCs, targets and
+// optionally its workflow, options or follow-up origin) and saves it as pending
+func (tm *JSONTaskManager) createTask(task *TaskConfig) (*TaskConfig, error) {
+	targets, err := NormalizeTargets(task.Targets)
+	if err != nil {
+		return nil, err
+	}
+	task.Targets = targets
+	task.Labels = normalizeTaskLabels(task.Labels)
 
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
 	// Use provided task name or generate default
-	if taskName == "" {
-		taskName = fmt.Sprintf("Task-%d", tm.nextTaskID)
+	if task.Name == "" {
+		task.Name = fmt.Sprintf("Task-%d", tm.nextTaskID)
 	}
 
 	now := time.Now()
 	taskID := tm.nextTaskID
 	tm.nextTaskID++
 
-	fmt.Printf("Assigned task ID: %d\n", taskID)
+	logInfof("Assigned task ID: %d\n", taskID)
 
 	// Create task configuration
-	task := &TaskConfig{
-		ID:                taskID,
-		Name:              taskName,
-		Status:            "pending",
-		POCs:              pocs,
-		Targets:           targets,
-		TotalRequests:     len(pocs) * len(targets),
-		CompletedRequests: 0,
-		FoundVulns:        0,
-		StartTime:         now,
-		OutputFile:        filepath.Join(tm.resultsDir, fmt.Sprintf("task_%d_result.json", taskID)),
-		LogFile:           filepath.Join(tm.logsDir, fmt.Sprintf("task_%d.log", taskID)),
-		CreatedAt:         now,
-		UpdatedAt:         now,
-	}
-
-	fmt.Printf("Task config created: %+v\n", task)
+	task.ID = taskID
+	task.Status = "pending"
+	task.TotalRequests = len(task.POCs) * len(task.Targets)
+	task.CompletedRequests = 0
+	task.FoundVulns = 0
+	task.StartTime = now
+	task.OutputFile = filepath.Join(tm.resultsDir, resultFileName(taskID))
+	task.LogFile = filepath.Join(tm.logsDir, fmt.Sprintf("task_%d.log", taskID))
+	task.CreatedAt = now
+	task.UpdatedAt = now
+
+	logInfof("Task config created: %+v\n", task)
+	tm.checkFilteredTemplates(task)
 
 	// Save task configuration to JSON file
 	if err := tm.saveTaskConfig(task); err != nil {
-		fmt.Printf("Failed to save task config: %v\n", err)
+		logErrorf("Failed to save task config: %v\n", err)
 		return nil, fmt.Errorf("failed to save task config: %w", err)
 	}
 
-	fmt.Printf("Task config saved successfully to disk\n")
+	logInfof("Task config saved successfully to disk\n")
+	tm.recordTimeline(taskID, &TimelineEvent{Kind: TimelineCreated, Time: now})
 
 	return task, nil
 }
 
+// checkStartGate runs the checks a task must pass each time its scan is launched: template
+// trust, confirmation of code templates and the request estimate (caller holds mu)
+func (tm *JSONTaskManager) checkStartGate(task *TaskConfig) error {
+	if err := tm.checkTemplateTrust(task); err != nil {
+		return err
+	}
+	if err := tm.checkCodeTemplates(task); err != nil {
+		return err
+	}
+	return tm.checkRequestEstimate(task)
+}
+
 // StartTask starts a scanning task
 func (tm *JSONTaskManager) StartTask(taskID int64) error {
 	tm.mu.Lock()
@@ -193,23 +272,45 @@ func (tm *JSONTaskManager) StartTask(taskID int64) error {
 	if err != nil {
 		return fmt.Errorf("failed to load task config: %w", err)
 	}
+	if err := task.checkUnlocked(); err != nil {
+		return err
+	}
+	if task.ImportedFrom != "" {
+		return fmt.Errorf("任务 %d 由外部扫描结果导入，无法启动", taskID)
+	}
+
+	// 用户暂停的任务从续扫文件继续，其余任务重置进度数据（清零）
+	resuming := task.Status == "paused" && task.ResumeFile != ""
+	if !resuming {
+		task.UseTemplateSnapshot = false
+		task.CompletedRequests = 0
+		task.FoundVulns = 0
+		task.ResumeFile = ""
+		task.StartTime = time.Time{}
+		tm.refreshWorkflowTemplates(task)
+	}
+
+	if err := tm.checkStartGate(task); err != nil {
+		return err
+	}
+
+	// 不在扫描时间窗口内时等待窗口开启
+	if deferred, err := tm.deferToScanWindow(task); deferred || err != nil {
+		return err
+	}
 
 	// Update task status to running
 	task.Status = "running"
 	task.StartTime = time.Now()
 	task.UpdatedAt = time.Now()
 
-	// 重置任务的进度数据（清零）
-	task.CompletedRequests = 0
-	task.FoundVulns = 0
-
 	// Save updated task configuration
 	if err := tm.saveTaskConfig(task); err != nil {
 		return fmt.Errorf("failed to save task config: %w", err)
 	}
 
 	// 发送初始化的进度事件到前端，清零旧数据
-	fmt.Printf("🔄 任务 %d 启动，发送初始化进度事件（清零）\n", taskID)
+	logInfof("🔄 任务 %d 启动，发送初始化进度事件（清零）\n", taskID)
 	initialProgress := &ScanProgress{
 		TaskID:              taskID,
 		TotalRequests:       task.TotalRequests,
@@ -250,10 +351,45 @@ func (tm *JSONTaskManager) UpdateConfig(config *models.Config) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 	tm.config = config
+	if config != nil {
+		SetLoggingConfig(config.Logging)
+		SetOfflineMode(config.Offline)
+		SetTemplateRoots(config)
+		tm.forwarder.Configure(forwardingConfig(config))
+	}
 }
 
-// RescanTask restarts a completed or failed task with the same configuration
+// ReloadTasks reloads the task storage after it was replaced (e.g. a workspace restore);
+// task JSON files restored from backups of older versions are migrated into the database
+func (tm *JSONTaskManager) ReloadTasks() error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if err := tm.migrateStorage(); err != nil {
+		return fmt.Errorf("failed to migrate task storage: %w", err)
+	}
+	nextID, err := tm.findNextTaskID()
+	if err != nil {
+		return fmt.Errorf("failed to find next task ID: %w", err)
+	}
+	tm.nextTaskID = nextID
+	return nil
+}
+
+// RescanTask restarts a finished (completed, failed, stopped or interrupted) task with the
+// same configuration
 func (tm *JSONTaskManager) RescanTask(taskID int64) error {
+	return tm.rescanTask(taskID, false)
+}
+
+// RescanTaskWithSnapshot restarts a finished task against the template versions
+// recorded when it was last started, even if the templates were edited or updated since
+func (tm *JSONTaskManager) RescanTaskWithSnapshot(taskID int64) error {
+	return tm.rescanTask(taskID, true)
+}
+
+// rescanTask restarts a finished task, optionally against its template snapshot
+func (tm *JSONTaskManager) rescanTask(taskID int64, useSnapshot bool) error {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
@@ -264,21 +400,53 @@ func (tm *JSONTaskManager) RescanTask(taskID int64) error {
 	}
 
 	// Check if task can be rescanned
-	if task.Status != "completed" && task.Status != "failed" {
+	if !finishedTask(task) {
 		return fmt.Errorf("task %d is not in a rescanable state (current status: %s)", taskID, task.Status)
 	}
+	if err := task.checkUnlocked(); err != nil {
+		return err
+	}
+	if task.ImportedFrom != "" {
+		return fmt.Errorf("任务 %d 由外部扫描结果导入，无法重扫", taskID)
+	}
+
+	if useSnapshot {
+		entries, err := tm.db.GetTemplateSnapshot(taskID)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return fmt.Errorf("任务 %d 没有模板快照", taskID)
+		}
+	}
 
 	// Reset task state for rescan
-	task.Status = "running"
-	task.StartTime = time.Now()
+	task.UseTemplateSnapshot = useSnapshot
 	task.EndTime = nil
 	task.CompletedRequests = 0
 	task.FoundVulns = 0
-	task.UpdatedAt = time.Now()
+	task.ResumeFile = ""
+	task.StartTime = time.Time{}
 
 	// Clear previous results and logs
-	task.OutputFile = filepath.Join(tm.resultsDir, fmt.Sprintf("task_%d_result.json", taskID))
+	task.OutputFile = filepath.Join(tm.resultsDir, resultFileName(taskID))
 	task.LogFile = filepath.Join(tm.logsDir, fmt.Sprintf("task_%d.log", taskID))
+	if !useSnapshot {
+		tm.refreshWorkflowTemplates(task)
+	}
+
+	if err := tm.checkStartGate(task); err != nil {
+		return err
+	}
+
+	// 不在扫描时间窗口内时等待窗口开启
+	if deferred, err := tm.deferToScanWindow(task); deferred || err != nil {
+		return err
+	}
+
+	task.Status = "running"
+	task.StartTime = time.Now()
+	task.UpdatedAt = time.Now()
 
 	// Save updated task configuration
 	if err := tm.saveTaskConfig(task); err != nil {
@@ -298,6 +466,14 @@ func (tm *JSONTaskManager) RegisterEventHandler(taskID int64, handler func(*Scan
 	tm.eventHandlers[taskID] = handler
 }
 
+// SetDefaultEventHandler sets the handler used for tasks without their own handler, so
+// events of running tasks keep flowing after the frontend reloads
+func (tm *JSONTaskManager) SetDefaultEventHandler(handler func(*ScanEvent)) {
+	tm.handlersMu.Lock()
+	defer tm.handlersMu.Unlock()
+	tm.defaultHandler = handler
+}
+
 // UnregisterEventHandler unregisters the event handler for a task
 func (tm *JSONTaskManager) UnregisterEventHandler(taskID int64) {
 	tm.handlersMu.Lock()
@@ -305,10 +481,19 @@ func (tm *JSONTaskManager) UnregisterEventHandler(taskID int64) {
 	delete(tm.eventHandlers, taskID)
 }
 
-// emitEvent emits an event to the registered handler
+// emitEvent emits an event to the registered handler and the event bus
 func (tm *JSONTaskManager) emitEvent(taskID int64, event *ScanEvent) {
+	tm.notifyHandlers(taskID, event)
+	tm.publishScanEvent(event)
+}
+
+// notifyHandlers passes an event to the registered handler only
+func (tm *JSONTaskManager) notifyHandlers(taskID int64, event *ScanEvent) {
 	tm.handlersMu.RLock()
 	handler, exists := tm.eventHandlers[taskID]
+	if !exists {
+		handler, exists = tm.defaultHandler, tm.defaultHandler != nil
+	}
 	tm.handlersMu.RUnlock()
 
 	if exists && handler != nil {
@@ -318,12 +503,30 @@ func (tm *JSONTaskManager) emitEvent(taskID int64, event *ScanEvent) {
 
 // runScanTask executes the scanning task
 func (tm *JSONTaskManager) runScanTask(task *TaskConfig) {
+	// 新启动的扫描记录所用模板的快照；快照重扫和续扫沿用已有快照
+	if !task.UseTemplateSnapshot && task.ResumeFile == "" {
+		tm.recordTemplateSnapshot(task)
+	}
+
 	// Create a simple scanner that runs nuclei and saves results
 	scanner := NewSimpleNucleiScanner(task, tm)
-
-	// Listen to scanner events and forward them
+	// 任务结束后按保留策略清理旧任务
+	defer tm.ApplyRetention()
+	// 任务结束（取消跟踪）后清理该任务遗留的临时目录
+	defer tm.cleanupTaskTempDirs(task.ID)
+	tm.trackScanner(task.ID, scanner)
+	defer tm.untrackScanner(task.ID)
+
+	// Listen to scanner events and forward them; gaps in the sequence (events that did not
+	// fit in the channel) are replayed from the persistent event log
+	var lastSeq int64
+	if task.ResumeFile != "" {
+		// 续扫在原事件日志后追加，之前的事件已处理过
+		lastSeq = tm.lastEventSeq(task.ID)
+	}
 	go func() {
-		for event := range scanner.GetEventChannel() {
+		handle := func(event *ScanEvent) {
+			lastSeq = event.Seq
 			tm.emitEvent(task.ID, event)
 
 			// Update task configuration based on event
@@ -336,25 +539,57 @@ func (tm *JSONTaskManager) runScanTask(task *TaskConfig) {
 					task.UpdatedAt = time.Now()
 
 					// 如果任务完成，设置结束时间
-					if progress.Status == "completed" || progress.Status == "failed" {
+					if progress.Status == "completed" || progress.Status == "failed" || progress.Status == "stopped" {
 						now := time.Now()
 						task.EndTime = &now
-						fmt.Printf("✅ 任务 %d 完成，设置EndTime并保存状态: %s\n", task.ID, progress.Status)
+						logInfof("✅ 任务 %d 完成，设置EndTime并保存状态: %s\n", task.ID, progress.Status)
 					}
 
 					tm.saveTaskConfig(task)
 					tm.mu.Unlock()
+					tm.saveProgressSnapshot(progress)
 
 					// Also emit event to ensure it's received by frontend
-					tm.emitEvent(task.ID, event)
+					tm.notifyHandlers(task.ID, event)
 				}
 			}
 		}
+		replay := func(beforeSeq int64) {
+			missed, err := tm.GetTaskEvents(task.ID, lastSeq)
+			if err != nil {
+				logWarnf("⚠️ 读取事件日志失败: %v\n", err)
+				return
+			}
+			for _, event := range missed {
+				if beforeSeq > 0 && event.Seq >= beforeSeq {
+					break
+				}
+				handle(event)
+			}
+		}
+
+		for event := range scanner.GetEventChannel() {
+			if event.Seq > lastSeq+1 {
+				replay(event.Seq)
+			}
+			handle(event)
+		}
+		// 通道关闭后补发剩余事件（包括最终的completed事件）
+		replay(0)
 
 		// Unregister event handler when done
 		tm.UnregisterEventHandler(task.ID)
 	}()
 
+	// 机器资源紧张时延迟启动
+	tm.waitForResources(scanner)
+
+	started := &TimelineEvent{Kind: TimelineStarted}
+	if task.ResumeFile != "" {
+		started.Message = "续扫"
+	}
+	tm.recordTimeline(task.ID, started)
+
 	// Run the scan
 	err := scanner.Start()
 
@@ -362,28 +597,54 @@ func (tm *JSONTaskManager) runScanTask(task *TaskConfig) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
+	// 暂停时保存续扫文件，其余情况清除（事件转发协程保存的也是这个任务对象）
+	resumeFile := ""
+	if errors.Is(err, ErrScanPaused) {
+		resumeFile = scanner.ResumeFile()
+	}
+	task.ResumeFile = resumeFile
+
 	// Reload task to get latest state
 	task, loadErr := tm.loadTaskConfig(task.ID)
 	if loadErr != nil {
-		fmt.Printf("Failed to reload task config: %v\n", loadErr)
+		logErrorf("Failed to reload task config: %v\n", loadErr)
 		return
 	}
 
 	now := time.Now()
 	task.EndTime = &now
 	task.UpdatedAt = now
-
-	if err != nil {
+	task.ResumeFile = resumeFile
+
+	if errors.Is(err, ErrScanPaused) {
+		task.Status = scanner.pausedStatus()
+		task.EndTime = nil
+		logInfof("Task %d paused (%s)\n", task.ID, task.Status)
+	} else if errors.Is(err, ErrScanStopped) && tm.isShuttingDown() {
+		task.Status = "interrupted"
+		logInfof("Task %d interrupted by shutdown\n", task.ID)
+	} else if errors.Is(err, ErrScanStopped) {
+		task.Status = "stopped"
+		logInfof("Task %d stopped\n", task.ID)
+	} else if err != nil {
 		task.Status = "failed"
-		fmt.Printf("Task %d failed: %v\n", task.ID, err)
+		logInfof("Task %d failed: %v\n", task.ID, err)
 	} else {
 		task.Status = "completed"
-		fmt.Printf("Task %d completed successfully\n", task.ID)
+		logInfof("Task %d completed successfully\n", task.ID)
 	}
 
 	// Save final task configuration
 	if saveErr := tm.saveTaskConfig(task); saveErr != nil {
-		fmt.Printf("Failed to save final task config: %v\n", saveErr)
+		logErrorf("Failed to save final task config: %v\n", saveErr)
+	}
+	finished := &TimelineEvent{Kind: timelineStatus(task.Status)}
+	if task.Status == "failed" && err != nil {
+		finished.Message = err.Error()
+	}
+	tm.recordTimeline(task.ID, finished)
+	if task.Status != "waiting_window" && task.Status != "paused" {
+		tm.forwardTaskSummary(task)
 	}
 }
 
@@ -391,17 +652,21 @@ func (tm *JSONTaskManager) runScanTask(task *TaskConfig) {
 func (tm *JSONTaskManager) GetAllTasks() ([]*TaskConfig, error) {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
+	return tm.listTaskConfigs()
+}
 
-	files, err := filepath.Glob(filepath.Join(tm.tasksDir, "task_*.json"))
+// listTaskConfigs loads all task configurations, newest first (caller holds mu)
+func (tm *JSONTaskManager) listTaskConfigs() ([]*TaskConfig, error) {
+	documents, err := tm.db.ListTasks()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list task files: %w", err)
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
 	}
 
 	var tasks []*TaskConfig
-	for _, file := range files {
-		task, err := tm.loadTaskConfigFromFile(file)
+	for _, data := range documents {
+		task, err := tm.decodeTask(data)
 		if err != nil {
-			fmt.Printf("Failed to load task from file %s: %v\n", file, err)
+			logErrorf("Failed to decode task: %v\n", err)
 			continue
 		}
 		tasks = append(tasks, task)
@@ -413,28 +678,39 @@ func (tm *JSONTaskManager) GetAllTasks() ([]*TaskConfig, error) {
 // GetTaskByID returns a specific task
 // UpdateTask updates an existing task configuration
 func (tm *JSONTaskManager) UpdateTask(taskID int64, pocs []string, targets []string, taskName string) (*TaskConfig, error) {
+	targets, err := NormalizeTargets(targets)
+	if err != nil {
+		return nil, err
+	}
+
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
 	// Load existing task
 	task, err := tm.loadTaskConfig(taskID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load task: %v", err)
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
 	}
 
-	// Check if task is running
+	// Check if task is running (a task waiting for its scan window may be edited: the start
+	// checks run again when the window opens, see resumeTask)
 	if task.Status == "running" {
-		return nil, fmt.Errorf("cannot update running task")
+		return nil, i18n.Errorf(i18n.ErrTaskRunning)
+	}
+	if err := task.checkUnlocked(); err != nil {
+		return nil, err
 	}
 
 	// Update task fields
 	task.Name = taskName
 	task.POCs = pocs
+	task.Workflow = "" // 编辑模板列表后按模板列表执行
 	task.Targets = targets
 	task.UpdatedAt = time.Now()
 
 	// Calculate total requests
 	task.TotalRequests = len(pocs) * len(targets)
+	tm.checkFilteredTemplates(task)
 
 	// Save updated task
 	if err := tm.saveTaskConfig(task); err != nil {
@@ -444,6 +720,79 @@ func (tm *JSONTaskManager) UpdateTask(taskID int64, pocs []string, targets []str
 	return task, nil
 }
 
+// maxTaskNameLength bounds task names, in characters
+const maxTaskNameLength = 200
+
+// UpdateTaskMetadata renames a task and replaces its description and labels. Unlike
+// UpdateTask it leaves the POCs and targets alone, so it is allowed on finished tasks
+// (only running tasks are refused); the name stored with the task's result follows.
+func (tm *JSONTaskManager) UpdateTaskMetadata(taskID int64, name, description string, labels []string) (*TaskConfig, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("任务名称不能为空")
+	}
+	if utf8.RuneCountInString(name) > maxTaskNameLength {
+		return nil, fmt.Errorf("任务名称超过 %d 个字符", maxTaskNameLength)
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.loadTaskConfig(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+	// 扫描中任务配置由扫描协程保存，修改会被覆盖
+	if task.Status == "running" {
+		return nil, i18n.Errorf(i18n.ErrTaskRunning)
+	}
+	if err := task.checkUnlocked(); err != nil {
+		return nil, err
+	}
+
+	renamed := task.Name != name
+	task.Name = name
+	task.Description = strings.TrimSpace(description)
+	task.Labels = normalizeTaskLabels(labels)
+	task.UpdatedAt = time.Now()
+	if err := tm.saveTaskConfig(task); err != nil {
+		return nil, fmt.Errorf("failed to save updated task: %v", err)
+	}
+
+	// 结果中保存的任务名称随之更新（已归档任务的结果在压缩包中，保持原名）
+	if renamed {
+		if data, err := tm.db.GetTaskResult(taskID); err == nil {
+			var result TaskResult
+			if err := json.Unmarshal(data, &result); err == nil {
+				result.TaskName = name
+				if err := tm.saveTaskResult(&result); err != nil {
+					logWarnf("⚠️ 更新任务 %d 结果中的名称失败: %v\n", taskID, err)
+				}
+			}
+		}
+	}
+
+	return task, nil
+}
+
+// normalizeTaskLabels trims labels and drops empty and duplicate ones, keeping their order
+func normalizeTaskLabels(labels []string) []string {
+	seen := make(map[string]bool)
+	normalized := []string{}
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || seen[strings.ToLower(label)] {
+			continue
+		}
+		seen[strings.ToLower(label)] = true
+		normalized = append(normalized, label)
+	}
+	if len(normalized) == 0 {
+		return nil
+	}
+	return normalized
+}
+
 func (tm *JSONTaskManager) GetTaskByID(taskID int64) (*TaskConfig, error) {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
@@ -451,7 +800,7 @@ func (tm *JSONTaskManager) GetTaskByID(taskID int64) (*TaskConfig, error) {
 	return tm.loadTaskConfig(taskID)
 }
 
-// DeleteTask deletes a task and its associated files
+// DeleteTask deletes a task, its stored result/logs and associated files
 func (tm *JSONTaskManager) DeleteTask(taskID int64) error {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
@@ -459,25 +808,56 @@ func (tm *JSONTaskManager) DeleteTask(taskID int64) error {
 	// Load task to get file paths
 	task, err := tm.loadTaskConfig(taskID)
 	if err != nil {
-		return fmt.Errorf("failed to load task: %w", err)
+		return i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+	if err := task.checkUnlocked(); err != nil {
+		return err
 	}
 
-	// Delete task configuration file
-	taskFile := filepath.Join(tm.tasksDir, fmt.Sprintf("task_%d.json", taskID))
-	if err := os.Remove(taskFile); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to delete task file: %w", err)
+	attachments, err := tm.db.TaskAttachmentFiles(taskID)
+	if err != nil {
+		return err
 	}
 
-	// Delete result file
-	if err := os.Remove(task.OutputFile); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to delete result file: %w", err)
+	// Delete task, result, HTTP logs, progress snapshot and finding attachments
+	if err := tm.db.DeleteTask(taskID); err != nil {
+		return err
 	}
 
-	// Delete log file
-	if err := os.Remove(task.LogFile); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to delete log file: %w", err)
+	return tm.removeTaskFiles(task, attachments)
+}
+
+// removeTaskFiles deletes the files kept for a task outside the database: its log, the
+// task_<id>_* files (events, replays, OOB interactions, errors, timeline, full responses),
+// enhanced logs, output directory, archive and the given attachment files
+func (tm *JSONTaskManager) removeTaskFiles(task *TaskConfig, attachments []string) error {
+	paths := []string{
+		task.LogFile,
+		filepath.Join(tm.resultsDir, fmt.Sprintf("task_%d", task.ID)),
+		tm.taskArchivePath(task.ID),
+	}
+	for _, dir := range []string{tm.logsDir, tm.resultsDir, filepath.Join(tm.logsDir, "enhanced")} {
+		for _, pattern := range []string{"task_%d.*", "task_%d_*"} {
+			matches, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf(pattern, task.ID)))
+			paths = append(paths, matches...)
+		}
+	}
+	for _, name := range attachments {
+		paths = append(paths, filepath.Join(tm.attachmentsDir(), filepath.FromSlash(name)))
 	}
 
+	var failed []string
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete task files: %s", strings.Join(failed, "; "))
+	}
 	return nil
 }
 
@@ -489,21 +869,27 @@ func (tm *JSONTaskManager) GetTaskResult(taskID int64) (*TaskResult, error) {
 	// Load task configuration
 	task, err := tm.loadTaskConfig(taskID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load task: %w", err)
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
 	}
 
-	// Check if result file exists
-	if _, err := os.Stat(task.OutputFile); os.IsNotExist(err) {
-		return nil, fmt.Errorf("result file does not exist")
+	data, err := tm.db.GetTaskResult(taskID)
+	if errors.Is(err, database.ErrNotFound) {
+		// 已归档任务从压缩包中读取结果
+		data, err = tm.readArchivedFile(taskID, "results/"+resultFileName(taskID))
+		if err != nil {
+			return nil, fmt.Errorf("result does not exist")
+		}
 	}
-
-	// Load result file
-	result, err := tm.loadTaskResult(task.OutputFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load result: %w", err)
 	}
 
-	return result, nil
+	var result TaskResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode result of task %s: %w", task.Name, err)
+	}
+	result.Locked = task.Lock != nil
+	return &result, nil
 }
 
 // GetAllTaskResults returns all task results with vulnerabilities found
@@ -511,116 +897,248 @@ func (tm *JSONTaskManager) GetAllTaskResults() ([]*TaskResult, error) {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 
-	files, err := filepath.Glob(filepath.Join(tm.resultsDir, "task_*_result.json"))
+	documents, err := tm.db.ListTaskResultsWithVulns()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list result files: %w", err)
+		return nil, fmt.Errorf("failed to list results: %w", err)
 	}
 
 	var results []*TaskResult
-	for _, file := range files {
-		result, err := tm.loadTaskResult(file)
-		if err != nil {
-			fmt.Printf("Failed to load result from file %s: %v\n", file, err)
+	for _, data := range documents {
+		var result TaskResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			logErrorf("Failed to decode result: %v\n", err)
 			continue
 		}
 		// Only include results that have vulnerabilities found
 		if result.FoundVulns > 0 && len(result.Vulnerabilities) > 0 {
-			results = append(results, result)
+			results = append(results, &result)
 		}
 	}
 
 	return results, nil
 }
 
-// Helper methods
+// ListTaskResults returns the task results matching a filter, including results without
+// findings when filter.IncludeEmpty is set, sorted by sortBy (created_at, found_vulns or status;
+// prefix "-" for descending)
+func (tm *JSONTaskManager) ListTaskResults(sortBy string, filter models.TaskResultFilter) ([]*TaskResult, error) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
 
-func (tm *JSONTaskManager) saveTaskConfig(task *TaskConfig) error {
-	filename := filepath.Join(tm.tasksDir, fmt.Sprintf("task_%d.json", task.ID))
-	data, err := json.MarshalIndent(task, "", "  ")
+	documents, err := tm.db.ListTaskResults(sortBy, filter)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to list results: %w", err)
 	}
-	return os.WriteFile(filename, data, 0644)
+
+	results := []*TaskResult{}
+	for _, data := range documents {
+		var result TaskResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			logErrorf("Failed to decode result: %v\n", err)
+			continue
+		}
+		if filter.From != nil && result.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && result.CreatedAt.After(*filter.To) {
+			continue
+		}
+		results = append(results, &result)
+	}
+
+	return results, nil
 }
 
-func (tm *JSONTaskManager) loadTaskConfig(taskID int64) (*TaskConfig, error) {
-	filename := filepath.Join(tm.tasksDir, fmt.Sprintf("task_%d.json", taskID))
-	return tm.loadTaskConfigFromFile(filename)
+// ListTasks returns the tasks matching a filter, sorted by sortBy (created_at, updated_at,
+// end_time, name, found_vulns or status; prefix "-" for descending)
+func (tm *JSONTaskManager) ListTasks(sortBy string, filter models.TaskFilter) ([]*TaskConfig, error) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	documents, err := tm.db.ListTasksFiltered(sortBy, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	tasks := []*TaskConfig{}
+	for _, data := range documents {
+		task, err := tm.decodeTask(data)
+		if err != nil {
+			logErrorf("Failed to decode task: %v\n", err)
+			continue
+		}
+		if filter.From != nil && task.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && task.CreatedAt.After(*filter.To) {
+			continue
+		}
+		if !task.hasLabels(filter.Labels) {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// TaskLabelCount is a task label with the number of tasks carrying it
+type TaskLabelCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
 }
 
-func (tm *JSONTaskManager) loadTaskConfigFromFile(filename string) (*TaskConfig, error) {
-	data, err := os.ReadFile(filename)
+// GetTaskLabels returns the labels used on tasks, most used first
+func (tm *JSONTaskManager) GetTaskLabels() ([]*TaskLabelCount, error) {
+	tasks, err := tm.GetAllTasks()
 	if err != nil {
 		return nil, err
 	}
 
-	var task TaskConfig
-	if err := json.Unmarshal(data, &task); err != nil {
-		return nil, err
+	byName := make(map[string]*TaskLabelCount)
+	labels := []*TaskLabelCount{}
+	for _, task := range tasks {
+		for _, label := range task.Labels {
+			key := strings.ToLower(label)
+			if count, ok := byName[key]; ok {
+				count.Count++
+				continue
+			}
+			count := &TaskLabelCount{Name: label, Count: 1}
+			byName[key] = count
+			labels = append(labels, count)
+		}
+	}
+	sort.SliceStable(labels, func(i, j int) bool {
+		if labels[i].Count != labels[j].Count {
+			return labels[i].Count > labels[j].Count
+		}
+		return strings.ToLower(labels[i].Name) < strings.ToLower(labels[j].Name)
+	})
+	return labels, nil
+}
+
+// hasLabels reports whether the task carries all of the labels (case-insensitive)
+func (task *TaskConfig) hasLabels(labels []string) bool {
+	for _, wanted := range labels {
+		wanted = strings.TrimSpace(wanted)
+		if wanted == "" {
+			continue
+		}
+		found := false
+		for _, label := range task.Labels {
+			if strings.EqualFold(label, wanted) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
 	}
+	return true
+}
+
+// Helper methods
 
-	return &task, nil
+// resultFileName is the name of a task's result in exports and archives
+func resultFileName(taskID int64) string {
+	return fmt.Sprintf("task_%d_result.json", taskID)
 }
 
-func (tm *JSONTaskManager) loadTaskResult(filename string) (*TaskResult, error) {
-	data, err := os.ReadFile(filename)
+// httpLogsFileName is the name of a task's HTTP request logs in exports and archives
+func httpLogsFileName(taskID int64) string {
+	return fmt.Sprintf("task_%d_http_logs.json", taskID)
+}
+
+func (tm *JSONTaskManager) saveTaskConfig(task *TaskConfig) error {
+	data, err := tm.encodeTask(task)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	return tm.db.SaveTask(&database.TaskRecord{
+		ID:         task.ID,
+		Name:       task.Name,
+		Status:     task.Status,
+		FoundVulns: task.FoundVulns,
+		Archived:   task.Archived,
+		CreatedAt:  task.CreatedAt,
+		UpdatedAt:  task.UpdatedAt,
+		EndTime:    task.EndTime,
+		Data:       data,
+	})
+}
 
-	var result TaskResult
-	if err := json.Unmarshal(data, &result); err != nil {
+func (tm *JSONTaskManager) loadTaskConfig(taskID int64) (*TaskConfig, error) {
+	data, err := tm.db.GetTask(taskID)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, fmt.Errorf("task %d not found", taskID)
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	return &result, nil
+	return tm.decodeTask(data)
 }
 
 func (tm *JSONTaskManager) saveTaskResult(result *TaskResult) error {
-	data, err := json.MarshalIndent(result, "", "  ")
+	data, err := json.Marshal(result)
 	if err != nil {
 		return err
 	}
-
-	// Create result file path
-	resultFile := filepath.Join(tm.resultsDir, fmt.Sprintf("task_%d_result.json", result.TaskID))
-	return os.WriteFile(resultFile, data, 0644)
+	return tm.db.SaveTaskResult(result.TaskID, result.Status, result.FoundVulns, result.CreatedAt, data)
 }
 
 // SaveHTTPRequestLogs saves HTTP request logs for a task
 func (tm *JSONTaskManager) SaveHTTPRequestLogs(taskID int64, logs []*HTTPRequestLog) error {
-	data, err := json.MarshalIndent(logs, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal HTTP logs: %w", err)
+	records := make([]*database.HTTPLogRecord, 0, len(logs))
+	for i, log := range logs {
+		data, err := json.Marshal(log)
+		if err != nil {
+			return fmt.Errorf("failed to marshal HTTP logs: %w", err)
+		}
+		records = append(records, &database.HTTPLogRecord{
+			Seq:         int64(i + 1),
+			TemplateID:  log.TemplateID,
+			Target:      log.Target,
+			StatusCode:  log.StatusCode,
+			IsVulnFound: log.IsVulnFound,
+			Data:        data,
+		})
 	}
 
-	httpLogsFile := filepath.Join(tm.logsDir, fmt.Sprintf("task_%d_http_logs.json", taskID))
-	if err := os.WriteFile(httpLogsFile, data, 0644); err != nil {
+	if err := tm.db.ReplaceHTTPLogs(taskID, records); err != nil {
 		return fmt.Errorf("failed to write HTTP logs: %w", err)
 	}
 
-	fmt.Printf("✅ HTTP请求日志已保存: %s (%d 条记录)\n", httpLogsFile, len(logs))
+	logInfof("✅ HTTP请求日志已保存: 任务 %d (%d 条记录)\n", taskID, len(logs))
 	return nil
 }
 
 // GetHTTPRequestLogs returns HTTP request logs for a task
 func (tm *JSONTaskManager) GetHTTPRequestLogs(taskID int64) ([]*HTTPRequestLog, error) {
-	httpLogsFile := filepath.Join(tm.logsDir, fmt.Sprintf("task_%d_http_logs.json", taskID))
-
-	// Check if file exists
-	if _, err := os.Stat(httpLogsFile); os.IsNotExist(err) {
-		// Return empty list if file doesn't exist
-		return []*HTTPRequestLog{}, nil
-	}
-
-	data, err := os.ReadFile(httpLogsFile)
+	documents, err := tm.db.GetHTTPLogs(taskID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read HTTP logs: %w", err)
 	}
 
-	var logs []*HTTPRequestLog
-	if err := json.Unmarshal(data, &logs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal HTTP logs: %w", err)
+	logs := []*HTTPRequestLog{}
+	if len(documents) == 0 {
+		// 已归档任务从压缩包中读取
+		if data, err := tm.readArchivedFile(taskID, "logs/"+httpLogsFileName(taskID)); err == nil {
+			if err := json.Unmarshal(data, &logs); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal HTTP logs: %w", err)
+			}
+		}
+		return logs, nil
+	}
+
+	for _, data := range documents {
+		var log HTTPRequestLog
+		if err := json.Unmarshal(data, &log); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal HTTP logs: %w", err)
+		}
+		logs = append(logs, &log)
 	}
 
 	return logs, nil
@@ -0,0 +1,50 @@
+package scanner
+
+import (
+	"bufio"
+	"io"
+)
+
+const (
+	// initialLineBuffer is the initial buffer of nuclei output scanners
+	initialLineBuffer = 64 * 1024
+	// maxOutputLineSize bounds a single line of nuclei output; debug dumps and JSONL records
+	// with -include-rr carry whole responses, far beyond bufio's default 64KB
+	maxOutputLineSize = 16 * 1024 * 1024
+)
+
+// newLineScanner returns a line scanner for nuclei output (pipes and JSONL files). Lines
+// longer than maxOutputLineSize are cut instead of stopping the scanner with ErrTooLong,
+// which would leave the rest of the output unread (and a nuclei pipe blocked).
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, initialLineBuffer), maxOutputLineSize)
+	scanner.Split(truncatingLines(maxOutputLineSize))
+	return scanner
+}
+
+// truncatingLines is bufio.ScanLines for lines up to maxLine bytes: the first maxLine bytes
+// of a longer line are returned and the rest of it is discarded
+func truncatingLines(maxLine int) bufio.SplitFunc {
+	discarding := false
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if advance > 0 || token != nil || err != nil {
+			if discarding {
+				// 超长行的剩余部分
+				discarding = false
+				return advance, nil, err
+			}
+			return advance, token, err
+		}
+		if len(data) < maxLine {
+			return 0, nil, nil // 继续读取
+		}
+		if discarding {
+			return len(data), nil, nil
+		}
+		discarding = true
+		logWarnf("⚠️ nuclei输出中有超过 %dMB 的行，已截断\n", maxLine/(1024*1024))
+		return len(data), data[:maxLine], nil
+	}
+}
@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"sort"
+
+	"wepoc/internal/i18n"
+)
+
+// LiveProgress is the current state of a task for (re)attaching a progress display
+type LiveProgress struct {
+	TaskID   int64         `json:"task_id"`
+	Running  bool          `json:"running"`  // 是否正在扫描
+	Status   string        `json:"status"`   // 任务状态
+	Progress *ScanProgress `json:"progress"` // 进度快照
+	LastSeq  int64         `json:"last_seq"` // 已发出的最后一个事件序号，之后的事件可通过 GetTaskEvents 补取
+
+	Performance *PerformanceInfo `json:"performance,omitempty"` // nuclei进程的CPU/内存占用
+}
+
+// snapshotProgress returns a copy of the current progress
+func (sns *SimpleNucleiScanner) snapshotProgress() *ScanProgress {
+	sns.progressMu.Lock()
+	defer sns.progressMu.Unlock()
+
+	progress := *sns.progress
+	progress.SelectedTemplates = append([]string{}, sns.progress.SelectedTemplates...)
+	progress.ScannedTemplateIDs = append([]string{}, sns.progress.ScannedTemplateIDs...)
+	progress.FailedTemplateIDs = append([]string{}, sns.progress.FailedTemplateIDs...)
+	progress.FilteredTemplateIDs = append([]string{}, sns.progress.FilteredTemplateIDs...)
+	progress.SkippedTemplateIDs = append([]string{}, sns.progress.SkippedTemplateIDs...)
+	return &progress
+}
+
+// lastEventSeq returns the sequence number of the last emitted event
+func (sns *SimpleNucleiScanner) lastEventSeq() int64 {
+	sns.eventMu.Lock()
+	defer sns.eventMu.Unlock()
+
+	if sns.eventLog == nil {
+		return 0
+	}
+	sns.eventLog.mu.Lock()
+	defer sns.eventLog.mu.Unlock()
+	return sns.eventLog.seq
+}
+
+// trackScanner records a running scanner so its live progress can be queried
+func (tm *JSONTaskManager) trackScanner(taskID int64, scanner *SimpleNucleiScanner) {
+	tm.runningMu.Lock()
+	defer tm.runningMu.Unlock()
+	tm.running[taskID] = scanner
+}
+
+// untrackScanner forgets a finished scanner
+func (tm *JSONTaskManager) untrackScanner(taskID int64) {
+	tm.runningMu.Lock()
+	defer tm.runningMu.Unlock()
+	delete(tm.running, taskID)
+}
+
+// runningScanner returns the scanner of a running task
+func (tm *JSONTaskManager) runningScanner(taskID int64) (*SimpleNucleiScanner, bool) {
+	tm.runningMu.RLock()
+	defer tm.runningMu.RUnlock()
+	scanner, ok := tm.running[taskID]
+	return scanner, ok
+}
+
+// RunningTaskIDs returns the IDs of tasks currently being scanned, in ascending order
+func (tm *JSONTaskManager) RunningTaskIDs() []int64 {
+	tm.runningMu.RLock()
+	defer tm.runningMu.RUnlock()
+
+	ids := make([]int64, 0, len(tm.running))
+	for taskID := range tm.running {
+		ids = append(ids, taskID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// GetLiveProgress returns the in-memory progress of a running task, or the stored state
+// of a task that is not running
+func (tm *JSONTaskManager) GetLiveProgress(taskID int64) (*LiveProgress, error) {
+	if scanner, ok := tm.runningScanner(taskID); ok {
+		progress := scanner.snapshotProgress()
+		return &LiveProgress{
+			TaskID:   taskID,
+			Running:  true,
+			Status:   progress.Status,
+			Progress: progress,
+			LastSeq:  scanner.lastEventSeq(),
+
+			Performance: scanner.Performance(),
+		}, nil
+	}
+
+	task, err := tm.GetTaskByID(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+
+	// 优先使用扫描时保存的进度快照（包含模板统计等详细信息）
+	if progress := tm.storedProgress(taskID); progress != nil {
+		progress.Status = task.Status
+		return &LiveProgress{TaskID: taskID, Status: task.Status, Progress: progress}, nil
+	}
+
+	progress := &ScanProgress{
+		TaskID:            taskID,
+		TotalRequests:     task.TotalRequests,
+		CompletedRequests: task.CompletedRequests,
+		FoundVulns:        task.FoundVulns,
+		Status:            task.Status,
+		TotalTemplates:    len(task.POCs),
+		SelectedTemplates: append([]string{}, task.POCs...),
+		ETASeconds:        -1,
+	}
+	if task.TotalRequests > 0 {
+		progress.Percentage = float64(task.CompletedRequests) / float64(task.TotalRequests) * 100
+	}
+	if task.Status == "completed" {
+		progress.Percentage = 100
+		progress.ETASeconds = 0
+	}
+
+	return &LiveProgress{TaskID: taskID, Status: task.Status, Progress: progress}, nil
+}
+
+// ReattachEventHandlers registers handler for every running task (e.g. after the frontend
+// reloaded) and returns their live progress
+func (tm *JSONTaskManager) ReattachEventHandlers(handler func(*ScanEvent)) []*LiveProgress {
+	var result []*LiveProgress
+	for _, taskID := range tm.RunningTaskIDs() {
+		tm.RegisterEventHandler(taskID, handler)
+		if live, err := tm.GetLiveProgress(taskID); err == nil {
+			result = append(result, live)
+		}
+	}
+	return result
+}
+
+// GetRunningTasks returns the tasks currently being scanned, in ascending order of ID
+func (tm *JSONTaskManager) GetRunningTasks() []*TaskConfig {
+	tasks := []*TaskConfig{}
+	for _, taskID := range tm.RunningTaskIDs() {
+		if task, err := tm.GetTaskByID(taskID); err == nil {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
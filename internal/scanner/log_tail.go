@@ -0,0 +1,131 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxTailBytes bounds the log data returned by one TailTaskLog call
+const maxTailBytes = 256 * 1024
+
+// TaskLogTail is a chunk of the raw nuclei output of a task
+type TaskLogTail struct {
+	TaskID  int64    `json:"task_id"`
+	File    string   `json:"file"`    // 调试日志文件名，重新扫描会生成新文件
+	Lines   []string `json:"lines"`   // 新增的完整行
+	Offset  int64    `json:"offset"`  // 下次调用传入的偏移量
+	Size    int64    `json:"size"`    // 日志文件当前大小
+	More    bool     `json:"more"`    // 还有未返回的数据（单次最多返回256KB）
+	Running bool     `json:"running"` // 任务是否仍在扫描
+}
+
+// TaskLogLine is a raw nuclei output line streamed while a task runs
+type TaskLogLine struct {
+	TaskID    int64     `json:"task_id"`
+	Line      string    `json:"line"`
+	Stderr    bool      `json:"stderr"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// TailTaskLog returns the complete lines written to the task's nuclei debug log after
+// fromOffset. An offset beyond the end of the file (a new scan started a new log) starts over.
+func (tm *JSONTaskManager) TailTaskLog(taskID int64, fromOffset int64) (*TaskLogTail, error) {
+	tail := &TaskLogTail{TaskID: taskID, Lines: []string{}, Offset: fromOffset}
+
+	path := ""
+	if scanner, ok := tm.runningScanner(taskID); ok {
+		tail.Running = true
+		path = scanner.debugLogFile
+	}
+	if path == "" {
+		matches, _ := filepath.Glob(filepath.Join(tm.logsDir, fmt.Sprintf("scan_debug_%d_*.log", taskID)))
+		if len(matches) == 0 {
+			return tail, nil
+		}
+		// 文件名中的时间戳格式可按字典序排序
+		sort.Strings(matches)
+		path = matches[len(matches)-1]
+	}
+	tail.File = filepath.Base(path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tail, nil
+		}
+		return nil, fmt.Errorf("failed to open debug log: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat debug log: %w", err)
+	}
+	tail.Size = info.Size()
+	if fromOffset < 0 || fromOffset > tail.Size {
+		fromOffset = 0
+	}
+	tail.Offset = fromOffset
+
+	data := make([]byte, min(tail.Size-fromOffset, maxTailBytes))
+	n, err := file.ReadAt(data, fromOffset)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read debug log: %w", err)
+	}
+	data = data[:n]
+
+	// 只返回完整的行，未写完的行留到下次；超长的单行直接返回
+	end := bytes.LastIndexByte(data, '\n') + 1
+	if end == 0 && len(data) == maxTailBytes {
+		end = len(data)
+	}
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			tail.Lines = append(tail.Lines, line)
+		}
+	}
+	tail.Offset = fromOffset + int64(end)
+	tail.More = tail.Offset < tail.Size && end == len(data) && len(data) == maxTailBytes
+	return tail, nil
+}
+
+// SetLogLineHandler sets the callback receiving the nuclei output lines of tasks whose log
+// streaming is enabled
+func (tm *JSONTaskManager) SetLogLineHandler(handler func(*TaskLogLine)) {
+	tm.handlersMu.Lock()
+	defer tm.handlersMu.Unlock()
+	tm.logLineHandler = handler
+}
+
+// SetTaskLogStreaming turns streaming of a task's raw nuclei output on or off; it is off by
+// default so that the output does not flood the UI
+func (tm *JSONTaskManager) SetTaskLogStreaming(taskID int64, enabled bool) {
+	tm.handlersMu.Lock()
+	defer tm.handlersMu.Unlock()
+	if tm.logStreams == nil {
+		tm.logStreams = make(map[int64]bool)
+	}
+	if enabled {
+		tm.logStreams[taskID] = true
+	} else {
+		delete(tm.logStreams, taskID)
+	}
+}
+
+// streamLogLine forwards a nuclei output line when streaming is enabled for the task
+func (tm *JSONTaskManager) streamLogLine(taskID int64, line string, isStderr bool) {
+	tm.handlersMu.RLock()
+	handler := tm.logLineHandler
+	enabled := tm.logStreams[taskID]
+	tm.handlersMu.RUnlock()
+
+	if enabled && handler != nil {
+		handler(&TaskLogLine{TaskID: taskID, Line: line, Stderr: isStderr, Timestamp: time.Now()})
+	}
+}
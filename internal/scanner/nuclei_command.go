@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// nucleiCommandFile is the name of the recorded command in a task's output directory
+const nucleiCommandFile = "nuclei_command.json"
+
+// NucleiCommand is the nuclei invocation of the last scan of a task, recorded so that the
+// scan can be reproduced outside wepoc; secrets (proxy passwords, tokens, header values) are masked
+type NucleiCommand struct {
+	TaskID      int64     `json:"task_id"`
+	RecordedAt  time.Time `json:"recorded_at"`   // 扫描开始时间
+	Executable  string    `json:"executable"`    // nuclei路径
+	Args        []string  `json:"args"`          // 命令参数（已脱敏）
+	CommandLine string    `json:"command_line"`  // 可直接在shell中执行的完整命令
+	WorkDir     string    `json:"work_dir"`      // 工作目录
+	Env         []string  `json:"env,omitempty"` // 额外设置的环境变量名
+	Templates   []string  `json:"templates"`     // 使用的模板文件
+	TargetsFile string    `json:"targets_file"`  // 目标列表文件（扫描结束后删除）
+	Targets     string    `json:"targets"`       // 目标列表文件内容
+}
+
+// recordNucleiCommand saves the command about to be run together with the templates and the
+// content of the targets file, which is removed once the scan ends
+func (sns *SimpleNucleiScanner) recordNucleiCommand(cmd *exec.Cmd, targetsFile, outputDir string) {
+	targets, err := os.ReadFile(targetsFile)
+	if err != nil {
+		logWarnf("⚠️ 读取目标列表文件失败: %v\n", err)
+	}
+
+	args := MaskCommandArgs(cmd.Args[1:])
+	record := &NucleiCommand{
+		TaskID:      sns.task.ID,
+		RecordedAt:  time.Now(),
+		Executable:  sns.nucleiPath,
+		Args:        args,
+		CommandLine: commandLine(sns.nucleiPath, args),
+		WorkDir:     cmd.Dir,
+		Templates:   sns.templateFiles(),
+		TargetsFile: targetsFile,
+		Targets:     string(targets),
+	}
+	for _, env := range sns.extraEnv {
+		name, _, _ := strings.Cut(env, "=")
+		record.Env = append(record.Env, name)
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(outputDir, nucleiCommandFile), data, 0644)
+	}
+	if err != nil {
+		logWarnf("⚠️ 保存Nuclei命令失败: %v\n", err)
+	}
+}
+
+// GetTaskNucleiCommand returns the nuclei command line, template files and targets used by
+// the last scan of a task
+func (tm *JSONTaskManager) GetTaskNucleiCommand(taskID int64) (*NucleiCommand, error) {
+	if _, err := tm.GetTaskByID(taskID); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(tm.resultsDir, fmt.Sprintf("task_%d", taskID), nucleiCommandFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("任务尚未执行，没有记录的Nuclei命令")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nuclei command: %w", err)
+	}
+
+	var record NucleiCommand
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode nuclei command: %w", err)
+	}
+	return &record, nil
+}
+
+// commandLine joins an executable and its arguments into a shell command, quoting the
+// arguments that need it
+func commandLine(executable string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{executable}, args...) {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`*?;&|<>()[]{}!#~") {
+			arg = shellQuote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"wepoc/internal/i18n"
+	"wepoc/internal/models"
+)
+
+// offlineMode mirrors Config.Offline for the code paths that have no access to the config
+// (template validation, template sources, search engines...)
+var offlineMode atomic.Bool
+
+// SetOfflineMode turns the air-gapped mode on or off
+func SetOfflineMode(offline bool) {
+	if offlineMode.Swap(offline) != offline {
+		if offline {
+			logInfof("✈️ 离线模式已开启，更新检查和需要访问外网的功能已停用\n")
+		} else {
+			logInfof("🌐 离线模式已关闭\n")
+		}
+	}
+}
+
+// IsOfflineMode reports whether the air-gapped mode is on
+func IsOfflineMode() bool {
+	return offlineMode.Load()
+}
+
+// requireOnline returns ErrOffline while the air-gapped mode is on
+func requireOnline() error {
+	if offlineMode.Load() {
+		return i18n.Errorf(i18n.ErrOffline)
+	}
+	return nil
+}
+
+// updateCheckArgs returns -disable-update-check in offline mode, so that nuclei never
+// contacts the update servers
+func updateCheckArgs() []string {
+	if offlineMode.Load() {
+		return []string{"-disable-update-check"}
+	}
+	return nil
+}
+
+// forwardingConfig returns the forwarding connectors to run; notifications are not sent in
+// offline mode
+func forwardingConfig(config *models.Config) models.ForwardingConfig {
+	if config.Offline {
+		return models.ForwardingConfig{}
+	}
+	return config.Forwarding
+}
+
+// isLocalGitSource reports whether a repository address points to the local file system
+// (a path or file:// URL), which stays usable in offline mode
+func isLocalGitSource(repoURL string) bool {
+	if strings.HasPrefix(repoURL, "file://") {
+		return true
+	}
+	return !strings.Contains(repoURL, "://") && !strings.Contains(repoURL, "@")
+}
@@ -0,0 +1,389 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OOBInteraction represents an out-of-band interaction (DNS/HTTP/SMTP...) correlated to a task
+type OOBInteraction struct {
+	ID            int64     `json:"id"`        // 交互序号
+	TaskID        int64     `json:"task_id"`   // 所属任务ID
+	Protocol      string    `json:"protocol"`  // 协议：dns/http/smtp/ldap...
+	UniqueID      string    `json:"unique_id"` // 关联ID（correlation-id + nonce）
+	FullID        string    `json:"full_id"`   // 完整子域名标识
+	QType         string    `json:"q_type,omitempty"`
+	RawRequest    string    `json:"raw_request"`  // 原始请求
+	RawResponse   string    `json:"raw_response"` // 原始响应
+	SMTPFrom      string    `json:"smtp_from,omitempty"`
+	RemoteAddress string    `json:"remote_address"` // 来源地址
+	Timestamp     time.Time `json:"timestamp"`      // 交互时间
+	TemplateID    string    `json:"template_id"`    // 生成载荷的POC模板
+	Target        string    `json:"target"`         // 生成载荷的目标
+	Source        string    `json:"source"`         // 来源：nuclei（扫描结果）/ wepoc（内置客户端）
+}
+
+// oobPayload records which template/target a generated payload belongs to
+type oobPayload struct {
+	TemplateID string
+	Target     string
+}
+
+// oobSession is an interactsh session owned by a single task
+type oobSession struct {
+	client   *InteractshClient
+	payloads map[string]oobPayload // unique-id -> 来源
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+}
+
+// OOBManager keeps interactsh sessions per task and stores the received interactions
+type OOBManager struct {
+	logsDir      string
+	pollInterval time.Duration
+	sessions     map[int64]*oobSession
+	mu           sync.Mutex
+	fileMu       sync.Mutex
+	writeFile    func(path string, data []byte) error // 加密写入交互记录，见SetFileCipher
+	readFile     func(path string) ([]byte, error)
+	handler      func(*OOBInteraction)
+	handlerMu    sync.RWMutex
+}
+
+// NewOOBManager creates a new OOB manager storing interactions under logsDir
+func NewOOBManager(logsDir string) *OOBManager {
+	return &OOBManager{
+		logsDir:      logsDir,
+		pollInterval: 5 * time.Second,
+		sessions:     make(map[int64]*oobSession),
+	}
+}
+
+// SetHandler sets the callback invoked for every new interaction
+func (m *OOBManager) SetHandler(handler func(*OOBInteraction)) {
+	m.handlerMu.Lock()
+	defer m.handlerMu.Unlock()
+	m.handler = handler
+}
+
+// SetFileCipher sets the functions encrypting the interaction logs as they are written and
+// reading them back (see JSONTaskManager.SetFileCipher)
+func (m *OOBManager) SetFileCipher(write func(path string, data []byte) error, read func(path string) ([]byte, error)) {
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+	m.writeFile = write
+	m.readFile = read
+}
+
+// session returns the task's session, registering a new one if needed
+func (m *OOBManager) session(taskID int64, server, token string) (*oobSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, exists := m.sessions[taskID]; exists {
+		return s, nil
+	}
+
+	client, err := NewInteractshClient(server, token)
+	if err != nil {
+		return nil, err
+	}
+
+	registerCtx, cancelRegister := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancelRegister()
+	if err := client.Register(registerCtx); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &oobSession{
+		client:   client,
+		payloads: make(map[string]oobPayload),
+		cancel:   cancel,
+	}
+	m.sessions[taskID] = s
+
+	logInfof("📡 任务 %d 已注册Interactsh会话: %s\n", taskID, client.Domain())
+	go m.pollLoop(ctx, taskID, s)
+
+	return s, nil
+}
+
+// GeneratePayload returns a new OOB host bound to the given template and target
+func (m *OOBManager) GeneratePayload(taskID int64, server, token, templateID, target string) (string, error) {
+	s, err := m.session(taskID, server, token)
+	if err != nil {
+		return "", err
+	}
+
+	host, uniqueID, err := s.client.NewPayload()
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.payloads[uniqueID] = oobPayload{TemplateID: templateID, Target: target}
+	s.mu.Unlock()
+
+	return host, nil
+}
+
+// pollLoop polls the interactsh server until the session is stopped
+func (m *OOBManager) pollLoop(ctx context.Context, taskID int64, s *oobSession) {
+	ticker := time.NewTicker(m.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			events, err := s.client.Poll(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					logWarnf("⚠️ 任务 %d 轮询Interactsh失败: %v\n", taskID, err)
+				}
+				continue
+			}
+			for _, event := range events {
+				m.record(m.correlate(taskID, s, event))
+			}
+		}
+	}
+}
+
+// correlate maps a raw interaction back to the template/target that generated it
+func (m *OOBManager) correlate(taskID int64, s *oobSession, event *interactshEvent) *OOBInteraction {
+	interaction := &OOBInteraction{
+		TaskID:        taskID,
+		Protocol:      event.Protocol,
+		UniqueID:      event.UniqueID,
+		FullID:        event.FullID,
+		QType:         event.QType,
+		RawRequest:    event.RawRequest,
+		RawResponse:   event.RawResponse,
+		SMTPFrom:      event.SMTPFrom,
+		RemoteAddress: event.RemoteAddress,
+		Timestamp:     event.Timestamp,
+		Source:        "wepoc",
+	}
+	if interaction.Timestamp.IsZero() {
+		interaction.Timestamp = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if payload, ok := s.payloads[strings.ToLower(event.UniqueID)]; ok {
+		interaction.TemplateID = payload.TemplateID
+		interaction.Target = payload.Target
+		return interaction
+	}
+	fullID := strings.ToLower(event.FullID)
+	for uniqueID, payload := range s.payloads {
+		if strings.Contains(fullID, uniqueID) {
+			interaction.TemplateID = payload.TemplateID
+			interaction.Target = payload.Target
+			break
+		}
+	}
+	return interaction
+}
+
+// RecordNucleiInteraction stores an interaction reported by nuclei for a matched template
+func (m *OOBManager) RecordNucleiInteraction(taskID int64, templateID, target string, data map[string]interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	event := &interactshEvent{}
+	if err := json.Unmarshal(raw, event); err != nil {
+		return
+	}
+
+	interaction := &OOBInteraction{
+		TaskID:        taskID,
+		Protocol:      event.Protocol,
+		UniqueID:      event.UniqueID,
+		FullID:        event.FullID,
+		QType:         event.QType,
+		RawRequest:    event.RawRequest,
+		RawResponse:   event.RawResponse,
+		SMTPFrom:      event.SMTPFrom,
+		RemoteAddress: event.RemoteAddress,
+		Timestamp:     event.Timestamp,
+		TemplateID:    templateID,
+		Target:        target,
+		Source:        "nuclei",
+	}
+	if interaction.Timestamp.IsZero() {
+		interaction.Timestamp = time.Now()
+	}
+	m.record(interaction)
+}
+
+// record persists the interaction and notifies the handler
+func (m *OOBManager) record(interaction *OOBInteraction) {
+	if err := m.save(interaction); err != nil {
+		logWarnf("⚠️ 保存OOB交互记录失败: %v\n", err)
+	}
+
+	m.handlerMu.RLock()
+	handler := m.handler
+	m.handlerMu.RUnlock()
+	if handler != nil {
+		handler(interaction)
+	}
+}
+
+// interactionsFile returns the path of the task's interaction log
+func (m *OOBManager) interactionsFile(taskID int64) string {
+	return filepath.Join(m.logsDir, fmt.Sprintf("task_%d_oob.json", taskID))
+}
+
+// sealInteractions encrypts an interaction log written before encryption was turned on,
+// without racing a new interaction being saved
+func (m *OOBManager) sealInteractions(path string, seal func(path string) error) error {
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+	return seal(path)
+}
+
+// GetInteractions returns all interactions recorded for a task
+func (m *OOBManager) GetInteractions(taskID int64) ([]*OOBInteraction, error) {
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+	return m.load(taskID)
+}
+
+// load reads the interaction log (caller holds fileMu)
+func (m *OOBManager) load(taskID int64) ([]*OOBInteraction, error) {
+	read := m.readFile
+	if read == nil {
+		read = os.ReadFile
+	}
+	data, err := read(m.interactionsFile(taskID))
+	if os.IsNotExist(err) {
+		return []*OOBInteraction{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OOB interactions: %w", err)
+	}
+
+	var interactions []*OOBInteraction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal OOB interactions: %w", err)
+	}
+	return interactions, nil
+}
+
+// save appends an interaction to the task's log
+func (m *OOBManager) save(interaction *OOBInteraction) error {
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+
+	interactions, err := m.load(interaction.TaskID)
+	if err != nil {
+		return err
+	}
+
+	interaction.ID = 1
+	if len(interactions) > 0 {
+		interaction.ID = interactions[len(interactions)-1].ID + 1
+	}
+	interactions = append(interactions, interaction)
+
+	data, err := json.MarshalIndent(interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal OOB interactions: %w", err)
+	}
+	if m.writeFile != nil {
+		return m.writeFile(m.interactionsFile(interaction.TaskID), data)
+	}
+	return writePrivateFile(m.interactionsFile(interaction.TaskID), data)
+}
+
+// StopSession stops polling and deregisters the task's session
+func (m *OOBManager) StopSession(taskID int64) {
+	m.mu.Lock()
+	s, exists := m.sessions[taskID]
+	delete(m.sessions, taskID)
+	m.mu.Unlock()
+
+	if !exists {
+		return
+	}
+	s.cancel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.client.Deregister(ctx); err != nil {
+		logWarnf("⚠️ 注销任务 %d 的Interactsh会话失败: %v\n", taskID, err)
+	}
+}
+
+// Close stops all sessions
+func (m *OOBManager) Close() {
+	m.mu.Lock()
+	taskIDs := make([]int64, 0, len(m.sessions))
+	for taskID := range m.sessions {
+		taskIDs = append(taskIDs, taskID)
+	}
+	m.mu.Unlock()
+
+	for _, taskID := range taskIDs {
+		m.StopSession(taskID)
+	}
+}
+
+// GenerateOOBPayload returns an OOB host for manual testing, correlated to the task/template/target
+func (tm *JSONTaskManager) GenerateOOBPayload(taskID int64, templateID, target string) (string, error) {
+	server, token := tm.interactshSettings(taskID)
+	if strings.TrimSpace(server) == "" || server == DefaultInteractshServer {
+		// 离线模式下只能使用自建的Interactsh服务器
+		if err := requireOnline(); err != nil {
+			return "", err
+		}
+	}
+	return tm.oob.GeneratePayload(taskID, server, token, templateID, target)
+}
+
+// interactshSettings returns the interactsh server and token used for a task,
+// preferring the task's own settings over the global configuration
+func (tm *JSONTaskManager) interactshSettings(taskID int64) (string, string) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	if task, err := tm.loadTaskConfig(taskID); err == nil && task.Options.InteractshServer != "" {
+		return task.Options.InteractshServer, task.Options.InteractshToken
+	}
+	if tm.config == nil {
+		return DefaultInteractshServer, ""
+	}
+	return tm.config.NucleiConfig.InteractshServer, tm.config.NucleiConfig.InteractshToken
+}
+
+// GetOOBInteractions returns the OOB interactions recorded for a task
+func (tm *JSONTaskManager) GetOOBInteractions(taskID int64) ([]*OOBInteraction, error) {
+	return tm.oob.GetInteractions(taskID)
+}
+
+// SetOOBHandler sets the callback for newly received OOB interactions
+func (tm *JSONTaskManager) SetOOBHandler(handler func(*OOBInteraction)) {
+	tm.oob.SetHandler(handler)
+}
+
+// StopOOBSession stops the interactsh session of a task
+func (tm *JSONTaskManager) StopOOBSession(taskID int64) {
+	tm.oob.StopSession(taskID)
+}
+
+// CloseOOBSessions deregisters all interactsh sessions (called on shutdown)
+func (tm *JSONTaskManager) CloseOOBSessions() {
+	tm.oob.Close()
+}
@@ -0,0 +1,209 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pocDebugTimeout bounds a single POC debug run, like the blocking single POC test
+const pocDebugTimeout = 5 * time.Minute
+
+// POC debug event types
+const (
+	DebugEventRequest  = "request"  // nuclei发出的请求原文
+	DebugEventResponse = "response" // 收到的响应原文
+	DebugEventResult   = "result"   // 命中结果（JSONL）
+	DebugEventLog      = "log"      // 其他nuclei输出
+	DebugEventDone     = "done"     // 运行结束，Status为 completed/cancelled/timeout/failed
+)
+
+// POCDebugEvent is an item of nuclei output streamed while a single POC is debugged
+type POCDebugEvent struct {
+	SessionID  string                 `json:"session_id"`
+	Seq        int                    `json:"seq"`
+	Type       string                 `json:"type"`
+	TemplateID string                 `json:"template_id,omitempty"`
+	Protocol   string                 `json:"protocol,omitempty"` // 请求类型（HTTP/DNS/Network...）
+	Target     string                 `json:"target,omitempty"`
+	Content    string                 `json:"content,omitempty"` // 请求/响应原文或日志行
+	Result     map[string]interface{} `json:"result,omitempty"`
+	Status     string                 `json:"status,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	Results    int                    `json:"results,omitempty"` // 结束时的命中数
+	Time       time.Time              `json:"time"`
+}
+
+// dumpHeaderPattern matches the line nuclei -debug prints before a dumped request or
+// response, e.g. "[INF] [tpl] Dumped HTTP request for https://host/path"
+var dumpHeaderPattern = regexp.MustCompile(`\[([^\]]+)\] Dumped (\S+) (request|response)(?: for)? (\S+)`)
+
+// logLinePattern matches regular nuclei log lines, which end a dumped block
+var logLinePattern = regexp.MustCompile(`^\[(INF|WRN|ERR|FTL|DBG|VER)\]`)
+
+// pocDebugSessions holds the cancel functions of running debug sessions
+var pocDebugSessions = struct {
+	cancels map[string]context.CancelFunc
+	mu      sync.Mutex
+}{cancels: make(map[string]context.CancelFunc)}
+
+// StartPOCDebug runs nuclei with -debug in the background and streams its requests,
+// responses, results (args should request -jsonl output) and log lines to emit as they
+// happen. It returns the session ID used to cancel the run; cleanup is called once nuclei exited.
+func StartPOCDebug(nucleiPath string, args []string, emit func(*POCDebugEvent), cleanup func()) (string, error) {
+	sessionID := fmt.Sprintf("debug-%d", time.Now().UnixNano())
+	ctx, cancel := context.WithTimeout(context.Background(), pocDebugTimeout)
+
+	cmd := exec.CommandContext(ctx, nucleiPath, append(args, "-debug", "-nc")...)
+	hideWindowOnWindows(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err == nil {
+		cmd.Stderr = cmd.Stdout // 合并输出，保持请求/响应与日志的先后顺序
+		err = cmd.Start()
+	}
+	if err != nil {
+		cancel()
+		return "", fmt.Errorf("无法启动Nuclei: %w", err)
+	}
+	untrack := TrackProcess(cmd)
+
+	pocDebugSessions.mu.Lock()
+	pocDebugSessions.cancels[sessionID] = cancel
+	pocDebugSessions.mu.Unlock()
+
+	go func() {
+		defer func() {
+			pocDebugSessions.mu.Lock()
+			delete(pocDebugSessions.cancels, sessionID)
+			pocDebugSessions.mu.Unlock()
+			cancel()
+			untrack()
+			if cleanup != nil {
+				cleanup()
+			}
+		}()
+
+		stream := &pocDebugStream{sessionID: sessionID, emit: emit}
+		stream.read(stdout)
+		err := cmd.Wait()
+
+		done := &POCDebugEvent{Type: DebugEventDone, Status: "completed", Results: stream.results}
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			done.Status, done.Error = "timeout", "测试超时（5分钟）"
+		case errors.Is(ctx.Err(), context.Canceled):
+			done.Status = "cancelled"
+		case err != nil && stream.results == 0:
+			// 退出码2通常表示未发现漏洞，仅在没有任何结果时视为失败
+			if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 2 {
+				done.Status, done.Error = "failed", err.Error()
+			}
+		}
+		stream.send(done)
+	}()
+
+	logInfof("🐞 开始调试POC: %s\n", sessionID)
+	return sessionID, nil
+}
+
+// CancelPOCDebug stops a running debug session
+func CancelPOCDebug(sessionID string) error {
+	pocDebugSessions.mu.Lock()
+	cancel, ok := pocDebugSessions.cancels[sessionID]
+	pocDebugSessions.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("调试会话不存在或已结束: %s", sessionID)
+	}
+	cancel()
+	return nil
+}
+
+// pocDebugStream splits nuclei -debug output into events
+type pocDebugStream struct {
+	sessionID string
+	emit      func(*POCDebugEvent)
+	seq       int
+	results   int
+	block     *POCDebugEvent // 正在收集的请求/响应
+	content   strings.Builder
+}
+
+// read consumes the output until nuclei closes it
+func (s *pocDebugStream) read(r io.Reader) {
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		s.line(scanner.Text())
+	}
+	s.flush()
+}
+
+// line handles one output line
+func (s *pocDebugStream) line(line string) {
+	if matches := dumpHeaderPattern.FindStringSubmatch(line); matches != nil {
+		s.flush()
+		eventType := DebugEventRequest
+		if matches[3] == "response" {
+			eventType = DebugEventResponse
+		}
+		s.block = &POCDebugEvent{Type: eventType, TemplateID: matches[1], Protocol: matches[2], Target: matches[4]}
+		return
+	}
+
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &result); err == nil {
+			if _, ok := result["template-id"]; ok {
+				s.flush()
+				s.results++
+				event := &POCDebugEvent{Type: DebugEventResult, Result: result}
+				event.TemplateID, _ = result["template-id"].(string)
+				event.Target, _ = result["matched-at"].(string)
+				s.send(event)
+				return
+			}
+		}
+	}
+
+	if logLinePattern.MatchString(trimmed) {
+		s.flush()
+		s.send(&POCDebugEvent{Type: DebugEventLog, Content: trimmed})
+		return
+	}
+	if s.block != nil {
+		s.content.WriteString(line + "\n")
+		return
+	}
+	if trimmed != "" {
+		s.send(&POCDebugEvent{Type: DebugEventLog, Content: trimmed})
+	}
+}
+
+// flush emits the request or response being collected
+func (s *pocDebugStream) flush() {
+	if s.block == nil {
+		return
+	}
+	s.block.Content = strings.Trim(s.content.String(), "\n")
+	s.send(s.block)
+	s.block = nil
+	s.content.Reset()
+}
+
+// send numbers and emits an event
+func (s *pocDebugStream) send(event *POCDebugEvent) {
+	s.seq++
+	event.SessionID = s.sessionID
+	event.Seq = s.seq
+	event.Time = time.Now()
+	if s.emit != nil {
+		s.emit(event)
+	}
+}
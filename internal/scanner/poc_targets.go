@@ -0,0 +1,139 @@
+package scanner
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// POC test outcome statuses
+const (
+	OutcomeVulnerable    = "vulnerable"     // 命中
+	OutcomeNotVulnerable = "not_vulnerable" // 未命中
+	OutcomeUnreachable   = "unreachable"    // 探测不可达且未命中
+)
+
+// POCTargetOutcome is the outcome of a single POC test for one of its targets
+type POCTargetOutcome struct {
+	Target     string                   `json:"target"`      // 输入的目标
+	ScanTarget string                   `json:"scan_target"` // 探测后实际扫描的目标
+	Status     string                   `json:"status"`
+	Error      string                   `json:"error,omitempty"` // 探测错误
+	Results    []map[string]interface{} `json:"results"`
+}
+
+// SinglePOCOutcomes assigns the results of a single POC test run against several targets
+// to the targets they were found on
+func SinglePOCOutcomes(probes []*ProbeResult, results []map[string]interface{}) []*POCTargetOutcome {
+	outcomes := make([]*POCTargetOutcome, 0, len(probes))
+	for _, probe := range probes {
+		outcomes = append(outcomes, &POCTargetOutcome{
+			Target:     probe.Input,
+			ScanTarget: probe.Target,
+			Status:     OutcomeNotVulnerable,
+			Error:      probe.Error,
+			Results:    []map[string]interface{}{},
+		})
+	}
+
+	for _, result := range results {
+		if outcome := matchOutcome(outcomes, result); outcome != nil {
+			outcome.Results = append(outcome.Results, result)
+		}
+	}
+
+	for i, outcome := range outcomes {
+		switch {
+		case len(outcome.Results) > 0:
+			outcome.Status = OutcomeVulnerable
+		case !probes[i].Reachable:
+			outcome.Status = OutcomeUnreachable
+		}
+	}
+	return outcomes
+}
+
+// matchOutcome finds the target a result belongs to; a single target takes every result
+func matchOutcome(outcomes []*POCTargetOutcome, result map[string]interface{}) *POCTargetOutcome {
+	if len(outcomes) == 1 {
+		return outcomes[0]
+	}
+	var locations []string
+	for _, key := range []string{"matched-at", "url", "host"} {
+		if value, ok := result[key].(string); ok && value != "" {
+			locations = append(locations, value)
+		}
+	}
+
+	targets := make([]string, len(outcomes))
+	for i, outcome := range outcomes {
+		targets[i] = outcome.ScanTarget
+	}
+	if i := matchTarget(targets, locations); i >= 0 {
+		return outcomes[i]
+	}
+	return nil
+}
+
+// matchTarget returns the index of the target the locations belong to: by host and port,
+// then by host name alone; -1 when none matches
+func matchTarget(targets []string, locations []string) int {
+	for _, location := range locations {
+		address := targetAddress(location)
+		for i, target := range targets {
+			if address != "" && address == targetAddress(target) {
+				return i
+			}
+		}
+	}
+	for _, location := range locations {
+		host := targetHost(location)
+		for i, target := range targets {
+			if host != "" && host == targetHost(target) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// targetAddress returns the lowercased host:port of a URL or host[:port], using the
+// scheme's default port
+func targetAddress(target string) string {
+	host, port := splitTarget(target)
+	if host == "" {
+		return ""
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// targetHost returns the lowercased host name of a URL or host[:port]
+func targetHost(target string) string {
+	host, _ := splitTarget(target)
+	return host
+}
+
+// splitTarget splits a URL or host[:port] into host and port
+func splitTarget(target string) (string, string) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", ""
+	}
+	if !strings.Contains(target, "://") {
+		target = "tcp://" + bracketIPv6(target)
+	}
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return "", ""
+	}
+	port := parsed.Port()
+	if port == "" {
+		switch parsed.Scheme {
+		case "https":
+			port = "443"
+		case "http":
+			port = "80"
+		}
+	}
+	return strings.ToLower(parsed.Hostname()), port
+}
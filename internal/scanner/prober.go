@@ -0,0 +1,227 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProbeResult represents the outcome of probing a single target
+type ProbeResult struct {
+	Input      string `json:"input"`       // 原始输入
+	Target     string `json:"target"`      // 探测后的目标（带协议）
+	Scheme     string `json:"scheme"`      // https / http / tcp（非HTTP服务）
+	Reachable  bool   `json:"reachable"`   // 是否可达
+	StatusCode int    `json:"status_code"` // HTTP状态码
+	Error      string `json:"error,omitempty"`
+}
+
+// TargetProbeReport summarizes a probing run over a target list
+type TargetProbeReport struct {
+	Targets     []string       `json:"targets"`     // 重写后的可达目标
+	Unreachable []string       `json:"unreachable"` // 不可达目标
+	Results     []*ProbeResult `json:"results"`     // 每个目标的探测详情
+}
+
+// needsProbe reports whether a target is a bare host[:port] without a scheme
+func needsProbe(target string) bool {
+	return !strings.Contains(target, "://")
+}
+
+// ProbeTarget tries https then http for a bare host[:port]; targets that accept TCP
+// but speak neither are kept as raw host:port for network templates
+func ProbeTarget(ctx context.Context, target, proxyURL string, timeout time.Duration) *ProbeResult {
+	return probeTarget(ctx, target, SendOptions{ProxyURL: proxyURL, Timeout: timeout})
+}
+
+// probeTarget probes a target with the proxy, timeout, SNI and resolver of opts
+func probeTarget(ctx context.Context, target string, opts SendOptions) *ProbeResult {
+	proxyURL, timeout := opts.ProxyURL, opts.Timeout
+	target = strings.TrimSpace(target)
+	result := &ProbeResult{Input: target, Target: target}
+
+	if !needsProbe(target) {
+		result.Reachable = true
+		if parsed, err := url.Parse(target); err == nil {
+			result.Scheme = parsed.Scheme
+		}
+		return result
+	}
+
+	transport := &http.Transport{
+		// 探测时接受旧版TLS，避免仅支持TLS 1.0/1.1的内网目标被判定为不可达
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS10, ServerName: opts.ServerName},
+		DialContext:     opts.dialContext(),
+	}
+	if proxyURL != "" {
+		if parsedProxy, err := url.Parse(proxyURL); err == nil {
+			transport.Proxy = http.ProxyURL(parsedProxy)
+		}
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	var lastErr error
+	for _, scheme := range []string{"https", "http"} {
+		candidate := scheme + "://" + bracketIPv6(target)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, candidate, nil)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+
+		result.Target = candidate
+		result.Scheme = scheme
+		result.Reachable = true
+		result.StatusCode = resp.StatusCode
+		return result
+	}
+
+	// 非HTTP服务：只要TCP端口可连接就保留原始 host:port
+	if _, _, err := net.SplitHostPort(target); err == nil && proxyURL == "" {
+		dial := (&net.Dialer{Timeout: timeout}).DialContext
+		if opts.Resolver != nil {
+			dial = opts.Resolver.DialContext(&net.Dialer{Timeout: timeout})
+		}
+		if conn, err := dial(ctx, "tcp", target); err == nil {
+			conn.Close()
+			result.Scheme = "tcp"
+			result.Reachable = true
+			return result
+		}
+	}
+
+	if lastErr != nil {
+		result.Error = lastErr.Error()
+	} else {
+		result.Error = "unreachable"
+	}
+	return result
+}
+
+// ProbeTargets probes all targets concurrently and rewrites them with working schemes
+func ProbeTargets(ctx context.Context, targets []string, proxyURL string, concurrency int, timeout time.Duration) *TargetProbeReport {
+	return probeTargets(ctx, targets, SendOptions{ProxyURL: proxyURL, Timeout: timeout}, concurrency)
+}
+
+// probeTargets probes all targets concurrently with the settings of opts
+func probeTargets(ctx context.Context, targets []string, opts SendOptions, concurrency int) *TargetProbeReport {
+	if concurrency <= 0 {
+		concurrency = 20
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	results := make([]*ProbeResult, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, target string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = probeTarget(ctx, target, opts)
+		}(i, target)
+	}
+	wg.Wait()
+
+	report := &TargetProbeReport{
+		Targets:     []string{},
+		Unreachable: []string{},
+		Results:     results,
+	}
+	for _, result := range results {
+		if result.Reachable {
+			report.Targets = append(report.Targets, result.Target)
+		} else {
+			report.Unreachable = append(report.Unreachable, result.Input)
+		}
+	}
+	return report
+}
+
+// ProbeTaskTargets probes targets using the proxy, timeout and resolver of the current configuration
+func (tm *JSONTaskManager) ProbeTaskTargets(ctx context.Context, targets []string) *TargetProbeReport {
+	return probeTargets(ctx, targets, tm.defaultSendOptions(), 0)
+}
+
+// sendOptionsForTask returns the settings of wepoc's own requests to the targets of a task:
+// the global proxy and timeout with the task's client certificate, TLS and resolver settings
+func (tm *JSONTaskManager) sendOptionsForTask(task *TaskConfig) SendOptions {
+	opts := tm.defaultSendOptions()
+	opts.ClientCert = tm.clientCertForTask(task)
+	tm.tlsOptionsForTask(task).applySendOptions(&opts)
+	opts.Resolver = tm.resolverForTask(task)
+	return opts
+}
+
+// prepareTargets enumerates subdomains and probes bare targets before the scan when the
+// task asks for it; stopping the task cancels ctx and ends the preparation early
+func (sns *SimpleNucleiScanner) prepareTargets(ctx context.Context) error {
+	sns.enumerateSubdomains(ctx)
+	if ctx.Err() != nil {
+		return ErrScanStopped
+	}
+	sns.scanTargets = sns.task.Targets
+	if !sns.task.Options.ProbeTargets || sns.manager == nil {
+		return nil
+	}
+
+	logInfof("🔍 探测目标协议: %d 个目标\n", len(sns.task.Targets))
+	opts := sns.manager.sendOptionsForTask(sns.task)
+	report := probeTargets(ctx, sns.task.Targets, opts, 0)
+	if ctx.Err() != nil {
+		return ErrScanStopped
+	}
+	for _, result := range report.Results {
+		if !result.Reachable {
+			sns.addLog("WARNING", "", result.Input, fmt.Sprintf("目标不可达: %s", result.Error), "", "", false)
+		} else if result.Target != result.Input {
+			logInfof("  %s -> %s\n", result.Input, result.Target)
+		}
+	}
+
+	sns.scanTargets = report.Targets
+	sns.unreachableTargets = report.Unreachable
+	logInfof("🔍 探测完成: 可达 %d, 不可达 %d\n", len(report.Targets), len(report.Unreachable))
+
+	// 采集Web目标的标题、Server头和favicon哈希，便于在结果中识别系统
+	sns.targetInfo = collectTargetInfo(ctx, sns.scanTargets, opts, 0)
+	if ctx.Err() != nil {
+		return ErrScanStopped
+	}
+	logInfof("🔍 已采集 %d 个Web目标的标题/指纹\n", len(sns.targetInfo))
+	if behindWAF := wafTargets(sns.targetInfo); len(behindWAF) > 0 {
+		logInfof("🛡️ %d 个目标位于WAF/CDN之后\n", len(behindWAF))
+		for _, info := range sns.targetInfo {
+			if info.WAF != "" {
+				sns.addLog("WARNING", "", info.Target, fmt.Sprintf("检测到WAF/CDN: %s，命中结果需人工确认", info.WAF), "", "", false)
+			}
+		}
+	}
+
+	if len(sns.scanTargets) == 0 {
+		return fmt.Errorf("所有目标均不可达")
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+//go:build linux
+// +build linux
+
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicksPerSecond is USER_HZ, which is 100 on all mainstream Linux platforms
+const clockTicksPerSecond = 100
+
+// sampleProcess reads the CPU time and resident memory of a process from /proc
+func sampleProcess(pid int) (processSample, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return processSample{}, err
+	}
+
+	// 进程名可能包含空格，从最后一个 ')' 之后开始解析
+	stat := string(data)
+	end := strings.LastIndex(stat, ")")
+	if end < 0 {
+		return processSample{}, fmt.Errorf("unexpected /proc stat format")
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return processSample{}, fmt.Errorf("unexpected /proc stat format")
+	}
+
+	utime, _ := strconv.ParseInt(fields[11], 10, 64)
+	stime, _ := strconv.ParseInt(fields[12], 10, 64)
+	rssPages, _ := strconv.ParseInt(fields[21], 10, 64)
+
+	return processSample{
+		CPUTime: time.Duration(utime+stime) * time.Second / clockTicksPerSecond,
+		RSS:     rssPages * int64(os.Getpagesize()),
+	}, nil
+}
@@ -0,0 +1,49 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package scanner
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sampleProcess reads the CPU time and resident memory of a process using ps
+func sampleProcess(pid int) (processSample, error) {
+	output, err := exec.Command("ps", "-o", "rss=,time=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return processSample{}, err
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) < 2 {
+		return processSample{}, fmt.Errorf("unexpected ps output: %q", output)
+	}
+
+	rssKB, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return processSample{}, fmt.Errorf("unexpected ps output: %q", output)
+	}
+	return processSample{CPUTime: parsePSTime(fields[1]), RSS: rssKB * 1024}, nil
+}
+
+// parsePSTime parses the ps cumulative CPU time ([dd-][hh:]mm:ss[.ss])
+func parsePSTime(value string) time.Duration {
+	var total time.Duration
+	if i := strings.Index(value, "-"); i >= 0 {
+		days, _ := strconv.Atoi(value[:i])
+		total += time.Duration(days) * 24 * time.Hour
+		value = value[i+1:]
+	}
+
+	parts := strings.Split(value, ":")
+	unit := time.Second
+	for i := len(parts) - 1; i >= 0; i-- {
+		n, _ := strconv.ParseFloat(parts[i], 64)
+		total += time.Duration(n * float64(unit))
+		unit *= 60
+	}
+	return total
+}
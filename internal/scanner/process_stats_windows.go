@@ -0,0 +1,57 @@
+//go:build windows
+// +build windows
+
+package scanner
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	processQueryInformation = 0x0400
+	processVMRead           = 0x0010
+)
+
+var procGetProcessMemoryInfo = syscall.NewLazyDLL("psapi.dll").NewProc("GetProcessMemoryInfo")
+
+// processMemoryCounters mirrors PROCESS_MEMORY_COUNTERS
+type processMemoryCounters struct {
+	CB                         uint32
+	PageFaultCount             uint32
+	PeakWorkingSetSize         uintptr
+	WorkingSetSize             uintptr
+	QuotaPeakPagedPoolUsage    uintptr
+	QuotaPagedPoolUsage        uintptr
+	QuotaPeakNonPagedPoolUsage uintptr
+	QuotaNonPagedPoolUsage     uintptr
+	PagefileUsage              uintptr
+	PeakPagefileUsage          uintptr
+}
+
+// sampleProcess reads the CPU time and working set of a process via the Win32 API
+func sampleProcess(pid int) (processSample, error) {
+	handle, err := syscall.OpenProcess(processQueryInformation|processVMRead, false, uint32(pid))
+	if err != nil {
+		return processSample{}, err
+	}
+	defer syscall.CloseHandle(handle)
+
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return processSample{}, err
+	}
+	// FILETIME 以 100 纳秒为单位
+	ticks := int64(kernel.HighDateTime)<<32 | int64(kernel.LowDateTime)
+	ticks += int64(user.HighDateTime)<<32 | int64(user.LowDateTime)
+
+	sample := processSample{CPUTime: time.Duration(ticks) * 100}
+
+	var counters processMemoryCounters
+	counters.CB = uint32(unsafe.Sizeof(counters))
+	if ret, _, _ := procGetProcessMemoryInfo.Call(uintptr(handle), uintptr(unsafe.Pointer(&counters)), uintptr(counters.CB)); ret != 0 {
+		sample.RSS = int64(counters.WorkingSetSize)
+	}
+	return sample, nil
+}
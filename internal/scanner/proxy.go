@@ -0,0 +1,251 @@
+package scanner
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// ProxyWithCredentials embeds username/password into a proxy URL that has no credentials yet
+func ProxyWithCredentials(proxyURL, username, password string) string {
+	proxyURL = strings.TrimSpace(proxyURL)
+	if proxyURL == "" || username == "" {
+		return proxyURL
+	}
+
+	parsed, err := url.Parse(proxyURL)
+	if err != nil || parsed.User != nil {
+		return proxyURL
+	}
+	parsed.User = url.UserPassword(username, password)
+	return parsed.String()
+}
+
+// MaskProxyURL hides the password of a proxy URL for logging
+func MaskProxyURL(proxyURL string) string {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil || parsed.User == nil {
+		return proxyURL
+	}
+	if _, hasPassword := parsed.User.Password(); hasPassword {
+		parsed.User = url.UserPassword(parsed.User.Username(), "***")
+	}
+	return parsed.String()
+}
+
+// MaskProxyList masks every proxy of a comma separated list
+func MaskProxyList(proxyList string) string {
+	proxies := strings.Split(proxyList, ",")
+	for i, proxy := range proxies {
+		proxies[i] = MaskProxyURL(proxy)
+	}
+	return strings.Join(proxies, ",")
+}
+
+// MaskCommandArgs returns a copy of nuclei arguments with secrets masked, for logging
+func MaskCommandArgs(args []string) []string {
+	masked := append([]string{}, args...)
+	for i := 0; i < len(masked)-1; i++ {
+		switch masked[i] {
+		case "-proxy", "-p":
+			masked[i+1] = MaskProxyList(masked[i+1])
+		case "-interactsh-token", "-itoken":
+			masked[i+1] = "***"
+		case "-H", "-header":
+			if name, _, found := strings.Cut(masked[i+1], ":"); found {
+				masked[i+1] = name + ": ***"
+			}
+		case "-var", "-V":
+			if name, _, found := strings.Cut(masked[i+1], "="); found {
+				masked[i+1] = name + "=***"
+			}
+		}
+	}
+	return masked
+}
+
+// NucleiProxyArgs builds nuclei's -proxy arguments (credentials included) from the config
+func NucleiProxyArgs(nucleiConfig models.NucleiAdvancedConfig) []string {
+	if !nucleiConfig.ProxyEnabled {
+		return nil
+	}
+
+	var proxies []string
+	if strings.TrimSpace(nucleiConfig.ProxyURL) != "" {
+		proxies = append(proxies, nucleiConfig.ProxyURL)
+	}
+	for _, proxy := range nucleiConfig.ProxyList {
+		if strings.TrimSpace(proxy) != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	if len(proxies) == 0 {
+		return nil
+	}
+
+	for i, proxy := range proxies {
+		proxies[i] = ProxyWithCredentials(proxy, nucleiConfig.ProxyUsername, nucleiConfig.ProxyPassword)
+	}
+
+	args := []string{"-proxy", strings.Join(proxies, ",")}
+	if nucleiConfig.ProxyInternal {
+		args = append(args, "-proxy-internal")
+	}
+	return args
+}
+
+// SOCKS5 reply codes (RFC 1928)
+var socks5ReplyErrors = map[byte]string{
+	0x01: "general SOCKS server failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+// DialSOCKS5 performs a full SOCKS5 handshake (with optional username/password
+// authentication, RFC 1929) and returns a connection tunnelled to targetAddr
+func DialSOCKS5(ctx context.Context, proxyAddr, username, password, targetAddr string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("connect to proxy failed: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if err := socks5Handshake(conn, username, password, targetAddr); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// socks5Handshake negotiates authentication and issues a CONNECT request
+func socks5Handshake(conn net.Conn, username, password, targetAddr string) error {
+	// Greeting: offer no-auth, plus username/password when credentials are given
+	methods := []byte{0x00}
+	if username != "" {
+		methods = []byte{0x00, 0x02}
+	}
+	greeting := append([]byte{0x05, byte(len(methods))}, methods...)
+	if _, err := conn.Write(greeting); err != nil {
+		return fmt.Errorf("SOCKS5 greeting failed: %w", err)
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("SOCKS5 greeting failed: %w", err)
+	}
+	if reply[0] != 0x05 {
+		return fmt.Errorf("not a SOCKS5 proxy (version %d)", reply[0])
+	}
+
+	switch reply[1] {
+	case 0x00:
+		// No authentication required
+	case 0x02:
+		if username == "" {
+			return fmt.Errorf("SOCKS5 proxy requires username/password")
+		}
+		if len(username) > 255 || len(password) > 255 {
+			return fmt.Errorf("SOCKS5 credentials too long")
+		}
+		auth := []byte{0x01, byte(len(username))}
+		auth = append(auth, username...)
+		auth = append(auth, byte(len(password)))
+		auth = append(auth, password...)
+		if _, err := conn.Write(auth); err != nil {
+			return fmt.Errorf("SOCKS5 authentication failed: %w", err)
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return fmt.Errorf("SOCKS5 authentication failed: %w", err)
+		}
+		if reply[1] != 0x00 {
+			return fmt.Errorf("SOCKS5 authentication rejected")
+		}
+	case 0xFF:
+		return fmt.Errorf("SOCKS5 proxy accepted none of the offered authentication methods")
+	default:
+		return fmt.Errorf("unsupported SOCKS5 authentication method 0x%02x", reply[1])
+	}
+
+	// CONNECT request
+	host, portStr, err := net.SplitHostPort(targetAddr)
+	if err != nil {
+		return fmt.Errorf("invalid target address: %w", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("invalid target port: %s", portStr)
+	}
+
+	req := []byte{0x05, 0x01, 0x00}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(req, 0x01)
+			req = append(req, ip4...)
+		} else {
+			req = append(req, 0x04)
+			req = append(req, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return fmt.Errorf("target host name too long")
+		}
+		req = append(req, 0x03, byte(len(host)))
+		req = append(req, host...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+
+	if _, err := conn.Write(req); err != nil {
+		return fmt.Errorf("SOCKS5 connect failed: %w", err)
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("SOCKS5 connect failed: %w", err)
+	}
+	if header[1] != 0x00 {
+		if msg, ok := socks5ReplyErrors[header[1]]; ok {
+			return fmt.Errorf("SOCKS5 connect failed: %s", msg)
+		}
+		return fmt.Errorf("SOCKS5 connect failed: reply code 0x%02x", header[1])
+	}
+
+	// Skip the bound address
+	var skip int
+	switch header[3] {
+	case 0x01:
+		skip = net.IPv4len + 2
+	case 0x04:
+		skip = net.IPv6len + 2
+	case 0x03:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return fmt.Errorf("SOCKS5 connect failed: %w", err)
+		}
+		skip = int(length[0]) + 2
+	default:
+		return fmt.Errorf("SOCKS5 connect failed: unknown address type 0x%02x", header[3])
+	}
+	if _, err := io.ReadFull(conn, make([]byte, skip)); err != nil {
+		return fmt.Errorf("SOCKS5 connect failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package scanner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// Built-in rate profile names
+const (
+	RateProfileStealth    = "stealth"
+	RateProfileNormal     = "normal"
+	RateProfileAggressive = "aggressive"
+)
+
+// RateProfile is a named set of nuclei throttling parameters
+type RateProfile struct {
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	RateLimit       int    `json:"rate_limit"`        // 每秒最大请求数（-rl）
+	RateLimitMinute int    `json:"rate_limit_minute"` // 每分钟最大请求数（-rlm），0 表示不限制
+	Concurrency     int    `json:"concurrency"`       // 并行模板数（-c）
+	BulkSize        int    `json:"bulk_size"`         // 每个模板并行的目标数（-bulk-size）
+}
+
+// RateProfiles are the built-in profiles; normal matches nuclei's own defaults
+var RateProfiles = []RateProfile{
+	{Name: RateProfileStealth, Description: "低速扫描，适用于脆弱或生产环境目标", RateLimit: 10, RateLimitMinute: 300, Concurrency: 5, BulkSize: 5},
+	{Name: RateProfileNormal, Description: "Nuclei默认速率", RateLimit: nucleiDefaultRateLimit, Concurrency: 25, BulkSize: 25},
+	{Name: RateProfileAggressive, Description: "高速扫描，仅用于可承受高负载的目标", RateLimit: 500, Concurrency: 50, BulkSize: 50},
+}
+
+// LookupRateProfile finds a built-in profile by name (case-insensitive)
+func LookupRateProfile(name string) (RateProfile, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, profile := range RateProfiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return RateProfile{}, false
+}
+
+// validateRateOptions checks the rate profile and per-host cap of task options
+func validateRateOptions(options TaskOptions) error {
+	if options.RateProfile != "" {
+		if _, ok := LookupRateProfile(options.RateProfile); !ok {
+			return fmt.Errorf("未知的速率配置: %s", options.RateProfile)
+		}
+	}
+	if options.WAFRateProfile != "" {
+		if _, ok := LookupRateProfile(options.WAFRateProfile); !ok {
+			return fmt.Errorf("未知的WAF/CDN速率配置: %s", options.WAFRateProfile)
+		}
+	}
+	if options.Evasion != nil && options.Evasion.DelayJitterMs < 0 {
+		return fmt.Errorf("请求延迟抖动不能为负数")
+	}
+	if options.PerHostRateLimit < 0 {
+		return fmt.Errorf("单目标速率上限不能为负数")
+	}
+	return nil
+}
+
+// EffectiveRateProfile resolves the profile of a task: the task's own profile, then the
+// configured default, then normal. A per-host cap lowers the global rate limit, since
+// nuclei only limits the total rate and a single host may receive all of it.
+func EffectiveRateProfile(options TaskOptions, config *models.Config) RateProfile {
+	profile, ok := LookupRateProfile(options.RateProfile)
+	if !ok && config != nil {
+		profile, ok = LookupRateProfile(config.DefaultRateProfile)
+	}
+	if !ok {
+		profile, _ = LookupRateProfile(RateProfileNormal)
+	}
+
+	if perHost := options.PerHostRateLimit; perHost > 0 && perHost < profile.RateLimit {
+		profile.RateLimit = perHost
+		if profile.RateLimitMinute > perHost*60 {
+			profile.RateLimitMinute = perHost * 60
+		}
+	}
+	return profile
+}
+
+// wafRateProfile returns the WAF/CDN rate profile of a task when it is slower than the given
+// profile. nuclei only limits the rate of the whole process, so the slower profile applies to
+// the entire scan rather than just the hosts behind the WAF/CDN.
+func wafRateProfile(profile RateProfile, options TaskOptions) RateProfile {
+	slower, ok := LookupRateProfile(options.WAFRateProfile)
+	if !ok || slower.RateLimit >= profile.RateLimit {
+		return profile
+	}
+	if profile.RateLimitMinute > 0 && profile.RateLimitMinute < slower.RateLimitMinute {
+		slower.RateLimitMinute = profile.RateLimitMinute
+	}
+	return slower
+}
+
+// Args returns the nuclei throttling arguments of the profile
+func (p RateProfile) Args() []string {
+	args := []string{
+		"-rl", strconv.Itoa(p.RateLimit),
+		"-c", strconv.Itoa(p.Concurrency),
+		"-bulk-size", strconv.Itoa(p.BulkSize),
+	}
+	if p.RateLimitMinute > 0 {
+		args = append(args, "-rlm", strconv.Itoa(p.RateLimitMinute))
+	}
+	return args
+}
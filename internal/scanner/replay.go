@@ -0,0 +1,495 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// ReplayEntry represents a logged HTTP request that was re-sent manually
+// 用于手工复核漏洞：保存重放的请求及最新响应
+type ReplayEntry struct {
+	ID          int64     `json:"id"`            // 重放序号
+	TaskID      int64     `json:"task_id"`       // 所属任务ID
+	SourceLogID int64     `json:"source_log_id"` // 来源HTTP请求日志ID
+	Timestamp   time.Time `json:"timestamp"`     // 重放时间
+	TemplateID  string    `json:"template_id"`   // POC模板ID
+	Method      string    `json:"method"`        // HTTP方法
+	URL         string    `json:"url"`           // 实际请求的URL
+	StatusCode  int       `json:"status_code"`   // HTTP状态码
+	Request     string    `json:"request"`       // 发送的请求包
+	Response    string    `json:"response"`      // 收到的响应包
+	Duration    int64     `json:"duration_ms"`   // 请求耗时（毫秒）
+	Error       string    `json:"error,omitempty"`
+}
+
+// HTTPHeader represents a single header line, keeping the original order and case
+type HTTPHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// RawHTTPRequest represents a parsed raw HTTP request dump
+type RawHTTPRequest struct {
+	Method  string       `json:"method"`
+	Path    string       `json:"path"`
+	Proto   string       `json:"proto"`
+	Headers []HTTPHeader `json:"headers"`
+	Body    string       `json:"body"`
+}
+
+// ParseRawHTTPRequest parses a raw HTTP request as dumped by nuclei -debug
+func ParseRawHTTPRequest(raw string) (*RawHTTPRequest, error) {
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	raw = strings.TrimLeft(raw, "\n")
+	if raw == "" {
+		return nil, fmt.Errorf("empty request")
+	}
+
+	head, body := raw, ""
+	if idx := strings.Index(raw, "\n\n"); idx != -1 {
+		head = raw[:idx]
+		body = raw[idx+2:]
+	}
+
+	lines := strings.Split(head, "\n")
+	requestLine := strings.Fields(lines[0])
+	if len(requestLine) < 2 {
+		return nil, fmt.Errorf("invalid request line: %q", lines[0])
+	}
+
+	req := &RawHTTPRequest{
+		Method: strings.ToUpper(requestLine[0]),
+		Path:   requestLine[1],
+		Proto:  "HTTP/1.1",
+		Body:   strings.TrimRight(body, "\n"),
+	}
+	if len(requestLine) >= 3 {
+		req.Proto = requestLine[2]
+	}
+
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		req.Headers = append(req.Headers, HTTPHeader{
+			Name:  strings.TrimSpace(parts[0]),
+			Value: strings.TrimSpace(parts[1]),
+		})
+	}
+
+	return req, nil
+}
+
+// GetHeader returns the first header value matching name (case-insensitive)
+func (r *RawHTTPRequest) GetHeader(name string) string {
+	for _, h := range r.Headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// BuildURL resolves the absolute request URL from the URL the request was logged for: its
+// scheme and host are where the request was actually sent, which may differ from the Host
+// header (virtual hosts, IP targets). Without such a URL it falls back to the Host header
+// over http.
+func (r *RawHTTPRequest) BuildURL(requestURL string) (string, error) {
+	if strings.HasPrefix(r.Path, "http://") || strings.HasPrefix(r.Path, "https://") {
+		return r.Path, nil
+	}
+
+	scheme := "http"
+	host := r.GetHeader("Host")
+	if parsed, err := url.Parse(requestURL); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		scheme = parsed.Scheme
+		host = parsed.Host
+	}
+	if host == "" {
+		return "", fmt.Errorf("cannot determine request host")
+	}
+
+	path := r.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, host, path), nil
+}
+
+// ProxyURLFromConfig returns the proxy that manual requests should go through,
+// following the same settings used for nuclei scans
+func ProxyURLFromConfig(config *models.Config) string {
+	if config == nil || !config.NucleiConfig.ProxyEnabled {
+		return ""
+	}
+	nucleiConfig := config.NucleiConfig
+	if nucleiConfig.ProxyURL != "" {
+		return ProxyWithCredentials(nucleiConfig.ProxyURL, nucleiConfig.ProxyUsername, nucleiConfig.ProxyPassword)
+	}
+	for _, proxy := range nucleiConfig.ProxyList {
+		if strings.TrimSpace(proxy) != "" {
+			return ProxyWithCredentials(proxy, nucleiConfig.ProxyUsername, nucleiConfig.ProxyPassword)
+		}
+	}
+	return ""
+}
+
+// URLForTarget builds the request URL against an explicit target (scheme://host[:port]),
+// keeping the request path and leaving the Host header untouched
+func (r *RawHTTPRequest) URLForTarget(target string) (string, error) {
+	parsed, err := url.Parse(target)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return "", fmt.Errorf("invalid target override: %s", target)
+	}
+
+	path := r.Path
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		if u, err := url.Parse(path); err == nil {
+			path = u.RequestURI()
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return fmt.Sprintf("%s://%s%s", parsed.Scheme, parsed.Host, path), nil
+}
+
+// SendOptions controls how a raw request is sent
+type SendOptions struct {
+	ProxyURL        string
+	Timeout         time.Duration
+	VerifyTLS       bool   // 校验证书（默认不校验）
+	ServerName      string // 自定义SNI
+	MinTLSVersion   uint16 // 最低TLS版本（0表示使用默认值）
+	FollowRedirects bool
+	MaxRedirects    int
+	ClientCert      ClientCertConfig // 客户端证书（mTLS）
+	Resolver        *HostResolver    // 自定义DNS服务器和Hosts覆盖（为空时使用系统解析）
+}
+
+// dialContext returns the dial function of transports built from the options, or nil for
+// the default dialer
+func (opts SendOptions) dialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if opts.Resolver == nil {
+		return nil
+	}
+	return opts.Resolver.DialContext(&net.Dialer{Timeout: opts.Timeout})
+}
+
+// parseTLSVersion converts "1.0"-"1.3" into the crypto/tls constant
+func parseTLSVersion(version string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "tls") {
+	case "":
+		return 0, nil
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version: %s", version)
+	}
+}
+
+// SendRawRequest sends a raw HTTP request to the given URL and returns the raw
+// request actually sent, the raw response and the status code
+func SendRawRequest(ctx context.Context, req *RawHTTPRequest, targetURL string, opts SendOptions) (string, string, int, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: !opts.VerifyTLS,
+		ServerName:         opts.ServerName,
+		MinVersion:         opts.MinTLSVersion,
+	}
+	if err := opts.ClientCert.apply(tlsConfig); err != nil {
+		return "", "", 0, err
+	}
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+		DialContext:     opts.dialContext(),
+	}
+	if opts.ProxyURL != "" {
+		parsedProxy, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return "", "", 0, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(parsedProxy)
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		// 默认不自动跟随跳转，保持与原始请求一致
+		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+			if !opts.FollowRedirects {
+				return http.ErrUseLastResponse
+			}
+			maxRedirects := opts.MaxRedirects
+			if maxRedirects <= 0 {
+				maxRedirects = 10
+			}
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, targetURL, strings.NewReader(req.Body))
+	if err != nil {
+		return "", "", 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	for _, h := range req.Headers {
+		switch strings.ToLower(h.Name) {
+		case "host":
+			httpReq.Host = h.Value
+		case "content-length":
+			// Recomputed from the body by net/http
+		default:
+			httpReq.Header.Add(h.Name, h.Value)
+		}
+	}
+
+	requestDump, _ := httputil.DumpRequestOut(httpReq, true)
+	// DumpRequestOut consumes the body, so rebuild it
+	httpReq.Body = io.NopCloser(strings.NewReader(req.Body))
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return string(requestDump), "", 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseDump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return string(requestDump), "", resp.StatusCode, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return string(requestDump), string(responseDump), resp.StatusCode, nil
+}
+
+// RepeaterRequest represents an edited request sent from the repeater workbench
+type RepeaterRequest struct {
+	TaskID          int64  `json:"task_id"`          // 关联任务ID（为0时不保存记录）
+	SourceLogID     int64  `json:"source_log_id"`    // 来源HTTP请求日志ID（可选）
+	TemplateID      string `json:"template_id"`      // 关联的POC模板ID（可选）
+	RawRequest      string `json:"raw_request"`      // 编辑后的原始请求包
+	Target          string `json:"target"`           // 目标覆盖，如 https://example.com:8443
+	ProxyURL        string `json:"proxy_url"`        // 代理覆盖（为空时使用全局代理配置）
+	VerifyTLS       bool   `json:"verify_tls"`       // 校验证书
+	ServerName      string `json:"server_name"`      // 自定义SNI
+	MinTLSVersion   string `json:"min_tls_version"`  // 最低TLS版本：1.0/1.1/1.2/1.3
+	FollowRedirects bool   `json:"follow_redirects"` // 跟随跳转
+	MaxRedirects    int    `json:"max_redirects"`    // 最大跳转次数
+	Timeout         int    `json:"timeout"`          // 超时时间（秒）
+}
+
+// SendRepeaterRequest sends an edited raw request and records it as a replay entry of its task
+func (tm *JSONTaskManager) SendRepeaterRequest(ctx context.Context, params RepeaterRequest) (*ReplayEntry, error) {
+	rawReq, err := ParseRawHTTPRequest(params.RawRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	var targetURL string
+	if params.Target != "" {
+		targetURL, err = rawReq.URLForTarget(params.Target)
+	} else {
+		targetURL, err = rawReq.BuildURL("")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	minTLS, err := parseTLSVersion(params.MinTLSVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := tm.defaultSendOptions()
+	if params.ProxyURL != "" {
+		opts.ProxyURL = params.ProxyURL
+	}
+	if params.Timeout > 0 {
+		opts.Timeout = time.Duration(params.Timeout) * time.Second
+	}
+	opts.VerifyTLS = params.VerifyTLS
+	opts.ServerName = params.ServerName
+	opts.MinTLSVersion = minTLS
+	opts.FollowRedirects = params.FollowRedirects
+	opts.MaxRedirects = params.MaxRedirects
+	if task, err := tm.GetTaskByID(params.TaskID); err == nil {
+		opts.ClientCert = tm.clientCertForTask(task)
+		opts.Resolver = tm.resolverForTask(task)
+	}
+
+	entry := &ReplayEntry{
+		TaskID:      params.TaskID,
+		SourceLogID: params.SourceLogID,
+		Timestamp:   time.Now(),
+		TemplateID:  params.TemplateID,
+		Method:      rawReq.Method,
+		URL:         targetURL,
+	}
+	tm.sendReplay(ctx, entry, rawReq, opts)
+
+	if params.TaskID > 0 {
+		if err := tm.saveReplayEntry(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return entry, nil
+}
+
+// defaultSendOptions returns send options derived from the global configuration
+func (tm *JSONTaskManager) defaultSendOptions() SendOptions {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	opts := SendOptions{
+		ProxyURL:   ProxyURLFromConfig(tm.config),
+		Timeout:    30 * time.Second,
+		ClientCert: ClientCertFromConfig(tm.config),
+		Resolver:   resolverFromConfig(tm.config),
+	}
+	if tm.config != nil && tm.config.Timeout > 0 {
+		opts.Timeout = time.Duration(tm.config.Timeout) * time.Second
+	}
+	return opts
+}
+
+// sendReplay sends the request and fills the response fields of the entry
+func (tm *JSONTaskManager) sendReplay(ctx context.Context, entry *ReplayEntry, rawReq *RawHTTPRequest, opts SendOptions) {
+	start := time.Now()
+	sentRequest, response, statusCode, err := SendRawRequest(ctx, rawReq, entry.URL, opts)
+	entry.Duration = time.Since(start).Milliseconds()
+	entry.Request = sentRequest
+	entry.Response = response
+	entry.StatusCode = statusCode
+	if err != nil {
+		entry.Error = err.Error()
+	}
+}
+
+// ReplayHTTPRequest re-sends a logged HTTP request and stores the result as a replay entry
+func (tm *JSONTaskManager) ReplayHTTPRequest(ctx context.Context, taskID, requestID int64) (*ReplayEntry, error) {
+	source, err := tm.findHTTPRequestLog(taskID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	rawReq, err := ParseRawHTTPRequest(source.Request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse logged request: %w", err)
+	}
+
+	targetURL, err := rawReq.BuildURL(source.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &ReplayEntry{
+		TaskID:      taskID,
+		SourceLogID: requestID,
+		Timestamp:   time.Now(),
+		TemplateID:  source.TemplateID,
+		Method:      rawReq.Method,
+		URL:         targetURL,
+	}
+	opts := tm.defaultSendOptions()
+	if task, err := tm.GetTaskByID(taskID); err == nil {
+		opts.ClientCert = tm.clientCertForTask(task)
+		opts.Resolver = tm.resolverForTask(task)
+	}
+	tm.sendReplay(ctx, entry, rawReq, opts)
+
+	if err := tm.saveReplayEntry(entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// findHTTPRequestLog looks up a single logged request of a task
+func (tm *JSONTaskManager) findHTTPRequestLog(taskID, requestID int64) (*HTTPRequestLog, error) {
+	logs, err := tm.GetHTTPRequestLogs(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, log := range logs {
+		if log.ID == requestID {
+			return log, nil
+		}
+	}
+	return nil, fmt.Errorf("HTTP request %d not found in task %d", requestID, taskID)
+}
+
+// GetReplayEntries returns all replay entries recorded for a task
+func (tm *JSONTaskManager) GetReplayEntries(taskID int64) ([]*ReplayEntry, error) {
+	replayFile := filepath.Join(tm.logsDir, fmt.Sprintf("task_%d_replays.json", taskID))
+
+	if _, err := os.Stat(replayFile); os.IsNotExist(err) {
+		return []*ReplayEntry{}, nil
+	}
+
+	data, err := tm.readEvidenceFile(replayFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay entries: %w", err)
+	}
+
+	var entries []*ReplayEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal replay entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// saveReplayEntry appends a replay entry to the task's replay file
+func (tm *JSONTaskManager) saveReplayEntry(entry *ReplayEntry) error {
+	tm.replayMu.Lock()
+	defer tm.replayMu.Unlock()
+
+	entries, err := tm.GetReplayEntries(entry.TaskID)
+	if err != nil {
+		return err
+	}
+
+	entry.ID = int64(len(entries)) + 1
+	if len(entries) > 0 && entries[len(entries)-1].ID >= entry.ID {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+	entries = append(entries, entry)
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal replay entries: %w", err)
+	}
+
+	replayFile := filepath.Join(tm.logsDir, fmt.Sprintf("task_%d_replays.json", entry.TaskID))
+	if err := tm.writeEvidenceFile(replayFile, data); err != nil {
+		return fmt.Errorf("failed to write replay entries: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"wepoc/internal/i18n"
+	"wepoc/internal/models"
+)
+
+// defaultRequestConfirmThreshold is the estimated request count above which starting a task
+// needs confirmation when the config leaves it at 0
+const defaultRequestConfirmThreshold = 1000000
+
+// ErrRequestConfirmationRequired is returned when a task would send more requests than the
+// confirmation threshold and the user has not confirmed the estimate
+var ErrRequestConfirmationRequired = errors.New("request estimate requires confirmation")
+
+// RequestConfirmation records that the user accepted the estimated request count of a task
+type RequestConfirmation struct {
+	ConfirmedAt       time.Time `json:"confirmed_at"`
+	User              string    `json:"user"`
+	EstimatedRequests int       `json:"estimated_requests"` // 确认时的预估请求数，超过时需重新确认
+	Override          bool      `json:"override"`           // 管理员放行：不再按预估请求数要求确认
+}
+
+// requestConfirmThreshold returns the configured threshold; negative means never ask
+func requestConfirmThreshold(config *models.Config) int {
+	if config == nil || config.RequestConfirmThreshold == 0 {
+		return defaultRequestConfirmThreshold
+	}
+	return config.RequestConfirmThreshold
+}
+
+// checkRequestEstimate refuses to start a task whose estimated request count exceeds the
+// threshold unless the user confirmed at least that many requests or an admin overrode it
+func (tm *JSONTaskManager) checkRequestEstimate(task *TaskConfig) error {
+	threshold := requestConfirmThreshold(tm.config)
+	if threshold < 0 || (task.RequestConfirmation != nil && task.RequestConfirmation.Override) {
+		return nil
+	}
+	report, err := tm.estimateTask(task)
+	if err != nil {
+		return err
+	}
+	if report.EstimatedRequests <= threshold {
+		return nil
+	}
+	if task.RequestConfirmation != nil && report.EstimatedRequests <= task.RequestConfirmation.EstimatedRequests {
+		return nil
+	}
+	return fmt.Errorf("%w: 任务预估发送 %d 个请求（%d 个目标，每个目标 %d 个请求，约 %s），超过确认阈值 %d",
+		ErrRequestConfirmationRequired, report.EstimatedRequests, report.TargetCount, report.RequestsPerTarget,
+		report.EstimatedDuration, threshold)
+}
+
+// ConfirmRequestEstimate records the user's confirmation of the current request estimate of
+// a task, or an admin override when override is set, and writes it to the audit log
+func (tm *JSONTaskManager) ConfirmRequestEstimate(taskID int64, override bool) (*TaskConfig, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.loadTaskConfig(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+	if task.Status == "running" {
+		return nil, i18n.Errorf(i18n.ErrTaskRunning)
+	}
+	if err := task.checkUnlocked(); err != nil {
+		return nil, err
+	}
+	report, err := tm.estimateTask(task)
+	if err != nil {
+		return nil, err
+	}
+
+	tm.handlersMu.RLock()
+	user := tm.auditUser
+	tm.handlersMu.RUnlock()
+	task.RequestConfirmation = &RequestConfirmation{
+		ConfirmedAt:       time.Now(),
+		User:              user,
+		EstimatedRequests: report.EstimatedRequests,
+		Override:          override,
+	}
+	task.UpdatedAt = time.Now()
+	if err := tm.saveTaskConfig(task); err != nil {
+		return nil, fmt.Errorf("failed to save task: %w", err)
+	}
+
+	detail := fmt.Sprintf("确认预估请求数 %d", report.EstimatedRequests)
+	if override {
+		detail = fmt.Sprintf("管理员放行请求量限制（当前预估 %d）", report.EstimatedRequests)
+	}
+	tm.audit(&AuditEntry{
+		Action:   AuditRequestsConfirmed,
+		TaskID:   task.ID,
+		TaskName: task.Name,
+		Detail:   detail,
+	})
+	return task, nil
+}
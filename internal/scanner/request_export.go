@@ -0,0 +1,154 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// shellQuote wraps a value in single quotes for POSIX shells
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// BuildCurlCommand converts a parsed raw request into an equivalent curl command
+func BuildCurlCommand(req *RawHTTPRequest, targetURL string) string {
+	parts := []string{"curl", "-i", "-s", "-k"}
+	if req.Method != "" && req.Method != "GET" {
+		parts = append(parts, "-X", shellQuote(req.Method))
+	}
+
+	for _, h := range req.Headers {
+		if strings.EqualFold(h.Name, "Content-Length") {
+			continue
+		}
+		parts = append(parts, "-H", shellQuote(h.Name+": "+h.Value))
+	}
+
+	if req.Body != "" {
+		parts = append(parts, "--data-binary", shellQuote(req.Body))
+	}
+	parts = append(parts, shellQuote(targetURL))
+
+	return strings.Join(parts, " ")
+}
+
+// GetRequestAsCurl returns a curl command reproducing a logged request
+func (tm *JSONTaskManager) GetRequestAsCurl(taskID, requestID int64) (string, error) {
+	source, err := tm.findHTTPRequestLog(taskID, requestID)
+	if err != nil {
+		return "", err
+	}
+
+	rawReq, err := ParseRawHTTPRequest(source.Request)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse logged request: %w", err)
+	}
+
+	targetURL, err := rawReq.BuildURL(source.Target)
+	if err != nil {
+		return "", err
+	}
+
+	return BuildCurlCommand(rawReq, targetURL), nil
+}
+
+// GetFindingAsCurl returns a curl command for a finding, preferring the one emitted by nuclei
+func (tm *JSONTaskManager) GetFindingAsCurl(taskID int64, findingIndex int) (string, error) {
+	result, err := tm.GetTaskResult(taskID)
+	if err != nil {
+		return "", err
+	}
+	if findingIndex < 0 || findingIndex >= len(result.Vulnerabilities) {
+		return "", fmt.Errorf("finding %d not found in task %d", findingIndex, taskID)
+	}
+
+	vuln := result.Vulnerabilities[findingIndex]
+	if vuln.CurlCommand != "" {
+		return vuln.CurlCommand, nil
+	}
+
+	rawReq, err := ParseRawHTTPRequest(vuln.Request)
+	if err != nil {
+		return "", fmt.Errorf("finding has no usable request: %w", err)
+	}
+
+	targetURL, err := rawReq.BuildURL(vuln.MatchedAt)
+	if err != nil {
+		return "", err
+	}
+
+	return BuildCurlCommand(rawReq, targetURL), nil
+}
+
+// SendRequestToBurp forwards a logged request through the configured Burp listener
+// so that it shows up in Burp's proxy history for manual testing
+func (tm *JSONTaskManager) SendRequestToBurp(ctx context.Context, taskID, requestID int64) (*ReplayEntry, error) {
+	source, err := tm.findHTTPRequestLog(taskID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &ReplayEntry{
+		TaskID:      taskID,
+		SourceLogID: requestID,
+		TemplateID:  source.TemplateID,
+	}
+	return tm.sendToBurp(ctx, entry, source.Request, source.Target)
+}
+
+// SendFindingToBurp forwards the request of a finding through the configured Burp listener
+func (tm *JSONTaskManager) SendFindingToBurp(ctx context.Context, taskID int64, findingIndex int) (*ReplayEntry, error) {
+	result, err := tm.GetTaskResult(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if findingIndex < 0 || findingIndex >= len(result.Vulnerabilities) {
+		return nil, fmt.Errorf("finding %d not found in task %d", findingIndex, taskID)
+	}
+
+	vuln := result.Vulnerabilities[findingIndex]
+	entry := &ReplayEntry{
+		TaskID:     taskID,
+		TemplateID: vuln.TemplateID,
+	}
+	return tm.sendToBurp(ctx, entry, vuln.Request, vuln.MatchedAt)
+}
+
+// sendToBurp sends the raw request via the Burp proxy and fills in the entry
+func (tm *JSONTaskManager) sendToBurp(ctx context.Context, entry *ReplayEntry, rawRequest, target string) (*ReplayEntry, error) {
+	tm.mu.RLock()
+	burpURL := ""
+	if tm.config != nil {
+		burpURL = strings.TrimSpace(tm.config.BurpProxyURL)
+	}
+	tm.mu.RUnlock()
+
+	if burpURL == "" {
+		return nil, fmt.Errorf("未配置Burp代理地址")
+	}
+
+	rawReq, err := ParseRawHTTPRequest(rawRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	targetURL, err := rawReq.BuildURL(target)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := tm.defaultSendOptions()
+	opts.ProxyURL = burpURL
+
+	entry.Timestamp = time.Now()
+	entry.Method = rawReq.Method
+	entry.URL = targetURL
+	tm.sendReplay(ctx, entry, rawReq, opts)
+
+	if entry.Error != "" && entry.StatusCode == 0 {
+		return entry, fmt.Errorf("发送到Burp失败（%s）: %s", burpURL, entry.Error)
+	}
+	return entry, nil
+}
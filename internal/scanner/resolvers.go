@@ -0,0 +1,399 @@
+package scanner
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+const (
+	// dnsOverrideTTL is the TTL of answers for overridden names
+	dnsOverrideTTL = 60
+	// dnsForwardTimeout bounds forwarding a query to an upstream DNS server
+	dnsForwardTimeout = 3 * time.Second
+)
+
+// DNS record types and response codes used by the override server
+const (
+	dnsTypeA         = 1
+	dnsTypeAAAA      = 28
+	dnsRcodeNoError  = 0
+	dnsRcodeServFail = 2
+	dnsRcodeNXDomain = 3
+)
+
+// HostResolver resolves target names with hosts-style overrides and custom DNS servers, so
+// internal names resolve without touching the OS hosts file
+type HostResolver struct {
+	overrides      map[string]string // 小写主机名（或 *.后缀） -> IP
+	servers        []string          // DNS服务器 host:port
+	systemFallback bool              // 自定义DNS解析失败时回退到系统解析（nuclei -sr）
+}
+
+// NewHostResolver builds a resolver from overrides and DNS servers; nil when neither is set
+func NewHostResolver(overrides []models.HostOverride, servers []string, systemFallback bool) *HostResolver {
+	resolver := &HostResolver{overrides: make(map[string]string), systemFallback: systemFallback}
+	for _, override := range overrides {
+		host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(override.Host)), ".")
+		ip := strings.TrimSpace(override.IP)
+		if host != "" && net.ParseIP(ip) != nil {
+			resolver.overrides[host] = ip
+		}
+	}
+	for _, server := range servers {
+		if server = normalizeResolver(server); server != "" {
+			resolver.servers = append(resolver.servers, server)
+		}
+	}
+	if len(resolver.overrides) == 0 && len(resolver.servers) == 0 {
+		return nil
+	}
+	return resolver
+}
+
+// normalizeResolver adds the default DNS port to a server address
+func normalizeResolver(server string) string {
+	server = strings.TrimSpace(server)
+	if server == "" {
+		return ""
+	}
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), "53")
+}
+
+// validateResolverOptions checks the DNS servers and host overrides of task options
+func validateResolverOptions(servers []string, overrides []models.HostOverride) error {
+	for _, server := range servers {
+		host, _, err := net.SplitHostPort(normalizeResolver(server))
+		if err != nil || net.ParseIP(host) == nil {
+			return fmt.Errorf("无效的DNS服务器: %s（格式为 IP 或 IP:端口）", server)
+		}
+	}
+	for _, override := range overrides {
+		if strings.TrimSpace(override.Host) == "" {
+			return fmt.Errorf("Hosts覆盖的主机名不能为空")
+		}
+		if net.ParseIP(strings.TrimSpace(override.IP)) == nil {
+			return fmt.Errorf("Hosts覆盖 %s 的IP无效: %s", override.Host, override.IP)
+		}
+	}
+	return nil
+}
+
+// lookup returns the override IP of a host; "*.example.com" overrides match all subdomains
+func (r *HostResolver) lookup(host string) (string, bool) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if ip, ok := r.overrides[host]; ok {
+		return ip, true
+	}
+	for rest := host; ; {
+		_, parent, found := strings.Cut(rest, ".")
+		if !found {
+			return "", false
+		}
+		if ip, ok := r.overrides["*."+parent]; ok {
+			return ip, true
+		}
+		rest = parent
+	}
+}
+
+// DialContext returns a dial function that applies the overrides and resolves other names
+// with the custom DNS servers, for wepoc's own requests to the targets
+func (r *HostResolver) DialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		if ip, ok := r.lookup(host); ok {
+			return dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		}
+		if len(r.servers) == 0 || net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		addrs, err := r.dnsResolver().LookupIPAddr(ctx, host)
+		if err != nil || len(addrs) == 0 {
+			if r.systemFallback {
+				return dialer.DialContext(ctx, network, addr)
+			}
+			if err == nil {
+				err = fmt.Errorf("no addresses for %s", host)
+			}
+			return nil, err
+		}
+		var lastErr error
+		for _, ipAddr := range addrs {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ipAddr.IP.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
+
+// dnsResolver returns a Go resolver that queries the custom DNS servers
+func (r *HostResolver) dnsResolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, r.servers[rand.Intn(len(r.servers))])
+		},
+	}
+}
+
+// resolverFromConfig returns the global resolver settings (caller holds mu)
+func resolverFromConfig(config *models.Config) *HostResolver {
+	if config == nil {
+		return nil
+	}
+	nucleiConfig := config.NucleiConfig
+	return NewHostResolver(nucleiConfig.HostOverrides, nucleiConfig.Resolvers, nucleiConfig.SystemResolvers)
+}
+
+// resolverForTask returns the resolver of a task: task DNS servers replace the global ones
+// and task host overrides take precedence over the global ones
+func (tm *JSONTaskManager) resolverForTask(task *TaskConfig) *HostResolver {
+	tm.mu.RLock()
+	var nucleiConfig models.NucleiAdvancedConfig
+	if tm.config != nil {
+		nucleiConfig = tm.config.NucleiConfig
+	}
+	tm.mu.RUnlock()
+
+	servers := nucleiConfig.Resolvers
+	overrides := nucleiConfig.HostOverrides
+	if task != nil {
+		if len(task.Options.Resolvers) > 0 {
+			servers = task.Options.Resolvers
+		}
+		overrides = append(append([]models.HostOverride{}, overrides...), task.Options.HostOverrides...)
+	}
+	return NewHostResolver(overrides, servers, nucleiConfig.SystemResolvers)
+}
+
+// dnsOverrideServer is a local DNS server handed to nuclei (-r) that answers overridden
+// names itself and forwards all other queries to the custom DNS servers
+type dnsOverrideServer struct {
+	resolver *HostResolver
+	conn     net.PacketConn
+}
+
+// startDNSOverrideServer listens on a random local UDP port
+func startDNSOverrideServer(resolver *HostResolver) (*dnsOverrideServer, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start DNS override server: %w", err)
+	}
+	server := &dnsOverrideServer{resolver: resolver, conn: conn}
+	go server.serve()
+	return server, nil
+}
+
+// Addr returns the address to list in nuclei's resolvers file
+func (s *dnsOverrideServer) Addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+// Close stops the server
+func (s *dnsOverrideServer) Close() {
+	s.conn.Close()
+}
+
+// serve answers queries until the server is closed
+func (s *dnsOverrideServer) serve() {
+	buf := make([]byte, 4096)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		query := append([]byte{}, buf[:n]...)
+		go func() {
+			if response := s.answer(query); response != nil {
+				s.conn.WriteTo(response, addr)
+			}
+		}()
+	}
+}
+
+// answer builds the response to a query: the override for overridden names (no answer for
+// a record type the override IP does not have), otherwise the upstream answer. Without
+// upstream servers the name is reported as nonexistent so nuclei falls back to the system
+// resolver (-sr).
+func (s *dnsOverrideServer) answer(query []byte) []byte {
+	name, qtype, end, ok := parseDNSQuestion(query)
+	if !ok {
+		return nil
+	}
+	if ip, found := s.resolver.lookup(name); found {
+		return dnsReply(query[:end], qtype, net.ParseIP(ip), dnsRcodeNoError)
+	}
+	if len(s.resolver.servers) == 0 {
+		return dnsReply(query[:end], qtype, nil, dnsRcodeNXDomain)
+	}
+	for _, server := range s.resolver.servers {
+		if response, err := forwardDNS(query, server); err == nil {
+			return response
+		}
+	}
+	return dnsReply(query[:end], qtype, nil, dnsRcodeServFail)
+}
+
+// parseDNSQuestion reads the name and type of the single question of a query and returns
+// the offset where the question ends
+func parseDNSQuestion(query []byte) (string, uint16, int, bool) {
+	if len(query) < 12 || binary.BigEndian.Uint16(query[4:6]) != 1 {
+		return "", 0, 0, false
+	}
+	var labels []string
+	offset := 12
+	for {
+		if offset >= len(query) {
+			return "", 0, 0, false
+		}
+		length := int(query[offset])
+		offset++
+		if length == 0 {
+			break
+		}
+		if length > 63 || offset+length > len(query) {
+			return "", 0, 0, false
+		}
+		labels = append(labels, string(query[offset:offset+length]))
+		offset += length
+	}
+	if offset+4 > len(query) {
+		return "", 0, 0, false
+	}
+	qtype := binary.BigEndian.Uint16(query[offset : offset+2])
+	return strings.ToLower(strings.Join(labels, ".")), qtype, offset + 4, true
+}
+
+// dnsReply builds a response to the header and question of a query, with an answer when the
+// IP matches the queried record type
+func dnsReply(question []byte, qtype uint16, ip net.IP, rcode uint16) []byte {
+	response := append([]byte{}, question...)
+	// QR + AA + RA，保留查询的opcode和RD
+	flags := binary.BigEndian.Uint16(question[2:4])&0x7900 | 0x8000 | 0x0400 | 0x0080 | rcode
+	binary.BigEndian.PutUint16(response[2:4], flags)
+	binary.BigEndian.PutUint16(response[6:8], 0)   // ANCOUNT
+	binary.BigEndian.PutUint16(response[8:10], 0)  // NSCOUNT
+	binary.BigEndian.PutUint16(response[10:12], 0) // ARCOUNT
+
+	var rdata []byte
+	switch {
+	case ip == nil:
+	case qtype == dnsTypeA && ip.To4() != nil:
+		rdata = ip.To4()
+	case qtype == dnsTypeAAAA && ip.To4() == nil:
+		rdata = ip.To16()
+	}
+	if rdata == nil {
+		return response
+	}
+
+	binary.BigEndian.PutUint16(response[6:8], 1)
+	answer := make([]byte, 12, 12+len(rdata))
+	answer[0], answer[1] = 0xC0, 0x0C // 指向问题中的域名
+	binary.BigEndian.PutUint16(answer[2:4], qtype)
+	binary.BigEndian.PutUint16(answer[4:6], 1) // IN
+	binary.BigEndian.PutUint32(answer[6:10], dnsOverrideTTL)
+	binary.BigEndian.PutUint16(answer[10:12], uint16(len(rdata)))
+	return append(append(response, answer...), rdata...)
+}
+
+// forwardDNS sends a query to an upstream DNS server over UDP and returns its response
+func forwardDNS(query []byte, server string) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", server, dnsForwardTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(dnsForwardTimeout))
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// startResolver prepares the resolvers file passed to nuclei (-r): the custom DNS servers,
+// or the local override server when the task has host overrides
+func (sns *SimpleNucleiScanner) startResolver() error {
+	if sns.manager == nil {
+		return nil
+	}
+	resolver := sns.manager.resolverForTask(sns.task)
+	if resolver == nil {
+		return nil
+	}
+
+	servers := resolver.servers
+	if len(resolver.overrides) > 0 {
+		server, err := startDNSOverrideServer(resolver)
+		if err != nil {
+			return err
+		}
+		sns.dnsServer = server
+		servers = []string{server.Addr()}
+		logInfof("🧭 启用Hosts覆盖: %d 条，本地DNS: %s\n", len(resolver.overrides), server.Addr())
+	}
+
+	file, err := os.CreateTemp("", "wepoc-resolvers-*.txt")
+	if err != nil {
+		sns.stopResolver()
+		return fmt.Errorf("failed to create resolvers file: %w", err)
+	}
+	_, err = file.WriteString(strings.Join(servers, "\n") + "\n")
+	file.Close()
+	sns.resolversFile = file.Name()
+	if err != nil {
+		sns.stopResolver()
+		return fmt.Errorf("failed to write resolvers file: %w", err)
+	}
+	// 仅有Hosts覆盖时其他域名由系统解析
+	sns.systemResolvers = resolver.systemFallback || len(resolver.servers) == 0
+	return nil
+}
+
+// stopResolver stops the local override server and removes the resolvers file
+func (sns *SimpleNucleiScanner) stopResolver() {
+	if sns.dnsServer != nil {
+		sns.dnsServer.Close()
+		sns.dnsServer = nil
+	}
+	if sns.resolversFile != "" {
+		os.Remove(sns.resolversFile)
+		sns.resolversFile = ""
+	}
+}
+
+// resolverArgs returns nuclei's -r/-sr arguments
+func (sns *SimpleNucleiScanner) resolverArgs() []string {
+	if sns.resolversFile == "" {
+		return nil
+	}
+	args := []string{"-r", sns.resolversFile}
+	if sns.systemResolvers {
+		args = append(args, "-sr")
+	}
+	return args
+}
@@ -0,0 +1,175 @@
+package scanner
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"wepoc/internal/i18n"
+)
+
+const (
+	// resourceSampleInterval is how often the CPU/memory of nuclei processes is sampled
+	resourceSampleInterval = 5 * time.Second
+	// throttleMaxWait bounds how long a task waits for resources before starting with reduced concurrency
+	throttleMaxWait = 10 * time.Minute
+	// defaultMaxScanCPUPercent is the share of total machine CPU nuclei processes may use together
+	defaultMaxScanCPUPercent = 80
+	// defaultMaxScanMemoryMB is the memory all nuclei processes may use together
+	defaultMaxScanMemoryMB = 2048
+)
+
+// processSample is a point-in-time reading of a process' resource usage
+type processSample struct {
+	CPUTime time.Duration // 累计CPU时间
+	RSS     int64         // 常驻内存（字节）
+}
+
+// ThrottleInfo is the payload of a "throttled" scan event
+type ThrottleInfo struct {
+	TaskID      int64   `json:"task_id"`
+	Action      string  `json:"action"`       // delayed（延迟启动）/ reduced_concurrency（降低并发启动）
+	Reason      string  `json:"reason"`       // 触发原因
+	CPUPercent  float64 `json:"cpu_percent"`  // 当前nuclei进程CPU占用（占整机百分比）
+	MemoryBytes int64   `json:"memory_bytes"` // 当前nuclei进程内存占用
+	RunningTask int     `json:"running_tasks"`
+}
+
+// monitorResources samples the nuclei process until stop is closed and keeps sns.performance current
+func (sns *SimpleNucleiScanner) monitorResources(pid int, stop <-chan struct{}) {
+	startTime := time.Now()
+	last, err := sampleProcess(pid)
+	if err != nil {
+		return
+	}
+	lastTime := time.Now()
+
+	ticker := time.NewTicker(resourceSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		sample, err := sampleProcess(pid)
+		if err != nil {
+			continue
+		}
+		now := time.Now()
+		cpuPercent := float64(sample.CPUTime-last.CPUTime) / float64(now.Sub(lastTime)) * 100
+		last, lastTime = sample, now
+
+		sns.progressMu.RLock()
+		requestsPerSec := sns.progress.RequestsPerSecond
+		sns.progressMu.RUnlock()
+
+		sns.perfMu.Lock()
+		sns.performance = &PerformanceInfo{
+			MemoryUsage:    sample.RSS,
+			CPUUsage:       cpuPercent,
+			Duration:       now.Sub(startTime),
+			RequestsPerSec: requestsPerSec,
+		}
+		sns.perfMu.Unlock()
+	}
+}
+
+// Performance returns the latest resource sample of the nuclei process, or nil before the first sample
+func (sns *SimpleNucleiScanner) Performance() *PerformanceInfo {
+	sns.perfMu.Lock()
+	defer sns.perfMu.Unlock()
+	if sns.performance == nil {
+		return nil
+	}
+	perf := *sns.performance
+	return &perf
+}
+
+// resourceUsage sums the latest samples of all running nuclei processes; CPU is expressed
+// as a percentage of the whole machine
+func (tm *JSONTaskManager) resourceUsage() (float64, int64, int) {
+	var cpu float64
+	var memory int64
+	running := 0
+	for _, taskID := range tm.RunningTaskIDs() {
+		scanner, ok := tm.runningScanner(taskID)
+		if !ok {
+			continue
+		}
+		if perf := scanner.Performance(); perf != nil {
+			cpu += perf.CPUUsage
+			memory += perf.MemoryUsage
+			running++
+		}
+	}
+	return cpu / float64(runtime.NumCPU()), memory, running
+}
+
+// resourcePressure reports whether running scans exceed the configured CPU or memory budget
+func (tm *JSONTaskManager) resourcePressure() (*ThrottleInfo, bool) {
+	maxCPU, maxMemoryMB := defaultMaxScanCPUPercent, defaultMaxScanMemoryMB
+	if tm.config != nil {
+		if tm.config.MaxScanCPUPercent > 0 {
+			maxCPU = tm.config.MaxScanCPUPercent
+		}
+		if tm.config.MaxScanMemoryMB > 0 {
+			maxMemoryMB = tm.config.MaxScanMemoryMB
+		}
+	}
+
+	cpu, memory, running := tm.resourceUsage()
+	info := &ThrottleInfo{CPUPercent: cpu, MemoryBytes: memory, RunningTask: running}
+	switch {
+	case cpu >= float64(maxCPU):
+		info.Reason = fmt.Sprintf("nuclei进程CPU占用 %.0f%% 超过上限 %d%%", cpu, maxCPU)
+	case memory >= int64(maxMemoryMB)*1024*1024:
+		info.Reason = fmt.Sprintf("nuclei进程内存占用 %dMB 超过上限 %dMB", memory/1024/1024, maxMemoryMB)
+	default:
+		return info, false
+	}
+	return info, true
+}
+
+// waitForResources delays the start of a scan while running scans are over the resource
+// budget; after throttleMaxWait the scan starts with halved concurrency instead
+func (tm *JSONTaskManager) waitForResources(scanner *SimpleNucleiScanner) {
+	deadline := time.Now().Add(throttleMaxWait)
+	delayed := false
+	for {
+		info, pressured := tm.resourcePressure()
+		if !pressured || scanner.stopped() || scanner.paused() {
+			if delayed {
+				logInfof("▶️ 资源占用恢复正常，任务 %d 开始扫描\n", scanner.task.ID)
+			}
+			return
+		}
+
+		info.TaskID = scanner.task.ID
+		if time.Now().After(deadline) {
+			scanner.throttled = true
+			info.Action = "reduced_concurrency"
+			args := []interface{}{scanner.task.ID, info.Reason}
+			logWarnf("⚠️ %s\n", i18n.Sprintf(i18n.MsgResourceReducedParallel, args...))
+			tm.emitEvent(scanner.task.ID, &ScanEvent{TaskID: scanner.task.ID, EventType: "throttled", Data: info, Timestamp: time.Now(), Code: i18n.MsgResourceReducedParallel, Args: args})
+			return
+		}
+		if !delayed {
+			delayed = true
+			info.Action = "delayed"
+			args := []interface{}{scanner.task.ID, info.Reason}
+			logInfof("⏳ %s\n", i18n.Sprintf(i18n.MsgResourceDelayed, args...))
+			tm.recordTimeline(scanner.task.ID, &TimelineEvent{Kind: TimelineQueued, Message: i18n.Sprintf(i18n.MsgResourceDelayed, args...)})
+			tm.emitEvent(scanner.task.ID, &ScanEvent{TaskID: scanner.task.ID, EventType: "throttled", Data: info, Timestamp: time.Now(), Code: i18n.MsgResourceDelayed, Args: args})
+		}
+		time.Sleep(resourceSampleInterval)
+	}
+}
+
+// throttledProfile halves the concurrency of a profile for scans started under resource pressure
+func throttledProfile(profile RateProfile) RateProfile {
+	profile.Concurrency = max(1, profile.Concurrency/2)
+	profile.BulkSize = max(1, profile.BulkSize/2)
+	return profile
+}
@@ -0,0 +1,232 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// Result file kinds
+const (
+	ResultFileNucleiOutput = "nuclei_output" // nuclei的JSONL输出（task_12/nuclei_output.jsonl）
+	ResultFileScanResult   = "scan_result"   // 旧版扫描器的结果文件（scan_result_12_20240101_120000.json）
+	ResultFileLegacyResult = "legacy_result" // 已迁移到数据库的JSON存储结果文件（task_12_result.json）
+)
+
+var (
+	// scanResultFilePattern matches result files of the legacy scanner
+	scanResultFilePattern = regexp.MustCompile(`^scan_result_(\d+)_.*\.json$`)
+	// taskOutputDirPattern matches the per-task nuclei output directories
+	taskOutputDirPattern = regexp.MustCompile(`^task_(\d+)$`)
+)
+
+// ResultFile describes a result file found in the results directory
+type ResultFile struct {
+	Path      string    `json:"path"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	TaskID    int64     `json:"task_id"`
+	TaskName  string    `json:"task_name"`  // 任务已删除时取结果文件中记录的名称
+	Size      int64     `json:"size"`       // 文件大小（字节）
+	ModTime   time.Time `json:"mod_time"`   // 修改时间
+	VulnCount int       `json:"vuln_count"` // 漏洞数量
+	Error     string    `json:"error,omitempty"`
+}
+
+// legacyResultsDir is where result files of the JSON storage are kept after migration
+func (tm *JSONTaskManager) legacyResultsDir() string {
+	return filepath.Join(filepath.Dir(tm.tasksDir), "legacy_json", filepath.Base(tm.resultsDir))
+}
+
+// ListResultFiles indexes the result files on disk: nuclei output of each task, result files
+// of the legacy scanner and migrated JSON results, newest first
+func (tm *JSONTaskManager) ListResultFiles() ([]*ResultFile, error) {
+	var files []*ResultFile
+
+	entries, err := os.ReadDir(tm.resultsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results directory: %w", err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(tm.resultsDir, entry.Name())
+		if entry.IsDir() {
+			matches := taskOutputDirPattern.FindStringSubmatch(entry.Name())
+			if matches == nil {
+				continue
+			}
+			taskID, _ := strconv.ParseInt(matches[1], 10, 64)
+			outputs, _ := filepath.Glob(filepath.Join(path, "nuclei_output*.jsonl"))
+			for _, output := range outputs {
+				files = append(files, tm.resultFile(output, ResultFileNucleiOutput, taskID))
+			}
+			continue
+		}
+		if matches := scanResultFilePattern.FindStringSubmatch(entry.Name()); matches != nil {
+			taskID, _ := strconv.ParseInt(matches[1], 10, 64)
+			files = append(files, tm.resultFile(path, ResultFileScanResult, taskID))
+		} else if matches := legacyResultFilePattern.FindStringSubmatch(entry.Name()); matches != nil {
+			taskID, _ := strconv.ParseInt(matches[1], 10, 64)
+			files = append(files, tm.resultFile(path, ResultFileLegacyResult, taskID))
+		}
+	}
+
+	legacyEntries, _ := os.ReadDir(tm.legacyResultsDir())
+	for _, entry := range legacyEntries {
+		matches := legacyResultFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+		taskID, _ := strconv.ParseInt(matches[1], 10, 64)
+		files = append(files, tm.resultFile(filepath.Join(tm.legacyResultsDir(), entry.Name()), ResultFileLegacyResult, taskID))
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime.After(files[j].ModTime)
+	})
+	return files, nil
+}
+
+// resultFile builds the index entry of a result file, reading it to count the findings
+func (tm *JSONTaskManager) resultFile(path, kind string, taskID int64) *ResultFile {
+	file := &ResultFile{
+		Path:   path,
+		Name:   filepath.Base(path),
+		Kind:   kind,
+		TaskID: taskID,
+	}
+	if info, err := os.Stat(path); err == nil {
+		file.Size = info.Size()
+		file.ModTime = info.ModTime()
+	}
+
+	vulns, name, err := readResultFile(path, kind)
+	if err != nil {
+		file.Error = err.Error()
+	}
+	file.VulnCount = len(vulns)
+	file.TaskName = name
+	if task, err := tm.GetTaskByID(taskID); err == nil {
+		file.TaskName = task.Name
+	}
+	return file
+}
+
+// ReadResultFile returns the findings stored in a result file listed by ListResultFiles
+func (tm *JSONTaskManager) ReadResultFile(path string) ([]*models.NucleiResult, error) {
+	path = filepath.Clean(path)
+	if !withinDir(tm.resultsDir, path) && !withinDir(tm.legacyResultsDir(), path) {
+		return nil, fmt.Errorf("只能读取结果目录中的文件: %s", path)
+	}
+
+	name := filepath.Base(path)
+	kind := ResultFileNucleiOutput
+	switch {
+	case scanResultFilePattern.MatchString(name):
+		kind = ResultFileScanResult
+	case legacyResultFilePattern.MatchString(name):
+		kind = ResultFileLegacyResult
+	case !strings.HasSuffix(name, ".jsonl"):
+		return nil, fmt.Errorf("不支持的结果文件: %s", name)
+	}
+
+	vulns, _, err := readResultFile(path, kind)
+	return vulns, err
+}
+
+// DeleteResultFile deletes a result file listed by ListResultFiles (and its .log file) unless
+// it belongs to a locked task
+func (tm *JSONTaskManager) DeleteResultFile(path string) error {
+	path = filepath.Clean(path)
+	if !withinDir(tm.resultsDir, path) && !withinDir(tm.legacyResultsDir(), path) {
+		return fmt.Errorf("只能删除结果目录中的文件: %s", path)
+	}
+	if err := tm.CheckResultFileUnlocked(path); err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete scan result file: %w", err)
+	}
+	if err := os.Remove(path + ".log"); err != nil && !os.IsNotExist(err) {
+		logWarnf("⚠️ 删除日志文件失败 %s: %v\n", path+".log", err)
+	}
+	return nil
+}
+
+// LegacyTaskResult loads the findings of a task that has no result in the database from the
+// newest result file of the task on disk
+func (tm *JSONTaskManager) LegacyTaskResult(taskID int64) ([]*models.NucleiResult, error) {
+	files, err := tm.ListResultFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.TaskID == taskID && file.Error == "" {
+			vulns, _, err := readResultFile(file.Path, file.Kind)
+			return vulns, err
+		}
+	}
+	return nil, fmt.Errorf("no result file found for task %d", taskID)
+}
+
+// readResultFile reads the findings (and the task name, if recorded) of a result file
+func readResultFile(path, kind string) ([]*models.NucleiResult, string, error) {
+	if kind == ResultFileNucleiOutput {
+		vulns, err := parseJSONLFile(path)
+		return vulns, "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if kind == ResultFileLegacyResult {
+		var result TaskResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, "", fmt.Errorf("failed to parse result file: %w", err)
+		}
+		return result.Vulnerabilities, result.TaskName, nil
+	}
+
+	var result models.ScanResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, "", fmt.Errorf("failed to parse scan result file: %w", err)
+	}
+	vulns := make([]*models.NucleiResult, 0, len(result.Vulnerabilities))
+	for _, detail := range result.Vulnerabilities {
+		vulns = append(vulns, &models.NucleiResult{
+			TemplateID: detail.TemplateID,
+			Info: models.NucleiInfo{
+				Name:        detail.Name,
+				Author:      detail.Author,
+				Tags:        detail.Tags,
+				Description: detail.Description,
+				Reference:   detail.Reference,
+				Severity:    detail.Severity,
+			},
+			Host:             detail.Host,
+			MatchedAt:        detail.MatchedAt,
+			MatcherName:      detail.MatcherName,
+			ExtractedResults: detail.ExtractedResults,
+			Request:          detail.Request.Raw,
+			Response:         detail.Response.Raw,
+			Timestamp:        detail.Timestamp,
+		})
+	}
+	return vulns, result.TaskName, nil
+}
+
+// withinDir reports whether path is inside dir
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
@@ -0,0 +1,126 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// ImportNucleiResults creates a completed task from the JSONL output of a standalone nuclei
+// run (-jsonl / -o), so findings of scans run elsewhere are kept with the app's own results
+func (tm *JSONTaskManager) ImportNucleiResults(path, taskName string) (*TaskConfig, error) {
+	vulns, err := parseJSONLFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nuclei output: %w", err)
+	}
+	if len(vulns) == 0 {
+		return nil, fmt.Errorf("%s 中没有可导入的漏洞结果", filepath.Base(path))
+	}
+	return tm.importFindings(path, taskName, vulns)
+}
+
+// importedScope returns the targets and template IDs the imported findings refer to
+func importedScope(vulns []*models.NucleiResult) (targets []string, templates []string) {
+	seenTargets := make(map[string]bool)
+	seenTemplates := make(map[string]bool)
+	for _, vuln := range vulns {
+		target := vuln.Host
+		if target == "" {
+			target = vuln.MatchedAt
+		}
+		if target != "" && !seenTargets[target] {
+			seenTargets[target] = true
+			targets = append(targets, target)
+		}
+		if vuln.TemplateID != "" && !seenTemplates[vuln.TemplateID] {
+			seenTemplates[vuln.TemplateID] = true
+			templates = append(templates, vuln.TemplateID)
+		}
+	}
+	sort.Strings(targets)
+	sort.Strings(templates)
+	return targets, templates
+}
+
+// importFindings saves findings read from an external scan as the result of a new completed
+// task; the scan period is taken from the timestamps of the findings
+func (tm *JSONTaskManager) importFindings(source, taskName string, vulns []*models.NucleiResult) (*TaskConfig, error) {
+	vulns = dedupFindings(vulns)
+	targets, templates := importedScope(vulns)
+
+	now := time.Now()
+	var start, end time.Time
+	for _, vuln := range vulns {
+		if vuln.Timestamp.IsZero() {
+			continue
+		}
+		if start.IsZero() || vuln.Timestamp.Before(start) {
+			start = vuln.Timestamp
+		}
+		if vuln.Timestamp.After(end) {
+			end = vuln.Timestamp
+		}
+	}
+	if start.IsZero() {
+		start, end = now, now
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	taskID := tm.nextTaskID
+	tm.nextTaskID++
+	if taskName == "" {
+		taskName = fmt.Sprintf("导入-%s", filepath.Base(source))
+	}
+
+	task := &TaskConfig{
+		ID:           taskID,
+		Name:         taskName,
+		Status:       "completed",
+		Targets:      targets,
+		FoundVulns:   len(vulns),
+		StartTime:    start,
+		EndTime:      &end,
+		OutputFile:   filepath.Join(tm.resultsDir, resultFileName(taskID)),
+		LogFile:      filepath.Join(tm.logsDir, fmt.Sprintf("task_%d.log", taskID)),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		ImportedFrom: source,
+	}
+	if err := tm.saveTaskConfig(task); err != nil {
+		return nil, fmt.Errorf("failed to save task config: %w", err)
+	}
+
+	result := &TaskResult{
+		TaskID:          task.ID,
+		TaskName:        task.Name,
+		Status:          task.Status,
+		StartTime:       start,
+		EndTime:         end,
+		Duration:        end.Sub(start).String(),
+		Targets:         targets,
+		Templates:       templates,
+		TemplateCount:   len(templates),
+		TargetCount:     len(targets),
+		FoundVulns:      len(vulns),
+		SuccessRate:     100,
+		Vulnerabilities: vulns,
+		Summary: map[string]interface{}{
+			"found_vulns":   len(vulns),
+			"imported_from": source,
+		},
+		CreatedAt: now,
+	}
+	tm.recordFindings(result)
+	if err := tm.saveTaskResult(result); err != nil {
+		return nil, fmt.Errorf("failed to save task result: %w", err)
+	}
+
+	tm.recordTimeline(taskID, &TimelineEvent{Kind: TimelineCreated, Time: now, Message: "导入: " + filepath.Base(source)})
+	logInfof("📥 已导入 %s: 任务 %d (%d 个漏洞)\n", source, taskID, len(vulns))
+	return task, nil
+}
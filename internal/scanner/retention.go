@@ -0,0 +1,484 @@
+package scanner
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// RetentionReport summarizes a retention run
+type RetentionReport struct {
+	DeletedTasks   []int64 `json:"deleted_tasks"`   // 超出保留策略被删除的任务
+	ArchivedTasks  []int64 `json:"archived_tasks"`  // 结果和日志被压缩归档的任务
+	ReclaimedBytes int64   `json:"reclaimed_bytes"` // 释放的空间（字节）
+	Reclaimed      string  `json:"reclaimed"`       // 释放的空间（可读格式）
+}
+
+// TaskStorage is the disk usage of a single task
+type TaskStorage struct {
+	TaskID   int64  `json:"task_id"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Bytes    int64  `json:"bytes"`
+	Size     string `json:"size"`
+	Archived bool   `json:"archived"`
+}
+
+// StorageReport breaks the workspace usage down by category and by task
+type StorageReport struct {
+	Root       string            `json:"root"`
+	TotalBytes int64             `json:"total_bytes"`
+	Total      string            `json:"total"`
+	Categories []*DiskUsageEntry `json:"categories"` // database/results/logs/templates/archives/temp/other，按大小降序
+	Tasks      []*TaskStorage    `json:"tasks"`      // 按大小降序
+}
+
+// storageCategories maps top-level workspace entries to report categories
+var storageCategories = map[string]string{
+	"tasks":            "tasks",
+	"results":          "results",
+	"logs":             "logs",
+	"nuclei-templates": "templates",
+	"archives":         "archives",
+	"tmp":              "temp",
+	"wordlists":        "wordlists",
+	"wepoc.db":         "database",
+}
+
+// finishedTask reports whether a task is in a final state retention may act on
+func finishedTask(task *TaskConfig) bool {
+	switch task.Status {
+	case "completed", "failed", "stopped", "interrupted":
+		return true
+	}
+	return false
+}
+
+// taskFinishedAt is the time a finished task ended (falls back to its last update)
+func taskFinishedAt(task *TaskConfig) time.Time {
+	if task.EndTime != nil {
+		return *task.EndTime
+	}
+	return task.UpdatedAt
+}
+
+// archivesDir is where compressed task archives are kept
+func (tm *JSONTaskManager) archivesDir() string {
+	return filepath.Join(filepath.Dir(tm.tasksDir), "archives")
+}
+
+// taskArchivePath returns the zip archive of a task
+func (tm *JSONTaskManager) taskArchivePath(taskID int64) string {
+	return filepath.Join(tm.archivesDir(), fmt.Sprintf("task_%d.zip", taskID))
+}
+
+// taskDataFiles lists the result and log files of a task, keyed by their path inside an archive
+func (tm *JSONTaskManager) taskDataFiles(taskID int64) map[string]string {
+	files := make(map[string]string)
+	for _, dir := range []string{tm.resultsDir, tm.logsDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if id, ok := taskIDFromName(entry.Name()); ok && id == taskID {
+				files[filepath.Base(dir)+"/"+entry.Name()] = filepath.Join(dir, entry.Name())
+			}
+		}
+	}
+	return files
+}
+
+// taskDocuments exports the stored result and HTTP request logs of a task as JSON, keyed
+// by their path inside an archive
+func (tm *JSONTaskManager) taskDocuments(taskID int64) (map[string][]byte, error) {
+	documents := make(map[string][]byte)
+	if data, err := tm.db.GetTaskResult(taskID); err == nil {
+		documents["results/"+resultFileName(taskID)] = data
+	}
+
+	logs, err := tm.db.GetHTTPLogs(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) > 0 {
+		entries := make([]json.RawMessage, 0, len(logs))
+		for _, data := range logs {
+			entries = append(entries, data)
+		}
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return nil, err
+		}
+		documents["logs/"+httpLogsFileName(taskID)] = data
+	}
+	return documents, nil
+}
+
+// archiveTask compresses the result and log files of a task, together with its result and
+// HTTP request logs from the database, into its zip archive and removes the originals.
+// Entries already in the archive are kept unless replaced.
+func (tm *JSONTaskManager) archiveTask(task *TaskConfig) (int64, error) {
+	files := tm.taskDataFiles(task.ID)
+	documents, err := tm.taskDocuments(task.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load stored task data: %w", err)
+	}
+	if len(files) == 0 && len(documents) == 0 {
+		return 0, nil
+	}
+	if err := os.MkdirAll(tm.archivesDir(), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create archives directory: %w", err)
+	}
+
+	archivePath := tm.taskArchivePath(task.ID)
+	tmpPath := archivePath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	writer := zip.NewWriter(out)
+
+	var originalBytes int64
+	replaced := make(map[string]string)
+	for name, path := range files {
+		replaced[name] = path
+	}
+	for name, data := range documents {
+		originalBytes += int64(len(data))
+		replaced[name] = ""
+		if err := addBytesToZip(writer, name, data); err != nil {
+			writer.Close()
+			out.Close()
+			os.Remove(tmpPath)
+			return 0, err
+		}
+	}
+	for _, name := range sortedKeys(files) {
+		size, _ := pathSize(files[name])
+		originalBytes += size
+		if err := addToZip(writer, name, files[name]); err != nil {
+			writer.Close()
+			out.Close()
+			os.Remove(tmpPath)
+			return 0, err
+		}
+	}
+	if err := tm.copyZipEntries(writer, archivePath, replaced); err != nil {
+		writer.Close()
+		out.Close()
+		os.Remove(tmpPath)
+		return 0, err
+	}
+
+	if err := writer.Close(); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	out.Close()
+	if err := tm.sealEvidenceFile(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+
+	oldSize, _ := pathSize(archivePath)
+	if err := os.Rename(tmpPath, archivePath); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("failed to save archive: %w", err)
+	}
+	for _, path := range files {
+		os.RemoveAll(path)
+	}
+	if len(documents) > 0 {
+		tm.db.DeleteTaskResult(task.ID)
+		tm.db.DeleteHTTPLogs(task.ID)
+	}
+
+	newSize, _ := pathSize(archivePath)
+	logInfof("🗜️ 任务 %d 的结果和日志已归档: %s (%s -> %s)\n", task.ID, archivePath, formatBytes(originalBytes), formatBytes(newSize))
+	// 很小的文件压缩后可能反而变大，此时不计入释放空间
+	return max(0, originalBytes+oldSize-newSize), nil
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// addBytesToZip adds an in-memory file to a zip archive
+func addBytesToZip(writer *zip.Writer, name string, data []byte) error {
+	dst, err := writer.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	_, err = dst.Write(data)
+	return err
+}
+
+// addToZip adds a file or directory tree to a zip archive under name
+func addToZip(writer *zip.Writer, name, path string) error {
+	return filepath.Walk(path, func(current string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(path, current)
+		if err != nil {
+			return err
+		}
+		entryName := name
+		if rel != "." {
+			entryName = name + "/" + filepath.ToSlash(rel)
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = entryName
+		header.Method = zip.Deflate
+		dst, err := writer.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", entryName, err)
+		}
+		src, err := os.Open(current)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(dst, src)
+		return err
+	})
+}
+
+// copyZipEntries copies the entries of an existing archive that are not being replaced
+func (tm *JSONTaskManager) copyZipEntries(writer *zip.Writer, archivePath string, replaced map[string]string) error {
+	reader, err := tm.openTaskArchive(archivePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open existing archive: %w", err)
+	}
+
+	for _, file := range reader.File {
+		if _, ok := replaced[file.Name]; ok {
+			continue
+		}
+		if _, ok := replaced[zipTopEntry(file.Name)]; ok {
+			continue
+		}
+		if err := writer.Copy(file); err != nil {
+			return fmt.Errorf("failed to copy archive entry %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// zipTopEntry returns the first two path segments of an entry (e.g. results/task_1 for results/task_1/out.jsonl)
+func zipTopEntry(name string) string {
+	parts := strings.SplitN(name, "/", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// readArchivedFile reads a file (e.g. "results/task_1_result.json") from a task archive
+func (tm *JSONTaskManager) readArchivedFile(taskID int64, name string) ([]byte, error) {
+	reader, err := tm.openTaskArchive(tm.taskArchivePath(taskID))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range reader.File {
+		if file.Name != name {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, os.ErrNotExist
+}
+
+// purgeTask deletes a task with all its results, logs, attachments and archive; returns the
+// bytes freed
+func (tm *JSONTaskManager) purgeTask(task *TaskConfig) int64 {
+	freed, _ := tm.db.TaskStorageSize(task.ID)
+	attachments, err := tm.db.TaskAttachmentFiles(task.ID)
+	if err != nil {
+		logWarnf("⚠️ 删除任务 %d 失败: %v\n", task.ID, err)
+		return 0
+	}
+	paths := []string{tm.taskArchivePath(task.ID)}
+	for _, path := range tm.taskDataFiles(task.ID) {
+		paths = append(paths, path)
+	}
+	for _, name := range attachments {
+		paths = append(paths, filepath.Join(tm.attachmentsDir(), filepath.FromSlash(name)))
+	}
+	for _, path := range paths {
+		size, _ := pathSize(path)
+		freed += size
+	}
+
+	if err := tm.db.DeleteTask(task.ID); err != nil {
+		logWarnf("⚠️ 删除任务 %d 失败: %v\n", task.ID, err)
+		return 0
+	}
+	if err := tm.removeTaskFiles(task, attachments); err != nil {
+		logWarnf("⚠️ 删除任务 %d 的文件失败: %v\n", task.ID, err)
+	}
+	logInfof("🗑️ 按保留策略删除任务 %d (%s)\n", task.ID, task.Name)
+	return freed
+}
+
+// ApplyRetention deletes finished tasks beyond the configured retention (keep last N tasks /
+// last X days) and compresses the results and logs of older finished tasks into archives
+func (tm *JSONTaskManager) ApplyRetention() (*RetentionReport, error) {
+	report := &RetentionReport{DeletedTasks: []int64{}, ArchivedTasks: []int64{}}
+
+	var policy models.RetentionConfig
+	if tm.config != nil {
+		policy = tm.config.Retention
+	}
+	if policy.KeepTasks <= 0 && policy.KeepDays <= 0 && policy.CompressAfterDays <= 0 {
+		report.Reclaimed = formatBytes(0)
+		return report, nil
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tasks, err := tm.listTaskConfigs()
+	if err != nil {
+		return nil, err
+	}
+	// 最新的任务在前
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+	})
+
+	now := time.Now()
+	kept := 0
+	for _, task := range tasks {
+		if !finishedTask(task) || tm.isTaskRunning(task.ID) {
+			kept++
+			continue
+		}
+		if task.Lock != nil {
+			// 锁定的证据任务不受保留策略影响
+			continue
+		}
+		finishedAt := taskFinishedAt(task)
+
+		expired := (policy.KeepTasks > 0 && kept >= policy.KeepTasks) ||
+			(policy.KeepDays > 0 && now.Sub(finishedAt) > time.Duration(policy.KeepDays)*24*time.Hour)
+		if expired {
+			report.ReclaimedBytes += tm.purgeTask(task)
+			report.DeletedTasks = append(report.DeletedTasks, task.ID)
+			continue
+		}
+		kept++
+
+		if policy.CompressAfterDays > 0 && now.Sub(finishedAt) > time.Duration(policy.CompressAfterDays)*24*time.Hour {
+			freed, err := tm.archiveTask(task)
+			if err != nil {
+				logWarnf("⚠️ 归档任务 %d 失败: %v\n", task.ID, err)
+				continue
+			}
+			if freed != 0 || !task.Archived {
+				report.ReclaimedBytes += freed
+				report.ArchivedTasks = append(report.ArchivedTasks, task.ID)
+			}
+			if !task.Archived {
+				task.Archived = true
+				tm.saveTaskConfig(task)
+			}
+		}
+	}
+
+	report.Reclaimed = formatBytes(report.ReclaimedBytes)
+	if len(report.DeletedTasks) > 0 || len(report.ArchivedTasks) > 0 {
+		logInfof("🧹 保留策略: 删除 %d 个任务, 归档 %d 个任务, 释放 %s\n", len(report.DeletedTasks), len(report.ArchivedTasks), report.Reclaimed)
+	}
+	return report, nil
+}
+
+// GetStorageReport returns the workspace usage broken down by tasks/results/logs/templates
+// and the usage of each task
+func (tm *JSONTaskManager) GetStorageReport() (*StorageReport, error) {
+	usage, err := tm.GetDiskUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StorageReport{Root: usage.Root, TotalBytes: usage.TotalBytes, Total: usage.Total, Tasks: []*TaskStorage{}}
+	categories := make(map[string]*DiskUsageEntry)
+	for _, entry := range usage.Entries {
+		name, ok := storageCategories[entry.Name]
+		if !ok {
+			name = "other"
+		}
+		category, ok := categories[name]
+		if !ok {
+			category = &DiskUsageEntry{Name: name, Path: entry.Path}
+			if name == "other" {
+				category.Path = usage.Root
+			}
+			categories[name] = category
+			report.Categories = append(report.Categories, category)
+		}
+		category.Bytes += entry.Bytes
+		category.Files += entry.Files
+	}
+	for _, category := range report.Categories {
+		category.Size = formatBytes(category.Bytes)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		return report.Categories[i].Bytes > report.Categories[j].Bytes
+	})
+
+	tasks, err := tm.GetAllTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	for _, task := range tasks {
+		size, _ := tm.db.TaskStorageSize(task.ID)
+		archiveSize, _ := pathSize(tm.taskArchivePath(task.ID))
+		size += archiveSize
+		for _, path := range tm.taskDataFiles(task.ID) {
+			fileSize, _ := pathSize(path)
+			size += fileSize
+		}
+		report.Tasks = append(report.Tasks, &TaskStorage{
+			TaskID:   task.ID,
+			Name:     task.Name,
+			Status:   task.Status,
+			Bytes:    size,
+			Size:     formatBytes(size),
+			Archived: task.Archived,
+		})
+	}
+	sort.Slice(report.Tasks, func(i, j int) bool {
+		return report.Tasks[i].Bytes > report.Tasks[j].Bytes
+	})
+	return report, nil
+}
@@ -0,0 +1,386 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"wepoc/internal/i18n"
+)
+
+// ErrScanPaused is returned by Start when the scan was paused because its scan window closed
+var ErrScanPaused = errors.New("scan paused outside of scan window")
+
+const (
+	// windowCheckInterval is how often the scheduler checks task scan windows
+	windowCheckInterval = 30 * time.Second
+	// pauseKillTimeout bounds how long a paused nuclei process may take to write its resume file
+	pauseKillTimeout = 20 * time.Second
+)
+
+// resumeFilePattern matches nuclei's message when it saves a resume file on interrupt
+var resumeFilePattern = regexp.MustCompile(`(?i)creating resume file:?\s*(\S+)`)
+
+// ScanWindow is a daily time range in which a task may scan, e.g. 22:00–06:00.
+// Windows where End is before Start span midnight.
+type ScanWindow struct {
+	Start    string `json:"start"`              // 开始时间 HH:MM
+	End      string `json:"end"`                // 结束时间 HH:MM（早于开始时间表示跨午夜）
+	Weekdays []int  `json:"weekdays,omitempty"` // 允许的星期（0=周日），按窗口开始当天计算；为空表示每天
+}
+
+// parseClock parses HH:MM into minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("无效的时间格式 %q（应为 HH:MM）", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// validate checks the window times and weekdays
+func (w ScanWindow) validate() error {
+	if _, err := parseClock(w.Start); err != nil {
+		return err
+	}
+	if _, err := parseClock(w.End); err != nil {
+		return err
+	}
+	for _, day := range w.Weekdays {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("无效的星期 %d（应为 0-6）", day)
+		}
+	}
+	return nil
+}
+
+// allowsDay reports whether the window opens on the given weekday
+func (w ScanWindow) allowsDay(day time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range w.Weekdays {
+		if time.Weekday(d) == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether t (local time) falls inside the window
+func (w ScanWindow) Contains(t time.Time) bool {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+
+	switch {
+	case start == end:
+		// 开始与结束相同表示全天
+		return w.allowsDay(t.Weekday())
+	case start < end:
+		return now >= start && now < end && w.allowsDay(t.Weekday())
+	case now >= start:
+		return w.allowsDay(t.Weekday())
+	case now < end:
+		// 跨午夜窗口的后半段属于前一天开启的窗口
+		return w.allowsDay(t.AddDate(0, 0, -1).Weekday())
+	}
+	return false
+}
+
+// InScanWindows reports whether t falls inside any of the windows; no windows means no restriction
+func InScanWindows(windows []ScanWindow, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateScanWindows checks all windows of a task
+func validateScanWindows(windows []ScanWindow) error {
+	for i, w := range windows {
+		if err := w.validate(); err != nil {
+			return fmt.Errorf("扫描时间窗口 %d 无效: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Pause interrupts the nuclei process so it writes a resume file; Start then processes
+// the partial results and returns ErrScanPaused. Windows cannot deliver an interrupt to
+// the child process, so nuclei is killed there and the scan restarts on resume.
+func (sns *SimpleNucleiScanner) Pause() {
+	sns.pause(false)
+}
+
+// pause interrupts nuclei for a pause requested by the user or by the scan window scheduler
+func (sns *SimpleNucleiScanner) pause(byUser bool) {
+	sns.stopMu.Lock()
+	defer sns.stopMu.Unlock()
+
+	if sns.stopRequested || sns.pauseRequested {
+		return
+	}
+	sns.pauseRequested = true
+	sns.userPaused = byUser
+	if byUser {
+		logInfof("⏸️ 暂停任务 %d 的扫描\n", sns.task.ID)
+	} else {
+		logInfof("⏸️ 任务 %d 超出扫描时间窗口，暂停扫描\n", sns.task.ID)
+	}
+	if sns.cmd != nil && sns.cmd.Process != nil {
+		sns.interruptProcess()
+	}
+}
+
+// pausedStatus is the task status after a pause: paused when the user paused the scan,
+// waiting_window when its scan window closed
+func (sns *SimpleNucleiScanner) pausedStatus() string {
+	sns.stopMu.Lock()
+	defer sns.stopMu.Unlock()
+	if sns.userPaused {
+		return "paused"
+	}
+	return "waiting_window"
+}
+
+// PauseTask pauses a running task: nuclei writes a resume file and StartTask continues the
+// scan from it
+func (tm *JSONTaskManager) PauseTask(taskID int64) error {
+	scanner, ok := tm.runningScanner(taskID)
+	if !ok {
+		return fmt.Errorf("task %d is not running", taskID)
+	}
+	scanner.pause(true)
+	return nil
+}
+
+// interruptProcess asks nuclei to exit gracefully and kills it if it does not (caller holds stopMu)
+func (sns *SimpleNucleiScanner) interruptProcess() {
+	process := sns.cmd.Process
+	if runtime.GOOS == "windows" || process.Signal(os.Interrupt) != nil {
+		process.Kill()
+		return
+	}
+	time.AfterFunc(pauseKillTimeout, func() {
+		// 进程已退出时 Kill 返回错误，忽略即可
+		process.Kill()
+	})
+}
+
+// paused reports whether Pause was called
+func (sns *SimpleNucleiScanner) paused() bool {
+	sns.stopMu.Lock()
+	defer sns.stopMu.Unlock()
+	return sns.pauseRequested
+}
+
+// recordResumeFile captures the resume file nuclei reports when interrupted
+func (sns *SimpleNucleiScanner) recordResumeFile(line string) {
+	matches := resumeFilePattern.FindStringSubmatch(line)
+	if len(matches) < 2 {
+		return
+	}
+	sns.stopMu.Lock()
+	sns.resumeFile = matches[1]
+	sns.stopMu.Unlock()
+	logInfof("💾 nuclei续扫文件: %s\n", matches[1])
+}
+
+// ResumeFile returns the resume file written by nuclei when the scan was paused
+func (sns *SimpleNucleiScanner) ResumeFile() string {
+	sns.stopMu.Lock()
+	defer sns.stopMu.Unlock()
+	return sns.resumeFile
+}
+
+// resumeArgs returns the -resume arguments when continuing a paused scan
+func (sns *SimpleNucleiScanner) resumeArgs() []string {
+	if sns.task.ResumeFile == "" {
+		return nil
+	}
+	if _, err := os.Stat(sns.task.ResumeFile); err != nil {
+		logWarnf("⚠️ 续扫文件不可用，重新开始扫描: %v\n", err)
+		return nil
+	}
+	logInfof("▶️ 使用续扫文件继续扫描: %s\n", sns.task.ResumeFile)
+	return []string{"-resume", sns.task.ResumeFile}
+}
+
+// archivePartialOutput keeps the output of earlier scan segments of a resumed task, since
+// nuclei overwrites the -jle file; fresh scans discard old segments
+func (sns *SimpleNucleiScanner) archivePartialOutput(outputFile string) error {
+	if sns.task.ResumeFile == "" {
+		for _, part := range partialOutputFiles(outputFile) {
+			os.Remove(part)
+		}
+		return nil
+	}
+	if _, err := os.Stat(outputFile); err != nil {
+		return nil
+	}
+	part := strings.TrimSuffix(outputFile, ".jsonl") + fmt.Sprintf("_part_%d.jsonl", time.Now().UnixNano())
+	return os.Rename(outputFile, part)
+}
+
+// partialOutputFiles lists archived output segments in the order they were written
+func partialOutputFiles(outputFile string) []string {
+	parts, _ := filepath.Glob(strings.TrimSuffix(outputFile, ".jsonl") + "_part_*.jsonl")
+	sort.Strings(parts)
+	return parts
+}
+
+// windowScheduler ensures the scan window scheduler is started only once
+var windowScheduler sync.Once
+
+// StartWindowScheduler periodically pauses running tasks outside their scan windows and
+// resumes waiting tasks once their window opens
+func (tm *JSONTaskManager) StartWindowScheduler() {
+	windowScheduler.Do(func() {
+		go func() {
+			ticker := time.NewTicker(windowCheckInterval)
+			defer ticker.Stop()
+			for {
+				tm.checkScanWindows(time.Now())
+				<-ticker.C
+			}
+		}()
+	})
+}
+
+// checkScanWindows applies the scan windows of all tasks at time now
+func (tm *JSONTaskManager) checkScanWindows(now time.Time) {
+	for _, taskID := range tm.RunningTaskIDs() {
+		scanner, ok := tm.runningScanner(taskID)
+		if !ok {
+			continue
+		}
+		if !InScanWindows(scanner.task.Options.ScanWindows, now) {
+			scanner.Pause()
+		}
+	}
+
+	tasks, err := tm.GetAllTasks()
+	if err != nil {
+		return
+	}
+	for _, task := range tasks {
+		if task.Status == "waiting_window" && InScanWindows(task.Options.ScanWindows, now) {
+			if err := tm.resumeTask(task.ID); err != nil {
+				logWarnf("⚠️ 恢复任务 %d 失败: %v\n", task.ID, err)
+			}
+		}
+	}
+}
+
+// deferToScanWindow marks a task as waiting for its scan window instead of starting it
+// (caller holds mu). Returns false when the task may start now.
+func (tm *JSONTaskManager) deferToScanWindow(task *TaskConfig) (bool, error) {
+	if InScanWindows(task.Options.ScanWindows, time.Now()) {
+		return false, nil
+	}
+
+	task.Status = "waiting_window"
+	task.UpdatedAt = time.Now()
+	if err := tm.saveTaskConfig(task); err != nil {
+		return true, fmt.Errorf("failed to save task config: %w", err)
+	}
+
+	logInfof("🕒 %s\n", i18n.Sprintf(i18n.MsgScanWaitingWindow, task.ID))
+	tm.recordTimeline(task.ID, &TimelineEvent{Kind: TimelineQueued, Message: i18n.Sprintf(i18n.MsgScanWaitingWindow, task.ID)})
+	tm.emitEvent(task.ID, &ScanEvent{
+		TaskID:    task.ID,
+		EventType: "progress",
+		Code:      i18n.MsgScanWaitingWindow,
+		Args:      []interface{}{task.ID},
+		Data: &ScanProgress{
+			TaskID:            task.ID,
+			TotalRequests:     task.TotalRequests,
+			CompletedRequests: task.CompletedRequests,
+			FoundVulns:        task.FoundVulns,
+			Status:            "waiting_window",
+			TotalTemplates:    len(task.POCs),
+			SelectedTemplates: task.POCs,
+			ETASeconds:        -1,
+		},
+	})
+	return true, nil
+}
+
+// resumeTask continues a task that was waiting for its scan window
+func (tm *JSONTaskManager) resumeTask(taskID int64) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.loadTaskConfig(taskID)
+	if err != nil {
+		return fmt.Errorf("failed to load task config: %w", err)
+	}
+	if task.Status != "waiting_window" {
+		return nil
+	}
+	// 模板、确认或配置可能在等待期间发生变化，启动前重新检查
+	if err := tm.checkStartGate(task); err != nil {
+		tm.failWaitingTask(task, err)
+		return err
+	}
+
+	task.Status = "running"
+	task.EndTime = nil
+	task.UpdatedAt = time.Now()
+	if task.StartTime.IsZero() {
+		task.StartTime = time.Now()
+	}
+	if err := tm.saveTaskConfig(task); err != nil {
+		return fmt.Errorf("failed to save task config: %w", err)
+	}
+
+	logInfof("▶️ 扫描时间窗口开启，恢复任务 %d\n", taskID)
+	go tm.runScanTask(task)
+	return nil
+}
+
+// failWaitingTask marks a task that may no longer start when its scan window opens as
+// failed (caller holds mu)
+func (tm *JSONTaskManager) failWaitingTask(task *TaskConfig, cause error) {
+	now := time.Now()
+	task.Status = "failed"
+	task.EndTime = &now
+	task.UpdatedAt = now
+	if err := tm.saveTaskConfig(task); err != nil {
+		logErrorf("Failed to save task config: %v\n", err)
+	}
+	tm.recordTimeline(task.ID, &TimelineEvent{Kind: timelineStatus(task.Status), Message: cause.Error()})
+	tm.emitEvent(task.ID, &ScanEvent{
+		TaskID:    task.ID,
+		EventType: "progress",
+		Data: &ScanProgress{
+			TaskID:            task.ID,
+			TotalRequests:     task.TotalRequests,
+			CompletedRequests: task.CompletedRequests,
+			FoundVulns:        task.FoundVulns,
+			Status:            task.Status,
+			TotalTemplates:    len(task.POCs),
+			SelectedTemplates: task.POCs,
+			ETASeconds:        -1,
+		},
+	})
+}
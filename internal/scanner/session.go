@@ -0,0 +1,305 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoginConfig defines how to obtain a session for authenticated scanning
+type LoginConfig struct {
+	RawRequest        string `json:"raw_request"`         // 登录请求包（原始HTTP请求）
+	Target            string `json:"target"`              // 登录目标，如 https://example.com（为空时使用Host头）
+	TokenSource       string `json:"token_source"`        // 凭证来源：cookie / header / json / regex
+	TokenName         string `json:"token_name"`          // Cookie名 / 响应头名 / JSON路径（如 data.token）
+	TokenRegex        string `json:"token_regex"`         // 从响应体提取凭证的正则（取第一个分组）
+	InjectHeader      string `json:"inject_header"`       // 注入的请求头，默认 cookie 来源为 Cookie，其余为 Authorization
+	InjectFormat      string `json:"inject_format"`       // 注入格式，如 "Bearer {{token}}"，默认 "{{token}}"
+	ExpiryStatusCodes []int  `json:"expiry_status_codes"` // 表示会话过期的状态码，默认 401
+	ExpiryPattern     string `json:"expiry_pattern"`      // 表示会话过期的响应特征（正则，匹配响应头和响应体）
+	MaxRelogins       int    `json:"max_relogins"`        // 扫描中最多重新登录次数，默认 5
+}
+
+// LoginTestResult represents the outcome of a login attempt
+type LoginTestResult struct {
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"status_code"`
+	Header     string `json:"header"`   // 将注入的请求头名
+	Value      string `json:"value"`    // 将注入的值（已脱敏）
+	Response   string `json:"response"` // 登录响应
+	Error      string `json:"error,omitempty"`
+}
+
+// AuthSession holds the current session token and re-logins when it expires
+type AuthSession struct {
+	config        LoginConfig
+	sendOpts      SendOptions
+	expiryPattern *regexp.Regexp
+	tokenRegex    *regexp.Regexp
+
+	mu       sync.Mutex
+	value    string // 当前注入值
+	relogins int    // 已重新登录次数
+}
+
+// NewAuthSession validates the login configuration and creates a session
+func NewAuthSession(config LoginConfig, sendOpts SendOptions) (*AuthSession, error) {
+	if strings.TrimSpace(config.RawRequest) == "" {
+		return nil, fmt.Errorf("登录请求不能为空")
+	}
+
+	config.TokenSource = strings.ToLower(strings.TrimSpace(config.TokenSource))
+	if config.TokenSource == "" {
+		config.TokenSource = "cookie"
+	}
+	if config.InjectHeader == "" {
+		if config.TokenSource == "cookie" {
+			config.InjectHeader = "Cookie"
+		} else {
+			config.InjectHeader = "Authorization"
+		}
+	}
+	if config.InjectFormat == "" {
+		config.InjectFormat = "{{token}}"
+	}
+	if len(config.ExpiryStatusCodes) == 0 {
+		config.ExpiryStatusCodes = []int{401}
+	}
+	if config.MaxRelogins <= 0 {
+		config.MaxRelogins = 5
+	}
+
+	session := &AuthSession{config: config, sendOpts: sendOpts}
+
+	if config.ExpiryPattern != "" {
+		re, err := regexp.Compile(config.ExpiryPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry pattern: %w", err)
+		}
+		session.expiryPattern = re
+	}
+
+	switch config.TokenSource {
+	case "cookie", "header", "json":
+	case "regex":
+		re, err := regexp.Compile(config.TokenRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token regex: %w", err)
+		}
+		session.tokenRegex = re
+	default:
+		return nil, fmt.Errorf("unsupported token source: %s", config.TokenSource)
+	}
+
+	return session, nil
+}
+
+// Login sends the login request and stores the extracted session value
+func (s *AuthSession) Login(ctx context.Context) (*LoginTestResult, error) {
+	result := &LoginTestResult{Header: s.config.InjectHeader}
+
+	rawReq, err := ParseRawHTTPRequest(s.config.RawRequest)
+	if err != nil {
+		return result, fmt.Errorf("failed to parse login request: %w", err)
+	}
+
+	var targetURL string
+	if s.config.Target != "" {
+		targetURL, err = rawReq.URLForTarget(s.config.Target)
+	} else {
+		targetURL, err = rawReq.BuildURL("")
+	}
+	if err != nil {
+		return result, err
+	}
+
+	_, rawResp, status, err := SendRawRequest(ctx, rawReq, targetURL, s.sendOpts)
+	result.StatusCode = status
+	result.Response = rawResp
+	if err != nil {
+		return result, fmt.Errorf("login request failed: %w", err)
+	}
+
+	token, err := s.extractToken(parseRawHTTPResponse(rawResp))
+	if err != nil {
+		return result, err
+	}
+
+	value := strings.ReplaceAll(s.config.InjectFormat, "{{token}}", token)
+
+	s.mu.Lock()
+	s.value = value
+	s.mu.Unlock()
+
+	result.Success = true
+	result.Value = maskValue(value)
+	return result, nil
+}
+
+// extractToken pulls the session token out of the login response
+func (s *AuthSession) extractToken(resp *rawHTTPResponse) (string, error) {
+	switch s.config.TokenSource {
+	case "cookie":
+		var cookies []string
+		for _, h := range resp.Headers {
+			if !strings.EqualFold(h.Name, "Set-Cookie") {
+				continue
+			}
+			pair := strings.TrimSpace(strings.SplitN(h.Value, ";", 2)[0])
+			name := strings.SplitN(pair, "=", 2)[0]
+			if s.config.TokenName == "" || name == s.config.TokenName {
+				cookies = append(cookies, pair)
+			}
+		}
+		if len(cookies) == 0 {
+			return "", fmt.Errorf("登录响应中未找到Cookie %s", s.config.TokenName)
+		}
+		return strings.Join(cookies, "; "), nil
+
+	case "header":
+		if value := findHeader(resp.Headers, s.config.TokenName); value != "" {
+			return value, nil
+		}
+		return "", fmt.Errorf("登录响应中未找到响应头 %s", s.config.TokenName)
+
+	case "json":
+		var body interface{}
+		if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+			return "", fmt.Errorf("登录响应不是合法JSON: %w", err)
+		}
+		for _, key := range strings.Split(s.config.TokenName, ".") {
+			obj, ok := body.(map[string]interface{})
+			if !ok {
+				body = nil
+				break
+			}
+			body = obj[key]
+		}
+		if body == nil {
+			return "", fmt.Errorf("登录响应中未找到字段 %s", s.config.TokenName)
+		}
+		return fmt.Sprintf("%v", body), nil
+
+	case "regex":
+		matches := s.tokenRegex.FindStringSubmatch(resp.Body)
+		if len(matches) < 2 {
+			return "", fmt.Errorf("登录响应未匹配凭证正则")
+		}
+		return matches[1], nil
+	}
+
+	return "", fmt.Errorf("unsupported token source: %s", s.config.TokenSource)
+}
+
+// HeaderValue returns the header name and current value to inject
+func (s *AuthSession) HeaderValue() (string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config.InjectHeader, s.value
+}
+
+// IsExpired reports whether a response indicates the session has expired
+func (s *AuthSession) IsExpired(statusCode int, headers, body string) bool {
+	for _, code := range s.config.ExpiryStatusCodes {
+		if statusCode == code {
+			return true
+		}
+	}
+	if s.expiryPattern != nil {
+		return s.expiryPattern.MatchString(headers) || s.expiryPattern.MatchString(body)
+	}
+	return false
+}
+
+// Relogin logs in again unless another request already refreshed the session
+// after usedValue was issued, or the relogin budget is exhausted
+func (s *AuthSession) Relogin(ctx context.Context, usedValue string) bool {
+	s.mu.Lock()
+	if s.value != usedValue {
+		// 其他请求已完成重新登录
+		s.mu.Unlock()
+		return true
+	}
+	if s.relogins >= s.config.MaxRelogins {
+		s.mu.Unlock()
+		return false
+	}
+	s.relogins++
+	attempt := s.relogins
+	s.mu.Unlock()
+
+	logInfof("🔑 会话已过期，重新登录 (%d/%d)\n", attempt, s.config.MaxRelogins)
+	if _, err := s.Login(ctx); err != nil {
+		logWarnf("⚠️ 重新登录失败: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// maskValue keeps only the first few characters of a secret
+func maskValue(value string) string {
+	if len(value) <= 8 {
+		return "***"
+	}
+	return value[:6] + "***"
+}
+
+// TestLogin performs a login with the given configuration and reports the extracted session
+func (tm *JSONTaskManager) TestLogin(ctx context.Context, config LoginConfig) *LoginTestResult {
+	session, err := NewAuthSession(config, tm.defaultSendOptions())
+	if err != nil {
+		return &LoginTestResult{Error: err.Error()}
+	}
+
+	result, err := session.Login(ctx)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// startSession performs the task's login and starts the session proxy used by nuclei when
+// the task has a login script or evasion settings
+func (sns *SimpleNucleiScanner) startSession() error {
+	if sns.manager == nil {
+		return nil
+	}
+	login := sns.task.Options.Login
+	evasion := newRequestEvasion(sns.evasionConfig(), sns.task.Options)
+	if login == nil && evasion == nil {
+		return nil
+	}
+
+	sendOpts := sns.manager.sendOptionsForTask(sns.task)
+	var session *AuthSession
+	if login != nil {
+		var err error
+		session, err = NewAuthSession(*login, sendOpts)
+		if err != nil {
+			return fmt.Errorf("invalid login config: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		if _, err := session.Login(ctx); err != nil {
+			sns.addLog("ERROR", "", login.Target, fmt.Sprintf("登录失败: %v", err), "", "", false)
+			return fmt.Errorf("登录失败: %w", err)
+		}
+		logInfof("🔑 登录成功，已获取会话凭证\n")
+	}
+	if evasion != nil {
+		logInfof("🥷 启用规避设置: 随机UA=%v, 延迟抖动=%dms, 请求头大小写随机=%v\n",
+			evasion.config.RandomUserAgent, evasion.config.DelayJitterMs, evasion.config.RandomizeHeaderCase)
+	}
+
+	proxy, err := StartSessionProxy(session, evasion, sendOpts)
+	if err != nil {
+		return err
+	}
+	sns.sessionProxy = proxy
+	return nil
+}
@@ -0,0 +1,331 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxInspectedBody limits how much of a response is buffered for session-expiry checks
+const maxInspectedBody = 10 << 20
+
+// SessionProxy is a local HTTP(S) proxy placed between nuclei and the targets. It injects
+// the current session header into every request and re-logins when a response shows
+// the session has expired, and applies the task's evasion settings. HTTPS is intercepted
+// with a throwaway CA, which nuclei accepts because it does not verify certificates.
+type SessionProxy struct {
+	session   *AuthSession    // 为空表示任务未启用登录脚本
+	evasion   *requestEvasion // 为空表示未启用规避设置
+	transport *http.Transport
+	listener  net.Listener
+	server    *http.Server
+
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	certs  map[string]*tls.Certificate
+	certMu sync.Mutex
+}
+
+// StartSessionProxy starts the proxy on a random local port, chaining to the upstream proxy
+// and presenting the client certificate from opts when configured; session and evasion
+// may each be nil
+func StartSessionProxy(session *AuthSession, evasion *requestEvasion, opts SendOptions) (*SessionProxy, error) {
+	caCert, caKey, err := newProxyCA()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         opts.ServerName,
+		MinVersion:         opts.MinTLSVersion,
+	}
+	if err := opts.ClientCert.apply(tlsConfig); err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{
+		TLSClientConfig:     tlsConfig,
+		DialContext:         opts.dialContext(),
+		MaxIdleConnsPerHost: 20,
+		IdleConnTimeout:     30 * time.Second,
+	}
+	if opts.ProxyURL != "" {
+		parsed, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid upstream proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(parsed)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session proxy: %w", err)
+	}
+
+	p := &SessionProxy{
+		session:   session,
+		evasion:   evasion,
+		transport: transport,
+		listener:  listener,
+		caCert:    caCert,
+		caKey:     caKey,
+		certs:     make(map[string]*tls.Certificate),
+	}
+	p.server = &http.Server{Handler: p}
+	go p.server.Serve(listener)
+
+	return p, nil
+}
+
+// URL returns the proxy URL to pass to nuclei
+func (p *SessionProxy) URL() string {
+	return "http://" + p.listener.Addr().String()
+}
+
+// Close stops the proxy
+func (p *SessionProxy) Close() {
+	p.server.Close()
+	p.transport.CloseIdleConnections()
+}
+
+// ServeHTTP handles plain proxied requests and CONNECT tunnels
+func (p *SessionProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.handleConnect(w, r)
+		return
+	}
+
+	r.RequestURI = ""
+	resp := p.forward(r)
+	defer resp.Body.Close()
+
+	for name, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// handleConnect terminates TLS locally and forwards the decrypted requests
+func (p *SessionProxy) handleConnect(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	clientConn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer clientConn.Close()
+
+	if _, err := clientConn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		return
+	}
+
+	host := r.Host
+	tlsConn := tls.Server(clientConn, &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			name := hello.ServerName
+			if name == "" {
+				name, _, _ = net.SplitHostPort(host)
+			}
+			return p.certFor(name)
+		},
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		return
+	}
+
+	reader := bufio.NewReader(tlsConn)
+	for {
+		req, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+		req.URL.Scheme = "https"
+		req.URL.Host = host
+		req.RequestURI = ""
+
+		resp := p.forward(req)
+		writeErr := resp.Write(tlsConn)
+		resp.Body.Close()
+		if writeErr != nil || req.Close || resp.Close {
+			return
+		}
+	}
+}
+
+// forward injects the session header, sends the request and re-logins once on session expiry
+func (p *SessionProxy) forward(req *http.Request) *http.Response {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+	req.Header.Del("Proxy-Connection")
+	req.Header.Del("Proxy-Authorization")
+
+	resp, usedValue, err := p.send(req, body)
+	if err != nil {
+		return errorResponse(req, err)
+	}
+	if p.session == nil {
+		return resp
+	}
+
+	// 检查会话是否过期（需要缓冲响应体）
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxInspectedBody))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	var headerDump strings.Builder
+	resp.Header.Write(&headerDump)
+	if !p.session.IsExpired(resp.StatusCode, headerDump.String(), string(respBody)) {
+		return resp
+	}
+	if !p.session.Relogin(req.Context(), usedValue) {
+		return resp
+	}
+
+	retryResp, _, err := p.send(req, body)
+	if err != nil {
+		return resp
+	}
+	resp.Body.Close()
+	return retryResp
+}
+
+// send performs one round trip with the current session value and the evasion settings
+func (p *SessionProxy) send(req *http.Request, body []byte) (*http.Response, string, error) {
+	out := req.Clone(context.Background())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+
+	var value string
+	if p.session != nil {
+		var name string
+		name, value = p.session.HeaderValue()
+		if strings.EqualFold(name, "Cookie") {
+			if existing := out.Header.Get("Cookie"); existing != "" {
+				out.Header.Set("Cookie", existing+"; "+value)
+			} else {
+				out.Header.Set("Cookie", value)
+			}
+		} else {
+			out.Header.Set(name, value)
+		}
+	}
+	if p.evasion != nil {
+		p.evasion.apply(out)
+		p.evasion.delay()
+	}
+
+	resp, err := p.transport.RoundTrip(out)
+	return resp, value, err
+}
+
+// errorResponse builds a 502 response for upstream failures
+func errorResponse(req *http.Request, err error) *http.Response {
+	body := fmt.Sprintf("session proxy error: %v", err)
+	return &http.Response{
+		StatusCode:    http.StatusBadGateway,
+		Status:        "502 Bad Gateway",
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/plain"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// newProxyCA creates the throwaway CA used to intercept HTTPS
+func newProxyCA() (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "wepoc session proxy"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(7 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// certFor returns (and caches) a leaf certificate for the given host
+func (p *SessionProxy) certFor(host string) (*tls.Certificate, error) {
+	p.certMu.Lock()
+	defer p.certMu.Unlock()
+
+	if cert, ok := p.certs[host]; ok {
+		return cert, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(7 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, p.caCert, &key.PublicKey, p.caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	cert := &tls.Certificate{
+		Certificate: [][]byte{der, p.caCert.Raw},
+		PrivateKey:  key,
+	}
+	p.certs[host] = cert
+	return cert, nil
+}
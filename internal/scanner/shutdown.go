@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"time"
+)
+
+// isShuttingDown reports whether ShutdownTasks was called
+func (tm *JSONTaskManager) isShuttingDown() bool {
+	tm.runningMu.RLock()
+	defer tm.runningMu.RUnlock()
+	return tm.shuttingDown
+}
+
+// ShutdownTasks stops the running scans when the application exits: nuclei is killed, the
+// scanners flush the partial results and the tasks are marked interrupted. Tasks that do not
+// finish within the wait are marked interrupted directly; tasks waiting for their scan
+// window are stopped.
+func (tm *JSONTaskManager) ShutdownTasks() *StopAllResult {
+	tm.runningMu.Lock()
+	tm.shuttingDown = true
+	tm.runningMu.Unlock()
+
+	result := tm.StopAllTasks()
+	for _, taskID := range result.PendingTasks {
+		tm.markInterrupted(taskID)
+	}
+	if len(result.StoppedTasks) > 0 {
+		logInfof("🛑 程序退出: 已中断 %d 个任务（%d 个未能及时保存结果）\n", len(result.StoppedTasks), len(result.PendingTasks))
+	}
+	return result
+}
+
+// recoverInterruptedTasks marks tasks still stored as running as interrupted; their nuclei
+// process ended with the previous run of the application (e.g. a crash)
+func (tm *JSONTaskManager) recoverInterruptedTasks() {
+	tasks, err := tm.listTaskConfigs()
+	if err != nil {
+		return
+	}
+	for _, task := range tasks {
+		if task.Status == "running" {
+			logWarnf("⚠️ 任务 %d 在上次退出时仍在运行，已标记为中断\n", task.ID)
+			tm.markInterrupted(task.ID)
+		}
+	}
+}
+
+// markInterrupted stores a task as interrupted
+func (tm *JSONTaskManager) markInterrupted(taskID int64) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.loadTaskConfig(taskID)
+	if err != nil || task.Status != "running" {
+		return
+	}
+	now := time.Now()
+	task.Status = "interrupted"
+	task.EndTime = &now
+	task.UpdatedAt = now
+	if err := tm.saveTaskConfig(task); err != nil {
+		logWarnf("⚠️ 保存任务 %d 中断状态失败: %v\n", taskID, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -15,15 +16,22 @@ import (
 	"sync"
 	"time"
 
+	"wepoc/internal/i18n"
 	"wepoc/internal/models"
 )
 
+// defaultProgressInterval is the minimum interval between progress events unless configured
+const defaultProgressInterval = 500 * time.Millisecond
+
 // ScanEvent represents a real-time scan event
 type ScanEvent struct {
 	TaskID    int64       `json:"task_id"`
 	EventType string      `json:"event_type"` // progress, log, vuln_found, completed, error
 	Data      interface{} `json:"data"`
 	Timestamp time.Time   `json:"timestamp"`
+	Seq       int64       `json:"seq"` // 任务内事件序号（用于补发遗漏的事件）
+	Code      i18n.Code     `json:"code,omitempty"` // 消息码，前端据此本地化事件说明
+	Args      []interface{} `json:"args,omitempty"` // 消息参数
 }
 
 // ScanProgress represents real-time scan progress
@@ -48,6 +56,11 @@ type ScanProgress struct {
 	FailedTemplateIDs  []string `json:"failed_template_ids"`  // 扫描失败模板的ID集合
 	FilteredTemplateIDs []string `json:"filtered_template_ids"` // 被过滤模板的ID集合
 	SkippedTemplateIDs  []string `json:"skipped_template_ids"`  // 被跳过模板的ID集合
+	RequestsPerSecond float64 `json:"requests_per_second"` // 平均请求速率（来自nuclei统计）
+	ElapsedSeconds    int64   `json:"elapsed_seconds"`     // 已扫描时长（秒）
+	ETASeconds        int64   `json:"eta_seconds"`         // 预计剩余时间（秒），未知时为 -1
+	ETA               string  `json:"eta"`                 // 预计剩余时间（可读格式）
+	EstimatedRequests int     `json:"estimated_requests"`  // 启动时按模板请求数×目标数预估的总请求数（nuclei报告总数前作为总数）
 }
 
 // ScanLogEntry represents a log entry with request/response
@@ -76,8 +89,13 @@ type HTTPRequestLog struct {
 	StatusCode  int       `json:"status_code"`  // HTTP状态码
 	IsVulnFound bool      `json:"is_vuln_found"` // 是否发现漏洞
 	Request     string    `json:"request"`      // 完整请求包
-	Response    string    `json:"response"`     // 完整响应包
+	Response    string    `json:"response"`     // 响应包（超过大小上限时截断，二进制响应体默认省略）
 	Duration    int64     `json:"duration_ms"`  // 请求耗时（毫秒）
+	ContentType       string `json:"content_type,omitempty"`        // 响应的Content-Type
+	ResponseSize      int    `json:"response_size"`                 // 原始响应大小（字节）
+	ResponseTruncated bool   `json:"response_truncated,omitempty"`  // 响应超过大小上限已截断
+	BinaryResponse    bool   `json:"binary_response,omitempty"`     // 二进制响应
+	FullResponseSaved bool   `json:"full_response_saved,omitempty"` // 完整响应已保存，可通过 GetHTTPLogFullResponse 读取
 }
 
 // SimpleNucleiScanner is a simplified scanner that runs nuclei and saves results to JSON
@@ -92,21 +110,49 @@ type SimpleNucleiScanner struct {
 	httpRequestLogs  []*HTTPRequestLog // 新增：HTTP请求日志列表
 	httpLogsMu       sync.Mutex        // 新增：HTTP请求日志互斥锁
 	requestCounter   int64             // 新增：请求计数器
+	responses        *responseCapture  // HTTP请求日志中响应的大小上限与二进制过滤
+	timeline         timelineTracker   // 本次扫描已记录的时间线节点
 	eventChannel     chan *ScanEvent
 	ctx              context.Context
 	lastProgressEmit time.Time
 	lastProgressMu   sync.Mutex
+	progressPending  bool // 是否有待合并发送的进度事件
 	nucleiPath       string          // Add nuclei path configuration
 	tempDir          string          // Temporary directory for templates
+	snapshotFiles    map[string]string // 使用模板快照扫描时 POC -> 快照文件
 	logger           *EnhancedLogger // Enhanced logger for detailed logging
-	templateSet       map[string]bool   // 用于跟踪已扫描的模板（成功+失败）
-	templateSetMu     sync.Mutex        // 保护templateSet的互斥锁
-	failedTemplates   map[string]bool   // 用于跟踪扫描失败的模板
-	failedTemplatesMu sync.Mutex        // 保护failedTemplates的互斥锁
+	templates         *templateTracker  // 按nuclei JSONL执行记录统计已扫描/完成/失败的模板
 	templateIndex     map[string]int    // 模板ID到选择顺序索引的映射（0-based）
 	templateSeverity  map[string]string // 模板ID到严重性的映射
 	templateSevMu     sync.Mutex        // 保护templateSeverity的互斥锁
 	debugLogFile      string            // Debug log file path for nuclei output
+	scanTargets        []string         // 实际扫描的目标（协议探测后）
+	unreachableTargets []string         // 协议探测不可达的目标
+	targetInfo         []*TargetInfo    // 探测时采集的目标标题/指纹
+	sessionProxy       *SessionProxy    // 登录会话注入/规避代理（启用登录脚本或规避设置时）
+	dnsServer          *dnsOverrideServer // Hosts覆盖使用的本地DNS服务器
+	resolversFile      string           // 传给nuclei -r 的DNS服务器列表文件
+	systemResolvers    bool             // 同时传 -sr，自定义DNS解析失败时使用系统解析
+	templateTimer      *templateTimer   // 每个模板的执行耗时统计
+	hostErrors         *hostErrorTracker // 每个目标的错误统计
+	errorStats         *errorCollector   // 按类别统计的错误
+	eventLog           *EventLog         // 持久化事件日志（可按序号重放）
+	eventMu            sync.Mutex        // 保证事件序号与发送顺序一致
+	eventsClosed       bool              // 事件通道是否已关闭
+	cmd                *exec.Cmd         // 正在运行的nuclei进程
+	stopRequested      bool              // 是否已请求停止
+	pauseRequested     bool              // 是否已请求暂停（超出扫描时间窗口或用户暂停）
+	userPaused         bool              // 由用户暂停，等待手动继续
+	resumeFile         string            // 暂停时nuclei写入的续扫文件
+	performance        *PerformanceInfo  // nuclei进程最近一次的资源占用采样
+	perfMu             sync.Mutex
+	throttled          bool              // 资源紧张时以降低的并发启动
+	browserDir         string            // headless模板使用的浏览器目录（加入nuclei的PATH）
+	extraEnv           []string          // 传给nuclei的额外环境变量（code模板配置）
+	config             *models.Config    // 扫描使用的配置（已套用任务引用的配置方案）
+	stopMu             sync.Mutex
+	runCtx             context.Context    // 扫描准备阶段（子域名枚举、目标探测）的上下文，Stop时取消
+	cancelRun          context.CancelFunc
 }
 
 // NewSimpleNucleiScanner creates a new simple nuclei scanner
@@ -114,14 +160,16 @@ func NewSimpleNucleiScanner(task *TaskConfig, manager *JSONTaskManager) *SimpleN
 	// Initialize enhanced logger
 	logger, err := NewEnhancedLogger(task.ID, "SimpleNucleiScanner")
 	if err != nil {
-		fmt.Printf("⚠️ Failed to create enhanced logger: %v\n", err)
+		logWarnf("⚠️ Failed to create enhanced logger: %v\n", err)
 		logger = nil
 	}
 
 	// Get nuclei path from configuration
 	nucleiPath := "nuclei" // Default fallback
+	var config *models.Config
 	if manager != nil && manager.config != nil {
 		nucleiPath = manager.config.NucleiPath
+		config = manager.config
 	}
 
 	// 构建模板索引映射
@@ -134,16 +182,28 @@ func NewSimpleNucleiScanner(task *TaskConfig, manager *JSONTaskManager) *SimpleN
 		task:             task,
 		manager:          manager,
 		timeout:          30 * time.Minute, // Default timeout
-		progress:         &ScanProgress{TaskID: task.ID, Status: "pending", TotalTemplates: len(task.POCs), SelectedTemplates: append([]string{}, task.POCs...)},
+		progress:         &ScanProgress{TaskID: task.ID, Status: "pending", TotalTemplates: len(task.POCs), SelectedTemplates: append([]string{}, task.POCs...), ETASeconds: -1},
 		logs:             make([]*ScanLogEntry, 0),
 		eventChannel:     make(chan *ScanEvent, 100),
 		nucleiPath:       nucleiPath, // Use nuclei path from configuration
+		config:           config,
 		logger:           logger,
-		templateSet:      make(map[string]bool),   // 初始化模板跟踪集合
-		failedTemplates:  make(map[string]bool),   // 初始化失败模板跟踪集合
+		templates:        newTemplateTracker(len(task.Targets)),
 		templateIndex:    idx,
 		templateSeverity: make(map[string]string), // 初始化模板严重性映射
+		templateTimer:    newTemplateTimer(),
+		hostErrors:       newHostErrorTracker(),
+		errorStats:       newErrorCollector(task.ID),
+	}
+	logsDir := ""
+	if manager != nil {
+		logsDir = manager.logsDir
+	}
+	scanner.responses = newResponseCapture(config, logsDir, task.ID)
+	if manager != nil {
+		scanner.responses.seal = manager.sealLogRecord
 	}
+	scanner.runCtx, scanner.cancelRun = context.WithCancel(context.Background())
 
 	// Log scanner initialization
 	if logger != nil {
@@ -170,7 +230,8 @@ func (sns *SimpleNucleiScanner) GetEventChannel() <-chan *ScanEvent {
 	return sns.eventChannel
 }
 
-// emitEvent emits an event to the channel
+// emitEvent persists an event to the task's event log and delivers it to the channel.
+// Delivery never blocks: events that don't fit in the channel are replayed from the log.
 func (sns *SimpleNucleiScanner) emitEvent(eventType string, data interface{}) {
 	event := &ScanEvent{
 		TaskID:    sns.task.ID,
@@ -179,28 +240,60 @@ func (sns *SimpleNucleiScanner) emitEvent(eventType string, data interface{}) {
 		Timestamp: time.Now(),
 	}
 
-	// 对于完成状态的事件，使用阻塞发送确保一定被接收
-	if eventType == "progress" {
-		if progress, ok := data.(*ScanProgress); ok && progress.Status == "completed" {
-			fmt.Printf("📤 强制发送完成事件（阻塞模式）\n")
-			sns.eventChannel <- event // 阻塞发送，确保completed事件一定被接收
-			return
+	sns.eventMu.Lock()
+	defer sns.eventMu.Unlock()
+
+	if sns.eventsClosed {
+		return
+	}
+	if sns.eventLog != nil {
+		if err := sns.eventLog.Append(event); err != nil {
+			logWarnf("⚠️  写入事件日志失败: %v\n", err)
 		}
 	}
 
-	// 其他事件使用非阻塞发送
 	select {
 	case sns.eventChannel <- event:
 	default:
-		// Channel full, skip event
-		fmt.Printf("⚠️  Event channel full, skipping event: %s\n", eventType)
+		// Channel full, the consumer catches up from the event log
+		logWarnf("⚠️  Event channel full, event %d (%s) will be replayed from log\n", event.Seq, eventType)
+	}
+}
+
+// openEvents starts the persistent event log of this scan
+func (sns *SimpleNucleiScanner) openEvents() {
+	if sns.manager == nil {
+		return
 	}
+	eventLog, err := sns.manager.openEventLog(sns.task.ID, sns.task.ResumeFile != "")
+	if err != nil {
+		logWarnf("⚠️  创建事件日志失败: %v\n", err)
+		return
+	}
+	sns.eventMu.Lock()
+	sns.eventLog = eventLog
+	sns.eventMu.Unlock()
+}
+
+// closeEvents closes the event log and the event channel; later events are dropped
+func (sns *SimpleNucleiScanner) closeEvents() {
+	sns.eventMu.Lock()
+	defer sns.eventMu.Unlock()
+
+	if sns.eventsClosed {
+		return
+	}
+	sns.eventsClosed = true
+	if sns.eventLog != nil {
+		sns.eventLog.Close()
+	}
+	close(sns.eventChannel)
 }
 
-// updateProgress updates the scan progress
+// updateProgress updates the scan progress. Status changes are emitted immediately,
+// plain counter updates go through the progress coalescer.
 func (sns *SimpleNucleiScanner) updateProgress(completed int, foundVulns int, status string) {
 	sns.progressMu.Lock()
-	defer sns.progressMu.Unlock()
 
 	if completed > 0 {
 		sns.progress.CompletedRequests = completed
@@ -211,19 +304,77 @@ func (sns *SimpleNucleiScanner) updateProgress(completed int, foundVulns int, st
 	if status != "" {
 		sns.progress.Status = status
 		if status == "completed" {
-			fmt.Printf("🎯 updateProgress设置状态为completed\n")
+			logInfof("🎯 updateProgress设置状态为completed\n")
+			sns.progress.ETASeconds = 0
+			sns.progress.ETA = "0s"
 		}
 	}
 
-	if sns.progress.TotalRequests > 0 {
-		sns.progress.Percentage = float64(sns.progress.CompletedRequests) / float64(sns.progress.TotalRequests) * 100
-	}
+	sns.setPercentage()
+	sns.progressMu.Unlock()
 
 	// Emit progress event
 	if status == "completed" {
-		fmt.Printf("🎯 updateProgress发送completed事件\n")
+		logInfof("🎯 updateProgress发送completed事件\n")
+	}
+	if status != "" {
+		sns.emitProgressNow()
+	} else {
+		sns.emitProgress()
+	}
+}
+
+// progressInterval returns the minimum interval between coalesced progress events
+func (sns *SimpleNucleiScanner) progressInterval() time.Duration {
+	if sns.manager != nil && sns.manager.config != nil && sns.manager.config.ProgressIntervalMs > 0 {
+		return time.Duration(sns.manager.config.ProgressIntervalMs) * time.Millisecond
+	}
+	return defaultProgressInterval
+}
+
+// emitProgress emits a progress event at most once per progress interval. Updates arriving
+// in between are coalesced into a single event carrying the latest state.
+func (sns *SimpleNucleiScanner) emitProgress() {
+	interval := sns.progressInterval()
+
+	sns.lastProgressMu.Lock()
+	since := time.Since(sns.lastProgressEmit)
+	if since >= interval {
+		sns.lastProgressEmit = time.Now()
+		sns.progressPending = false
+		sns.lastProgressMu.Unlock()
+		sns.emitEvent("progress", sns.snapshotProgress())
+		return
+	}
+	if !sns.progressPending {
+		sns.progressPending = true
+		time.AfterFunc(interval-since, sns.flushProgress)
+	}
+	sns.lastProgressMu.Unlock()
+}
+
+// flushProgress emits the pending coalesced progress event, if any
+func (sns *SimpleNucleiScanner) flushProgress() {
+	sns.lastProgressMu.Lock()
+	if !sns.progressPending {
+		sns.lastProgressMu.Unlock()
+		return
 	}
-	sns.emitEvent("progress", sns.progress)
+	sns.progressPending = false
+	sns.lastProgressEmit = time.Now()
+	sns.lastProgressMu.Unlock()
+
+	sns.emitEvent("progress", sns.snapshotProgress())
+}
+
+// emitProgressNow emits a progress event immediately, superseding any pending one
+func (sns *SimpleNucleiScanner) emitProgressNow() {
+	sns.lastProgressMu.Lock()
+	sns.progressPending = false
+	sns.lastProgressEmit = time.Now()
+	sns.lastProgressMu.Unlock()
+
+	sns.emitEvent("progress", sns.snapshotProgress())
 }
 
 // addLog adds a log entry WITHOUT emitting an event (to avoid UI lag)
@@ -266,8 +417,9 @@ func (sns *SimpleNucleiScanner) setTemplateSeverity(templateID, severity string)
 	sns.templateSeverity[templateID] = severity
 }
 
-// addHTTPRequestLog records a single HTTP request/response for display in frontend table
-func (sns *SimpleNucleiScanner) addHTTPRequestLog(templateID, templateName, severity, target, method string, statusCode int, request, response string, isVuln bool, duration int64) {
+// addHTTPRequestLog records a single HTTP request/response for display in frontend table.
+// The response is capped and binary bodies are omitted per the HTTP log config.
+func (sns *SimpleNucleiScanner) addHTTPRequestLog(templateID, templateName, severity, target, method string, statusCode int, request, response string, isVuln bool, duration int64) *HTTPRequestLog {
 	sns.httpLogsMu.Lock()
 	defer sns.httpLogsMu.Unlock()
 
@@ -285,9 +437,10 @@ func (sns *SimpleNucleiScanner) addHTTPRequestLog(templateID, templateName, seve
 		StatusCode:   statusCode,
 		IsVulnFound:  isVuln,
 		Request:      request,
-		Response:     response,
 		Duration:     duration,
 	}
+	httpLog.Response = sns.responses.capture(httpLog, response)
+	sns.markFirstRequest(templateID, target)
 
 	sns.httpRequestLogs = append(sns.httpRequestLogs, httpLog)
 
@@ -304,13 +457,19 @@ func (sns *SimpleNucleiScanner) addHTTPRequestLog(templateID, templateName, seve
 		"status_code":   httpLog.StatusCode,
 		"is_vuln_found": httpLog.IsVulnFound,
 		"duration_ms":   httpLog.Duration,
+		"response_size": httpLog.ResponseSize,
 	})
+	return httpLog
 }
 
 // Start begins the scanning process
 func (sns *SimpleNucleiScanner) Start() error {
 	startTime := time.Now()
 
+	sns.openEvents()
+	defer sns.closeEvents()
+	defer sns.cancelRun()
+
 	// Log scan start
 	if sns.logger != nil {
 		sns.logger.Info("Starting nuclei scan", map[string]interface{}{
@@ -325,6 +484,12 @@ func (sns *SimpleNucleiScanner) Start() error {
 	// Update progress to running
 	sns.updateProgress(0, 0, "running")
 
+	// 套用任务引用的配置方案
+	if err := sns.applyConfigProfile(); err != nil {
+		return err
+	}
+	sns.seedRequestEstimate()
+
 	// Create output directory with absolute path
 	// Use the manager's results directory as base
 	baseDir := sns.manager.resultsDir
@@ -361,6 +526,30 @@ func (sns *SimpleNucleiScanner) Start() error {
 		return fmt.Errorf("failed to prepare output file: %v", err)
 	}
 
+	// 续扫时保留之前扫描片段的结果
+	if err := sns.archivePartialOutput(outputFile); err != nil {
+		return fmt.Errorf("failed to archive partial output: %v", err)
+	}
+
+	// Probe bare host:port targets if requested
+	if err := sns.prepareTargets(sns.runCtx); err != nil {
+		return err
+	}
+
+	// Log in and start the session proxy if the task uses a login script
+	if err := sns.startSession(); err != nil {
+		return err
+	}
+	if sns.sessionProxy != nil {
+		defer sns.sessionProxy.Close()
+	}
+
+	// Custom DNS servers and host overrides
+	if err := sns.startResolver(); err != nil {
+		return err
+	}
+	defer sns.stopResolver()
+
 	// Create targets file
 	targetsFile, err := sns.createTargetsFile()
 	if err != nil {
@@ -372,6 +561,9 @@ func (sns *SimpleNucleiScanner) Start() error {
 		return fmt.Errorf("failed to create targets file: %v", err)
 	}
 	defer os.Remove(targetsFile)
+	if sns.scanTargets != nil {
+		sns.templates = newTemplateTracker(len(sns.scanTargets))
+	}
 
 	// Log targets file creation
 	if sns.logger != nil {
@@ -384,12 +576,13 @@ func (sns *SimpleNucleiScanner) Start() error {
 
 	// Build nuclei command
 	cmd := sns.buildNucleiCommand(targetsFile, outputFile)
+	sns.recordNucleiCommand(cmd, targetsFile, outputDir)
 
 	// Log command construction
 	if sns.logger != nil {
 		cmdInfo := &CommandInfo{
 			Executable:  cmd.Path,
-			Arguments:   cmd.Args[1:], // Skip the executable name
+			Arguments:   MaskCommandArgs(cmd.Args[1:]), // Skip the executable name
 			WorkingDir:  cmd.Dir,
 			Environment: make(map[string]string),
 		}
@@ -431,6 +624,10 @@ func (sns *SimpleNucleiScanner) Start() error {
 
 	// Start the command
 	if err := cmd.Start(); err != nil {
+		sns.errorStats.record(fmt.Sprintf("failed to start nuclei: %v", err))
+		if sns.manager != nil {
+			sns.errorStats.save(sns.manager)
+		}
 		if sns.logger != nil {
 			sns.logger.Error("Failed to start nuclei command", err, map[string]interface{}{
 				"task_id": sns.task.ID,
@@ -440,6 +637,12 @@ func (sns *SimpleNucleiScanner) Start() error {
 		return fmt.Errorf("failed to start nuclei command: %v", err)
 	}
 
+	sns.setCmd(cmd)
+
+	// 采样nuclei进程的CPU/内存占用
+	stopMonitor := make(chan struct{})
+	go sns.monitorResources(cmd.Process.Pid, stopMonitor)
+
 	// Log command start
 	if sns.logger != nil {
 		sns.logger.Info("Nuclei command started", map[string]interface{}{
@@ -454,12 +657,12 @@ func (sns *SimpleNucleiScanner) Start() error {
 
 	go func() {
 		defer wg.Done()
-		sns.monitorStdout(bufio.NewScanner(stdout))
+		sns.monitorStdout(newLineScanner(stdout))
 	}()
 
 	go func() {
 		defer wg.Done()
-		sns.monitorStderr(bufio.NewScanner(stderr))
+		sns.monitorStderr(newLineScanner(stderr))
 	}()
 
 	// Wait for command to complete or timeout
@@ -491,6 +694,18 @@ func (sns *SimpleNucleiScanner) Start() error {
 		}
 		cmdErr = fmt.Errorf("nuclei command timed out after %v", sns.timeout)
 	}
+	close(stopMonitor)
+	if perf := sns.Performance(); perf != nil && sns.logger != nil {
+		sns.logger.LogPerformance(perf, "Nuclei process resource usage", map[string]interface{}{
+			"task_id": sns.task.ID,
+		})
+	}
+
+	if sns.paused() {
+		cmdErr = ErrScanPaused
+	} else if sns.stopped() {
+		cmdErr = ErrScanStopped
+	}
 
 	executionDuration := time.Since(startTime)
 
@@ -503,7 +718,7 @@ func (sns *SimpleNucleiScanner) Start() error {
 
 		cmdInfo := &CommandInfo{
 			Executable: cmd.Path,
-			Arguments:  cmd.Args[1:],
+			Arguments:  MaskCommandArgs(cmd.Args[1:]),
 			WorkingDir: cmd.Dir,
 			Duration:   executionDuration,
 		}
@@ -571,58 +786,34 @@ func (sns *SimpleNucleiScanner) Start() error {
 			})
 		}
 	}
-
-	// 扫描结束后的最终统计
-	sns.progressMu.Lock()
-	
-	// 计算被跳过的模板数量
-	// 跳过的模板 = 总模板 - 被过滤的模板 - 实际扫描的模板
-	actualScanned := len(sns.templateSet)
-	filteredCount := sns.progress.FilteredTemplates
-	skippedCount := sns.progress.TotalTemplates - filteredCount - actualScanned
-	
-	if skippedCount > 0 {
-		sns.progress.SkippedTemplates = skippedCount
-		// 为跳过的模板生成ID列表（用于调试）
-		scannedSet := make(map[string]bool)
-		for templateID := range sns.templateSet {
-			scannedSet[templateID] = true
-		}
-		
-		// 找出被跳过的模板ID
-		for _, templateID := range sns.progress.SelectedTemplates {
-			if !scannedSet[templateID] {
-				sns.progress.SkippedTemplateIDs = append(sns.progress.SkippedTemplateIDs, templateID)
-			}
-		}
-		
-		fmt.Printf("📋 最终统计: 总计%d个POC，过滤%d个，跳过%d个，实际扫描%d个\n", 
-			sns.progress.TotalTemplates, filteredCount, skippedCount, actualScanned)
+	if err := sns.errorStats.save(sns.manager); err != nil {
+		logWarnf("⚠️ 保存错误统计失败: %v\n", err)
 	}
-	
-	sns.progress.ScannedTemplates = actualScanned
-	sns.progress.CompletedTemplates = actualScanned
-	sns.progressMu.Unlock()
-
-	// Update final progress - 打印统计信息
-	fmt.Printf("\n✅ 扫描完成！统计信息：\n")
-	fmt.Printf("   - 已扫描POC: %d/%d\n", actualScanned, sns.progress.TotalTemplates)
 
-	sns.failedTemplatesMu.Lock()
-	actualFailed := len(sns.failedTemplates)
-	sns.failedTemplatesMu.Unlock()
+	// 扫描结束后的最终统计：按nuclei执行记录确定已扫描、失败和跳过的模板
+	counts := sns.finalizeTemplateCounts()
+	logInfof("📋 最终统计: 总计%d个POC，过滤%d个，跳过%d个，实际扫描%d个\n",
+		sns.progress.TotalTemplates, sns.progress.FilteredTemplates, sns.progress.SkippedTemplates, counts.Scanned)
 
-	fmt.Printf("   - 失败POC: %d\n", actualFailed)
-	fmt.Printf("   - 发现漏洞: %d\n", sns.progress.FoundVulns)
-	fmt.Printf("   - 完成请求: %d/%d\n", sns.progress.CompletedRequests, sns.progress.TotalRequests)
-	fmt.Printf("   - 设置状态: completed\n\n")
+	// Update final progress - 打印统计信息
+	logInfof("\n✅ 扫描完成！统计信息：\n")
+	logInfof("   - 已扫描POC: %d/%d\n", counts.Scanned, sns.progress.TotalTemplates)
+	actualFailed := counts.Failed
 
-	// 发送最终的completed状态事件（只发送一次）
-	fmt.Printf("🎯 发送最终completed状态事件到前端\n")
-	sns.updateProgress(sns.progress.CompletedRequests, sns.progress.FoundVulns, "completed")
+	logInfof("   - 失败POC: %d\n", actualFailed)
+	logInfof("   - 发现漏洞: %d\n", sns.progress.FoundVulns)
+	logInfof("   - 完成请求: %d/%d\n", sns.progress.CompletedRequests, sns.progress.TotalRequests)
 
-	// 等待确保事件被发送和处理
-	time.Sleep(200 * time.Millisecond)
+	// 发送最终状态事件（只发送一次）：被停止的任务为 stopped，超出时间窗口暂停的任务为 waiting_window，
+	// 用户暂停的任务为 paused，否则为 completed
+	finalStatus := "completed"
+	if sns.paused() {
+		finalStatus = sns.pausedStatus()
+	} else if sns.stopped() {
+		finalStatus = "stopped"
+	}
+	logInfof("🎯 发送最终%s状态事件到前端\n", finalStatus)
+	sns.updateProgress(sns.progress.CompletedRequests, sns.progress.FoundVulns, finalStatus)
 
 	// Log scan completion
 	if sns.logger != nil {
@@ -640,10 +831,27 @@ func (sns *SimpleNucleiScanner) Start() error {
 	return cmdErr
 }
 
+// Patterns of nuclei output lines, compiled once for all monitored lines
+var (
+	ansiCodePattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	// [INF] Templates loaded for current scan: 123
+	templatesLoadedPattern = regexp.MustCompile(`Templates loaded for current scan: (\d+)`)
+	// [2025-01-24 23:17:14] [CVE-2020-0760] Executing CVE-2020-0760 on http://192.168.1.3:8080
+	executingPattern = regexp.MustCompile(`\[([^\]]+)\] Executing ([^\s]+) on (.+)`)
+	// [INF] [CVE-2017-12615] Dumped HTTP request for http://192.168.1.2:8080/
+	dumpedRequestPattern = regexp.MustCompile(`\[([^\]]+)\] Dumped HTTP request for (https?://[^\s]+)`)
+	// [VER] [CVE-2017-12615] Sent HTTP request to ...
+	logTemplatePattern = regexp.MustCompile(`\[(VER|INF|DBG)\] \[([^\]]+)\]`)
+	requestTargetPattern = regexp.MustCompile(`(?:Sent HTTP request to|Dumped HTTP (?:request|response)) (https?://[^\s]+)`)
+	// 模板ID标记：[CVE-2020-1234]、[tomcat-default-login]等，允许字母（大小写）、数字、连字符、下划线
+	templateIDPattern = regexp.MustCompile(`\[([a-zA-Z][a-zA-Z0-9\-_]+)\]`)
+	// [WRN] Excluded X template[s]
+	excludedTemplatesPattern = regexp.MustCompile(`\[WRN\]\s+Excluded\s+(\d+)\s+(\w+)\s+template`)
+)
+
 // stripAnsiCodes removes ANSI color codes from a string
 func stripAnsiCodes(s string) string {
-	re := regexp.MustCompile(`\x1b\[[0-9;]*m`)
-	return re.ReplaceAllString(s, "")
+	return ansiCodePattern.ReplaceAllString(s, "")
 }
 
 // monitorStdout monitors the nuclei stdout for stats, debug logs, and progress
@@ -652,35 +860,6 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 	var currentTemplate, currentTarget string
 	inRequest, inResponse := false, false
 	
-	// 用于跟踪所有模板的扫描状态
-	allTemplatesSet := make(map[string]bool) // 所有遇到的模板（成功+失败）
-
-	// 辅助函数：统一处理模板计数
-	updateTemplateCount := func(templateID string, reason string) {
-		sns.templateSetMu.Lock()
-		defer sns.templateSetMu.Unlock()
-		
-		// 只有当模板第一次遇到时才更新计数
-		if !allTemplatesSet[templateID] {
-			allTemplatesSet[templateID] = true
-			sns.progressMu.Lock()
-			sns.progress.ScannedTemplates = len(allTemplatesSet)
-			// 记录已扫描模板ID
-			sns.progress.ScannedTemplateIDs = append(sns.progress.ScannedTemplateIDs, templateID)
-			// 更新当前序号（若可解析索引）
-			if idx, ok := sns.templateIndex[templateID]; ok {
-				sns.progress.CurrentIndex = idx + 1 // 1-based
-			} else {
-				// 回退为已扫描数量
-				sns.progress.CurrentIndex = sns.progress.ScannedTemplates
-			}
-			sns.progressMu.Unlock()
-			
-			fmt.Printf("📋 POC扫描进度: %d/%d 个POC已扫描 - %s (%s)\n", 
-				sns.progress.ScannedTemplates, sns.progress.TotalTemplates, templateID, reason)
-		}
-	}
-
 	for stdout.Scan() {
 		rawLine := stdout.Text()
 		line := stripAnsiCodes(rawLine) // Remove ANSI color codes
@@ -700,35 +879,17 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 
 				// Check if this is a vulnerability finding
 				if templateID, ok := jsonData["template-id"].(string); ok {
+					// 每次模板执行（-ms）都有一条记录，据此统计模板进度
+					sns.observeTemplateEvent(jsonData)
+					if matched, ok := jsonData["matcher-status"].(bool); ok && !matched {
+						continue
+					}
+
 					// This is a vulnerability finding!
 					sns.progressMu.Lock()
 					sns.progress.FoundVulns++
 					currentVulns := sns.progress.FoundVulns
-					
-					// 更新当前扫描的模板信息
-					sns.progress.CurrentTemplate = templateID
-					if host, ok := jsonData["host"].(string); ok {
-						sns.progress.CurrentTarget = host
-					}
-					// 更新当前序号
-					if idx, ok := sns.templateIndex[templateID]; ok {
-						sns.progress.CurrentIndex = idx + 1
-					} else {
-						sns.progress.CurrentIndex = sns.progress.ScannedTemplates
-					}
-					
-					// 标记模板为已扫描（成功）- 只更新成功计数，不重复更新总计数
-					sns.templateSetMu.Lock()
-					if !sns.templateSet[templateID] {
-						sns.templateSet[templateID] = true
-						sns.progress.CompletedTemplates = len(sns.templateSet)
-					}
-					sns.templateSetMu.Unlock()
-					
 					sns.progressMu.Unlock()
-					
-					// 统一更新模板计数（如果是第一次遇到这个模板）
-					updateTemplateCount(templateID, "发现漏洞")
 
 					// Get vulnerability details
 					vulnHost := ""
@@ -749,11 +910,13 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 						}
 					}
 
-					fmt.Printf("🐛 发现漏洞 #%d: [%s] %s - %s (目标: %s)\n",
+					logInfof("🐛 发现漏洞 #%d: [%s] %s - %s (目标: %s)\n",
 						currentVulns, vulnSeverity, templateID, vulnName, vulnHost)
 
 					// Immediately emit progress update to show vuln count
-					sns.emitEvent("progress", sns.progress)
+					sns.emitProgress()
+
+					sns.markFirstVuln(templateID, vulnHost)
 
 					// Emit vulnerability found event for real-time notification
 					sns.emitEvent("vuln_found", map[string]interface{}{
@@ -765,6 +928,16 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 						"timestamp":   time.Now().Format("15:04:05"),
 					})
 
+					// 转发到SIEM
+					if sns.manager != nil {
+						sns.manager.forwardFinding(sns.task, jsonData)
+					}
+
+					// 记录nuclei上报的OOB交互（DNS/HTTP外带）
+					if interaction, ok := jsonData["interaction"].(map[string]interface{}); ok && sns.manager != nil {
+						sns.manager.oob.RecordNucleiInteraction(sns.task.ID, templateID, vulnHost, interaction)
+					}
+
 					// Log the vulnerability
 					sns.addLog("VULN", templateID, vulnHost,
 						fmt.Sprintf("[%s] %s - %s", vulnSeverity, templateID, vulnName), "", "", true)
@@ -776,8 +949,7 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 	// 检测Nuclei模板加载和过滤信息
 	if strings.Contains(line, "Templates loaded for current scan:") {
 		// 解析实际加载的模板数量
-		re := regexp.MustCompile(`Templates loaded for current scan: (\d+)`)
-		matches := re.FindStringSubmatch(line)
+		matches := templatesLoadedPattern.FindStringSubmatch(line)
 		if len(matches) > 1 {
 			if loadedCount, err := strconv.Atoi(matches[1]); err == nil {
 				sns.progressMu.Lock()
@@ -785,13 +957,13 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 				filteredCount := sns.progress.TotalTemplates - loadedCount
 				if filteredCount > 0 {
 					sns.progress.FilteredTemplates = filteredCount
-					fmt.Printf("📋 模板过滤: %d/%d 个POC被Nuclei过滤（不适用当前扫描）\n", 
+					logInfof("📋 模板过滤: %d/%d 个POC被Nuclei过滤（不适用当前扫描）\n", 
 						filteredCount, sns.progress.TotalTemplates)
 				}
 				sns.progressMu.Unlock()
 				
 				// 发送进度更新
-				sns.emitEvent("progress", sns.progress)
+				sns.emitProgress()
 			}
 		}
 		continue
@@ -800,8 +972,7 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 	// 检测模板开始扫描的标志
 		if strings.Contains(line, "Executing") && strings.Contains(line, "on") {
 			// 匹配类似 "[2025-01-24 23:17:14] [CVE-2020-0760] Executing CVE-2020-0760 on http://192.168.1.3:8080"
-			re := regexp.MustCompile(`\[([^\]]+)\] Executing ([^\s]+) on (.+)`)
-			matches := re.FindStringSubmatch(line)
+			matches := executingPattern.FindStringSubmatch(line)
 			if len(matches) > 3 {
 				templateID := matches[2]
 				target := matches[3]
@@ -818,11 +989,8 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 				}
 				sns.progressMu.Unlock()
 				
-				// 使用统一的计数函数
-				updateTemplateCount(templateID, fmt.Sprintf("目标: %s", target))
-				
 				// 发送进度更新
-				sns.emitEvent("progress", sns.progress)
+				sns.emitProgress()
 			}
 			continue
 		}
@@ -830,8 +998,7 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 		// Parse request/response from debug output
 		if strings.Contains(line, "Dumped HTTP request for") {
 			// Extract template and target
-			re := regexp.MustCompile(`\[([^\]]+)\] Dumped HTTP request for (https?://[^\s]+)`)
-			matches := re.FindStringSubmatch(line)
+			matches := dumpedRequestPattern.FindStringSubmatch(line)
 			if len(matches) > 2 {
 				currentTemplate = matches[1]
 				currentTarget = matches[2]
@@ -848,21 +1015,8 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 				}
 				sns.progressMu.Unlock()
 				
-				// 使用统一的计数函数
-				updateTemplateCount(currentTemplate, "")
-				
-				// 检查是否为成功扫描的模板
-				sns.templateSetMu.Lock()
-				if !sns.templateSet[currentTemplate] {
-					sns.templateSet[currentTemplate] = true
-					sns.progressMu.Lock()
-					sns.progress.CompletedTemplates = len(sns.templateSet)
-					sns.progressMu.Unlock()
-				}
-				sns.templateSetMu.Unlock()
-				
 				// 发送进度更新
-				sns.emitEvent("progress", sns.progress)
+				sns.emitProgress()
 				
 				inRequest = true
 				currentRequest.Reset()
@@ -870,83 +1024,6 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 			continue
 		}
 
-		// 检测模板扫描失败或跳过的情况
-		if strings.Contains(line, "Could not execute step") || 
-		   strings.Contains(line, "template execution failed") ||
-		   strings.Contains(line, "skipping template") ||
-		   strings.Contains(line, "template not applicable") {
-			
-			// 尝试从错误信息中提取模板ID
-			templateID := ""
-			if strings.Contains(line, "[") && strings.Contains(line, "]") {
-				re := regexp.MustCompile(`\[([^\]]+)\]`)
-				matches := re.FindStringSubmatch(line)
-				if len(matches) > 1 {
-					templateID = matches[1]
-				}
-			}
-			
-			if templateID != "" {
-				sns.failedTemplatesMu.Lock()
-				if !sns.failedTemplates[templateID] {
-					sns.failedTemplates[templateID] = true
-					sns.progressMu.Lock()
-					sns.progress.FailedTemplates = len(sns.failedTemplates)
-					// 记录失败模板ID
-					sns.progress.FailedTemplateIDs = append(sns.progress.FailedTemplateIDs, templateID)
-					// 更新当前序号
-					if idx, ok := sns.templateIndex[templateID]; ok {
-						sns.progress.CurrentIndex = idx + 1
-					}
-					sns.progressMu.Unlock()
-					
-					fmt.Printf("❌ POC扫描失败: %s - %s\n", templateID, line)
-				}
-				sns.failedTemplatesMu.Unlock()
-				
-				// 使用统一的计数函数
-				updateTemplateCount(templateID, "失败")
-				
-				// 发送进度更新
-				sns.emitEvent("progress", sns.progress)
-			}
-			continue
-		}
-
-		// 检测模板完成但没有发现漏洞的情况
-		// 匹配类似 "[CVE-2020-0760] Finished CVE-2020-0760 execution on http://192.168.1.3:8080"
-		// 或者 "[template-id] No match found for template-id on target"
-		if (strings.Contains(line, "Finished") && strings.Contains(line, "execution")) ||
-		   (strings.Contains(line, "No match") && strings.Contains(line, "found")) ||
-		   (strings.Contains(line, "completed") && strings.Contains(line, "template")) {
-			
-			// 尝试从日志中提取模板ID
-			templateID := ""
-			if strings.Contains(line, "[") && strings.Contains(line, "]") {
-				re := regexp.MustCompile(`\[([^\]]+)\]`)
-				matches := re.FindStringSubmatch(line)
-				if len(matches) > 1 {
-					templateID = matches[1]
-				}
-			}
-			
-			if templateID != "" {
-				// 更新当前序号
-				sns.progressMu.Lock()
-				if idx, ok := sns.templateIndex[templateID]; ok {
-					sns.progress.CurrentIndex = idx + 1
-				}
-				sns.progressMu.Unlock()
-
-				// 使用统一的计数函数
-				updateTemplateCount(templateID, "无漏洞")
-				
-				// 发送进度更新
-				sns.emitEvent("progress", sns.progress)
-			}
-			continue
-		}
-
 		// Detect HTTP request start
 		if strings.HasPrefix(line, "GET ") || strings.HasPrefix(line, "POST ") ||
 			strings.HasPrefix(line, "PUT ") || strings.HasPrefix(line, "DELETE ") ||
@@ -990,16 +1067,14 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 					realTarget := currentTarget       // 默认使用currentTarget
 
 					// 正则匹配：\[...\] \[template-id\] ...
-					re := regexp.MustCompile(`\[(VER|INF|DBG)\] \[([^\]]+)\]`)
-					matches := re.FindStringSubmatch(requestStr + responseStr)
+					matches := logTemplatePattern.FindStringSubmatch(requestStr + responseStr)
 					if len(matches) > 2 {
 						realTemplateID = matches[2]
 					}
 
 					// 从请求中提取target URL（从Nuclei日志中）
 					// 示例：Sent HTTP request to http://example.com/path
-					targetRe := regexp.MustCompile(`(?:Sent HTTP request to|Dumped HTTP (?:request|response)) (https?://[^\s]+)`)
-					targetMatches := targetRe.FindStringSubmatch(requestStr + responseStr)
+					targetMatches := requestTargetPattern.FindStringSubmatch(requestStr + responseStr)
 					if len(targetMatches) > 1 {
 						realTarget = targetMatches[1]
 					}
@@ -1028,13 +1103,8 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 						}
 					}
 
-					// Save to old format logs for later viewing
-					sns.addLog("DEBUG", realTemplateID, realTarget,
-						fmt.Sprintf("%s -> %s", realTemplateID, realTarget),
-						requestStr, responseStr, false)
-
 					// 记录到HTTP请求日志（用于前端表格展示）
-					sns.addHTTPRequestLog(
+					httpLog := sns.addHTTPRequestLog(
 						realTemplateID,                      // template_id (使用真实提取的ID)
 						realTemplateID,                      // template_name
 						sns.getTemplateSeverity(realTemplateID), // severity (从缓存获取)
@@ -1047,6 +1117,11 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 						0,                                   // duration_ms
 					)
 
+					// Save to old format logs for later viewing (with the capped response)
+					sns.addLog("DEBUG", realTemplateID, realTarget,
+						fmt.Sprintf("%s -> %s", realTemplateID, realTarget),
+						requestStr, httpLog.Response, false)
+
 					// Emit to frontend in real-time (deprecated, 已由 addHTTPRequestLog 发送)
 					// sns.emitEvent("http", ...)
 				}
@@ -1055,6 +1130,15 @@ func (sns *SimpleNucleiScanner) monitorStdout(stdout *bufio.Scanner) {
 			}
 		}
 	}
+	if err := stdout.Err(); err != nil {
+		logWarnf("⚠️ 读取nuclei标准输出失败: %v\n", err)
+	}
+}
+
+// nucleiLogLevels are the bracketed log level markers of nuclei output, not template IDs
+var nucleiLogLevels = map[string]bool{
+	"INF": true, "VER": true, "DBG": true, "WRN": true,
+	"ERR": true, "FTL": true, "TRC": true, "SIL": true,
 }
 
 // monitorStderr monitors the nuclei stderr for POC progress, HTTP requests/responses
@@ -1063,15 +1147,6 @@ func (sns *SimpleNucleiScanner) monitorStderr(stderr *bufio.Scanner) {
 	var currentTemplate, currentTarget string
 	inRequest, inResponse := false, false
 	lastLine := ""
-
-	// 用于跟踪实际扫描的POC（从stderr的[template-id]标记）
-	scannedPOCs := make(map[string]bool)
-	// 匹配所有template-id：[CVE-2020-1234]、[tomcat-default-login]等
-	// 允许字母（大小写）、数字、连字符、下划线
-	templateIDPattern := regexp.MustCompile(`\[([a-zA-Z][a-zA-Z0-9\-_]+)\]`)
-
-	// 匹配Nuclei过滤信息：[WRN] Excluded X template[s]
-	excludedPattern := regexp.MustCompile(`\[WRN\]\s+Excluded\s+(\d+)\s+(\w+)\s+template`)
 	totalFiltered := 0
 
 	for stderr.Scan() {
@@ -1087,13 +1162,25 @@ func (sns *SimpleNucleiScanner) monitorStderr(stderr *bufio.Scanner) {
 		}
 		lastLine = line
 
+		// 错误分类统计
+		sns.errorStats.observe(line)
+
+		// 暂停时记录nuclei的续扫文件
+		sns.recordResumeFile(line)
+
+		// 记录目标错误；目标因错误过多被nuclei跳过时提示
+		if skipped, ok := sns.hostErrors.observe(line); ok {
+			logWarnf("⚠️ 目标因错误过多被跳过: %s\n", skipped)
+			sns.addLog("WARN", "", skipped, fmt.Sprintf("目标因错误过多被跳过: %s", line), "", "", false)
+		}
+
 		// 解析Nuclei过滤信息：Excluded X template[s]
-		if matches := excludedPattern.FindStringSubmatch(line); len(matches) >= 3 {
+		if matches := excludedTemplatesPattern.FindStringSubmatch(line); len(matches) >= 3 {
 			count := 0
 			if n, err := fmt.Sscanf(matches[1], "%d", &count); err == nil && n == 1 {
 				templateType := matches[2]
 				totalFiltered += count
-				fmt.Printf("📝 Nuclei过滤: %d个%s模板\n", count, templateType)
+				logInfof("📝 Nuclei过滤: %d个%s模板\n", count, templateType)
 
 				// 更新进度
 				sns.progressMu.Lock()
@@ -1102,53 +1189,16 @@ func (sns *SimpleNucleiScanner) monitorStderr(stderr *bufio.Scanner) {
 			}
 		}
 
-		// 解析POC扫描进度：提取 [template-id] 标记
+		// 提取 [template-id] 标记，用于归属HTTP请求和记录模板执行时间（模板计数来自JSONL执行记录）
 		// 格式: [INF] [CVE-2020-1234] ... 或 [VER] [CVE-2020-1234] ...
 		if strings.Contains(line, "[INF]") || strings.Contains(line, "[VER]") || strings.Contains(line, "[DBG]") {
-			matches := templateIDPattern.FindAllStringSubmatch(line, -1)
-			for _, match := range matches {
-				if len(match) > 1 {
-					templateID := match[1]
-					// 跳过日志级别标记（非POC的标记）
-					logLevels := map[string]bool{
-						"INF": true, "VER": true, "DBG": true, "WRN": true,
-						"ERR": true, "FTL": true, "TRC": true, "SIL": true,
-					}
-					if logLevels[templateID] {
-						continue
-					}
-
-					// 检查是否是新的POC
-					if !scannedPOCs[templateID] {
-						scannedPOCs[templateID] = true
-						currentTemplate = templateID
-
-						// 更新进度
-						sns.templateSetMu.Lock()
-						sns.templateSet[templateID] = true
-						scannedCount := len(sns.templateSet)
-						sns.templateSetMu.Unlock()
-
-						sns.progressMu.Lock()
-						sns.progress.ScannedTemplates = scannedCount
-						sns.progress.CompletedTemplates = scannedCount
-						sns.progress.CurrentTemplate = templateID
-						sns.progress.ScannedTemplateIDs = append(sns.progress.ScannedTemplateIDs, templateID)
-
-						// 更新当前序号
-						if idx, ok := sns.templateIndex[templateID]; ok {
-							sns.progress.CurrentIndex = idx + 1
-						} else {
-							sns.progress.CurrentIndex = scannedCount
-						}
-						sns.progressMu.Unlock()
-
-						fmt.Printf("📋 POC扫描: %d/%d - %s\n",
-							scannedCount, sns.progress.TotalTemplates, templateID)
-
-						// 发送进度更新
-						sns.emitEvent("progress", sns.progress)
-					}
+			for _, match := range templateIDPattern.FindAllStringSubmatch(line, -1) {
+				// 跳过日志级别标记（非POC的标记）
+				if templateID := match[1]; !nucleiLogLevels[templateID] {
+					// 每行只取第一个模板标记
+					sns.templateTimer.touch(templateID, false)
+					currentTemplate = templateID
+					break
 				}
 			}
 		}
@@ -1163,6 +1213,8 @@ func (sns *SimpleNucleiScanner) monitorStderr(stderr *bufio.Scanner) {
 				sns.emitHTTPEvent(currentTemplate, currentTarget, currentRequest.String(), currentResponse.String())
 			}
 
+			sns.templateTimer.touch(currentTemplate, true)
+
 			inRequest = true
 			inResponse = false
 			currentRequest.Reset()
@@ -1221,32 +1273,16 @@ func (sns *SimpleNucleiScanner) monitorStderr(stderr *bufio.Scanner) {
 		}
 	}
 
+	if err := stderr.Err(); err != nil {
+		logWarnf("⚠️ 读取nuclei错误输出失败: %v\n", err)
+	}
+
 	// 处理最后可能剩余的请求/响应对
 	if currentRequest.Len() > 0 && currentResponse.Len() > 0 {
 		sns.emitHTTPEvent(currentTemplate, currentTarget, currentRequest.String(), currentResponse.String())
 	}
 
-	// 最终统计
-	sns.progressMu.Lock()
-	actualScanned := len(scannedPOCs)
-	sns.progress.ScannedTemplates = actualScanned
-	sns.progress.CompletedTemplates = actualScanned
-
-	// 计算被跳过的POC数量
-	// 跳过数 = 总数 - 已扫描 - 被过滤
-	sns.progress.SkippedTemplates = sns.progress.TotalTemplates - actualScanned - sns.progress.FilteredTemplates
-	if sns.progress.SkippedTemplates < 0 {
-		sns.progress.SkippedTemplates = 0
-	}
-	sns.progressMu.Unlock()
-
-	fmt.Printf("\n✅ Stderr监控结束\n")
-	fmt.Printf("   - 总POC数: %d\n", sns.progress.TotalTemplates)
-	fmt.Printf("   - 已扫描: %d\n", actualScanned)
-	fmt.Printf("   - 被过滤: %d (Nuclei安全机制)\n", sns.progress.FilteredTemplates)
-	fmt.Printf("   - 被跳过: %d (条件不符)\n", sns.progress.SkippedTemplates)
-	fmt.Printf("   - 统计: %d + %d + %d = %d\n\n",
-		actualScanned, sns.progress.FilteredTemplates, sns.progress.SkippedTemplates, sns.progress.TotalTemplates)
+	logInfof("\n✅ Stderr监控结束\n")
 }
 
 // emitHTTPEvent 发送HTTP请求/响应事件到前端
@@ -1293,7 +1329,7 @@ func (sns *SimpleNucleiScanner) emitHTTPEvent(templateID, target, request, respo
 	}
 
 	// 记录到HTTP请求日志
-	sns.addHTTPRequestLog(
+	httpLog := sns.addHTTPRequestLog(
 		templateID,
 		templateID,
 		sns.getTemplateSeverity(templateID), // 从缓存获取severity
@@ -1311,11 +1347,25 @@ func (sns *SimpleNucleiScanner) emitHTTPEvent(templateID, target, request, respo
 		"template_id": templateID,
 		"target":      target,
 		"request":     request,
-		"response":    response,
+		"response":    httpLog.Response,
 		"timestamp":   time.Now().Format("15:04:05"),
 	})
 
-	fmt.Printf("📨 HTTP请求/响应: %s -> %s\n", templateID, target)
+	logInfof("📨 HTTP请求/响应: %s -> %s\n", templateID, target)
+}
+
+// parseNumericValue parses numeric values from various types
+func parseNumericValue(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case string:
+		return strconv.Atoi(v)
+	case float64:
+		return int(v), nil
+	case int:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("unsupported type: %T", value)
+	}
 }
 
 // parseStatsLine parses the JSON stats output from nuclei
@@ -1345,6 +1395,22 @@ func (sns *SimpleNucleiScanner) parseStatsLine(line string) {
 		}
 	}
 
+	// 已用时长优先取nuclei统计中的duration（h:mm:ss），否则按任务开始时间计算
+	elapsed := time.Since(sns.task.StartTime)
+	if durationVal, ok := stats["duration"].(string); ok {
+		if d, err := parseStatsDuration(durationVal); err == nil && d > 0 {
+			elapsed = d
+		}
+	}
+	rps := 0.0
+	if elapsed >= time.Second && completed > 0 {
+		rps = float64(completed) / elapsed.Seconds()
+	} else if rpsVal, ok := stats["rps"]; ok {
+		if val, err := parseNumericValue(rpsVal); err == nil {
+			rps = float64(val)
+		}
+	}
+
 	// Update progress
 	sns.progressMu.Lock()
 	if total > 0 {
@@ -1352,30 +1418,49 @@ func (sns *SimpleNucleiScanner) parseStatsLine(line string) {
 	}
 	sns.progress.CompletedRequests = completed
 	sns.progress.FoundVulns = matched
-	if sns.progress.TotalRequests > 0 {
-		sns.progress.Percentage = float64(completed) / float64(sns.progress.TotalRequests) * 100
+	sns.setPercentage()
+	sns.progress.RequestsPerSecond = math.Round(rps*10) / 10
+	sns.progress.ElapsedSeconds = int64(elapsed.Seconds())
+	sns.progress.ETASeconds = -1
+	sns.progress.ETA = ""
+	if rps > 0 && sns.progress.TotalRequests > 0 {
+		remaining := sns.progress.TotalRequests - completed
+		if remaining < 0 {
+			remaining = 0
+		}
+		eta := time.Duration(float64(remaining) / rps * float64(time.Second)).Round(time.Second)
+		sns.progress.ETASeconds = int64(eta.Seconds())
+		sns.progress.ETA = eta.String()
 	}
 	sns.progressMu.Unlock()
 
-	// Emit progress event more frequently - every 0.02 seconds or every request
-	// This ensures frontend gets real-time updates even for very fast scans
-	sns.lastProgressMu.Lock()
-	now := time.Now()
-	timeSinceLastEmit := now.Sub(sns.lastProgressEmit)
-	shouldEmit := timeSinceLastEmit >= 20*time.Millisecond || completed%1 == 0 // Emit every 0.02 seconds OR every request
+	if completed > 0 {
+		sns.markFirstRequest("", "")
+	}
 
-	if shouldEmit {
-		sns.lastProgressEmit = now
-		sns.lastProgressMu.Unlock()
+	// 进度事件经合并层限流发送，避免快速扫描时前端卡顿
+	sns.emitProgress()
 
-		// Emit progress event
-		sns.emitEvent("progress", sns.progress)
+	logInfof("📊 进度: %d/%d (%.1f%%), 发现漏洞: %d, 速率: %.1f req/s, 剩余: %s\n",
+		completed, sns.progress.TotalRequests, sns.progress.Percentage, matched, sns.progress.RequestsPerSecond, sns.progress.ETA)
+}
 
-		fmt.Printf("📊 进度: %d/%d (%.1f%%), 发现漏洞: %d\n",
-			completed, sns.progress.TotalRequests, sns.progress.Percentage, matched)
-	} else {
-		sns.lastProgressMu.Unlock()
+// parseStatsDuration parses the "h:mm:ss" duration reported in nuclei stats
+func parseStatsDuration(value string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid stats duration: %s", value)
+	}
+	var total time.Duration
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid stats duration: %s", value)
+		}
+		total += time.Duration(n) * units[i]
 	}
+	return total, nil
 }
 
 // prepareOutputFile creates the output directory if it doesn't exist
@@ -1394,7 +1479,11 @@ func (sns *SimpleNucleiScanner) createTargetsFile() (string, error) {
 	defer tmpFile.Close()
 
 	// Write targets to file
-	for _, target := range sns.task.Targets {
+	targets := sns.scanTargets
+	if targets == nil {
+		targets = sns.task.Targets
+	}
+	for _, target := range targets {
 		if _, err := tmpFile.WriteString(target + "\n"); err != nil {
 			os.Remove(tmpFile.Name())
 			return "", err
@@ -1404,6 +1493,51 @@ func (sns *SimpleNucleiScanner) createTargetsFile() (string, error) {
 	return tmpFile.Name(), nil
 }
 
+// interactshArgs returns the DNS out-of-band (Interactsh) arguments of a nuclei run: the
+// task's own server or the configured one, or -no-interactsh when it is turned off or the
+// public servers can't be used in offline mode
+func interactshArgs(config *models.Config, options TaskOptions) []string {
+	if config == nil {
+		return nil
+	}
+	nucleiConfig := config.NucleiConfig
+
+	// 任务级Interactsh配置优先于全局配置
+	interactshServer := nucleiConfig.InteractshServer
+	interactshToken := nucleiConfig.InteractshToken
+	taskInteractsh := options.InteractshServer != ""
+	if taskInteractsh {
+		interactshServer = options.InteractshServer
+		interactshToken = options.InteractshToken
+	}
+
+	customInteractsh := (nucleiConfig.InteractshEnabled || taskInteractsh) && interactshServer != ""
+
+	var args []string
+	// 如果完全禁用 Interactsh
+	if nucleiConfig.InteractshDisable && !taskInteractsh {
+		args = append(args, "-no-interactsh")
+		logInfof("🔧 DNS外带功能已禁用: -no-interactsh\n")
+	} else if config.Offline && !customInteractsh {
+		// 离线模式下不使用公共Interactsh服务器
+		args = append(args, "-no-interactsh")
+		logInfof("🔧 离线模式，未配置自建Interactsh服务器，DNS外带功能已禁用\n")
+	} else if nucleiConfig.InteractshEnabled || taskInteractsh {
+		// 启用 Interactsh 并配置自定义服务器
+		if interactshServer != "" {
+			args = append(args, "-interactsh-server", interactshServer)
+			logInfof("🔧 使用自定义Interactsh服务器: %s\n", interactshServer)
+		}
+
+		// 添加 Interactsh Token（如果有）
+		if interactshToken != "" {
+			args = append(args, "-interactsh-token", interactshToken)
+			logInfof("🔧 使用Interactsh认证Token\n")
+		}
+	}
+	return args
+}
+
 // buildNucleiCommand builds the nuclei command with -debug flag
 func (sns *SimpleNucleiScanner) buildNucleiCommand(targetsFile, outputFile string) *exec.Cmd {
 	// Build command arguments - following user's specification
@@ -1411,6 +1545,7 @@ func (sns *SimpleNucleiScanner) buildNucleiCommand(targetsFile, outputFile strin
 		"-l", targetsFile, // Target list file
 		"-jle", outputFile, // JSONL export to file (matches user's spec)
 		"-jsonl",               // Also output JSONL to stdout for real-time parsing
+		"-ms",                  // Matcher status: a JSONL record for every template execution (non-matches and errors too), used to count templates
 		"-include-rr",          // Include request/response in outputs
 		"-stats",               // Show statistics
 		"-stats-interval", "2", // Stats interval (as specified)
@@ -1420,48 +1555,105 @@ func (sns *SimpleNucleiScanner) buildNucleiCommand(targetsFile, outputFile strin
 		"-nc", // No color output
 		"-v",  // Verbose
 	}
+	args = append(args, updateCheckArgs()...)
 
 	// 添加 DNS 外带 (Interactsh) 配置
-	if sns.manager != nil && sns.manager.config != nil {
-		nucleiConfig := sns.manager.config.NucleiConfig
-
-		// 如果完全禁用 Interactsh
-		if nucleiConfig.InteractshDisable {
-			args = append(args, "-no-interactsh")
-			fmt.Printf("🔧 DNS外带功能已禁用: -no-interactsh\n")
-		} else if nucleiConfig.InteractshEnabled {
-			// 启用 Interactsh 并配置自定义服务器
-			if nucleiConfig.InteractshServer != "" {
-				args = append(args, "-interactsh-server", nucleiConfig.InteractshServer)
-				fmt.Printf("🔧 使用自定义Interactsh服务器: %s\n", nucleiConfig.InteractshServer)
-			}
+	args = append(args, interactshArgs(sns.config, sns.task.Options)...)
 
-			// 添加 Interactsh Token（如果有）
-			if nucleiConfig.InteractshToken != "" {
-				args = append(args, "-interactsh-token", nucleiConfig.InteractshToken)
-				fmt.Printf("🔧 使用Interactsh认证Token\n")
-			}
+	// 客户端证书（mTLS）：任务配置优先于全局配置
+	if sns.manager != nil {
+		if certArgs := sns.manager.clientCertForTask(sns.task).NucleiArgs(); len(certArgs) > 0 {
+			args = append(args, certArgs...)
+			logInfof("🔧 使用客户端证书: %s\n", certArgs[1])
+		}
+		if tlsArgs := sns.manager.tlsOptionsForTask(sns.task).NucleiArgs(); len(tlsArgs) > 0 {
+			args = append(args, tlsArgs...)
+			logInfof("🔧 TLS参数: %s\n", strings.Join(tlsArgs, " "))
+		}
+	}
+
+	// 自定义DNS服务器/Hosts覆盖
+	if resolverArgs := sns.resolverArgs(); len(resolverArgs) > 0 {
+		args = append(args, resolverArgs...)
+		logInfof("🔧 使用自定义DNS解析: %s\n", strings.Join(resolverArgs, " "))
+	}
+
+	// 模板变量覆盖
+	if varArgs := VarArgs(sns.task.Options.Variables); len(varArgs) > 0 {
+		args = append(args, varArgs...)
+		logInfof("🔧 使用模板变量: %d 个\n", len(varArgs)/2)
+	}
+
+	// 任务自定义请求头/Cookie
+	if headerArgs := sns.task.Options.HeaderArgs(); len(headerArgs) > 0 {
+		args = append(args, headerArgs...)
+		logInfof("🔧 使用自定义请求头: %d 个\n", len(headerArgs)/2)
+	}
+
+	// 速率配置
+	config := sns.config
+	rateProfile := EffectiveRateProfile(sns.task.Options, config)
+	if len(wafTargets(sns.targetInfo)) > 0 && sns.task.Options.WAFRateProfile != "" {
+		rateProfile = wafRateProfile(rateProfile, sns.task.Options)
+		logInfof("🛡️ 存在WAF/CDN目标，使用速率配置: %s\n", rateProfile.Name)
+	}
+	if sns.throttled {
+		rateProfile = throttledProfile(rateProfile)
+	}
+	args = append(args, rateProfile.Args()...)
+	logInfof("🔧 速率配置: %s (-rl %d, -c %d, -bulk-size %d)\n", rateProfile.Name, rateProfile.RateLimit, rateProfile.Concurrency, rateProfile.BulkSize)
+
+	// headless模板（浏览器）
+	args = append(args, sns.headlessArgs(config)...)
+
+	// 代理配置（包含认证信息）；启用登录脚本时经由会话代理转发
+	if sns.sessionProxy != nil {
+		args = append(args, "-proxy", sns.sessionProxy.URL())
+		logInfof("🔑 使用会话代理: %s\n", sns.sessionProxy.URL())
+	} else if config != nil {
+		if proxyArgs := NucleiProxyArgs(config.NucleiConfig); len(proxyArgs) > 0 {
+			args = append(args, proxyArgs...)
+			logInfof("🔧 使用代理: %s\n", MaskProxyList(proxyArgs[1]))
+		}
+	}
+
+	// 快照重扫和续扫使用任务启动时记录的模板版本
+	sns.prepareTemplateSnapshot()
+
+	// code协议模板（需在设置中允许并确认，按实际使用的模板文件核对）
+	args = append(args, sns.codeArgs(config)...)
+
+	// 工作流任务以 -w 执行工作流，失败时回退到直接扫描其引用的模板
+	workflow := false
+	if sns.task.Workflow != "" {
+		if err := sns.addWorkflow(&args); err != nil {
+			logWarnf("⚠️  准备工作流失败，回退到模板列表模式: %v\n", err)
+			sns.addLog("WARN", "", "", fmt.Sprintf("准备工作流失败，按模板列表扫描: %v", err), "", "", false)
+		} else {
+			workflow = true
 		}
 	}
 
 	// Use temporary directory approach to avoid Windows command line length limits
-	if len(sns.task.POCs) > 100 { // Use temp directory for large template sets
+	if workflow {
+		// 模板由工作流引用
+	} else if len(sns.task.POCs) > 100 { // Use temp directory for large template sets
 		tempManager, err := NewTempManager()
 		if err != nil {
-			fmt.Printf("⚠️  创建临时目录管理器失败，回退到单个模板模式: %v\n", err)
+			logWarnf("⚠️  创建临时目录管理器失败，回退到单个模板模式: %v\n", err)
 			// Fallback to individual templates
 			sns.addIndividualTemplates(&args)
 		} else {
 			// Create temporary directory with selected templates
-			tempDir, err := tempManager.CreateTempTemplateDir(sns.task.ID, sns.task.POCs)
+			tempDir, err := tempManager.CreateTempTemplateDir(sns.task.ID, sns.templateFiles())
 			if err != nil {
-				fmt.Printf("⚠️  创建临时模板目录失败，回退到单个模板模式: %v\n", err)
+				logWarnf("⚠️  创建临时模板目录失败，回退到单个模板模式: %v\n", err)
 				// Fallback to individual templates
 				sns.addIndividualTemplates(&args)
 			} else {
 				// Use directory parameter instead of individual -t parameters
 				args = append(args, "-t", tempDir)
-				fmt.Printf("🚀 使用临时目录模式: %s (包含 %d 个模板)\n", tempDir, len(sns.task.POCs))
+				logInfof("🚀 使用临时目录模式: %s (包含 %d 个模板)\n", tempDir, len(sns.task.POCs))
 
 				// Store temp directory for cleanup
 				sns.tempDir = tempDir
@@ -1472,11 +1664,17 @@ func (sns *SimpleNucleiScanner) buildNucleiCommand(targetsFile, outputFile strin
 		sns.addIndividualTemplates(&args)
 	}
 
+	// 模板引用的载荷文件解析到字典库，并注入默认凭证字典
+	sns.applyTemplateOverrides(&args)
+
+	// 从暂停处继续扫描
+	args = append(args, sns.resumeArgs()...)
+
 	// Log the command being executed for debugging
-	fmt.Printf("🔧 执行命令: %s %v\n", sns.nucleiPath, args)
+	logInfof("🔧 执行命令: %s %v\n", sns.nucleiPath, MaskCommandArgs(args))
 
 	// Save debug info to log file
-	sns.logDebugInfo(sns.nucleiPath, args, outputFile)
+	sns.logDebugInfo(sns.nucleiPath, MaskCommandArgs(args), outputFile)
 
 	cmd := exec.Command(sns.nucleiPath, args...)
 
@@ -1503,36 +1701,221 @@ func (sns *SimpleNucleiScanner) buildNucleiCommand(targetsFile, outputFile strin
 		hideWindowOnWindows(cmd)
 	}
 
+	// 使 -system-chrome 找到下载或设置中指定的浏览器
+	if sns.browserDir != "" {
+		env := cmd.Env
+		if env == nil {
+			env = os.Environ()
+		}
+		cmd.Env = withPathPrefix(env, sns.browserDir)
+	}
+	if len(sns.extraEnv) > 0 {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, sns.extraEnv...)
+	}
+
 	return cmd
 }
 
+// seedRequestEstimate uses the request estimate of the task's templates as the total until
+// nuclei reports its own, so that the progress moves from the first stats line on
+func (sns *SimpleNucleiScanner) seedRequestEstimate() {
+	if sns.manager == nil {
+		return
+	}
+	report, err := sns.manager.estimateTask(sns.task)
+	if err != nil || report.EstimatedRequests <= 0 {
+		return
+	}
+	sns.progressMu.Lock()
+	sns.progress.EstimatedRequests = report.EstimatedRequests
+	if sns.progress.TotalRequests == 0 {
+		sns.progress.TotalRequests = report.EstimatedRequests
+	}
+	sns.progressMu.Unlock()
+	logInfof("📐 预估总请求数: %d\n", report.EstimatedRequests)
+}
+
+// setPercentage computes the percentage from the request counts (progressMu held). Nuclei
+// revises its total while templates load, so a running scan never goes backwards and stays
+// below 100% until it finishes.
+func (sns *SimpleNucleiScanner) setPercentage() {
+	progress := sns.progress
+	if progress.Status == "completed" {
+		progress.Percentage = 100
+		return
+	}
+	if progress.TotalRequests <= 0 {
+		return
+	}
+	if progress.CompletedRequests > progress.TotalRequests {
+		progress.TotalRequests = progress.CompletedRequests
+	}
+	percentage := float64(progress.CompletedRequests) / float64(progress.TotalRequests) * 100
+	if percentage > 99 {
+		percentage = 99
+	}
+	if progress.Status == "running" && percentage < progress.Percentage {
+		percentage = progress.Percentage
+	}
+	progress.Percentage = percentage
+}
+
+// applyConfigProfile switches the scan to the config profile referenced by the task
+func (sns *SimpleNucleiScanner) applyConfigProfile() error {
+	if sns.manager == nil || sns.task.Options.ConfigProfile == "" {
+		return nil
+	}
+	config, err := sns.manager.configForTask(sns.task)
+	if err != nil {
+		sns.addLog("ERROR", "", "", err.Error(), "", "", false)
+		return err
+	}
+	sns.config = config
+	if config.NucleiPath != "" {
+		sns.nucleiPath = config.NucleiPath
+	}
+	logInfof("🔧 使用配置方案: %s\n", config.ActiveProfile)
+	sns.addLog("INFO", "", "", fmt.Sprintf("使用配置方案: %s", config.ActiveProfile), "", "", false)
+	return nil
+}
+
 // addIndividualTemplates adds individual template files to the command arguments
 func (sns *SimpleNucleiScanner) addIndividualTemplates(args *[]string) {
-	fmt.Printf("使用的模板文件:\n")
+	logInfof("使用的模板文件:\n")
 	for _, poc := range sns.task.POCs {
-		// Check if poc is already an absolute path
-		var templateFile string
-		if filepath.IsAbs(poc) {
-			// It's already an absolute path from frontend
-			templateFile = poc
-		} else {
-			// It's a relative path, add base directory
-			homeDir, _ := os.UserHomeDir()
-			templatesDir := filepath.Join(homeDir, ".wepoc", "nuclei-templates")
+		templateFile := sns.templateFile(poc)
 
-			// Check if it has .yaml extension
-			if strings.HasSuffix(poc, ".yaml") || strings.HasSuffix(poc, ".yml") {
-				templateFile = filepath.Join(templatesDir, poc)
-			} else {
-				templateFile = filepath.Join(templatesDir, poc+".yaml")
+		// Add template file directly without checking existence (already validated during import)
+		*args = append(*args, "-t", templateFile)
+		logInfof("  📄 %s\n", templateFile)
+	}
+	logInfof("模板数量: %d\n", len(sns.task.POCs))
+}
+
+// applyTemplateOverrides rewrites template copies before the scan: payload file references
+// that resolve to managed wordlists (the wordlists are copied into the temp directory) and,
+// when enabled for the task, default-login payloads extended with the credential dictionary
+func (sns *SimpleNucleiScanner) applyTemplateOverrides(args *[]string) {
+	homeDir, _ := os.UserHomeDir()
+	templatesDir, ok := templateRootDir(sns.task.Options.TemplateRoot)
+	if !ok {
+		templatesDir = defaultTemplatesDir()
+	}
+
+	baseDir := sns.tempDir
+	if baseDir == "" {
+		baseDir = filepath.Join(homeDir, ".wepoc", "tmp", fmt.Sprintf("task_%d_overrides_%d", sns.task.ID, time.Now().Unix()))
+	}
+
+	wordlists, err := NewWordlistManager()
+	if err != nil {
+		logWarnf("⚠️  初始化字典库失败: %v\n", err)
+	}
+
+	var credentials *CredentialDictionary
+	if sns.task.Options.InjectCredentials {
+		if store, err := NewCredentialStore(); err != nil {
+			logWarnf("⚠️  初始化凭证字典失败: %v\n", err)
+		} else if credentials, err = store.Load(); err != nil {
+			logWarnf("⚠️  加载凭证字典失败: %v\n", err)
+		}
+	}
+
+	if wordlists == nil && credentials == nil {
+		return
+	}
+
+	usedWordlists := false
+	rewrite := func(content []byte) ([]byte, bool, error) {
+		changed := false
+		if wordlists != nil {
+			data, wordlistChanged, err := wordlists.rewritePayloadFiles(content, templatesDir, filepath.Join(baseDir, "wordlists"))
+			if err != nil {
+				return nil, false, err
+			}
+			if wordlistChanged {
+				content, changed, usedWordlists = data, true, true
 			}
 		}
+		if credentials != nil {
+			data, credChanged, err := credentials.rewriteTemplateCredentials(content)
+			if err != nil {
+				return nil, false, err
+			}
+			if credChanged {
+				content, changed = data, true
+			}
+		}
+		return content, changed, nil
+	}
 
-		// Add template file directly without checking existence (already validated during import)
-		*args = append(*args, "-t", templateFile)
-		fmt.Printf("  📄 %s\n", templateFile)
+	rewritten := 0
+	if sns.tempDir != "" {
+		// 临时目录模式：直接改写目录中的模板副本
+		filepath.Walk(sns.tempDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !(strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
+				return nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			data, changed, err := rewrite(content)
+			if err != nil || !changed {
+				return nil
+			}
+			if err := os.WriteFile(path, data, 0644); err == nil {
+				rewritten++
+			}
+			return nil
+		})
+	} else {
+		// 单模板模式：将需要改写的模板复制到临时目录后替换 -t 参数
+		for i := 0; i+1 < len(*args); i++ {
+			if (*args)[i] != "-t" {
+				continue
+			}
+			templateFile := (*args)[i+1]
+			content, err := os.ReadFile(templateFile)
+			if err != nil {
+				continue
+			}
+			data, changed, err := rewrite(content)
+			if err != nil {
+				logWarnf("⚠️  改写模板失败 %s: %v\n", templateFile, err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			if err := os.MkdirAll(baseDir, 0755); err != nil {
+				logWarnf("⚠️  创建临时目录失败: %v\n", err)
+				return
+			}
+			dst := filepath.Join(baseDir, fmt.Sprintf("%d_%s", rewritten, filepath.Base(templateFile)))
+			if err := os.WriteFile(dst, data, 0644); err != nil {
+				logWarnf("⚠️  写入模板副本失败: %v\n", err)
+				continue
+			}
+			(*args)[i+1] = dst
+			rewritten++
+		}
+		if rewritten > 0 {
+			// Store temp directory for cleanup
+			sns.tempDir = baseDir
+		}
+	}
+
+	if usedWordlists {
+		// 字典位于模板目录之外，需要允许本地文件访问
+		*args = append(*args, "-lfa")
+	}
+	if rewritten > 0 {
+		logInfof("📚 已改写 %d 个模板（字典库/凭证字典）\n", rewritten)
 	}
-	fmt.Printf("模板数量: %d\n", len(sns.task.POCs))
 }
 
 // logDebugInfo saves debug information to log file
@@ -1540,14 +1923,14 @@ func (sns *SimpleNucleiScanner) logDebugInfo(nucleiPath string, args []string, o
 	// Get home directory
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		fmt.Printf("❌ 无法获取用户目录: %v\n", err)
+		logErrorf("❌ 无法获取用户目录: %v\n", err)
 		return
 	}
 
 	// Create logs directory
 	logsDir := filepath.Join(homeDir, ".wepoc", "logs")
 	if err := os.MkdirAll(logsDir, 0755); err != nil {
-		fmt.Printf("❌ 无法创建日志目录: %v\n", err)
+		logErrorf("❌ 无法创建日志目录: %v\n", err)
 		return
 	}
 
@@ -1561,7 +1944,7 @@ func (sns *SimpleNucleiScanner) logDebugInfo(nucleiPath string, args []string, o
 	// Open log file
 	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
-		fmt.Printf("❌ 无法创建日志文件: %v\n", err)
+		logErrorf("❌ 无法创建日志文件: %v\n", err)
 		return
 	}
 	defer file.Close()
@@ -1606,24 +1989,14 @@ func (sns *SimpleNucleiScanner) logDebugInfo(nucleiPath string, args []string, o
 
 	fmt.Fprintf(file, "=== 模板文件列表 ===\n")
 	for i, poc := range sns.task.POCs {
-		var templateFile string
-		if filepath.IsAbs(poc) {
-			templateFile = poc
-		} else {
-			templatesDir := filepath.Join(homeDir, ".wepoc", "nuclei-templates")
-			if strings.HasSuffix(poc, ".yaml") {
-				templateFile = filepath.Join(templatesDir, poc)
-			} else {
-				templateFile = filepath.Join(templatesDir, poc+".yaml")
-			}
-		}
+		templateFile := sns.templateFile(poc)
 
 		// 只记录模板文件路径，不检查存在性（提升性能）
 		fmt.Fprintf(file, "📄 模板 %d: %s\n", i+1, templateFile)
 	}
 	fmt.Fprintf(file, "\n")
 
-	fmt.Printf("📝 调试信息已保存到: %s\n", logFile)
+	logInfof("📝 调试信息已保存到: %s\n", logFile)
 }
 
 // logNucleiOutput logs nuclei stdout/stderr to debug file
@@ -1642,8 +2015,15 @@ func (sns *SimpleNucleiScanner) logNucleiOutput(line string, isStderr bool) {
 	if isStderr {
 		prefix = "[STDERR]"
 	}
+
+	// 屏蔽自定义请求头/Cookie中的敏感值
+	line = MaskSecrets(line, sns.task.Options.SecretValues())
 	
 	fmt.Fprintf(file, "%s %s %s\n", time.Now().Format("15:04:05"), prefix, line)
+
+	if sns.manager != nil {
+		sns.manager.streamLogLine(sns.task.ID, line, isStderr)
+	}
 }
 
 // logError saves error information to log file
@@ -1651,14 +2031,14 @@ func (sns *SimpleNucleiScanner) logError(message string, err error, nucleiPath,
 	// Get home directory
 	homeDir, err2 := os.UserHomeDir()
 	if err2 != nil {
-		fmt.Printf("❌ 无法获取用户目录: %v\n", err2)
+		logErrorf("❌ 无法获取用户目录: %v\n", err2)
 		return
 	}
 
 	// Create logs directory
 	logsDir := filepath.Join(homeDir, ".wepoc", "logs")
 	if err2 := os.MkdirAll(logsDir, 0755); err2 != nil {
-		fmt.Printf("❌ 无法创建日志目录: %v\n", err2)
+		logErrorf("❌ 无法创建日志目录: %v\n", err2)
 		return
 	}
 
@@ -1669,7 +2049,7 @@ func (sns *SimpleNucleiScanner) logError(message string, err error, nucleiPath,
 	// Open log file
 	file, err2 := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err2 != nil {
-		fmt.Printf("❌ 无法创建错误日志文件: %v\n", err2)
+		logErrorf("❌ 无法创建错误日志文件: %v\n", err2)
 		return
 	}
 	defer file.Close()
@@ -1703,34 +2083,45 @@ func (sns *SimpleNucleiScanner) logError(message string, err error, nucleiPath,
 	}
 	fmt.Fprintf(file, "\n")
 
-	fmt.Printf("📝 错误信息已保存到: %s\n", logFile)
+	logInfof("📝 错误信息已保存到: %s\n", logFile)
 }
 
 // processResults processes the nuclei output and creates a result file
 func (sns *SimpleNucleiScanner) processResults(outputFile string) error {
+	// 续扫任务的结果包括之前扫描片段的输出
+	outputFiles := partialOutputFiles(outputFile)
+	if _, err := os.Stat(outputFile); err == nil {
+		outputFiles = append(outputFiles, outputFile)
+	}
+
 	// Check if output file exists
-	if _, err := os.Stat(outputFile); os.IsNotExist(err) {
-		fmt.Printf("📄 输出文件不存在，创建空结果...\n")
+	if len(outputFiles) == 0 {
+		logInfof("📄 输出文件不存在，创建空结果...\n")
 		// No output file means no vulnerabilities found
 		return sns.createEmptyResult()
 	}
 
-	fmt.Printf("📄 读取输出文件: %s\n", outputFile)
-
 	// Read and parse the JSONL output
-	vulnerabilities, err := sns.parseJSONLOutput(outputFile)
-	if err != nil {
-		fmt.Printf("❌ 解析输出文件失败: %v\n", err)
-		return fmt.Errorf("failed to parse output: %w", err)
+	var vulnerabilities []*models.NucleiResult
+	for _, file := range outputFiles {
+		logInfof("📄 读取输出文件: %s\n", file)
+		parsed, err := sns.parseJSONLOutput(file)
+		if err != nil {
+			logErrorf("❌ 解析输出文件失败: %v\n", err)
+			return fmt.Errorf("failed to parse output: %w", err)
+		}
+		vulnerabilities = append(vulnerabilities, parsed...)
 	}
+	vulnerabilities = dedupFindings(vulnerabilities)
+	flagWAFFindings(vulnerabilities, sns.targetInfo)
 
-	fmt.Printf("🔍 发现漏洞数量: %d\n", len(vulnerabilities))
+	logInfof("🔍 发现漏洞数量: %d\n", len(vulnerabilities))
 
 	// Print vulnerability details
 	for i, vuln := range vulnerabilities {
-		fmt.Printf("  %d. %s - %s\n", i+1, vuln.TemplateID, vuln.Info.Name)
-		fmt.Printf("     目标: %s\n", vuln.MatchedAt)
-		fmt.Printf("     严重程度: %s\n", vuln.Info.Severity)
+		logInfof("  %d. %s - %s\n", i+1, vuln.TemplateID, vuln.Info.Name)
+		logInfof("     目标: %s\n", vuln.MatchedAt)
+		logInfof("     严重程度: %s\n", vuln.Info.Severity)
 	}
 
 	// 获取实际的统计数据
@@ -1757,7 +2148,7 @@ func (sns *SimpleNucleiScanner) processResults(outputFile string) error {
 	result := &TaskResult{
 		TaskID:            sns.task.ID,
 		TaskName:          sns.task.Name,
-		Status:            "completed",
+		Status:            sns.resultStatus(),
 		StartTime:         sns.task.StartTime,
 		EndTime:           time.Now(),
 		Duration:          time.Since(sns.task.StartTime).String(),
@@ -1765,6 +2156,11 @@ func (sns *SimpleNucleiScanner) processResults(outputFile string) error {
 		Templates:         sns.task.POCs,
 		TemplateCount:     len(sns.task.POCs),
 		TargetCount:       len(sns.task.Targets),
+		UnreachableTargets: sns.unreachableTargets,
+		TargetInfo:         sns.targetInfo,
+		SlowestTemplates:   sns.templateTimer.slowest(slowestTemplateCount),
+		HostErrors:         sns.hostErrors.summaries(),
+		SkippedTargets:     sns.hostErrors.skippedTargets(),
 		TotalRequests:     actualTotalRequests,          // 使用实际值
 		CompletedRequests: actualCompletedRequests,      // 使用实际值
 		FoundVulns:        len(vulnerabilities),
@@ -1797,14 +2193,14 @@ func (sns *SimpleNucleiScanner) processResults(outputFile string) error {
 		CreatedAt: time.Now(),
 	}
 
-	fmt.Printf("💾 保存结果到文件...\n")
+	logInfof("💾 保存结果到文件...\n")
 	// Save result to JSON file
 	if err := sns.saveResult(result); err != nil {
-		fmt.Printf("❌ 保存结果失败: %v\n", err)
+		logErrorf("❌ 保存结果失败: %v\n", err)
 		return err
 	}
 
-	fmt.Printf("✅ 结果已保存到: %s\n", filepath.Join(sns.manager.resultsDir, fmt.Sprintf("task_%d_result.json", result.TaskID)))
+	logInfof("✅ 结果已保存: 任务 %d (%d 个漏洞)\n", result.TaskID, result.FoundVulns)
 
 	// 保存HTTP请求日志
 	sns.httpLogsMu.Lock()
@@ -1812,9 +2208,9 @@ func (sns *SimpleNucleiScanner) processResults(outputFile string) error {
 	sns.httpLogsMu.Unlock()
 
 	if len(httpLogs) > 0 {
-		fmt.Printf("💾 保存HTTP请求日志 (%d 条记录)...\n", len(httpLogs))
+		logInfof("💾 保存HTTP请求日志 (%d 条记录)...\n", len(httpLogs))
 		if err := sns.manager.SaveHTTPRequestLogs(sns.task.ID, httpLogs); err != nil {
-			fmt.Printf("⚠️ 保存HTTP请求日志失败: %v\n", err)
+			logWarnf("⚠️ 保存HTTP请求日志失败: %v\n", err)
 			// 不返回错误，因为主结果已保存成功
 		}
 	}
@@ -1824,6 +2220,11 @@ func (sns *SimpleNucleiScanner) processResults(outputFile string) error {
 
 // parseJSONLOutput parses the JSONL output file
 func (sns *SimpleNucleiScanner) parseJSONLOutput(outputFile string) ([]*models.NucleiResult, error) {
+	return parseJSONLFile(outputFile)
+}
+
+// parseJSONLFile reads the findings of a nuclei JSONL output file
+func parseJSONLFile(outputFile string) ([]*models.NucleiResult, error) {
 	file, err := os.Open(outputFile)
 	if err != nil {
 		return nil, err
@@ -1831,7 +2232,7 @@ func (sns *SimpleNucleiScanner) parseJSONLOutput(outputFile string) ([]*models.N
 	defer file.Close()
 
 	var vulnerabilities []*models.NucleiResult
-	scanner := bufio.NewScanner(file)
+	scanner := newLineScanner(file)
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -1845,8 +2246,8 @@ func (sns *SimpleNucleiScanner) parseJSONLOutput(outputFile string) ([]*models.N
 			continue
 		}
 
-		// Only include results with vulnerabilities
-		if result.MatchedAt != "" {
+		// Only include results with vulnerabilities (-ms also records non-matching executions)
+		if result.MatchedAt != "" && (result.MatcherStatus == nil || *result.MatcherStatus) {
 			vulnerabilities = append(vulnerabilities, &result)
 		}
 	}
@@ -1860,7 +2261,7 @@ func (sns *SimpleNucleiScanner) parseJSONLOutput(outputFile string) ([]*models.N
 
 // createEmptyResult creates an empty result when no vulnerabilities are found
 func (sns *SimpleNucleiScanner) createEmptyResult() error {
-	fmt.Printf("🔍 未发现漏洞，创建空结果...\n")
+	logInfof("🔍 未发现漏洞，创建空结果...\n")
 
 	// 获取实际的统计数据
 	sns.progressMu.RLock()
@@ -1885,7 +2286,7 @@ func (sns *SimpleNucleiScanner) createEmptyResult() error {
 	result := &TaskResult{
 		TaskID:            sns.task.ID,
 		TaskName:          sns.task.Name,
-		Status:            "completed",
+		Status:            sns.resultStatus(),
 		StartTime:         sns.task.StartTime,
 		EndTime:           time.Now(),
 		Duration:          time.Since(sns.task.StartTime).String(),
@@ -1893,6 +2294,11 @@ func (sns *SimpleNucleiScanner) createEmptyResult() error {
 		Templates:         sns.task.POCs,
 		TemplateCount:     len(sns.task.POCs),
 		TargetCount:       len(sns.task.Targets),
+		UnreachableTargets: sns.unreachableTargets,
+		TargetInfo:         sns.targetInfo,
+		SlowestTemplates:   sns.templateTimer.slowest(slowestTemplateCount),
+		HostErrors:         sns.hostErrors.summaries(),
+		SkippedTargets:     sns.hostErrors.skippedTargets(),
 		TotalRequests:     actualTotalRequests,
 		CompletedRequests: actualCompletedRequests,
 		FoundVulns:        0,
@@ -1925,29 +2331,26 @@ func (sns *SimpleNucleiScanner) createEmptyResult() error {
 		CreatedAt: time.Now(),
 	}
 
-	fmt.Printf("💾 保存空结果到文件...\n")
+	logInfof("💾 保存空结果到文件...\n")
 	if err := sns.saveResult(result); err != nil {
-		fmt.Printf("❌ 保存空结果失败: %v\n", err)
+		logErrorf("❌ 保存空结果失败: %v\n", err)
 		return err
 	}
 
-	fmt.Printf("✅ 空结果已保存到: %s\n", filepath.Join(sns.manager.resultsDir, fmt.Sprintf("task_%d_result.json", result.TaskID)))
+	logInfof("✅ 空结果已保存: 任务 %d\n", result.TaskID)
 	return nil
 }
 
-// saveResult saves the result to a JSON file
+// saveResult stores the result in the task database
 func (sns *SimpleNucleiScanner) saveResult(result *TaskResult) error {
-	// Create result file path
-	resultFile := filepath.Join(sns.manager.resultsDir, fmt.Sprintf("task_%d_result.json", result.TaskID))
-
-	// Marshal to JSON
-	data, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
+	sns.manager.recordFindings(result)
+	if err := sns.manager.saveTaskResult(result); err != nil {
 		return err
 	}
-
-	// Write to file
-	return os.WriteFile(resultFile, data, 0644)
+	sns.manager.recordTemplateStats(result)
+	sns.manager.queueFollowUps(sns.task, result)
+	go sns.manager.captureFindingScreenshots(result)
+	return nil
 }
 
 // saveLogs saves the logs to a JSON file
@@ -1969,6 +2372,6 @@ func (sns *SimpleNucleiScanner) saveLogs() error {
 		return fmt.Errorf("failed to write log file: %w", err)
 	}
 
-	fmt.Printf("💾 日志已保存到: %s (%d 条记录)\n", logFile, len(sns.logs))
+	logInfof("💾 日志已保存到: %s (%d 条记录)\n", logFile, len(sns.logs))
 	return nil
 }
@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// ErrScanStopped is returned by Start when the scan was stopped on request
+var ErrScanStopped = errors.New("scan stopped by user")
+
+// stopAllWait bounds how long StopAllTasks waits for scanners to flush partial results
+const stopAllWait = 15 * time.Second
+
+// setCmd records the running nuclei process; a stop requested before the process
+// started is applied immediately
+func (sns *SimpleNucleiScanner) setCmd(cmd *exec.Cmd) {
+	sns.stopMu.Lock()
+	defer sns.stopMu.Unlock()
+
+	sns.cmd = cmd
+	if cmd.Process == nil {
+		return
+	}
+	if sns.stopRequested {
+		cmd.Process.Kill()
+	} else if sns.pauseRequested {
+		sns.interruptProcess()
+	}
+}
+
+// Stop terminates the nuclei process; Start then processes the partial results and
+// reports the scan as stopped
+func (sns *SimpleNucleiScanner) Stop() {
+	sns.stopMu.Lock()
+	defer sns.stopMu.Unlock()
+
+	if sns.stopRequested {
+		return
+	}
+	sns.stopRequested = true
+	logInfof("🛑 停止任务 %d 的扫描\n", sns.task.ID)
+	// 中止仍在进行的子域名枚举和目标探测
+	sns.cancelRun()
+	if sns.cmd != nil && sns.cmd.Process != nil {
+		if err := sns.cmd.Process.Kill(); err != nil {
+			logWarnf("⚠️ 终止nuclei进程失败: %v\n", err)
+		}
+	}
+}
+
+// stopped reports whether Stop was called
+func (sns *SimpleNucleiScanner) stopped() bool {
+	sns.stopMu.Lock()
+	defer sns.stopMu.Unlock()
+	return sns.stopRequested
+}
+
+// resultStatus is the status stored in the task result
+func (sns *SimpleNucleiScanner) resultStatus() string {
+	if sns.paused() {
+		return "paused"
+	}
+	if sns.stopped() {
+		return "stopped"
+	}
+	return "completed"
+}
+
+// processRegistry tracks nuclei processes started outside of task scans (e.g. single POC tests)
+var processRegistry = struct {
+	procs map[*exec.Cmd]bool
+	mu    sync.Mutex
+}{procs: make(map[*exec.Cmd]bool)}
+
+// TrackProcess registers a started process so StopAllTasks can kill it; call the returned
+// function once the process has exited
+func TrackProcess(cmd *exec.Cmd) func() {
+	processRegistry.mu.Lock()
+	processRegistry.procs[cmd] = true
+	processRegistry.mu.Unlock()
+
+	return func() {
+		processRegistry.mu.Lock()
+		delete(processRegistry.procs, cmd)
+		processRegistry.mu.Unlock()
+	}
+}
+
+// killTrackedProcesses kills every tracked process and returns how many were killed
+func killTrackedProcesses() int {
+	processRegistry.mu.Lock()
+	defer processRegistry.mu.Unlock()
+
+	killed := 0
+	for cmd := range processRegistry.procs {
+		if cmd.Process != nil && cmd.Process.Kill() == nil {
+			killed++
+		}
+	}
+	return killed
+}
+
+// StopAllResult summarizes an emergency stop
+type StopAllResult struct {
+	StoppedTasks    []int64 `json:"stopped_tasks"`    // 被停止的任务
+	KilledProcesses int     `json:"killed_processes"` // 被终止的其他nuclei进程（如单POC测试）
+	PendingTasks    []int64 `json:"pending_tasks"`    // 等待超时仍未结束的任务
+}
+
+// StopTask stops a running task scan
+func (tm *JSONTaskManager) StopTask(taskID int64) error {
+	scanner, ok := tm.runningScanner(taskID)
+	if !ok {
+		return tm.cancelWaitingTask(taskID)
+	}
+	scanner.Stop()
+	return nil
+}
+
+// cancelWaitingTask marks a task that is waiting for its scan window (or paused) as stopped
+func (tm *JSONTaskManager) cancelWaitingTask(taskID int64) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.loadTaskConfig(taskID)
+	if err != nil || (task.Status != "waiting_window" && task.Status != "paused") {
+		return fmt.Errorf("task %d is not running", taskID)
+	}
+
+	now := time.Now()
+	task.Status = "stopped"
+	task.EndTime = &now
+	task.UpdatedAt = now
+	task.ResumeFile = ""
+	if err := tm.saveTaskConfig(task); err != nil {
+		return fmt.Errorf("failed to save task config: %w", err)
+	}
+	logInfof("🛑 取消等待扫描时间窗口的任务 %d\n", taskID)
+	return nil
+}
+
+// StopAllTasks kills every running nuclei process (task scans and single POC tests), waits
+// for the task scanners to flush their partial results and mark the tasks stopped. Tasks
+// waiting for their scan window are stopped as well, so none of them resumes later.
+func (tm *JSONTaskManager) StopAllTasks() *StopAllResult {
+	result := &StopAllResult{StoppedTasks: []int64{}, PendingTasks: []int64{}}
+
+	for _, taskID := range tm.RunningTaskIDs() {
+		if scanner, ok := tm.runningScanner(taskID); ok {
+			scanner.Stop()
+			result.StoppedTasks = append(result.StoppedTasks, taskID)
+		}
+	}
+	if tasks, err := tm.GetAllTasks(); err == nil {
+		for _, task := range tasks {
+			if task.Status == "waiting_window" && tm.cancelWaitingTask(task.ID) == nil {
+				result.StoppedTasks = append(result.StoppedTasks, task.ID)
+			}
+		}
+	}
+	result.KilledProcesses = killTrackedProcesses()
+
+	deadline := time.Now().Add(stopAllWait)
+	for len(tm.RunningTaskIDs()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	result.PendingTasks = append(result.PendingTasks, tm.RunningTaskIDs()...)
+
+	logInfof("🛑 紧急停止: %d 个任务, %d 个其他进程\n", len(result.StoppedTasks), result.KilledProcesses)
+	return result
+}
@@ -0,0 +1,300 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// subdomainsFile is the name of the enumeration output in a task's output directory
+	subdomainsFile = "subdomains.json"
+	// maxSubdomainsPerDomain bounds the subdomains probed for one root domain
+	maxSubdomainsPerDomain = 1000
+	// subdomainSourceTimeout bounds a single passive source request; crt.sh is slow on large domains
+	subdomainSourceTimeout = 60 * time.Second
+)
+
+// subdomainSource queries one passive source for the subdomains of a domain
+type subdomainSource struct {
+	name  string
+	query func(ctx context.Context, client *http.Client, domain string) ([]string, error)
+}
+
+// subdomainSources are the passive sources used for enumeration; none needs an API key
+var subdomainSources = []subdomainSource{
+	{name: "crtsh", query: crtshSubdomains},
+	{name: "hackertarget", query: hackertargetSubdomains},
+}
+
+// DomainEnumeration is the enumeration output of one root domain
+type DomainEnumeration struct {
+	Domain     string            `json:"domain"`
+	Subdomains []string          `json:"subdomains"`       // 枚举到的子域名（已去重排序）
+	Sources    map[string]int    `json:"sources"`          // 数据源 -> 返回的子域名数
+	Errors     map[string]string `json:"errors,omitempty"` // 数据源 -> 错误信息
+	Truncated  bool              `json:"truncated,omitempty"`
+}
+
+// SubdomainEnumeration is the subdomain enumeration stage of a scan, stored with the task
+type SubdomainEnumeration struct {
+	TaskID    int64                `json:"task_id"`
+	StartedAt time.Time            `json:"started_at"`
+	Duration  string               `json:"duration"`
+	Domains   []*DomainEnumeration `json:"domains"`
+	Live      []string             `json:"live"`  // 探测可达的子域名（探测后的目标）
+	Dead      []string             `json:"dead"`  // 探测不可达的子域名
+	Added     []string             `json:"added"` // 新加入任务目标列表的子域名
+}
+
+// EnumerateSubdomains collects the subdomains of a root domain from passive sources
+func EnumerateSubdomains(ctx context.Context, domain, proxyURL string) *DomainEnumeration {
+	domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "*.")
+	result := &DomainEnumeration{
+		Domain:     domain,
+		Subdomains: []string{},
+		Sources:    make(map[string]int),
+		Errors:     make(map[string]string),
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	if proxyURL != "" {
+		if parsedProxy, err := url.Parse(proxyURL); err == nil {
+			transport.Proxy = http.ProxyURL(parsedProxy)
+		}
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: subdomainSourceTimeout}
+
+	seen := make(map[string]bool)
+	for _, source := range subdomainSources {
+		names, err := source.query(ctx, client, domain)
+		if err != nil {
+			result.Errors[source.name] = err.Error()
+			continue
+		}
+		result.Sources[source.name] = len(names)
+		for _, name := range names {
+			name = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "*.")
+			if name == domain || !strings.HasSuffix(name, "."+domain) || seen[name] {
+				continue
+			}
+			seen[name] = true
+			result.Subdomains = append(result.Subdomains, name)
+		}
+	}
+
+	sort.Strings(result.Subdomains)
+	if len(result.Subdomains) > maxSubdomainsPerDomain {
+		result.Subdomains = result.Subdomains[:maxSubdomainsPerDomain]
+		result.Truncated = true
+	}
+	return result
+}
+
+// crtshSubdomains reads the names of the certificates logged for a domain from crt.sh
+func crtshSubdomains(ctx context.Context, client *http.Client, domain string) ([]string, error) {
+	data, err := subdomainRequest(ctx, client, "https://crt.sh/?output=json&q="+url.QueryEscape("%."+domain))
+	if err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		NameValue string `json:"name_value"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("无法解析crt.sh响应: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.Split(entry.NameValue, "\n")...)
+	}
+	return names, nil
+}
+
+// hackertargetSubdomains reads the host search of HackerTarget ("name,ip" lines)
+func hackertargetSubdomains(ctx context.Context, client *http.Client, domain string) ([]string, error) {
+	data, err := subdomainRequest(ctx, client, "https://api.hackertarget.com/hostsearch/?q="+url.QueryEscape(domain))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	lines := bufio.NewScanner(strings.NewReader(string(data)))
+	for lines.Scan() {
+		name, _, found := strings.Cut(lines.Text(), ",")
+		if !found {
+			// 超出免费额度等错误以纯文本返回
+			return nil, fmt.Errorf("HackerTarget返回错误: %s", strings.TrimSpace(lines.Text()))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// subdomainRequest fetches a passive source URL
+func subdomainRequest(ctx context.Context, client *http.Client, sourceURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, lastLine(string(data)))
+	}
+	return data, nil
+}
+
+// rootDomains returns the domain names among the targets; IP addresses and subdomains of
+// other domain targets (e.g. added by an earlier enumeration) are skipped
+func rootDomains(targets []string) []string {
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		host := targetHost(target)
+		if host != "" && net.ParseIP(host) == nil && strings.Contains(host, ".") {
+			seen[host] = true
+		}
+	}
+
+	var domains []string
+	for host := range seen {
+		parent := host
+		isSubdomain := false
+		for {
+			_, rest, found := strings.Cut(parent, ".")
+			if !found {
+				break
+			}
+			if seen[rest] {
+				isSubdomain = true
+				break
+			}
+			parent = rest
+		}
+		if !isSubdomain {
+			domains = append(domains, host)
+		}
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// enumerateSubdomains enumerates the subdomains of the domain targets of the task, probes them
+// and adds the live ones to the task's targets; the output is stored with the task
+func (sns *SimpleNucleiScanner) enumerateSubdomains(ctx context.Context) {
+	if !sns.task.Options.EnumerateSubdomains || sns.manager == nil {
+		return
+	}
+	if IsOfflineMode() {
+		logWarnf("⚠️ 离线模式，跳过子域名枚举\n")
+		return
+	}
+	domains := rootDomains(sns.task.Targets)
+	if len(domains) == 0 {
+		logWarnf("⚠️ 任务目标中没有域名，跳过子域名枚举\n")
+		return
+	}
+
+	record := &SubdomainEnumeration{
+		TaskID:    sns.task.ID,
+		StartedAt: time.Now(),
+		Live:      []string{},
+		Dead:      []string{},
+		Added:     []string{},
+	}
+	proxyURL := sns.manager.defaultSendOptions().ProxyURL
+
+	existing := make(map[string]bool)
+	for _, target := range sns.task.Targets {
+		existing[targetHost(target)] = true
+	}
+	var candidates []string
+	for _, domain := range domains {
+		logInfof("🌐 枚举子域名: %s\n", domain)
+		if ctx.Err() != nil {
+			return
+		}
+		enumeration := EnumerateSubdomains(ctx, domain, proxyURL)
+		for source, errMsg := range enumeration.Errors {
+			sns.addLog("WARNING", "", domain, fmt.Sprintf("子域名数据源 %s 查询失败: %s", source, errMsg), "", "", false)
+		}
+		record.Domains = append(record.Domains, enumeration)
+		for _, name := range enumeration.Subdomains {
+			if !existing[name] {
+				existing[name] = true
+				candidates = append(candidates, name)
+			}
+		}
+	}
+
+	if len(candidates) > 0 {
+		report := probeTargets(ctx, candidates, sns.manager.sendOptionsForTask(sns.task), 0)
+		if ctx.Err() != nil {
+			return
+		}
+		record.Live = report.Targets
+		record.Dead = report.Unreachable
+	}
+
+	if len(record.Live) > 0 {
+		sns.manager.mu.Lock()
+		sns.task.Targets = append(sns.task.Targets, record.Live...)
+		sns.task.TotalRequests = len(sns.task.POCs) * len(sns.task.Targets)
+		if err := sns.manager.saveTaskConfig(sns.task); err != nil {
+			logWarnf("⚠️ 保存子域名目标失败: %v\n", err)
+		}
+		sns.manager.mu.Unlock()
+		record.Added = record.Live
+	}
+	record.Duration = time.Since(record.StartedAt).Round(time.Second).String()
+	logInfof("🌐 子域名枚举完成: 发现 %d 个新子域名, 可达 %d, 不可达 %d\n", len(candidates), len(record.Live), len(record.Dead))
+
+	outputDir := filepath.Join(sns.manager.resultsDir, fmt.Sprintf("task_%d", sns.task.ID))
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(outputDir, subdomainsFile), data, 0644)
+	}
+	if err != nil {
+		logWarnf("⚠️ 保存子域名枚举结果失败: %v\n", err)
+	}
+}
+
+// GetTaskSubdomains returns the subdomain enumeration output of the last scan of a task
+func (tm *JSONTaskManager) GetTaskSubdomains(taskID int64) (*SubdomainEnumeration, error) {
+	if _, err := tm.GetTaskByID(taskID); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(tm.resultsDir, fmt.Sprintf("task_%d", taskID), subdomainsFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("任务没有子域名枚举结果")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subdomain enumeration: %w", err)
+	}
+
+	var record SubdomainEnumeration
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode subdomain enumeration: %w", err)
+	}
+	return &record, nil
+}
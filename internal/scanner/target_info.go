@@ -0,0 +1,233 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"html"
+	"io"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxTargetPageSize bounds the page body read for the title and favicon link
+	maxTargetPageSize = 1 << 20
+	// maxFaviconSize bounds the favicon read for the hash
+	maxFaviconSize = 512 << 10
+)
+
+var (
+	titlePattern   = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	iconLinkTag    = regexp.MustCompile(`(?is)<link\s[^>]*rel\s*=\s*["']?[^"'>]*icon[^"'>]*["']?[^>]*>`)
+	hrefAttribute  = regexp.MustCompile(`(?is)href\s*=\s*["']?([^"'\s>]+)`)
+	whitespaceRuns = regexp.MustCompile(`\s+`)
+)
+
+// TargetInfo identifies what runs on an HTTP target: page title, server header, the
+// favicon hash (mmh3 as used by Shodan/FOFA icon_hash) and the WAF/CDN in front of it
+type TargetInfo struct {
+	Target      string `json:"target"`                 // 扫描目标
+	URL         string `json:"url"`                    // 跟随跳转后的最终地址
+	StatusCode  int    `json:"status_code"`            // HTTP状态码
+	Title       string `json:"title"`                  // 页面标题
+	Server      string `json:"server"`                 // Server响应头
+	PoweredBy   string `json:"powered_by,omitempty"`   // X-Powered-By响应头
+	FaviconURL  string `json:"favicon_url,omitempty"`  // favicon地址
+	FaviconHash int32  `json:"favicon_hash,omitempty"` // favicon的mmh3哈希
+	WAF         string `json:"waf,omitempty"`          // 根据指纹识别的WAF/CDN
+	Error       string `json:"error,omitempty"`
+}
+
+// CollectTargetInfo fetches the title, server header and favicon hash of the HTTP targets
+// concurrently; targets without an http(s) scheme are skipped
+func CollectTargetInfo(ctx context.Context, targets []string, proxyURL string, concurrency int, timeout time.Duration) []*TargetInfo {
+	return collectTargetInfo(ctx, targets, SendOptions{ProxyURL: proxyURL, Timeout: timeout}, concurrency)
+}
+
+// collectTargetInfo fetches the target info with the proxy, timeout, SNI and resolver of opts
+func collectTargetInfo(ctx context.Context, targets []string, opts SendOptions, concurrency int) []*TargetInfo {
+	proxyURL, timeout := opts.ProxyURL, opts.Timeout
+	if concurrency <= 0 {
+		concurrency = 20
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	transport := &http.Transport{
+		// 探测时接受旧版TLS，避免仅支持TLS 1.0/1.1的内网目标被判定为不可达
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS10, ServerName: opts.ServerName},
+		DialContext:     opts.dialContext(),
+	}
+	if proxyURL != "" {
+		if parsedProxy, err := url.Parse(proxyURL); err == nil {
+			transport.Proxy = http.ProxyURL(parsedProxy)
+		}
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+
+	var httpTargets []string
+	for _, target := range targets {
+		if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+			httpTargets = append(httpTargets, target)
+		}
+	}
+
+	infos := make([]*TargetInfo, len(httpTargets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range httpTargets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, target string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			infos[i] = fetchTargetInfo(ctx, client, target)
+		}(i, target)
+	}
+	wg.Wait()
+	return infos
+}
+
+// fetchTargetInfo requests the page of a target and its favicon
+func fetchTargetInfo(ctx context.Context, client *http.Client, target string) *TargetInfo {
+	info := &TargetInfo{Target: target, URL: target}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxTargetPageSize))
+	resp.Body.Close()
+
+	info.URL = resp.Request.URL.String()
+	info.StatusCode = resp.StatusCode
+	info.Server = resp.Header.Get("Server")
+	info.PoweredBy = resp.Header.Get("X-Powered-By")
+	info.WAF = DetectWAF(resp.Header, body)
+	if matches := titlePattern.FindSubmatch(body); matches != nil {
+		info.Title = strings.TrimSpace(whitespaceRuns.ReplaceAllString(html.UnescapeString(string(matches[1])), " "))
+	}
+
+	info.FaviconURL = faviconURL(resp.Request.URL, body)
+	if icon := fetchFavicon(ctx, client, info.FaviconURL); len(icon) > 0 {
+		info.FaviconHash = FaviconHash(icon)
+	} else {
+		info.FaviconURL = ""
+	}
+	return info
+}
+
+// faviconURL returns the icon linked by the page, or /favicon.ico
+func faviconURL(page *url.URL, body []byte) string {
+	fallback := page.ResolveReference(&url.URL{Path: "/favicon.ico"}).String()
+	tag := iconLinkTag.Find(body)
+	if tag == nil {
+		return fallback
+	}
+	matches := hrefAttribute.FindSubmatch(tag)
+	if matches == nil {
+		return fallback
+	}
+	ref, err := url.Parse(html.UnescapeString(string(matches[1])))
+	if err != nil || ref.Scheme == "data" {
+		return fallback
+	}
+	return page.ResolveReference(ref).String()
+}
+
+// fetchFavicon downloads a favicon; non-200 responses and HTML error pages yield nil
+func fetchFavicon(ctx context.Context, client *http.Client, iconURL string) []byte {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, iconURL, nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+		return nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxFaviconSize))
+	return data
+}
+
+// FaviconHash computes the Shodan/FOFA favicon hash: mmh3 (seed 0) of the base64 encoding
+// with a newline after every 76 characters and at the end
+func FaviconHash(data []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var builder strings.Builder
+	for len(encoded) > 76 {
+		builder.WriteString(encoded[:76])
+		builder.WriteByte('\n')
+		encoded = encoded[76:]
+	}
+	builder.WriteString(encoded)
+	builder.WriteByte('\n')
+	return int32(murmur3([]byte(builder.String())))
+}
+
+// murmur3 is the 32-bit MurmurHash3 with seed 0
+func murmur3(data []byte) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	var h uint32
+	n := len(data) / 4 * 4
+	for i := 0; i < n; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	var k uint32
+	switch len(data) - n {
+	case 3:
+		k ^= uint32(data[n+2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(data[n+1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(data[n])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
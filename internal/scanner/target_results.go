@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// Probe statuses of a target in a result breakdown
+const (
+	ProbeReachable   = "reachable"   // 探测可达
+	ProbeUnreachable = "unreachable" // 探测不可达，未扫描
+	ProbeNotProbed   = "not_probed"  // 任务未开启协议探测
+)
+
+// TargetResult is the part of a task result that belongs to one target
+type TargetResult struct {
+	Target       string                 `json:"target"`
+	Status       string                 `json:"status"`       // vulnerable / not_vulnerable / unreachable
+	ProbeStatus  string                 `json:"probe_status"` // reachable / unreachable / not_probed
+	Findings     []*models.NucleiResult `json:"findings"`
+	Severities   map[string]int         `json:"severities"`    // 严重程度 -> 漏洞数
+	HTTPRequests int                    `json:"http_requests"` // 记录的HTTP请求数
+	Errors       map[string]int         `json:"errors"`        // 错误类别 -> 次数
+	ErrorCount   int                    `json:"error_count"`
+	LastError    string                 `json:"last_error,omitempty"`
+	Skipped      bool                   `json:"skipped"`        // 被nuclei因错误过多跳过
+	Info         *TargetInfo            `json:"info,omitempty"` // 标题、Server头和favicon哈希
+}
+
+// TaskTargetBreakdown splits a task result by target, answering which targets are affected
+type TaskTargetBreakdown struct {
+	TaskID          int64           `json:"task_id"`
+	TotalTargets    int             `json:"total_targets"`
+	AffectedTargets int             `json:"affected_targets"` // 存在漏洞的目标数
+	Targets         []*TargetResult `json:"targets"`          // 存在漏洞的目标在前，按漏洞数排序
+	// 无法对应到任务目标的漏洞（如跳转到其他主机后命中）
+	Unassigned []*models.NucleiResult `json:"unassigned,omitempty"`
+}
+
+// GetTaskResultByTarget returns the findings, logged HTTP requests, errors and probe status
+// of every target of a task
+func (tm *JSONTaskManager) GetTaskResultByTarget(taskID int64) (*TaskTargetBreakdown, error) {
+	task, err := tm.GetTaskByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := tm.GetTaskResult(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := result.Targets
+	if len(targets) == 0 {
+		targets = task.Targets
+	}
+	unreachable := make(map[string]bool, len(result.UnreachableTargets))
+	for _, target := range result.UnreachableTargets {
+		unreachable[target] = true
+	}
+
+	breakdown := &TaskTargetBreakdown{
+		TaskID:       taskID,
+		TotalTargets: len(targets),
+		Targets:      make([]*TargetResult, 0, len(targets)),
+	}
+	for _, target := range targets {
+		entry := &TargetResult{
+			Target:      target,
+			Status:      OutcomeNotVulnerable,
+			ProbeStatus: ProbeNotProbed,
+			Findings:    []*models.NucleiResult{},
+			Severities:  make(map[string]int),
+			Errors:      make(map[string]int),
+		}
+		if unreachable[target] {
+			entry.Status = OutcomeUnreachable
+			entry.ProbeStatus = ProbeUnreachable
+		} else if task.Options.ProbeTargets {
+			entry.ProbeStatus = ProbeReachable
+		}
+		breakdown.Targets = append(breakdown.Targets, entry)
+	}
+
+	for _, vuln := range result.Vulnerabilities {
+		i := matchTarget(targets, []string{vuln.MatchedAt, vuln.Host})
+		if i < 0 {
+			breakdown.Unassigned = append(breakdown.Unassigned, vuln)
+			continue
+		}
+		entry := breakdown.Targets[i]
+		entry.Findings = append(entry.Findings, vuln)
+		entry.Severities[strings.ToLower(vuln.Info.Severity)]++
+		entry.Status = OutcomeVulnerable
+	}
+
+	for _, summary := range result.HostErrors {
+		if i := matchTarget(targets, []string{summary.Target}); i >= 0 {
+			entry := breakdown.Targets[i]
+			for category, count := range summary.Errors {
+				entry.Errors[category] += count
+			}
+			entry.ErrorCount += summary.Total
+			entry.LastError = summary.LastError
+			entry.Skipped = entry.Skipped || summary.Skipped
+		}
+	}
+
+	for _, info := range result.TargetInfo {
+		if i := matchTarget(targets, []string{info.Target}); i >= 0 && breakdown.Targets[i].Info == nil {
+			breakdown.Targets[i].Info = info
+		}
+	}
+
+	counts, err := tm.httpRequestCounts(taskID)
+	if err != nil {
+		return nil, err
+	}
+	for target, count := range counts {
+		if i := matchTarget(targets, []string{target}); i >= 0 {
+			breakdown.Targets[i].HTTPRequests += count
+		}
+	}
+
+	for _, entry := range breakdown.Targets {
+		if len(entry.Findings) > 0 {
+			breakdown.AffectedTargets++
+		}
+	}
+	sort.SliceStable(breakdown.Targets, func(i, j int) bool {
+		return len(breakdown.Targets[i].Findings) > len(breakdown.Targets[j].Findings)
+	})
+	return breakdown, nil
+}
+
+// httpRequestCounts counts the logged HTTP requests of a task per target, reading the
+// archive for archived tasks
+func (tm *JSONTaskManager) httpRequestCounts(taskID int64) (map[string]int, error) {
+	counts, err := tm.db.CountHTTPLogsByTarget(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if len(counts) > 0 {
+		return counts, nil
+	}
+
+	logs, err := tm.GetHTTPRequestLogs(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HTTP logs: %w", err)
+	}
+	for _, log := range logs {
+		counts[log.Target]++
+	}
+	return counts, nil
+}
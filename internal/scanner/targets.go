@@ -0,0 +1,248 @@
+package scanner
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"wepoc/internal/i18n"
+)
+
+// maxCIDRTargets bounds the addresses a single CIDR target expands to
+const maxCIDRTargets = 1 << 16
+
+// TargetValidation is the preview of a target list before a task is created
+type TargetValidation struct {
+	Targets    []string        `json:"targets"`    // 规范化、去重后的目标
+	Duplicates []string        `json:"duplicates"` // 重复的输入（规范化后相同）
+	Invalid    []InvalidTarget `json:"invalid"`    // 无效的输入及原因
+	Total      int             `json:"total"`      // 非空输入条数
+	Valid      int             `json:"valid"`      // 规范化后的目标数
+	Expanded   int             `json:"expanded"`   // CIDR展开得到的地址数
+}
+
+// InvalidTarget is a target input that cannot be scanned
+type InvalidTarget struct {
+	Input  string    `json:"input"`
+	Code   i18n.Code `json:"code"` // 错误码，前端据此本地化原因
+	Reason string    `json:"reason"`
+	err    error
+}
+
+// NormalizeTargets trims, validates and deduplicates targets: CIDR ranges are expanded into
+// addresses and IPv6 literals are written in canonical form, bracketed where a port or URL
+// requires it. Malformed IPv6 input is rejected with an explanation.
+func NormalizeTargets(inputs []string) ([]string, error) {
+	validation := ValidateTargets(inputs)
+	if len(validation.Invalid) > 0 {
+		return nil, validation.Invalid[0].err
+	}
+	return validation.Targets, nil
+}
+
+// ValidateTargets normalizes every input like NormalizeTargets but collects duplicates and
+// invalid entries instead of stopping at the first error, so the list can be cleaned up
+// before a task is created. Bare hosts stay bare; the scheme is probed when the scan starts.
+func ValidateTargets(inputs []string) *TargetValidation {
+	validation := &TargetValidation{Targets: []string{}, Duplicates: []string{}, Invalid: []InvalidTarget{}}
+	seen := make(map[string]bool, len(inputs))
+	duplicated := make(map[string]bool)
+	add := func(input, target string) {
+		if seen[target] {
+			// 重叠的CIDR只记录一次
+			if !duplicated[input] {
+				duplicated[input] = true
+				validation.Duplicates = append(validation.Duplicates, input)
+			}
+			return
+		}
+		seen[target] = true
+		validation.Targets = append(validation.Targets, target)
+	}
+
+	for _, input := range inputs {
+		input = strings.TrimSpace(input)
+		if input == "" || strings.HasPrefix(input, "#") {
+			continue
+		}
+		validation.Total++
+		if !strings.Contains(input, "://") && strings.Contains(input, "/") {
+			if _, _, err := net.ParseCIDR(input); err == nil {
+				addresses, err := expandCIDR(input)
+				if err != nil {
+					validation.Invalid = append(validation.Invalid, InvalidTarget{Input: input, Code: i18n.CodeOf(err), Reason: err.Error(), err: err})
+					continue
+				}
+				validation.Expanded += len(addresses)
+				for _, address := range addresses {
+					add(input, address)
+				}
+				continue
+			}
+		}
+		target, err := normalizeTarget(input)
+		if err != nil {
+			validation.Invalid = append(validation.Invalid, InvalidTarget{Input: input, Code: i18n.CodeOf(err), Reason: err.Error(), err: err})
+			continue
+		}
+		add(input, target)
+	}
+	validation.Valid = len(validation.Targets)
+	return validation
+}
+
+// normalizeTarget validates a URL, host, host:port or IP literal
+func normalizeTarget(input string) (string, error) {
+	if strings.Contains(input, "://") {
+		return normalizeURLTarget(input)
+	}
+
+	host, port := input, ""
+	if strings.HasPrefix(input, "[") {
+		end := strings.Index(input, "]")
+		if end < 0 {
+			return "", i18n.Errorf(i18n.ErrTargetMissingBracket, input)
+		}
+		host, port = input[1:end], strings.TrimPrefix(input[end+1:], ":")
+		if rest := input[end+1:]; rest != "" && !strings.HasPrefix(rest, ":") {
+			return "", i18n.Errorf(i18n.ErrTargetBracketSuffix, input)
+		}
+		ip, ok := canonicalIPv6(host)
+		if !ok {
+			return "", i18n.Errorf(i18n.ErrTargetInvalidIPv6, input)
+		}
+		if port == "" {
+			return ip, nil
+		}
+		if !validPort(port) {
+			return "", i18n.Errorf(i18n.ErrTargetInvalidPort, input, port)
+		}
+		return net.JoinHostPort(ip, port), nil
+	}
+
+	if strings.Count(input, ":") > 1 {
+		// 未加方括号的IPv6地址不能带端口
+		ip, ok := canonicalIPv6(input)
+		if !ok {
+			return "", i18n.Errorf(i18n.ErrTargetIPv6Port, input)
+		}
+		return ip, nil
+	}
+
+	if i := strings.LastIndex(input, ":"); i >= 0 {
+		host, port = input[:i], strings.SplitN(input[i+1:], "/", 2)[0]
+		if host == "" {
+			return "", i18n.Errorf(i18n.ErrTargetMissingHost, input)
+		}
+		if !validPort(port) {
+			return "", i18n.Errorf(i18n.ErrTargetInvalidPort, input, port)
+		}
+	}
+	return input, nil
+}
+
+// normalizeURLTarget validates a URL target and canonicalizes its IPv6 host
+func normalizeURLTarget(input string) (string, error) {
+	parsed, err := url.Parse(input)
+	if err != nil || parsed.Host == "" {
+		if hostPart := urlHostPart(input); strings.Count(hostPart, ":") > 1 && !strings.HasPrefix(hostPart, "[") {
+			return "", i18n.Errorf(i18n.ErrTargetIPv6URL, input)
+		}
+		if err == nil {
+			return "", i18n.Errorf(i18n.ErrTargetMissingHost, input)
+		}
+		return "", i18n.Wrap(i18n.ErrTargetInvalidURL, err, input)
+	}
+
+	if !strings.HasPrefix(parsed.Host, "[") {
+		if strings.Count(parsed.Host, ":") > 1 {
+			return "", i18n.Errorf(i18n.ErrTargetIPv6URL, input)
+		}
+		if port := parsed.Port(); port != "" && !validPort(port) {
+			return "", i18n.Errorf(i18n.ErrTargetInvalidPort, input, port)
+		}
+		return input, nil
+	}
+
+	ip, ok := canonicalIPv6(parsed.Hostname())
+	if !ok {
+		return "", i18n.Errorf(i18n.ErrTargetInvalidIPv6, input)
+	}
+	port := parsed.Port()
+	parsed.Host = "[" + ip + "]"
+	if port != "" {
+		if !validPort(port) {
+			return "", i18n.Errorf(i18n.ErrTargetInvalidPort, input, port)
+		}
+		parsed.Host = net.JoinHostPort(ip, port)
+	}
+	return parsed.String(), nil
+}
+
+// urlHostPart returns the authority of a URL string without parsing it
+func urlHostPart(input string) string {
+	_, rest, _ := strings.Cut(input, "://")
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		rest = rest[i+1:]
+	}
+	return rest
+}
+
+// canonicalIPv6 validates an IPv6 literal (optionally with a %zone) and returns its
+// canonical compressed form
+func canonicalIPv6(literal string) (string, bool) {
+	address, zone, hasZone := strings.Cut(literal, "%")
+	ip := net.ParseIP(address)
+	if ip == nil || !strings.Contains(address, ":") || (hasZone && zone == "") {
+		return "", false
+	}
+	if hasZone {
+		return ip.String() + "%" + zone, true
+	}
+	return ip.String(), true
+}
+
+// validPort reports whether a port is a number between 1 and 65535
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
+
+// expandCIDR lists the addresses of an IPv4 or IPv6 network
+func expandCIDR(cidr string) ([]string, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的CIDR %s: %w", cidr, err)
+	}
+	ones, bits := network.Mask.Size()
+	if bits-ones > 16 {
+		return nil, i18n.Errorf(i18n.ErrTargetCIDRTooLarge, cidr, maxCIDRTargets)
+	}
+
+	count := 1 << (bits - ones)
+	addresses := make([]string, 0, count)
+	ip := append(net.IP{}, network.IP...)
+	for i := 0; i < count; i++ {
+		addresses = append(addresses, ip.String())
+		for j := len(ip) - 1; j >= 0; j-- {
+			ip[j]++
+			if ip[j] != 0 {
+				break
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// bracketIPv6 brackets a bare IPv6 literal so it can be used as a URL host or with a port
+func bracketIPv6(host string) string {
+	if strings.Count(host, ":") > 1 && !strings.HasPrefix(host, "[") {
+		return "[" + host + "]"
+	}
+	return host
+}
@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wepoc/internal/i18n"
+)
+
+// TaskLock marks a finished task as evidence: while it is set the task, its result and its
+// logs can't be modified, rescanned or deleted (retention leaves it alone as well)
+type TaskLock struct {
+	LockedAt time.Time `json:"locked_at"`
+	LockedBy string    `json:"locked_by"`
+	Reason   string    `json:"reason,omitempty"` // 锁定原因，如项目编号、取证说明
+}
+
+// checkUnlocked returns an error when the task is locked
+func (task *TaskConfig) checkUnlocked() error {
+	if task.Lock != nil {
+		return i18n.Errorf(i18n.ErrTaskLocked, task.ID)
+	}
+	return nil
+}
+
+// LockTask locks a finished task and its result against modification and deletion
+func (tm *JSONTaskManager) LockTask(taskID int64, reason string) (*TaskConfig, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.loadTaskConfig(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+	if task.Lock != nil {
+		return task, nil
+	}
+	if !finishedTask(task) || tm.isTaskRunning(taskID) {
+		return nil, fmt.Errorf("只能锁定已结束的任务（当前状态: %s）", task.Status)
+	}
+
+	tm.handlersMu.RLock()
+	user := tm.auditUser
+	tm.handlersMu.RUnlock()
+	task.Lock = &TaskLock{LockedAt: time.Now(), LockedBy: user, Reason: strings.TrimSpace(reason)}
+	task.UpdatedAt = time.Now()
+	if err := tm.saveTaskConfig(task); err != nil {
+		return nil, fmt.Errorf("failed to save task: %w", err)
+	}
+
+	tm.audit(&AuditEntry{Action: AuditTaskLocked, TaskID: task.ID, TaskName: task.Name, Detail: task.Lock.Reason})
+	return task, nil
+}
+
+// UnlockTask removes the lock of a task
+func (tm *JSONTaskManager) UnlockTask(taskID int64, reason string) (*TaskConfig, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.loadTaskConfig(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+	if task.Lock == nil {
+		return task, nil
+	}
+
+	task.Lock = nil
+	task.UpdatedAt = time.Now()
+	if err := tm.saveTaskConfig(task); err != nil {
+		return nil, fmt.Errorf("failed to save task: %w", err)
+	}
+
+	tm.audit(&AuditEntry{Action: AuditTaskUnlocked, TaskID: task.ID, TaskName: task.Name, Detail: strings.TrimSpace(reason)})
+	return task, nil
+}
+
+// CheckResultFileUnlocked returns an error when a result file belongs to a locked task
+// (the task ID is taken from the file name or, for nuclei output, its task_<id> directory)
+func (tm *JSONTaskManager) CheckResultFileUnlocked(path string) error {
+	taskID, ok := taskIDFromName(filepath.Base(path))
+	if !ok {
+		if matches := scanResultFilePattern.FindStringSubmatch(filepath.Base(path)); matches != nil {
+			taskID, ok = taskIDFromName("task_" + matches[1])
+		} else {
+			taskID, ok = taskIDFromName(filepath.Base(filepath.Dir(path)))
+		}
+	}
+	if !ok {
+		return nil
+	}
+	task, err := tm.GetTaskByID(taskID)
+	if err != nil {
+		return nil
+	}
+	return task.checkUnlocked()
+}
+
+// checkFindingUnlocked returns an error when a locked task reported the finding with a dedup
+// key: attachments are kept per finding, so changing them would alter that task's evidence
+func (tm *JSONTaskManager) checkFindingUnlocked(dedupKey string) error {
+	tasks, err := tm.GetAllTasks()
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if task.Lock == nil {
+			continue
+		}
+		result, err := tm.GetTaskResult(task.ID)
+		if err != nil {
+			continue
+		}
+		for _, vuln := range result.Vulnerabilities {
+			key := vuln.DedupKey
+			if key == "" {
+				key = FindingDedupKey(vuln)
+			}
+			if key == dedupKey {
+				return task.checkUnlocked()
+			}
+		}
+	}
+	return nil
+}
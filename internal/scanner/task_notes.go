@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"wepoc/internal/i18n"
+)
+
+// maxTaskNoteLength bounds each narrative field of a task, in characters
+const maxTaskNoteLength = 20000
+
+// TaskNotes is the narrative a tester keeps with a task; it is included in report exports
+type TaskNotes struct {
+	Scope            string     `json:"scope"`             // 测试范围说明
+	TesterNotes      string     `json:"tester_notes"`      // 测试人员备注
+	ExecutiveSummary string     `json:"executive_summary"` // 人工撰写的管理层摘要
+	UpdatedAt        *time.Time `json:"updated_at,omitempty"`
+	UpdatedBy        string     `json:"updated_by,omitempty"`
+}
+
+// empty reports whether none of the narrative fields is filled in
+func (n *TaskNotes) empty() bool {
+	return n.Scope == "" && n.TesterNotes == "" && n.ExecutiveSummary == ""
+}
+
+// UpdateTaskNotes replaces the scope description, tester notes and executive summary of a
+// task; clearing all three removes the notes
+func (tm *JSONTaskManager) UpdateTaskNotes(taskID int64, notes TaskNotes) (*TaskConfig, error) {
+	notes.Scope = strings.TrimSpace(notes.Scope)
+	notes.TesterNotes = strings.TrimSpace(notes.TesterNotes)
+	notes.ExecutiveSummary = strings.TrimSpace(notes.ExecutiveSummary)
+	fields := []struct{ name, value string }{
+		{"测试范围", notes.Scope}, {"测试备注", notes.TesterNotes}, {"管理层摘要", notes.ExecutiveSummary},
+	}
+	for _, field := range fields {
+		if utf8.RuneCountInString(field.value) > maxTaskNoteLength {
+			return nil, fmt.Errorf("%s超过 %d 个字符", field.name, maxTaskNoteLength)
+		}
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.loadTaskConfig(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+	// 扫描中任务配置由扫描协程保存，修改会被覆盖
+	if task.Status == "running" {
+		return nil, i18n.Errorf(i18n.ErrTaskRunning)
+	}
+	if err := task.checkUnlocked(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if notes.empty() {
+		task.Notes = nil
+	} else {
+		tm.handlersMu.RLock()
+		notes.UpdatedBy = tm.auditUser
+		tm.handlersMu.RUnlock()
+		notes.UpdatedAt = &now
+		task.Notes = &notes
+	}
+	task.UpdatedAt = now
+	if err := tm.saveTaskConfig(task); err != nil {
+		return nil, fmt.Errorf("failed to save task: %w", err)
+	}
+
+	tm.audit(&AuditEntry{
+		Action:   AuditTaskNotesEdited,
+		TaskID:   task.ID,
+		TaskName: task.Name,
+	})
+	return task, nil
+}
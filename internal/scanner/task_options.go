@@ -0,0 +1,226 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"wepoc/internal/i18n"
+	"wepoc/internal/models"
+)
+
+// TaskOptions holds per-task overrides of the global scan configuration
+type TaskOptions struct {
+	InteractshServer string `json:"interactsh_server,omitempty"` // 任务专用Interactsh服务器（为空时使用全局配置）
+	InteractshToken  string `json:"interactsh_token,omitempty"`  // 任务专用Interactsh Token
+	ProbeTargets     bool   `json:"probe_targets,omitempty"`     // 扫描前探测裸 host:port 目标的协议，并采集Web目标的标题/指纹/WAF
+
+	// 扫描前枚举域名目标的子域名（crt.sh、HackerTarget被动数据源），探测后将可达的子域名加入任务目标
+	EnumerateSubdomains bool `json:"enumerate_subdomains,omitempty"`
+
+	// 自定义请求头/Cookie（用于扫描需要登录的区域）
+	Headers []HTTPHeader `json:"headers,omitempty"` // 自定义请求头，对应 nuclei -H
+	Cookies string       `json:"cookies,omitempty"` // Cookie 值，如 "session=abc; token=xyz"
+
+	// 登录脚本：扫描前登录获取会话，并在会话过期时自动重新登录
+	Login *LoginConfig `json:"login,omitempty"`
+
+	// 规避设置（随机UA、请求延迟抖动、请求头大小写随机化），为空时使用全局配置
+	Evasion *models.EvasionConfig `json:"evasion,omitempty"`
+
+	// 客户端证书（mTLS），为空时使用全局配置
+	ClientCert ClientCertConfig `json:"client_cert"`
+
+	// TLS设置（容忍无效证书、最低TLS版本、自定义SNI），为空时使用全局配置
+	TLS TLSOptions `json:"tls"`
+
+	// DNS服务器（替换全局配置）和Hosts覆盖（优先于全局配置），使内网域名无需修改系统hosts即可解析
+	Resolvers     []string              `json:"resolvers,omitempty"`
+	HostOverrides []models.HostOverride `json:"host_overrides,omitempty"`
+
+	// 模板变量覆盖，对应 nuclei -var key=value
+	Variables map[string]string `json:"variables,omitempty"`
+
+	// 将凭证字典注入 default-login 模板的用户名/密码载荷
+	InjectCredentials bool `json:"inject_credentials,omitempty"`
+
+	// 启用headless模板（nuclei -headless），需要Chrome/Chromium
+	Headless bool `json:"headless,omitempty"`
+
+	// 启用code协议模板（nuclei -code，会在本机执行命令），需在设置中允许并在启动前确认
+	CodeTemplates bool `json:"code_templates,omitempty"`
+
+	// 允许扫描的时间窗口（如仅 22:00-06:00），窗口外自动暂停并在窗口开启后继续；为空表示不限制
+	ScanWindows []ScanWindow `json:"scan_windows,omitempty"`
+
+	// 速率配置（stealth/normal/aggressive），为空时使用全局默认配置
+	RateProfile      string `json:"rate_profile,omitempty"`
+	PerHostRateLimit int    `json:"per_host_rate_limit,omitempty"` // 单个目标每秒最大请求数，0 表示不限制
+	WAFRateProfile   string `json:"waf_rate_profile,omitempty"`    // 探测到WAF/CDN目标时改用的速率配置（如stealth），为空表示不调整
+
+	// 配置方案（nuclei路径、代理、Interactsh和速率），为空时使用当前配置
+	ConfigProfile string `json:"config_profile,omitempty"`
+
+	// 模板根目录名称：相对路径和模板ID在该目录下解析，为空时使用POC目录
+	TemplateRoot string `json:"template_root,omitempty"`
+}
+
+// VarArgs returns nuclei -var arguments for the given variables, sorted by name
+func VarArgs(variables map[string]string) []string {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		if strings.TrimSpace(name) != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	args := make([]string, 0, len(names)*2)
+	for _, name := range names {
+		args = append(args, "-var", fmt.Sprintf("%s=%s", strings.TrimSpace(name), variables[name]))
+	}
+	return args
+}
+
+// HeaderArgs returns the nuclei -H arguments for the custom headers and cookies
+func (o TaskOptions) HeaderArgs() []string {
+	var args []string
+	for _, h := range o.Headers {
+		name := strings.TrimSpace(h.Name)
+		if name == "" {
+			continue
+		}
+		args = append(args, "-H", fmt.Sprintf("%s: %s", name, strings.TrimSpace(h.Value)))
+	}
+	if cookies := strings.TrimSpace(o.Cookies); cookies != "" {
+		args = append(args, "-H", "Cookie: "+cookies)
+	}
+	return args
+}
+
+// SecretValues returns the header and cookie values (and the Interactsh token) that must not
+// appear in logs or exports
+func (o TaskOptions) SecretValues() []string {
+	var secrets []string
+	if token := strings.TrimSpace(o.InteractshToken); len(token) >= 4 {
+		secrets = append(secrets, token)
+	}
+	for _, h := range o.Headers {
+		if value := strings.TrimSpace(h.Value); len(value) >= 4 {
+			secrets = append(secrets, value)
+		}
+	}
+	for _, cookie := range strings.Split(o.Cookies, ";") {
+		parts := strings.SplitN(strings.TrimSpace(cookie), "=", 2)
+		if len(parts) == 2 && len(parts[1]) >= 4 {
+			secrets = append(secrets, parts[1])
+		}
+	}
+	return secrets
+}
+
+// MaskSecrets replaces every occurrence of the given secret values with "***", including
+// their escaped forms in JSON and HTML text
+func MaskSecrets(text string, secrets []string) string {
+	for _, secret := range secrets {
+		for _, form := range secretForms(secret) {
+			text = strings.ReplaceAll(text, form, "***")
+		}
+	}
+	return text
+}
+
+// secretForms returns the ways a secret is written in masked text: JSON-escaped (with and
+// without the HTML escaping of json.Marshal), HTML-escaped and as is. Escaped forms come
+// first, as the plain secret may be part of them.
+func secretForms(secret string) []string {
+	if secret == "" {
+		return nil
+	}
+	var forms []string
+	add := func(form string) {
+		for _, existing := range forms {
+			if existing == form {
+				return
+			}
+		}
+		forms = append(forms, form)
+	}
+
+	if data, err := json.Marshal(secret); err == nil {
+		add(string(data[1 : len(data)-1]))
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(secret); err == nil {
+		data := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+		add(string(data[1 : len(data)-1]))
+	}
+	add(html.EscapeString(secret))
+	add(secret)
+	return forms
+}
+
+// UpdateTaskOptions replaces the per-task options of a task that is not running
+func (tm *JSONTaskManager) UpdateTaskOptions(taskID int64, options TaskOptions) (*TaskConfig, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.loadTaskConfig(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+
+	if task.Status == "running" {
+		return nil, i18n.Errorf(i18n.ErrTaskRunning)
+	}
+	if err := task.checkUnlocked(); err != nil {
+		return nil, err
+	}
+	if err := validateScanWindows(options.ScanWindows); err != nil {
+		return nil, err
+	}
+	if err := validateRateOptions(options); err != nil {
+		return nil, err
+	}
+	if options.ConfigProfile != "" {
+		if _, ok := LookupConfigProfile(tm.config, options.ConfigProfile); !ok {
+			return nil, i18n.Errorf(i18n.ErrConfigProfileNotFound, options.ConfigProfile)
+		}
+	}
+	if options.TemplateRoot != "" {
+		if _, ok := templateRootDir(options.TemplateRoot); !ok {
+			return nil, i18n.Errorf(i18n.ErrTemplateRootNotFound, options.TemplateRoot)
+		}
+	}
+	if err := validateTLSOptions(options.TLS); err != nil {
+		return nil, err
+	}
+	if err := validateResolverOptions(options.Resolvers, options.HostOverrides); err != nil {
+		return nil, err
+	}
+
+	task.Options = options
+	task.UpdatedAt = time.Now()
+	tm.checkFilteredTemplates(task) // 选项（如headless）影响哪些模板被过滤
+
+	if err := tm.saveTaskConfig(task); err != nil {
+		return nil, fmt.Errorf("failed to save updated task: %v", err)
+	}
+
+	return task, nil
+}
+
+// TaskSecretValues returns the secret header/cookie values configured on a task
+func (tm *JSONTaskManager) TaskSecretValues(taskID int64) []string {
+	task, err := tm.GetTaskByID(taskID)
+	if err != nil {
+		return nil
+	}
+	return task.Options.SecretValues()
+}
@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SetSecretCipher sets the functions encrypting the secrets saved with a task (its Interactsh
+// token) and decrypting them when the task is loaded; encrypt must return encrypted values and
+// decrypt unencrypted values unchanged. With a nil encrypt the secrets are saved as they are.
+func (tm *JSONTaskManager) SetSecretCipher(encrypt, decrypt func(string) (string, error)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.encryptSecret = encrypt
+	tm.decryptSecret = decrypt
+}
+
+// encodeTask marshals a task as it is stored, with its secrets encrypted
+func (tm *JSONTaskManager) encodeTask(task *TaskConfig) ([]byte, error) {
+	stored := *task
+	if tm.encryptSecret != nil && stored.Options.InteractshToken != "" {
+		token, err := tm.encryptSecret(stored.Options.InteractshToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt interactsh token: %w", err)
+		}
+		stored.Options.InteractshToken = token
+	}
+	return json.Marshal(&stored)
+}
+
+// decodeTask unmarshals a stored task and decrypts its secrets. A secret that cannot be
+// decrypted (the secret key is locked) is kept encrypted, so saving the task does not lose it.
+func (tm *JSONTaskManager) decodeTask(data []byte) (*TaskConfig, error) {
+	var task TaskConfig
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, err
+	}
+	if tm.decryptSecret != nil && task.Options.InteractshToken != "" {
+		token, err := tm.decryptSecret(task.Options.InteractshToken)
+		if err != nil {
+			logWarnf("⚠️  任务 %d 的Interactsh Token无法解密: %v\n", task.ID, err)
+		} else {
+			task.Options.InteractshToken = token
+		}
+	}
+	return &task, nil
+}
+
+// SealTaskSecrets encrypts the Interactsh tokens of tasks saved before task secrets were
+// stored encrypted and returns the number of tasks rewritten
+func (tm *JSONTaskManager) SealTaskSecrets() (int, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if tm.encryptSecret == nil {
+		return 0, nil
+	}
+	documents, err := tm.db.ListTasks()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	sealed := 0
+	for _, data := range documents {
+		var stored TaskConfig
+		if err := json.Unmarshal(data, &stored); err != nil || stored.Options.InteractshToken == "" {
+			continue
+		}
+		// 已加密的值原样返回
+		encrypted, err := tm.encryptSecret(stored.Options.InteractshToken)
+		if err != nil {
+			return sealed, fmt.Errorf("failed to encrypt interactsh token: %w", err)
+		}
+		if encrypted == stored.Options.InteractshToken {
+			continue
+		}
+		stored.Options.InteractshToken = encrypted
+		if err := tm.saveTaskConfig(&stored); err != nil {
+			return sealed, err
+		}
+		sealed++
+	}
+	return sealed, nil
+}
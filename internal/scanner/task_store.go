@@ -0,0 +1,360 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"wepoc/internal/i18n"
+	"wepoc/internal/models"
+)
+
+// migrationLegacyScanTasks is the storage migration importing tasks of the legacy scan_tasks table
+const migrationLegacyScanTasks = "legacy_scan_tasks"
+
+var (
+	// legacyTaskFilePattern matches task files of the JSON storage (tasks/task_12.json)
+	legacyTaskFilePattern = regexp.MustCompile(`^task_(\d+)\.json$`)
+	// legacyResultFilePattern matches result files of the JSON storage (results/task_12_result.json)
+	legacyResultFilePattern = regexp.MustCompile(`^task_(\d+)_result\.json$`)
+	// legacyHTTPLogsFilePattern matches HTTP log files of the JSON storage (logs/task_12_http_logs.json)
+	legacyHTTPLogsFilePattern = regexp.MustCompile(`^task_(\d+)_http_logs\.json$`)
+)
+
+// TaskExport is the JSON export of a task with its result and HTTP request logs
+type TaskExport struct {
+	ExportedAt time.Time         `json:"exported_at"`
+	Task       *TaskConfig       `json:"task"`
+	Result     *TaskResult       `json:"result,omitempty"`
+	HTTPLogs   []*HTTPRequestLog `json:"http_logs,omitempty"`
+	Progress   *ScanProgress     `json:"progress,omitempty"`
+}
+
+// migrateStorage moves task data of older versions into the database: the JSON files of
+// the file based storage and the tasks of the legacy scan_tasks table. Imported files are
+// moved to ~/.wepoc/legacy_json so the migration runs only once per file.
+func (tm *JSONTaskManager) migrateStorage() error {
+	legacyDir := filepath.Join(filepath.Dir(tm.tasksDir), "legacy_json")
+	imported := 0
+
+	for _, source := range []struct {
+		dir     string
+		pattern *regexp.Regexp
+		load    func(taskID int64, data []byte) error
+	}{
+		{tm.tasksDir, legacyTaskFilePattern, tm.importLegacyTask},
+		{tm.resultsDir, legacyResultFilePattern, tm.importLegacyResult},
+		{tm.logsDir, legacyHTTPLogsFilePattern, tm.importLegacyHTTPLogs},
+	} {
+		entries, err := os.ReadDir(source.dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			matches := source.pattern.FindStringSubmatch(entry.Name())
+			if entry.IsDir() || matches == nil {
+				continue
+			}
+			taskID, _ := strconv.ParseInt(matches[1], 10, 64)
+			path := filepath.Join(source.dir, entry.Name())
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			if err := source.load(taskID, data); err != nil {
+				logWarnf("⚠️  迁移文件失败: %s, 错误: %v\n", path, err)
+				continue
+			}
+
+			dst := filepath.Join(legacyDir, filepath.Base(source.dir), entry.Name())
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return fmt.Errorf("failed to create legacy directory: %w", err)
+			}
+			if err := os.Rename(path, dst); err != nil {
+				return fmt.Errorf("failed to move migrated file %s: %w", path, err)
+			}
+			imported++
+		}
+	}
+	if imported > 0 {
+		logInfof("📦 已将 %d 个JSON任务文件迁移到数据库，原文件保存在: %s\n", imported, legacyDir)
+	}
+
+	return tm.migrateLegacyScanTasks()
+}
+
+// importLegacyTask stores a task file unless the database already has the task
+func (tm *JSONTaskManager) importLegacyTask(taskID int64, data []byte) error {
+	var task TaskConfig
+	if err := json.Unmarshal(data, &task); err != nil {
+		return err
+	}
+	if exists, err := tm.db.TaskExists(task.ID); err != nil || exists {
+		return err
+	}
+	return tm.saveTaskConfig(&task)
+}
+
+// importLegacyResult stores a result file unless the database already has a result for the task
+func (tm *JSONTaskManager) importLegacyResult(taskID int64, data []byte) error {
+	if _, err := tm.db.GetTaskResult(taskID); err == nil {
+		return nil
+	}
+	var result TaskResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	result.TaskID = taskID
+	return tm.saveTaskResult(&result)
+}
+
+// importLegacyHTTPLogs stores an HTTP log file unless the database already has logs for the task
+func (tm *JSONTaskManager) importLegacyHTTPLogs(taskID int64, data []byte) error {
+	if existing, err := tm.db.GetHTTPLogs(taskID); err != nil || len(existing) > 0 {
+		return err
+	}
+	var logs []*HTTPRequestLog
+	if err := json.Unmarshal(data, &logs); err != nil {
+		return err
+	}
+	return tm.SaveHTTPRequestLogs(taskID, logs)
+}
+
+// migrateLegacyScanTasks copies the tasks of the legacy scan_tasks table (and the findings
+// of their output files) into the task storage under new IDs
+func (tm *JSONTaskManager) migrateLegacyScanTasks() error {
+	applied, err := tm.db.MigrationApplied(migrationLegacyScanTasks)
+	if err != nil || applied {
+		return err
+	}
+
+	legacyTasks, err := tm.db.GetAllScanTasks()
+	if err != nil {
+		return err
+	}
+	nextID, err := tm.findNextTaskID()
+	if err != nil {
+		return err
+	}
+
+	for _, legacy := range legacyTasks {
+		task := &TaskConfig{
+			ID:                nextID,
+			Name:              legacy.Name,
+			Status:            legacy.Status,
+			TotalRequests:     legacy.TotalRequests,
+			CompletedRequests: legacy.CompletedRequests,
+			FoundVulns:        legacy.FoundVulns,
+			StartTime:         legacy.StartTime,
+			EndTime:           legacy.EndTime,
+			OutputFile:        filepath.Join(tm.resultsDir, resultFileName(nextID)),
+			LogFile:           filepath.Join(tm.logsDir, fmt.Sprintf("task_%d.log", nextID)),
+			CreatedAt:         legacy.CreatedAt,
+			UpdatedAt:         legacy.CreatedAt,
+		}
+		json.Unmarshal([]byte(legacy.POCs), &task.POCs)
+		json.Unmarshal([]byte(legacy.Targets), &task.Targets)
+		// 旧任务管理器的进程已不存在，未结束的任务按已停止处理
+		if !finishedTask(task) {
+			task.Status = "stopped"
+		}
+		if err := tm.saveTaskConfig(task); err != nil {
+			return err
+		}
+
+		if vulns := loadLegacyOutput(legacy.OutputFile); len(vulns) > 0 {
+			result := &TaskResult{
+				TaskID:          task.ID,
+				TaskName:        task.Name,
+				Status:          task.Status,
+				StartTime:       task.StartTime,
+				Targets:         task.Targets,
+				Templates:       task.POCs,
+				TemplateCount:   len(task.POCs),
+				TargetCount:     len(task.Targets),
+				TotalRequests:   task.TotalRequests,
+				FoundVulns:      len(vulns),
+				Vulnerabilities: vulns,
+				Summary:         map[string]interface{}{"migrated_from": "scan_tasks", "legacy_id": legacy.ID},
+				CreatedAt:       task.CreatedAt,
+			}
+			if task.EndTime != nil {
+				result.EndTime = *task.EndTime
+			}
+			if err := tm.saveTaskResult(result); err != nil {
+				return err
+			}
+		}
+		nextID++
+	}
+
+	if len(legacyTasks) > 0 {
+		logInfof("📦 已将 %d 个旧版数据库任务迁移到任务存储\n", len(legacyTasks))
+	}
+	return tm.db.MarkMigrationApplied(migrationLegacyScanTasks)
+}
+
+// loadLegacyOutput reads the findings written by the legacy scanner (a JSON array)
+func loadLegacyOutput(outputFile string) []*models.NucleiResult {
+	if outputFile == "" {
+		return nil
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		outputFile = strings.Replace(outputFile, "~", homeDir, 1)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		return nil
+	}
+	var results []*models.NucleiResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil
+	}
+	return results
+}
+
+// saveProgressSnapshot stores the latest progress of a task so it survives restarts
+func (tm *JSONTaskManager) saveProgressSnapshot(progress *ScanProgress) {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return
+	}
+	if err := tm.db.SaveTaskProgress(progress.TaskID, data); err != nil {
+		logWarnf("⚠️ 保存任务 %d 进度快照失败: %v\n", progress.TaskID, err)
+	}
+}
+
+// storedProgress returns the last progress snapshot of a task, or nil
+func (tm *JSONTaskManager) storedProgress(taskID int64) *ScanProgress {
+	data, err := tm.db.GetTaskProgress(taskID)
+	if err != nil {
+		return nil
+	}
+	var progress ScanProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil
+	}
+	return &progress
+}
+
+// ExportTask returns a task with its result, HTTP request logs and last progress snapshot
+func (tm *JSONTaskManager) ExportTask(taskID int64) (*TaskExport, error) {
+	task, err := tm.GetTaskByID(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+
+	export := &TaskExport{ExportedAt: time.Now(), Task: task, Progress: tm.storedProgress(taskID)}
+	if result, err := tm.ExportTaskResult(taskID); err == nil {
+		export.Result = result
+	}
+	logs, err := tm.GetHTTPRequestLogs(taskID)
+	if err != nil {
+		return nil, err
+	}
+	export.HTTPLogs = logs
+	maskTaskExport(export)
+	return export, nil
+}
+
+// sensitiveHeaders are the headers whose values are masked in the requests and responses of
+// a task export
+var sensitiveHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"x-api-key":           true,
+}
+
+// maskHeaders replaces the values of credential headers (and of extra, e.g. the header a
+// login script injects) in the header section of a raw HTTP message
+func maskHeaders(raw string, extra string) string {
+	lines := strings.Split(raw, "\n")
+	for i, line := range lines {
+		if i == 0 {
+			continue // 请求行/状态行
+		}
+		if strings.TrimSpace(line) == "" {
+			break // 头部结束
+		}
+		name, _, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		lower := strings.ToLower(strings.TrimSpace(name))
+		if sensitiveHeaders[lower] || (extra != "" && lower == strings.ToLower(extra)) {
+			lines[i] = name + ": ***"
+			if strings.HasSuffix(line, "\r") {
+				lines[i] += "\r"
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maskTaskExport removes the credentials of a task from its export: the custom header and
+// cookie values, the Interactsh token and the login request of the task options, and the
+// credential headers and secret values in the findings and HTTP request logs
+func maskTaskExport(export *TaskExport) {
+	options := &export.Task.Options
+	secrets := options.SecretValues()
+	injectHeader := ""
+	for i := range options.Headers {
+		if options.Headers[i].Value != "" {
+			options.Headers[i].Value = "***"
+		}
+	}
+	if options.Cookies != "" {
+		options.Cookies = "***"
+	}
+	if options.InteractshToken != "" {
+		options.InteractshToken = "***"
+	}
+	if options.Login != nil {
+		login := *options.Login
+		login.RawRequest = "***"
+		injectHeader = login.InjectHeader
+		if injectHeader == "" {
+			injectHeader = "Authorization"
+		}
+		options.Login = &login
+	}
+	mask := func(raw string) string {
+		return MaskSecrets(maskHeaders(raw, injectHeader), secrets)
+	}
+
+	if export.Result != nil {
+		for _, vuln := range export.Result.Vulnerabilities {
+			vuln.Request = mask(vuln.Request)
+			vuln.Response = mask(vuln.Response)
+			vuln.CurlCommand = MaskSecrets(vuln.CurlCommand, secrets)
+		}
+	}
+	for _, log := range export.HTTPLogs {
+		log.Request = mask(log.Request)
+		log.Response = mask(log.Response)
+	}
+}
+
+// ExportTaskJSON writes the JSON export of a task to path, sealed with a manifest of hashes
+func (tm *JSONTaskManager) ExportTaskJSON(taskID int64, path string) error {
+	export, err := tm.ExportTask(taskID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal task export: %w", err)
+	}
+	if err := WriteSealedExport(path, data); err != nil {
+		return fmt.Errorf("failed to write task export: %w", err)
+	}
+	logInfof("💾 任务 %d 已导出: %s\n", taskID, path)
+	return nil
+}
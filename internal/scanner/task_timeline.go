@@ -0,0 +1,234 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"wepoc/internal/i18n"
+)
+
+// Task timeline milestones
+const (
+	TimelineCreated       = "created"        // 任务创建
+	TimelineQueued        = "queued"         // 等待扫描时间窗口或资源
+	TimelineStarted       = "started"        // nuclei开始执行（每次启动/重扫/续扫各一次）
+	TimelineFirstRequest  = "first_request"  // 本次执行发出第一个请求
+	TimelineFirstVuln     = "first_vuln"     // 本次执行发现第一个漏洞
+	TimelineBatchFinished = "batch_finished" // 完成一批模板（每批为所选模板的1/timelineBatches）
+	TimelineCompleted     = "completed"
+	TimelineFailed        = "failed"
+	TimelineStopped       = "stopped"
+	TimelineInterrupted   = "interrupted" // 程序退出时中断
+	TimelinePaused        = "paused"      // 超出扫描时间窗口或用户暂停
+)
+
+// timelineBatches is the number of template batches a scan's progress is split into
+const timelineBatches = 4
+
+// TimelineEvent is a milestone of a task's execution
+type TimelineEvent struct {
+	Kind       string    `json:"kind"`
+	Time       time.Time `json:"time"`
+	Run        int       `json:"run"`        // 第几次执行，创建后首次启动前为0
+	ElapsedMs  int64     `json:"elapsed_ms"` // 距本次执行开始的毫秒数
+	Message    string    `json:"message,omitempty"`
+	Batch      int       `json:"batch,omitempty"`     // batch_finished 的批次序号（1-based）
+	Templates  int       `json:"templates,omitempty"` // batch_finished 时已完成的模板数
+	TemplateID string    `json:"template_id,omitempty"`
+	Target     string    `json:"target,omitempty"`
+}
+
+// timelineFileName returns the timeline file of a task inside the logs directory
+func timelineFileName(taskID int64) string {
+	return fmt.Sprintf("task_%d_timeline.jsonl", taskID)
+}
+
+// timelineMu serializes appends to timeline files
+var timelineMu sync.Mutex
+
+// recordTimeline appends a milestone to the timeline of a task; failures are only logged
+func (tm *JSONTaskManager) recordTimeline(taskID int64, event *TimelineEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		logWarnf("⚠️ 记录任务时间线失败: %v\n", err)
+		return
+	}
+
+	timelineMu.Lock()
+	defer timelineMu.Unlock()
+	file, err := os.OpenFile(filepath.Join(tm.logsDir, timelineFileName(taskID)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		logWarnf("⚠️ 记录任务时间线失败: %v\n", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		logWarnf("⚠️ 记录任务时间线失败: %v\n", err)
+	}
+}
+
+// GetTaskTimeline returns the milestones of a task in the order they happened, with the
+// run they belong to and their offset from the start of that run
+func (tm *JSONTaskManager) GetTaskTimeline(taskID int64) ([]*TimelineEvent, error) {
+	data, err := os.ReadFile(filepath.Join(tm.logsDir, timelineFileName(taskID)))
+	if os.IsNotExist(err) {
+		// 已归档任务从压缩包中读取
+		if data, err = tm.readArchivedFile(taskID, "logs/"+timelineFileName(taskID)); err != nil {
+			return []*TimelineEvent{}, nil
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read task timeline: %w", err)
+	}
+
+	events := []*TimelineEvent{}
+	run := 0
+	var runStart time.Time
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		event := &TimelineEvent{}
+		if err := json.Unmarshal(line, event); err != nil {
+			continue
+		}
+		if event.Kind == TimelineStarted {
+			run++
+			runStart = event.Time
+		}
+		event.Run = run
+		if !runStart.IsZero() {
+			event.ElapsedMs = event.Time.Sub(runStart).Milliseconds()
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// timelineTracker records the milestones reached during one scan
+type timelineTracker struct {
+	mu           sync.Mutex
+	firstRequest bool
+	firstVuln    bool
+	batches      int // 已记录的批次数
+}
+
+// timelineStatus maps the final status of a run to its timeline milestone
+func timelineStatus(status string) string {
+	switch status {
+	case "completed":
+		return TimelineCompleted
+	case "stopped":
+		return TimelineStopped
+	case "interrupted":
+		return TimelineInterrupted
+	case "waiting_window", "paused":
+		return TimelinePaused
+	default:
+		return TimelineFailed
+	}
+}
+
+// recordTimeline records a milestone of the scanned task
+func (sns *SimpleNucleiScanner) recordTimeline(event *TimelineEvent) {
+	if sns.manager != nil {
+		sns.manager.recordTimeline(sns.task.ID, event)
+	}
+}
+
+// markFirstRequest records the first request of the scan
+func (sns *SimpleNucleiScanner) markFirstRequest(templateID, target string) {
+	sns.timeline.mu.Lock()
+	first := !sns.timeline.firstRequest
+	sns.timeline.firstRequest = true
+	sns.timeline.mu.Unlock()
+	if first {
+		sns.recordTimeline(&TimelineEvent{Kind: TimelineFirstRequest, TemplateID: templateID, Target: target})
+	}
+}
+
+// markFirstVuln records the first finding of the scan
+func (sns *SimpleNucleiScanner) markFirstVuln(templateID, target string) {
+	sns.timeline.mu.Lock()
+	first := !sns.timeline.firstVuln
+	sns.timeline.firstVuln = true
+	sns.timeline.mu.Unlock()
+	if first {
+		sns.recordTimeline(&TimelineEvent{Kind: TimelineFirstVuln, TemplateID: templateID, Target: target})
+	}
+}
+
+// markTemplatesCompleted records a batch milestone each time the completed templates reach
+// another 1/timelineBatches of the selected templates
+func (sns *SimpleNucleiScanner) markTemplatesCompleted(completed, total int) {
+	if total <= 0 {
+		return
+	}
+	sns.timeline.mu.Lock()
+	var finished []int
+	for sns.timeline.batches < timelineBatches && completed*timelineBatches >= (sns.timeline.batches+1)*total {
+		sns.timeline.batches++
+		finished = append(finished, sns.timeline.batches)
+	}
+	sns.timeline.mu.Unlock()
+
+	for _, batch := range finished {
+		sns.recordTimeline(&TimelineEvent{
+			Kind:      TimelineBatchFinished,
+			Batch:     batch,
+			Templates: completed,
+			Message:   fmt.Sprintf("已完成 %d/%d 个模板", completed, total),
+		})
+	}
+}
+
+// TaskSummary condenses a task: statistics of its last result, its timeline milestones and
+// the error categories of its last scan
+type TaskSummary struct {
+	TaskID            int64             `json:"task_id"`
+	Status            string            `json:"status"`
+	StartTime         time.Time         `json:"start_time"`
+	EndTime           *time.Time        `json:"end_time,omitempty"`
+	Duration          string            `json:"duration"`
+	TotalRequests     int               `json:"total_requests"`
+	CompletedRequests int               `json:"completed_requests"`
+	FoundVulns        int               `json:"found_vulns"`
+	SuccessRate       float64           `json:"success_rate"`
+	KeyEvents         []*TimelineEvent  `json:"key_events"`
+	Errors            *TaskErrorSummary `json:"errors,omitempty"`
+}
+
+// GetTaskSummary returns the summary of a task
+func (tm *JSONTaskManager) GetTaskSummary(taskID int64) (*TaskSummary, error) {
+	task, err := tm.GetTaskByID(taskID)
+	if err != nil {
+		return nil, i18n.Wrap(i18n.ErrTaskLoad, err)
+	}
+	summary := &TaskSummary{
+		TaskID:            taskID,
+		Status:            task.Status,
+		StartTime:         task.StartTime,
+		EndTime:           task.EndTime,
+		TotalRequests:     task.TotalRequests,
+		CompletedRequests: task.CompletedRequests,
+		FoundVulns:        task.FoundVulns,
+	}
+	if result, err := tm.GetTaskResult(taskID); err == nil {
+		summary.Duration = result.Duration
+		summary.TotalRequests = result.TotalRequests
+		summary.CompletedRequests = result.CompletedRequests
+		summary.FoundVulns = result.FoundVulns
+		summary.SuccessRate = result.SuccessRate
+	}
+	if summary.KeyEvents, err = tm.GetTaskTimeline(taskID); err != nil {
+		return nil, err
+	}
+	if errorSummary, err := tm.GetTaskErrorSummary(taskID); err == nil {
+		summary.Errors = errorSummary
+	}
+	return summary, nil
+}
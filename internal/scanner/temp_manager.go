@@ -5,7 +5,6 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 )
 
@@ -28,7 +27,7 @@ func NewTempManager() (*TempManager, error) {
 	logger, err := NewEnhancedLogger(0, "temp_manager")
 	if err != nil {
 		// If logger creation fails, continue without it
-		fmt.Printf("⚠️  Failed to create enhanced logger for temp manager: %v\n", err)
+		logWarnf("⚠️  Failed to create enhanced logger for temp manager: %v\n", err)
 	}
 	
 	tm := &TempManager{
@@ -73,19 +72,10 @@ func (tm *TempManager) CreateTempTemplateDir(taskID int64, selectedPOCs []string
 		return "", fmt.Errorf("failed to create temp directory %s: %w", tempDir, err)
 	}
 	
-	fmt.Printf("📁 创建临时模板目录: %s\n", tempDir)
+	logInfof("📁 创建临时模板目录: %s\n", tempDir)
 	
 	// Get source templates directory
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		if tm.logger != nil {
-			tm.logger.Error("Failed to get user home directory", err, map[string]interface{}{
-				"task_id": taskID,
-			})
-		}
-		return "", fmt.Errorf("failed to get user home directory: %w", err)
-	}
-	templatesDir := filepath.Join(homeDir, ".wepoc", "nuclei-templates")
+	templatesDir := defaultTemplatesDir()
 	
 	if tm.logger != nil {
 		tm.logger.Debug("Source templates directory", map[string]interface{}{
@@ -106,20 +96,20 @@ func (tm *TempManager) CreateTempTemplateDir(taskID int64, selectedPOCs []string
 		if filepath.IsAbs(pocPath) {
 			srcPath = pocPath
 		} else {
-			srcPath = filepath.Join(templatesDir, pocPath)
+			srcPath = resolveTemplatePathIn(pocPath, "")
 		}
 		
-		// Create destination path (preserve directory structure)
-		relPath := pocPath
-		if filepath.IsAbs(pocPath) {
-			// For absolute paths, try to make them relative to templates directory
-			if strings.HasPrefix(pocPath, templatesDir) {
-				relPath = strings.TrimPrefix(pocPath, templatesDir)
-				relPath = strings.TrimPrefix(relPath, string(filepath.Separator))
-			} else {
-				// Use just the filename for absolute paths outside templates dir
-				relPath = filepath.Base(pocPath)
+		// Create destination path (preserve directory structure below the template root;
+		// templates of other roots go below a directory named after the root)
+		var relPath string
+		if rootName, rootDir, ok := templateRootOf(srcPath); ok {
+			relPath, _ = filepath.Rel(rootDir, srcPath)
+			if rootName != DefaultTemplateRoot {
+				relPath = filepath.Join(rootName, relPath)
 			}
+		} else {
+			// Use just the filename for paths outside every template root
+			relPath = filepath.Base(srcPath)
 		}
 		
 		dstPath := filepath.Join(tempDir, relPath)
@@ -182,12 +172,12 @@ func (tm *TempManager) CreateTempTemplateDir(taskID int64, selectedPOCs []string
 		})
 	}
 	
-	fmt.Printf("📋 复制完成: %d/%d 个POC文件 (耗时: %v)\n", copiedCount, len(selectedPOCs), duration)
+	logInfof("📋 复制完成: %d/%d 个POC文件 (耗时: %v)\n", copiedCount, len(selectedPOCs), duration)
 	
 	if failedCount > 0 {
-		fmt.Printf("⚠️  %d 个文件复制失败\n", failedCount)
+		logWarnf("⚠️  %d 个文件复制失败\n", failedCount)
 		for _, errMsg := range copyErrors {
-			fmt.Printf("   - %s\n", errMsg)
+			logInfof("   - %s\n", errMsg)
 		}
 	}
 	
@@ -233,7 +223,7 @@ func (tm *TempManager) CleanupTempDir(tempDir string) error {
 				"temp_dir": tempDir,
 			})
 		}
-		fmt.Printf("📁 临时目录不存在，跳过清理: %s\n", tempDir)
+		logInfof("📁 临时目录不存在，跳过清理: %s\n", tempDir)
 		return nil
 	}
 	
@@ -273,7 +263,7 @@ func (tm *TempManager) CleanupTempDir(tempDir string) error {
 		})
 	}
 	
-	fmt.Printf("🗑️  已清理临时目录: %s (文件数: %d, 耗时: %v)\n", tempDir, fileCount, duration)
+	logInfof("🗑️  已清理临时目录: %s (文件数: %d, 耗时: %v)\n", tempDir, fileCount, duration)
 	return nil
 }
 
@@ -305,7 +295,7 @@ func (tm *TempManager) CleanupOldTempDirs() error {
 		// Remove directories older than 24 hours
 		if info.ModTime().Before(cutoff) {
 			if err := os.RemoveAll(dirPath); err != nil {
-				fmt.Printf("⚠️  清理旧临时目录失败: %s, 错误: %v\n", dirPath, err)
+				logWarnf("⚠️  清理旧临时目录失败: %s, 错误: %v\n", dirPath, err)
 			} else {
 				cleanedCount++
 			}
@@ -313,7 +303,7 @@ func (tm *TempManager) CleanupOldTempDirs() error {
 	}
 	
 	if cleanedCount > 0 {
-		fmt.Printf("🧹 清理了 %d 个旧的临时目录\n", cleanedCount)
+		logInfof("🧹 清理了 %d 个旧的临时目录\n", cleanedCount)
 	}
 	
 	return nil
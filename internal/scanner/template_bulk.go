@@ -0,0 +1,200 @@
+package scanner
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"wepoc/internal/database"
+	"wepoc/internal/models"
+)
+
+// BulkProgressFunc reports the progress of a bulk template operation, like the import callback
+type BulkProgressFunc func(current, total int, status string, stats ...map[string]int)
+
+// BulkTemplateResult is the outcome of a bulk operation over a list of template IDs
+type BulkTemplateResult struct {
+	Operation  string   `json:"operation"` // delete, tags, export, validate
+	Total      int      `json:"total"`
+	Succeeded  int      `json:"succeeded"`
+	Failed     int      `json:"failed"`
+	Errors     []string `json:"errors"`
+	Invalid    []string `json:"invalid,omitempty"`     // 重新验证未通过的模板ID
+	OutputPath string   `json:"output_path,omitempty"` // 导出的zip文件
+}
+
+// forEachTemplate runs fn for every template ID and reports progress after each one.
+// Failures are collected in the result and don't stop the operation.
+func forEachTemplate(db *database.Database, operation string, templateIDs []string, progress BulkProgressFunc, fn func(template *models.Template) error) *BulkTemplateResult {
+	result := &BulkTemplateResult{Operation: operation, Total: len(templateIDs), Errors: []string{}}
+
+	for i, templateID := range templateIDs {
+		template, err := db.GetTemplateByTemplateID(templateID)
+		if err == nil {
+			err = fn(template)
+		}
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", templateID, err))
+		} else {
+			result.Succeeded++
+		}
+
+		if progress != nil {
+			progress(i+1, result.Total, fmt.Sprintf("正在处理: %s", templateID), map[string]int{
+				"successful": result.Succeeded,
+				"errors":     result.Failed,
+			})
+		}
+	}
+	return result
+}
+
+// BulkDeleteTemplates removes the files and database entries of templates
+func (tp *TemplateParser) BulkDeleteTemplates(db *database.Database, templateIDs []string, progress BulkProgressFunc) *BulkTemplateResult {
+	return forEachTemplate(db, "delete", templateIDs, progress, func(template *models.Template) error {
+		if err := os.Remove(template.FilePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete template file: %w", err)
+		}
+		return db.DeleteTemplate(template.ID)
+	})
+}
+
+// BulkEditTemplateTags adds and removes tags of templates, both in the YAML file and the database
+func (tp *TemplateParser) BulkEditTemplateTags(db *database.Database, templateIDs []string, addTags, removeTags []string, progress BulkProgressFunc) *BulkTemplateResult {
+	return forEachTemplate(db, "tags", templateIDs, progress, func(template *models.Template) error {
+		tags := editTags(splitTags(template.Tags), addTags, removeTags)
+		if err := setTemplateTags(template.FilePath, tags); err != nil {
+			return err
+		}
+		return db.UpdateTemplateTags(template.TemplateID, strings.Join(tags, ","))
+	})
+}
+
+// BulkExportTemplates writes the YAML files of templates to a zip archive. Files are stored
+// below their category so that importing the archive restores the categories.
+func (tp *TemplateParser) BulkExportTemplates(db *database.Database, templateIDs []string, zipPath string, progress BulkProgressFunc) (*BulkTemplateResult, error) {
+	file, err := os.Create(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer file.Close()
+	writer := zip.NewWriter(file)
+
+	result := forEachTemplate(db, "export", templateIDs, progress, func(template *models.Template) error {
+		data, err := os.ReadFile(template.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read template file: %w", err)
+		}
+		return addBytesToZip(writer, path.Join(template.Category, filepath.Base(template.FilePath)), data)
+	})
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	result.OutputPath = zipPath
+	logInfof("📦 已导出 %d 个模板: %s\n", result.Succeeded, zipPath)
+	return result, nil
+}
+
+// BulkRevalidateTemplates runs nuclei -validate on templates again, e.g. after a nuclei upgrade
+func (tp *TemplateParser) BulkRevalidateTemplates(db *database.Database, templateIDs []string, nucleiPath string, progress BulkProgressFunc) *BulkTemplateResult {
+	var invalid []string
+	result := forEachTemplate(db, "validate", templateIDs, progress, func(template *models.Template) error {
+		if err := tp.ValidateTemplate(template.FilePath, nucleiPath); err != nil {
+			invalid = append(invalid, template.TemplateID)
+			return err
+		}
+		return nil
+	})
+	result.Invalid = invalid
+	return result
+}
+
+// splitTags splits a comma separated tag list
+func splitTags(tags string) []string {
+	var result []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// editTags removes and then adds tags (case-insensitive), keeping the existing order
+func editTags(tags, addTags, removeTags []string) []string {
+	seen := make(map[string]bool)
+	for _, tag := range removeTags {
+		seen[strings.ToLower(strings.TrimSpace(tag))] = true
+	}
+
+	var result []string
+	for _, tag := range append(tags, addTags...) {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// setTemplateTags rewrites info.tags of a template file. The YAML is re-encoded from its
+// node tree, which keeps comments and key order but may normalize indentation and blank lines.
+func setTemplateTags(filePath string, tags []string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read template file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse template YAML: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid template structure")
+	}
+	info := mappingValue(doc.Content[0], "info")
+	if info == nil || info.Kind != yaml.MappingNode {
+		return fmt.Errorf("template has no info section")
+	}
+
+	value := stringScalar(strings.Join(tags, ","))
+	replaced := false
+	for i := 0; i+1 < len(info.Content); i += 2 {
+		if info.Content[i].Value != "tags" {
+			continue
+		}
+		if len(tags) == 0 {
+			info.Content = append(info.Content[:i], info.Content[i+2:]...)
+		} else {
+			info.Content[i+1] = value
+		}
+		replaced = true
+		break
+	}
+	if !replaced && len(tags) > 0 {
+		setMappingValue(info, "tags", value.Value)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode template YAML: %w", err)
+	}
+	encoder.Close()
+
+	if err := os.WriteFile(filePath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write template file: %w", err)
+	}
+	return nil
+}
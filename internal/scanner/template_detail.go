@@ -0,0 +1,293 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"wepoc/internal/models"
+)
+
+// TemplateDetail is the structured content of a template, so that the frontend doesn't
+// have to parse the raw YAML
+type TemplateDetail struct {
+	Template          *models.Template       `json:"template"` // 数据库中的模板记录（分类、收藏等）
+	Info              *TemplateDetailInfo    `json:"info"`
+	Protocols         []string               `json:"protocols"` // 模板包含的请求类型（http/dns/network/...）
+	Requests          []*TemplateRequest     `json:"requests"`
+	Variables         map[string]interface{} `json:"variables,omitempty"` // 模板中定义的变量
+	RequiredVariables []string               `json:"required_variables"`  // 模板引用但未定义的变量，需要通过 -var 提供
+	Flow              string                 `json:"flow,omitempty"`
+	SelfContained     bool                   `json:"self_contained"`
+}
+
+// TemplateDetailInfo is the info section of a template
+type TemplateDetailInfo struct {
+	Name           string                 `json:"name"`
+	Authors        []string               `json:"authors"`
+	Severity       string                 `json:"severity"`
+	Description    string                 `json:"description"`
+	Remediation    string                 `json:"remediation,omitempty"`
+	References     []string               `json:"references"`
+	Tags           []string               `json:"tags"`
+	Classification map[string]interface{} `json:"classification,omitempty"` // cve-id、cwe-id、cvss等
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// TemplateRequest is one request block of a template
+type TemplateRequest struct {
+	Protocol          string                 `json:"protocol"`
+	Index             int                    `json:"index"` // 同协议请求块中的序号（0起）
+	ID                string                 `json:"id,omitempty"`
+	Method            string                 `json:"method,omitempty"`
+	Paths             []string               `json:"paths,omitempty"`
+	Raw               []string               `json:"raw,omitempty"`
+	Headers           map[string]string      `json:"headers,omitempty"`
+	Body              string                 `json:"body,omitempty"`
+	Payloads          map[string]interface{} `json:"payloads,omitempty"`
+	Attack            string                 `json:"attack,omitempty"`
+	MatchersCondition string                 `json:"matchers_condition,omitempty"`
+	Matchers          []*TemplateMatcher     `json:"matchers"`
+	Extractors        []*TemplateExtractor   `json:"extractors"`
+	Definition        map[string]interface{} `json:"definition"` // 完整的请求块，包含上面未展开的字段
+}
+
+// TemplateMatcher is a matcher of a request block
+type TemplateMatcher struct {
+	Type      string   `json:"type"` // word/regex/status/size/binary/dsl/xpath
+	Name      string   `json:"name,omitempty"`
+	Part      string   `json:"part,omitempty"`
+	Condition string   `json:"condition,omitempty"`
+	Negative  bool     `json:"negative,omitempty"`
+	Internal  bool     `json:"internal,omitempty"`
+	Values    []string `json:"values"` // 按类型取自 words/regex/status/size/binary/dsl/xpath
+}
+
+// TemplateExtractor is an extractor of a request block
+type TemplateExtractor struct {
+	Type     string   `json:"type"` // regex/kval/json/xpath/dsl
+	Name     string   `json:"name,omitempty"`
+	Part     string   `json:"part,omitempty"`
+	Group    int      `json:"group,omitempty"`
+	Internal bool     `json:"internal,omitempty"`
+	Values   []string `json:"values"` // 按类型取自 regex/kval/json/xpath/dsl
+}
+
+// matcherValueKeys are the keys holding the values of each matcher type
+var matcherValueKeys = []string{"words", "regex", "status", "size", "binary", "dsl", "xpath"}
+
+// extractorValueKeys are the keys holding the values of each extractor type
+var extractorValueKeys = []string{"regex", "kval", "json", "xpath", "dsl"}
+
+// templateVariablePattern matches plain {{name}} placeholders (DSL expressions are ignored)
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// builtinTemplateVariables are the variables nuclei provides for every target
+var builtinTemplateVariables = map[string]bool{
+	"BaseURL": true, "RootURL": true, "Hostname": true, "Host": true, "Port": true,
+	"Path": true, "File": true, "Scheme": true, "Input": true, "FQDN": true,
+	"RDN": true, "DN": true, "TLD": true, "SD": true, "ip": true,
+	"interactsh_url": true, "interactsh_protocol": true, "interactsh_request": true, "interactsh_response": true,
+	"host": true, "port": true, "hostname": true, "randstr": true,
+}
+
+// ParseTemplateDetail parses a template file into its structured form
+func (tp *TemplateParser) ParseTemplateDetail(filePath string) (*TemplateDetail, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template file: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse template YAML: %w", err)
+	}
+
+	detail := &TemplateDetail{
+		Info:      parseTemplateInfo(doc["info"]),
+		Protocols: []string{},
+		Requests:  []*TemplateRequest{},
+	}
+	detail.Flow, _ = doc["flow"].(string)
+	detail.SelfContained, _ = doc["self-contained"].(bool)
+	if variables, ok := doc["variables"].(map[string]interface{}); ok {
+		detail.Variables = variables
+	}
+
+	// 模板自身定义的名称：变量、载荷和内部提取器的结果
+	defined := make(map[string]bool)
+	for name := range detail.Variables {
+		defined[name] = true
+	}
+
+	for _, protocol := range requestProtocols {
+		blocks, ok := doc[protocol].([]interface{})
+		if !ok {
+			continue
+		}
+		protocol = normalizeProtocol(protocol)
+		if !containsString(detail.Protocols, protocol) {
+			detail.Protocols = append(detail.Protocols, protocol)
+		}
+		for i, block := range blocks {
+			definition, ok := block.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			request := parseTemplateRequest(protocol, i, definition)
+			for name := range request.Payloads {
+				defined[name] = true
+			}
+			for _, extractor := range request.Extractors {
+				if extractor.Name != "" {
+					defined[extractor.Name] = true
+				}
+			}
+			detail.Requests = append(detail.Requests, request)
+		}
+	}
+
+	detail.RequiredVariables = requiredVariables(string(data), defined)
+	return detail, nil
+}
+
+// parseTemplateInfo reads the info section of a template
+func parseTemplateInfo(value interface{}) *TemplateDetailInfo {
+	info := &TemplateDetailInfo{Authors: []string{}, References: []string{}, Tags: []string{}}
+	section, ok := value.(map[string]interface{})
+	if !ok {
+		return info
+	}
+	info.Name, _ = section["name"].(string)
+	info.Severity, _ = section["severity"].(string)
+	if description, ok := section["description"].(string); ok {
+		info.Description = strings.TrimSpace(description)
+	}
+	if remediation, ok := section["remediation"].(string); ok {
+		info.Remediation = strings.TrimSpace(remediation)
+	}
+	info.Authors = stringList(section["author"], true)
+	info.References = stringList(section["reference"], false)
+	info.Tags = stringList(section["tags"], true)
+	info.Classification, _ = section["classification"].(map[string]interface{})
+	info.Metadata, _ = section["metadata"].(map[string]interface{})
+	return info
+}
+
+// parseTemplateRequest extracts the commonly used fields of a request block
+func parseTemplateRequest(protocol string, index int, definition map[string]interface{}) *TemplateRequest {
+	request := &TemplateRequest{
+		Protocol:   protocol,
+		Index:      index,
+		Matchers:   []*TemplateMatcher{},
+		Extractors: []*TemplateExtractor{},
+		Definition: definition,
+	}
+	request.ID, _ = definition["id"].(string)
+	request.Method, _ = definition["method"].(string)
+	request.Paths = stringList(definition["path"], false)
+	request.Raw = stringList(definition["raw"], false)
+	request.Body, _ = definition["body"].(string)
+	request.Attack, _ = definition["attack"].(string)
+	request.MatchersCondition, _ = definition["matchers-condition"].(string)
+	request.Payloads, _ = definition["payloads"].(map[string]interface{})
+
+	if headers, ok := definition["headers"].(map[string]interface{}); ok {
+		request.Headers = make(map[string]string, len(headers))
+		for name, value := range headers {
+			request.Headers[name] = fmt.Sprint(value)
+		}
+	}
+
+	matchers, _ := definition["matchers"].([]interface{})
+	for _, item := range matchers {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		matcher := &TemplateMatcher{Values: []string{}}
+		matcher.Type, _ = m["type"].(string)
+		matcher.Name, _ = m["name"].(string)
+		matcher.Part, _ = m["part"].(string)
+		matcher.Condition, _ = m["condition"].(string)
+		matcher.Negative, _ = m["negative"].(bool)
+		matcher.Internal, _ = m["internal"].(bool)
+		for _, key := range matcherValueKeys {
+			matcher.Values = append(matcher.Values, stringList(m[key], false)...)
+		}
+		request.Matchers = append(request.Matchers, matcher)
+	}
+
+	extractors, _ := definition["extractors"].([]interface{})
+	for _, item := range extractors {
+		e, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		extractor := &TemplateExtractor{Values: []string{}}
+		extractor.Type, _ = e["type"].(string)
+		extractor.Name, _ = e["name"].(string)
+		extractor.Part, _ = e["part"].(string)
+		extractor.Group, _ = e["group"].(int)
+		extractor.Internal, _ = e["internal"].(bool)
+		for _, key := range extractorValueKeys {
+			extractor.Values = append(extractor.Values, stringList(e[key], false)...)
+		}
+		request.Extractors = append(request.Extractors, extractor)
+	}
+	return request
+}
+
+// requiredVariables returns the {{name}} placeholders of a template that are neither
+// nuclei built-ins nor defined by the template itself, sorted by name
+func requiredVariables(content string, defined map[string]bool) []string {
+	seen := make(map[string]bool)
+	required := []string{}
+	for _, match := range templateVariablePattern.FindAllStringSubmatch(content, -1) {
+		name := match[1]
+		if seen[name] || defined[name] || builtinTemplateVariables[name] || strings.HasPrefix(name, "randstr_") {
+			continue
+		}
+		seen[name] = true
+		required = append(required, name)
+	}
+	sort.Strings(required)
+	return required
+}
+
+// stringList converts a YAML scalar or list into strings; comma separated scalars are
+// split when splitComma is set (author and tags)
+func stringList(value interface{}, splitComma bool) []string {
+	result := []string{}
+	switch v := value.(type) {
+	case nil:
+	case []interface{}:
+		for _, item := range v {
+			result = append(result, fmt.Sprint(item))
+		}
+	case string:
+		if !splitComma {
+			return append(result, v)
+		}
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	default:
+		result = append(result, fmt.Sprint(v))
+	}
+	return result
+}
+
+// containsString reports whether list contains value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
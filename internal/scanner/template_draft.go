@@ -0,0 +1,292 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+const (
+	// defaultDraftTimeout is the LLM request timeout when none is configured
+	defaultDraftTimeout = 120 * time.Second
+	// maxDraftAttempts bounds the drafting rounds: a failed nuclei -validate is sent back
+	// to the model once to repair the template
+	maxDraftAttempts = 2
+)
+
+// draftSystemPrompt instructs the model to answer with a single nuclei template
+const draftSystemPrompt = `You write nuclei templates (nuclei v3 YAML format) for vulnerability detection.
+Answer with exactly one YAML template in a yaml code block and nothing else.
+Rules:
+- Use the "http:" key for HTTP requests (not the deprecated "requests:").
+- Include info with name, author "wepoc-ai", severity, description, reference and tags; add classification cve-id when the advisory names a CVE.
+- Detect the vulnerability without modifying or destroying data on the target; prefer version or behaviour fingerprints over exploitation.
+- Use at least two matchers combined with matchers-condition: and, so that generic pages don't match.
+- Use {{BaseURL}} for the target; don't invent credentials, hosts or tokens.`
+
+// yamlBlockPattern extracts the content of a fenced code block from a model answer
+var yamlBlockPattern = regexp.MustCompile("(?s)```(?:ya?ml)?[ \\t]*\\r?\\n(.*?)```")
+
+// TemplateDraft is a template written by the LLM, stored outside the POC directory until
+// the user reviews and accepts it
+type TemplateDraft struct {
+	Path            string    `json:"path"` // 草稿文件（~/.wepoc/drafts），不在POC目录中
+	Content         string    `json:"content"`
+	TemplateID      string    `json:"template_id"`
+	Name            string    `json:"name"`
+	Severity        string    `json:"severity"`
+	Valid           bool      `json:"valid"`                      // nuclei -validate 是否通过
+	ValidationError string    `json:"validation_error,omitempty"` // 验证失败的输出
+	Model           string    `json:"model,omitempty"`
+	Attempts        int       `json:"attempts,omitempty"` // 生成轮数（验证失败时会让模型修正一次）
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// chatMessage is a message of an OpenAI-compatible chat completion
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// TemplateDrafter drafts templates from advisory texts with an OpenAI-compatible LLM
+type TemplateDrafter struct {
+	config     models.AIConfig
+	parser     *TemplateParser
+	nucleiPath string
+	draftsDir  string
+}
+
+// NewTemplateDrafter creates a drafter storing drafts in draftsDir
+func NewTemplateDrafter(config models.AIConfig, parser *TemplateParser, nucleiPath, draftsDir string) *TemplateDrafter {
+	return &TemplateDrafter{
+		config:     config,
+		parser:     parser,
+		nucleiPath: nucleiPath,
+		draftsDir:  draftsDir,
+	}
+}
+
+// Draft asks the model for a template detecting the vulnerability described by advisory
+// (a CVE description or advisory text), validates it with nuclei and stores it as a draft
+func (d *TemplateDrafter) Draft(advisory string) (*TemplateDraft, error) {
+	if !d.config.Enabled || strings.TrimSpace(d.config.Endpoint) == "" {
+		return nil, fmt.Errorf("未配置AI模型接口，请在设置中启用并填写接口地址")
+	}
+	if err := requireOnline(); err != nil {
+		return nil, err
+	}
+	advisory = strings.TrimSpace(advisory)
+	if advisory == "" {
+		return nil, fmt.Errorf("漏洞描述不能为空")
+	}
+	if err := os.MkdirAll(d.draftsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create drafts directory: %w", err)
+	}
+
+	messages := []chatMessage{
+		{Role: "system", Content: draftSystemPrompt},
+		{Role: "user", Content: "Write a nuclei template for this vulnerability:\n\n" + advisory},
+	}
+	path := filepath.Join(d.draftsDir, fmt.Sprintf("draft_%s.yaml", time.Now().Format("20060102_150405")))
+
+	var draft *TemplateDraft
+	for attempt := 1; attempt <= maxDraftAttempts; attempt++ {
+		answer, err := d.complete(messages)
+		if err != nil {
+			return nil, err
+		}
+		content := extractYAML(answer)
+		if draft, err = d.writeDraft(path, content); err != nil {
+			return nil, err
+		}
+		draft.Attempts = attempt
+		if draft.Valid {
+			break
+		}
+		messages = append(messages,
+			chatMessage{Role: "assistant", Content: answer},
+			chatMessage{Role: "user", Content: "nuclei -validate rejected the template:\n" + draft.ValidationError + "\nFix it and answer with the complete corrected template."},
+		)
+	}
+
+	if draft.Valid {
+		logInfof("🤖 AI模板草稿已生成: %s (验证通过)\n", draft.Path)
+	} else {
+		logInfof("🤖 AI模板草稿已生成: %s (验证未通过)\n", draft.Path)
+	}
+	return draft, nil
+}
+
+// SaveDraft stores edited draft content and validates it again
+func (d *TemplateDrafter) SaveDraft(path, content string) (*TemplateDraft, error) {
+	if err := d.checkDraftPath(path); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("模板内容不能为空")
+	}
+	return d.writeDraft(path, content)
+}
+
+// Accept validates a reviewed draft and moves it into the POC directory (targetDir). It is
+// the only way a draft becomes part of the scan pool.
+func (d *TemplateDrafter) Accept(path, targetDir string) (*models.Template, error) {
+	if err := d.checkDraftPath(path); err != nil {
+		return nil, err
+	}
+	template, err := d.parser.ParseTemplate(path)
+	if err != nil {
+		return nil, err
+	}
+	if template.TemplateID == "" {
+		return nil, fmt.Errorf("模板缺少id")
+	}
+	if err := d.parser.ValidateTemplate(path, d.nucleiPath); err != nil {
+		return nil, fmt.Errorf("模板验证未通过，不能加入模板库: %w", err)
+	}
+
+	targetPath := filepath.Join(targetDir, filepath.Base(template.TemplateID)+".yaml")
+	if _, err := os.Stat(targetPath); err == nil {
+		return nil, fmt.Errorf("POC目录中已存在同名模板: %s", filepath.Base(targetPath))
+	}
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create target directory: %w", err)
+	}
+	if err := d.parser.copyTemplate(path, targetPath); err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+
+	template.FilePath = targetPath
+	return template, nil
+}
+
+// Discard deletes a draft
+func (d *TemplateDrafter) Discard(path string) error {
+	if err := d.checkDraftPath(path); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete draft: %w", err)
+	}
+	return nil
+}
+
+// writeDraft writes draft content and fills in its metadata and validation result
+func (d *TemplateDrafter) writeDraft(path, content string) (*TemplateDraft, error) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write draft: %w", err)
+	}
+
+	draft := &TemplateDraft{Path: path, Content: content, Model: d.config.Model, CreatedAt: time.Now()}
+	template, err := d.parser.ParseTemplate(path)
+	if err != nil {
+		draft.ValidationError = err.Error()
+		return draft, nil
+	}
+	draft.TemplateID = template.TemplateID
+	draft.Name = template.Name
+	draft.Severity = template.Severity
+
+	if err := d.parser.ValidateTemplate(path, d.nucleiPath); err != nil {
+		draft.ValidationError = err.Error()
+	} else {
+		draft.Valid = true
+	}
+	return draft, nil
+}
+
+// checkDraftPath makes sure a path points to a draft file
+func (d *TemplateDrafter) checkDraftPath(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("无法解析草稿路径: %w", err)
+	}
+	absDir, err := filepath.Abs(d.draftsDir)
+	if err != nil {
+		return fmt.Errorf("无法解析草稿目录: %w", err)
+	}
+	if filepath.Dir(absPath) != absDir {
+		return fmt.Errorf("草稿路径必须在草稿目录内")
+	}
+	return nil
+}
+
+// complete sends a chat completion request and returns the answer
+func (d *TemplateDrafter) complete(messages []chatMessage) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model":       d.config.Model,
+		"messages":    messages,
+		"temperature": 0.2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, chatCompletionsURL(d.config.Endpoint), bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("无效的AI接口地址: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+d.config.APIKey)
+	}
+
+	timeout := defaultDraftTimeout
+	if d.config.TimeoutSeconds > 0 {
+		timeout = time.Duration(d.config.TimeoutSeconds) * time.Second
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("AI接口请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return "", fmt.Errorf("AI接口请求失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("AI接口返回错误: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(data, &completion); err != nil {
+		return "", fmt.Errorf("无法解析AI接口响应: %w", err)
+	}
+	if len(completion.Choices) == 0 || strings.TrimSpace(completion.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("AI接口未返回内容")
+	}
+	return completion.Choices[0].Message.Content, nil
+}
+
+// chatCompletionsURL accepts both an API base URL (https://host/v1) and the full endpoint
+func chatCompletionsURL(endpoint string) string {
+	endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if strings.HasSuffix(endpoint, "/chat/completions") {
+		return endpoint
+	}
+	return endpoint + "/chat/completions"
+}
+
+// extractYAML returns the template of a model answer: the first fenced code block, or the
+// whole answer when it has none
+func extractYAML(answer string) string {
+	if match := yamlBlockPattern.FindStringSubmatch(answer); match != nil {
+		return strings.TrimSpace(match[1]) + "\n"
+	}
+	return strings.TrimSpace(answer) + "\n"
+}
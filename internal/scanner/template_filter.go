@@ -0,0 +1,77 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// FilteredTemplate is a selected template that nuclei will skip, reported when the task is
+// created instead of only in the scan statistics
+type FilteredTemplate struct {
+	POC        string `json:"poc"`
+	TemplateID string `json:"template_id"`
+	Protocol   string `json:"protocol"` // 导致被过滤的请求类型
+	Reason     string `json:"reason"`
+}
+
+// selectedTemplates returns the templates of pocs by index, resolved against the given
+// template root, using the library entries and parsing templates that are not in the
+// library; unreadable templates are nil
+func (tm *JSONTaskManager) selectedTemplates(pocs []string, root string) []*models.Template {
+	paths := make([]string, 0, len(pocs))
+	for _, poc := range pocs {
+		paths = append(paths, resolveTemplatePathIn(poc, root))
+	}
+	templates, err := tm.db.GetTemplatesByPath(paths)
+	if err != nil {
+		logWarnf("⚠️ 查询模板协议失败: %v\n", err)
+		templates = map[string]*models.Template{}
+	}
+
+	parser := NewTemplateParser()
+	selected := make([]*models.Template, len(paths))
+	for i, path := range paths {
+		template, ok := templates[filepath.Clean(path)]
+		if !ok {
+			if template, err = parser.ParseTemplate(path); err != nil {
+				continue // 无法读取的模板在扫描统计中报告
+			}
+		}
+		selected[i] = template
+	}
+	return selected
+}
+
+// filteredTemplates returns the templates among pocs that nuclei will filter with the task
+// options, using the protocols stored at import and parsing templates not in the library
+func (tm *JSONTaskManager) filteredTemplates(pocs []string, options TaskOptions) []*FilteredTemplate {
+	filtered := []*FilteredTemplate{}
+	for i, template := range tm.selectedTemplates(pocs, options.TemplateRoot) {
+		if template == nil {
+			continue
+		}
+		for _, protocol := range strings.Split(template.Protocols, ",") {
+			if reason, ok := filterReason(protocol, options); ok {
+				filtered = append(filtered, &FilteredTemplate{POC: pocs[i], TemplateID: template.TemplateID, Protocol: protocol, Reason: reason})
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// checkFilteredTemplates records and prints the templates of a task nuclei will filter
+func (tm *JSONTaskManager) checkFilteredTemplates(task *TaskConfig) {
+	task.FilteredTemplates = tm.filteredTemplates(task.POCs, task.Options)
+	if len(task.FilteredTemplates) > 0 {
+		logWarnf("⚠️ 任务 %d 中有 %d 个模板会被Nuclei过滤（code/headless/file），不会实际扫描\n", task.ID, len(task.FilteredTemplates))
+	}
+}
+
+// CheckFilteredTemplates returns which of the given templates nuclei will filter with the
+// task options and why, so that the selection can be reviewed before creating a task
+func (tm *JSONTaskManager) CheckFilteredTemplates(pocs []string, options TaskOptions) []*FilteredTemplate {
+	return tm.filteredTemplates(pocs, options)
+}
@@ -0,0 +1,140 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// importedFile is the state of a source template when it was last imported
+type importedFile struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	SHA256  string    `json:"sha256"`
+	Target  string    `json:"target"` // 复制到的模板路径
+}
+
+// templateImportState remembers the source files of earlier directory imports in
+// ~/.wepoc/template_import_state.json, so that re-importing a directory only validates and
+// copies new or modified templates
+type templateImportState struct {
+	path  string
+	Files map[string]*importedFile `json:"files"` // 来源文件绝对路径 -> 导入时的状态
+}
+
+// loadTemplateImportState reads the import state; a missing or unreadable file means that
+// nothing was imported yet
+func loadTemplateImportState() *templateImportState {
+	state := &templateImportState{Files: make(map[string]*importedFile)}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return state
+	}
+	state.path = filepath.Join(homeDir, ".wepoc", "template_import_state.json")
+	data, err := os.ReadFile(state.path)
+	if err != nil {
+		return state
+	}
+	if err := json.Unmarshal(data, state); err != nil || state.Files == nil {
+		logWarnf("⚠️ 模板导入状态文件损坏，将完整重新导入: %v\n", err)
+		state.Files = make(map[string]*importedFile)
+	}
+	return state
+}
+
+// save writes the import state
+func (s *templateImportState) save() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create wepoc directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal template import state: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write template import state: %w", err)
+	}
+	return nil
+}
+
+// unchanged reports whether a source file is identical to its last import and the imported
+// copy still exists. The size and modification time are checked first; the content is only
+// hashed when they differ (e.g. after a fresh checkout of the same templates).
+func (s *templateImportState) unchanged(sourcePath string, info os.FileInfo) bool {
+	entry, ok := s.Files[sourcePath]
+	if !ok {
+		return false
+	}
+	if _, err := os.Stat(entry.Target); err != nil {
+		return false
+	}
+	if entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+		return true
+	}
+	if entry.Size != info.Size() {
+		return false
+	}
+	hash, err := fileSHA256(sourcePath)
+	if err != nil || hash != entry.SHA256 {
+		return false
+	}
+	entry.ModTime = info.ModTime()
+	return true
+}
+
+// ownsTarget reports whether a template in the target directory was copied from the given
+// source file, i.e. may be replaced by its modified version
+func (s *templateImportState) ownsTarget(sourcePath, targetPath string) bool {
+	entry, ok := s.Files[sourcePath]
+	return ok && entry.Target == targetPath
+}
+
+// record stores the state of an imported source file
+func (s *templateImportState) record(sourcePath, targetPath string) {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return
+	}
+	hash, err := fileSHA256(sourcePath)
+	if err != nil {
+		return
+	}
+	s.Files[sourcePath] = &importedFile{Size: info.Size(), ModTime: info.ModTime(), SHA256: hash, Target: targetPath}
+}
+
+// changedTemplateFiles walks a source directory and splits its templates into the files
+// that need to be imported and the number of files unchanged since their last import
+func (s *templateImportState) changedTemplateFiles(sourceDir string) ([]string, int, error) {
+	var changed []string
+	unchanged := 0
+	err := filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") {
+			return nil
+		}
+		if s.unchanged(absolutePath(path), info) {
+			unchanged++
+		} else {
+			changed = append(changed, path)
+		}
+		return nil
+	})
+	return changed, unchanged, err
+}
+
+// absolutePath returns the absolute form of a path, or the path itself when it cannot be
+// resolved
+func absolutePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
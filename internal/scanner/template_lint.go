@@ -0,0 +1,203 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Lint issue levels
+const (
+	LintError   = "error"   // 应在使用前修正（如破坏性载荷）
+	LintWarning = "warning" // 可能导致误报或影响使用
+	LintInfo    = "info"    // 元数据不完整
+)
+
+// TemplateLintIssue is a quality problem found in a template that nuclei -validate accepts
+type TemplateLintIssue struct {
+	Rule     string `json:"rule"`  // 规则标识，如 missing-description、broad-matcher
+	Level    string `json:"level"` // error/warning/info
+	Message  string `json:"message"`
+	Location string `json:"location,omitempty"` // 问题位置，如 http[0].matchers[1]
+}
+
+// TemplateLintResult is the lint result of one template
+type TemplateLintResult struct {
+	TemplateID string               `json:"template_id"`
+	FilePath   string               `json:"file_path"`
+	Issues     []*TemplateLintIssue `json:"issues"`
+}
+
+// broadMatcherTypes are matcher types that don't look at the response content
+var broadMatcherTypes = map[string]bool{"status": true, "size": true}
+
+// statusOnlyDSLPattern matches DSL matchers that only compare the status code
+var statusOnlyDSLPattern = regexp.MustCompile(`^\s*status_code\s*==\s*\d+\s*$`)
+
+// dangerousPayloadPatterns match request content that modifies or destroys data on the target
+var dangerousPayloadPatterns = []struct {
+	pattern *regexp.Regexp
+	message string
+}{
+	{regexp.MustCompile(`(?i)\brm\s+-[a-z]*[rf]`), "删除文件（rm -rf）"},
+	{regexp.MustCompile(`(?i)\b(drop|truncate)\s+(table|database|schema)\b`), "删除数据库对象（DROP/TRUNCATE）"},
+	{regexp.MustCompile(`(?i)\bdelete\s+from\s+\w+`), "删除数据库记录（DELETE FROM）"},
+	{regexp.MustCompile(`(?i)\b(shutdown|reboot|halt|poweroff)\b(\s+-[a-z]|\s+/[a-z]|\s+now|\s*;|\s*$)`), "关闭或重启目标主机"},
+	{regexp.MustCompile(`(?i)\b(mkfs(\.\w+)?|format\s+[a-z]:|dd\s+if=)`), "格式化或覆写磁盘"},
+	{regexp.MustCompile(`(?i)\bdel\s+/[fsq]|\brmdir\s+/s\b`), "删除Windows文件"},
+	{regexp.MustCompile(`:\(\)\s*\{\s*:\|:&\s*\};:`), "fork炸弹"},
+}
+
+// LintTemplate checks a template for quality problems beyond nuclei -validate: incomplete
+// metadata, matchers that only check status or size, missing max-request hints and
+// destructive payloads
+func (tp *TemplateParser) LintTemplate(filePath string) (*TemplateLintResult, error) {
+	detail, err := tp.ParseTemplateDetail(filePath)
+	if err != nil {
+		return nil, err
+	}
+	template, err := tp.ParseTemplate(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TemplateLintResult{TemplateID: template.TemplateID, FilePath: filePath, Issues: []*TemplateLintIssue{}}
+	add := func(rule, level, location, message string) {
+		result.Issues = append(result.Issues, &TemplateLintIssue{Rule: rule, Level: level, Message: message, Location: location})
+	}
+
+	// 元数据
+	info := detail.Info
+	switch strings.ToLower(info.Severity) {
+	case "":
+		add("missing-severity", LintWarning, "info.severity", "缺少严重程度")
+	case "critical", "high", "medium", "low", "info", "unknown":
+	default:
+		add("invalid-severity", LintWarning, "info.severity", fmt.Sprintf("未知的严重程度: %s", info.Severity))
+	}
+	if info.Description == "" {
+		add("missing-description", LintInfo, "info.description", "缺少漏洞描述")
+	}
+	if len(info.References) == 0 {
+		add("missing-reference", LintInfo, "info.reference", "缺少参考链接")
+	}
+
+	requests := 0
+	fuzzing := false
+	for _, request := range detail.Requests {
+		location := fmt.Sprintf("%s[%d]", request.Protocol, request.Index)
+		requests += len(request.Paths) + len(request.Raw)
+		if len(request.Payloads) > 0 {
+			fuzzing = true
+		}
+		if issue := broadMatcherIssue(request); issue != "" {
+			add("broad-matcher", LintWarning, location+".matchers", issue)
+		}
+		for _, message := range dangerousPayloads(request) {
+			add("dangerous-payload", LintError, location, message)
+		}
+	}
+
+	// 发送多个请求的模板应在 metadata.max-request 中注明请求数
+	if _, ok := info.Metadata["max-request"]; !ok && (requests > 1 || fuzzing) {
+		message := fmt.Sprintf("模板包含 %d 个请求定义，建议在 metadata 中添加 max-request", requests)
+		if fuzzing {
+			message = fmt.Sprintf("模板包含 %d 个请求定义并使用载荷，建议在 metadata 中添加 max-request", requests)
+		}
+		add("missing-max-request", LintInfo, "info.metadata.max-request", message)
+	}
+	return result, nil
+}
+
+// lintImported lints an imported template and returns the result when it has issues
+func (tp *TemplateParser) lintImported(filePath string) *TemplateLintResult {
+	result, err := tp.LintTemplate(filePath)
+	if err != nil || len(result.Issues) == 0 {
+		return nil
+	}
+	return result
+}
+
+// broadMatcherIssue describes why the matchers of a request match almost any response, or
+// returns "" when they inspect the response content
+func broadMatcherIssue(request *TemplateRequest) string {
+	if request.Protocol != "http" {
+		return ""
+	}
+	var effective []*TemplateMatcher
+	for _, matcher := range request.Matchers {
+		if !matcher.Negative && !matcher.Internal {
+			effective = append(effective, matcher)
+		}
+	}
+	if len(effective) == 0 {
+		if len(request.Extractors) == 0 {
+			return "请求没有匹配器"
+		}
+		return ""
+	}
+
+	// and 条件下只要有一个匹配器检查内容即可；or 条件下每个匹配器都必须检查内容
+	and := strings.EqualFold(request.MatchersCondition, "and")
+	broad := 0
+	for _, matcher := range effective {
+		if isBroadMatcher(matcher) {
+			broad++
+		}
+	}
+	if (and && broad == len(effective)) || (!and && broad > 0) {
+		return "匹配器只检查状态码或响应大小，容易误报（如仅匹配 status 200）"
+	}
+	return ""
+}
+
+// isBroadMatcher reports whether a matcher only checks the status code or response size
+func isBroadMatcher(matcher *TemplateMatcher) bool {
+	if broadMatcherTypes[matcher.Type] {
+		return true
+	}
+	if matcher.Type != "dsl" || len(matcher.Values) == 0 {
+		return false
+	}
+	for _, value := range matcher.Values {
+		if !statusOnlyDSLPattern.MatchString(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// dangerousPayloads returns the destructive operations found in the method, paths, raw
+// requests, body and payloads of a request
+func dangerousPayloads(request *TemplateRequest) []string {
+	var messages []string
+	if method := strings.ToUpper(request.Method); method == "DELETE" {
+		messages = append(messages, "使用 DELETE 方法，可能删除目标数据")
+	}
+	for _, raw := range request.Raw {
+		if line := strings.Fields(strings.TrimSpace(raw)); len(line) > 0 && strings.EqualFold(line[0], "DELETE") {
+			messages = append(messages, "原始请求使用 DELETE 方法，可能删除目标数据")
+			break
+		}
+	}
+
+	var content []string
+	content = append(content, request.Paths...)
+	content = append(content, request.Raw...)
+	content = append(content, request.Body)
+	for _, values := range request.Payloads {
+		content = append(content, stringList(values, false)...)
+	}
+	if request.Protocol != "http" {
+		// 其他协议的请求内容（inputs、code、args等）在完整定义中
+		content = append(content, fmt.Sprint(request.Definition))
+	}
+
+	text := strings.Join(content, "\n")
+	for _, dangerous := range dangerousPayloadPatterns {
+		if dangerous.pattern.MatchString(text) {
+			messages = append(messages, "包含破坏性载荷: "+dangerous.message)
+		}
+	}
+	return messages
+}
@@ -24,6 +24,42 @@ type TemplateInfo struct {
 	DNS         interface{}            `yaml:"dns,omitempty"`
 	SSL         interface{}            `yaml:"ssl,omitempty"`
 	Code        interface{}            `yaml:"code,omitempty"`
+	Requests    interface{}            `yaml:"requests,omitempty"`   // 旧版模板的 http
+	TCP         interface{}            `yaml:"tcp,omitempty"`        // 旧版模板的 network
+	Websocket   interface{}            `yaml:"websocket,omitempty"`
+	Whois       interface{}            `yaml:"whois,omitempty"`
+	Javascript  interface{}            `yaml:"javascript,omitempty"`
+}
+
+// protocols returns the protocols of the template's request blocks in requestProtocols
+// order; legacy keys are reported under their current name (requests → http, tcp → network)
+func (ti *TemplateInfo) protocols() []string {
+	blocks := map[string]interface{}{
+		"http": ti.HTTP, "requests": ti.Requests, "dns": ti.DNS, "network": ti.Network, "tcp": ti.TCP,
+		"ssl": ti.SSL, "websocket": ti.Websocket, "whois": ti.Whois, "javascript": ti.Javascript,
+		"code": ti.Code, "headless": ti.Headless, "file": ti.File, "workflows": ti.Workflows,
+	}
+	protocols := []string{}
+	for _, key := range requestProtocols {
+		if blocks[key] == nil {
+			continue
+		}
+		if protocol := normalizeProtocol(key); !containsString(protocols, protocol) {
+			protocols = append(protocols, protocol)
+		}
+	}
+	return protocols
+}
+
+// normalizeProtocol maps legacy request-block keys to their current protocol name
+func normalizeProtocol(key string) string {
+	switch key {
+	case "requests":
+		return "http"
+	case "tcp":
+		return "network"
+	}
+	return key
 }
 
 // TemplateParser handles parsing of Nuclei templates
@@ -53,6 +89,7 @@ func (tp *TemplateParser) ParseTemplate(filePath string) (*models.Template, erro
 	template := &models.Template{
 		TemplateID: templateInfo.ID,
 		FilePath:   filePath,
+		Protocols:  strings.Join(templateInfo.protocols(), ","),
 	}
 
 	// Extract info fields
@@ -63,6 +100,9 @@ func (tp *TemplateParser) ParseTemplate(filePath string) (*models.Template, erro
 		if severity, ok := templateInfo.Info["severity"].(string); ok {
 			template.Severity = severity
 		}
+		if description, ok := templateInfo.Info["description"].(string); ok {
+			template.Description = strings.TrimSpace(description)
+		}
 		
 		// Handle tags - can be string or array
 		if tags, ok := templateInfo.Info["tags"]; ok {
@@ -127,6 +167,7 @@ func (tp *TemplateParser) ScanDirectory(dirPath string) ([]*models.Template, []e
 			errors = append(errors, fmt.Errorf("failed to parse %s: %w", path, err))
 			return nil // Continue walking
 		}
+		template.Category = templateCategory(dirPath, path)
 
 		templates = append(templates, template)
 		return nil
@@ -139,10 +180,57 @@ func (tp *TemplateParser) ScanDirectory(dirPath string) ([]*models.Template, []e
 	return templates, errors
 }
 
+// parseTemplateFiles parses the given template files of a directory, like ScanDirectory
+func (tp *TemplateParser) parseTemplateFiles(dirPath string, paths []string) ([]*models.Template, []error) {
+	var templates []*models.Template
+	var errors []error
+	for _, path := range paths {
+		template, err := tp.ParseTemplate(path)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("failed to parse %s: %w", path, err))
+			continue
+		}
+		template.Category = templateCategory(dirPath, path)
+		templates = append(templates, template)
+	}
+	return templates, errors
+}
+
+// templateCategory derives a category from the directory of a template below the import
+// root, e.g. http/cves/2021/CVE-2021-1.yaml -> http/cves (year directories are dropped)
+func templateCategory(root, path string) string {
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for len(parts) > 0 && isNumeric(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, "/")
+}
+
+// isNumeric reports whether s consists of digits only
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // ValidateTemplate validates a template file using nuclei -validate
 func (tp *TemplateParser) ValidateTemplate(templatePath string, nucleiPath string) error {
-	// Use nuclei -validate command to validate the template
-	cmd := exec.Command(nucleiPath, "-validate", "-t", templatePath)
+	// Use nuclei -validate command to validate the template (workflows are loaded with -w)
+	flag := "-t"
+	if template, err := tp.ParseTemplate(templatePath); err == nil && isWorkflow(template) {
+		flag = "-w"
+	}
+	cmd := exec.Command(nucleiPath, append([]string{"-validate", flag, templatePath}, updateCheckArgs()...)...)
 	output, err := cmd.CombinedOutput()
 	
 	if err != nil {
@@ -182,11 +270,12 @@ func (tp *TemplateParser) PreValidateTemplates(sourceDir, nucleiPath string) (*I
 	validationResult, err := tp.validateTemplatesBatch(sourceDir, nucleiPath)
 	if err != nil {
 		result.Errors = append(result.Errors, fmt.Sprintf("Batch validation failed: %v", err))
-		// Fall back to individual validation
+		// Fall back to validating in parallel chunks
+		invalid := tp.validateTemplatesParallel(templates, nucleiPath, nil)
 		for _, template := range templates {
-			if err := tp.ValidateTemplate(template.FilePath, nucleiPath); err != nil {
+			if message, failed := invalid[template.FilePath]; failed {
 				result.Failed++
-				result.Errors = append(result.Errors, fmt.Sprintf("Validation failed for %s: %v", template.TemplateID, err))
+				result.Errors = append(result.Errors, fmt.Sprintf("Validation failed for %s: %s", template.TemplateID, message))
 			} else {
 				result.Validated++
 				result.ValidTemplates = append(result.ValidTemplates, template)
@@ -210,7 +299,9 @@ func (tp *TemplateParser) PreValidateTemplates(sourceDir, nucleiPath string) (*I
 	return result, nil
 }
 
-// validateTemplatesBatch validates all templates in a directory using nuclei
+// validateTemplatesBatch validates all templates in a directory using nuclei. The error lines
+// of the output are mapped to the template files they name; when nuclei fails without naming
+// any file (e.g. it cannot run) an error is returned so callers validate one by one instead.
 func (tp *TemplateParser) validateTemplatesBatch(sourceDir, nucleiPath string) (*ImportResult, error) {
 	result := &ImportResult{
 		TotalFound: 0,
@@ -220,7 +311,7 @@ func (tp *TemplateParser) validateTemplatesBatch(sourceDir, nucleiPath string) (
 	}
 
 	// Use nuclei to validate the entire directory
-	cmd := exec.Command(nucleiPath, "-validate", "-t", sourceDir)
+	cmd := exec.Command(nucleiPath, append([]string{"-validate", "-t", sourceDir}, updateCheckArgs()...)...)
 	output, err := cmd.CombinedOutput()
 	
 	outputStr := string(output)
@@ -228,36 +319,96 @@ func (tp *TemplateParser) validateTemplatesBatch(sourceDir, nucleiPath string) (
 	// Count total templates found
 	templates, _ := tp.ScanDirectory(sourceDir)
 	result.TotalFound = len(templates)
-	
+
+	// 将错误行对应到其中提到的模板文件
+	files := make([]string, 0, len(templates))
+	for _, template := range templates {
+		files = append(files, template.FilePath)
+	}
+	lines, invalid := mapValidationErrors(outputStr, files)
+	result.Errors = append(result.Errors, lines...)
+	result.invalid = invalid
+	if err != nil && len(result.invalid) == 0 {
+		return result, fmt.Errorf("nuclei validation failed: %s", strings.TrimSpace(outputStr))
+	}
+
+	result.Failed = len(result.invalid)
+	result.Validated = result.TotalFound - result.Failed
+	return result, nil
+}
+
+// mentionsFile reports whether a line of nuclei output names a file, in the form it was
+// passed or as an absolute path
+func mentionsFile(line, filePath string) bool {
+	for _, form := range []string{filePath, absolutePath(filePath)} {
+		if strings.Contains(line, form) || strings.Contains(line, filepath.ToSlash(form)) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTemplateFiles validates a subset of templates in one nuclei run by staging copies
+// of them in a temporary directory
+func (tp *TemplateParser) validateTemplateFiles(templates []*models.Template, nucleiPath string) (*ImportResult, error) {
+	stageDir, err := os.MkdirTemp("", "wepoc-validate-*")
 	if err != nil {
-		// Parse error output to count failed templates
-		lines := strings.Split(outputStr, "\n")
-		errorCount := 0
-		for _, line := range lines {
-			if strings.Contains(line, "[ERR]") {
-				errorCount++
-				result.Errors = append(result.Errors, strings.TrimSpace(line))
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	sources := make(map[string]string, len(templates)) // 临时副本 -> 原模板
+	for i, template := range templates {
+		// 每个模板放在单独的子目录中，避免同名文件互相覆盖
+		dir := filepath.Join(stageDir, fmt.Sprintf("%d", i))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		staged := filepath.Join(dir, filepath.Base(template.FilePath))
+		if err := tp.copyTemplate(template.FilePath, staged); err != nil {
+			return nil, err
+		}
+		sources[staged] = template.FilePath
+	}
+
+	result, err := tp.validateTemplatesBatch(stageDir, nucleiPath)
+	if result != nil {
+		invalid := make(map[string]string, len(result.invalid))
+		for staged, line := range result.invalid {
+			invalid[sources[staged]] = strings.ReplaceAll(line, staged, sources[staged])
+		}
+		result.invalid = invalid
+		for i, line := range result.Errors {
+			for staged, source := range sources {
+				line = strings.ReplaceAll(line, staged, source)
 			}
+			result.Errors[i] = line
 		}
-		result.Failed = errorCount
-		result.Validated = result.TotalFound - result.Failed
-	} else {
-		// All templates validated successfully
-		result.Validated = result.TotalFound
-		result.Failed = 0
 	}
+	return result, err
+}
 
-	return result, nil
+// importTarget returns the path a template is copied to and whether it replaces an earlier
+// import of the same source file; ok is false when another template with the same file name
+// already exists there
+func (tp *TemplateParser) importTarget(state *templateImportState, template *models.Template, targetDir string) (targetPath string, replace bool, ok bool) {
+	targetPath = filepath.Join(targetDir, filepath.Base(template.FilePath))
+	if _, err := os.Stat(targetPath); err != nil {
+		return targetPath, false, true
+	}
+	if state.ownsTarget(absolutePath(template.FilePath), targetPath) {
+		return targetPath, true, true
+	}
+	return "", false, false
 }
 
-// isTemplateValid checks if a template was validated successfully
+// isTemplateValid checks if a template was validated successfully: it has an ID and a name
+// and nuclei reported no error for its file
 func (tp *TemplateParser) isTemplateValid(template *models.Template, validationResult *ImportResult) bool {
-	// Simple heuristic: if we have fewer failed templates than total, 
-	// and this template has a valid ID, consider it valid
-	if template.TemplateID != "" && template.Name != "" {
-		return true
+	if template.TemplateID == "" || template.Name == "" {
+		return false
 	}
-	return false
+	return validationError(validationResult, template.FilePath) == ""
 }
 
 // ImportTemplatesWithValidation imports templates with validation and incremental copy
@@ -265,7 +416,9 @@ func (tp *TemplateParser) ImportTemplatesWithValidation(sourceDir, targetDir, nu
 	return tp.ImportTemplatesWithValidationAndProgress(sourceDir, targetDir, nucleiPath, nil)
 }
 
-// ImportTemplatesWithValidationAndProgress imports templates with validation, incremental copy and progress updates
+// ImportTemplatesWithValidationAndProgress imports templates with validation, incremental copy and progress updates.
+// Templates unchanged since the last import of the same source files are skipped without
+// validation; modified ones replace their earlier copy.
 func (tp *TemplateParser) ImportTemplatesWithValidationAndProgress(sourceDir, targetDir, nucleiPath string, progressCallback func(current, total int, status string, stats ...map[string]int)) (*ImportResult, error) {
 	result := &ImportResult{
 		TotalFound:    0,
@@ -273,6 +426,7 @@ func (tp *TemplateParser) ImportTemplatesWithValidationAndProgress(sourceDir, ta
 		Failed:        0,
 		AlreadyExists: 0,
 		Errors:        []string{},
+		LintIssues:    []*TemplateLintResult{},
 	}
 
 	// Ensure target directory exists
@@ -280,9 +434,15 @@ func (tp *TemplateParser) ImportTemplatesWithValidationAndProgress(sourceDir, ta
 		return nil, fmt.Errorf("failed to create target directory: %w", err)
 	}
 
-	// Scan source directory for templates
-	templates, errors := tp.ScanDirectory(sourceDir)
-	result.TotalFound = len(templates)
+	// 只处理上次导入后新增或修改的模板
+	state := loadTemplateImportState()
+	changedFiles, unchanged, err := state.changedTemplateFiles(sourceDir)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to walk directory: %v", err))
+	}
+	templates, errors := tp.parseTemplateFiles(sourceDir, changedFiles)
+	result.Unchanged = unchanged
+	result.TotalFound = len(templates) + unchanged
 	
 	// Add scan errors to result
 	for _, err := range errors {
@@ -291,42 +451,53 @@ func (tp *TemplateParser) ImportTemplatesWithValidationAndProgress(sourceDir, ta
 
 	// Send initial progress
 	if progressCallback != nil {
-		progressCallback(0, result.TotalFound, "开始批量验证模板...")
+		progressCallback(unchanged, result.TotalFound, fmt.Sprintf("%d 个模板未变化，开始批量验证 %d 个新增或修改的模板...", unchanged, len(templates)))
 	}
 
-	// 使用批量验证提高速度
-	validationResult, err := tp.validateTemplatesBatch(sourceDir, nucleiPath)
+	// 使用批量验证提高速度；增量导入时只验证变化的模板
+	var validationResult *ImportResult
+	switch {
+	case len(templates) == 0:
+		validationResult = &ImportResult{Errors: []string{}}
+	case unchanged == 0:
+		validationResult, err = tp.validateTemplatesBatch(sourceDir, nucleiPath)
+	default:
+		validationResult, err = tp.validateTemplateFiles(templates, nucleiPath)
+	}
 	if err != nil {
-		// 如果批量验证失败，回退到单个验证
+		// 如果批量验证失败，回退到并行分批验证
 		if progressCallback != nil {
-			progressCallback(0, result.TotalFound, "批量验证失败，使用单个验证...")
+			progressCallback(unchanged, result.TotalFound, "批量验证失败，使用并行分批验证...")
 		}
-		
-		// Process each template individually
-		for i, template := range templates {
-			// Update progress with real-time stats
+
+		// 已存在的模板不再验证
+		var pending []*models.Template
+		for _, template := range templates {
+			if _, _, ok := tp.importTarget(state, template, targetDir); !ok {
+				result.AlreadyExists++
+				continue
+			}
+			pending = append(pending, template)
+		}
+		skipped := unchanged + result.AlreadyExists
+		invalid := tp.validateTemplatesParallel(pending, nucleiPath, func(done, failed, total int) {
 			if progressCallback != nil {
 				stats := map[string]int{
-					"successful": result.Validated,
-					"errors":     result.Failed,
+					"successful": done - failed,
+					"errors":     failed,
 					"duplicates": result.AlreadyExists,
 				}
-				progressCallback(i, result.TotalFound, fmt.Sprintf("验证模板 %d/%d: %s", i+1, result.TotalFound, template.TemplateID), stats)
-			}
-
-			// Check if template already exists in target directory
-			targetPath := filepath.Join(targetDir, filepath.Base(template.FilePath))
-			if _, err := os.Stat(targetPath); err == nil {
-				result.AlreadyExists++
-				continue
+				progressCallback(skipped+done, result.TotalFound, fmt.Sprintf("验证模板 %d/%d", done, total), stats)
 			}
+		})
 
-			// Validate template using nuclei
-			if err := tp.ValidateTemplate(template.FilePath, nucleiPath); err != nil {
+		for _, template := range pending {
+			if message, failed := invalid[template.FilePath]; failed {
 				result.Failed++
-				result.Errors = append(result.Errors, fmt.Sprintf("Validation failed for %s: %v", template.TemplateID, err))
+				result.Errors = append(result.Errors, fmt.Sprintf("Validation failed for %s: %s", template.TemplateID, message))
 				continue
 			}
+			targetPath, replace, _ := tp.importTarget(state, template, targetDir)
 
 			// Copy validated template to target directory
 			if err := tp.copyTemplate(template.FilePath, targetPath); err != nil {
@@ -334,10 +505,17 @@ func (tp *TemplateParser) ImportTemplatesWithValidationAndProgress(sourceDir, ta
 				result.Errors = append(result.Errors, fmt.Sprintf("Failed to copy %s: %v", template.TemplateID, err))
 				continue
 			}
+			state.record(absolutePath(template.FilePath), targetPath)
+			if replace {
+				result.Updated++
+			}
 
 			// Update template file path to target location
 			template.FilePath = targetPath
 			result.Validated++
+			if lint := tp.lintImported(targetPath); lint != nil {
+				result.LintIssues = append(result.LintIssues, lint)
+			}
 		}
 	} else {
 		// 使用批量验证结果
@@ -356,12 +534,12 @@ func (tp *TemplateParser) ImportTemplatesWithValidationAndProgress(sourceDir, ta
 					"errors":     result.Failed,
 					"duplicates": result.AlreadyExists,
 				}
-				progressCallback(i, result.TotalFound, fmt.Sprintf("复制模板 %d/%d: %s", i+1, result.TotalFound, template.TemplateID), stats)
+				progressCallback(unchanged+i, result.TotalFound, fmt.Sprintf("复制模板 %d/%d: %s", unchanged+i+1, result.TotalFound, template.TemplateID), stats)
 			}
 
 			// Check if template already exists in target directory
-			targetPath := filepath.Join(targetDir, filepath.Base(template.FilePath))
-			if _, err := os.Stat(targetPath); err == nil {
+			targetPath, replace, ok := tp.importTarget(state, template, targetDir)
+			if !ok {
 				result.AlreadyExists++
 				continue
 			}
@@ -374,15 +552,26 @@ func (tp *TemplateParser) ImportTemplatesWithValidationAndProgress(sourceDir, ta
 					result.Errors = append(result.Errors, fmt.Sprintf("Failed to copy %s: %v", template.TemplateID, err))
 					continue
 				}
+				state.record(absolutePath(template.FilePath), targetPath)
+				if replace {
+					result.Updated++
+				}
 
 				// Update template file path to target location
 				template.FilePath = targetPath
 				validTemplates = append(validTemplates, template)
+				if lint := tp.lintImported(targetPath); lint != nil {
+					result.LintIssues = append(result.LintIssues, lint)
+				}
 			}
 		}
 		result.Validated = len(validTemplates)
 	}
 
+	if err := state.save(); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
 	// Send final progress with final stats
 	if progressCallback != nil {
 		finalStats := map[string]int{
@@ -485,6 +674,12 @@ type ImportResult struct {
 	Validated      int                 `json:"validated"`
 	Failed         int                 `json:"failed"`
 	AlreadyExists  int                 `json:"already_exists"`
+	Unchanged      int                 `json:"unchanged"` // 自上次导入后未变化而跳过的模板
+	Updated        int                 `json:"updated"`   // 替换了上次导入副本的修改过的模板
 	Errors         []string            `json:"errors"`
 	ValidTemplates []*models.Template  `json:"valid_templates,omitempty"`
+	LintIssues     []*TemplateLintResult `json:"lint_issues"` // 导入模板的质量问题（仅列出有问题的模板）
+	Manifest       *ManifestVerification `json:"manifest,omitempty"` // 来源目录签名清单的验证结果
+
+	invalid map[string]string // 批量验证失败的模板文件 -> nuclei的错误行
 }
@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// maxTemplateFileNameLength bounds file names derived from template IDs
+const maxTemplateFileNameLength = 100
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// safeTemplateFileName derives a file name from a template ID that is valid on every
+// platform and cannot escape the target directory
+func safeTemplateFileName(templateID string) string {
+	name := unsafeFileNameChars.ReplaceAllString(templateID, "-")
+	name = strings.Trim(name, ".-")
+	if len(name) > maxTemplateFileNameLength {
+		name = strings.Trim(name[:maxTemplateFileNameLength], ".-")
+	}
+	if name == "" {
+		name = "template"
+	}
+	return name + ".yaml"
+}
+
+// CreateTemplateFromContent adds a template given as raw YAML (e.g. pasted from the clipboard)
+// to targetDir. The content must parse, carry an id and pass nuclei -validate; the file is
+// named after the id. Pasted templates are unverified.
+func (tp *TemplateParser) CreateTemplateFromContent(content, targetDir, nucleiPath string) (*models.Template, error) {
+	content = strings.TrimSpace(strings.TrimPrefix(content, "\ufeff"))
+	if content == "" {
+		return nil, fmt.Errorf("模板内容不能为空")
+	}
+
+	// 先写入临时文件验证，通过后再放入POC目录
+	staged, err := os.CreateTemp("", "wepoc-paste-*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(staged.Name())
+	_, err = staged.WriteString(content + "\n")
+	if closeErr := staged.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	template, err := tp.ParseTemplate(staged.Name())
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(template.TemplateID) == "" {
+		return nil, fmt.Errorf("模板缺少id")
+	}
+	if err := tp.ValidateTemplate(staged.Name(), nucleiPath); err != nil {
+		return nil, fmt.Errorf("模板验证未通过，不能加入模板库: %w", err)
+	}
+
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create target directory: %w", err)
+	}
+	targetPath := filepath.Join(targetDir, safeTemplateFileName(template.TemplateID))
+	// O_EXCL：不覆盖已有的同名模板
+	file, err := os.OpenFile(targetPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if os.IsExist(err) {
+		return nil, fmt.Errorf("POC目录中已存在同名模板: %s", filepath.Base(targetPath))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template file: %w", err)
+	}
+	_, err = file.WriteString(content + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(targetPath)
+		return nil, fmt.Errorf("failed to write template file: %w", err)
+	}
+
+	template.FilePath = targetPath
+	template.Trust = models.TrustUnverified
+	return template, nil
+}
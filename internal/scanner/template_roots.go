@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"wepoc/internal/models"
+)
+
+// DefaultTemplateRoot names the POC directory as a template root
+const DefaultTemplateRoot = "default"
+
+var templateRootNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{2,32}$`)
+
+// templateRoots mirrors the POC directory and Config.TemplateRoots for the code paths that
+// resolve template paths without access to the config (snapshots, dry runs, temp copies...)
+var templateRoots = struct {
+	mu         sync.RWMutex
+	defaultDir string
+	dirs       map[string]string // 名称（小写） -> 目录
+}{dirs: map[string]string{}}
+
+// SetTemplateRoots registers the POC directory and the additional template roots of a config
+func SetTemplateRoots(config *models.Config) {
+	dirs := make(map[string]string, len(config.TemplateRoots))
+	for _, root := range config.TemplateRoots {
+		if root.Name != "" && root.Path != "" {
+			dirs[strings.ToLower(root.Name)] = filepath.Clean(root.Path)
+		}
+	}
+	templateRoots.mu.Lock()
+	defer templateRoots.mu.Unlock()
+	templateRoots.defaultDir = config.POCDirectory
+	templateRoots.dirs = dirs
+}
+
+// ValidateTemplateRoots checks the template roots of a config before it is saved
+func ValidateTemplateRoots(roots []models.TemplateRoot) error {
+	seen := make(map[string]bool, len(roots))
+	for _, root := range roots {
+		name := strings.ToLower(root.Name)
+		if !templateRootNamePattern.MatchString(root.Name) || name == DefaultTemplateRoot {
+			return fmt.Errorf("无效的模板根目录名称: %s（2-32个字母、数字、_或-，不能为%s）", root.Name, DefaultTemplateRoot)
+		}
+		if seen[name] {
+			return fmt.Errorf("模板根目录名称重复: %s", root.Name)
+		}
+		seen[name] = true
+		if !filepath.IsAbs(root.Path) {
+			return fmt.Errorf("模板根目录 %s 必须是绝对路径: %s", root.Name, root.Path)
+		}
+		if info, err := os.Stat(root.Path); err != nil || !info.IsDir() {
+			return fmt.Errorf("模板根目录 %s 不存在: %s", root.Name, root.Path)
+		}
+	}
+	return nil
+}
+
+// defaultTemplatesDir returns the POC directory, ~/.wepoc/nuclei-templates unless configured
+func defaultTemplatesDir() string {
+	templateRoots.mu.RLock()
+	dir := templateRoots.defaultDir
+	templateRoots.mu.RUnlock()
+	if dir != "" {
+		return dir
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".wepoc", "nuclei-templates")
+}
+
+// templateRootDir returns the directory of a template root; an empty name is the POC directory
+func templateRootDir(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == DefaultTemplateRoot {
+		return defaultTemplatesDir(), true
+	}
+	templateRoots.mu.RLock()
+	defer templateRoots.mu.RUnlock()
+	dir, ok := templateRoots.dirs[name]
+	return dir, ok
+}
+
+// resolveTemplatePathIn resolves a task's template reference: an absolute path, a
+// "root:relative/path" reference, or a path or template ID relative to the given root (the
+// POC directory when empty or unknown)
+func resolveTemplatePathIn(poc, root string) string {
+	if filepath.IsAbs(poc) {
+		return poc
+	}
+	if name, rel, found := strings.Cut(poc, ":"); found {
+		if dir, ok := templateRootDir(name); ok {
+			return templateFileIn(dir, rel)
+		}
+	}
+	dir, ok := templateRootDir(root)
+	if !ok {
+		dir = defaultTemplatesDir()
+	}
+	return templateFileIn(dir, poc)
+}
+
+// templateFileIn returns the file of a relative template path or template ID below dir
+func templateFileIn(dir, poc string) string {
+	if strings.HasSuffix(poc, ".yaml") || strings.HasSuffix(poc, ".yml") {
+		return filepath.Join(dir, filepath.FromSlash(poc))
+	}
+	return filepath.Join(dir, filepath.FromSlash(poc)+".yaml")
+}
+
+// templateRootOf returns the name and directory of the template root containing a file,
+// preferring the most specific root; ok is false for files outside every root
+func templateRootOf(path string) (name, dir string, ok bool) {
+	candidates := map[string]string{DefaultTemplateRoot: defaultTemplatesDir()}
+	templateRoots.mu.RLock()
+	for rootName, rootDir := range templateRoots.dirs {
+		candidates[rootName] = rootDir
+	}
+	templateRoots.mu.RUnlock()
+
+	for rootName, rootDir := range candidates {
+		rel, err := filepath.Rel(rootDir, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") || filepath.IsAbs(rel) {
+			continue
+		}
+		if !ok || len(rootDir) > len(dir) {
+			name, dir, ok = rootName, rootDir, true
+		}
+	}
+	return name, dir, ok
+}
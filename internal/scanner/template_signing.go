@@ -0,0 +1,267 @@
+package scanner
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// TemplateManifestFile is the signed manifest at the root of a template directory
+const TemplateManifestFile = "wepoc-manifest.json"
+
+// TemplateManifest lists the SHA-256 of every template of a directory, signed with the
+// signer's Ed25519 key
+type TemplateManifest struct {
+	Signer    string            `json:"signer"`
+	Files     map[string]string `json:"files"`     // 相对路径（/分隔） -> SHA-256
+	Signature string            `json:"signature"` // Base64 Ed25519 签名
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// SigningKeyPair is a generated Ed25519 key pair, base64 encoded
+type SigningKeyPair struct {
+	PublicKey  string `json:"public_key"`  // 添加到设置中的受信任签名者
+	PrivateKey string `json:"private_key"` // 用于签名模板目录，由用户自行保管
+}
+
+// ManifestVerification is the result of verifying the manifest of a template directory
+type ManifestVerification struct {
+	Found      bool              `json:"found"` // 目录中存在清单
+	Signer     string            `json:"signer,omitempty"`
+	Level      string            `json:"level"`    // 签名有效时为签名者的信任级别，否则为 unverified
+	Verified   bool              `json:"verified"` // 签名有效且来自受信任的签名者
+	Error      string            `json:"error,omitempty"`
+	Mismatched []string          `json:"mismatched"` // 内容与清单哈希不一致或不在清单中的模板
+	trust      map[string]string // 模板绝对路径 -> 信任级别
+}
+
+// TrustOf returns the trust level of a template of the verified directory
+func (v *ManifestVerification) TrustOf(path string) string {
+	if v == nil {
+		return models.TrustUnverified
+	}
+	if level, ok := v.trust[filepath.Clean(path)]; ok {
+		return level
+	}
+	return models.TrustUnverified
+}
+
+// manifestPayload is the signed content: the signer and every file hash in path order
+func manifestPayload(manifest *TemplateManifest) []byte {
+	paths := make([]string, 0, len(manifest.Files))
+	for path := range manifest.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var payload strings.Builder
+	payload.WriteString("wepoc-manifest-v1\n")
+	payload.WriteString(manifest.Signer + "\n")
+	for _, path := range paths {
+		fmt.Fprintf(&payload, "%s  %s\n", manifest.Files[path], path)
+	}
+	return []byte(payload.String())
+}
+
+// templateHashes returns the SHA-256 of every YAML file below dir, keyed by relative path
+func templateHashes(dir string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		hashes[filepath.ToSlash(rel)] = contentHash(data)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash templates: %w", err)
+	}
+	return hashes, nil
+}
+
+// GenerateSigningKey creates an Ed25519 key pair for signing template directories
+func GenerateSigningKey() (*SigningKeyPair, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	return &SigningKeyPair{
+		PublicKey:  base64.StdEncoding.EncodeToString(publicKey),
+		PrivateKey: base64.StdEncoding.EncodeToString(privateKey),
+	}, nil
+}
+
+// SignTemplateDirectory hashes the templates of dir and writes a manifest signed with the
+// base64 Ed25519 private key
+func SignTemplateDirectory(dir, signer, privateKey string) (*TemplateManifest, error) {
+	signer = strings.TrimSpace(signer)
+	if signer == "" {
+		return nil, fmt.Errorf("签名者名称不能为空")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(privateKey))
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("无效的签名私钥")
+	}
+
+	hashes, err := templateHashes(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("目录中没有模板文件")
+	}
+
+	manifest := &TemplateManifest{Signer: signer, Files: hashes, CreatedAt: time.Now()}
+	manifest.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(ed25519.PrivateKey(key), manifestPayload(manifest)))
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, TemplateManifestFile), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	logInfof("🔏 模板目录已签名: %s (%d 个模板, 签名者 %s)\n", dir, len(hashes), signer)
+	return manifest, nil
+}
+
+// VerifyTemplateManifest checks the manifest of a template directory against the trusted
+// signers. Templates get the signer's trust level when the signature is valid and their
+// content matches the manifest; everything else is unverified.
+func VerifyTemplateManifest(dir string, signers []models.TrustedSigner) *ManifestVerification {
+	verification := &ManifestVerification{Level: models.TrustUnverified, Mismatched: []string{}, trust: map[string]string{}}
+
+	data, err := os.ReadFile(filepath.Join(dir, TemplateManifestFile))
+	if err != nil {
+		return verification
+	}
+	verification.Found = true
+
+	var manifest TemplateManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		verification.Error = fmt.Sprintf("无法解析模板清单: %v", err)
+		return verification
+	}
+	verification.Signer = manifest.Signer
+
+	var trusted *models.TrustedSigner
+	for i := range signers {
+		if signers[i].Name == manifest.Signer {
+			trusted = &signers[i]
+			break
+		}
+	}
+	if trusted == nil {
+		verification.Error = fmt.Sprintf("签名者不在受信任列表中: %s", manifest.Signer)
+		return verification
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(trusted.PublicKey))
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		verification.Error = fmt.Sprintf("签名者 %s 的公钥无效", trusted.Name)
+		return verification
+	}
+	signature, err := base64.StdEncoding.DecodeString(manifest.Signature)
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(publicKey), manifestPayload(&manifest), signature) {
+		verification.Error = "模板清单签名无效"
+		return verification
+	}
+
+	level := trusted.Level
+	if level != models.TrustOfficial && level != models.TrustInternal {
+		level = models.TrustInternal
+	}
+	verification.Verified = true
+	verification.Level = level
+
+	hashes, err := templateHashes(dir)
+	if err != nil {
+		verification.Error = err.Error()
+		return verification
+	}
+	for path, hash := range hashes {
+		if manifest.Files[path] != hash {
+			verification.Mismatched = append(verification.Mismatched, path)
+			continue
+		}
+		verification.trust[filepath.Join(dir, filepath.FromSlash(path))] = level
+	}
+	sort.Strings(verification.Mismatched)
+	return verification
+}
+
+// checkTemplateTrust refuses tasks with unverified templates when the configuration blocks
+// them for the task's rate profile
+func (tm *JSONTaskManager) checkTemplateTrust(task *TaskConfig) error {
+	if tm.config == nil || !tm.config.TemplateTrust.BlockUnverified {
+		return nil
+	}
+	policy := tm.config.TemplateTrust
+	config, err := tm.configForTask(task)
+	if err != nil {
+		return err
+	}
+	profile := EffectiveRateProfile(task.Options, config).Name
+	if len(policy.ProductionProfiles) > 0 {
+		production := false
+		for _, name := range policy.ProductionProfiles {
+			if strings.EqualFold(strings.TrimSpace(name), profile) {
+				production = true
+				break
+			}
+		}
+		if !production {
+			return nil
+		}
+	}
+
+	paths := make([]string, 0, len(task.POCs))
+	for _, poc := range task.POCs {
+		paths = append(paths, task.templatePath(poc))
+	}
+	templates, err := tm.db.GetTemplatesByPath(paths)
+	if err != nil {
+		return err
+	}
+	var unverified []string
+	for i, path := range paths {
+		if template, ok := templates[filepath.Clean(path)]; !ok || template.Trust == models.TrustUnverified {
+			unverified = append(unverified, task.POCs[i])
+		}
+	}
+	if len(unverified) == 0 {
+		return nil
+	}
+	if len(unverified) > 5 {
+		return fmt.Errorf("任务包含 %d 个未验证的模板（%s 等），当前配置禁止在 %s 速率配置下运行未验证模板",
+			len(unverified), strings.Join(unverified[:5], ", "), profile)
+	}
+	return fmt.Errorf("任务包含未验证的模板（%s），当前配置禁止在 %s 速率配置下运行未验证模板",
+		strings.Join(unverified, ", "), profile)
+}
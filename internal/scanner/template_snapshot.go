@@ -0,0 +1,140 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"wepoc/internal/database"
+)
+
+// TemplateSnapshotItem is a template recorded when a task started, compared with the
+// current template file
+type TemplateSnapshotItem struct {
+	POC         string    `json:"poc"`
+	FilePath    string    `json:"file_path"`
+	Hash        string    `json:"hash"`         // 快照中的内容哈希（SHA-256）
+	CurrentHash string    `json:"current_hash"` // 当前文件的内容哈希，文件不存在时为空
+	Changed     bool      `json:"changed"`      // 模板自快照后被修改或删除
+	RecordedAt  time.Time `json:"recorded_at"`
+}
+
+// contentHash returns the hex SHA-256 of template content
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordTemplateSnapshot stores the content of every template of a task so that the scan
+// can be repeated later with identical template versions
+func (tm *JSONTaskManager) recordTemplateSnapshot(task *TaskConfig) {
+	entries := make([]*database.TemplateSnapshotEntry, 0, len(task.POCs))
+	for _, poc := range task.POCs {
+		path := task.templatePath(poc)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logWarnf("⚠️ 模板快照跳过无法读取的模板 %s: %v\n", poc, err)
+			continue
+		}
+		entries = append(entries, &database.TemplateSnapshotEntry{
+			POC:      poc,
+			FilePath: path,
+			Hash:     contentHash(data),
+			Content:  data,
+		})
+	}
+
+	if err := tm.db.SaveTemplateSnapshot(task.ID, entries); err != nil {
+		logWarnf("⚠️ 保存任务 %d 模板快照失败: %v\n", task.ID, err)
+		return
+	}
+	logInfof("📸 任务 %d 模板快照已记录: %d 个模板\n", task.ID, len(entries))
+}
+
+// GetTemplateSnapshot returns the template snapshot of a task and which templates changed since
+func (tm *JSONTaskManager) GetTemplateSnapshot(taskID int64) ([]*TemplateSnapshotItem, error) {
+	entries, err := tm.db.GetTemplateSnapshot(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*TemplateSnapshotItem, 0, len(entries))
+	for _, entry := range entries {
+		item := &TemplateSnapshotItem{
+			POC:        entry.POC,
+			FilePath:   entry.FilePath,
+			Hash:       entry.Hash,
+			RecordedAt: entry.CreatedAt,
+		}
+		if data, err := os.ReadFile(entry.FilePath); err == nil {
+			item.CurrentHash = contentHash(data)
+		}
+		item.Changed = item.CurrentHash != item.Hash
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// useTemplateSnapshot reports whether a scan runs against the recorded templates: for
+// snapshot rescans and when resuming a paused scan, so that both halves use the same versions
+func (sns *SimpleNucleiScanner) useTemplateSnapshot() bool {
+	return sns.task.UseTemplateSnapshot || sns.task.ResumeFile != ""
+}
+
+// prepareTemplateSnapshot writes the snapshot of the task into a temp directory (cleaned up
+// with the task's other temp directories) and maps each POC to its snapshot file
+func (sns *SimpleNucleiScanner) prepareTemplateSnapshot() {
+	if !sns.useTemplateSnapshot() || sns.manager == nil {
+		return
+	}
+	entries, err := sns.manager.db.GetTemplateSnapshot(sns.task.ID)
+	if err != nil || len(entries) == 0 {
+		logWarnf("⚠️ 任务 %d 没有可用的模板快照，使用当前模板\n", sns.task.ID)
+		return
+	}
+
+	tempManager, err := NewTempManager()
+	if err != nil {
+		logWarnf("⚠️ 创建模板快照目录失败，使用当前模板: %v\n", err)
+		return
+	}
+	dir := filepath.Join(tempManager.GetTempBaseDir(), fmt.Sprintf("task_%d_snapshot_%d", sns.task.ID, time.Now().Unix()))
+
+	files := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		// 按哈希分目录，保留原文件名
+		path := filepath.Join(dir, entry.Hash[:16], filepath.Base(entry.FilePath))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = os.WriteFile(path, entry.Content, 0644)
+		}
+		if err != nil {
+			logWarnf("⚠️ 写入模板快照失败，使用当前模板: %v\n", err)
+			os.RemoveAll(dir)
+			return
+		}
+		files[entry.POC] = path
+	}
+	sns.snapshotFiles = files
+	logInfof("📸 使用任务启动时的模板快照: %d 个模板\n", len(files))
+}
+
+// templateFile returns the file scanned for a POC: its snapshot copy when scanning
+// against the snapshot, otherwise the current template
+func (sns *SimpleNucleiScanner) templateFile(poc string) string {
+	if path, ok := sns.snapshotFiles[poc]; ok {
+		return path
+	}
+	return sns.task.templatePath(poc)
+}
+
+// templateFiles returns the files of all POCs of the task
+func (sns *SimpleNucleiScanner) templateFiles() []string {
+	files := make([]string, 0, len(sns.task.POCs))
+	for _, poc := range sns.task.POCs {
+		files = append(files, sns.templateFile(poc))
+	}
+	return files
+}
@@ -0,0 +1,553 @@
+package scanner
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wepoc/internal/database"
+	"wepoc/internal/models"
+)
+
+const (
+	// TemplateSourceGit is a template source cloned from a Git repository
+	TemplateSourceGit = "git"
+	// TemplateSourceZip is a template source downloaded as a zip archive
+	TemplateSourceZip = "zip"
+	// TemplateSourceArchive is a template source imported from a local zip archive
+	TemplateSourceArchive = "archive"
+)
+
+// zipDownloadTimeout bounds the download of a template zip archive
+const zipDownloadTimeout = 10 * time.Minute
+
+// Size limits of template zip archives, so a hostile or broken source cannot fill the disk
+// with an oversized download or a zip bomb
+const (
+	maxTemplateZipSize   = 512 << 20 // 下载的压缩包
+	maxTemplateEntrySize = 64 << 20  // 解压后的单个文件
+	maxTemplateTotalSize = 2 << 30   // 解压后的总大小
+)
+
+// GitAuth holds the credentials for a private repository. They are only used for the
+// current sync and are not stored.
+type GitAuth struct {
+	Username   string `json:"username"`     // HTTPS用户名，使用访问令牌时可留空
+	Token      string `json:"token"`        // HTTPS密码或访问令牌
+	SSHKeyPath string `json:"ssh_key_path"` // SSH私钥路径（git@ 地址）
+}
+
+// TemplateSyncResult is the outcome of syncing a template source into the POC directory
+type TemplateSyncResult struct {
+	Source     *models.TemplateSource `json:"source"`
+	UpToDate   bool                   `json:"up_to_date"` // 来源自上次同步后没有变化
+	TotalFound int                    `json:"total_found"`
+	Added      int                    `json:"added"`
+	Updated    int                    `json:"updated"`
+	Removed    int                    `json:"removed"` // 已从来源删除的模板
+	Unchanged  int                    `json:"unchanged"`
+	Failed     int                    `json:"failed"`
+	Conflicts  int                    `json:"conflicts"` // POC目录中已有其他来源的同名文件
+	Errors     []string               `json:"errors"`
+	LintIssues []*TemplateLintResult  `json:"lint_issues"`        // 新增和更新模板的质量问题
+	Manifest   *ManifestVerification  `json:"manifest,omitempty"` // 来源签名清单的验证结果
+}
+
+// TemplateSourceSync imports templates from Git repositories and zip URLs. Checkouts are
+// cached so that a repeated import only pulls and re-imports the changed files.
+type TemplateSourceSync struct {
+	db         *database.Database
+	parser     *TemplateParser
+	cacheDir   string // 仓库检出和zip解压目录
+	targetDir  string // POC目录
+	nucleiPath string
+	signers    []models.TrustedSigner // 验证来源签名清单的受信任签名者
+}
+
+// NewTemplateSourceSync creates a syncer importing into targetDir; templates get a trust
+// level when the source carries a manifest signed by one of signers
+func NewTemplateSourceSync(db *database.Database, parser *TemplateParser, cacheDir, targetDir, nucleiPath string, signers []models.TrustedSigner) *TemplateSourceSync {
+	return &TemplateSourceSync{
+		db:         db,
+		parser:     parser,
+		cacheDir:   cacheDir,
+		targetDir:  targetDir,
+		nucleiPath: nucleiPath,
+		signers:    signers,
+	}
+}
+
+// SyncGit clones or pulls a repository and imports the templates below subpath
+func (s *TemplateSourceSync) SyncGit(repoURL, branch, subpath string, auth GitAuth, progress BulkProgressFunc) (*TemplateSyncResult, error) {
+	repoURL = strings.TrimSpace(repoURL)
+	branch = strings.TrimSpace(branch)
+	if repoURL == "" {
+		return nil, fmt.Errorf("仓库地址不能为空")
+	}
+	// 以"-"开头的值会被git当作选项解析
+	if strings.HasPrefix(repoURL, "-") {
+		return nil, fmt.Errorf("无效的仓库地址: %s", repoURL)
+	}
+	if strings.HasPrefix(branch, "-") {
+		return nil, fmt.Errorf("无效的分支名: %s", branch)
+	}
+	if !isLocalGitSource(repoURL) {
+		if err := requireOnline(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("未找到git命令，请先安装Git")
+	}
+
+	checkout := filepath.Join(s.cacheDir, sourceKey(TemplateSourceGit, repoURL, branch))
+	if progress != nil {
+		progress(0, 0, "正在拉取仓库...")
+	}
+	if _, err := os.Stat(filepath.Join(checkout, ".git")); err != nil {
+		os.RemoveAll(checkout)
+		if err := os.MkdirAll(s.cacheDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create source cache directory: %w", err)
+		}
+		args := []string{"clone", "--depth", "1"}
+		if branch != "" {
+			args = append(args, "--branch", branch)
+		}
+		if _, err := runGit("", auth, append(args, "--", repoURL, checkout)...); err != nil {
+			os.RemoveAll(checkout)
+			return nil, err
+		}
+	} else {
+		ref := branch
+		if ref == "" {
+			ref = "HEAD"
+		}
+		if _, err := runGit(checkout, auth, "fetch", "--depth", "1", "--", "origin", ref); err != nil {
+			return nil, err
+		}
+		if _, err := runGit(checkout, auth, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			return nil, err
+		}
+	}
+
+	revision, err := runGit(checkout, auth, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	source := &models.TemplateSource{Kind: TemplateSourceGit, URL: repoURL, Branch: branch, Subpath: cleanSubpath(subpath)}
+	return s.sync(source, checkout, revision, progress)
+}
+
+// SyncZipURL downloads a zip archive and imports the templates below subpath. A single
+// top-level directory in the archive (as in GitHub archives) is skipped.
+func (s *TemplateSourceSync) SyncZipURL(zipURL, subpath string, progress BulkProgressFunc) (*TemplateSyncResult, error) {
+	zipURL = strings.TrimSpace(zipURL)
+	if !strings.HasPrefix(zipURL, "http://") && !strings.HasPrefix(zipURL, "https://") {
+		return nil, fmt.Errorf("无效的下载地址: %s", zipURL)
+	}
+	if err := requireOnline(); err != nil {
+		return nil, err
+	}
+	if progress != nil {
+		progress(0, 0, "正在下载模板压缩包...")
+	}
+
+	archive, revision, err := downloadZip(zipURL)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(archive)
+
+	dir := filepath.Join(s.cacheDir, sourceKey(TemplateSourceZip, zipURL, ""))
+	os.RemoveAll(dir)
+	if err := extractZip(archive, dir, maxTemplateEntrySize, maxTemplateTotalSize); err != nil {
+		return nil, err
+	}
+
+	source := &models.TemplateSource{Kind: TemplateSourceZip, URL: zipURL, Subpath: cleanSubpath(subpath)}
+	return s.sync(source, singleTopDir(dir), revision, progress)
+}
+
+// SyncArchive imports the templates below subpath of a local zip archive, e.g. a
+// nuclei-templates release copied into an air-gapped network. Importing a newer archive from
+// the same path only re-imports changed files.
+func (s *TemplateSourceSync) SyncArchive(archivePath, subpath string, progress BulkProgressFunc) (*TemplateSyncResult, error) {
+	archivePath, err := filepath.Abs(strings.TrimSpace(archivePath))
+	if err != nil {
+		return nil, fmt.Errorf("无效的文件路径: %w", err)
+	}
+	if progress != nil {
+		progress(0, 0, "正在解压模板压缩包...")
+	}
+	revision, err := fileSHA256(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(s.cacheDir, sourceKey(TemplateSourceArchive, archivePath, ""))
+	os.RemoveAll(dir)
+	if err := extractZip(archivePath, dir, maxTemplateEntrySize, maxTemplateTotalSize); err != nil {
+		return nil, err
+	}
+
+	source := &models.TemplateSource{Kind: TemplateSourceArchive, URL: archivePath, Subpath: cleanSubpath(subpath)}
+	return s.sync(source, singleTopDir(dir), revision, progress)
+}
+
+// sync copies the new and changed templates of a source into the POC directory and removes
+// the templates that were imported from the source before but no longer exist there
+func (s *TemplateSourceSync) sync(source *models.TemplateSource, root, revision string, progress BulkProgressFunc) (*TemplateSyncResult, error) {
+	previous := make(map[string]bool)
+	existing, err := s.db.GetTemplateSource(source.Kind, source.URL, source.Branch, source.Subpath)
+	switch {
+	case err == nil:
+		if existing.Revision == revision {
+			return &TemplateSyncResult{Source: existing, UpToDate: true, Unchanged: len(existing.Files), Errors: []string{}, LintIssues: []*TemplateLintResult{}}, nil
+		}
+		for _, name := range existing.Files {
+			previous[name] = true
+		}
+	case err != database.ErrNotFound:
+		return nil, err
+	}
+
+	sourceDir := filepath.Join(root, filepath.FromSlash(source.Subpath))
+	if info, err := os.Stat(sourceDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("来源中不存在目录: %s", source.Subpath)
+	}
+	if err := os.MkdirAll(s.targetDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create target directory: %w", err)
+	}
+
+	templates, scanErrors := s.parser.ScanDirectory(sourceDir)
+	result := &TemplateSyncResult{Source: source, TotalFound: len(templates), Errors: []string{}, LintIssues: []*TemplateLintResult{}}
+	for _, err := range scanErrors {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	result.Manifest = VerifyTemplateManifest(sourceDir, s.signers)
+	if result.Manifest.Error != "" {
+		result.Errors = append(result.Errors, result.Manifest.Error)
+	}
+
+	if progress != nil {
+		progress(0, result.TotalFound, "开始批量验证模板...")
+	}
+	validation, batchErr := s.parser.validateTemplatesBatch(sourceDir, s.nucleiPath)
+	if batchErr != nil {
+		// 批量验证无法区分各个模板时并行分批验证
+		validation = &ImportResult{invalid: s.parser.validateTemplatesParallel(templates, s.nucleiPath, func(done, failed, total int) {
+			if progress != nil {
+				progress(done, total, fmt.Sprintf("验证模板 %d/%d", done, total), map[string]int{"errors": failed})
+			}
+		})}
+	}
+
+	var files []string
+	var added []*models.Template
+	for i, template := range templates {
+		if progress != nil {
+			progress(i, result.TotalFound, fmt.Sprintf("同步模板 %d/%d: %s", i+1, result.TotalFound, template.TemplateID), map[string]int{
+				"successful": result.Added + result.Updated + result.Unchanged,
+				"errors":     result.Failed,
+				"duplicates": result.Conflicts,
+			})
+		}
+		if template.TemplateID == "" {
+			continue // 仓库中的其他YAML文件（如CI配置）
+		}
+
+		name := filepath.Base(template.FilePath)
+		if invalid := validationError(validation, template.FilePath); invalid != "" {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Validation failed for %s: %s", template.TemplateID, invalid))
+			if previous[name] {
+				files = append(files, name) // 保留上次导入的有效版本
+			}
+			continue
+		}
+
+		data, err := os.ReadFile(template.FilePath)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Failed to read %s: %v", template.TemplateID, err))
+			continue
+		}
+		targetPath := filepath.Join(s.targetDir, name)
+		current, err := os.ReadFile(targetPath)
+		exists := err == nil
+		template.Trust = result.Manifest.TrustOf(template.FilePath)
+
+		switch {
+		case exists && bytes.Equal(current, data):
+			result.Unchanged++
+			if previous[name] {
+				// 内容未变，但来源的签名可能变化
+				if err := s.db.SetTemplateTrustByPath(targetPath, template.Trust); err != nil {
+					result.Errors = append(result.Errors, err.Error())
+				}
+			}
+		case exists && !previous[name]:
+			result.Conflicts++
+			result.Errors = append(result.Errors, fmt.Sprintf("POC目录中已存在同名模板，跳过: %s", name))
+			continue
+		default:
+			if err := os.WriteFile(targetPath, data, 0644); err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("Failed to copy %s: %v", template.TemplateID, err))
+				continue
+			}
+			template.FilePath = targetPath
+			if lint := s.parser.lintImported(targetPath); lint != nil {
+				result.LintIssues = append(result.LintIssues, lint)
+			}
+			if exists {
+				if err := s.db.UpdateTemplate(template); err != nil {
+					result.Errors = append(result.Errors, err.Error())
+				}
+				result.Updated++
+			} else {
+				added = append(added, template)
+				result.Added++
+			}
+		}
+		files = append(files, name)
+	}
+
+	if len(added) > 0 {
+		if err := s.db.BatchInsertTemplates(added); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("Failed to save templates to database: %v", err))
+		}
+	}
+
+	kept := make(map[string]bool, len(files))
+	for _, name := range files {
+		kept[name] = true
+	}
+	for name := range previous {
+		if kept[name] {
+			continue
+		}
+		targetPath := filepath.Join(s.targetDir, name)
+		if err := os.Remove(targetPath); err != nil && !os.IsNotExist(err) {
+			result.Errors = append(result.Errors, fmt.Sprintf("Failed to remove %s: %v", name, err))
+			continue
+		}
+		if err := s.db.DeleteTemplateByPath(targetPath); err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		result.Removed++
+	}
+
+	now := time.Now()
+	source.Revision = revision
+	source.Files = files
+	source.SyncedAt = &now
+	if err := s.db.SaveTemplateSource(source); err != nil {
+		return nil, err
+	}
+
+	if progress != nil {
+		progress(result.TotalFound, result.TotalFound, "同步完成!", map[string]int{
+			"successful": result.Added + result.Updated + result.Unchanged,
+			"errors":     result.Failed,
+			"duplicates": result.Conflicts,
+		})
+	}
+	logInfof("🔄 模板来源已同步: %s (新增 %d, 更新 %d, 删除 %d, 未变化 %d)\n",
+		source.URL, result.Added, result.Updated, result.Removed, result.Unchanged)
+	return result, nil
+}
+
+// validationError returns the nuclei error line mentioning a template file, if any
+func validationError(validation *ImportResult, filePath string) string {
+	if validation == nil {
+		return ""
+	}
+	if validation.invalid != nil {
+		return validation.invalid[filePath]
+	}
+	for _, line := range validation.Errors {
+		if strings.Contains(line, filePath) {
+			return line
+		}
+	}
+	return ""
+}
+
+// runGit runs a git command and returns its trimmed output. Tokens are passed as an HTTP
+// header through the environment of this command only, so they neither end up in the
+// checkout's .git/config nor show in the process list.
+func runGit(dir string, auth GitAuth, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if auth.Token != "" {
+		username := auth.Username
+		if username == "" {
+			username = "git"
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + auth.Token))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials)
+	}
+	if auth.SSHKeyPath != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf(`GIT_SSH_COMMAND=ssh -i "%s" -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new`, auth.SSHKeyPath))
+	}
+	hideWindowOnWindows(cmd)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s 失败: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// downloadZip downloads a zip archive to a temporary file and returns its path and the
+// SHA-256 of its content
+func downloadZip(zipURL string) (string, string, error) {
+	client := &http.Client{Timeout: zipDownloadTimeout}
+	resp, err := client.Get(zipURL)
+	if err != nil {
+		return "", "", fmt.Errorf("下载失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("下载失败: HTTP %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxTemplateZipSize {
+		return "", "", fmt.Errorf("下载失败: 压缩包超过 %s", formatBytes(maxTemplateZipSize))
+	}
+
+	file, err := os.CreateTemp("", "wepoc_templates_*.zip")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(resp.Body, maxTemplateZipSize+1))
+	if err != nil {
+		os.Remove(file.Name())
+		return "", "", fmt.Errorf("下载失败: %w", err)
+	}
+	if written > maxTemplateZipSize {
+		os.Remove(file.Name())
+		return "", "", fmt.Errorf("下载失败: 压缩包超过 %s", formatBytes(maxTemplateZipSize))
+	}
+	return file.Name(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fileSHA256 returns the SHA-256 of a file's content
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// extractZip extracts an archive into dir, rejecting entries that would escape it and
+// archives whose entries extract to more than maxEntry bytes each or maxTotal bytes in all
+func extractZip(archive, dir string, maxEntry, maxTotal int64) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("无效的zip文件: %w", err)
+	}
+	defer reader.Close()
+
+	var total int64
+	for _, file := range reader.File {
+		target := filepath.Join(dir, filepath.FromSlash(file.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", file.Name)
+		}
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if file.UncompressedSize64 > uint64(maxEntry) {
+			return fmt.Errorf("压缩包中的 %s 超过 %s", file.Name, formatBytes(maxEntry))
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		// 声明的大小可能与实际不符，按实际写入的字节数计算
+		written, err := extractZipFile(file, target, min(maxEntry, maxTotal-total))
+		if err != nil {
+			return err
+		}
+		if total += written; total > maxTotal {
+			return fmt.Errorf("压缩包解压后超过 %s", formatBytes(maxTotal))
+		}
+	}
+	return nil
+}
+
+// extractZipFile writes one archive entry of at most limit bytes to target, keeping its
+// permission bits (the executables of a browser archive must stay executable), and returns
+// the number of bytes written
+func extractZipFile(file *zip.File, target string, limit int64) (int64, error) {
+	src, err := file.Open()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s from archive: %w", file.Name, err)
+	}
+	defer src.Close()
+
+	perm := file.Mode().Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return 0, err
+	}
+	defer dst.Close()
+	written, err := io.Copy(dst, io.LimitReader(src, limit+1))
+	if err == nil && written > limit {
+		err = fmt.Errorf("压缩包中的 %s 解压后过大", file.Name)
+	}
+	return written, err
+}
+
+// singleTopDir returns the only directory in dir when dir contains nothing else
+func singleTopDir(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
+
+// cleanSubpath normalizes a directory inside a source to a relative slash path that cannot
+// leave the source
+func cleanSubpath(subpath string) string {
+	subpath = path.Clean("/" + filepath.ToSlash(strings.TrimSpace(subpath)))
+	return strings.TrimPrefix(subpath, "/")
+}
+
+// sourceKey names the cache directory of a source
+func sourceKey(kind, url, branch string) string {
+	sum := sha1.Sum([]byte(url + "#" + branch))
+	return kind + "_" + hex.EncodeToString(sum[:])[:12]
+}
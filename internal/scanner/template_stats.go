@@ -0,0 +1,26 @@
+package scanner
+
+// recordTemplateStats adds the templates scanned by a finished run and their findings to the
+// template effectiveness statistics. Paused runs are recorded when the resumed scan finishes.
+func (tm *JSONTaskManager) recordTemplateStats(result *TaskResult) {
+	if result.Status == "paused" {
+		return
+	}
+
+	findings := make(map[string]int, len(result.ScannedTemplateIDs))
+	for _, templateID := range result.ScannedTemplateIDs {
+		findings[templateID] = 0
+	}
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.TemplateID != "" {
+			findings[vuln.TemplateID]++
+		}
+	}
+	if len(findings) == 0 {
+		return
+	}
+
+	if err := tm.db.RecordTemplateRun(result.TaskID, result.StartTime, result.EndTime, findings); err != nil {
+		logWarnf("⚠️ 记录任务 %d 模板命中统计失败: %v\n", result.TaskID, err)
+	}
+}
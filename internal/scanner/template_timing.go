@@ -0,0 +1,76 @@
+package scanner
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// slowestTemplateCount is how many templates are listed in TaskResult.SlowestTemplates
+const slowestTemplateCount = 10
+
+// TemplateTiming is the observed execution window of a template, taken from the first and
+// last debug output lines that mention it (templates run concurrently, so windows overlap)
+type TemplateTiming struct {
+	TemplateID string    `json:"template_id"`
+	StartedAt  time.Time `json:"started_at"`  // 首次出现在输出中的时间
+	FinishedAt time.Time `json:"finished_at"` // 最后出现在输出中的时间
+	DurationMs int64     `json:"duration_ms"` // 执行耗时（毫秒）
+	Requests   int       `json:"requests"`    // 发送的请求数
+}
+
+// templateTimer collects per-template timings during a scan
+type templateTimer struct {
+	timings map[string]*TemplateTiming
+	mu      sync.Mutex
+}
+
+// newTemplateTimer creates an empty timer
+func newTemplateTimer() *templateTimer {
+	return &templateTimer{timings: make(map[string]*TemplateTiming)}
+}
+
+// touch records activity for a template; request marks that a request was sent
+func (t *templateTimer) touch(templateID string, request bool) {
+	if templateID == "" {
+		return
+	}
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timing, exists := t.timings[templateID]
+	if !exists {
+		timing = &TemplateTiming{TemplateID: templateID, StartedAt: now}
+		t.timings[templateID] = timing
+	}
+	timing.FinishedAt = now
+	timing.DurationMs = now.Sub(timing.StartedAt).Milliseconds()
+	if request {
+		timing.Requests++
+	}
+}
+
+// slowest returns the n templates with the longest execution window
+func (t *templateTimer) slowest(n int) []*TemplateTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := make([]*TemplateTiming, 0, len(t.timings))
+	for _, timing := range t.timings {
+		copied := *timing
+		timings = append(timings, &copied)
+	}
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].DurationMs != timings[j].DurationMs {
+			return timings[i].DurationMs > timings[j].DurationMs
+		}
+		return timings[i].TemplateID < timings[j].TemplateID
+	})
+
+	if len(timings) > n {
+		timings = timings[:n]
+	}
+	return timings
+}
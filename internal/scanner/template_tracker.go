@@ -0,0 +1,181 @@
+package scanner
+
+import (
+	"sync"
+
+	"wepoc/internal/models"
+)
+
+// templateTracker counts template executions from nuclei's JSONL events. With -ms
+// (matcher status) nuclei writes an event for every template and target it ran, including
+// non-matches and execution errors, so the counts do not depend on parsing log lines.
+type templateTracker struct {
+	mu      sync.Mutex
+	targets int                        // 每个模板要执行的目标数
+	hosts   map[string]map[string]bool // 模板ID -> 已有执行记录的目标
+	order   []string                   // 按首次执行记录排序的模板ID
+	failed  []string                   // 出现执行错误的模板ID
+	errors  map[string]string          // 模板ID -> 首个执行错误
+}
+
+// templateCounts is a snapshot of the tracked templates
+type templateCounts struct {
+	Scanned   int // 已有执行记录的模板数
+	Completed int // 所有目标都已有执行记录的模板数
+	Failed    int // 出现执行错误的模板数
+}
+
+func newTemplateTracker(targets int) *templateTracker {
+	if targets < 1 {
+		targets = 1
+	}
+	return &templateTracker{
+		targets: targets,
+		hosts:   make(map[string]map[string]bool),
+		errors:  make(map[string]string),
+	}
+}
+
+// observe records a JSONL event of a template execution and reports whether it was the first
+// event of the template and whether it was its first execution error
+func (t *templateTracker) observe(templateID, host, execErr string) (first, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hosts, ok := t.hosts[templateID]
+	if !ok {
+		hosts = make(map[string]bool)
+		t.hosts[templateID] = hosts
+		t.order = append(t.order, templateID)
+		first = true
+	}
+	hosts[host] = true
+	if execErr != "" {
+		if _, seen := t.errors[templateID]; !seen {
+			t.errors[templateID] = execErr
+			t.failed = append(t.failed, templateID)
+			failed = true
+		}
+	}
+	return first, failed
+}
+
+// counts returns the current counts
+func (t *templateTracker) counts() templateCounts {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := templateCounts{Scanned: len(t.order), Failed: len(t.failed)}
+	for _, hosts := range t.hosts {
+		if len(hosts) >= t.targets {
+			counts.Completed++
+		}
+	}
+	return counts
+}
+
+// scanned reports whether a template has any execution event
+func (t *templateTracker) scanned(templateID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.hosts[templateID]
+	return ok
+}
+
+// scannedIDs returns the IDs of the templates with execution events, in order of their first event
+func (t *templateTracker) scannedIDs() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string{}, t.order...)
+}
+
+// failedIDs returns the IDs of the templates with execution errors
+func (t *templateTracker) failedIDs() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string{}, t.failed...)
+}
+
+// observeTemplateEvent updates the template progress from a JSONL event of nuclei
+func (sns *SimpleNucleiScanner) observeTemplateEvent(event map[string]interface{}) {
+	templateID, _ := event["template-id"].(string)
+	if templateID == "" {
+		return
+	}
+	host, _ := event["host"].(string)
+	execErr, _ := event["error"].(string)
+	first, failed := sns.templates.observe(templateID, host, execErr)
+	counts := sns.templates.counts()
+
+	sns.progressMu.Lock()
+	sns.progress.CurrentTemplate = templateID
+	if host != "" {
+		sns.progress.CurrentTarget = host
+	}
+	sns.progress.ScannedTemplates = counts.Scanned
+	sns.progress.CompletedTemplates = counts.Completed
+	sns.progress.FailedTemplates = counts.Failed
+	if first {
+		sns.progress.ScannedTemplateIDs = append(sns.progress.ScannedTemplateIDs, templateID)
+	}
+	if failed {
+		sns.progress.FailedTemplateIDs = append(sns.progress.FailedTemplateIDs, templateID)
+	}
+	if idx, ok := sns.templateIndex[templateID]; ok {
+		sns.progress.CurrentIndex = idx + 1 // 1-based
+	} else {
+		sns.progress.CurrentIndex = counts.Scanned
+	}
+	totalTemplates := sns.progress.TotalTemplates
+	sns.progressMu.Unlock()
+	sns.markTemplatesCompleted(counts.Completed, totalTemplates)
+
+	if first {
+		logInfof("📋 POC扫描: %d/%d - %s\n", counts.Scanned, sns.progress.TotalTemplates, templateID)
+	}
+	if failed {
+		logErrorf("❌ POC扫描失败: %s - %s\n", templateID, execErr)
+	}
+	sns.emitProgress()
+}
+
+// finalizeTemplateCounts sets the template counts of the finished scan. Every template with
+// an execution event is done; the others were filtered (code/headless/file) or skipped.
+func (sns *SimpleNucleiScanner) finalizeTemplateCounts() templateCounts {
+	filtered := make(map[string]bool, len(sns.task.FilteredTemplates))
+	var filteredIDs []string
+	for _, template := range sns.task.FilteredTemplates {
+		filtered[template.POC] = true
+		filteredIDs = append(filteredIDs, template.TemplateID)
+	}
+
+	var selected []*models.Template
+	if sns.manager != nil {
+		selected = sns.manager.selectedTemplates(sns.task.POCs, sns.task.Options.TemplateRoot)
+	}
+	skipped := []string{}
+	for i, poc := range sns.task.POCs {
+		templateID := poc
+		if i < len(selected) && selected[i] != nil && selected[i].TemplateID != "" {
+			templateID = selected[i].TemplateID
+		}
+		if !filtered[poc] && !sns.templates.scanned(templateID) {
+			skipped = append(skipped, templateID)
+		}
+	}
+
+	counts := sns.templates.counts()
+	sns.progressMu.Lock()
+	defer sns.progressMu.Unlock()
+	sns.progress.ScannedTemplates = counts.Scanned
+	sns.progress.CompletedTemplates = counts.Scanned
+	sns.progress.FailedTemplates = counts.Failed
+	sns.progress.ScannedTemplateIDs = sns.templates.scannedIDs()
+	sns.progress.FailedTemplateIDs = sns.templates.failedIDs()
+	sns.progress.SkippedTemplates = len(skipped)
+	sns.progress.SkippedTemplateIDs = skipped
+	if len(sns.progress.FilteredTemplateIDs) == 0 {
+		sns.progress.FilteredTemplateIDs = filteredIDs
+	}
+	return counts
+}
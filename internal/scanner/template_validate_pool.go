@@ -0,0 +1,131 @@
+package scanner
+
+import (
+	"os/exec"
+	goruntime "runtime"
+	"strings"
+	"sync"
+
+	"wepoc/internal/models"
+)
+
+const (
+	validationChunkSize  = 50 // 每次nuclei -validate 验证的模板数
+	maxValidationWorkers = 4  // 同时运行的nuclei验证进程数上限
+)
+
+// ValidationProgressFunc receives the number of validated and failed templates
+type ValidationProgressFunc func(done, failed, total int)
+
+// validateTemplatesParallel validates templates in chunks (nuclei accepts multiple -t) on a
+// bounded pool of nuclei processes, for when validating the whole directory at once failed.
+// It returns the nuclei error of each invalid template file.
+func (tp *TemplateParser) validateTemplatesParallel(templates []*models.Template, nucleiPath string, progress ValidationProgressFunc) map[string]string {
+	var chunks [][]*models.Template
+	var chunk []*models.Template
+	for _, template := range templates {
+		if isWorkflow(template) {
+			// 工作流需以 -w 单独验证
+			chunks = append(chunks, []*models.Template{template})
+			continue
+		}
+		chunk = append(chunk, template)
+		if len(chunk) == validationChunkSize {
+			chunks = append(chunks, chunk)
+			chunk = nil
+		}
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+
+	workers := goruntime.NumCPU()
+	if workers > maxValidationWorkers {
+		workers = maxValidationWorkers
+	}
+	if workers > len(chunks) {
+		workers = len(chunks)
+	}
+
+	invalid := make(map[string]string)
+	var mu sync.Mutex
+	done := 0
+	jobs := make(chan []*models.Template)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range jobs {
+				errs := tp.validateChunk(chunk, nucleiPath)
+				mu.Lock()
+				for path, message := range errs {
+					invalid[path] = message
+				}
+				done += len(chunk)
+				if progress != nil {
+					progress(done, len(invalid), len(templates))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, chunk := range chunks {
+		jobs <- chunk
+	}
+	close(jobs)
+	wg.Wait()
+	return invalid
+}
+
+// validateChunk validates templates in one nuclei run. When nuclei fails without naming a
+// file, the chunk is split in halves until the failing templates are found.
+func (tp *TemplateParser) validateChunk(chunk []*models.Template, nucleiPath string) map[string]string {
+	invalid := make(map[string]string)
+	if len(chunk) == 1 {
+		if err := tp.ValidateTemplate(chunk[0].FilePath, nucleiPath); err != nil {
+			invalid[chunk[0].FilePath] = err.Error()
+		}
+		return invalid
+	}
+
+	args := []string{"-validate"}
+	files := make([]string, 0, len(chunk))
+	for _, template := range chunk {
+		args = append(args, "-t", template.FilePath)
+		files = append(files, template.FilePath)
+	}
+	output, err := exec.Command(nucleiPath, append(args, updateCheckArgs()...)...).CombinedOutput()
+	_, invalid = mapValidationErrors(string(output), files)
+	if err == nil || len(invalid) > 0 {
+		return invalid
+	}
+
+	half := len(chunk) / 2
+	for _, part := range [][]*models.Template{chunk[:half], chunk[half:]} {
+		for path, message := range tp.validateChunk(part, nucleiPath) {
+			invalid[path] = message
+		}
+	}
+	return invalid
+}
+
+// mapValidationErrors returns the error lines of nuclei -validate output and maps them to
+// the template files they name
+func mapValidationErrors(output string, files []string) ([]string, map[string]string) {
+	var lines []string
+	invalid := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.Contains(line, "[ERR]") && !strings.Contains(line, "[FTL]") {
+			continue
+		}
+		lines = append(lines, line)
+		for _, file := range files {
+			if _, seen := invalid[file]; !seen && mentionsFile(line, file) {
+				invalid[file] = line
+			}
+		}
+	}
+	return lines, invalid
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"crypto/tls"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// TLSOptions are the TLS settings used against the targets. nuclei never verifies server
+// certificates, but Go's parser still rejects malformed ones (e.g. negative serials on
+// internal appliances); SkipVerify switches nuclei to the lenient zcrypto TLS stack.
+type TLSOptions struct {
+	SkipVerify bool   `json:"skip_verify"` // 容忍无效/畸形证书（nuclei -ztls）
+	MinVersion string `json:"min_version"` // 强制最低TLS版本：1.0/1.1/1.2/1.3，1.0/1.1时nuclei使用 -ztls
+	SNI        string `json:"sni"`         // 自定义SNI（nuclei -sni）
+}
+
+// Enabled reports whether any TLS setting is configured
+func (o TLSOptions) Enabled() bool {
+	return o.SkipVerify || strings.TrimSpace(o.MinVersion) != "" || strings.TrimSpace(o.SNI) != ""
+}
+
+// NucleiArgs returns nuclei's -sni and -ztls arguments. nuclei cannot restrict the TLS
+// version itself; legacy versions need zcrypto, which still speaks TLS 1.0/1.1.
+func (o TLSOptions) NucleiArgs() []string {
+	var args []string
+	if sni := strings.TrimSpace(o.SNI); sni != "" {
+		args = append(args, "-sni", sni)
+	}
+	version, _ := parseTLSVersion(o.MinVersion)
+	if o.SkipVerify || (version != 0 && version < tls.VersionTLS12) {
+		args = append(args, "-ztls")
+	}
+	return args
+}
+
+// applySendOptions sets the SNI and minimum TLS version of wepoc's own requests
+func (o TLSOptions) applySendOptions(opts *SendOptions) {
+	if sni := strings.TrimSpace(o.SNI); sni != "" {
+		opts.ServerName = sni
+	}
+	if version, err := parseTLSVersion(o.MinVersion); err == nil && version != 0 {
+		opts.MinTLSVersion = version
+	}
+}
+
+// validateTLSOptions checks the TLS version of task options
+func validateTLSOptions(options TLSOptions) error {
+	_, err := parseTLSVersion(options.MinVersion)
+	return err
+}
+
+// TLSOptionsFromConfig returns the global TLS settings
+func TLSOptionsFromConfig(config *models.Config) TLSOptions {
+	if config == nil {
+		return TLSOptions{}
+	}
+	return TLSOptions{
+		SkipVerify: config.NucleiConfig.TLSSkipVerify,
+		MinVersion: config.NucleiConfig.TLSMinVersion,
+		SNI:        config.NucleiConfig.TLSServerName,
+	}
+}
+
+// tlsOptionsForTask returns the task's TLS settings, falling back to the global ones
+func (tm *JSONTaskManager) tlsOptionsForTask(task *TaskConfig) TLSOptions {
+	if task != nil && task.Options.TLS.Enabled() {
+		return task.Options.TLS
+	}
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return TLSOptionsFromConfig(tm.config)
+}
@@ -0,0 +1,385 @@
+package scanner
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"wepoc/internal/models"
+)
+
+// Search engines supported for target discovery
+const (
+	EngineFofa   = "fofa"
+	EngineHunter = "hunter"
+	EngineShodan = "shodan"
+)
+
+const (
+	// defaultUncoverLimit is the number of results fetched when the caller sets no limit
+	defaultUncoverLimit = 100
+	// defaultUncoverMaxResults bounds the results of one query when the settings set no cap
+	defaultUncoverMaxResults = 1000
+	// uncoverTimeout bounds a single search engine API request
+	uncoverTimeout = 30 * time.Second
+)
+
+// UncoverResult is the outcome of a search engine query after deduplication
+type UncoverResult struct {
+	Engine       string   `json:"engine"`
+	Query        string   `json:"query"`
+	Total        int      `json:"total"`                   // 搜索引擎报告的结果总数
+	Fetched      int      `json:"fetched"`                 // 实际获取的结果数（受数量上限限制）
+	Duplicates   int      `json:"duplicates"`              // 查询结果内重复的目标数
+	Targets      []string `json:"targets"`                 // 去重后的新目标
+	KnownTargets []string `json:"known_targets,omitempty"` // 已存在于其他任务中被跳过的目标
+}
+
+// UncoverTask is a task created from a search engine query
+type UncoverTask struct {
+	Task   *TaskConfig    `json:"task"`
+	Search *UncoverResult `json:"search"`
+}
+
+// uncoverPage is one page of search engine results
+type uncoverPage struct {
+	total   int
+	targets []string
+}
+
+// SearchTargets queries a search engine (fofa, hunter or shodan) and returns up to limit
+// targets; limit is capped by the configured max results
+func SearchTargets(ctx context.Context, config models.UncoverConfig, engine, query string, limit int) (*UncoverResult, error) {
+	if err := requireOnline(); err != nil {
+		return nil, err
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("查询语句不能为空")
+	}
+	maxResults := config.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultUncoverMaxResults
+	}
+	if limit <= 0 {
+		limit = defaultUncoverLimit
+	}
+	if limit > maxResults {
+		limit = maxResults
+	}
+
+	var fetchPage func(ctx context.Context, client *http.Client, query string, page, size int) (*uncoverPage, error)
+	pageSize := 100
+	switch strings.ToLower(engine) {
+	case EngineFofa:
+		if config.FofaKey == "" {
+			return nil, fmt.Errorf("未配置FOFA API Key")
+		}
+		fetchPage = func(ctx context.Context, client *http.Client, query string, page, size int) (*uncoverPage, error) {
+			return fofaSearch(ctx, client, config.FofaEmail, config.FofaKey, query, page, size)
+		}
+	case EngineHunter:
+		if config.HunterKey == "" {
+			return nil, fmt.Errorf("未配置Hunter API Key")
+		}
+		fetchPage = func(ctx context.Context, client *http.Client, query string, page, size int) (*uncoverPage, error) {
+			return hunterSearch(ctx, client, config.HunterKey, query, page, size)
+		}
+	case EngineShodan:
+		if config.ShodanKey == "" {
+			return nil, fmt.Errorf("未配置Shodan API Key")
+		}
+		fetchPage = func(ctx context.Context, client *http.Client, query string, page, _ int) (*uncoverPage, error) {
+			return shodanSearch(ctx, client, config.ShodanKey, query, page)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的搜索引擎: %s", engine)
+	}
+
+	result := &UncoverResult{Engine: strings.ToLower(engine), Query: query, Targets: []string{}}
+	client := &http.Client{Timeout: uncoverTimeout}
+	seen := make(map[string]bool)
+	for page := 1; result.Fetched < limit; page++ {
+		size := pageSize
+		if remaining := limit - result.Fetched; remaining < size {
+			size = remaining
+		}
+		batch, err := fetchPage(ctx, client, query, page, size)
+		if err != nil {
+			if result.Fetched > 0 {
+				// 保留已获取的结果，后续页失败不影响已有目标
+				logWarnf("⚠️ %s 第 %d 页查询失败，使用已获取的 %d 条结果: %v\n", result.Engine, page, result.Fetched, err)
+				break
+			}
+			return nil, err
+		}
+		result.Total = batch.total
+		for _, target := range batch.targets {
+			if result.Fetched >= limit {
+				break
+			}
+			result.Fetched++
+			key := targetAddress(target)
+			if key == "" || seen[key] {
+				result.Duplicates++
+				continue
+			}
+			seen[key] = true
+			result.Targets = append(result.Targets, target)
+		}
+		if len(batch.targets) == 0 || result.Fetched >= batch.total {
+			break
+		}
+	}
+	return result, nil
+}
+
+// fofaSearch fetches one page of FOFA results
+func fofaSearch(ctx context.Context, client *http.Client, email, key, query string, page, size int) (*uncoverPage, error) {
+	params := url.Values{}
+	params.Set("key", key)
+	if email != "" {
+		params.Set("email", email)
+	}
+	params.Set("qbase64", base64.StdEncoding.EncodeToString([]byte(query)))
+	params.Set("fields", "host,protocol")
+	params.Set("page", strconv.Itoa(page))
+	params.Set("size", strconv.Itoa(size))
+
+	var response struct {
+		Error   bool       `json:"error"`
+		ErrMsg  string     `json:"errmsg"`
+		Size    int        `json:"size"`
+		Results [][]string `json:"results"`
+	}
+	if err := uncoverRequest(ctx, client, "FOFA", "https://fofa.info/api/v1/search/all?"+params.Encode(), &response); err != nil {
+		return nil, err
+	}
+	if response.Error {
+		return nil, fmt.Errorf("FOFA返回错误: %s", response.ErrMsg)
+	}
+
+	batch := &uncoverPage{total: response.Size}
+	for _, row := range response.Results {
+		if len(row) < 2 {
+			continue
+		}
+		batch.targets = append(batch.targets, uncoverTarget(row[0], row[1]))
+	}
+	return batch, nil
+}
+
+// hunterSearch fetches one page of Qianxin Hunter results
+func hunterSearch(ctx context.Context, client *http.Client, key, query string, page, size int) (*uncoverPage, error) {
+	params := url.Values{}
+	params.Set("api-key", key)
+	params.Set("search", base64.URLEncoding.EncodeToString([]byte(query)))
+	params.Set("page", strconv.Itoa(page))
+	params.Set("page_size", strconv.Itoa(size))
+
+	var response struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    struct {
+			Total int `json:"total"`
+			Arr   []struct {
+				URL      string `json:"url"`
+				IP       string `json:"ip"`
+				Port     int    `json:"port"`
+				Domain   string `json:"domain"`
+				Protocol string `json:"protocol"`
+			} `json:"arr"`
+		} `json:"data"`
+	}
+	if err := uncoverRequest(ctx, client, "Hunter", "https://hunter.qianxin.com/openApi/search?"+params.Encode(), &response); err != nil {
+		return nil, err
+	}
+	if response.Code != http.StatusOK {
+		return nil, fmt.Errorf("Hunter返回错误: %d %s", response.Code, response.Message)
+	}
+
+	batch := &uncoverPage{total: response.Data.Total}
+	for _, item := range response.Data.Arr {
+		if item.URL != "" {
+			batch.targets = append(batch.targets, item.URL)
+			continue
+		}
+		host := item.Domain
+		if host == "" {
+			host = item.IP
+		}
+		batch.targets = append(batch.targets, uncoverTarget(fmt.Sprintf("%s:%d", host, item.Port), item.Protocol))
+	}
+	return batch, nil
+}
+
+// shodanSearch fetches one page (100 results) of Shodan results
+func shodanSearch(ctx context.Context, client *http.Client, key, query string, page int) (*uncoverPage, error) {
+	params := url.Values{}
+	params.Set("key", key)
+	params.Set("query", query)
+	params.Set("page", strconv.Itoa(page))
+
+	var response struct {
+		Error   string `json:"error"`
+		Total   int    `json:"total"`
+		Matches []struct {
+			IP        string          `json:"ip_str"`
+			Port      int             `json:"port"`
+			Hostnames []string        `json:"hostnames"`
+			HTTP      json.RawMessage `json:"http"`
+			SSL       json.RawMessage `json:"ssl"`
+		} `json:"matches"`
+	}
+	if err := uncoverRequest(ctx, client, "Shodan", "https://api.shodan.io/shodan/host/search?"+params.Encode(), &response); err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("Shodan返回错误: %s", response.Error)
+	}
+
+	batch := &uncoverPage{total: response.Total}
+	for _, match := range response.Matches {
+		host := match.IP
+		if len(match.Hostnames) > 0 {
+			host = match.Hostnames[0]
+		}
+		protocol := ""
+		if len(match.HTTP) > 0 {
+			protocol = "http"
+			if len(match.SSL) > 0 {
+				protocol = "https"
+			}
+		}
+		batch.targets = append(batch.targets, uncoverTarget(fmt.Sprintf("%s:%d", host, match.Port), protocol))
+	}
+	return batch, nil
+}
+
+// uncoverRequest sends a GET request to a search engine API and decodes the JSON answer
+func uncoverRequest(ctx context.Context, client *http.Client, engine, apiURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", engine, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// 错误信息中的URL包含API Key，只返回底层错误
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s接口请求失败: %v", engine, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("%s接口请求失败: %w", engine, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s接口返回错误: HTTP %d: %s", engine, resp.StatusCode, lastLine(string(data)))
+		}
+		return fmt.Errorf("无法解析%s接口响应: %w", engine, err)
+	}
+	return nil
+}
+
+// uncoverTarget turns a search engine host and protocol into a scan target: a URL for
+// web services, host:port otherwise
+func uncoverTarget(host, protocol string) string {
+	host = strings.TrimSpace(host)
+	if strings.Contains(host, "://") {
+		return host
+	}
+	switch strings.ToLower(protocol) {
+	case "http", "https":
+		return strings.ToLower(protocol) + "://" + bracketIPv6(host)
+	}
+	return host
+}
+
+// UncoverTargets runs a search engine query and drops the targets that are already part of
+// an existing task when skipKnown is set
+func (tm *JSONTaskManager) UncoverTargets(ctx context.Context, engine, query string, limit int, skipKnown bool) (*UncoverResult, error) {
+	tm.mu.RLock()
+	var config models.UncoverConfig
+	if tm.config != nil {
+		config = tm.config.Uncover
+	}
+	tm.mu.RUnlock()
+
+	result, err := SearchTargets(ctx, config, engine, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	if !skipKnown || len(result.Targets) == 0 {
+		return result, nil
+	}
+
+	known, err := tm.knownTargets()
+	if err != nil {
+		return nil, err
+	}
+	fresh := make([]string, 0, len(result.Targets))
+	for _, target := range result.Targets {
+		if known[targetAddress(target)] {
+			result.KnownTargets = append(result.KnownTargets, target)
+			continue
+		}
+		fresh = append(fresh, target)
+	}
+	result.Targets = fresh
+	return result, nil
+}
+
+// knownTargets returns the host:port addresses of the targets of all tasks
+func (tm *JSONTaskManager) knownTargets() (map[string]bool, error) {
+	tasks, err := tm.GetAllTasks()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool)
+	for _, task := range tasks {
+		for _, target := range task.Targets {
+			if address := targetAddress(target); address != "" {
+				known[address] = true
+			}
+		}
+	}
+	return known, nil
+}
+
+// CreateTaskFromQuery creates a task whose targets are the results of a search engine query
+func (tm *JSONTaskManager) CreateTaskFromQuery(ctx context.Context, engine, query string, limit int, pocs []string, taskName string, skipKnown bool) (*UncoverTask, error) {
+	result, err := tm.UncoverTargets(ctx, engine, query, limit, skipKnown)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Targets) == 0 {
+		if len(result.KnownTargets) > 0 {
+			return nil, fmt.Errorf("查询结果中的 %d 个目标均已存在于其他任务中", len(result.KnownTargets))
+		}
+		return nil, fmt.Errorf("查询未返回任何目标")
+	}
+
+	task, err := tm.createTask(&TaskConfig{
+		Name:           taskName,
+		POCs:           pocs,
+		Targets:        result.Targets,
+		DiscoveryQuery: result.Engine + ": " + result.Query,
+	})
+	if err != nil {
+		return nil, err
+	}
+	logInfof("🔎 %s 查询获取 %d 条结果，新目标 %d 个，跳过已有目标 %d 个，创建任务 %d\n",
+		result.Engine, result.Fetched, len(result.Targets), len(result.KnownTargets), task.ID)
+	return &UncoverTask{Task: task, Search: result}, nil
+}
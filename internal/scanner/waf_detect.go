@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"net/http"
+	"strings"
+
+	"wepoc/internal/models"
+)
+
+// WAFReviewNote is the review note of findings on targets behind a WAF/CDN
+const WAFReviewNote = "目标位于WAF/CDN之后，请人工确认"
+
+// wafSignature identifies a WAF/CDN by response headers, cookies or block page content;
+// all values are matched case-insensitively
+type wafSignature struct {
+	name    string
+	headers map[string]string // 响应头 -> 值包含的内容（为空表示只要存在该头）
+	cookies []string          // Cookie名前缀
+	body    []string          // 拦截页面包含的内容
+}
+
+// wafSignatures are the known WAF/CDN fingerprints, checked in order
+var wafSignatures = []wafSignature{
+	{name: "Cloudflare", headers: map[string]string{"cf-ray": "", "server": "cloudflare"}, cookies: []string{"__cfduid", "__cf_bm", "cf_clearance"}},
+	{name: "Akamai", headers: map[string]string{"server": "akamaighost", "x-akamai-transformed": ""}, cookies: []string{"ak_bmsc", "bm_sz"}},
+	{name: "AWS CloudFront", headers: map[string]string{"x-amz-cf-id": "", "server": "cloudfront"}},
+	{name: "AWS WAF", headers: map[string]string{"x-amzn-waf-action": ""}, cookies: []string{"aws-waf-token"}},
+	{name: "Imperva Incapsula", headers: map[string]string{"x-iinfo": "", "x-cdn": "incapsula"}, cookies: []string{"incap_ses", "visid_incap"}},
+	{name: "Sucuri", headers: map[string]string{"x-sucuri-id": "", "server": "sucuri"}},
+	{name: "Fastly", headers: map[string]string{"x-fastly-request-id": "", "x-served-by": "cache-"}},
+	{name: "Azure Front Door", headers: map[string]string{"x-azure-ref": ""}},
+	{name: "F5 BIG-IP ASM", headers: map[string]string{"x-wa-info": ""}, cookies: []string{"TS01", "BIGipServer"}},
+	{name: "Barracuda", cookies: []string{"barra_counter_session"}},
+	{name: "ModSecurity", headers: map[string]string{"server": "mod_security"}, body: []string{"mod_security", "this error was generated by mod_security"}},
+	{name: "阿里云WAF", cookies: []string{"aliyungf_tc", "acw_tc"}, body: []string{"errors.aliyun.com"}},
+	{name: "腾讯云WAF", headers: map[string]string{"server": "tencent"}, body: []string{"waf.tencent-cloud.com"}},
+	{name: "百度云加速", headers: map[string]string{"server": "yunjiasu"}},
+	{name: "360网站卫士", headers: map[string]string{"server": "360wzws", "x-safe-firewall": ""}},
+	{name: "知道创宇加速乐", headers: map[string]string{"server": "jiasule", "x-via-jsl": ""}, cookies: []string{"__jsluid", "jsl_tracking"}},
+	{name: "安全狗", headers: map[string]string{"server": "safedog", "x-powered-by": "waf/2.0"}, cookies: []string{"safedog-flow-item"}},
+	{name: "云锁", cookies: []string{"yunsuo_session"}, body: []string{"yunsuologo"}},
+	{name: "长亭雷池", headers: map[string]string{"server": "safeline"}, body: []string{"safeline", "event_id"}},
+	{name: "网宿CDN", headers: map[string]string{"server": "cdn cache server", "x-via": "cdn cache server"}},
+}
+
+// DetectWAF returns the WAF/CDN a response passed through, or "" when no signature matches
+func DetectWAF(header http.Header, body []byte) string {
+	lowerBody := strings.ToLower(string(body))
+	var cookies []string
+	for _, line := range header.Values("Set-Cookie") {
+		name, _, _ := strings.Cut(line, "=")
+		cookies = append(cookies, strings.ToLower(strings.TrimSpace(name)))
+	}
+
+	for _, signature := range wafSignatures {
+		for name, value := range signature.headers {
+			got := header.Get(name)
+			if got != "" && strings.Contains(strings.ToLower(got), value) {
+				return signature.name
+			}
+		}
+		for _, prefix := range signature.cookies {
+			for _, cookie := range cookies {
+				if strings.HasPrefix(cookie, strings.ToLower(prefix)) {
+					return signature.name
+				}
+			}
+		}
+		if len(signature.body) > 0 && matchesAllBody(lowerBody, signature.body) {
+			return signature.name
+		}
+	}
+	return ""
+}
+
+// matchesAllBody reports whether the lowercased body contains every marker; block pages
+// need all markers so that pages merely mentioning a product are not flagged
+func matchesAllBody(lowerBody string, markers []string) bool {
+	for _, marker := range markers {
+		if !strings.Contains(lowerBody, marker) {
+			return false
+		}
+	}
+	return true
+}
+
+// flagWAFFindings marks the findings on targets detected behind a WAF/CDN for manual review
+func flagWAFFindings(vulns []*models.NucleiResult, infos []*TargetInfo) {
+	var targets []string
+	var wafs []string
+	for _, info := range infos {
+		if info != nil && info.WAF != "" {
+			targets = append(targets, info.Target, info.URL)
+			wafs = append(wafs, info.WAF, info.WAF)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	flagged := 0
+	for _, vuln := range vulns {
+		if i := matchTarget(targets, []string{vuln.MatchedAt, vuln.Host}); i >= 0 {
+			vuln.BehindWAF = wafs[i]
+			vuln.ReviewNote = WAFReviewNote
+			flagged++
+		}
+	}
+	if flagged > 0 {
+		logInfof("🛡️ %d 个漏洞的目标位于WAF/CDN之后，已标记为需人工确认\n", flagged)
+	}
+}
+
+// wafTargets returns the targets detected behind a WAF/CDN
+func wafTargets(infos []*TargetInfo) []string {
+	var targets []string
+	for _, info := range infos {
+		if info != nil && info.WAF != "" {
+			targets = append(targets, info.Target)
+		}
+	}
+	return targets
+}
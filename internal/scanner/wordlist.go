@@ -0,0 +1,339 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WordlistInfo describes a managed wordlist file
+type WordlistInfo struct {
+	Name       string    `json:"name"`        // 文件名
+	Path       string    `json:"path"`        // 绝对路径
+	Size       int64     `json:"size"`        // 文件大小（字节）
+	Lines      int       `json:"lines"`       // 行数（条目数）
+	ModifiedAt time.Time `json:"modified_at"` // 修改时间
+}
+
+// WordlistPreview holds the first lines of a wordlist
+type WordlistPreview struct {
+	Name       string   `json:"name"`
+	Lines      []string `json:"lines"`       // 预览内容
+	TotalLines int      `json:"total_lines"` // 总行数
+}
+
+// WordlistManager manages payload files under ~/.wepoc/wordlists
+type WordlistManager struct {
+	dir string
+}
+
+// NewWordlistManager creates the manager and ensures the wordlist directory exists
+func NewWordlistManager() (*WordlistManager, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	dir := filepath.Join(homeDir, ".wepoc", "wordlists")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create wordlist directory: %w", err)
+	}
+
+	return &WordlistManager{dir: dir}, nil
+}
+
+// Dir returns the wordlist directory
+func (wm *WordlistManager) Dir() string {
+	return wm.dir
+}
+
+// path validates a wordlist name and returns its absolute path
+func (wm *WordlistManager) path(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("无效的字典名称: %s", name)
+	}
+	return filepath.Join(wm.dir, name), nil
+}
+
+// Import copies a file into the wordlist directory; name defaults to the source file name
+func (wm *WordlistManager) Import(srcPath, name string) (*WordlistInfo, error) {
+	if name == "" {
+		name = filepath.Base(srcPath)
+	}
+	dstPath, err := wm.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wordlist: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wordlist: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return nil, fmt.Errorf("failed to copy wordlist: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write wordlist: %w", err)
+	}
+
+	logInfof("📚 导入字典: %s -> %s\n", srcPath, dstPath)
+	return wm.info(dstPath)
+}
+
+// info collects the metadata of a wordlist file
+func (wm *WordlistManager) info(path string) (*WordlistInfo, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := countLines(path)
+	if err != nil {
+		return nil, err
+	}
+	return &WordlistInfo{
+		Name:       filepath.Base(path),
+		Path:       path,
+		Size:       stat.Size(),
+		Lines:      lines,
+		ModifiedAt: stat.ModTime(),
+	}, nil
+}
+
+// List returns all managed wordlists sorted by name
+func (wm *WordlistManager) List() ([]*WordlistInfo, error) {
+	entries, err := os.ReadDir(wm.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wordlist directory: %w", err)
+	}
+
+	wordlists := []*WordlistInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := wm.info(filepath.Join(wm.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		wordlists = append(wordlists, info)
+	}
+	sort.Slice(wordlists, func(i, j int) bool {
+		return wordlists[i].Name < wordlists[j].Name
+	})
+	return wordlists, nil
+}
+
+// Preview returns the first maxLines lines of a wordlist
+func (wm *WordlistManager) Preview(name string, maxLines int) (*WordlistPreview, error) {
+	path, err := wm.path(name)
+	if err != nil {
+		return nil, err
+	}
+	if maxLines <= 0 {
+		maxLines = 100
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wordlist: %w", err)
+	}
+	defer file.Close()
+
+	preview := &WordlistPreview{Name: name, Lines: []string{}}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if preview.TotalLines < maxLines {
+			preview.Lines = append(preview.Lines, scanner.Text())
+		}
+		preview.TotalLines++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read wordlist: %w", err)
+	}
+	return preview, nil
+}
+
+// Delete removes a managed wordlist
+func (wm *WordlistManager) Delete(name string) error {
+	path, err := wm.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete wordlist: %w", err)
+	}
+	return nil
+}
+
+// Resolve maps a payload file reference from a template to a managed wordlist.
+// References such as "wordlists/users.txt", "~/.wepoc/wordlists/users.txt" or just
+// "users.txt" resolve by file name.
+func (wm *WordlistManager) Resolve(ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.ContainsAny(ref, "\n{}") {
+		return "", false
+	}
+	path, err := wm.path(filepath.Base(filepath.FromSlash(ref)))
+	if err != nil {
+		return "", false
+	}
+	if stat, err := os.Stat(path); err != nil || stat.IsDir() {
+		return "", false
+	}
+	return path, true
+}
+
+// countLines counts the lines of a file (a trailing line without newline counts too)
+func countLines(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	count := 0
+	last := byte('\n')
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := file.Read(buf)
+		for _, b := range buf[:n] {
+			if b == '\n' {
+				count++
+			}
+		}
+		if n > 0 {
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if last != '\n' {
+		count++
+	}
+	return count, nil
+}
+
+// rewritePayloadFiles points payload file references of a template at managed wordlists.
+// References that already exist (absolute, or relative to templatesDir) are left alone.
+// Resolved wordlists are copied into wordlistDir; returns the rewritten YAML and whether
+// anything changed.
+func (wm *WordlistManager) rewritePayloadFiles(content []byte, templatesDir, wordlistDir string) ([]byte, bool, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, false, err
+	}
+
+	changed := false
+	var walkErr error
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				if key.Value == "payloads" && value.Kind == yaml.MappingNode {
+					for j := 1; j < len(value.Content); j += 2 {
+						if wm.rewritePayloadRef(value.Content[j], templatesDir, wordlistDir, &walkErr) {
+							changed = true
+						}
+					}
+					continue
+				}
+				walk(value)
+			}
+			return
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(&root)
+
+	if walkErr != nil {
+		return nil, false, walkErr
+	}
+	if !changed {
+		return content, false, nil
+	}
+
+	data, err := marshalYAMLNode(&root)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// marshalYAMLNode encodes a rewritten template with the two-space indent templates use
+func marshalYAMLNode(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rewritePayloadRef rewrites a single payload scalar if it refers to a managed wordlist
+func (wm *WordlistManager) rewritePayloadRef(node *yaml.Node, templatesDir, wordlistDir string, errOut *error) bool {
+	if node.Kind != yaml.ScalarNode {
+		return false
+	}
+	ref := node.Value
+	if filepath.IsAbs(ref) {
+		if _, err := os.Stat(ref); err == nil {
+			return false
+		}
+	} else if _, err := os.Stat(filepath.Join(templatesDir, ref)); err == nil {
+		return false
+	}
+
+	src, ok := wm.Resolve(ref)
+	if !ok {
+		return false
+	}
+
+	if err := os.MkdirAll(wordlistDir, 0755); err != nil {
+		*errOut = fmt.Errorf("failed to create wordlist directory: %w", err)
+		return false
+	}
+	dst := filepath.Join(wordlistDir, filepath.Base(src))
+	if _, err := os.Stat(dst); os.IsNotExist(err) {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			*errOut = fmt.Errorf("failed to read wordlist: %w", err)
+			return false
+		}
+		if err := os.WriteFile(dst, data, 0644); err != nil {
+			*errOut = fmt.Errorf("failed to copy wordlist: %w", err)
+			return false
+		}
+	}
+
+	node.Value = dst
+	node.Style = 0
+	return true
+}
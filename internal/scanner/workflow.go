@@ -0,0 +1,254 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"wepoc/internal/models"
+)
+
+// WorkflowStep is a template reference of a workflow, flattened in execution order
+type WorkflowStep struct {
+	Template  string `json:"template,omitempty"`  // 引用的模板路径
+	Tags      string `json:"tags,omitempty"`      // 按标签选择模板（由nuclei在其模板目录中解析）
+	Path      string `json:"path,omitempty"`      // 解析到的模板文件，为空表示未找到
+	Condition string `json:"condition,omitempty"` // 执行条件：父模板命中的matcher名称，为空表示父模板命中即执行
+	Depth     int    `json:"depth"`               // 嵌套层级（0为顶层）
+}
+
+// WorkflowDetail is a parsed nuclei workflow with its template references resolved
+// against the template library
+type WorkflowDetail struct {
+	Template   *models.Template `json:"template"`
+	Steps      []*WorkflowStep  `json:"steps"`
+	Templates  []string         `json:"templates"`  // 引用并已找到的模板文件（去重）
+	Unresolved []string         `json:"unresolved"` // 模板库中找不到的模板引用
+}
+
+// workflowDefinition mirrors the workflow blocks of a nuclei workflow file
+type workflowDefinition struct {
+	Template     string               `yaml:"template"`
+	Tags         interface{}          `yaml:"tags"`
+	Matchers     []workflowMatcher    `yaml:"matchers"`
+	Subtemplates []workflowDefinition `yaml:"subtemplates"`
+}
+
+// workflowMatcher runs subtemplates when the named matcher of the parent template hits
+type workflowMatcher struct {
+	Name         interface{}          `yaml:"name"`
+	Subtemplates []workflowDefinition `yaml:"subtemplates"`
+}
+
+// isWorkflow reports whether a template is a workflow
+func isWorkflow(template *models.Template) bool {
+	return template != nil && containsString(strings.Split(template.Protocols, ","), "workflows")
+}
+
+// workflowTemplatesDir returns the template library directory workflow references resolve against
+func workflowTemplatesDir(config *models.Config) string {
+	if config != nil && config.POCDirectory != "" {
+		return config.POCDirectory
+	}
+	return defaultTemplatesDir()
+}
+
+// resolveWorkflowTemplate finds the template file of a workflow reference: an absolute
+// path, a path relative to the workflow or to the template library, or a template imported
+// flat into the library under the same file name
+func resolveWorkflowTemplate(ref, workflowDir, templatesDir string) string {
+	ref = filepath.FromSlash(strings.TrimSpace(ref))
+	if ref == "" {
+		return ""
+	}
+	candidates := []string{ref}
+	if !filepath.IsAbs(ref) {
+		candidates = []string{filepath.Join(workflowDir, ref), filepath.Join(templatesDir, ref), filepath.Join(templatesDir, filepath.Base(ref))}
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return filepath.Clean(candidate)
+		}
+	}
+	return ""
+}
+
+// ParseWorkflow parses a workflow file and resolves the templates it references
+func (tp *TemplateParser) ParseWorkflow(filePath, templatesDir string) (*WorkflowDetail, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow file: %w", err)
+	}
+	var workflow struct {
+		Workflows []workflowDefinition `yaml:"workflows"`
+	}
+	if err := yaml.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow YAML: %w", err)
+	}
+	if len(workflow.Workflows) == 0 {
+		return nil, fmt.Errorf("模板不是工作流: %s", filepath.Base(filePath))
+	}
+
+	detail := &WorkflowDetail{Steps: []*WorkflowStep{}, Templates: []string{}, Unresolved: []string{}}
+	workflowDir := filepath.Dir(filePath)
+	var walk func(definitions []workflowDefinition, condition string, depth int)
+	walk = func(definitions []workflowDefinition, condition string, depth int) {
+		for _, definition := range definitions {
+			step := &WorkflowStep{Template: definition.Template, Tags: strings.Join(stringList(definition.Tags, true), ","), Condition: condition, Depth: depth}
+			if step.Template != "" {
+				step.Path = resolveWorkflowTemplate(step.Template, workflowDir, templatesDir)
+				if step.Path == "" {
+					detail.Unresolved = append(detail.Unresolved, step.Template)
+				} else if !containsString(detail.Templates, step.Path) {
+					detail.Templates = append(detail.Templates, step.Path)
+				}
+			}
+			detail.Steps = append(detail.Steps, step)
+
+			walk(definition.Subtemplates, "", depth+1)
+			for _, matcher := range definition.Matchers {
+				walk(matcher.Subtemplates, strings.Join(stringList(matcher.Name, false), ","), depth+1)
+			}
+		}
+	}
+	walk(workflow.Workflows, "", 0)
+	return detail, nil
+}
+
+// CreateWorkflowTask creates a task that runs a workflow of the template library; the
+// templates the workflow references become the POCs of the task
+func (tm *JSONTaskManager) CreateWorkflowTask(templateID string, targets []string, taskName string) (*TaskConfig, error) {
+	template, err := tm.db.GetTemplateByTemplateID(templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow: %w", err)
+	}
+	if !isWorkflow(template) {
+		return nil, fmt.Errorf("模板 %s 不是工作流", templateID)
+	}
+	detail, err := NewTemplateParser().ParseWorkflow(template.FilePath, workflowTemplatesDir(tm.config))
+	if err != nil {
+		return nil, err
+	}
+	if len(detail.Templates) == 0 {
+		return nil, fmt.Errorf("工作流 %s 引用的模板均不在模板库中", templateID)
+	}
+	if len(detail.Unresolved) > 0 {
+		logWarnf("⚠️ 工作流 %s 中有 %d 个模板不在模板库中，将被跳过: %s\n", templateID, len(detail.Unresolved), strings.Join(detail.Unresolved, ", "))
+	}
+
+	if taskName == "" {
+		taskName = template.Name
+	}
+	return tm.createTask(&TaskConfig{Name: taskName, POCs: detail.Templates, Targets: targets, Workflow: template.FilePath})
+}
+
+// refreshWorkflowTemplates updates the POCs of a workflow task from the current workflow
+func (tm *JSONTaskManager) refreshWorkflowTemplates(task *TaskConfig) {
+	if task.Workflow == "" {
+		return
+	}
+	detail, err := NewTemplateParser().ParseWorkflow(task.Workflow, workflowTemplatesDir(tm.config))
+	if err != nil {
+		logWarnf("⚠️ 解析工作流失败，使用上次的模板列表: %v\n", err)
+		return
+	}
+	task.POCs = detail.Templates
+	task.TotalRequests = len(task.POCs) * len(task.Targets)
+}
+
+// addWorkflow copies the workflow of the task and the templates it references into a
+// temporary directory, pointing the references at the copies (snapshot copies when scanning
+// against the template snapshot), and runs the copy with -w
+func (sns *SimpleNucleiScanner) addWorkflow(args *[]string) error {
+	data, err := os.ReadFile(sns.task.Workflow)
+	if err != nil {
+		return fmt.Errorf("failed to read workflow: %w", err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("failed to parse workflow YAML: %w", err)
+	}
+
+	tempManager, err := NewTempManager()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(tempManager.GetTempBaseDir(), fmt.Sprintf("task_%d_workflow_%d", sns.task.ID, time.Now().Unix()))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create workflow directory: %w", err)
+	}
+
+	workflowDir := filepath.Dir(sns.task.Workflow)
+	var config *models.Config
+	if sns.manager != nil {
+		config = sns.manager.config
+	}
+	templatesDir := workflowTemplatesDir(config)
+	copies := map[string]string{} // 模板文件 -> 副本
+	var copyErr error
+	rewriteWorkflowTemplates(&root, func(ref string) string {
+		path := resolveWorkflowTemplate(ref, workflowDir, templatesDir)
+		if path == "" || !containsString(sns.task.POCs, path) {
+			return ref
+		}
+		if dst, ok := copies[path]; ok {
+			return dst
+		}
+		content, err := os.ReadFile(sns.templateFile(path))
+		if err != nil {
+			copyErr = fmt.Errorf("failed to read workflow template: %w", err)
+			return ref
+		}
+		dst := filepath.Join(dir, "templates", fmt.Sprintf("%d_%s", len(copies), filepath.Base(path)))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err == nil {
+			err = os.WriteFile(dst, content, 0644)
+		}
+		if err != nil {
+			copyErr = fmt.Errorf("failed to copy workflow template: %w", err)
+			return ref
+		}
+		copies[path] = dst
+		return dst
+	})
+	if copyErr != nil {
+		os.RemoveAll(dir)
+		return copyErr
+	}
+
+	content, err := yaml.Marshal(&root)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, filepath.Base(sns.task.Workflow)), content, 0644)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("failed to write workflow: %w", err)
+	}
+	workflowFile := filepath.Join(dir, filepath.Base(sns.task.Workflow))
+
+	// Store temp directory for cleanup
+	sns.tempDir = dir
+	*args = append(*args, "-w", workflowFile)
+	logInfof("🔀 使用工作流: %s (引用 %d 个模板)\n", sns.task.Workflow, len(copies))
+	return nil
+}
+
+// rewriteWorkflowTemplates replaces the value of every template key in a workflow
+func rewriteWorkflowTemplates(node *yaml.Node, rewrite func(ref string) string) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "template" && value.Kind == yaml.ScalarNode {
+				value.Value = rewrite(value.Value)
+				continue
+			}
+			rewriteWorkflowTemplates(value, rewrite)
+		}
+		return
+	}
+	for _, child := range node.Content {
+		rewriteWorkflowTemplates(child, rewrite)
+	}
+}
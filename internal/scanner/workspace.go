@@ -0,0 +1,236 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// staleTempAge is the age after which unrecognized temp files are considered abandoned
+const staleTempAge = 24 * time.Hour
+
+// taskDirPattern extracts the task ID from per-task file and directory names (task_12, task_12.json,
+// task_12_1700000000, task_12_overrides_..., scan_debug_12_...)
+var taskDirPattern = regexp.MustCompile(`^(?:task|scan_debug|scan_error)_(\d+)(?:[_.]|$)`)
+
+// CleanupReport summarizes what a workspace cleanup removed
+type CleanupReport struct {
+	RemovedDirs    int      `json:"removed_dirs"`    // 删除的目录数
+	RemovedFiles   int      `json:"removed_files"`   // 删除的文件数
+	ReclaimedBytes int64    `json:"reclaimed_bytes"` // 释放的空间（字节）
+	Reclaimed      string   `json:"reclaimed"`       // 释放的空间（可读格式）
+	Paths          []string `json:"paths"`           // 被删除的路径
+}
+
+// DiskUsageEntry is the size of one part of the wepoc workspace
+type DiskUsageEntry struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+	Size  string `json:"size"`
+	Files int    `json:"files"`
+}
+
+// DiskUsage is the disk usage of ~/.wepoc broken down by directory
+type DiskUsage struct {
+	Root       string            `json:"root"`
+	TotalBytes int64             `json:"total_bytes"`
+	Total      string            `json:"total"`
+	Entries    []*DiskUsageEntry `json:"entries"` // 按大小降序
+}
+
+// formatBytes renders a byte count with binary units
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// pathSize returns the total size and file count of a file or directory tree
+func pathSize(path string) (int64, int) {
+	var size int64
+	files := 0
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+			files++
+		}
+		return nil
+	})
+	return size, files
+}
+
+// remove deletes a path and records it in the report
+func (r *CleanupReport) remove(path string) {
+	size, files := pathSize(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if err := os.RemoveAll(path); err != nil {
+		logWarnf("⚠️  清理失败: %s, 错误: %v\n", path, err)
+		return
+	}
+	if info.IsDir() {
+		r.RemovedDirs++
+	}
+	r.RemovedFiles += files
+	r.ReclaimedBytes += size
+	r.Paths = append(r.Paths, path)
+}
+
+// finish fills in the readable size
+func (r *CleanupReport) finish() *CleanupReport {
+	r.Reclaimed = formatBytes(r.ReclaimedBytes)
+	return r
+}
+
+// taskIDFromName returns the task ID encoded in a per-task file or directory name
+func taskIDFromName(name string) (int64, bool) {
+	matches := taskDirPattern.FindStringSubmatch(name)
+	if len(matches) < 2 {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(matches[1], 10, 64)
+	return id, err == nil
+}
+
+// CleanupOrphanedTempDirs removes temp directories of tasks that are not running. Directories
+// without a task ID fall back to the 24 hour age rule of CleanupOldTempDirs.
+func (tm *TempManager) CleanupOrphanedTempDirs(isRunning func(taskID int64) bool) (*CleanupReport, error) {
+	report := &CleanupReport{Paths: []string{}}
+
+	entries, err := os.ReadDir(tm.baseDir)
+	if os.IsNotExist(err) {
+		return report.finish(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read temp base directory: %w", err)
+	}
+
+	cutoff := time.Now().Add(-staleTempAge)
+	for _, entry := range entries {
+		path := filepath.Join(tm.baseDir, entry.Name())
+		if taskID, ok := taskIDFromName(entry.Name()); ok {
+			if !isRunning(taskID) {
+				report.remove(path)
+			}
+			continue
+		}
+		if info, err := entry.Info(); err == nil && info.ModTime().Before(cutoff) {
+			report.remove(path)
+		}
+	}
+
+	if report.RemovedDirs > 0 || report.RemovedFiles > 0 {
+		logInfof("🧹 清理临时目录: %d 个目录, 释放 %s\n", report.RemovedDirs, formatBytes(report.ReclaimedBytes))
+	}
+	return report.finish(), nil
+}
+
+// isTaskRunning reports whether a task has a running scanner
+func (tm *JSONTaskManager) isTaskRunning(taskID int64) bool {
+	_, ok := tm.runningScanner(taskID)
+	return ok
+}
+
+// CleanupTempDirs removes temp directories left behind by tasks that are no longer running
+// (e.g. after a crash); called on startup and after every task
+func (tm *JSONTaskManager) CleanupTempDirs() (*CleanupReport, error) {
+	tempManager, err := NewTempManager()
+	if err != nil {
+		return nil, err
+	}
+	return tempManager.CleanupOrphanedTempDirs(tm.isTaskRunning)
+}
+
+// cleanupTaskTempDirs removes the temp directories of a finished task
+func (tm *JSONTaskManager) cleanupTaskTempDirs(taskID int64) {
+	tempManager, err := NewTempManager()
+	if err != nil {
+		return
+	}
+	if _, err := tempManager.CleanupOrphanedTempDirs(func(id int64) bool { return id != taskID }); err != nil {
+		logWarnf("⚠️  清理任务 %d 的临时目录失败: %v\n", taskID, err)
+	}
+}
+
+// CleanWorkspace removes orphaned temp directories, stale target list files and result/log
+// files of deleted tasks, and reports the reclaimed space
+func (tm *JSONTaskManager) CleanWorkspace() (*CleanupReport, error) {
+	report, err := tm.CleanupTempDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	// 扫描中途崩溃时遗留的目标列表文件
+	if len(tm.RunningTaskIDs()) == 0 {
+		targetFiles, _ := filepath.Glob(filepath.Join(os.TempDir(), "wepoc-targets-*.txt"))
+		for _, path := range targetFiles {
+			report.remove(path)
+		}
+	}
+
+	// 已删除任务的结果和日志
+	existing := make(map[int64]bool)
+	tasks, err := tm.GetAllTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	for _, task := range tasks {
+		existing[task.ID] = true
+	}
+	for _, dir := range []string{tm.resultsDir, tm.logsDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if taskID, ok := taskIDFromName(entry.Name()); ok && !existing[taskID] && !tm.isTaskRunning(taskID) {
+				report.remove(filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+
+	logInfof("🧹 清理工作区: 删除 %d 个目录、%d 个文件, 释放 %s\n", report.RemovedDirs, report.RemovedFiles, formatBytes(report.ReclaimedBytes))
+	return report.finish(), nil
+}
+
+// GetDiskUsage returns the disk usage of the wepoc workspace (~/.wepoc) per top-level entry
+func (tm *JSONTaskManager) GetDiskUsage() (*DiskUsage, error) {
+	root := filepath.Dir(tm.tasksDir)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace directory: %w", err)
+	}
+
+	usage := &DiskUsage{Root: root, Entries: []*DiskUsageEntry{}}
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Name())
+		size, files := pathSize(path)
+		usage.Entries = append(usage.Entries, &DiskUsageEntry{
+			Name:  entry.Name(),
+			Path:  path,
+			Bytes: size,
+			Size:  formatBytes(size),
+			Files: files,
+		})
+		usage.TotalBytes += size
+	}
+	sort.Slice(usage.Entries, func(i, j int) bool {
+		return usage.Entries[i].Bytes > usage.Entries[j].Bytes
+	})
+	usage.Total = formatBytes(usage.TotalBytes)
+	return usage, nil
+}
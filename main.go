@@ -4,6 +4,8 @@ import (
 	"embed"
 	"log"
 
+	"wepoc/internal/i18n"
+
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
@@ -29,7 +31,10 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 255, G: 255, B: 255, A: 255},
 		OnStartup:        app.startup,
+		OnBeforeClose:    app.beforeClose,
 		OnShutdown:       app.shutdown,
+		// 带错误码的错误以 {code, message, args} 返回给前端，其余错误仍为字符串
+		ErrorFormatter: i18n.FormatError,
 		Bind: []interface{}{
 			app,
 		},